@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/annotations"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// annotationsPath returns --annotations or where notes from interactive
+// review are kept by default
+func annotationsPath() (string, error) {
+	if annotationsFile != "" {
+		return annotationsFile, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "annotations.json"), nil
+}
+
+// applyAnnotations attaches notes from earlier interactive sessions to the
+// matches of comparisons, so results and reports show them. Missing or
+// unreadable notes leave comparisons unchanged.
+func applyAnnotations(comparisons []models.PairComparison) {
+	path, err := annotationsPath()
+	if err != nil {
+		return
+	}
+	store, err := annotations.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring annotations: %v\n", err)
+		return
+	}
+	if n := store.Apply(comparisons); n > 0 {
+		fmt.Fprintf(os.Stderr, "Annotated: %d match(es) have notes from earlier sessions\n", n)
+	}
+}
+
+// saveAnnotations records the notes written during a session
+func saveAnnotations(notes []models.SetNote) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	path, err := annotationsPath()
+	if err != nil {
+		return err
+	}
+	store, err := annotations.Load(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, note := range notes {
+		store.Set(note.Paths[0], note.Paths[1], note.Text, now)
+	}
+	if err := store.Save(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d note(s) to %s\n", len(notes), path)
+	return nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/trash"
+)
+
+var (
+	applyCmd = &cobra.Command{
+		Use:   "apply PLAN",
+		Short: "Remove the files a saved plan removes, after checking each one again",
+		Long: `apply carries out a plan saved by scan --save, report simulate --format json
+or report suggest --out, which may have been reviewed and edited since, even
+on another machine: drop a decision, or a file from its remove list, to keep
+those files.
+
+Every removal is checked against the files as they are now. A file is passed
+over, with the reason, when it or the copy kept in its place is missing or
+changed in size or modification time since the plan, when the plan keeps it
+in another set, or when it is on an offline volume. With --require-hash (the
+default), both files must also have the same hash: the one in the plan for
+files that have one, otherwise computed now, and with --verify bytes the same
+contents byte by byte. The remaining files are listed
+for confirmation, then removed, or moved to --quarantine or with --trash to the
+trash, or with --symlink replaced with symlinks to the copies kept.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runApply,
+	}
+
+	applyDryRun bool
+	applyYes    bool
+)
+
+func init() {
+	applyCmd.Flags().BoolVarP(&applyDryRun, "dry-run", "n", false, "Check the plan and list what would be removed, without removing anything")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Remove without asking for confirmation")
+	applyCmd.Flags().IntVarP(&numWorkers, "workers", "w", runtime.NumCPU(), "Number of parallel workers for --quarantine")
+	addDecisionFlags(applyCmd)
+	addRemovalFlags(applyCmd)
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	if err := interactive.ValidateLink(symlinkMode); err != nil {
+		return err
+	}
+	if err := finder.ValidateVerify(verifyMode); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	plan, err := report.LoadPlan(args[0])
+	if err != nil {
+		return err
+	}
+	actions, skips := report.PlanActions(plan, requireHash, verifyMode)
+	for _, skip := range skips {
+		fmt.Fprintf(os.Stderr, "Skipping %s (set #%d): %s\n", skip.Path, skip.SetID, skip.Reason)
+	}
+	if symlinkMode != "" {
+		actions = interactive.SafeLinks(actions)
+	}
+	if len(actions) == 0 {
+		fmt.Println("Nothing to remove: no file of the plan can be removed safely")
+		return nil
+	}
+
+	if applyDryRun {
+		verb := "remove"
+		if symlinkMode != "" {
+			verb = "link  "
+		}
+		for _, action := range actions {
+			fmt.Printf("%s  %s  (kept: %s)\n", verb, action.DeleteFile, action.KeepFile)
+		}
+		fmt.Printf("\n%s file(s) would be removed, %s passed over\n", output.FormatCount(len(actions)), output.FormatCount(len(skips)))
+		return nil
+	}
+
+	opts := models.ScanOptions{Quarantine: quarantinePath(), Trash: trashFiles, Symlink: symlinkMode, Explain: explain, NumWorkers: checkWorkers(numWorkers)}
+	if !applyYes {
+		confirmed, err := interactive.ConfirmDeletion(actions, opts)
+		if err != nil || !confirmed {
+			fmt.Fprintln(os.Stderr, "\nPlan not applied.")
+			return nil
+		}
+	}
+
+	results, err := interactive.RemoveFiles(actions, opts)
+	if err != nil {
+		return err
+	}
+	var removed, failed int
+	var freed int64
+	for _, result := range results {
+		if result.Success {
+			removed++
+			freed += result.SizeFreed
+			continue
+		}
+		failed++
+		fmt.Fprintf(os.Stderr, "  ✗ %s\n     Error: %v\n", result.Path, result.Error)
+	}
+	if opts.Symlink != "" {
+		fmt.Printf("Replaced %s file(s) with symlinks, freeing %s\n", output.FormatCount(removed), output.FormatSize(freed))
+	} else if opts.Trash {
+		fmt.Printf("Moved %s file(s), %s, to %s\n", output.FormatCount(removed), output.FormatSize(freed), trash.Name)
+	} else if opts.Quarantine != "" {
+		fmt.Printf("Moved %s file(s), %s, to %s\n", output.FormatCount(removed), output.FormatSize(freed), opts.Quarantine)
+		fmt.Printf("Undo with: dup-finder restore %s\n", opts.Quarantine)
+	} else {
+		fmt.Printf("Removed %s file(s), freeing %s\n", output.FormatCount(removed), output.FormatSize(freed))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be removed", failed)
+	}
+	return nil
+}
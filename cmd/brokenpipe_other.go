@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package cmd
+
+// ignoreBrokenPipe does nothing on this platform
+func ignoreBrokenPipe() {}
+
+// isBrokenPipe cannot tell a closed pipe apart on this platform
+func isBrokenPipe(err error) bool {
+	return false
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ignoreBrokenPipe makes writes to stdout fail with EPIPE once its reader,
+// such as head or less, has exited, instead of SIGPIPE killing the process
+// halfway through saving files. Signals are caught rather than ignored, so
+// programs started later get the default behavior.
+func ignoreBrokenPipe() {
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+}
+
+// isBrokenPipe reports whether err comes from writing to a pipe whose
+// reader has exited
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
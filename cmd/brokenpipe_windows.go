@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNoData is returned by writes to a pipe that is being closed
+const errorNoData = syscall.Errno(232)
+
+// ignoreBrokenPipe does nothing: writes to a pipe whose reader has exited
+// already fail with an error on Windows
+func ignoreBrokenPipe() {}
+
+// isBrokenPipe reports whether err comes from writing to a pipe whose
+// reader has exited
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.ERROR_BROKEN_PIPE) || errors.Is(err, errorNoData)
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/catalog"
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+var (
+	catalogCmd = &cobra.Command{
+		Use:   "catalog DIR",
+		Short: "Record the paths, sizes, mtimes and hashes of a volume's files",
+		Long: `catalog hashes every file under DIR, such as the root of a removable drive,
+and writes a catalog of them. Once the drive is detached, give the catalog as
+catalog:FILE in place of a directory to compare against its contents, or to
+exists to ask whether a file is already on it. Hashes are cached in the hash
+index between runs.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runCatalog,
+	}
+
+	catalogOut string
+)
+
+func init() {
+	catalogCmd.Flags().StringVar(&catalogOut, "out", "", "Write the catalog to this file instead of stdout, replacing it only once it is complete")
+	catalogCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	catalogCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	catalogCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not show files found, bytes hashed, throughput and ETA on stderr while running (shown on a terminal only)")
+	rootCmd.AddCommand(catalogCmd)
+}
+
+func runCatalog(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	root := scanner.NormalizeRoot(args[0])
+	files, unhashed, err := hashTree(cmd, root)
+	if err != nil {
+		return err
+	}
+
+	if catalogOut == "" {
+		err := catalog.Write(os.Stdout, files)
+		if isBrokenPipe(err) {
+			return brokenPipeError(cmd)
+		}
+		if err != nil {
+			return err
+		}
+	} else if err := writeListing(catalogOut, func(w io.Writer) error { return catalog.Write(w, files) }); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Cataloged %d file(s) (%s) under %s\n", len(files)-unhashed, output.FormatSize(catalogSize(files)), root)
+	return nil
+}
+
+// hashTree scans every file under root and hashes it, taking the hashes of
+// files unchanged since they were last hashed from the hash index. Files
+// that cannot be read are left without a hash, with a warning, and counted
+// in unhashed.
+func hashTree(cmd *cobra.Command, root string) (files []models.FileInfo, unhashed int, err error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, 0, fmt.Errorf("cannot access %s: %w", root, err)
+	}
+	idx, err := openIndex()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	startProgress()
+	defer stopProgress()
+	opts := models.ScanOptions{
+		Directories: []string{root},
+		Recursive:   true,
+		MaxDepth:    -1,
+		NumWorkers:  runtime.NumCPU(),
+	}
+	allFiles, err := scanner.NewScanner(opts).ScanAll(cmd.Context())
+	if err != nil {
+		return nil, 0, fmt.Errorf("error scanning %s: %w", root, err)
+	}
+	files = allFiles[root]
+
+	// Files unchanged since they were last hashed are not read again
+	var toHash []*models.FileInfo
+	for i := range files {
+		if idx != nil {
+			if hash, ok := idx.Lookup(files[i]); ok {
+				files[i].Hash = hash
+				continue
+			}
+		}
+		toHash = append(toHash, &files[i])
+	}
+	hashErr := finder.ComputeHashesParallel(cmd.Context(), toHash, opts.NumWorkers)
+	if idx != nil {
+		for _, file := range toHash {
+			idx.Store(*file)
+		}
+		if err := idx.Save(); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+	if cmd.Context().Err() != nil {
+		return nil, 0, hashErr
+	}
+	stopProgress()
+
+	for _, file := range files {
+		if file.Hash == "" {
+			unhashed++
+		}
+	}
+	if unhashed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d file(s) could not be read and are left out of the listing\n", unhashed)
+	}
+	return files, unhashed, nil
+}
+
+// writeListing writes a catalog or manifest to path with write, replacing
+// the file only once it is complete
+func writeListing(path string, write func(io.Writer) error) error {
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Commit()
+}
+
+// catalogSize returns the total size of the files with a hash, which are
+// the ones written to a catalog
+func catalogSize(files []models.FileInfo) int64 {
+	var total int64
+	for _, file := range files {
+		if file.Hash != "" {
+			total += file.Size
+		}
+	}
+	return total
+}
+
+// splitCatalogs separates the catalog:FILE arguments from directory
+// arguments and loads the catalogs they name. A catalog that cannot be read
+// is an error, as its volume cannot be scanned in its place.
+func splitCatalogs(args []string) ([]string, []*catalog.Catalog, error) {
+	var dirs []string
+	var catalogs []*catalog.Catalog
+	for _, arg := range args {
+		file, ok := catalog.Parse(arg)
+		if !ok {
+			dirs = append(dirs, arg)
+			continue
+		}
+		c, err := catalog.Load(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Loaded %s catalog %s: %d file(s) on an offline volume\n", c.Format, file, len(c.Files))
+		catalogs = append(catalogs, c)
+	}
+	return dirs, catalogs, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+var (
+	cmpCmd = &cobra.Command{
+		Use:   "cmp FILE1 FILE2 [FILE...]",
+		Short: "Check which of the given files are identical",
+		Long: `cmp compares the contents of the given files and lists the sets of
+identical files, then the files identical to none of the others. Files of
+the same size are hashed and then compared byte by byte.
+
+It exits 0 if all the files are identical, 1 if any differ, and 2 on errors.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runCmp,
+	}
+
+	cmpWorkers int
+)
+
+func init() {
+	cmpCmd.Flags().IntVarP(&cmpWorkers, "workers", "w", 0, "Files hashed in parallel (default: number of CPUs)")
+	rootCmd.AddCommand(cmpCmd)
+}
+
+func runCmp(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	files := make([]models.FileInfo, 0, len(args))
+	for _, path := range args {
+		info, err := os.Stat(path)
+		if err != nil {
+			return &ExitError{Code: 2, Err: fmt.Errorf("cannot access %s: %w", path, err)}
+		}
+		if !info.Mode().IsRegular() {
+			return &ExitError{Code: 2, Err: fmt.Errorf("%s is not a regular file", path)}
+		}
+		files = append(files, models.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	}
+
+	sets, unique, err := finder.GroupIdentical(cmd.Context(), files, cmpWorkers)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	for _, set := range sets {
+		fmt.Printf("Identical (%s file(s), %s each):\n", output.FormatCount(len(set.Files)), output.FormatSize(set.Files[0].Size))
+		for _, file := range set.Files {
+			fmt.Printf("  %s\n", file.Path)
+		}
+	}
+	if len(unique) > 0 {
+		fmt.Println("Identical to no other file:")
+		for _, file := range unique {
+			fmt.Printf("  %s (%s)\n", file.Path, output.FormatSize(file.Size))
+		}
+	}
+
+	if len(sets) == 1 && len(unique) == 0 {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	return &ExitError{Code: 1}
+}
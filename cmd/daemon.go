@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/daemon"
+)
+
+var (
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Run scans on cron schedules, keeping their reports and sending notifications",
+		Long: `daemon runs the scans listed in its configuration, each on its own cron
+schedule, until stopped with Ctrl+C or SIGTERM, such as to keep a NAS free of
+duplicates without running dup-finder by hand. Each run saves its results in
+JSON and its output as a log, named by its run ID, under a directory of the
+job in the reports directory, and can run a command or call a webhook with
+what it found.
+
+Scans run one at a time, as dup-finder with the job's args and
+--format json --output REPORT --run-id ID. A scan running when the daemon
+stops is interrupted, so it saves its partial results and a session to
+continue with dup-finder resume.
+
+The configuration is JSON, by default daemon.json in the dup-finder
+directory of the user config directory:
+
+  {
+    "version": 1,
+    "reports": "/var/lib/dup-finder/reports",
+    "keep_reports": 30,
+    "notify": {"on": "duplicates", "webhook": "https://hooks.example.com/..."},
+    "jobs": [
+      {"name": "nas", "schedule": "0 3 * * *", "args": ["-H", "/mnt/nas/photos", "/mnt/nas/backup"]}
+    ]
+  }`,
+		Args: cobra.NoArgs,
+		RunE: runDaemon,
+	}
+
+	daemonConfig string
+	daemonCheck  bool
+	daemonOnce   bool
+)
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonConfig, "config", "", "Daemon configuration file (default: user config directory)")
+	daemonCmd.Flags().BoolVar(&daemonCheck, "check", false, "Check the configuration and print when each job runs next, without running any")
+	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "Run every job once now, then exit")
+	daemonCmd.MarkFlagsMutuallyExclusive("check", "once")
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	path := daemonConfig
+	if path == "" {
+		var err error
+		if path, err = daemon.DefaultConfigPath(); err != nil {
+			return err
+		}
+	}
+	cfg, err := daemon.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if daemonCheck {
+		now := time.Now()
+		fmt.Printf("Configuration %s is valid; reports go to %s\n", path, cfg.Reports)
+		for i := range cfg.Jobs {
+			job := &cfg.Jobs[i]
+			fmt.Printf("  %s (%s): next run %s\n", job.Name, job.Schedule, job.Next(now).Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	program, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the dup-finder executable: %w", err)
+	}
+	runner := &daemon.Runner{Config: cfg, Program: program, Log: os.Stderr}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if daemonOnce {
+		failed := 0
+		for i := range cfg.Jobs {
+			if runner.RunJob(ctx, &cfg.Jobs[i]).Status == daemon.StatusFailed {
+				failed++
+			}
+			if ctx.Err() != nil {
+				return interruptedError(cmd)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d job(s) failed", failed)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %d job(s) from %s; Ctrl+C to stop\n", len(cfg.Jobs), path)
+	return runner.Run(ctx)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/devgen"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+var devgenOpts = devgen.DefaultOptions()
+
+var devgenCmd = &cobra.Command{
+	Use:   "devgen DIR",
+	Short: "Generate synthetic trees with known duplicates for benchmarking",
+	Long: `devgen fills DIR with synthetic trees, root0, root1, ..., for trying
+dup-finder at scale and measuring performance before a release.
+
+Every root holds --files files spread over --depth levels of directories.
+In every root but the first, a --dup-ratio fraction of the files are copies
+of a same-named file in root0 and a --changed-ratio fraction share a name
+and size with one but not its contents. The same options, including
+--seed, always produce the same trees, and the counts printed are what a
+full comparison should find.
+
+  dup-finder devgen --files 2000000 /tmp/bench
+  dup-finder --compare-hash /tmp/bench/root0 /tmp/bench/root1`,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runDevgen,
+}
+
+func init() {
+	flags := devgenCmd.Flags()
+	flags.IntVar(&devgenOpts.Roots, "roots", devgenOpts.Roots, "Number of trees to generate")
+	flags.IntVar(&devgenOpts.Files, "files", devgenOpts.Files, "Files per tree")
+	flags.Float64Var(&devgenOpts.DupRatio, "dup-ratio", devgenOpts.DupRatio, "Fraction of files that are copies of a file in root0")
+	flags.Float64Var(&devgenOpts.ChangedRatio, "changed-ratio", devgenOpts.ChangedRatio, "Fraction of files named like a file in root0 with other contents")
+	flags.IntVar(&devgenOpts.Depth, "depth", devgenOpts.Depth, "Directory levels in each tree")
+	flags.IntVar(&devgenOpts.Fanout, "fanout", devgenOpts.Fanout, "Subdirectories per directory")
+	flags.IntVar(&devgenOpts.MinSize, "min-size", devgenOpts.MinSize, "Smallest file in bytes")
+	flags.IntVar(&devgenOpts.MaxSize, "max-size", devgenOpts.MaxSize, "Largest file in bytes")
+	flags.BoolVar(&devgenOpts.Unicode, "unicode", false, "Use non-ASCII file and directory names")
+	flags.Uint64Var(&devgenOpts.Seed, "seed", devgenOpts.Seed, "Seed of the generated layout and contents")
+	flags.IntVarP(&devgenOpts.Workers, "workers", "w", 0, "Files written in parallel (default: number of CPUs)")
+	rootCmd.AddCommand(devgenCmd)
+}
+
+func runDevgen(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	dir := args[0]
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+
+	start := time.Now()
+	stats, err := devgen.Generate(dir, devgenOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated %s file(s), %s, in %d tree(s) in %s\n",
+		output.FormatCount(stats.Files), output.FormatSize(stats.Bytes), len(stats.Roots), time.Since(start).Round(time.Millisecond))
+	fmt.Printf("Duplicates of root0: %s, same name with other contents: %s\n",
+		output.FormatCount(stats.Duplicates), output.FormatCount(stats.Changed))
+	for _, root := range stats.Roots {
+		fmt.Printf("  %s\n", root)
+	}
+	return nil
+}
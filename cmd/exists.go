@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+var (
+	existsCmd = &cobra.Command{
+		Use:   "exists FILE DIR...",
+		Short: "Check whether a file's content already exists under the given directories",
+		Long: `exists reports whether FILE's content is already present anywhere under DIR.
+It prints the path of the first identical file and exits 0, or exits 1 if none is found.
+Other errors exit with status 2. Hashes are cached in the hash index between runs.
+A DIR given as catalog:FILE is searched by the hashes its catalog records, so
+detached drives cataloged with dup-finder catalog are searched too.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runExists,
+	}
+
+	indexPath string
+	noIndex   bool
+)
+
+func init() {
+	existsCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	existsCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	rootCmd.AddCommand(existsCmd)
+}
+
+func runExists(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	target := args[0]
+	info, err := os.Stat(target)
+	if err != nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("cannot access %s: %w", target, err)}
+	}
+	if !info.Mode().IsRegular() {
+		return &ExitError{Code: 2, Err: fmt.Errorf("%s is not a regular file", target)}
+	}
+
+	idx, err := openIndex()
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	dirs, catalogs, err := splitCatalogs(args[1:])
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	// Only files of the target's size can match, so let the scanner drop smaller ones
+	opts := models.ScanOptions{
+		Directories: normalizeRoots(dirs),
+		Recursive:   true,
+		MinSize:     info.Size(),
+		MaxDepth:    -1,
+		NumWorkers:  runtime.NumCPU(),
+	}
+	allFiles, err := scanner.NewScanner(opts).ScanAll(cmd.Context())
+	if err != nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("error scanning directories: %w", err)}
+	}
+
+	var candidates []models.FileInfo
+	for _, dir := range opts.Directories {
+		candidates = append(candidates, allFiles[dir]...)
+	}
+	// Files of a catalog can only be compared by the hash it records
+	for _, c := range catalogs {
+		for _, file := range c.Files {
+			if file.Hash != "" {
+				candidates = append(candidates, file)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	var cache finder.HashCache
+	if idx != nil {
+		cache = idx
+	}
+	match, err := finder.FindContent(models.FileInfo{
+		Path:    target,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, candidates, cache)
+
+	if idx != nil {
+		if saveErr := idx.Save(); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", saveErr)
+		}
+	}
+
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+	if match == nil {
+		cmd.SilenceErrors = true
+		return &ExitError{Code: 1}
+	}
+
+	fmt.Println(match.Path)
+	return nil
+}
+
+// openIndex loads the hash index selected by --index, or returns nil with --no-index
+func openIndex() (*index.Index, error) {
+	if noIndex {
+		return nil, nil
+	}
+
+	path := indexPath
+	if path == "" {
+		var err error
+		path, err = index.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return index.Load(path)
+}
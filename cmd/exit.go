@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExitNoDuplicates is the exit code of a run that completed without finding
+// any duplicates, so scripts can tell it apart from success (0) and errors (1)
+const ExitNoDuplicates = 3
+
+// ExitPartial is the exit code of a run that completed after some of its
+// roots, passes or workers failed, with results for the rest
+const ExitPartial = 4
+
+// ExitInterrupted is the exit code of a run stopped by Ctrl+C or SIGTERM
+// after printing its partial results, as shells report for SIGINT
+const ExitInterrupted = 130
+
+// ExitBrokenPipe is the exit code of a run whose output reader, such as
+// head, exited before reading everything, as shells report for SIGPIPE
+const ExitBrokenPipe = 141
+
+// ExitError carries a specific process exit code for a failed command
+type ExitError struct {
+	Code int
+	Err  error // Underlying error (nil for a silent status-only exit)
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/fingerprint"
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+var (
+	fingerprintCmd = &cobra.Command{
+		Use:   "fingerprint {DIR | --compare A B}",
+		Short: "Print a digest of a directory tree, or compare two trees by digest",
+		Long: `fingerprint prints a digest of DIR computed from the names and contents
+of everything in it, like a Merkle tree: two trees have the same digest
+exactly when they hold the same files, links and directories under the same
+names. With --output, the digest of every directory is saved too.
+
+--compare A B checks whether two trees are identical. Each of A and B is a
+fingerprint saved with --output or a directory, fingerprinted on the spot, so
+a backup can be checked against a fingerprint taken when it was written
+without reading the original again. Where the trees differ, the directories
+whose files changed and those present on one side only are listed. It exits
+0 if the trees are identical, 1 if they differ, and 2 on errors.
+
+Hashes are cached in the hash index between runs, so fingerprinting a tree
+again reads only files whose size or modification time changed.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if fingerprintCompare {
+				return cobra.ExactArgs(2)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: runFingerprint,
+	}
+
+	fingerprintCompare bool
+	fingerprintOutput  string
+)
+
+func init() {
+	fingerprintCmd.Flags().BoolVar(&fingerprintCompare, "compare", false, "Compare two fingerprints or directories")
+	fingerprintCmd.Flags().StringVarP(&fingerprintOutput, "output", "o", "", "Save the fingerprint, with the digest of every directory, to this file")
+	fingerprintCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	fingerprintCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	rootCmd.AddCommand(fingerprintCmd)
+}
+
+func runFingerprint(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	idx, err := openIndex()
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+	if idx != nil {
+		defer func() {
+			if err := idx.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}()
+	}
+
+	if fingerprintCompare {
+		return compareFingerprints(cmd, args[0], args[1], idx)
+	}
+
+	fp, err := computeFingerprint(cmd, args[0], idx)
+	if err != nil {
+		return err
+	}
+	if fingerprintOutput != "" {
+		if err := fingerprint.Save(fp, fingerprintOutput); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Saved the fingerprint of %s file(s), %s, to %s\n",
+			output.FormatCount(fp.Files), output.FormatSize(fp.Bytes), fingerprintOutput)
+	}
+	fmt.Printf("%s  %s\n", fp.Digest, fp.Root)
+	return nil
+}
+
+// computeFingerprint fingerprints the tree at dir
+func computeFingerprint(cmd *cobra.Command, dir string, idx *index.Index) (*fingerprint.Fingerprint, error) {
+	var cache finder.HashCache
+	if idx != nil {
+		cache = idx
+	}
+	fp, err := fingerprint.Compute(cmd.Context(), dir, cache, runtime.NumCPU())
+	if err != nil {
+		return nil, fmt.Errorf("cannot fingerprint %s: %w", dir, err)
+	}
+	return fp, nil
+}
+
+// compareFingerprints compares the trees a and b, each a saved fingerprint
+// or a directory
+func compareFingerprints(cmd *cobra.Command, a, b string, idx *index.Index) error {
+	load := func(path string) (*fingerprint.Fingerprint, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return computeFingerprint(cmd, path, idx)
+		}
+		return fingerprint.Load(path)
+	}
+	fp1, err := load(a)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+	fp2, err := load(b)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	diffs := fingerprint.Compare(fp1, fp2)
+	if len(diffs) == 0 {
+		fmt.Printf("Identical: %s (%s file(s), %s)\n", fp1.Digest, output.FormatCount(fp1.Files), output.FormatSize(fp1.Bytes))
+		return nil
+	}
+
+	fmt.Printf("Trees differ: %s and %s\n", fp1.Root, fp2.Root)
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case fingerprint.OnlyIn1:
+			fmt.Printf("  only in %s: %s\n", fp1.Root, diff.Dir)
+		case fingerprint.OnlyIn2:
+			fmt.Printf("  only in %s: %s\n", fp2.Root, diff.Dir)
+		default:
+			fmt.Printf("  changed: %s\n", diff.Dir)
+		}
+	}
+	cmd.SilenceErrors = true
+	return &ExitError{Code: 1}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/importer"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+var (
+	importCmd = &cobra.Command{
+		Use:   "import SRC... --into DEST",
+		Short: "Copy files into DEST, skipping content that is already there",
+		Long: `import copies files from SRC into DEST unless identical content already exists
+anywhere under DEST. Identical files within SRC are only copied once.
+Hashes are cached in the hash index between runs.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runImport,
+	}
+
+	importInto   string
+	importByDate bool
+	importDryRun bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importInto, "into", "", "Destination directory (required)")
+	importCmd.Flags().BoolVar(&importByDate, "by-date", false, "Organize copies into YYYY/MM folders by EXIF capture date or modification time")
+	importCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Show what would be copied without copying")
+	importCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	importCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	importCmd.MarkFlagRequired("into")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	sources := normalizeRoots(args)
+	for _, src := range sources {
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("cannot access source %s: %w", src, err)
+		}
+	}
+
+	idx, err := openIndex()
+	if err != nil {
+		return err
+	}
+	var cache finder.HashCache
+	if idx != nil {
+		cache = idx
+	}
+
+	// Scan sources
+	srcOpts := models.ScanOptions{
+		Directories: sources,
+		Recursive:   true,
+		MaxDepth:    -1,
+		NumWorkers:  runtime.NumCPU(),
+	}
+	srcFiles, err := scanner.NewScanner(srcOpts).ScanAll(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("error scanning sources: %w", err)
+	}
+	var files []models.FileInfo
+	for _, src := range sources {
+		files = append(files, srcFiles[src]...)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	// Scan the destination, which may not exist yet
+	var existing []models.FileInfo
+	if _, err := os.Stat(importInto); err == nil {
+		destOpts := srcOpts
+		destOpts.Directories = []string{importInto}
+		destFiles, err := scanner.NewScanner(destOpts).ScanAll(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("error scanning destination: %w", err)
+		}
+		existing = destFiles[importInto]
+	}
+
+	im := importer.NewImporter(importer.Options{
+		Into:   importInto,
+		ByDate: importByDate,
+		DryRun: importDryRun,
+	}, existing, cache)
+	summary := im.Import(files)
+
+	if idx != nil {
+		if err := idx.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	verb := "Copied"
+	if importDryRun {
+		verb = "Would copy"
+	}
+	for _, r := range summary.Copied {
+		fmt.Printf("%s: %s -> %s\n", verb, r.Source, r.Destination)
+	}
+	for _, r := range summary.Skipped {
+		fmt.Printf("Skipped: %s (already present as %s)\n", r.Source, r.Existing)
+	}
+	for _, r := range summary.Failed {
+		fmt.Fprintf(os.Stderr, "Failed: %s: %v\n", r.Source, r.Error)
+	}
+
+	fmt.Printf("\n%s %d file(s) (%s), skipped %d already present",
+		verb, len(summary.Copied), output.FormatSize(summary.BytesCopied), len(summary.Skipped))
+	if len(summary.Failed) > 0 {
+		fmt.Printf(", %d failed", len(summary.Failed))
+	}
+	fmt.Println()
+
+	if len(summary.Failed) > 0 {
+		return fmt.Errorf("%d file(s) could not be imported", len(summary.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/index"
+)
+
+var (
+	indexCmd = &cobra.Command{
+		Use:   "index",
+		Short: "Export or import the hash index",
+		Long: `index exchanges the hash index used by exists and import with other tools.
+Only xxh64 hashes can be imported, since the index caches xxHash values:
+sha256sum, hashdeep and rdfind lists are refused, as is Parquet. TSV exports
+leave out paths with tabs or line breaks. sha256sum exports read every
+indexed file again, leaving out files changed since they were indexed.`,
+	}
+
+	indexExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Write the hash index to stdout",
+		Args:  cobra.NoArgs,
+		RunE:  runIndexExport,
+	}
+
+	indexImportCmd = &cobra.Command{
+		Use:   "import FILE...",
+		Short: "Merge hash lists into the hash index (use - for stdin)",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runIndexImport,
+	}
+
+	indexFormat string
+)
+
+func init() {
+	formatHelp := fmt.Sprintf("Format (%s)", strings.Join(index.Formats, ", "))
+	indexCmd.PersistentFlags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	indexExportCmd.Flags().StringVarP(&indexFormat, "format", "f", index.FormatTSV, formatHelp)
+	indexImportCmd.Flags().StringVarP(&indexFormat, "format", "f", index.FormatTSV, formatHelp)
+
+	indexCmd.AddCommand(indexExportCmd, indexImportCmd)
+	rootCmd.AddCommand(indexCmd)
+}
+
+func runIndexExport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	idx, err := openIndex()
+	if err != nil {
+		return err
+	}
+	err = index.Export(os.Stdout, idx, indexFormat)
+	if isBrokenPipe(err) {
+		return brokenPipeError(cmd)
+	}
+	return err
+}
+
+func runIndexImport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	idx, err := openIndex()
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, name := range args {
+		in := os.Stdin
+		if name != "-" {
+			in, err = os.Open(name)
+			if err != nil {
+				return fmt.Errorf("cannot open %s: %w", name, err)
+			}
+		}
+
+		added, err := index.Import(in, idx, indexFormat)
+		if in != os.Stdin {
+			in.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("error importing %s: %w", name, err)
+		}
+		total += added
+	}
+
+	if err := idx.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d entries (%d in index)\n", total, idx.Len())
+	return nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// interruptContext returns a context that is cancelled by the first Ctrl+C
+// or SIGTERM, so scanning and hashing stop and the results found so far
+// can still be printed and saved. After that first signal, or once release
+// is called, signals get their default behavior back: a second Ctrl+C
+// exits at once. release does not cancel the context.
+func interruptContext(parent context.Context) (ctx context.Context, release func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	released := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			fmt.Fprintln(os.Stderr, "\nInterrupted: finishing with partial results (Ctrl+C again to quit now)")
+			cancel()
+		case <-released:
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+
+	return ctx, sync.OnceFunc(func() {
+		signal.Stop(signals)
+		close(released)
+	})
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/catalog"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+var (
+	manifestCmd = &cobra.Command{
+		Use:   "manifest",
+		Short: "Record a baseline of files to find duplicates of later",
+		Long: `manifest records the files of a baseline, such as a NAS, so other directories
+can be checked for files it already holds without scanning it again.`,
+	}
+
+	manifestCreateCmd = &cobra.Command{
+		Use:   "create DIR",
+		Short: "Write a manifest of the relative paths, sizes and hashes of DIR's files",
+		Long: `create hashes every file under DIR and writes a JSON manifest of their paths
+relative to DIR, sizes, modification times and hashes. Compare directories
+against it with --against FILE to find the files already in DIR. Hashes are
+cached in the hash index between runs.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runManifestCreate,
+	}
+
+	manifestOut string
+)
+
+func init() {
+	manifestCreateCmd.Flags().StringVar(&manifestOut, "out", "", "Write the manifest to this file instead of stdout, replacing it only once it is complete")
+	manifestCreateCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	manifestCreateCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	manifestCreateCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not show files found, bytes hashed, throughput and ETA on stderr while running (shown on a terminal only)")
+
+	manifestCmd.AddCommand(manifestCreateCmd)
+	rootCmd.AddCommand(manifestCmd)
+}
+
+func runManifestCreate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	root := scanner.NormalizeRoot(args[0])
+	files, unhashed, err := hashTree(cmd, root)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error { return catalog.WriteManifest(w, root, files) }
+	if manifestOut == "" {
+		err := write(os.Stdout)
+		if isBrokenPipe(err) {
+			return brokenPipeError(cmd)
+		}
+		if err != nil {
+			return err
+		}
+	} else if err := writeListing(manifestOut, write); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Recorded %d file(s) (%s) under %s\n", len(files)-unhashed, output.FormatSize(catalogSize(files)), root)
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// preferencesPath returns where --remember keeps interactive preferences
+func preferencesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "preferences.json"), nil
+}
+
+// loadPreferences reads the preferences saved by --remember; none are
+// saved yet when the file does not exist
+func loadPreferences() (models.Preferences, error) {
+	var prefs models.Preferences
+	path, err := preferencesPath()
+	if err != nil {
+		return prefs, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return prefs, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &prefs)
+	}
+	if err != nil {
+		return prefs, fmt.Errorf("preferences %s: %w", path, err)
+	}
+	return prefs, nil
+}
+
+// applyPreferences starts an interactive session with the habits saved by
+// --remember: hashing each set automatically, and suggesting to keep copies
+// under the preferred directory unless --priority is given. Missing or
+// unreadable preferences leave opts unchanged.
+func applyPreferences(opts *models.ScanOptions) {
+	prefs, err := loadPreferences()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %v\n", err)
+		return
+	}
+
+	if prefs.AutoHash && !opts.AutoHash {
+		opts.AutoHash = true
+		fmt.Fprintln(os.Stderr, "Remembered preference: verifying each set by hash")
+	}
+	if prefs.PreferredDir != "" && len(opts.Priorities) == 0 {
+		for _, dir := range opts.Directories {
+			if dir == prefs.PreferredDir {
+				opts.Priorities = map[string]int{dir: 0}
+				fmt.Fprintf(os.Stderr, "Remembered preference: suggesting to keep copies under %s\n", dir)
+				break
+			}
+		}
+	}
+}
+
+// savePreferences writes the habits learned in a session for the next one.
+// A session too short to show a habit keeps the one saved before, so only
+// a directory kept in most of enough decisions replaces the preferred one.
+func savePreferences(learned models.Preferences) error {
+	path, err := preferencesPath()
+	if err != nil {
+		return err
+	}
+	prefs, err := loadPreferences()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: replacing unreadable %v\n", err)
+	}
+	prefs.AutoHash = prefs.AutoHash || learned.AutoHash
+	if learned.PreferredDir != "" {
+		prefs.PreferredDir = learned.PreferredDir
+	}
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot save preferences: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Saved preferences to %s\n", path)
+	return nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestSavePreferencesKeepsHabitsWithoutNewEvidence(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+	t.Setenv("AppData", tmp)
+
+	require.NoError(t, savePreferences(models.Preferences{PreferredDir: "/photos", AutoHash: true}))
+	// A short session learned nothing
+	require.NoError(t, savePreferences(models.Preferences{}))
+	prefs, err := loadPreferences()
+	require.NoError(t, err)
+	assert.Equal(t, models.Preferences{PreferredDir: "/photos", AutoHash: true}, prefs)
+
+	require.NoError(t, savePreferences(models.Preferences{PreferredDir: "/backup"}))
+	prefs, err = loadPreferences()
+	require.NoError(t, err)
+	assert.Equal(t, models.Preferences{PreferredDir: "/backup", AutoHash: true}, prefs)
+}
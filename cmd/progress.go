@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/progress"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+// progressInterval is how often the progress line is redrawn
+const progressInterval = 250 * time.Millisecond
+
+var (
+	// progressLine is the live status line shown while scanning and
+	// hashing, nil when it is not shown
+	progressLine *progress.Display
+
+	// stderr receives the messages printed while the progress line may be
+	// on screen, so they do not run into it
+	stderr io.Writer = os.Stderr
+)
+
+// startProgress shows the files found and bytes hashed on stderr until
+// stopProgress, unless --no-progress is set or stderr is not a terminal
+func startProgress() {
+	if noProgress || !isTerminal(os.Stderr) {
+		return
+	}
+	progressLine = progress.Start(os.Stderr, terminalWidth(os.Stderr), progressInterval)
+	stderr = progressLine
+}
+
+// stopProgress clears the progress line for good, before results are
+// printed on the same terminal
+func stopProgress() {
+	progressLine.Stop()
+	progressLine = nil
+	stderr = os.Stderr
+}
+
+// pauseProgress hides the progress line while handler asks whether to go
+// on past a soft limit
+func pauseProgress(handler scanner.SoftLimitFunc) scanner.SoftLimitFunc {
+	return func(files int, elapsed time.Duration) scanner.SoftLimitAction {
+		progressLine.Pause()
+		defer progressLine.Resume()
+		return handler(files, elapsed)
+	}
+}
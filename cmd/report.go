@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/diskspace"
+	"github.com/Sho2010/dup-finder/internal/media"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/report"
+)
+
+var (
+	reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Work with results saved by --format json or by other duplicate finders",
+	}
+
+	reportDiffCmd = &cobra.Command{
+		Use:   "diff OLD.json NEW.json",
+		Short: "Show duplicates introduced and resolved between two saved results",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runReportDiff,
+	}
+
+	reportSimulateCmd = &cobra.Command{
+		Use:   "simulate RESULTS.json",
+		Short: "Show which files a keep policy would keep and remove, without touching anything",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runReportSimulate,
+	}
+
+	reportAnonymizeCmd = &cobra.Command{
+		Use:   "anonymize RESULTS.json",
+		Short: "Replace file and directory names with stable pseudonyms for sharing",
+		Long: `anonymize writes a copy of saved results to stdout in which every file and
+directory name is replaced by a pseudonym such as dir3/file12.jpg. The same
+name always gets the same pseudonym, and the tree structure, sizes, times,
+hashes and extensions are kept.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReportAnonymize,
+	}
+
+	reportSuggestCmd = &cobra.Command{
+		Use:   "suggest RESULTS.json",
+		Short: "Rank duplicate sets by confidence, space freed and age, and plan the best ones",
+		Long: `suggest applies a keep policy like simulate, then ranks the sets with files to
+remove by a score from 0 to 100 combining confidence (whether the set was
+hash-verified), the space removing them frees and how long the copies to remove
+have gone unchanged. The --top best are listed, and --out saves them as a plan
+file to review before acting on any of them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReportSuggest,
+	}
+
+	reportSummaryCmd = &cobra.Command{
+		Use:   "summary RESULTS.json",
+		Short: "Print the totals of saved results: duplicates, sets and reclaimable space",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runReportSummary,
+	}
+
+	simulateKeep       string
+	simulatePreferDirs []string
+	simulateGroupBy    string
+	simulatePriorities []string
+	simulateProtect    []string
+	simulateFormat     string
+	simulateMinAge     string
+	summaryFormat      string
+	suggestTop         int
+	suggestOut         string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{reportSimulateCmd, reportSuggestCmd} {
+		cmd.Flags().StringVar(&simulateKeep, "keep", policy.KeepNewest,
+			fmt.Sprintf("Which copy to keep (%s)", strings.Join(policy.KeepStrategies, ", ")))
+		cmd.Flags().StringArrayVar(&simulatePreferDirs, "prefer-dir", []string{},
+			"Always keep copies under this directory first (repeatable, in priority order)")
+		cmd.Flags().StringArrayVar(&simulatePriorities, "priority", []string{},
+			"Keep priority for a directory, as DIR=N; lower numbers are kept first (repeatable)")
+		cmd.Flags().StringArrayVar(&simulateProtect, "protect", []string{},
+			"Never remove files under this directory (repeatable)")
+		cmd.Flags().StringVar(&simulateMinAge, "min-age", "",
+			"Never remove files modified more recently than this, e.g. 90d or 36h")
+	}
+	reportSimulateCmd.Flags().StringVar(&simulateFormat, "format", output.FormatNameText,
+		"Output format (text, or json for a plan file listing every decision and its reasons)")
+	reportSimulateCmd.Flags().StringVar(&simulateGroupBy, "group-by", "",
+		fmt.Sprintf("Group sets by photo capture date read from EXIF (%s)", strings.Join(report.GroupPeriods, ", ")))
+
+	reportSuggestCmd.Flags().IntVar(&suggestTop, "top", 10, "Number of best-scoring sets to list and plan (0 for all)")
+	reportSuggestCmd.Flags().StringVar(&suggestOut, "out", "", "Also save the listed sets as a plan file, as report simulate --format json writes")
+
+	reportSummaryCmd.Flags().StringVar(&summaryFormat, "format", output.FormatNameText,
+		"Output format (text, or json for a versioned summary document)")
+
+	addDecisionFlags(reportSimulateCmd)
+	addDecisionFlags(reportSuggestCmd)
+
+	reportCmd.AddCommand(reportDiffCmd, reportSimulateCmd, reportSuggestCmd, reportAnonymizeCmd, reportSummaryCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReportDiff(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	oldResults, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+	newResults, err := report.Load(args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(report.FormatDiff(report.Diff(oldResults, newResults), newResults.Labels))
+	return nil
+}
+
+// simulatePolicy returns the keep policy given by the flags of simulate
+// and suggest
+func simulatePolicy() (policy.Policy, error) {
+	priorities, err := policy.ParsePriorities(simulatePriorities)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	minAge, err := policy.ParseAge(simulateMinAge)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	p := policy.Policy{
+		Keep:        simulateKeep,
+		PreferDirs:  simulatePreferDirs,
+		Priorities:  priorities,
+		Protected:   simulateProtect,
+		MinAge:      minAge,
+		RequireHash: requireHash,
+	}
+	return p, p.Validate()
+}
+
+func runReportSimulate(cmd *cobra.Command, args []string) error {
+	p, err := simulatePolicy()
+	if err != nil {
+		return err
+	}
+	if simulateGroupBy != "" {
+		if err := report.ValidateGroupPeriod(simulateGroupBy); err != nil {
+			return err
+		}
+	}
+	if simulateFormat != output.FormatNameText && simulateFormat != output.FormatNameJSON {
+		return fmt.Errorf("unknown simulate format %q (supported: %s, %s)", simulateFormat, output.FormatNameText, output.FormatNameJSON)
+	}
+	cmd.SilenceUsage = true
+
+	results, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	decisions := report.Simulate(results, p)
+	if simulateFormat == output.FormatNameJSON {
+		plan, err := report.FormatPlan(decisions)
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan)
+		return nil
+	}
+
+	opts := output.Options{
+		Labels:     results.Labels,
+		TimeFormat: output.TimeFormat(timeFormat),
+		Explain:    explain,
+	}
+	if simulateGroupBy != "" {
+		groups := report.GroupByCapture(decisions, simulateGroupBy, media.CaptureTime)
+		fmt.Print(report.FormatGroupedSimulation(groups, opts))
+	} else {
+		fmt.Print(report.FormatSimulation(decisions, opts))
+	}
+
+	// Free space is projected from the file systems as they are now, so only
+	// files still present count toward a file system
+	if projections := diskspace.Project(report.PlannedRemovals(decisions)); len(projections) > 0 {
+		fmt.Print("\n" + diskspace.Format(projections))
+	}
+	return nil
+}
+
+func runReportSuggest(cmd *cobra.Command, args []string) error {
+	p, err := simulatePolicy()
+	if err != nil {
+		return err
+	}
+	if suggestTop < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+	cmd.SilenceUsage = true
+
+	results, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	suggestions := report.Suggest(report.Simulate(results, p), time.Now())
+	if suggestTop > 0 && len(suggestions) > suggestTop {
+		suggestions = suggestions[:suggestTop]
+	}
+	if suggestOut != "" {
+		plan, err := report.FormatPlan(report.Decisions(suggestions))
+		if err != nil {
+			return err
+		}
+		file, err := output.CreateAtomic(suggestOut)
+		if err != nil {
+			return err
+		}
+		defer file.Abort()
+		if _, err := file.WriteString(plan); err != nil {
+			return err
+		}
+		if err := file.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("No sets with files to remove")
+		return nil
+	}
+	fmt.Print(report.FormatSuggestions(suggestions, output.Options{
+		Labels:     results.Labels,
+		TimeFormat: output.TimeFormat(timeFormat),
+		Explain:    explain,
+	}))
+	if suggestOut != "" {
+		fmt.Printf("Plan for these %d set(s) saved to %s\n", len(suggestions), suggestOut)
+	}
+	return nil
+}
+
+func runReportAnonymize(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	results, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	anon := report.Anonymize(results)
+	data, err := output.FormatJSON(anon.Comparisons, output.Options{Labels: anon.Labels})
+	if err != nil {
+		return err
+	}
+	fmt.Print(data)
+	return nil
+}
+
+func runReportSummary(cmd *cobra.Command, args []string) error {
+	if summaryFormat != output.FormatNameText && summaryFormat != output.FormatNameJSON {
+		return fmt.Errorf("unknown summary format %q (supported: %s, %s)", summaryFormat, output.FormatNameText, output.FormatNameJSON)
+	}
+	cmd.SilenceUsage = true
+
+	results, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	summary := report.Summarize(results)
+	if summaryFormat == output.FormatNameJSON {
+		data, err := report.FormatSummaryJSON(summary)
+		if err != nil {
+			return err
+		}
+		fmt.Print(data)
+		return nil
+	}
+	fmt.Print(report.FormatSummaryText(summary))
+	return nil
+}
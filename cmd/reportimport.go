@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/foreign"
+	"github.com/Sho2010/dup-finder/internal/fsio"
+	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+var (
+	reportImportCmd = &cobra.Command{
+		Use:   "import RESULTS",
+		Short: "Review the duplicate sets found by fdupes, rdfind or czkawka interactively",
+		Long: `import reads the duplicate sets another tool found and opens them in the
+interactive session, as if dup-finder had found them: the output of fdupes -1,
+the results.txt of rdfind, or the JSON saved by czkawka. The tool is detected
+from the file unless --from names it. Files that no longer exist are dropped,
+and as the sets were not verified by dup-finder, --require-hash has each set
+hashed before any of its files is deleted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReportImport,
+	}
+
+	importFrom string
+)
+
+func init() {
+	reportImportCmd.Flags().StringVar(&importFrom, "from", "",
+		fmt.Sprintf("Tool that wrote RESULTS (%s) (default: detected)", strings.Join(foreign.Tools, ", ")))
+	reportImportCmd.Flags().DurationVar(&sessionBudget, "session-budget", 0, "Proceed to confirmation after this long and save the remaining sets for resume (0 for no limit)")
+	reportImportCmd.Flags().StringVar(&queuePath, "session-queue", "", "Where --session-budget saves deferred sets (default: user cache directory)")
+	addSessionFlags(reportImportCmd)
+	addDecisionFlags(reportImportCmd)
+	addRemovalFlags(reportImportCmd)
+	reportCmd.AddCommand(reportImportCmd)
+}
+
+func runReportImport(cmd *cobra.Command, args []string) error {
+	if importFrom != "" && !slices.Contains(foreign.Tools, importFrom) {
+		return fmt.Errorf("unknown --from tool %q (supported: %s)", importFrom, strings.Join(foreign.Tools, ", "))
+	}
+	if err := checkVerifySample(verifySample); err != nil {
+		return err
+	}
+	if err := finder.ValidateVerify(verifyMode); err != nil {
+		return err
+	}
+	if err := interactive.ValidateLink(symlinkMode); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("cannot read results: %w", err)
+	}
+	tool := importFrom
+	if tool == "" {
+		tool = foreign.Detect(data)
+	}
+	groups, err := foreign.Read(data, tool)
+	if err != nil {
+		return err
+	}
+	sets, dropped := importedSets(groups)
+	fmt.Fprintf(os.Stderr, "Loaded %d duplicate set(s) from %s results %s\n", len(sets), tool, args[0])
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Dropped %d file(s) that no longer exist\n", dropped)
+	}
+
+	comparisons := interactive.QueueComparisons(sets)
+	opts := models.ScanOptions{
+		Directories:   queueDirs(comparisons),
+		NumWorkers:    runtime.NumCPU(),
+		TimeFormat:    timeFormat,
+		SessionBudget: sessionBudget,
+		VerifySample:  verifySample,
+		Verify:        verifyMode,
+		Explain:       explain,
+		RequireHash:   requireHash,
+		Quarantine:    quarantinePath(),
+		Trash:         trashFiles,
+		Symlink:       symlinkMode,
+		FullScreen:    !noTUI,
+	}
+	applyPreferences(&opts)
+	summary, err := interactive.RunInteractiveSession(comparisons, opts)
+	if err != nil {
+		return fmt.Errorf("interactive session error: %w", err)
+	}
+	interactive.DisplaySummary(*summary)
+	if err := saveAnnotations(summary.Notes); err != nil {
+		return err
+	}
+	if rememberPrefs {
+		if err := savePreferences(summary.Learned); err != nil {
+			return err
+		}
+	}
+	if len(summary.Deferred) > 0 {
+		return saveSessionQueue(summary.Deferred, nil, summary.SkipRules)
+	}
+	return nil
+}
+
+// importedSets turns the paths of the sets another tool found into
+// duplicate sets of the files that still exist, each under its parent
+// directory, returning them and the number of files dropped. Sets left with
+// fewer than two files are dropped too. The sets are not hash-verified.
+func importedSets(groups [][]string) ([]models.DuplicateSet, int) {
+	var sets []models.DuplicateSet
+	dropped := 0
+	for _, group := range groups {
+		var files []models.FileInfo
+		for _, path := range group {
+			path = filepath.Clean(path)
+			info, err := fsio.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				dropped++
+				continue
+			}
+			files = append(files, models.FileInfo{
+				Path:      path,
+				Directory: filepath.Dir(path),
+				Size:      info.Size(),
+				ModTime:   info.ModTime(),
+			})
+		}
+		if len(files) >= 2 {
+			sets = append(sets, models.DuplicateSet{ID: len(sets) + 1, Files: files})
+		}
+	}
+	return sets, dropped
+}
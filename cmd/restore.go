@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/quarantine"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore DIR",
+	Short: "Move files quarantined by --quarantine back to where they were",
+	Long: `restore reads the manifest of a quarantine directory filled by
+--quarantine and moves every file listed there back to its original path.
+
+Files whose original path has been taken again stay in quarantine and are
+listed; run restore again once the path is free. Restored files are dropped
+from the manifest, so running restore twice is harmless.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().IntVarP(&numWorkers, "workers", "w", runtime.NumCPU(), "Number of parallel workers")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	dir := args[0]
+	if _, err := os.Stat(filepath.Join(dir, quarantine.ManifestName)); err != nil {
+		return fmt.Errorf("%s is not a quarantine directory: %w", dir, err)
+	}
+
+	restored, failed, err := quarantine.Restore(dir, numWorkers)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, entry := range restored {
+		size += entry.Size
+	}
+	fmt.Printf("Restored %s file(s), %s\n", output.FormatCount(len(restored)), output.FormatSize(size))
+
+	if len(failed) > 0 {
+		fmt.Println("\nLeft in quarantine:")
+		for _, failure := range failed {
+			fmt.Printf("  ✗ %s\n     Error: %v\n", failure.Entry.Original, failure.Err)
+		}
+		return fmt.Errorf("%d file(s) could not be restored", len(failed))
+	}
+	return nil
+}
+
+// quarantinePath returns --quarantine as an absolute path, so the restore
+// hint printed after a session works from any directory
+func quarantinePath() string {
+	if quarantineDir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(quarantineDir); err == nil {
+		return abs
+	}
+	return quarantineDir
+}
@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/session"
+)
+
+var (
+	resumeCmd = &cobra.Command{
+		Use:   "resume [SESSION]",
+		Short: "Continue an interrupted run, or an interactive session that ran out of --session-budget",
+		Long: `resume reopens the duplicate sets left undecided when an interactive session
+hit its --session-budget, without scanning again. Sets whose files no longer
+exist are dropped. Skip rules added with [x] in earlier sessions still apply.
+The queue is removed once every set has been handled.
+
+With SESSION, the run ID or state file printed when a run was interrupted,
+resume continues that run instead. An interrupted scan runs again with its
+original command line, reusing the hashes it had computed. An interrupted
+interactive session reopens at its first undecided set, with the deletions
+it had chosen still awaiting confirmation. The state is removed once the
+run ends.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runResume,
+	}
+
+	sessionBudget time.Duration
+	queuePath     string
+)
+
+func init() {
+	resumeCmd.Flags().DurationVar(&sessionBudget, "session-budget", 0, "Proceed to confirmation after this long and defer the remaining sets again (0 for no limit)")
+	resumeCmd.Flags().StringVar(&queuePath, "session-queue", "", "Deferred session queue file (default: user cache directory)")
+	addSessionFlags(resumeCmd)
+	addDecisionFlags(resumeCmd)
+	addRemovalFlags(resumeCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	if err := checkVerifySample(verifySample); err != nil {
+		return err
+	}
+	if err := finder.ValidateVerify(verifyMode); err != nil {
+		return err
+	}
+	if err := interactive.ValidateLink(symlinkMode); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return resumeSession(cmd, args[0])
+	}
+	cmd.SilenceUsage = true
+
+	path, err := sessionQueuePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no deferred session to resume (%s not found)", path)
+	}
+	results, err := report.Load(path)
+	if err != nil {
+		return err
+	}
+
+	comparisons, dropped := pruneMissing(results.Comparisons)
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Dropped %d set(s) whose files no longer exist\n", dropped)
+	}
+
+	skipRules, err := loadSkipRules()
+	if err != nil {
+		return err
+	}
+
+	opts := models.ScanOptions{
+		Directories:   queueDirs(comparisons),
+		NumWorkers:    runtime.NumCPU(),
+		Labels:        results.Labels,
+		TimeFormat:    timeFormat,
+		SessionBudget: sessionBudget,
+		VerifySample:  verifySample,
+		Verify:        verifyMode,
+		Explain:       explain,
+		SkipRules:     skipRules,
+		RequireHash:   requireHash,
+		Quarantine:    quarantinePath(),
+		Trash:         trashFiles,
+		Symlink:       symlinkMode,
+		FullScreen:    !noTUI,
+	}
+	applyPreferences(&opts)
+	summary, err := interactive.RunInteractiveSession(comparisons, opts)
+	if err != nil {
+		return fmt.Errorf("interactive session error: %w", err)
+	}
+	interactive.DisplaySummary(*summary)
+	if err := saveAnnotations(summary.Notes); err != nil {
+		return err
+	}
+
+	if rememberPrefs {
+		if err := savePreferences(summary.Learned); err != nil {
+			return err
+		}
+	}
+
+	if len(summary.Deferred) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot remove session queue: %w", err)
+		}
+		if err := os.Remove(skipRulesPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot remove skip rules: %w", err)
+		}
+		return nil
+	}
+	return saveSessionQueue(summary.Deferred, results.Labels, summary.SkipRules)
+}
+
+// resumeSession continues the interrupted run saved as name
+func resumeSession(cmd *cobra.Command, name string) error {
+	cmd.SilenceUsage = true
+	path, err := session.Path(name)
+	if err != nil {
+		return err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+	state, err := session.Load(path)
+	if err != nil {
+		return err
+	}
+	resumed, resumedPath = state, path
+
+	// Relative paths of the run are under the directory it started in
+	if state.WorkDir != "" {
+		if err := os.Chdir(state.WorkDir); err != nil {
+			return fmt.Errorf("cannot return to the directory of session %s: %w", path, err)
+		}
+	}
+
+	// The flags of the run apply again: its filters for a scan, and its
+	// priorities, protected directories and minimum age for a session
+	run, args := rootCmd, state.Args
+	if len(args) > 0 && args[0] == scanCmd.Name() {
+		run, args = scanCmd, args[1:]
+	}
+	if err := run.ParseFlags(args); err != nil {
+		return fmt.Errorf("cannot parse the command line of session %s: %w", path, err)
+	}
+	if state.Stage == session.StageScan {
+		fmt.Fprintf(os.Stderr, "Resuming interrupted run: dup-finder %s\n", strings.Join(state.Args, " "))
+		return run.RunE(run, run.Flags().Args())
+	}
+
+	if err := checkVerifySample(verifySample); err != nil {
+		return err
+	}
+	if err := finder.ValidateVerify(verifyMode); err != nil {
+		return err
+	}
+	if err := interactive.ValidateLink(symlinkMode); err != nil {
+		return err
+	}
+	priorities, err := policy.ParsePriorities(priorityArgs)
+	if err != nil {
+		return err
+	}
+	minAge, err := policy.ParseAge(minAgeArg)
+	if err != nil {
+		return err
+	}
+	progress := state.Progress
+	fmt.Fprintf(os.Stderr, "Resuming interactive session: %d set(s) decided, %d deletion(s) awaiting confirmation\n",
+		progress.Decided, len(progress.Actions))
+
+	opts := models.ScanOptions{
+		Directories:   state.Directories,
+		NumWorkers:    runtime.NumCPU(),
+		Labels:        state.Labels,
+		TimeFormat:    timeFormat,
+		SessionBudget: sessionBudget,
+		Priorities:    priorities,
+		Protected:     protectDirs,
+		MinAge:        minAge,
+		VerifySample:  verifySample,
+		Verify:        verifyMode,
+		Explain:       explain,
+		SkipRules:     progress.SkipRules,
+		RequireHash:   requireHash,
+		Quarantine:    quarantinePath(),
+		Trash:         trashFiles,
+		Symlink:       symlinkMode,
+		FullScreen:    !noTUI,
+		Resume:        progress,
+	}
+	applyPreferences(&opts)
+	summary, err := runSession(state.Comparisons, opts)
+	if err != nil {
+		return fmt.Errorf("interactive session error: %w", err)
+	}
+	return finishSession(summary, state.Labels)
+}
+
+// sessionQueuePath returns --session-queue or the default queue location
+func sessionQueuePath() (string, error) {
+	if queuePath != "" {
+		return queuePath, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "session-queue.json"), nil
+}
+
+// skipRulesPath returns where the skip rules of the queue at queue are kept
+func skipRulesPath(queue string) string {
+	return strings.TrimSuffix(queue, filepath.Ext(queue)) + "-skip-rules.json"
+}
+
+// loadSkipRules reads the skip rules saved with the session queue, if any
+func loadSkipRules() ([]string, error) {
+	queue, err := sessionQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	path := skipRulesPath(queue)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read skip rules: %w", err)
+	}
+	var rules []string
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid skip rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// saveSessionQueue writes deferred sets as a results document for resume,
+// with the skip rules of the session beside it
+func saveSessionQueue(deferred []models.DuplicateSet, labels output.Labels, skipRules []string) error {
+	path, err := sessionQueuePath()
+	if err != nil {
+		return err
+	}
+	data, err := output.FormatJSON(interactive.QueueComparisons(deferred), output.Options{Labels: labels})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create session queue directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("cannot save session queue: %w", err)
+	}
+	rulesPath := skipRulesPath(path)
+	if len(skipRules) == 0 {
+		if err := os.Remove(rulesPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot remove skip rules: %w", err)
+		}
+	} else {
+		rules, err := json.MarshalIndent(skipRules, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(rulesPath, append(rules, '\n'), 0644); err != nil {
+			return fmt.Errorf("cannot save skip rules: %w", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d deferred set(s) to %s; continue with: dup-finder resume\n", len(deferred), path)
+	return nil
+}
+
+// pruneMissing drops matches whose files were deleted or moved since the
+// queue was saved, returning the remaining comparisons and the number dropped
+func pruneMissing(comparisons []models.PairComparison) ([]models.PairComparison, int) {
+	dropped := 0
+	var result []models.PairComparison
+	for _, comparison := range comparisons {
+		var matches []models.FileMatch
+		for _, match := range comparison.Matches {
+			if fileExists(match.File1.Path) && fileExists(match.File2.Path) {
+				matches = append(matches, match)
+			} else {
+				dropped++
+			}
+		}
+		if len(matches) > 0 {
+			comparison.Matches = matches
+			result = append(result, comparison)
+		}
+	}
+	return result, dropped
+}
+
+// queueDirs lists the distinct directories of the queued comparisons
+func queueDirs(comparisons []models.PairComparison) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, comparison := range comparisons {
+		for _, dir := range []string{comparison.Dir1, comparison.Dir2} {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
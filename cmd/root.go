@@ -1,17 +1,36 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/Sho2010/dup-finder/internal/catalog"
+	"github.com/Sho2010/dup-finder/internal/checksum"
+	"github.com/Sho2010/dup-finder/internal/export"
 	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/fsio"
+	"github.com/Sho2010/dup-finder/internal/index"
 	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/media"
 	"github.com/Sho2010/dup-finder/internal/models"
 	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/panics"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/runid"
 	"github.com/Sho2010/dup-finder/internal/scanner"
+	"github.com/Sho2010/dup-finder/internal/session"
 )
 
 var (
@@ -19,105 +38,1148 @@ var (
 		Use:   "dup-finder [directory1] [directory2] [directory...]",
 		Short: "Find duplicate files across multiple directories",
 		Long:  `dup-finder scans multiple directories and finds duplicate files based on filename (optionally comparing content hash).`,
-		Args:  cobra.MinimumNArgs(2),
+		Args:  cobra.MinimumNArgs(1),
 		RunE:  runDupFinder,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			format := output.LocaleNumberFormat(output.EnvLocale())
+			format.SI = siUnits
+			output.SetNumberFormat(format)
+			if runID != "" {
+				return runid.Set(runID)
+			}
+			runid.ID()
+			return nil
+		},
 	}
 
-	recursive        bool
-	minSize          int64
-	extensions       []string
-	maxDepth         int
-	compareHash      bool
-	numWorkers       int
-	interactiveMode  bool
+	recursive       bool
+	minSize         int64
+	extensions      []string
+	includes        []string
+	nameRegex       string
+	excludeRegex    string
+	maxDepth        int
+	compareHash     bool
+	matchMode       string
+	againstPath     string
+	noNormalize     bool
+	fuzzyDistance   int
+	perceptual      bool
+	perceptualMax   int
+	numWorkers      int
+	interactiveMode bool
+	skipPairs       []string
+	maxPerPair      int
+	maxTotal        int
+	outputFormat    string
+	passNames       []string
+	passDir         string
+	includeCache    bool
+	skipHidden      bool
+	includeHidden   bool
+	noDupignore     bool
+	labelArgs       []string
+	timeFormat      string
+	excludeDirs     []string
+	noDirDefaults   bool
+	softMaxFiles    int
+	softMaxTime     time.Duration
+	collisionAudit  bool
+	priorityArgs    []string
+	protectDirs     []string
+	explain         bool
+	minAgeArg       string
+	largeOutput     string
+	largeThreshold  int
+	verifySample    float64
+	noTUI           bool
+	verifyMode      string
+	snapshotDirs    []string
+	keepSnapCopies  bool
+	htmlReportPath  string
+	outputPath      string
+	verbose         bool
+	summaryOnly     bool
+	listSymlinks    bool
+	followSymlinks  bool
+	symlinkFiles    string
+	exportTarget    string
+	ioTimeout       time.Duration
+	largeFileSize   int64
+	sampleLarge     bool
+	siUnits         bool
+	rememberPrefs   bool
+	annotationsFile string
+	quarantineDir   string
+	trashFiles      bool
+	symlinkMode     string
+	requireHash     bool
+	noProgress      bool
+	scanStats       bool
+	walkWorkers     int
+	runID           string
+	incremental     bool
+	planPath        string
+	planKeep        string
+	planPreferDirs  []string
 )
 
 func init() {
 	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Search directories recursively")
 	rootCmd.Flags().Int64VarP(&minSize, "min-size", "m", 0, "Minimum file size in bytes to consider")
-	rootCmd.Flags().StringSliceVarP(&extensions, "extensions", "e", []string{}, "File extensions to consider (e.g., .zip,.avi,.mp4)")
+	rootCmd.Flags().StringSliceVarP(&extensions, "extensions", "e", []string{}, "File extensions to consider, with or without the dot, case-insensitive (e.g., .zip,avi,MP4)")
+	rootCmd.Flags().StringArrayVar(&includes, "include", []string{}, "Only consider files whose name matches this glob, case-insensitive, e.g. 'IMG_*.jpg' (repeatable)")
+	rootCmd.Flags().StringVar(&nameRegex, "name-regex", "", "Only consider files whose path relative to their root directory matches this regular expression, e.g. '(^|/)[0-9]{8}_'")
+	rootCmd.Flags().StringVar(&excludeRegex, "exclude-regex", "", "Skip files whose path relative to their root directory matches this regular expression")
 	rootCmd.Flags().IntVarP(&maxDepth, "max-depth", "L", -1, "Maximum directory depth for recursive search (-1 for unlimited)")
 	rootCmd.Flags().BoolVarP(&compareHash, "compare-hash", "H", false, "Compare file content using xxHash")
+	rootCmd.Flags().StringVar(&matchMode, "match", finder.MatchName,
+		fmt.Sprintf("How to pair files across directories (%s): by name, by path relative to each directory, by size and hash whatever their names, by names that differ only as copies' do, or by audio stream whatever the tags of MP3, FLAC and M4A files", strings.Join(finder.MatchModes, ", ")))
+	rootCmd.Flags().StringVar(&againstPath, "against", "", "Compare each directory only with this baseline manifest (see manifest create) or catalog, to find the files it already holds; files are matched by content unless --match is given")
+	rootCmd.Flags().IntVar(&fuzzyDistance, "fuzzy-distance", 1, "With --match fuzzy, also pair names still this many character edits apart once copy markers are removed (0 for none)")
+	rootCmd.Flags().BoolVar(&perceptual, "perceptual", false, "Pair JPEG, PNG and GIF images that look alike by perceptual hash, even when re-encoded or resized, instead of matching files")
+	rootCmd.Flags().IntVar(&perceptualMax, "perceptual-threshold", finder.DefaultPerceptualLimit, "With --perceptual, how many of the 64 bits of two images' perceptual hashes may differ")
+	rootCmd.Flags().BoolVar(&noNormalize, "no-normalize-names", false, "Match names only in the same Unicode form, telling decomposed names (NFD, as macOS writes them) from composed ones (NFC)")
 	rootCmd.Flags().IntVarP(&numWorkers, "workers", "w", runtime.NumCPU(), "Number of parallel workers")
+	rootCmd.Flags().IntVar(&walkWorkers, "walk-workers", 1, "Directories of each root read at once while scanning; raise on disks where walking, not hashing, is the bottleneck")
 	rootCmd.Flags().BoolVarP(&interactiveMode, "interactive", "i", false, "Enable interactive deletion mode")
+	rootCmd.Flags().DurationVar(&sessionBudget, "session-budget", 0, "In interactive mode, proceed to confirmation after this long and save the remaining sets for resume (0 for no limit)")
+	addSessionFlags(rootCmd)
+	addDecisionFlags(rootCmd)
+	addRemovalFlags(rootCmd)
+	rootCmd.Flags().BoolVar(&incremental, "incremental", false, "Reuse the hashes recorded in the hash index for files whose size and mtime are unchanged, hashing only new and changed files, and record the new hashes for the next run")
+	rootCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file used by --incremental (default: user cache directory)")
+	rootCmd.Flags().StringVar(&queuePath, "session-queue", "", "Where --session-budget saves deferred sets (default: user cache directory)")
+	rootCmd.Flags().StringVar(&outputFormat, "format", output.FormatNameText, fmt.Sprintf("Output format (%s)", strings.Join(output.FormatNames, ", ")))
+	rootCmd.Flags().BoolVar(&summaryOnly, "summary", false, "Print only per-pair totals (matches, identical, reclaimable bytes) instead of every match")
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List the full paths of both files under each match in text output")
+	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write results to this file instead of stdout, replacing it only once the results are complete")
+	rootCmd.Flags().StringVar(&largeOutput, "large-output", output.LargeOutputSuggest,
+		fmt.Sprintf("What text output to a terminal does above --large-output-threshold matches (%s)", strings.Join(output.LargeOutputActions, ", ")))
+	rootCmd.Flags().IntVar(&largeThreshold, "large-output-threshold", 10000, "Match count above which --large-output applies (0 to disable)")
+	rootCmd.Flags().StringVar(&exportTarget, "export", "", "Also save the results for querying later, as KIND:PATH (sqlite:results.db)")
+	rootCmd.Flags().StringVar(&htmlReportPath, "html-report", "", "Also save a self-contained HTML report with sortable tables to this file")
+	rootCmd.Flags().StringSliceVar(&passNames, "passes", []string{}, fmt.Sprintf("Verification passes to run in order (%s)", strings.Join(finder.PassNames, ", ")))
+	rootCmd.Flags().StringVar(&passDir, "pass-dir", "", "Directory to save JSON results after each pass")
+	rootCmd.Flags().IntVar(&maxPerPair, "max-matches-per-pair", 0, "Stop listing matches for a pair after this many (0 for unlimited)")
+	rootCmd.Flags().IntVar(&maxTotal, "max-total-matches", 0, "Stop comparing once this many matches are found in total (0 for unlimited)")
+	rootCmd.Flags().Int64Var(&largeFileSize, "large-file-size", 50<<30, "Size in bytes from which files are hashed in resumable chunks with progress (0 to disable)")
+	rootCmd.Flags().BoolVar(&sampleLarge, "sample-large", true, "Compare files of at least --large-file-size by sampled regions before hashing them")
+	rootCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Do not show files found, bytes hashed, throughput and ETA on stderr while running (shown on a terminal only)")
+	rootCmd.Flags().DurationVar(&ioTimeout, "io-timeout", 0, "Give up on a path when stat or open takes longer than this, e.g. 30s for network mounts (0 to wait indefinitely)")
+	rootCmd.Flags().BoolVar(&scanStats, "scan-stats", false, "After scanning, show per directory the files seen, kept and left out by each filter, and read errors")
+	rootCmd.Flags().BoolVar(&listSymlinks, "list-symlinks", false, "List every symbolic link met while scanning and whether it was followed")
+	rootCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Follow links to directories inside the scanned trees, scanning each directory once so links cannot loop")
+	rootCmd.Flags().StringVar(&symlinkFiles, "symlink-files", scanner.SymlinkFilesSkip,
+		fmt.Sprintf("How to handle links to files (%s): skip them, or compare them as their target", strings.Join(scanner.SymlinkFilesModes, ", ")))
+	rootCmd.Flags().BoolVar(&includeCache, "include-cache-dirs", false, "Also scan package-manager and cache directories (node_modules, .venv, .cache, ...)")
+	rootCmd.Flags().BoolVar(&skipHidden, "skip-hidden", true, "Skip hidden files and directories: dot names like .cache on Unix, the hidden attribute on Windows")
+	rootCmd.Flags().BoolVar(&includeHidden, "include-hidden", false, "Scan hidden files and directories too (same as --skip-hidden=false)")
+	rootCmd.MarkFlagsMutuallyExclusive("skip-hidden", "include-hidden")
+	rootCmd.Flags().BoolVar(&noDupignore, "no-dupignore", false, "Scan files and directories listed in .dupignore files too")
+	rootCmd.Flags().StringArrayVar(&snapshotDirs, "snapshot", []string{}, "Also compare this snapshot of a root (ZFS, btrfs or Time Machine); the same file seen through it is not a duplicate (repeatable)")
+	rootCmd.Flags().BoolVar(&keepSnapCopies, "include-snapshot-copies", false, "Report files that are unchanged across --snapshot roots as duplicates too")
+	rootCmd.Flags().StringArrayVar(&excludeDirs, "exclude-dir", []string{}, "Directory name (or name glob) to skip at any depth, e.g. RAW (repeatable)")
+	rootCmd.Flags().BoolVar(&noDirDefaults, "no-default-excludes", false,
+		fmt.Sprintf("Scan the directories skipped by default too (%s)", strings.Join(scanner.DefaultExcludeDirs, ", ")))
+	rootCmd.Flags().BoolVar(&collisionAudit, "collision-audit", false, "Re-check every hash-equal pair by size and sampled bytes, reporting hash collisions")
+	rootCmd.Flags().IntVar(&softMaxFiles, "soft-max-files", 5000000, "Ask whether to continue after walking this many files (0 to disable)")
+	rootCmd.Flags().DurationVar(&softMaxTime, "soft-max-time", 30*time.Minute, "Ask whether to continue once scanning takes this long (0 to disable)")
+	rootCmd.Flags().StringArrayVar(&priorityArgs, "priority", []string{}, "Keep priority for a directory, as DIR=N; lower numbers are suggested for keeping (repeatable)")
+	rootCmd.Flags().StringArrayVar(&protectDirs, "protect", []string{}, "Never delete files under this directory in batch mode or a --save plan (repeatable)")
+	rootCmd.Flags().StringVar(&minAgeArg, "min-age", "", "Never delete files modified more recently than this in batch mode or a --save plan, e.g. 90d or 36h")
+	rootCmd.Flags().StringVar(&planPath, "save", "", "Save a plan of which copy of each duplicate set to keep and which to remove, to review and edit before running apply on it")
+	rootCmd.Flags().StringVar(&planKeep, "keep", policy.KeepNewest,
+		fmt.Sprintf("Which copy a --save plan keeps (%s)", strings.Join(policy.KeepStrategies, ", ")))
+	rootCmd.Flags().StringArrayVar(&planPreferDirs, "prefer-dir", []string{},
+		"Keep copies under this directory first in a --save plan (repeatable, in priority order)")
+	rootCmd.Flags().StringArrayVar(&labelArgs, "label", []string{}, "Short display name for a directory, as name=/path (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&timeFormat, "time-format", output.TimeFormatDefault,
+		fmt.Sprintf("Timestamp format: %s, or a Go time layout such as 02/01/2006", strings.Join(output.TimeFormatNames, ", ")))
+	rootCmd.PersistentFlags().BoolVar(&siUnits, "si", false,
+		"Show sizes in powers of 1000 (kB, MB), as du --si does, instead of 1024 (KiB, MiB)")
+	rootCmd.PersistentFlags().StringVar(&runID, "run-id", "",
+		"Identifier recorded in the results, plans, summaries, exports and quarantine manifest this run writes (default: generated)")
+	rootCmd.Flags().StringArrayVar(&skipPairs, "skip-pair", []string{}, "Directory pair to exclude from comparison, as dirA,dirB (repeatable)")
+}
+
+// addSessionFlags registers the options of interactive sessions on cmd
+func addSessionFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&rememberPrefs, "remember", false,
+		"Save the directory you usually keep and whether you hash every set, as defaults for later interactive sessions")
+	cmd.Flags().StringVar(&annotationsFile, "annotations", "",
+		"Notes written on sets in interactive mode, shown again in later results (default: user config directory)")
+	cmd.Flags().BoolVar(&noTUI, "no-tui", false,
+		"In interactive mode, answer line prompts instead of using the full-screen interface")
+	cmd.Flags().Float64Var(&verifySample, "verify-sample", 0,
+		"In interactive mode, re-hash this percent of kept files after deleting their duplicates (0 to skip)")
+}
+
+// addDecisionFlags registers on cmd the options of which files a command
+// that plans deletions may delete, and how it explains them
+func addDecisionFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&requireHash, "require-hash", true,
+		"Never delete a file whose duplicate was not verified by content hash; unverified sets are hashed first in interactive mode and retained by report simulate")
+	cmd.Flags().BoolVar(&explain, "explain", false,
+		"Annotate each planned deletion with the rule or choice that produced it")
+}
+
+// addRemovalFlags registers on cmd the options of how files are removed
+func addRemovalFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&quarantineDir, "quarantine", "",
+		"In interactive mode and apply, move files into this directory instead of deleting them; undo with: dup-finder restore DIR")
+	cmd.Flags().BoolVar(&trashFiles, "trash", false,
+		"In interactive mode and apply, move files to the trash (XDG trash on Linux and BSD, the Trash on macOS, the Recycle Bin on Windows) instead of deleting them")
+	cmd.Flags().StringVar(&symlinkMode, "symlink", "",
+		fmt.Sprintf("In interactive mode and apply, replace files with symlinks to the copies kept instead of deleting them, even across file systems (%s; %s when given alone)", strings.Join(interactive.LinkModes, ", "), interactive.LinkRelative))
+	cmd.Flags().Lookup("symlink").NoOptDefVal = interactive.LinkRelative
+	cmd.MarkFlagsMutuallyExclusive("trash", "quarantine", "symlink")
+	cmd.Flags().StringVar(&verifyMode, "verify", finder.VerifyHash,
+		fmt.Sprintf("What files with equal hashes must also pass to count as identical and be deleted (%s): nothing more, or a byte-by-byte comparison", strings.Join(finder.VerifyModes, ", ")))
 }
 
 // Execute runs the root command
 func Execute() error {
+	ignoreBrokenPipe()
 	return rootCmd.Execute()
 }
 
 func runDupFinder(cmd *cobra.Command, args []string) error {
+	start := time.Now()
+
+	// The first Ctrl+C stops scanning and hashing, and the results found so
+	// far are still printed and saved; a second one exits at once
+	ctx, release := interruptContext(cmd.Context())
+	defer release()
+
+	fsio.SetTimeout(ioTimeout)
+	defer reportTimeouts()
+
+	// Catalogs list the files of volumes that are not attached
+	dirArgs, catalogs, err := splitCatalogs(args)
+	if err != nil {
+		return err
+	}
+
+	// A baseline is compared with each directory, which are not compared
+	// with each other
+	var baseline *catalog.Catalog
+	if againstPath != "" {
+		baseline, err = catalog.Load(againstPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Loaded %s baseline %s: %d file(s)\n", baseline.Format, againstPath, len(baseline.Files))
+		catalogs = append(catalogs, baseline)
+		if !cmd.Flags().Changed("match") && !perceptual {
+			matchMode = finder.MatchContent
+		}
+	}
+
 	// Validate directories exist and filter out non-existent ones
+	roots := append(append([]string{}, dirArgs...), snapshotDirs...)
 	var validDirs []string
-	for _, dir := range args {
-		if _, err := os.Stat(dir); err != nil {
+	for _, dir := range normalizeRoots(roots) {
+		if _, err := fsio.Stat(dir); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Skipping %s: %v\n", dir, err)
 			continue
 		}
 		validDirs = append(validDirs, dir)
 	}
 
-	// Check if we have at least 2 valid directories
-	if len(validDirs) < 2 {
-		return fmt.Errorf("need at least 2 valid directories to compare, found only %d", len(validDirs))
+	// Check if we have at least 2 valid directories or catalogs
+	if len(validDirs)+len(catalogs) < 2 {
+		return fmt.Errorf("need at least 2 valid directories to compare, found only %d", len(validDirs)+len(catalogs))
+	}
+
+	// Files under a nested root are compared with themselves through both roots
+	for _, pair := range scanner.NestedRoots(validDirs) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is inside %s; its files are scanned under both\n", pair[1], pair[0])
 	}
 
 	// Show which directories will be compared
-	if len(validDirs) < len(args) {
-		fmt.Fprintf(os.Stderr, "Comparing %d out of %d directories:\n", len(validDirs), len(args))
+	if len(validDirs) < len(roots) {
+		fmt.Fprintf(os.Stderr, "Comparing %d out of %d directories:\n", len(validDirs), len(roots))
 		for _, dir := range validDirs {
 			fmt.Fprintf(os.Stderr, "  ✓ %s\n", dir)
 		}
 		fmt.Fprintln(os.Stderr)
 	}
 
+	numWorkers = checkWorkers(numWorkers)
+
+	if err := output.ValidateFormat(outputFormat); err != nil {
+		return err
+	}
+	if err := output.ValidateLargeOutput(largeOutput); err != nil {
+		return err
+	}
+	if err := checkVerifySample(verifySample); err != nil {
+		return err
+	}
+	if err := finder.ValidateVerify(verifyMode); err != nil {
+		return err
+	}
+	if err := interactive.ValidateLink(symlinkMode); err != nil {
+		return err
+	}
+	if summaryOnly && outputFormat != output.FormatNameText {
+		return fmt.Errorf("--summary applies to text output and cannot be combined with --format %s", outputFormat)
+	}
+	if outputFormat == output.FormatNameNDJSON && (interactiveMode || len(passNames) > 0 || collisionAudit || htmlReportPath != "" || exportTarget != "" || incremental || planPath != "") {
+		return fmt.Errorf("--format ndjson streams matches as they are found and cannot be combined with --interactive, --passes, --collision-audit, --html-report, --export, --incremental or --save")
+	}
+	var exportPath string
+	if exportTarget != "" {
+		_, path, err := export.ParseTarget(exportTarget)
+		if err != nil {
+			return err
+		}
+		exportPath = path
+	}
+
+	exts, warnings, err := scanner.NormalizeExtensions(extensions)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	passes, err := finder.ParsePasses(passNames)
+	if err != nil {
+		return err
+	}
+	if err := finder.ValidateMatch(matchMode); err != nil {
+		return err
+	}
+	if fuzzyDistance < 0 {
+		return fmt.Errorf("--fuzzy-distance must not be negative")
+	}
+	if perceptualMax < 0 || perceptualMax > media.DHashBits {
+		return fmt.Errorf("invalid --perceptual-threshold %d: expected a number of bits from 0 to %d", perceptualMax, media.DHashBits)
+	}
+	if perceptual && (compareHash || matchMode != finder.MatchName || len(passNames) > 0 || collisionAudit) {
+		return fmt.Errorf("--perceptual pairs similar images rather than identical files and cannot be combined with --compare-hash, --match, --passes or --collision-audit")
+	}
+	hashed := compareHash || matchMode == finder.MatchContent || matchMode == finder.MatchFuzzy
+	if collisionAudit && !hashed && !slices.Contains(passes, finder.PassDeep) {
+		return fmt.Errorf("--collision-audit needs hashes: add --compare-hash, --match content or --passes deep")
+	}
+
+	// Parse pairs to skip
+	skip, err := parseSkipPairs(skipPairs)
+	if err != nil {
+		return err
+	}
+
+	if err := scanner.ValidateDirPatterns(excludeDirs); err != nil {
+		return err
+	}
+	if err := scanner.ValidateSymlinkFiles(symlinkFiles); err != nil {
+		return err
+	}
+	if err := scanner.ValidateIncludePatterns(includes); err != nil {
+		return err
+	}
+	nameRe, err := scanner.CompilePathRegex("--name-regex", nameRegex)
+	if err != nil {
+		return err
+	}
+	excludeRe, err := scanner.CompilePathRegex("--exclude-regex", excludeRegex)
+	if err != nil {
+		return err
+	}
+
+	labels, err := parseLabels(labelArgs)
+	if err != nil {
+		return err
+	}
+
+	priorities, err := policy.ParsePriorities(priorityArgs)
+	if err != nil {
+		return err
+	}
+	minAge, err := policy.ParseAge(minAgeArg)
+	if err != nil {
+		return err
+	}
+	plan := policy.Policy{
+		Keep:        planKeep,
+		PreferDirs:  planPreferDirs,
+		Priorities:  priorities,
+		Protected:   protectDirs,
+		MinAge:      minAge,
+		RequireHash: requireHash,
+	}
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+
 	// Build scan options
 	opts := models.ScanOptions{
-		Directories: validDirs,
-		Recursive:   recursive,
-		MinSize:     minSize,
-		Extensions:  extensions,
-		MaxDepth:    maxDepth,
-		CompareHash: compareHash,
-		NumWorkers:  numWorkers,
+		Directories:       validDirs,
+		Recursive:         recursive,
+		MinSize:           minSize,
+		Extensions:        exts,
+		Includes:          includes,
+		NameRegex:         nameRe,
+		ExcludeRegex:      excludeRe,
+		MaxDepth:          maxDepth,
+		CompareHash:       compareHash,
+		Match:             matchMode,
+		NormalizeNames:    !noNormalize,
+		FuzzyDistance:     fuzzyDistance,
+		Perceptual:        perceptual,
+		PerceptualLimit:   perceptualMax,
+		NumWorkers:        numWorkers,
+		LargeFileSize:     largeFileSize,
+		SampleLarge:       sampleLarge,
+		HashStateDir:      hashStateDir(),
+		WalkWorkers:       walkWorkers,
+		SkipPairs:         skip,
+		MaxMatchesPerPair: maxPerPair,
+		MaxTotalMatches:   maxTotal,
+		SkipCacheDirs:     !includeCache,
+		SkipHidden:        skipHidden && !includeHidden,
+		FollowSymlinks:    followSymlinks,
+		SymlinkFiles:      symlinkFiles,
+		ExcludeDirs:       scanner.ExcludeDirs(excludeDirs, noDirDefaults, includeCache),
+		UseDupignore:      !noDupignore,
+		SoftMaxFiles:      softMaxFiles,
+		SoftMaxDuration:   softMaxTime,
+		SessionBudget:     sessionBudget,
+		Priorities:        priorities,
+		Protected:         protectDirs,
+		MinAge:            minAge,
+		Labels:            labels,
+		TimeFormat:        timeFormat,
+		VerifySample:      verifySample,
+		Verify:            verifyMode,
+		Explain:           explain,
+		RequireHash:       requireHash,
+		Quarantine:        quarantinePath(),
+		Trash:             trashFiles,
+		Symlink:           symlinkMode,
+		FullScreen:        !noTUI,
 	}
 
+	// Show live progress while scanning and hashing, until results are printed
+	startProgress()
+	defer stopProgress()
+	opts.LargeProgress = stderr
+
 	// Scan all directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	s.OnSoftLimit(pauseProgress(softLimitHandler()))
+	allFiles, err := s.ScanAll(ctx)
+	// Roots that failed are left out, and the run goes on with the others
+	var failures []models.Failure
+	var rootsErr *scanner.RootsError
+	if errors.As(err, &rootsErr) {
+		for _, root := range rootsErr.Roots() {
+			fmt.Fprintf(stderr, "Warning: cannot scan %s, so its pairs are not compared: %v\n", root, rootsErr.Failed[root])
+			failures = append(failures, models.Failure{Stage: models.StageScan, Target: root, Err: rootsErr.Failed[root].Error()})
+		}
+		validDirs = slices.DeleteFunc(validDirs, func(dir string) bool { return rootsErr.Failed[dir] != nil })
+		if len(validDirs)+len(catalogs) < 2 {
+			return fmt.Errorf("need at least 2 scanned directories to compare, found only %d", len(validDirs)+len(catalogs))
+		}
+		err = nil
+	}
+	if errors.Is(err, scanner.ErrScanAborted) {
+		return fmt.Errorf("%w: narrow the scan with --extensions, --min-size or --exclude-dir, or raise --soft-max-files/--soft-max-time", scanner.ErrScanAborted)
+	}
 	if err != nil {
 		return fmt.Errorf("error scanning directories: %w", err)
 	}
+	if s.Partial() && ctx.Err() == nil {
+		fmt.Fprintln(stderr, "Scan stopped at soft limit: results are partial")
+	}
+	reportSymlinks(s.Symlinks())
+	// Listed after the results, and saved with them, so it is clear what they lack
+	problems := output.Options{Labels: labels, ScanErrors: s.Errors(), Failures: failures}
+	if scanStats {
+		reportScanStats(s.Stats())
+	}
+
+	// Catalogs are compared like scanned directories, under the same filters
+	compared := slices.Clone(validDirs)
+	for _, c := range catalogs {
+		allFiles[c.Name] = s.FilterFiles(c.Root, c.Files)
+		compared = append(compared, c.Name)
+	}
+
+	// Files unchanged since an earlier incremental run keep the hash it saved
+	var idx *index.Index
+	if incremental {
+		if idx, err = openIndex(); err != nil {
+			return err
+		}
+		reused, total := 0, 0
+		for _, dir := range validDirs {
+			reused += finder.FillFromCache(allFiles[dir], idx)
+			total += len(allFiles[dir])
+		}
+		fmt.Fprintf(stderr, "Incremental: reusing the hashes of %s of %s file(s) unchanged since the last run\n",
+			output.FormatCount(reused), output.FormatCount(total))
+	}
+
+	// A resumed run reuses the hashes computed before it was interrupted
+	if resumed != nil && resumed.Stage == session.StageScan {
+		reused := 0
+		for _, dir := range validDirs {
+			reused += finder.FillFromCache(allFiles[dir], resumed)
+		}
+		fmt.Fprintf(stderr, "Resuming: reusing the hashes of %s file(s) from the interrupted run\n", output.FormatCount(reused))
+	}
 
 	// Generate directory pairs (only for valid directories)
-	pairs := finder.GeneratePairs(validDirs)
+	pairs := finder.ExcludePairs(finder.GeneratePairs(compared), opts.SkipPairs)
+	if baseline != nil {
+		pairs = finder.PairsWith(pairs, baseline.Name)
+	}
+
+	// Files unchanged since a snapshot are the same file, not an extra copy
+	var snapshots []string
+	if !keepSnapCopies {
+		for _, dir := range snapshotDirs {
+			snapshots = append(snapshots, scanner.NormalizeRoot(dir))
+		}
+	}
 
-	// Compare each pair
 	f := finder.NewFinder(opts)
+	if outputFormat == output.FormatNameNDJSON {
+		out, commit, abort, err := openResults()
+		if err != nil {
+			return err
+		}
+		defer abort()
+		// Matches streamed to the terminal would run into the progress line
+		if outputPath == "" && isTerminal(os.Stdout) {
+			stopProgress()
+		}
+		found, err := streamNDJSON(ctx, out, f, pairs, allFiles, snapshots)
+		problems.Failures = append(problems.Failures, panicFailures()...)
+		if isBrokenPipe(err) {
+			return brokenPipeError(cmd)
+		}
+		if err != nil {
+			return err
+		}
+		if err := commit(); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return interruptedError(cmd)
+		}
+		if len(problems.Failures) > 0 {
+			return partialError(cmd, problems.Failures)
+		}
+		if found == 0 {
+			return noDuplicatesError(cmd)
+		}
+		return nil
+	}
+
+	// Compare each pair
 	var comparisons []models.PairComparison
 
-	for _, pair := range pairs {
+	for i, pair := range pairs {
+		if f.LimitReached() {
+			fmt.Fprintf(stderr, "Match limit reached: skipped %d remaining pair(s)\n", len(pairs)-i)
+			break
+		}
+
 		dir1Files := allFiles[pair[0]]
 		dir2Files := allFiles[pair[1]]
 
-		comparison := f.ComparePair(dir1Files, dir2Files)
+		comparison := f.ComparePair(ctx, dir1Files, dir2Files)
 		comparisons = append(comparisons, comparison)
 	}
 
-	// Format and print output to stdout
-	result := output.FormatAllComparisons(comparisons, compareHash)
-	fmt.Print(result)
+	// Overlapping or symlinked roots report the same two files more than once
+	if collapsed := finder.CollapseRepeatedMatches(comparisons); collapsed > 0 {
+		fmt.Fprintf(stderr, "Collapsed %d match(es) of files already reported through another directory pair\n", collapsed)
+	}
+
+	if len(snapshots) > 0 {
+		// Drop files seen through a snapshot root rather than real extra copies
+		if dropped := f.DropSnapshotCopies(ctx, comparisons, snapshots); dropped > 0 {
+			fmt.Fprintf(stderr, "Ignored %d file(s) unchanged across snapshots\n", dropped)
+		}
+	}
+
+	// Run verification passes, saving results after each so the run can be
+	// stopped at any confidence level
+	showHash := hashed
+	for _, pass := range passes {
+		if ctx.Err() != nil {
+			break
+		}
+		remaining, err := f.RunPass(ctx, pass, comparisons)
+		if ctx.Err() != nil {
+			fmt.Fprintf(stderr, "Pass %s interrupted: %d candidate(s) remain, some unchecked\n", pass, remaining)
+			break
+		}
+		if err != nil {
+			// The passes before it stand, and the results are shown at their level
+			fmt.Fprintf(stderr, "Pass %s failed, skipping the remaining passes: %v\n", pass, err)
+			problems.Failures = append(problems.Failures, models.Failure{Stage: models.StagePass, Target: pass, Err: err.Error()})
+			break
+		}
+		fmt.Fprintf(stderr, "Pass %s complete: %d candidate(s) remain\n", pass, remaining)
+
+		if pass == finder.PassDeep {
+			showHash = true
+		}
+		if passDir != "" {
+			if err := savePassResults(passDir, pass, comparisons, problems); err != nil {
+				return err
+			}
+		}
+	}
+
+	if collisionAudit && ctx.Err() == nil {
+		audited, collisions := f.AuditCollisions(ctx, comparisons)
+		for _, c := range collisions {
+			fmt.Fprintf(stderr, "CRITICAL: hash collision: %s and %s share hash %s but differ by %s\n",
+				c.File1, c.File2, c.Hash, c.Check)
+		}
+		fmt.Fprintf(stderr, "Collision audit: %d hash-equal pair(s) checked, %d collision(s)\n", audited, len(collisions))
+	}
+
+	problems.Failures = append(problems.Failures, panicFailures()...)
+	stopProgress()
+
+	// Save the hashes of this run for the next incremental one
+	if idx != nil {
+		finder.StoreHashes(comparisons, idx)
+		if err := idx.Save(); err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Show the notes written on these files in earlier interactive sessions
+	applyAnnotations(comparisons)
+
+	// Format and print output to stdout or --output: one line when there is
+	// nothing to list, and a summary when the text would flood the terminal
+	out, commit, abort, err := openResults()
+	if err != nil {
+		return err
+	}
+	defer abort()
+	duplicates := output.CountDuplicates(comparisons)
+	// Pairwise matches merged into sets, so a file present in several
+	// directories is one set rather than one match per pair
+	sets := finder.MergeMatches(comparisons)
+	printed := false
+	var writeErr error
+	if duplicates == 0 && outputFormat == output.FormatNameText {
+		_, writeErr = fmt.Fprint(out, output.FormatNoDuplicates(countFiles(allFiles), len(compared), len(comparisons), time.Since(start)),
+			output.FormatScanErrors(problems.ScanErrors), output.FormatFailures(problems.Failures))
+		printed = true
+	} else if summaryOnly {
+		_, writeErr = fmt.Fprint(out, output.FormatSummary(comparisons, problems))
+		printed = true
+	} else if printed, writeErr, err = guardLargeOutput(out, comparisons, problems); err != nil {
+		return err
+	}
+	if !printed {
+		// Fit text to the terminal; files and pipes get full names
+		width := 0
+		if outputPath == "" {
+			width = terminalWidth(os.Stdout)
+		}
+		// With three or more directories, text lists each set once
+		var listed []models.DuplicateSet
+		if len(compared) > 2 {
+			listed = sets
+		}
+		// Checksum formats list digests, computed now for files not listed with one
+		if algorithm := checksum.Algorithm(outputFormat); algorithm != "" {
+			f.FillChecksums(ctx, comparisons, algorithm)
+		}
+		result, err := output.FormatComparisons(outputFormat, comparisons, output.Options{
+			ShowHash:   showHash,
+			Labels:     labels,
+			TimeFormat: output.TimeFormat(timeFormat),
+			Width:      width,
+			Verbose:    verbose,
+			ScanErrors: problems.ScanErrors,
+			Failures:   problems.Failures,
+			Sets:       listed,
+		})
+		if err != nil {
+			return err
+		}
+		_, writeErr = fmt.Fprint(out, result)
+	}
+	// A reader that exited early, such as head, is not an error: the
+	// reports and exports below are still written
+	if writeErr != nil && !isBrokenPipe(writeErr) {
+		return fmt.Errorf("cannot write results: %w", writeErr)
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+	if duplicates > 0 {
+		fmt.Fprintf(os.Stderr, "Reclaimable: %s by keeping one copy of each of %s duplicate set(s)\n",
+			output.FormatSize(finder.TotalSavings(sets)), output.FormatCount(len(sets)))
+	}
+
+	if htmlReportPath != "" {
+		page, err := report.FormatHTML(comparisons, labels)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(htmlReportPath, []byte(page), 0644); err != nil {
+			return fmt.Errorf("cannot save HTML report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "HTML report saved to %s\n", htmlReportPath)
+	}
+
+	if exportPath != "" {
+		if err := export.SQLite(exportPath, comparisons, labels); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Results exported to %s\n", exportPath)
+	}
+
+	// Save the plan to review before apply carries it out, even when the
+	// reader of stdout has gone; an interrupted run saves none
+	if planPath != "" && duplicates > 0 && ctx.Err() == nil {
+		if err := savePlan(planPath, comparisons, plan); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		// Never delete on the strength of a run cut short, but keep what
+		// it hashed so resume can finish it
+		saveScanSession(comparisons)
+		return interruptedError(cmd)
+	}
+	// A resumed run that finished its scan no longer needs the saved hashes
+	if resumed != nil {
+		if err := session.Remove(resumedPath); err != nil {
+			return err
+		}
+	}
+	if isBrokenPipe(writeErr) {
+		return brokenPipeError(cmd)
+	}
+	if duplicates == 0 {
+		if len(problems.Failures) > 0 {
+			return partialError(cmd, problems.Failures)
+		}
+		return noDuplicatesError(cmd)
+	}
 
 	// Enter interactive mode if requested
 	if interactiveMode {
+		// Ctrl+C leaves the session as usual
+		release()
 		fmt.Fprintln(os.Stderr, "\n--- Entering Interactive Deletion Mode ---")
-		summary, err := interactive.RunInteractiveSession(comparisons, opts)
+		applyPreferences(&opts)
+		summary, err := runSession(comparisons, opts)
 		if err != nil {
 			return fmt.Errorf("interactive session error: %w", err)
 		}
-		interactive.DisplaySummary(*summary)
+		if err := finishSession(summary, labels); err != nil {
+			return err
+		}
 	}
 
+	if len(problems.Failures) > 0 {
+		return partialError(cmd, problems.Failures)
+	}
+	return nil
+}
+
+// savePlan applies the keep policy p to the duplicate sets of comparisons
+// and saves the decisions as a plan file at path
+func savePlan(path string, comparisons []models.PairComparison, p policy.Policy) error {
+	decisions := report.Simulate(&models.Results{Comparisons: comparisons}, p)
+	// Absolute paths, so apply works from any directory
+	abs := func(path string) string {
+		if a, err := filepath.Abs(path); err == nil {
+			return a
+		}
+		return path
+	}
+	unverified := 0
+	for i := range decisions {
+		d := &decisions[i]
+		d.Keep.Path, d.Keep.Directory = abs(d.Keep.Path), abs(d.Keep.Directory)
+		for _, files := range [][]models.FileInfo{d.Remove, d.Retained} {
+			for j := range files {
+				files[j].Path, files[j].Directory = abs(files[j].Path), abs(files[j].Directory)
+			}
+		}
+		for j := range d.Reasons {
+			d.Reasons[j].Path = abs(d.Reasons[j].Path)
+		}
+		if !d.Verified {
+			unverified++
+		}
+	}
+	data, err := report.FormatPlan(decisions)
+	if err != nil {
+		return err
+	}
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.WriteString(data); err != nil {
+		return fmt.Errorf("cannot save plan: %w", err)
+	}
+	if err := file.Commit(); err != nil {
+		return err
+	}
+	_, removed := report.PlannedRemovals(decisions)
+	fmt.Fprintf(os.Stderr, "Plan saved to %s: %s file(s) to remove from %s set(s); review it, then run: dup-finder apply %s\n",
+		path, output.FormatCount(len(removed)), output.FormatCount(len(decisions)), path)
+	if unverified > 0 && p.RequireHash {
+		fmt.Fprintf(os.Stderr, "Warning: %s set(s) were not hash-verified, so the plan removes none of their files; add --compare-hash\n", output.FormatCount(unverified))
+	}
 	return nil
 }
+
+// interruptedError ends a run stopped by a signal with ExitInterrupted,
+// once its partial results are out
+func interruptedError(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	return &ExitError{Code: ExitInterrupted, Err: errors.New("interrupted: results are partial")}
+}
+
+// partialError ends a run with ExitPartial once the results of the parts
+// that completed are out
+func partialError(cmd *cobra.Command, failures []models.Failure) error {
+	cmd.SilenceUsage = true
+	return &ExitError{Code: ExitPartial, Err: fmt.Errorf("%d part(s) of the run failed: results are incomplete", len(failures))}
+}
+
+// brokenPipeError ends a run whose output reader exited early, such as
+// head after its first lines, without printing an error: the reader got
+// what it asked for
+func brokenPipeError(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: ExitBrokenPipe}
+}
+
+// noDuplicatesError ends a run that found nothing with ExitNoDuplicates,
+// without printing an error
+func noDuplicatesError(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: ExitNoDuplicates}
+}
+
+// reportScanStats prints what the scan of each root met, to explain why
+// an expected duplicate is missing
+func reportScanStats(stats []scanner.DirStats) {
+	// describe lists counts by reason, most frequent first
+	describe := func(counts map[string]int) string {
+		reasons := make([]string, 0, len(counts))
+		for reason := range counts {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if counts[reasons[i]] != counts[reasons[j]] {
+				return counts[reasons[i]] > counts[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		parts := make([]string, len(reasons))
+		for i, reason := range reasons {
+			parts[i] = fmt.Sprintf("%s %s", output.FormatCount(counts[reason]), reason)
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	for _, st := range stats {
+		fmt.Fprintf(stderr, "Scanned %s: %s file(s) seen, %s kept (%s), %s error(s)\n", st.Root,
+			output.FormatCount(st.Seen), output.FormatCount(st.Kept), output.FormatSize(st.Bytes), output.FormatCount(st.Errors))
+		if len(st.Filtered) > 0 {
+			fmt.Fprintf(stderr, "  files left out: %s\n", describe(st.Filtered))
+		}
+		if len(st.SkippedDirs) > 0 {
+			fmt.Fprintf(stderr, "  directories skipped: %s\n", describe(st.SkippedDirs))
+		}
+	}
+}
+
+// reportSymlinks tells how many symbolic links the scan followed and
+// skipped, listing each with --list-symlinks
+func reportSymlinks(links []scanner.Symlink) {
+	if len(links) == 0 {
+		return
+	}
+	var followedLinks, skippedLinks []scanner.Symlink
+	for _, link := range links {
+		if link.Followed() {
+			followedLinks = append(followedLinks, link)
+		} else {
+			skippedLinks = append(skippedLinks, link)
+		}
+	}
+	kinds := []string{scanner.SymlinkRoot, scanner.SymlinkFile, scanner.SymlinkDir, scanner.SymlinkLoop, scanner.SymlinkBroken}
+	describe := func(links []scanner.Symlink) string {
+		counts := scanner.CountSymlinks(links)
+		var parts []string
+		for _, kind := range kinds {
+			if n := counts[kind]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s link(s)", n, kind))
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	var parts []string
+	if len(followedLinks) > 0 {
+		parts = append(parts, "followed "+describe(followedLinks))
+	}
+	if len(skippedLinks) > 0 {
+		parts = append(parts, "skipped "+describe(skippedLinks))
+	}
+	hint := ""
+	if !listSymlinks {
+		hint = " (list them with --list-symlinks)"
+	}
+	fmt.Fprintf(stderr, "Symlinks: %s%s\n", strings.Join(parts, "; "), hint)
+
+	if listSymlinks {
+		for _, link := range links {
+			action := "skipped "
+			if link.Followed() {
+				action = "followed"
+			}
+			fmt.Fprintf(stderr, "  %s  %s -> %s (%s)\n", action, link.Path, link.Target, link.Kind)
+		}
+	}
+}
+
+// reportTimeouts lists the paths given up on because stat or open took
+// longer than --io-timeout. Their files are missing from the results.
+func reportTimeouts() {
+	paths := fsio.TimedOut()
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Timed out after %s on %d path(s); results omit them:\n", ioTimeout, len(paths))
+	for _, path := range paths {
+		fmt.Fprintf(os.Stderr, "  %s\n", path)
+	}
+}
+
+// panicFailures returns a failure for every file or pair whose worker
+// panicked, leaving it out of the results
+func panicFailures() []models.Failure {
+	var failures []models.Failure
+	for _, panicErr := range panics.Recovered() {
+		failures = append(failures, models.Failure{Stage: models.StageWorker, Target: panicErr.Path, Err: panicErr.Error()})
+	}
+	return failures
+}
+
+// countFiles returns the number of files scanned across all roots
+func countFiles(allFiles map[string][]models.FileInfo) int {
+	var total int
+	for _, files := range allFiles {
+		total += len(files)
+	}
+	return total
+}
+
+// savePassResults writes the current comparisons to DIR/pass-NAME.json,
+// with the labels, scan errors and failures in opts
+func savePassResults(dir, pass string, comparisons []models.PairComparison, opts output.Options) error {
+	data, err := output.FormatJSON(comparisons, opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create pass directory: %w", err)
+	}
+	path := filepath.Join(dir, "pass-"+pass+".json")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("cannot save pass results: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "  saved %s\n", path)
+	return nil
+}
+
+// guardLargeOutput applies --large-output when text results bound for a
+// terminal exceed --large-output-threshold matches: it writes a per-pair
+// summary to out instead, saving the full results to a JSON file if asked
+// to. It reports whether it wrote the results, and the error of writing
+// them apart from other errors. Labels, scan errors and failures come from
+// opts.
+func guardLargeOutput(out io.Writer, comparisons []models.PairComparison, opts output.Options) (printed bool, writeErr, err error) {
+	total := output.CountMatches(comparisons)
+	if outputFormat != output.FormatNameText || outputPath != "" || largeOutput == output.LargeOutputPrint ||
+		largeThreshold <= 0 || total <= largeThreshold || !isTerminal(os.Stdout) {
+		return false, nil, nil
+	}
+
+	_, writeErr = fmt.Fprint(out, output.FormatSummary(comparisons, opts))
+
+	if largeOutput == output.LargeOutputFile {
+		data, err := output.FormatJSON(comparisons, opts)
+		if err != nil {
+			return true, writeErr, err
+		}
+		path := fmt.Sprintf("dup-finder-%s.json", time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			return true, writeErr, fmt.Errorf("cannot save results: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "\n%s matches exceed --large-output-threshold %d: full results saved to %s\n", output.FormatCount(total), largeThreshold, path)
+		return true, writeErr, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%s matches exceed --large-output-threshold %d: showing a summary.\n", output.FormatCount(total), largeThreshold)
+	fmt.Fprintln(os.Stderr, "Save the full results with --format json > results.json, or list them here with --large-output print.")
+	return true, writeErr, nil
+}
+
+// openResults returns the writer for formatted results: stdout, or with
+// --output a temporary file beside the destination. commit moves the file
+// into place and abort discards it unless committed; both do nothing when
+// writing to stdout.
+func openResults() (io.Writer, func() error, func(), error) {
+	if outputPath == "" {
+		return os.Stdout, func() error { return nil }, func() {}, nil
+	}
+	file, err := output.CreateAtomic(outputPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	commit := func() error {
+		if err := file.Commit(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Results saved to %s\n", outputPath)
+		return nil
+	}
+	return file, commit, file.Abort, nil
+}
+
+// parseSkipPairs parses --skip-pair values of the form "dirA,dirB"
+func parseSkipPairs(values []string) ([][2]string, error) {
+	var pairs [][2]string
+	for _, v := range values {
+		parts := strings.Split(v, ",")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid --skip-pair value %q: expected dirA,dirB", v)
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])})
+	}
+	return pairs, nil
+}
+
+// parseLabels parses --label values of the form "name=/path" into a map
+// keyed by absolute directory
+func parseLabels(values []string) (output.Labels, error) {
+	labels := make(output.Labels)
+	seen := make(map[string]bool)
+	for _, v := range values {
+		name, dir, ok := strings.Cut(v, "=")
+		name, dir = strings.TrimSpace(name), strings.TrimSpace(dir)
+		if !ok || name == "" || dir == "" {
+			return nil, fmt.Errorf("invalid --label value %q: expected name=/path", v)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate label %q", name)
+		}
+		seen[name] = true
+
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --label path %q: %w", dir, err)
+		}
+		labels[abs] = name
+	}
+	return labels, nil
+}
+
+// softLimitHandler prompts on a terminal. Without one it warns once and
+// keeps scanning, so scripted runs behave as before.
+func softLimitHandler() scanner.SoftLimitFunc {
+	if isTerminal(os.Stdin) {
+		return interactive.PromptSoftLimit
+	}
+	warned := false
+	return func(files int, elapsed time.Duration) scanner.SoftLimitAction {
+		if !warned {
+			fmt.Fprintf(os.Stderr, "Warning: soft limit passed (%d files, %s); continuing without a terminal to ask\n", files, elapsed.Round(time.Second))
+			warned = true
+		}
+		return scanner.SoftLimitContinue
+	}
+}
+
+// isTerminal reports whether f is an interactive character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width in columns of the terminal behind f,
+// taken from COLUMNS when set, or 0 when f is not a terminal
+func terminalWidth(f *os.File) int {
+	if !isTerminal(f) {
+		return 0
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return ttyWidth(f)
+}
+
+// checkVerifySample validates the --verify-sample percentage
+func checkVerifySample(percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid --verify-sample %g: expected a percentage from 0 to 100", percent)
+	}
+	return nil
+}
+
+// checkWorkers replaces a zero or negative --workers value with the CPU
+// count and warns about counts far above it
+func checkWorkers(n int) int {
+	cpus := runtime.NumCPU()
+	if n < 1 {
+		fmt.Fprintf(os.Stderr, "Warning: --workers %d is not positive; using %d\n", n, cpus)
+	} else if n > cpus*scanner.MaxWorkersPerCPU {
+		fmt.Fprintf(os.Stderr, "Warning: --workers %d is far above the %d available CPUs; expect contention\n", n, cpus)
+	}
+	return scanner.EffectiveWorkers(n)
+}
+
+// normalizeRoots cleans directory arguments (separators, trailing slashes,
+// Windows drives and UNC shares) and drops repeats of the same directory
+func normalizeRoots(args []string) []string {
+	dirs := make([]string, len(args))
+	for i, arg := range args {
+		dirs[i] = scanner.NormalizeRoot(arg)
+	}
+	unique, dropped := scanner.DedupRoots(dirs)
+	for _, dir := range dropped {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping %s: directory given more than once\n", dir)
+	}
+	return unique
+}
+
+// hashStateDir returns where the progress of hashing large files is kept,
+// or "" if there is no cache directory
+func hashStateDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "dup-finder", "hash-state")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan DIR... --save PLAN",
+	Short: "Find duplicates and save a plan of what to remove, to review before apply",
+	Long: `scan runs the same comparison as dup-finder DIR..., with the same flags, and
+saves a plan with --save: for every duplicate set, the copy kept by --keep,
+--prefer-dir, --priority and --protect, the copies to remove, and the rule
+behind each choice. Nothing is removed.
+
+The expensive part can run unattended, such as overnight on a server. Review
+the plan later, even on another machine, edit it if needed, then carry it
+out with dup-finder apply PLAN, which checks every file again first.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runScan,
+}
+
+func init() {
+	// The flags of a plain run, shared so both spellings set the same options
+	scanCmd.Flags().AddFlagSet(rootCmd.Flags())
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	if planPath == "" {
+		return fmt.Errorf("scan saves a plan: add --save PLAN, or run dup-finder DIR... to list duplicates only")
+	}
+	return runDupFinder(cmd, args)
+}
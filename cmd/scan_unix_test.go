@@ -0,0 +1,46 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanSavesPlanWhenStdoutIsClosed(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "config"))
+	dirA, dirB := filepath.Join(tmp, "a"), filepath.Join(tmp, "b")
+	for _, dir := range []string{dirA, dirB} {
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "photo.jpg"), []byte("same"), 0644))
+	}
+
+	// A reader that exited before the first line, as with | head -0
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		w.Close()
+	})
+
+	plan := filepath.Join(tmp, "plan.json")
+	rootCmd.SetArgs([]string{"scan", dirA, dirB, "--compare-hash", "--save", plan})
+	err = rootCmd.Execute()
+
+	var exit *ExitError
+	require.ErrorAs(t, err, &exit)
+	assert.Equal(t, ExitBrokenPipe, exit.Code)
+	data, err := os.ReadFile(plan)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "photo.jpg")
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/schema"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [NAME]",
+	Short: "Print the JSON Schema of a JSON document dup-finder writes",
+	Long: fmt.Sprintf(`schema prints the JSON Schema (draft 2020-12) of a document dup-finder
+writes, so scripts and configuration management can validate it before use:
+
+  results      saved results (--format json)
+  plan         keep/remove plans (report simulate --format json)
+  summary      result totals (report summary --format json)
+  fingerprint  directory tree digests (fingerprint --output)
+
+Each document carries a "version" field. It is raised only for changes that
+would break existing readers; new optional fields keep the version.
+Without NAME, the available schemas are listed (%s).`, strings.Join(schema.Names, ", ")),
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSchema,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		for _, name := range schema.Names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	data, err := schema.Get(args[0])
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	_, err = os.Stdout.Write(data)
+	if isBrokenPipe(err) {
+		return brokenPipeError(cmd)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+	"github.com/Sho2010/dup-finder/internal/session"
+)
+
+// The session resume continues and its state file, unset for a fresh run
+var (
+	resumed     *session.State
+	resumedPath string
+)
+
+// sessionPath returns where the state of this run is saved if it is
+// interrupted: the file of the session being resumed, or one named after
+// the run ID
+func sessionPath() (string, error) {
+	if resumedPath != "" {
+		return resumedPath, nil
+	}
+	dir, err := session.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, runid.ID()+".json"), nil
+}
+
+// sessionName returns what to pass to resume for the state of this run:
+// the run ID of a session kept in the sessions directory, or its path
+func sessionName() string {
+	if resumedPath == "" {
+		return runid.ID()
+	}
+	if dir, err := session.Dir(); err == nil && filepath.Dir(resumedPath) == dir {
+		return strings.TrimSuffix(filepath.Base(resumedPath), ".json")
+	}
+	return resumedPath
+}
+
+// newSession starts the state of this run at stage, keeping the command
+// line of the session being resumed
+func newSession(stage string) *session.State {
+	args := os.Args[1:]
+	if resumed != nil {
+		args = resumed.Args
+	}
+	workDir, _ := os.Getwd()
+	return &session.State{
+		Version:     session.Version,
+		GeneratedAt: time.Now().UTC(),
+		RunID:       runid.ID(),
+		Stage:       stage,
+		Args:        args,
+		WorkDir:     workDir,
+	}
+}
+
+// saveScanSession saves the hashes computed by a run cut short, so resume
+// can run it again without hashing those files a second time
+func saveScanSession(comparisons []models.PairComparison) {
+	state := newSession(session.StageScan)
+	if resumed != nil && resumed.Stage == session.StageScan {
+		state.Hashes = resumed.Hashes
+	}
+	finder.StoreHashes(comparisons, state)
+
+	path, err := sessionPath()
+	if err == nil {
+		err = session.Save(state, path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot save session: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Session saved to %s; continue with: dup-finder resume %s\n", path, sessionName())
+}
+
+// runSession runs an interactive session over comparisons, saving its
+// progress before each prompt so an interrupted session can be resumed
+// with the deletions it had chosen. The state is removed once the session
+// ends.
+func runSession(comparisons []models.PairComparison, opts models.ScanOptions) (*models.SessionSummary, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	state := newSession(session.StageReview)
+	state.Directories = opts.Directories
+	state.Labels = opts.Labels
+	state.Comparisons = comparisons
+	warned := false
+	opts.Checkpoint = func(progress models.SessionProgress) {
+		state.GeneratedAt = time.Now().UTC()
+		state.Progress = &progress
+		if err := session.Save(state, path); err != nil && !warned {
+			fmt.Fprintf(os.Stderr, "Warning: progress will not be resumable: %v\n", err)
+			warned = true
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "If the session is interrupted, continue it with: dup-finder resume %s\n", sessionName())
+	summary, err := interactive.RunInteractiveSession(comparisons, opts)
+	if err != nil {
+		if state.Progress != nil && !warned {
+			fmt.Fprintf(os.Stderr, "Session saved to %s; continue with: dup-finder resume %s\n", path, sessionName())
+		}
+		return nil, err
+	}
+	if err := session.Remove(path); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// finishSession shows the outcome of an interactive session and saves
+// what it leaves for later: notes, learned preferences and deferred sets
+func finishSession(summary *models.SessionSummary, labels output.Labels) error {
+	interactive.DisplaySummary(*summary)
+	if err := saveAnnotations(summary.Notes); err != nil {
+		return err
+	}
+
+	if rememberPrefs {
+		if err := savePreferences(summary.Learned); err != nil {
+			return err
+		}
+	}
+
+	if len(summary.Deferred) > 0 {
+		if err := saveSessionQueue(summary.Deferred, labels, summary.SkipRules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// streamNDJSON compares each pair and writes its matches to w as
+// NDJSON while the comparison runs, without holding the results in memory.
+// It returns the number of duplicates written. Once ctx is done the
+// remaining matches are written without being hashed.
+func streamNDJSON(ctx context.Context, w io.Writer, f *finder.Finder, pairs [][2]string, allFiles map[string][]models.FileInfo, snapshots []string) (int, error) {
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	records := output.NewNDJSONWriter(out)
+
+	dropped, found := 0, 0
+	for i, pair := range pairs {
+		if f.LimitReached() {
+			fmt.Fprintf(stderr, "Match limit reached: skipped %d remaining pair(s)\n", len(pairs)-i)
+			break
+		}
+
+		comparison, err := f.StreamPair(ctx, allFiles[pair[0]], allFiles[pair[1]], func(batch []models.FileMatch) error {
+			if len(snapshots) > 0 {
+				batchPair := []models.PairComparison{{Dir1: pair[0], Dir2: pair[1], Matches: batch}}
+				dropped += f.DropSnapshotCopies(ctx, batchPair, snapshots)
+				batch = batchPair[0].Matches
+			}
+			for _, match := range batch {
+				if match.IsDuplicate() {
+					found++
+				}
+			}
+			if err := records.Write(pair[0], pair[1], batch); err != nil {
+				return err
+			}
+			return out.Flush()
+		})
+		if err != nil {
+			return found, fmt.Errorf("cannot write results: %w", err)
+		}
+		if comparison.Truncated {
+			fmt.Fprintf(stderr, "Further matches between %s and %s omitted: match limit reached\n", pair[0], pair[1])
+		}
+	}
+
+	if dropped > 0 {
+		fmt.Fprintf(stderr, "Ignored %d file(s) unchanged across snapshots\n", dropped)
+	}
+	return found, nil
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cmd
+
+import "os"
+
+// ttyWidth cannot query the terminal on this platform; COLUMNS is used
+// when set
+func ttyWidth(f *os.File) int {
+	return 0
+}
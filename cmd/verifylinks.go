@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/linkcheck"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+var verifyLinksCmd = &cobra.Command{
+	Use:   "verify-links DIR",
+	Short: "Check that symlinks and hardlinks in a deduplicated tree are intact",
+	Long: `verify-links walks DIR, such as a tree deduplicated earlier by
+replacing copies with links, and checks every symlink to a file and every
+file with several hardlinks.
+
+A symlink whose target is missing or unreachable is reported as broken. A
+linked file whose contents no longer match the hash recorded in the hash
+index (--index) is reported as diverged: editing one path of a hardlink, or
+the target of a symlink, changes every path sharing it. Files not in the
+index are only checked for reachability.
+
+Exits 1 if any link is broken or diverged.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerifyLinks,
+}
+
+func init() {
+	verifyLinksCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	verifyLinksCmd.Flags().BoolVar(&noIndex, "no-index", false, "Only check that links resolve, without comparing hashes")
+	rootCmd.AddCommand(verifyLinksCmd)
+}
+
+func runVerifyLinks(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	dir := args[0]
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("cannot access %s: %w", dir, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	idx, err := openIndex()
+	if err != nil {
+		return err
+	}
+	report, err := linkcheck.Check(dir, idx)
+	if err != nil {
+		return fmt.Errorf("error walking %s: %w", dir, err)
+	}
+
+	fmt.Printf("Checked %s symlink(s) and %s hardlinked file(s) sharing %s inode(s)\n",
+		output.FormatCount(report.Symlinks), output.FormatCount(report.Hardlinks), output.FormatCount(report.HardlinkGroups))
+	if len(report.Issues) == 0 {
+		fmt.Println("All links intact")
+		return nil
+	}
+
+	fmt.Println()
+	for _, issue := range report.Issues {
+		if issue.Target != "" {
+			fmt.Printf("  ✗ %s: %s -> %s\n     %s\n", issue.Kind, issue.Path, issue.Target, issue.Detail)
+		} else {
+			fmt.Printf("  ✗ %s: %s\n     %s\n", issue.Kind, issue.Path, issue.Detail)
+		}
+	}
+	return fmt.Errorf("%d link(s) broken or diverged", len(report.Issues))
+}
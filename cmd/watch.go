@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/interactive"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+	"github.com/Sho2010/dup-finder/internal/watch"
+)
+
+var (
+	watchCmd = &cobra.Command{
+		Use:   "watch DIR...",
+		Short: "Report files added to the directories that duplicate one already there",
+		Long: `watch follows DIR, such as a downloads or ingest folder, until interrupted,
+and reports each file added or changed that has the content of a file already
+under one of the DIRs. Files present when watch starts are not reported.
+
+The DIRs are scanned again when file system events show a change, and every
+--interval in any case, to catch changes events miss, as on network volumes.
+Where events are unavailable, such as past the system's limit on watched
+directories, watch falls back to scanning every --interval alone; lower it
+then. A file is considered once it keeps the same size and modification time
+over two scans, so downloads and copies in progress are not read
+half-written. Only files that share a size with a new one are hashed, and
+hashes are cached in the hash index, so a restart does not read the
+directories again. Hidden files and directories are skipped unless
+--include-hidden is given.
+
+With --action stage, each new duplicate is added to the plan file --save, in
+the format of scan --save, and nothing is touched: review the plan, then carry
+it out with dup-finder apply, which checks every file again first. Remove the
+plan once applied; watch starts a new one with the next duplicate. With
+--action quarantine, each new duplicate is moved to --quarantine as it is
+found, without confirmation, to be brought back with dup-finder restore; the
+copy already there is kept. The --quarantine directory is never watched, even
+when it lies under a DIR.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runWatch,
+	}
+
+	watchInterval time.Duration
+	watchAction   string
+)
+
+// watchSettle is how long watch lets changes gather before scanning, and
+// waits before scanning again for files still being written
+const watchSettle = 2 * time.Second
+
+// Actions on the duplicates watch finds
+const (
+	watchReport     = "report"
+	watchStage      = "stage"
+	watchQuarantine = "quarantine"
+)
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Minute, "Time between full scans of the directories, which catch changes missed by file system events")
+	watchCmd.Flags().StringVar(&watchAction, "action", watchReport, "What to do with a new duplicate: report, stage to add it to the plan --save for apply, or quarantine to move it to --quarantine")
+	watchCmd.Flags().StringVar(&planPath, "save", "", "With --action stage, the plan file to add new duplicates to")
+	watchCmd.Flags().StringVar(&quarantineDir, "quarantine", "", "With --action quarantine, the directory new duplicates are moved into; undo with: dup-finder restore DIR")
+	watchCmd.Flags().Int64VarP(&minSize, "min-size", "m", 0, "Minimum file size in bytes to consider")
+	watchCmd.Flags().StringSliceVarP(&extensions, "extensions", "e", []string{}, "File extensions to consider, with or without the dot, case-insensitive (e.g., .zip,avi,MP4)")
+	watchCmd.Flags().BoolVar(&skipHidden, "skip-hidden", true, "Skip hidden files and directories: dot names like .cache on Unix, the hidden attribute on Windows")
+	watchCmd.Flags().BoolVar(&includeHidden, "include-hidden", false, "Watch hidden files and directories too (same as --skip-hidden=false)")
+	watchCmd.Flags().StringArrayVar(&excludeDirs, "exclude-dir", []string{}, "Directory name (or name glob) to skip at any depth, e.g. RAW (repeatable)")
+	watchCmd.Flags().StringVar(&indexPath, "index", "", "Hash index file (default: user cache directory)")
+	watchCmd.Flags().BoolVar(&noIndex, "no-index", false, "Do not read or update the hash index")
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", watchInterval)
+	}
+	dir := quarantinePath()
+	switch watchAction {
+	case watchReport:
+	case watchStage:
+		if planPath == "" {
+			return fmt.Errorf("--action stage needs --save PLAN")
+		}
+	case watchQuarantine:
+		if dir == "" {
+			return fmt.Errorf("--action quarantine needs --quarantine DIR")
+		}
+	default:
+		return fmt.Errorf("invalid --action %q: must be report, stage or quarantine", watchAction)
+	}
+	exts, warnings, err := scanner.NormalizeExtensions(extensions)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err := scanner.ValidateDirPatterns(excludeDirs); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	opts := models.ScanOptions{
+		Directories:   normalizeRoots(args),
+		Recursive:     true,
+		MinSize:       minSize,
+		Extensions:    exts,
+		MaxDepth:      -1,
+		ExcludeDirs:   scanner.ExcludeDirs(excludeDirs, false, false),
+		SkipHidden:    skipHidden && !includeHidden,
+		SkipCacheDirs: true,
+		UseDupignore:  true,
+		NumWorkers:    runtime.NumCPU(),
+	}
+	for _, root := range opts.Directories {
+		if _, err := os.Stat(root); err != nil {
+			return fmt.Errorf("cannot access %s: %w", root, err)
+		}
+	}
+	idx, err := openIndex()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var cache finder.HashCache
+	if idx != nil {
+		cache = idx
+	}
+	tree := watch.New(cache)
+	// Follow events before the first scan, so no change falls in between
+	var changes <-chan struct{}
+	var eventErrs <-chan error
+	events, err := watch.NewEvents(opts.Directories, watchSkipDir(opts, dir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot follow file system events (%v); scanning every %s instead\n", err, watchInterval)
+	} else {
+		defer events.Close()
+		changes, eventErrs = events.Changes(), events.Errors()
+	}
+	files, err := watchScan(ctx, opts, dir)
+	if err != nil {
+		return err
+	}
+	tree.Seed(files)
+	fmt.Fprintf(os.Stderr, "Watching %s file(s) under %d director(ies); Ctrl+C to stop\n",
+		output.FormatCount(tree.Len()), len(opts.Directories))
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	// settle is armed while a scan is due after changes
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	armed := false
+	found := 0
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "\nStopped watching: %s new duplicate(s) found\n", output.FormatCount(found))
+			return nil
+		case err := <-eventErrs:
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		case <-changes:
+			if !armed {
+				settle.Reset(watchSettle)
+				armed = true
+			}
+			continue
+		case <-settle.C:
+		case <-ticker.C:
+		}
+		settle.Stop()
+		armed = false
+
+		found += watchOnce(ctx, opts, tree, idx, dir)
+
+		// Files still being written may raise no further event once done
+		if tree.Pending() > 0 {
+			settle.Reset(watchSettle)
+			armed = true
+		}
+	}
+}
+
+// watchOnce scans the watched directories again and acts on the new
+// duplicates, returning how many were found. Files under quarantine, the
+// --quarantine directory, are left out, so files moved there are never
+// found again.
+func watchOnce(ctx context.Context, opts models.ScanOptions, tree *watch.Tree, idx *index.Index, quarantine string) int {
+	files, err := watchScan(ctx, opts, quarantine)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		return 0
+	}
+	duplicates, errs := tree.Update(files)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, d := range duplicates {
+		fmt.Printf("%s  duplicate of %s  (%s)\n", d.File.Path, d.Original.Path, output.FormatSize(d.File.Size))
+	}
+	if watchAction == watchStage && len(duplicates) > 0 {
+		stageDuplicates(duplicates, planPath)
+	}
+	if watchAction == watchQuarantine && len(duplicates) > 0 {
+		quarantineDuplicates(tree, duplicates, quarantine)
+	}
+	saveWatchIndex(idx)
+	return len(duplicates)
+}
+
+// watchSkipDir returns whether watch leaves out the directory at a path,
+// as the scans do: hidden ones unless asked for, --exclude-dir, and the
+// quarantine directory
+func watchSkipDir(opts models.ScanOptions, quarantine string) func(path string) bool {
+	return func(path string) bool {
+		if quarantine != "" && policy.IsUnder(path, quarantine) {
+			return true
+		}
+		name := filepath.Base(path)
+		if opts.SkipHidden && strings.HasPrefix(name, ".") {
+			return true
+		}
+		for _, pattern := range opts.ExcludeDirs {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// watchScan lists the files under the watched directories but not under
+// leave. A directory that cannot be scanned, such as one removed and not
+// yet recreated, only warns.
+func watchScan(ctx context.Context, opts models.ScanOptions, leave string) ([]models.FileInfo, error) {
+	allFiles, err := scanner.NewScanner(opts).ScanAll(ctx)
+	if err != nil {
+		var rootsErr *scanner.RootsError
+		if !errors.As(err, &rootsErr) {
+			return nil, err
+		}
+		for _, root := range rootsErr.Roots() {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", rootsErr.Failed[root])
+		}
+	}
+	var files []models.FileInfo
+	for _, dir := range opts.Directories {
+		for _, file := range allFiles[dir] {
+			if leave == "" || !policy.IsUnder(file.Path, leave) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// stageDuplicates adds the new duplicates to the plan at path, to remove
+// with apply once reviewed, keeping the copies that were there first
+func stageDuplicates(duplicates []watch.Duplicate, path string) {
+	// Absolute paths, so apply works from any directory
+	abs := func(file models.FileInfo) models.FileInfo {
+		if a, err := filepath.Abs(file.Path); err == nil {
+			file.Path = a
+		}
+		if a, err := filepath.Abs(file.Directory); err == nil {
+			file.Directory = a
+		}
+		return file
+	}
+	decisions := make([]policy.Decision, len(duplicates))
+	for i, d := range duplicates {
+		keep, remove := abs(d.Original), abs(d.File)
+		decisions[i] = policy.Decision{
+			Keep:     keep,
+			Remove:   []models.FileInfo{remove},
+			Verified: true,
+			Reasons: []policy.Reason{
+				{Path: keep.Path, Action: policy.ActionKeep, Rule: policy.RuleFirstSeen, Detail: "present before watch found the copy"},
+				{Path: remove.Path, Action: policy.ActionRemove, Rule: policy.RuleFirstSeen, Detail: "added while watching"},
+			},
+		}
+	}
+	if err := report.AppendPlan(path, decisions); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	fmt.Printf("  staged %s file(s) in %s; review it, then run: dup-finder apply %s\n", output.FormatCount(len(decisions)), path, path)
+}
+
+// quarantineDuplicates moves the new duplicates to dir, keeping the copies
+// that were there first
+func quarantineDuplicates(tree *watch.Tree, duplicates []watch.Duplicate, dir string) {
+	actions := make([]models.UserAction, len(duplicates))
+	for i, d := range duplicates {
+		actions[i] = models.UserAction{Action: "delete", KeepFile: d.Original.Path, DeleteFile: d.File.Path, Reason: "watch"}
+	}
+	results, err := interactive.RemoveFiles(actions, models.ScanOptions{Quarantine: dir, NumWorkers: runtime.NumCPU()})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	for _, result := range results {
+		if !result.Success {
+			fmt.Fprintf(os.Stderr, "  ✗ %s\n     Error: %v\n", result.Path, result.Error)
+			continue
+		}
+		tree.Forget(result.Path)
+		fmt.Printf("  moved %s to %s\n", result.Path, dir)
+	}
+}
+
+// saveWatchIndex writes the hashes computed so far to the hash index, so
+// they survive watch being stopped
+func saveWatchIndex(idx *index.Index) {
+	if idx == nil {
+		return
+	}
+	if err := idx.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
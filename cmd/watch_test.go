@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/watch"
+)
+
+func TestWatchLeavesOutQuarantine(t *testing.T) {
+	root := t.TempDir()
+	quarantine := filepath.Join(root, "q")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("photo"), 0644))
+	action := watchAction
+	watchAction = watchQuarantine
+	t.Cleanup(func() { watchAction = action })
+
+	ctx := context.Background()
+	opts := models.ScanOptions{Directories: []string{root}, Recursive: true, MaxDepth: -1, NumWorkers: runtime.NumCPU()}
+	tree := watch.New(nil)
+	files, err := watchScan(ctx, opts, quarantine)
+	require.NoError(t, err)
+	tree.Seed(files)
+
+	copied := filepath.Join(root, "copy.txt")
+	require.NoError(t, os.WriteFile(copied, []byte("photo"), 0644))
+	mtime := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(copied, mtime, mtime))
+	// The copy is quarantined once it settled over two scans
+	assert.Equal(t, 0, watchOnce(ctx, opts, tree, nil, quarantine))
+	assert.Equal(t, 1, watchOnce(ctx, opts, tree, nil, quarantine))
+	assert.NoFileExists(t, copied)
+
+	// and never found again in the quarantine under the root
+	assert.Equal(t, 0, watchOnce(ctx, opts, tree, nil, quarantine))
+	assert.Equal(t, 0, watchOnce(ctx, opts, tree, nil, quarantine))
+	var moved []string
+	require.NoError(t, filepath.WalkDir(quarantine, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() && filepath.Base(path) == "copy.txt" {
+			moved = append(moved, path)
+		}
+		return err
+	}))
+	require.Len(t, moved, 1)
+	assert.NotContains(t, moved[0][len(quarantine):], "/q/")
+
+	assert.True(t, watchSkipDir(opts, quarantine)(quarantine))
+	assert.False(t, watchSkipDir(opts, quarantine)(filepath.Join(root, "sub")))
+}
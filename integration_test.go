@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,12 +40,12 @@ func TestTwoDirectoryComparison(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Verify results
 	assert.Len(t, comparison.Matches, 1)
@@ -73,7 +76,7 @@ func TestThreeDirectoryComparison(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Generate pairs
@@ -83,7 +86,7 @@ func TestThreeDirectoryComparison(t *testing.T) {
 	// Compare all pairs
 	f := finder.NewFinder(opts)
 	for _, pair := range pairs {
-		comparison := f.ComparePair(allFiles[pair[0]], allFiles[pair[1]])
+		comparison := f.ComparePair(context.Background(), allFiles[pair[0]], allFiles[pair[1]])
 		assert.Len(t, comparison.Matches, 1)
 		assert.Equal(t, "file.txt", comparison.Matches[0].Filename)
 	}
@@ -111,12 +114,12 @@ func TestPairwiseWithHashComparison(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Compare with hash
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Verify results
 	require.Len(t, comparison.Matches, 1)
@@ -145,12 +148,12 @@ func TestNoCommonFiles(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Verify no matches
 	assert.Len(t, comparison.Matches, 0)
@@ -178,11 +181,11 @@ func TestSameNameDifferentContent(t *testing.T) {
 
 	// Scan and compare
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Verify: same name but different hash
 	require.Len(t, comparison.Matches, 1)
@@ -215,7 +218,7 @@ func TestExtensionFilter(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Verify only .txt files are scanned
@@ -224,7 +227,7 @@ func TestExtensionFilter(t *testing.T) {
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Should only find .txt file
 	require.Len(t, comparison.Matches, 1)
@@ -257,6 +260,221 @@ func TestGeneratePairs(t *testing.T) {
 	}
 }
 
+func TestExcludePairs(t *testing.T) {
+	pairs := finder.GeneratePairs([]string{"a", "b", "c"})
+
+	// Skip entries match regardless of order
+	result := finder.ExcludePairs(pairs, [][2]string{{"c", "a"}})
+	assert.Equal(t, [][2]string{{"a", "b"}, {"b", "c"}}, result)
+
+	// No skip entries leaves pairs untouched
+	assert.Equal(t, pairs, finder.ExcludePairs(pairs, nil))
+
+	// Paths are compared after cleaning
+	result = finder.ExcludePairs(pairs, [][2]string{{"./a", "b/"}})
+	assert.Len(t, result, 2)
+	assert.NotContains(t, result, [2]string{"a", "b"})
+}
+
+func TestPairsWith(t *testing.T) {
+	pairs := finder.GeneratePairs([]string{"a", "b", "catalog:nas.json"})
+
+	// Directories are compared with the baseline only
+	result := finder.PairsWith(pairs, "catalog:nas.json")
+	assert.Equal(t, [][2]string{{"a", "catalog:nas.json"}, {"b", "catalog:nas.json"}}, result)
+}
+
+// TestSkipCacheDirs verifies that package-manager and cache directories are pruned
+func TestSkipCacheDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+
+	files := []string{
+		filepath.Join(dir1, "keep.txt"),
+		filepath.Join(dir1, "node_modules", "lib", "index.js"),
+		filepath.Join(dir1, ".cache", "thumb.png"),
+		filepath.Join(dir1, "rust", "Cargo.toml"),
+		filepath.Join(dir1, "rust", "target", "debug", "app"),
+		filepath.Join(dir1, "photos", "target", "shot.jpg"), // no project file: kept
+	}
+	for _, file := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+	}
+
+	opts := models.ScanOptions{
+		Directories:   []string{dir1},
+		Recursive:     true,
+		MaxDepth:      -1,
+		NumWorkers:    runtime.NumCPU(),
+		SkipCacheDirs: true,
+	}
+
+	allFiles, err := scanner.NewScanner(opts).ScanAll(context.Background())
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range allFiles[dir1] {
+		names = append(names, filepath.Base(file.Path))
+	}
+	assert.ElementsMatch(t, []string{"keep.txt", "Cargo.toml", "shot.jpg"}, names)
+
+	// Disabling the option scans everything
+	opts.SkipCacheDirs = false
+	allFiles, err = scanner.NewScanner(opts).ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, allFiles[dir1], len(files))
+}
+
+func TestExcludeDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+
+	files := []string{
+		filepath.Join(dir1, "a.jpg"),
+		filepath.Join(dir1, "RAW", "a.cr2"),
+		filepath.Join(dir1, "2023", "RAW", "b.cr2"),
+		filepath.Join(dir1, "2023", "b.jpg"),
+		filepath.Join(dir1, "Lib.photoslibrary", "c.jpg"),
+		filepath.Join(dir1, "RAWS", "d.jpg"), // name differs: kept
+	}
+	for _, file := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+	}
+
+	opts := models.ScanOptions{
+		Directories: []string{dir1},
+		Recursive:   true,
+		MaxDepth:    -1,
+		NumWorkers:  runtime.NumCPU(),
+		ExcludeDirs: []string{"RAW", "*.photoslibrary"},
+	}
+
+	allFiles, err := scanner.NewScanner(opts).ScanAll(context.Background())
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range allFiles[dir1] {
+		names = append(names, filepath.Base(file.Path))
+	}
+	assert.ElementsMatch(t, []string{"a.jpg", "b.jpg", "d.jpg"}, names)
+
+	assert.NoError(t, scanner.ValidateDirPatterns([]string{"RAW", "*.tmp"}))
+	assert.Error(t, scanner.ValidateDirPatterns([]string{"photos/RAW"}))
+	assert.Error(t, scanner.ValidateDirPatterns([]string{"[RAW"}))
+}
+
+func TestDefaultExcludeDirs(t *testing.T) {
+	dir1 := filepath.Join(t.TempDir(), "dir1")
+	files := []string{
+		filepath.Join(dir1, "a.jpg"),
+		filepath.Join(dir1, ".git", "objects", "pack"),
+		filepath.Join(dir1, "$RECYCLE.BIN", "S-1-5-21", "a.jpg"),
+		filepath.Join(dir1, "System Volume Information", "tracking.log"),
+		filepath.Join(dir1, ".Trash-1000", "files", "a.jpg"),
+	}
+	for _, file := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+	}
+
+	scan := func(excludes []string) int {
+		opts := models.ScanOptions{
+			Directories: []string{dir1},
+			Recursive:   true,
+			MaxDepth:    -1,
+			NumWorkers:  runtime.NumCPU(),
+			ExcludeDirs: excludes,
+		}
+		allFiles, err := scanner.NewScanner(opts).ScanAll(context.Background())
+		require.NoError(t, err)
+		return len(allFiles[dir1])
+	}
+	assert.Equal(t, 1, scan(scanner.ExcludeDirs(nil, false, false)))
+	assert.Equal(t, len(files), scan(scanner.ExcludeDirs(nil, true, false)))
+	assert.Equal(t, []string{"RAW"}, scanner.ExcludeDirs([]string{"RAW"}, true, false))
+	assert.Contains(t, scanner.ExcludeDirs([]string{"RAW"}, false, false), ".git")
+	// --include-cache-dirs alone brings node_modules back
+	assert.Contains(t, scanner.ExcludeDirs(nil, false, false), "node_modules")
+	assert.NotContains(t, scanner.ExcludeDirs(nil, false, true), "node_modules")
+	assert.Contains(t, scanner.ExcludeDirs(nil, false, true), ".git")
+}
+
+func TestSoftLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+	require.NoError(t, os.MkdirAll(dir1, 0755))
+	for i := 0; i < 6; i++ {
+		file := filepath.Join(dir1, fmt.Sprintf("file%d.txt", i))
+		require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+	}
+
+	opts := models.ScanOptions{
+		Directories:  []string{dir1},
+		Recursive:    true,
+		MaxDepth:     -1,
+		NumWorkers:   runtime.NumCPU(),
+		SoftMaxFiles: 2,
+	}
+
+	scan := func(action scanner.SoftLimitAction) (*scanner.Scanner, map[string][]models.FileInfo, int, error) {
+		calls := 0
+		s := scanner.NewScanner(opts)
+		s.OnSoftLimit(func(files int, elapsed time.Duration) scanner.SoftLimitAction {
+			calls++
+			return action
+		})
+		allFiles, err := s.ScanAll(context.Background())
+		return s, allFiles, calls, err
+	}
+
+	// Continuing asks again after every further limit's worth of files
+	s, allFiles, calls, err := scan(scanner.SoftLimitContinue)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, allFiles[dir1], 6)
+	assert.False(t, s.Partial())
+
+	// Proceeding with partial results keeps what was found before the limit
+	s, allFiles, calls, err = scan(scanner.SoftLimitPartial)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Len(t, allFiles[dir1], 2)
+	assert.True(t, s.Partial())
+
+	_, _, _, err = scan(scanner.SoftLimitAbort)
+	assert.ErrorIs(t, err, scanner.ErrScanAborted)
+}
+
+func TestInvalidWorkerCounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+	require.NoError(t, os.MkdirAll(dir1, 0755))
+	for i := 0; i < 20; i++ {
+		file := filepath.Join(dir1, fmt.Sprintf("file%d.txt", i))
+		require.NoError(t, os.WriteFile(file, []byte("content"), 0644))
+	}
+
+	for _, workers := range []int{0, -1, -64, 1, 1000} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			opts := models.ScanOptions{
+				Directories: []string{dir1},
+				Recursive:   true,
+				MaxDepth:    -1,
+				NumWorkers:  workers,
+			}
+			allFiles, err := scanner.NewScanner(opts).ScanAll(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, allFiles[dir1], 20)
+		})
+	}
+
+	assert.Equal(t, runtime.NumCPU(), scanner.EffectiveWorkers(0))
+	assert.Equal(t, runtime.NumCPU(), scanner.EffectiveWorkers(-5))
+	assert.Equal(t, 3, scanner.EffectiveWorkers(3))
+}
+
 // TestCrossPlatformPaths verifies that the tool correctly handles paths
 // on all platforms (Unix forward slashes, Windows backslashes)
 func TestCrossPlatformPaths(t *testing.T) {
@@ -285,7 +503,7 @@ func TestCrossPlatformPaths(t *testing.T) {
 
 	// Scan directories
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Verify files were found
@@ -302,7 +520,7 @@ func TestCrossPlatformPaths(t *testing.T) {
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Should find the test.txt in both subdirectories
 	require.Len(t, comparison.Matches, 1)
@@ -336,12 +554,12 @@ func TestWindowsStylePaths(t *testing.T) {
 	}
 
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Should find file with spaces in name
 	require.Len(t, comparison.Matches, 1)
@@ -383,7 +601,7 @@ func TestUnicodeFilenames(t *testing.T) {
 	}
 
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Should find all files
@@ -392,7 +610,7 @@ func TestUnicodeFilenames(t *testing.T) {
 
 	// Compare
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir2])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir2])
 
 	// Should find all Unicode named files
 	assert.Len(t, comparison.Matches, len(unicodeNames))
@@ -420,7 +638,7 @@ func TestCaseInsensitiveExtensions(t *testing.T) {
 	}
 
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
+	allFiles, err := s.ScanAll(context.Background())
 	require.NoError(t, err)
 
 	// Should find all .txt files regardless of case
@@ -451,8 +669,12 @@ func TestSkipNonExistentDirectories(t *testing.T) {
 	}
 
 	s := scanner.NewScanner(opts)
-	allFiles, err := s.ScanAll()
-	require.NoError(t, err)
+	allFiles, err := s.ScanAll(context.Background())
+
+	// The missing directory fails on its own, without stopping the others
+	var rootsErr *scanner.RootsError
+	require.ErrorAs(t, err, &rootsErr)
+	assert.Equal(t, []string{dir2}, rootsErr.Roots())
 
 	// Should have files from dir1 and dir3, but not dir2
 	assert.Equal(t, 1, len(allFiles[dir1]))
@@ -466,7 +688,7 @@ func TestSkipNonExistentDirectories(t *testing.T) {
 
 	// Compare the valid pair
 	f := finder.NewFinder(opts)
-	comparison := f.ComparePair(allFiles[dir1], allFiles[dir3])
+	comparison := f.ComparePair(context.Background(), allFiles[dir1], allFiles[dir3])
 
 	// Should find the matching file
 	require.Len(t, comparison.Matches, 1)
@@ -0,0 +1,144 @@
+// Package annotations keeps the notes attached to duplicate sets during
+// interactive review, such as "check later" or "keep both: different
+// projects", so later runs can show them with the same files
+package annotations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// Version is the current version of the annotations file
+const Version = 1
+
+// Note is a note on the two files of a duplicate set. The paths are
+// stored sorted, so the note is found whichever directory pair reports
+// the files.
+type Note struct {
+	Paths   [2]string `json:"paths"`
+	Text    string    `json:"note"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store holds the notes of every annotated set
+type Store struct {
+	Version int    `json:"version"`
+	Notes   []Note `json:"notes"`
+}
+
+// key returns the paths of a set in stored order
+func key(path1, path2 string) [2]string {
+	if path2 < path1 {
+		path1, path2 = path2, path1
+	}
+	return [2]string{path1, path2}
+}
+
+// Load reads the store at path. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Store{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Store
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid annotations %s: %w", path, err)
+	}
+	if s.Version > Version {
+		return nil, fmt.Errorf("annotations %s have version %d; this build supports up to %d", path, s.Version, Version)
+	}
+	return &s, nil
+}
+
+// Save writes the store to path, creating its directory, and replacing any
+// earlier file only once the new one is complete
+func (s *Store) Save(path string) error {
+	s.Version = Version
+	sort.Slice(s.Notes, func(i, j int) bool {
+		a, b := s.Notes[i].Paths, s.Notes[j].Paths
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return a[1] < b[1]
+	})
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create annotations directory: %w", err)
+	}
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot save annotations: %w", err)
+	}
+	return file.Commit()
+}
+
+// Get returns the note on the set of path1 and path2, or ""
+func (s *Store) Get(path1, path2 string) string {
+	k := key(path1, path2)
+	for _, note := range s.Notes {
+		if note.Paths == k {
+			return note.Text
+		}
+	}
+	return ""
+}
+
+// Set attaches text to the set of path1 and path2, replacing any earlier
+// note. Empty text removes the note.
+func (s *Store) Set(path1, path2, text string, now time.Time) {
+	k := key(path1, path2)
+	for i, note := range s.Notes {
+		if note.Paths == k {
+			if text == "" {
+				s.Notes = append(s.Notes[:i], s.Notes[i+1:]...)
+			} else {
+				s.Notes[i].Text, s.Notes[i].Updated = text, now
+			}
+			return
+		}
+	}
+	if text != "" {
+		s.Notes = append(s.Notes, Note{Paths: k, Text: text, Updated: now})
+	}
+}
+
+// Apply copies stored notes onto the matches of comparisons and returns
+// how many matches have one
+func (s *Store) Apply(comparisons []models.PairComparison) int {
+	if len(s.Notes) == 0 {
+		return 0
+	}
+	notes := make(map[[2]string]string, len(s.Notes))
+	for _, note := range s.Notes {
+		notes[note.Paths] = note.Text
+	}
+	annotated := 0
+	for i := range comparisons {
+		for j := range comparisons[i].Matches {
+			match := &comparisons[i].Matches[j]
+			if text, ok := notes[key(match.File1.Path, match.File2.Path)]; ok {
+				match.Note = text
+				annotated++
+			}
+		}
+	}
+	return annotated
+}
@@ -0,0 +1,65 @@
+package annotations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestStore_SetGet(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &Store{}
+	s.Set("/b/x.jpg", "/a/x.jpg", "check later", now)
+	assert.Equal(t, "check later", s.Get("/a/x.jpg", "/b/x.jpg"))
+	assert.Equal(t, [2]string{"/a/x.jpg", "/b/x.jpg"}, s.Notes[0].Paths)
+
+	s.Set("/a/x.jpg", "/b/x.jpg", "keep both: different projects", now)
+	require.Len(t, s.Notes, 1)
+	assert.Equal(t, "keep both: different projects", s.Get("/b/x.jpg", "/a/x.jpg"))
+
+	s.Set("/a/x.jpg", "/b/x.jpg", "", now)
+	assert.Empty(t, s.Notes)
+	assert.Empty(t, s.Get("/a/x.jpg", "/b/x.jpg"))
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "annotations.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, s.Notes)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.Set("/a/y.jpg", "/b/y.jpg", "check later", now)
+	s.Set("/a/x.jpg", "/b/x.jpg", "keep both", now)
+	require.NoError(t, s.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, Version, loaded.Version)
+	require.Len(t, loaded.Notes, 2)
+	assert.Equal(t, "/a/x.jpg", loaded.Notes[0].Paths[0])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "notes": []}`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "version 99")
+}
+
+func TestStore_Apply(t *testing.T) {
+	s := &Store{}
+	s.Set("/a/x.jpg", "/b/x.jpg", "check later", time.Now())
+	comparisons := []models.PairComparison{{Dir1: "/b", Dir2: "/a", Matches: []models.FileMatch{
+		{Filename: "x.jpg", File1: models.FileInfo{Path: "/b/x.jpg"}, File2: models.FileInfo{Path: "/a/x.jpg"}},
+		{Filename: "y.jpg", File1: models.FileInfo{Path: "/b/y.jpg"}, File2: models.FileInfo{Path: "/a/y.jpg"}},
+	}}}
+
+	assert.Equal(t, 1, s.Apply(comparisons))
+	assert.Equal(t, "check later", comparisons[0].Matches[0].Note)
+	assert.Empty(t, comparisons[0].Matches[1].Note)
+}
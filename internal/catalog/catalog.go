@@ -0,0 +1,295 @@
+// Package catalog reads listings of the files on a volume, such as an
+// external drive, so its contents can be compared without attaching it.
+// A listing can be the JSON written by tree -J -s, a CSV exported from
+// Everything, a hash list written by dup-finder index export, a
+// manifest written by dup-finder manifest create, or a checksum file
+// written by md5sum, sha1sum, sha256sum or an SFV tool.
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/checksum"
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Prefix marks a directory argument that names a catalog file
+const Prefix = "catalog:"
+
+// Parse returns the catalog file named by a directory argument, if the
+// argument is one
+func Parse(arg string) (string, bool) {
+	file, ok := strings.CutPrefix(arg, Prefix)
+	return file, ok && file != ""
+}
+
+// Formats a catalog can be in, detected from its contents
+const (
+	FormatTree       = "tree"       // JSON written by tree -J -s
+	FormatEverything = "everything" // CSV exported from Everything or es.exe
+	FormatIndex      = "index"      // TSV written by dup-finder index export --format tsv
+	FormatManifest   = "manifest"   // JSON written by dup-finder manifest create
+	FormatChecksum   = "checksum"   // Output of md5sum, sha1sum or sha256sum
+	FormatSFV        = "sfv"        // SFV file of CRC32s, detected by its .sfv extension
+)
+
+// Catalog is the listing of a volume's files
+type Catalog struct {
+	Name   string // Directory argument naming the catalog, used as its root directory
+	Format string
+	Root   string            // Directory the listing was taken of, "" if the format does not record it
+	Files  []models.FileInfo // Regular files, with slash-separated paths, marked offline and with Directory set to Name
+}
+
+// Load reads the catalog file at file, detecting its format
+func Load(file string) (*Catalog, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read catalog: %w", err)
+	}
+	c := &Catalog{Name: Prefix + file}
+	var entries []entry
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	switch {
+	case checksum.IsSFV(file):
+		c.Format = FormatSFV
+		c.Root, entries, err = readChecksums(file, trimmed, checksum.ReadSFV)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		c.Format = FormatManifest
+		c.Root, entries, err = readManifest(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		c.Format = FormatTree
+		c.Root, entries, err = readTree(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("path\t")):
+		c.Format = FormatIndex
+		entries, err = readIndex(trimmed)
+	case checksum.IsSumList(trimmed):
+		c.Format = FormatChecksum
+		c.Root, entries, err = readChecksums(file, trimmed, checksum.ReadSums)
+	default:
+		c.Format = FormatEverything
+		entries, err = readEverything(trimmed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s catalog %s: %w", c.Format, file, err)
+	}
+
+	for _, e := range entries {
+		c.Files = append(c.Files, models.FileInfo{
+			Path:        e.path,
+			Directory:   c.Name,
+			Size:        e.size,
+			ModTime:     e.modTime,
+			Hash:        e.hash,
+			Offline:     true,
+			Checksum:    e.checksum,
+			SizeUnknown: e.sizeUnknown,
+		})
+	}
+	return c, nil
+}
+
+// Write writes files as a catalog in the TSV hash list format of index
+// export, which Load reads back. Paths are made absolute, and files without
+// a hash are left out.
+func Write(w io.Writer, files []models.FileInfo) error {
+	idx := index.New("")
+	for _, file := range files {
+		idx.Store(file)
+	}
+	return index.Export(w, idx, index.FormatTSV)
+}
+
+// entry is one file of a catalog
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time // Zero if the catalog does not record it
+	hash    string    // "" if the catalog does not record it
+
+	checksum    string // Digest of a checksum file, as algorithm:hex
+	sizeUnknown bool   // The catalog records no size
+}
+
+// readChecksums reads a checksum file with read. Its paths are relative to
+// the directory holding it, which is the catalog's root.
+func readChecksums(file string, data []byte, read func([]byte) ([]checksum.Entry, error)) (string, []entry, error) {
+	sums, err := read(data)
+	if err != nil {
+		return "", nil, err
+	}
+	root := filepath.ToSlash(filepath.Dir(file))
+	var entries []entry
+	for _, sum := range sums {
+		p := filepath.ToSlash(sum.Path)
+		if !path.IsAbs(p) && !filepath.IsAbs(sum.Path) {
+			p = path.Join(root, p)
+		}
+		entries = append(entries, entry{path: p, checksum: checksum.Value(sum.Algorithm, sum.Digest), sizeUnknown: true})
+	}
+	return root, entries, nil
+}
+
+// treeNode is a file or directory in the output of tree -J
+type treeNode struct {
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	Size     *int64     `json:"size"`
+	Time     string     `json:"time"`
+	Contents []treeNode `json:"contents"`
+}
+
+// readTree reads the output of tree -J -s, whose first element is the
+// directory listed
+func readTree(data []byte) (string, []entry, error) {
+	var nodes []treeNode
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return "", nil, err
+	}
+	if len(nodes) == 0 || nodes[0].Type != "directory" {
+		return "", nil, fmt.Errorf("no directory listed")
+	}
+
+	var entries []entry
+	var walk func(dir string, nodes []treeNode) error
+	walk = func(dir string, nodes []treeNode) error {
+		for _, node := range nodes {
+			p := path.Join(dir, node.Name)
+			switch node.Type {
+			case "directory":
+				if err := walk(p, node.Contents); err != nil {
+					return err
+				}
+			case "file":
+				if node.Size == nil {
+					return fmt.Errorf("%s has no size: list the volume with tree -J -s", p)
+				}
+				entries = append(entries, entry{path: p, size: *node.Size, modTime: parseTime(node.Time)})
+			}
+		}
+		return nil
+	}
+	root := nodes[0].Name
+	if err := walk(root, nodes[0].Contents); err != nil {
+		return "", nil, err
+	}
+	return root, entries, nil
+}
+
+// readIndex reads a hash list written by index export in TSV
+func readIndex(data []byte) ([]entry, error) {
+	idx := index.New("")
+	if _, err := index.Import(bytes.NewReader(data), idx, index.FormatTSV); err != nil {
+		return nil, err
+	}
+	var entries []entry
+	idx.Each(func(p string, e index.Entry) {
+		entries = append(entries, entry{path: p, size: e.Size, modTime: e.ModTime, hash: e.Hash})
+	})
+	return entries, nil
+}
+
+// readEverything reads a CSV export of Everything or es.exe. Files are
+// named by a Filename column holding the full path, or by Name and Path
+// columns, and their paths are written with forward slashes like those of
+// other catalogs; folders, listed without a size or with the directory
+// attribute, are skipped.
+func readEverything(data []byte) ([]entry, error) {
+	reader := csv.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("no header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	_, hasFilename := columns["filename"]
+	_, hasName := columns["name"]
+	if _, ok := columns["size"]; !ok || (!hasFilename && !hasName) {
+		return nil, fmt.Errorf("expected a Size column and a Filename or Name column")
+	}
+
+	var entries []entry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isFolder(field(record, "attributes")) || field(record, "size") == "" {
+			continue
+		}
+		size, err := strconv.ParseInt(field(record, "size"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid size: %w", line, err)
+		}
+		p := field(record, "filename")
+		if !hasFilename {
+			p = field(record, "path") + `\` + field(record, "name")
+		}
+		p = path.Clean(strings.ReplaceAll(p, `\`, "/"))
+		entries = append(entries, entry{path: p, size: size, modTime: parseTime(field(record, "date modified"))})
+	}
+	return entries, nil
+}
+
+// isFolder reports whether Everything's attributes, as letters or as the
+// numeric Windows attributes, include the directory attribute
+func isFolder(attributes string) bool {
+	if n, err := strconv.ParseUint(attributes, 10, 32); err == nil {
+		return n&0x10 != 0
+	}
+	return strings.ContainsRune(attributes, 'D')
+}
+
+// filetimeUnixSeconds is the Unix time of the start of Windows FILETIME,
+// which counts 100ns units from 1601
+const filetimeUnixSeconds = -11644473600
+
+// timeLayouts are the timestamp formats catalogs are read with, besides
+// FILETIME and Unix seconds
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006/01/02 15:04:05", "2006/01/02 15:04"}
+
+// parseTime reads a modification time in any of the forms catalogs record
+// it: a Windows FILETIME as written by es.exe, Unix seconds, or a date. It
+// returns the zero time for anything else.
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// FILETIMEs of any date since 1970 are far beyond Unix seconds
+		if n > 1e15 {
+			return time.Unix(n/10_000_000+filetimeUnixSeconds, n%10_000_000*100).UTC()
+		}
+		return time.Unix(n, 0).UTC()
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
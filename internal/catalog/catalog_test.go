@@ -0,0 +1,205 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func writeCatalog(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestParse(t *testing.T) {
+	file, ok := Parse("catalog:backup.json")
+	assert.True(t, ok)
+	assert.Equal(t, "backup.json", file)
+
+	_, ok = Parse("/photos")
+	assert.False(t, ok)
+	_, ok = Parse("catalog:")
+	assert.False(t, ok)
+}
+
+func TestLoad_Tree(t *testing.T) {
+	path := writeCatalog(t, "backup.json", `[
+  {"type":"directory","name":"/mnt/backup","contents":[
+    {"type":"directory","name":"photos","contents":[
+      {"type":"file","name":"beach.jpg","size":2048,"time":"2024-01-15 14:30:00"}
+    ]},
+    {"type":"link","name":"latest","target":"photos"},
+    {"type":"file","name":"notes.txt","size":12}
+  ]},
+  {"type":"report","directories":1,"files":2}
+]`)
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatTree, c.Format)
+	assert.Equal(t, "catalog:"+path, c.Name)
+	assert.Equal(t, "/mnt/backup", c.Root)
+	require.Len(t, c.Files, 2)
+
+	beach := c.Files[0]
+	assert.Equal(t, "/mnt/backup/photos/beach.jpg", beach.Path)
+	assert.Equal(t, c.Name, beach.Directory)
+	assert.Equal(t, int64(2048), beach.Size)
+	assert.Equal(t, time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC), beach.ModTime)
+	assert.True(t, beach.Offline)
+	assert.True(t, c.Files[1].ModTime.IsZero())
+}
+
+func TestLoad_TreeWithoutSizes(t *testing.T) {
+	path := writeCatalog(t, "backup.json", `[{"type":"directory","name":".","contents":[{"type":"file","name":"a.jpg"}]}]`)
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "tree -J -s")
+}
+
+func TestLoad_Everything(t *testing.T) {
+	path := writeCatalog(t, "backup.csv", "\ufeffFilename,Size,Date Modified,Attributes\r\n"+
+		"\"E:\\Backup\",,133498026000000000,16\r\n"+
+		"\"E:\\Backup\\beach.jpg\",2048,133498026000000000,32\r\n"+
+		"\"E:\\Backup\\My Notes, 2024.txt\",12,,A\r\n")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatEverything, c.Format)
+	assert.Empty(t, c.Root)
+	require.Len(t, c.Files, 2, "folders are skipped")
+	assert.Equal(t, "E:/Backup/beach.jpg", c.Files[0].Path)
+	assert.Equal(t, int64(2048), c.Files[0].Size)
+	assert.Equal(t, time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC), c.Files[0].ModTime)
+	assert.Equal(t, "E:/Backup/My Notes, 2024.txt", c.Files[1].Path)
+}
+
+func TestLoad_EverythingNameAndPath(t *testing.T) {
+	path := writeCatalog(t, "backup.csv", "Name,Path,Size\nbeach.jpg,E:\\Backup\\,2048\n")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, c.Files, 1)
+	assert.Equal(t, "E:/Backup/beach.jpg", c.Files[0].Path)
+}
+
+func TestLoad_EverythingWithoutSizes(t *testing.T) {
+	path := writeCatalog(t, "backup.csv", "Filename,Date Modified\nE:\\beach.jpg,\n")
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "Size column")
+}
+
+func TestLoad_Index(t *testing.T) {
+	path := writeCatalog(t, "index.tsv", "path\tsize\tmtime\txxh64\n"+
+		"/mnt/backup/beach.jpg\t2048\t2024-01-15T14:30:00Z\t0123456789abcdef\n")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatIndex, c.Format)
+	require.Len(t, c.Files, 1)
+	assert.Equal(t, "/mnt/backup/beach.jpg", c.Files[0].Path)
+	assert.Equal(t, "0123456789abcdef", c.Files[0].Hash)
+	assert.True(t, c.Files[0].Offline)
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "cannot read catalog")
+}
+
+func TestWriteLoad(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	files := []models.FileInfo{
+		{Path: filepath.Join(dir, "beach.jpg"), Size: 2048, ModTime: mtime, Hash: "0123456789abcdef"},
+		{Path: filepath.Join(dir, "unreadable.jpg"), Size: 10, ModTime: mtime},
+	}
+	path := filepath.Join(dir, "volume1.catalog")
+	out, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, Write(out, files))
+	require.NoError(t, out.Close())
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatIndex, c.Format)
+	require.Len(t, c.Files, 1, "files without a hash are left out")
+	assert.Equal(t, files[0].Path, c.Files[0].Path)
+	assert.Equal(t, files[0].Hash, c.Files[0].Hash)
+	assert.True(t, mtime.Equal(c.Files[0].ModTime))
+}
+
+func TestWriteManifestLoad(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	files := []models.FileInfo{
+		{Path: filepath.Join(dir, "photos", "beach.jpg"), Size: 2048, ModTime: mtime, Hash: "0123456789abcdef"},
+		{Path: filepath.Join(dir, "unreadable.jpg"), Size: 10, ModTime: mtime},
+	}
+	path := filepath.Join(t.TempDir(), "nas.json")
+	out, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, WriteManifest(out, dir, files))
+	require.NoError(t, out.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"path": "photos/beach.jpg"`, "paths are recorded relative to the root")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatManifest, c.Format)
+	assert.Equal(t, filepath.ToSlash(dir), c.Root)
+	require.Len(t, c.Files, 1, "files without a hash are left out")
+	assert.Equal(t, filepath.ToSlash(dir)+"/photos/beach.jpg", c.Files[0].Path)
+	assert.Equal(t, int64(2048), c.Files[0].Size)
+	assert.Equal(t, "0123456789abcdef", c.Files[0].Hash)
+	assert.True(t, mtime.Equal(c.Files[0].ModTime))
+	assert.True(t, c.Files[0].Offline)
+}
+
+func TestLoad_ManifestTooNew(t *testing.T) {
+	path := writeCatalog(t, "nas.json", `{"version": 2, "root": "/mnt/nas", "files": []}`)
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "manifest version 2 is newer")
+}
+
+func TestLoad_Checksums(t *testing.T) {
+	path := writeCatalog(t, "SHA256SUMS", "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  photos/beach.jpg\n"+
+		"5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03 */mnt/other/notes.txt\n")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatChecksum, c.Format)
+	root := filepath.ToSlash(filepath.Dir(path))
+	assert.Equal(t, root, c.Root)
+	require.Len(t, c.Files, 2)
+	assert.Equal(t, root+"/photos/beach.jpg", c.Files[0].Path, "paths are relative to the checksum file")
+	assert.Equal(t, "/mnt/other/notes.txt", c.Files[1].Path)
+	assert.Equal(t, "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", c.Files[0].Checksum)
+	assert.True(t, c.Files[0].SizeUnknown)
+	assert.True(t, c.Files[0].Offline)
+}
+
+func TestLoad_SFV(t *testing.T) {
+	path := writeCatalog(t, "album.sfv", "; tracks\r\n01 Intro.flac 363A3020\r\n")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatSFV, c.Format)
+	require.Len(t, c.Files, 1)
+	assert.Equal(t, filepath.ToSlash(filepath.Dir(path))+"/01 Intro.flac", c.Files[0].Path)
+	assert.Equal(t, "crc32:363a3020", c.Files[0].Checksum)
+
+	_, err = Load(writeCatalog(t, "broken.sfv", "01 Intro.flac\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// ManifestVersion is the current version of the manifest document
+const ManifestVersion = 1
+
+// Manifest is the JSON listing written by manifest create: the files under
+// a root by relative path, with their sizes, modification times and hashes.
+// It is a catalog that keeps its root apart from the paths, so a baseline
+// such as a NAS can be compared against wherever it is mounted.
+type Manifest struct {
+	Version     int             `json:"version"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	RunID       string          `json:"run_id"`
+	Root        string          `json:"root"` // The directory listed, as given
+	Files       []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is one file of a manifest
+type ManifestEntry struct {
+	Path  string    `json:"path"` // Slash-separated, relative to the root
+	Size  int64     `json:"size"`
+	MTime time.Time `json:"mtime"`
+	Hash  string    `json:"hash"` // xxHash of the contents
+}
+
+// WriteManifest writes the files under root as a manifest, which Load
+// reads back. Files without a hash are left out.
+func WriteManifest(w io.Writer, root string, files []models.FileInfo) error {
+	m := Manifest{Version: ManifestVersion, GeneratedAt: time.Now().UTC(), RunID: runid.ID(), Root: root, Files: []ManifestEntry{}}
+	for _, file := range files {
+		if file.Hash == "" {
+			continue
+		}
+		rel, err := filepath.Rel(root, file.Path)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, ManifestEntry{Path: filepath.ToSlash(rel), Size: file.Size, MTime: file.ModTime.UTC(), Hash: file.Hash})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// readManifest reads a manifest, whose paths are joined to its root like
+// those of other catalogs
+func readManifest(data []byte) (string, []entry, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", nil, err
+	}
+	if m.Version > ManifestVersion {
+		return "", nil, fmt.Errorf("manifest version %d is newer than this dup-finder understands (%d)", m.Version, ManifestVersion)
+	}
+	root := filepath.ToSlash(m.Root)
+	var entries []entry
+	for _, e := range m.Files {
+		entries = append(entries, entry{path: path.Join(root, e.Path), size: e.Size, modTime: e.MTime, hash: e.Hash})
+	}
+	return root, entries, nil
+}
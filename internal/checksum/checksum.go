@@ -0,0 +1,267 @@
+// Package checksum reads and writes the checksum files of standard
+// verification tools, md5sum, sha1sum and sha256sum lists and SFV files,
+// and computes the digests they record, so dup-finder can compare against
+// them and hand its results back to those tools
+package checksum
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// Digest algorithms of checksum files
+const (
+	MD5    = "md5"
+	SHA1   = "sha1"
+	SHA256 = "sha256"
+	CRC32  = "crc32" // Recorded by SFV files
+)
+
+// Formats checksum files are written in
+const (
+	FormatMD5Sum    = "md5sum"
+	FormatSHA256Sum = "sha256sum"
+	FormatSFV       = "sfv"
+)
+
+// Formats lists the formats checksum files are written in
+var Formats = []string{FormatMD5Sum, FormatSHA256Sum, FormatSFV}
+
+// Algorithm returns the digest algorithm of a format, or "" if format is
+// not a checksum format
+func Algorithm(format string) string {
+	switch format {
+	case FormatMD5Sum:
+		return MD5
+	case FormatSHA256Sum:
+		return SHA256
+	case FormatSFV:
+		return CRC32
+	}
+	return ""
+}
+
+// Entry is a file listed in a checksum file
+type Entry struct {
+	Path      string
+	Algorithm string
+	Digest    string // Lowercase hex
+}
+
+// Value joins an algorithm and a digest as FileInfo.Checksum records
+// them, e.g. sha256:9f86d081...
+func Value(algorithm, digest string) string {
+	return algorithm + ":" + digest
+}
+
+// Split separates a FileInfo.Checksum value into its algorithm and digest
+func Split(value string) (algorithm, digest string) {
+	algorithm, digest, _ = strings.Cut(value, ":")
+	return algorithm, digest
+}
+
+// digestLengths maps the hex length of a digest to its algorithm, as sum
+// lists do not name it
+var digestLengths = map[int]string{32: MD5, 40: SHA1, 64: SHA256}
+
+// bsdTags maps the tags of BSD-style lines, SHA256 (path) = digest, to
+// their algorithms
+var bsdTags = map[string]string{"MD5": MD5, "SHA1": SHA1, "SHA256": SHA256}
+
+// IsSFV reports whether the file named name holds an SFV listing, judged
+// by its extension
+func IsSFV(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".sfv")
+}
+
+// IsSumList reports whether data looks like the output of md5sum, sha1sum
+// or sha256sum: its first line that is not blank or a comment lists a file
+func IsSumList(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, err := parseSumLine(scanner.Text())
+		return err == nil
+	}
+	return false
+}
+
+// ReadSums reads the output of md5sum, sha1sum or sha256sum, in the GNU
+// form (digest, then the path after two spaces or a space and an asterisk)
+// or the BSD form written with --tag. Blank lines and # comments are
+// skipped.
+func ReadSums(data []byte) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if trimmed := strings.TrimSpace(text); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		entry, err := parseSumLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseSumLine parses one line of a sum list
+func parseSumLine(line string) (Entry, error) {
+	// Lines whose path holds a backslash or newline are escaped
+	escaped := strings.HasPrefix(line, `\`)
+	if escaped {
+		line = line[1:]
+	}
+
+	var entry Entry
+	if tag, rest, ok := strings.Cut(line, " ("); ok && bsdTags[tag] != "" {
+		i := strings.LastIndex(rest, ") = ")
+		if i < 0 {
+			return Entry{}, fmt.Errorf("malformed %s line", tag)
+		}
+		entry = Entry{Path: rest[:i], Algorithm: bsdTags[tag], Digest: rest[i+4:]}
+	} else {
+		digest, path, ok := strings.Cut(line, " ")
+		if !ok || (!strings.HasPrefix(path, " ") && !strings.HasPrefix(path, "*")) {
+			return Entry{}, fmt.Errorf("expected a digest and a path")
+		}
+		entry = Entry{Path: path[1:], Algorithm: digestLengths[len(digest)], Digest: digest}
+	}
+	if escaped {
+		entry.Path = Unescape(entry.Path)
+	}
+	if err := entry.check(); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// ReadSFV reads an SFV file: a path and its CRC32 per line, separated by
+// the last space. Blank lines and ; comments are skipped.
+func ReadSFV(data []byte) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";") {
+			continue
+		}
+		i := strings.LastIndexAny(text, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected a path and a CRC32", line)
+		}
+		entry := Entry{Path: strings.TrimSpace(text[:i]), Algorithm: CRC32, Digest: text[i+1:]}
+		if err := entry.check(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// check validates the digest of e, lowercasing it
+func (e *Entry) check() error {
+	if e.Algorithm == "" {
+		return fmt.Errorf("digest of unknown length %d", len(e.Digest))
+	}
+	raw, err := hex.DecodeString(e.Digest)
+	if err != nil || len(raw) != newHash(e.Algorithm).Size() {
+		return fmt.Errorf("invalid %s digest %q", e.Algorithm, e.Digest)
+	}
+	if e.Path == "" {
+		return fmt.Errorf("no path")
+	}
+	e.Digest = strings.ToLower(e.Digest)
+	return nil
+}
+
+// Write writes entries as a checksum file in format, which the verifying
+// tool reads back: md5sum -c, sha256sum -c, or an SFV checker
+func Write(w io.Writer, format string, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	if format == FormatSFV {
+		fmt.Fprintln(bw, "; Written by dup-finder")
+	}
+	for _, e := range entries {
+		if format == FormatSFV {
+			fmt.Fprintf(bw, "%s %s\n", e.Path, strings.ToUpper(e.Digest))
+			continue
+		}
+		WriteSumLine(bw, e.Digest, e.Path)
+	}
+	return bw.Flush()
+}
+
+// Sum returns the digest of the file at path with algorithm, in lowercase
+// hex
+func Sum(path, algorithm string) (string, error) {
+	h := newHash(algorithm)
+	if h == nil {
+		return "", fmt.Errorf("unknown checksum algorithm %q", algorithm)
+	}
+	file, err := fsio.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newHash returns a hash of algorithm, or nil for an unknown one
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case MD5:
+		return md5.New()
+	case SHA1:
+		return sha1.New()
+	case SHA256:
+		return sha256.New()
+	case CRC32:
+		return crc32.NewIEEE()
+	}
+	return nil
+}
+
+// WriteSumLine writes one line of a GNU sum list, escaping a path that
+// holds backslashes or newlines and marking the line with a backslash
+func WriteSumLine(w io.Writer, digest, path string) {
+	if strings.ContainsAny(path, "\\\n") {
+		fmt.Fprintf(w, "\\%s  %s\n", digest, escape(path))
+		return
+	}
+	fmt.Fprintf(w, "%s  %s\n", digest, path)
+}
+
+// escape applies the escaping of GNU sum lists to a path holding
+// backslashes or newlines
+func escape(path string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(path)
+}
+
+// Unescape reverses escape, for the path of a line starting with a
+// backslash
+func Unescape(path string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(path)
+}
@@ -0,0 +1,106 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helloMD5    = "b1946ac92492d2347c6235b4d2611184"
+	helloSHA256 = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	helloCRC32  = "363a3020"
+)
+
+func TestReadSums(t *testing.T) {
+	entries, err := ReadSums([]byte("# made with sha256sum\n" +
+		strings.ToUpper(helloSHA256) + "  photos/beach.jpg\n" +
+		"\n" +
+		helloMD5 + " *notes.txt\r\n" +
+		"SHA256 (with (parens).txt) = " + helloSHA256 + "\n" +
+		"\\" + helloMD5 + "  back\\\\slash\\nnewline\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Path: "photos/beach.jpg", Algorithm: SHA256, Digest: helloSHA256},
+		{Path: "notes.txt", Algorithm: MD5, Digest: helloMD5},
+		{Path: "with (parens).txt", Algorithm: SHA256, Digest: helloSHA256},
+		{Path: "back\\slash\nnewline", Algorithm: MD5, Digest: helloMD5},
+	}, entries)
+}
+
+func TestReadSums_Malformed(t *testing.T) {
+	for _, line := range []string{
+		"abc  short.txt",
+		helloMD5 + "nospace.txt",
+		strings.Repeat("z", 32) + "  nothex.txt",
+		helloMD5 + "  ",
+	} {
+		_, err := ReadSums([]byte(line + "\n"))
+		assert.Error(t, err, line)
+	}
+}
+
+func TestReadSFV(t *testing.T) {
+	entries, err := ReadSFV([]byte("; Generated by an SFV tool\n" +
+		"disc 1/track 01.flac 363A3020\n" +
+		"\n" +
+		"notes.txt\t" + helloCRC32 + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Path: "disc 1/track 01.flac", Algorithm: CRC32, Digest: helloCRC32},
+		{Path: "notes.txt", Algorithm: CRC32, Digest: helloCRC32},
+	}, entries)
+
+	_, err = ReadSFV([]byte("notes.txt 12345\n"))
+	assert.Error(t, err)
+}
+
+func TestIsSumList(t *testing.T) {
+	assert.True(t, IsSumList([]byte("# comment\n"+helloSHA256+"  a.txt\n")))
+	assert.False(t, IsSumList([]byte("Filename,Size\n/a.txt,12\n")))
+	assert.False(t, IsSumList(nil))
+	assert.True(t, IsSFV("album.SFV"))
+	assert.False(t, IsSFV("album.sha256"))
+}
+
+func TestWriteReadBack(t *testing.T) {
+	entries := []Entry{
+		{Path: "/a/hello.txt", Algorithm: SHA256, Digest: helloSHA256},
+		{Path: "/a/odd\\name.txt", Algorithm: SHA256, Digest: helloSHA256},
+	}
+	var sums strings.Builder
+	require.NoError(t, Write(&sums, FormatSHA256Sum, entries))
+	assert.Equal(t, helloSHA256+"  /a/hello.txt\n\\"+helloSHA256+"  /a/odd\\\\name.txt\n", sums.String())
+	read, err := ReadSums([]byte(sums.String()))
+	require.NoError(t, err)
+	assert.Equal(t, entries, read)
+
+	var sfv strings.Builder
+	require.NoError(t, Write(&sfv, FormatSFV, []Entry{{Path: "/a/hello.txt", Algorithm: CRC32, Digest: helloCRC32}}))
+	assert.Equal(t, "; Written by dup-finder\n/a/hello.txt 363A3020\n", sfv.String())
+}
+
+func TestSum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0644))
+
+	for algorithm, want := range map[string]string{MD5: helloMD5, SHA256: helloSHA256, CRC32: helloCRC32} {
+		digest, err := Sum(path, algorithm)
+		require.NoError(t, err)
+		assert.Equal(t, want, digest, algorithm)
+	}
+	_, err := Sum(path, "blake3")
+	assert.Error(t, err)
+}
+
+func TestValueSplit(t *testing.T) {
+	algorithm, digest := Split(Value(MD5, helloMD5))
+	assert.Equal(t, MD5, algorithm)
+	assert.Equal(t, helloMD5, digest)
+	assert.Equal(t, SHA256, Algorithm(FormatSHA256Sum))
+	assert.Equal(t, "", Algorithm("csv"))
+}
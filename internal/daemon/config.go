@@ -0,0 +1,194 @@
+// Package daemon runs scans on cron schedules, keeps their reports and
+// sends notifications about what they found
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/schedule"
+)
+
+// ConfigVersion is the current version of the daemon configuration
+const ConfigVersion = 1
+
+// When notifications are sent
+const (
+	NotifyAlways     = "always"     // After every run
+	NotifyDuplicates = "duplicates" // When duplicates are found or a run fails
+	NotifyFailure    = "failure"    // When a run fails or is incomplete
+)
+
+// NotifyModes lists the accepted values of Notify.On
+var NotifyModes = []string{NotifyAlways, NotifyDuplicates, NotifyFailure}
+
+// Config is the daemon configuration: the scans to run and where their
+// reports go
+type Config struct {
+	Version     int     `json:"version"`
+	Reports     string  `json:"reports,omitempty"`      // Directory of the reports, one subdirectory per job (default: reports next to the configuration)
+	KeepReports int     `json:"keep_reports,omitempty"` // Reports kept per job, the oldest removed first (0 keeps all)
+	Notify      *Notify `json:"notify,omitempty"`       // Notification of every job that has none of its own
+	Jobs        []Job   `json:"jobs"`
+}
+
+// Job is a scan run on a schedule
+type Job struct {
+	Name     string   `json:"name"`             // Names the job's reports directory and notifications
+	Schedule string   `json:"schedule"`         // Cron expression, e.g. "0 3 * * *" for 3:00 every day
+	Args     []string `json:"args"`             // Command line of the scan without the program name, e.g. ["-H", "/mnt/a", "/mnt/b"]
+	Notify   *Notify  `json:"notify,omitempty"` // Replaces the configuration's notification for this job
+
+	schedule *schedule.Schedule
+}
+
+// Notify says how and when to tell about a run
+type Notify struct {
+	On      string   `json:"on,omitempty"`      // always, duplicates (the default) or failure
+	Command []string `json:"command,omitempty"` // Program and arguments, run with the outcome in its environment and as JSON on stdin
+	Webhook string   `json:"webhook,omitempty"` // URL the outcome is POSTed to as JSON
+}
+
+// DefaultConfigPath returns where the daemon looks for its configuration,
+// inside the user config directory
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "daemon.json"), nil
+}
+
+// LoadConfig reads and checks the configuration at path. A relative
+// Reports directory is taken relative to the configuration's directory.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read daemon configuration: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse daemon configuration %s: %w", path, err)
+	}
+	if cfg.Version > ConfigVersion {
+		return nil, fmt.Errorf("daemon configuration %s uses version %d, newer than supported version %d", path, cfg.Version, ConfigVersion)
+	}
+	if cfg.Version == 0 || cfg.Jobs == nil {
+		return nil, fmt.Errorf("%s is not a daemon configuration", path)
+	}
+
+	if cfg.Reports == "" {
+		cfg.Reports = "reports"
+	}
+	if !filepath.IsAbs(cfg.Reports) {
+		cfg.Reports = filepath.Join(filepath.Dir(path), cfg.Reports)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid daemon configuration %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// reservedFlags are set by the daemon on every scan, or would make a scan
+// wait for input that never comes
+var reservedFlags = []string{"--format", "--output", "-o", "--run-id", "--interactive", "-i"}
+
+func (cfg *Config) validate() error {
+	if cfg.KeepReports < 0 {
+		return fmt.Errorf("keep_reports must not be negative")
+	}
+	if len(cfg.Jobs) == 0 {
+		return fmt.Errorf("no jobs")
+	}
+	if err := cfg.Notify.validate(); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Jobs {
+		job := &cfg.Jobs[i]
+		if !validName(job.Name) {
+			return fmt.Errorf("job %d: invalid name %q: use letters, digits and . _ -", i+1, job.Name)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("job %s: name used twice", job.Name)
+		}
+		names[job.Name] = true
+
+		s, err := schedule.Parse(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		if s.Next(time.Now()).IsZero() {
+			return fmt.Errorf("job %s: schedule %q never fires", job.Name, job.Schedule)
+		}
+		job.schedule = s
+
+		if len(job.Args) == 0 {
+			return fmt.Errorf("job %s: no args: give the directories to scan", job.Name)
+		}
+		for _, arg := range job.Args {
+			for _, flag := range reservedFlags {
+				if arg == flag || strings.HasPrefix(arg, flag+"=") || (len(flag) == 2 && strings.HasPrefix(arg, flag) && !strings.HasPrefix(arg, "--")) {
+					return fmt.Errorf("job %s: %s is set by the daemon or needs a terminal, and cannot be in args", job.Name, flag)
+				}
+			}
+		}
+		if err := job.Notify.validate(); err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+	}
+	return nil
+}
+
+// Next returns when job runs next after t
+func (job *Job) Next(t time.Time) time.Time {
+	return job.schedule.Next(t)
+}
+
+// notification returns the notification of job, its own or cfg's
+func (cfg *Config) notification(job *Job) *Notify {
+	if job.Notify != nil {
+		return job.Notify
+	}
+	return cfg.Notify
+}
+
+func (n *Notify) validate() error {
+	if n == nil {
+		return nil
+	}
+	switch n.On {
+	case "", NotifyAlways, NotifyDuplicates, NotifyFailure:
+	default:
+		return fmt.Errorf("invalid notify.on %q: must be one of %s", n.On, strings.Join(NotifyModes, ", "))
+	}
+	if len(n.Command) == 0 && n.Webhook == "" {
+		return fmt.Errorf("notify needs a command or a webhook")
+	}
+	if n.Webhook != "" && !strings.HasPrefix(n.Webhook, "http://") && !strings.HasPrefix(n.Webhook, "https://") {
+		return fmt.Errorf("invalid notify.webhook %q: expected an http or https URL", n.Webhook)
+	}
+	return nil
+}
+
+// validName reports whether name can name a directory and a notification
+// without quoting
+func validName(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
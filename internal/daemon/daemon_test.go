@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/report"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "daemon.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `{
+		"version": 1,
+		"keep_reports": 5,
+		"notify": {"webhook": "https://hooks.example.com/x"},
+		"jobs": [
+			{"name": "nas", "schedule": "0 3 * * *", "args": ["-H", "/mnt/a", "/mnt/b"]},
+			{"name": "photos", "schedule": "@weekly", "args": ["/p", "/q"], "notify": {"on": "always", "command": ["true"]}}
+		]
+	}`)
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "reports"), cfg.Reports)
+	require.Len(t, cfg.Jobs, 2)
+	assert.Equal(t, "https://hooks.example.com/x", cfg.notification(&cfg.Jobs[0]).Webhook)
+	assert.Equal(t, NotifyAlways, cfg.notification(&cfg.Jobs[1]).On)
+	assert.False(t, cfg.Jobs[0].Next(time.Now()).IsZero())
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	job := func(fields string) string {
+		return `{"version": 1, "jobs": [` + fields + `]}`
+	}
+	for content, msg := range map[string]string{
+		`{"version": 1}`:                             "not a daemon configuration",
+		`{"version": 2, "jobs": []}`:                 "newer than supported",
+		`{"version": 1, "jobs": []}`:                 "no jobs",
+		`{"version": 1, "jobs": [}`:                  "cannot parse",
+		job(`{"name": "a/b", "schedule": "@daily"}`): "invalid name",
+		job(`{"name": "a", "schedule": "@daily", "args": ["/x"]}, {"name": "a", "schedule": "@daily", "args": ["/x"]}`): "name used twice",
+		job(`{"name": "a", "schedule": "0 3 * *", "args": ["/x"]}`):                                                     "expected 5 fields",
+		job(`{"name": "a", "schedule": "0 0 31 2 *", "args": ["/x"]}`):                                                  "never fires",
+		job(`{"name": "a", "schedule": "@daily"}`):                                                                      "no args",
+		job(`{"name": "a", "schedule": "@daily", "args": ["-i", "/x", "/y"]}`):                                          "-i is set by the daemon",
+		job(`{"name": "a", "schedule": "@daily", "args": ["--output=r.txt", "/x", "/y"]}`):                              "--output is set by the daemon",
+		job(`{"name": "a", "schedule": "@daily", "args": ["/x"], "notify": {"on": "never", "command": ["true"]}}`):      "invalid notify.on",
+		job(`{"name": "a", "schedule": "@daily", "args": ["/x"], "notify": {}}`):                                        "needs a command or a webhook",
+		job(`{"name": "a", "schedule": "@daily", "args": ["/x"], "notify": {"webhook": "ftp://x"}}`):                    "invalid notify.webhook",
+	} {
+		_, err := LoadConfig(writeConfig(t, content))
+		assert.ErrorContains(t, err, msg, content)
+	}
+}
+
+func TestNotify_Wants(t *testing.T) {
+	statuses := []string{StatusDuplicates, StatusClean, StatusPartial, StatusFailed}
+	for on, want := range map[string][]bool{
+		"":               {true, false, true, true},
+		NotifyAlways:     {true, true, true, true},
+		NotifyDuplicates: {true, false, true, true},
+		NotifyFailure:    {false, false, true, true},
+	} {
+		for i, status := range statuses {
+			n := &Notify{On: on}
+			assert.Equal(t, want[i], n.wants(Outcome{Status: status}), "%s %s", on, status)
+		}
+	}
+}
+
+func TestNotify_Webhook(t *testing.T) {
+	var received Outcome
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	o := Outcome{Job: "nas", Status: StatusDuplicates, Summary: &report.Summary{Sets: 3}, Text: "dup-finder nas: 3 duplicate set(s)"}
+	require.NoError(t, (&Notify{Webhook: server.URL}).Send(context.Background(), o))
+	assert.Equal(t, o.Text, received.Text)
+	assert.Equal(t, 3, received.Summary.Sets)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	assert.ErrorContains(t, (&Notify{Webhook: failing.URL}).Send(context.Background(), o), "403")
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	ids := []string{"20240301T030000Z-aaaaaaaa", "20240302T030000Z-bbbbbbbb", "20240303T030000Z-cccccccc"}
+	for _, id := range ids {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, id+".log"), nil, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, id+".json"), nil, 0644))
+	}
+	require.NoError(t, prune(dir, 2))
+
+	left, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, left, 4)
+	assert.NoFileExists(t, filepath.Join(dir, ids[0]+".json"))
+	assert.NoError(t, prune(dir, 2))
+}
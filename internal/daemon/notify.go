@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// notifyTimeout bounds each notification, so a hung command or webhook
+// does not hold up the next scan
+const notifyTimeout = time.Minute
+
+// wants reports whether n is sent for outcome o
+func (n *Notify) wants(o Outcome) bool {
+	switch n.On {
+	case NotifyAlways:
+		return true
+	case NotifyFailure:
+		return o.Status == StatusFailed || o.Status == StatusPartial
+	default:
+		return o.Status != StatusClean
+	}
+}
+
+// Send tells about o: it runs the command, with the outcome in DUP_FINDER_*
+// environment variables and as JSON on stdin, and POSTs the outcome as JSON
+// to the webhook. Its "text" field makes it a message for chat webhooks
+// such as Slack's.
+func (n *Notify) Send(ctx context.Context, o Outcome) error {
+	body, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	var errs []error
+	if len(n.Command) > 0 {
+		if err := runCommand(ctx, n.Command, o, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if n.Webhook != "" {
+		if err := postWebhook(ctx, n.Webhook, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runCommand(ctx context.Context, command []string, o Outcome, body []byte) error {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"DUP_FINDER_JOB="+o.Job,
+		"DUP_FINDER_RUN_ID="+o.RunID,
+		"DUP_FINDER_STATUS="+o.Status,
+		"DUP_FINDER_EXIT_CODE="+strconv.Itoa(o.ExitCode),
+		"DUP_FINDER_REPORT="+o.Report,
+		"DUP_FINDER_LOG="+o.Log,
+		"DUP_FINDER_TEXT="+o.Text,
+	)
+	if o.Summary != nil {
+		cmd.Env = append(cmd.Env,
+			"DUP_FINDER_SETS="+strconv.Itoa(o.Summary.Sets),
+			"DUP_FINDER_RECLAIMABLE_BYTES="+strconv.FormatInt(o.Summary.ReclaimableBytes, 10),
+		)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notification command %s failed: %w: %s", command[0], err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func postWebhook(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook failed: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// How a scheduled run ended, from the exit status of its scan
+const (
+	StatusDuplicates = "duplicates" // Duplicates were found
+	StatusClean      = "clean"      // No duplicates were found
+	StatusPartial    = "partial"    // Some roots, passes or workers failed; the report covers the rest
+	StatusFailed     = "failed"     // The scan failed or was interrupted
+)
+
+// stopDelay is how long a scan is given to save its partial results and
+// session once the daemon is stopped, before it is killed
+const stopDelay = time.Minute
+
+// Outcome is how a scheduled run ended
+type Outcome struct {
+	Job        string          `json:"job"`
+	RunID      string          `json:"run_id"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Status     string          `json:"status"`
+	ExitCode   int             `json:"exit_code"`
+	Report     string          `json:"report,omitempty"` // Results in --format json, when the scan wrote them
+	Log        string          `json:"log"`              // Output of the scan
+	Summary    *report.Summary `json:"summary,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Text       string          `json:"text"` // One line for people, e.g. in a chat message
+}
+
+// Runner runs the jobs of a configuration on their schedules
+type Runner struct {
+	Config  *Config
+	Program string    // dup-finder executable the scans run
+	Log     io.Writer // Where runs are logged
+}
+
+// Run runs every job when its schedule fires, until ctx is done. Jobs run
+// one at a time: a job that falls due while another runs starts once it
+// ends, and firings missed meanwhile are not made up for.
+func (r *Runner) Run(ctx context.Context) error {
+	jobs := r.Config.Jobs
+	next := make([]time.Time, len(jobs))
+	now := time.Now()
+	for i := range jobs {
+		next[i] = jobs[i].Next(now)
+		r.logf(&jobs[i], "next run %s", next[i].Format(time.RFC3339))
+	}
+
+	for {
+		due := 0
+		for i := range jobs {
+			if next[i].Before(next[due]) {
+				due = i
+			}
+		}
+		// Check the clock every minute rather than sleeping until the run,
+		// so a machine waking from sleep does not wait out the time it slept
+		wait := time.Until(next[due])
+		if wait > time.Minute {
+			wait = time.Minute
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+			continue
+		}
+
+		r.RunJob(ctx, &jobs[due])
+		if ctx.Err() != nil {
+			return nil
+		}
+		next[due] = jobs[due].Next(time.Now())
+		r.logf(&jobs[due], "next run %s", next[due].Format(time.RFC3339))
+	}
+}
+
+// RunJob runs the scan of job now, keeps its report and log under the
+// job's directory of reports, removes the reports beyond KeepReports, and
+// sends the job's notification
+func (r *Runner) RunJob(ctx context.Context, job *Job) Outcome {
+	r.logf(job, "starting")
+	o := r.scan(ctx, job)
+	r.logf(job, "%s", strings.TrimPrefix(o.Text, "dup-finder "+job.Name+": "))
+
+	if r.Config.KeepReports > 0 {
+		if err := prune(filepath.Dir(o.Log), r.Config.KeepReports); err != nil {
+			r.logf(job, "Warning: %v", err)
+		}
+	}
+	if n := r.Config.notification(job); n != nil && n.wants(o) {
+		// Still tell about a run cut short by stopping the daemon
+		if err := n.Send(context.WithoutCancel(ctx), o); err != nil {
+			r.logf(job, "Warning: %v", err)
+		}
+	}
+	return o
+}
+
+// logf logs a line about job, with the time
+func (r *Runner) logf(job *Job, format string, args ...any) {
+	fmt.Fprintf(r.Log, "%s %s: %s\n", time.Now().Format(time.RFC3339), job.Name, fmt.Sprintf(format, args...))
+}
+
+// scan runs the scan of job and reads the summary of its report
+func (r *Runner) scan(ctx context.Context, job *Job) Outcome {
+	id := runid.New()
+	dir := filepath.Join(r.Config.Reports, job.Name)
+	o := Outcome{
+		Job:       job.Name,
+		RunID:     id,
+		StartedAt: time.Now(),
+		Status:    StatusFailed,
+		ExitCode:  -1,
+		Log:       filepath.Join(dir, id+".log"),
+	}
+	fail := func(err error) Outcome {
+		o.FinishedAt = time.Now()
+		o.Error = err.Error()
+		o.Text = o.describe()
+		return o
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fail(fmt.Errorf("cannot create reports directory: %w", err))
+	}
+	logFile, err := os.Create(o.Log)
+	if err != nil {
+		return fail(fmt.Errorf("cannot create log: %w", err))
+	}
+	defer logFile.Close()
+
+	reportPath := filepath.Join(dir, id+".json")
+	args := append(append([]string{}, job.Args...), "--format", "json", "--output", reportPath, "--run-id", id)
+	cmd := exec.CommandContext(ctx, r.Program, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Interrupt rather than kill, so the scan saves what it found and its
+	// session for resume
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = stopDelay
+	err = cmd.Run()
+	o.FinishedAt = time.Now()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		o.ExitCode = 0
+	case errors.As(err, &exitErr):
+		o.ExitCode = exitErr.ExitCode()
+	default:
+		return fail(fmt.Errorf("cannot run scan: %w", err))
+	}
+	switch o.ExitCode {
+	case 0:
+		o.Status = StatusDuplicates
+	case 3:
+		o.Status = StatusClean
+	case 4:
+		o.Status = StatusPartial
+	default:
+		o.Error = fmt.Sprintf("scan exited with status %d; see %s", o.ExitCode, o.Log)
+	}
+
+	if results, err := report.Load(reportPath); err == nil {
+		summary := report.Summarize(results)
+		o.Report = reportPath
+		o.Summary = &summary
+	} else if o.Status != StatusFailed {
+		o.Status = StatusFailed
+		o.Error = err.Error()
+	}
+	o.Text = o.describe()
+	return o
+}
+
+// describe returns the outcome in one line
+func (o Outcome) describe() string {
+	var text string
+	switch o.Status {
+	case StatusDuplicates, StatusPartial:
+		text = fmt.Sprintf("%s duplicate set(s), %s reclaimable", output.FormatCount(o.Summary.Sets), output.FormatSize(o.Summary.ReclaimableBytes))
+		if o.Status == StatusPartial {
+			text += ", but parts of the scan failed"
+		}
+	case StatusClean:
+		text = "no duplicates"
+	default:
+		text = "failed: " + o.Error
+	}
+	text = fmt.Sprintf("dup-finder %s: %s", o.Job, text)
+	if o.Report != "" {
+		text += " (report " + o.Report + ")"
+	}
+	return text
+}
+
+// prune removes the oldest reports of dir, with their logs, so that keep
+// remain. Logs are written from the start of their run to its end, so the
+// oldest is the one modified first.
+func prune(dir string, keep int) error {
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return err
+	}
+	if len(logs) <= keep {
+		return nil
+	}
+	modified := make(map[string]time.Time, len(logs))
+	for _, log := range logs {
+		if info, err := os.Stat(log); err == nil {
+			modified[log] = info.ModTime()
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if !modified[logs[i]].Equal(modified[logs[j]]) {
+			return modified[logs[i]].Before(modified[logs[j]])
+		}
+		return logs[i] < logs[j]
+	})
+	var errs []error
+	for _, log := range logs[:len(logs)-keep] {
+		for _, path := range []string{log, strings.TrimSuffix(log, ".log") + ".json"} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("cannot remove old report: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+//go:build unix
+
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScan writes a program that stands in for dup-finder: it saves the
+// results document given to --output and exits with status
+func fakeScan(t *testing.T, results, status string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dup-finder")
+	script := `#!/bin/sh
+echo "scanning $1"
+while [ $# -gt 0 ]; do
+	if [ "$1" = "--output" ]; then printf '%s' '` + results + `' > "$2"; fi
+	shift
+done
+exit ` + status + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+const fakeResults = `{"version": 1, "comparisons": [{"dir1": "/a", "dir2": "/b", "matches": [{"file1": {"path": "/a/x", "size": 100}, "file2": {"path": "/b/x", "size": 100}, "hash_match": true, "hash_computed": true}]}]}`
+
+func TestRunJob(t *testing.T) {
+	notified := filepath.Join(t.TempDir(), "notified")
+	cfg := &Config{
+		Reports:     t.TempDir(),
+		KeepReports: 1,
+		Notify:      &Notify{Command: []string{"sh", "-c", `echo "$DUP_FINDER_STATUS $DUP_FINDER_SETS" > ` + notified}},
+		Jobs:        []Job{{Name: "nas", Schedule: "@daily", Args: []string{"/a", "/b"}}},
+	}
+	require.NoError(t, cfg.validate())
+
+	var log bytes.Buffer
+	r := &Runner{Config: cfg, Program: fakeScan(t, fakeResults, "0"), Log: &log}
+	o := r.RunJob(context.Background(), &cfg.Jobs[0])
+	assert.Equal(t, StatusDuplicates, o.Status, o.Error)
+	require.NotNil(t, o.Summary)
+	assert.Equal(t, 1, o.Summary.Sets)
+	assert.Equal(t, filepath.Join(cfg.Reports, "nas", o.RunID+".json"), o.Report)
+	assert.Contains(t, log.String(), "nas: 1 duplicate set(s)")
+
+	data, err := os.ReadFile(notified)
+	require.NoError(t, err)
+	assert.Equal(t, "duplicates 1\n", string(data))
+	data, err = os.ReadFile(o.Log)
+	require.NoError(t, err)
+	assert.Equal(t, "scanning /a\n", string(data))
+
+	// A clean run is not notified by default, and replaces the older report
+	require.NoError(t, os.Remove(notified))
+	r.Program = fakeScan(t, `{"version": 1, "comparisons": []}`, "3")
+	o2 := r.RunJob(context.Background(), &cfg.Jobs[0])
+	assert.Equal(t, StatusClean, o2.Status)
+	assert.NoFileExists(t, notified)
+	assert.NoFileExists(t, o.Report)
+	assert.FileExists(t, o2.Report)
+
+	// A scan that fails without results
+	r.Program = fakeScan(t, "", "1")
+	o3 := r.RunJob(context.Background(), &cfg.Jobs[0])
+	assert.Equal(t, StatusFailed, o3.Status)
+	assert.Contains(t, o3.Error, "status 1")
+	assert.FileExists(t, notified)
+}
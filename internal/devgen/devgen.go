@@ -0,0 +1,245 @@
+// Package devgen generates synthetic directory trees with a known number of
+// duplicates, for benchmarks and for trying dup-finder at scale
+package devgen
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Options describes the trees Generate creates. The same options always
+// produce the same trees.
+type Options struct {
+	Roots        int     // Top-level directories, each a tree to compare with the others (at least 1)
+	Files        int     // Files per root
+	DupRatio     float64 // Fraction of the files of every root but the first that are copies of a file in the first
+	ChangedRatio float64 // Fraction that share a name and size with a file in the first root but not its contents
+	Depth        int     // Directory levels below each root
+	Fanout       int     // Subdirectories per directory (1 gives a single chain Depth deep)
+	MinSize      int     // Smallest file in bytes
+	MaxSize      int     // Largest file in bytes
+	Unicode      bool    // Use non-ASCII file and directory names
+	Seed         uint64  // Seed of the random layout and contents
+	Workers      int     // Files written in parallel (0 = number of CPUs)
+}
+
+// DefaultOptions returns options for two roots of 10,000 small files, a
+// quarter of them duplicated
+func DefaultOptions() Options {
+	return Options{
+		Roots:        2,
+		Files:        10000,
+		DupRatio:     0.25,
+		ChangedRatio: 0.05,
+		Depth:        3,
+		Fanout:       4,
+		MinSize:      0,
+		MaxSize:      4096,
+		Seed:         1,
+	}
+}
+
+// Stats counts what Generate created
+type Stats struct {
+	Roots      []string // Root directories, in order
+	Files      int      // Files in all roots
+	Bytes      int64    // Total size of the files
+	Duplicates int      // Files identical to a same-named file in the first root
+	Changed    int      // Files named like a file in the first root, of the same size, with other contents
+}
+
+// Names mixed into file and directory names with Unicode set: accents in
+// composed and decomposed form, CJK, Cyrillic, right-to-left script and emoji
+var unicodeNames = []string{"café", "写真", "Überprüfung", "отчёт", "ملف", "📁データ", "résumé"}
+
+// extensions rotated through file names, so extension filters have
+// something to select
+var extensions = []string{".jpg", ".txt", ".dat", ".png", ".pdf"}
+
+// file is one file to write
+type file struct {
+	path    string
+	content uint64 // Key of the contents
+	size    int
+}
+
+// Generate creates opts.Roots trees named root0, root1, ... inside dir.
+// Every root holds opts.Files files spread over opts.Depth levels of
+// directories. In all roots but the first, a DupRatio fraction of the files
+// are copies of a file of the first root under the same name, placed in
+// another directory, and a ChangedRatio fraction share a name and size with
+// one but not its contents; the rest have names of their own.
+func Generate(dir string, opts Options) (Stats, error) {
+	if opts.Roots < 1 {
+		return Stats{}, fmt.Errorf("at least one root is needed")
+	}
+	if opts.Files < 0 || opts.Depth < 0 || opts.MinSize < 0 || opts.MaxSize < opts.MinSize {
+		return Stats{}, fmt.Errorf("invalid options: files, depth and sizes cannot be negative, and max size must be at least min size")
+	}
+	if opts.DupRatio < 0 || opts.ChangedRatio < 0 || opts.DupRatio+opts.ChangedRatio > 1 {
+		return Stats{}, fmt.Errorf("duplicate and changed ratios must be between 0 and 1 and add up to at most 1")
+	}
+	if opts.Fanout < 1 {
+		opts.Fanout = 1
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	stats := Stats{}
+	leaves := leafCount(opts)
+	for r := range opts.Roots {
+		root := filepath.Join(dir, "root"+strconv.Itoa(r))
+		stats.Roots = append(stats.Roots, root)
+
+		files := make([]file, opts.Files)
+		for i := range files {
+			rng := rand.New(rand.NewPCG(opts.Seed, uint64(r)<<32|uint64(i)))
+			name, content := fileName(opts, 0, i), contentKey(0, i)
+			if r > 0 {
+				switch roll := rng.Float64(); {
+				case roll < opts.DupRatio:
+					stats.Duplicates++
+				case roll < opts.DupRatio+opts.ChangedRatio:
+					content = contentKey(r, i)
+					stats.Changed++
+				default:
+					name, content = fileName(opts, r, i), contentKey(r, i)
+				}
+			}
+			files[i] = file{
+				path:    filepath.Join(root, dirPath(opts, rng.IntN(leaves)), name),
+				content: content,
+				size:    fileSize(opts, i),
+			}
+			stats.Bytes += int64(files[i].size)
+		}
+		stats.Files += len(files)
+
+		if err := writeFiles(files, opts.Seed, workers); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// leafCount returns the number of directories at the deepest level,
+// capped at one per file
+func leafCount(opts Options) int {
+	leaves := 1
+	for range opts.Depth {
+		if leaves > opts.Files/opts.Fanout {
+			return max(opts.Files, 1)
+		}
+		leaves *= opts.Fanout
+	}
+	return leaves
+}
+
+// dirPath returns the relative path of leaf directory n, one segment per
+// level, its digits in base Fanout from the lowest
+func dirPath(opts Options, n int) string {
+	segments := make([]string, opts.Depth)
+	for level := range segments {
+		name := "d"
+		if opts.Unicode {
+			name = unicodeNames[level%len(unicodeNames)]
+		}
+		segments[level] = name + strconv.Itoa(n%opts.Fanout)
+		n /= opts.Fanout
+	}
+	return filepath.Join(segments...)
+}
+
+// fileName returns the name of file i of root r, unique within the root
+func fileName(opts Options, r, i int) string {
+	prefix := "f"
+	if opts.Unicode {
+		prefix = unicodeNames[i%len(unicodeNames)] + "-"
+	}
+	return fmt.Sprintf("%s%d-%07d%s", prefix, r, i, extensions[i%len(extensions)])
+}
+
+// contentKey identifies the contents of file i of root r
+func contentKey(r, i int) uint64 {
+	return uint64(r)<<32 | uint64(i)
+}
+
+// fileSize returns the size of file i of the first root, which the files
+// sharing its name keep
+func fileSize(opts Options, i int) int {
+	if opts.MaxSize == opts.MinSize {
+		return opts.MinSize
+	}
+	rng := rand.New(rand.NewPCG(opts.Seed^0x5eed, uint64(i)))
+	return opts.MinSize + rng.IntN(opts.MaxSize-opts.MinSize+1)
+}
+
+// writeFiles writes files in parallel, creating their directories
+func writeFiles(files []file, seed uint64, workers int) error {
+	var (
+		next     atomic.Int64
+		dirs     sync.Map
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(files) {
+					return
+				}
+				f := files[i]
+				parent := filepath.Dir(f.path)
+				if _, ok := dirs.Load(parent); !ok {
+					if err := os.MkdirAll(parent, 0755); err != nil {
+						errOnce.Do(func() { firstErr = err })
+						return
+					}
+					dirs.Store(parent, true)
+				}
+				buf = fillContent(buf[:0], seed, f.content, f.size)
+				if err := os.WriteFile(f.path, buf, 0644); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// fillContent appends size pseudo-random bytes determined by seed and key
+func fillContent(buf []byte, seed, key uint64, size int) []byte {
+	rng := rand.New(rand.NewPCG(seed, key^0xc0ffee<<40))
+	for len(buf) < size {
+		v := rng.Uint64()
+		for b := 0; b < 8 && len(buf) < size; b++ {
+			buf = append(buf, byte(v>>(8*b)))
+		}
+	}
+	return buf
+}
+
+// ScaleFromEnv returns the number of files benchmarks generate: the value of
+// DUP_FINDER_BENCH_FILES when set, def otherwise. Setting it to a few
+// million checks performance at the scale of real trees before a release.
+func ScaleFromEnv(def int) int {
+	if n, err := strconv.Atoi(os.Getenv("DUP_FINDER_BENCH_FILES")); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
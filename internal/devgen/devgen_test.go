@@ -0,0 +1,98 @@
+package devgen
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+// listTree returns the contents of every file under dir by relative path
+func listTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		if d.Type().IsRegular() {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			rel, _ := filepath.Rel(dir, path)
+			files[rel] = string(data)
+		}
+		return nil
+	}))
+	return files
+}
+
+func TestGenerate_KnownDuplicates(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Files = 500
+	opts.MinSize = 1
+	opts.MaxSize = 64
+	stats, err := Generate(t.TempDir(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, stats.Files)
+	assert.InDelta(t, 125, stats.Duplicates, 30)
+	assert.InDelta(t, 25, stats.Changed, 15)
+
+	scanOpts := models.ScanOptions{Directories: stats.Roots, Recursive: true, MaxDepth: -1, CompareHash: true, NumWorkers: 4}
+	files, err := scanner.NewScanner(scanOpts).ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, files[stats.Roots[0]], 500)
+
+	comparison := finder.NewFinder(scanOpts).ComparePair(context.Background(), files[stats.Roots[0]], files[stats.Roots[1]])
+	assert.Len(t, comparison.Matches, stats.Duplicates+stats.Changed)
+	duplicates := 0
+	for _, match := range comparison.Matches {
+		if match.IsDuplicate() {
+			duplicates++
+		}
+	}
+	assert.Equal(t, stats.Duplicates, duplicates)
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	opts := Options{Roots: 2, Files: 50, DupRatio: 0.5, Depth: 6, Fanout: 2, MaxSize: 100, Unicode: true, Seed: 7}
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	_, err := Generate(dir1, opts)
+	require.NoError(t, err)
+	_, err = Generate(dir2, opts)
+	require.NoError(t, err)
+
+	tree := listTree(t, dir1)
+	assert.Len(t, tree, 100)
+	assert.Equal(t, tree, listTree(t, dir2))
+
+	opts.Seed = 8
+	dir3 := t.TempDir()
+	_, err = Generate(dir3, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, tree, listTree(t, dir3))
+}
+
+func TestGenerate_InvalidOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{Roots: 0, Files: 1},
+		{Roots: 1, Files: -1},
+		{Roots: 1, MinSize: 10, MaxSize: 5},
+		{Roots: 2, DupRatio: 0.8, ChangedRatio: 0.3},
+	} {
+		_, err := Generate(t.TempDir(), opts)
+		assert.Error(t, err, "%+v", opts)
+	}
+}
+
+func TestScaleFromEnv(t *testing.T) {
+	t.Setenv("DUP_FINDER_BENCH_FILES", "")
+	assert.Equal(t, 100, ScaleFromEnv(100))
+	t.Setenv("DUP_FINDER_BENCH_FILES", "2000000")
+	assert.Equal(t, 2000000, ScaleFromEnv(100))
+}
@@ -0,0 +1,148 @@
+// Package diskspace projects how planned deletions change the free space
+// of each file system they touch, since a total across several drives does
+// not tell whether the full one gets any relief
+package diskspace
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// Usage is the size of a file system and the space on it available to
+// unprivileged users, in bytes
+type Usage struct {
+	Total uint64
+	Free  uint64
+}
+
+// Projection is the effect of planned deletions on one file system
+type Projection struct {
+	Mount string // Mount point, or volume on Windows; "" for files that were not found
+	Files int    // Files to remove from the file system
+	Freed int64  // Bytes the removals free, counting hardlinks of kept files as nothing
+	Usage Usage  // Size and free space now; zero when unknown
+}
+
+// Known reports whether the size and free space of the file system are known
+func (p Projection) Known() bool {
+	return p.Usage.Total > 0
+}
+
+// FreeBefore returns the percent of the file system free now
+func (p Projection) FreeBefore() float64 {
+	return percent(p.Usage.Free, p.Usage.Total)
+}
+
+// FreeAfter returns the percent of the file system free once the removals
+// are done
+func (p Projection) FreeAfter() float64 {
+	return percent(min(p.Usage.Free+uint64(max(p.Freed, 0)), p.Usage.Total), p.Usage.Total)
+}
+
+func percent(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// Project groups removed files by the file system holding them and
+// projects the space freed on each, while the kept files stay. Files
+// that cannot be found are grouped under an empty mount, listed last.
+func Project(kept, removed []models.FileInfo) []Projection {
+	locate := newLocator()
+	groups := make(map[string][]models.FileInfo)
+	for _, file := range removed {
+		mount := locate.mount(file.Path)
+		groups[mount] = append(groups[mount], file)
+	}
+
+	projections := make([]Projection, 0, len(groups))
+	for mount, files := range groups {
+		p := Projection{Mount: mount, Files: len(files), Freed: finder.RemovalSavings(kept, files)}
+		if mount != "" {
+			if u, err := usage(mount); err == nil {
+				p.Usage = u
+			}
+		}
+		projections = append(projections, p)
+	}
+	sort.Slice(projections, func(i, j int) bool {
+		a, b := projections[i].Mount, projections[j].Mount
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return a < b
+	})
+	return projections
+}
+
+// Format renders projections as one line per file system
+func Format(projections []Projection) string {
+	if len(projections) == 0 {
+		return ""
+	}
+	width := 0
+	for _, p := range projections {
+		width = max(width, len(mountLabel(p.Mount)))
+	}
+
+	var builder strings.Builder
+	builder.WriteString("By file system:\n")
+	for _, p := range projections {
+		builder.WriteString(fmt.Sprintf("  %-*s  %s freed by %s file(s)", width, mountLabel(p.Mount),
+			output.FormatSize(p.Freed), output.FormatCount(p.Files)))
+		if p.Known() {
+			builder.WriteString(fmt.Sprintf(", %s%% → %s%% free of %s",
+				output.FormatPercent(p.FreeBefore()), output.FormatPercent(p.FreeAfter()), output.FormatSize(int64(p.Usage.Total))))
+		} else if p.Mount != "" {
+			builder.WriteString(", free space unknown")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func mountLabel(mount string) string {
+	if mount == "" {
+		return "(not found)"
+	}
+	return mount
+}
+
+// locator finds the mount holding each file, caching by directory since
+// files of one directory share a file system
+type locator struct {
+	dirs   map[string]string
+	mounts map[uint64]string
+}
+
+func newLocator() *locator {
+	return &locator{dirs: make(map[string]string), mounts: make(map[uint64]string)}
+}
+
+// mount returns the mount point of the file system holding path, or ""
+// if path's directory cannot be found
+func (l *locator) mount(path string) string {
+	dir := filepath.Dir(path)
+	if mount, ok := l.dirs[dir]; ok {
+		return mount
+	}
+	mount := ""
+	if dev, ok := device(dir); ok {
+		if m, ok := l.mounts[dev]; ok {
+			mount = m
+		} else {
+			mount = mountPoint(dir, dev)
+			l.mounts[dev] = mount
+		}
+	}
+	l.dirs[dir] = mount
+	return mount
+}
@@ -0,0 +1,62 @@
+package diskspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestProject(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) models.FileInfo {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+		return models.FileInfo{Path: path, Size: int64(size)}
+	}
+	kept := []models.FileInfo{write("keep.jpg", 100)}
+	removed := []models.FileInfo{
+		write("a.jpg", 100),
+		write("b.jpg", 200),
+		{Path: filepath.Join(dir, "gone", "c.jpg"), Size: 300},
+	}
+
+	projections := Project(kept, removed)
+	require.Len(t, projections, 2)
+
+	local := projections[0]
+	assert.NotEmpty(t, local.Mount)
+	assert.Equal(t, 2, local.Files)
+	assert.Equal(t, int64(300), local.Freed)
+
+	// Files whose directory is gone are listed last, without a mount
+	assert.Equal(t, Projection{Files: 1, Freed: 300}, projections[1])
+}
+
+func TestProjection_Percentages(t *testing.T) {
+	p := Projection{Mount: "/", Files: 3, Freed: 50, Usage: Usage{Total: 1000, Free: 80}}
+	assert.True(t, p.Known())
+	assert.InDelta(t, 8.0, p.FreeBefore(), 1e-9)
+	assert.InDelta(t, 13.0, p.FreeAfter(), 1e-9)
+
+	full := Projection{Freed: 500, Usage: Usage{Total: 1000, Free: 900}}
+	assert.InDelta(t, 100.0, full.FreeAfter(), 1e-9)
+	assert.False(t, Projection{Freed: 10}.Known())
+}
+
+func TestFormat(t *testing.T) {
+	text := Format([]Projection{
+		{Mount: "/", Files: 120, Freed: 1 << 30, Usage: Usage{Total: 100 << 30, Free: 8 << 30}},
+		{Mount: "/mnt/backup", Files: 3, Freed: 1 << 20},
+		{Files: 1, Freed: 10},
+	})
+	assert.Equal(t, "By file system:\n"+
+		"  /            1.0 GiB freed by 120 file(s), 8.0% → 9.0% free of 100.0 GiB\n"+
+		"  /mnt/backup  1.0 MiB freed by 3 file(s), free space unknown\n"+
+		"  (not found)  10 B freed by 1 file(s)\n", text)
+	assert.Empty(t, Format(nil))
+}
@@ -0,0 +1,43 @@
+//go:build !unix
+
+package diskspace
+
+import (
+	"hash/fnv"
+	"path/filepath"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// device identifies the volume holding path by its name, as Windows has
+// no device IDs in file info
+func device(path string) (uint64, bool) {
+	if _, err := fsio.Stat(path); err != nil {
+		return 0, false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(volume(abs)))
+	return h.Sum64(), true
+}
+
+// mountPoint returns the root of the volume holding dir
+func mountPoint(dir string, _ uint64) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return volume(abs)
+}
+
+// volume returns the root of the volume of an absolute path, such as C:\
+// or \\server\share\
+func volume(abs string) string {
+	if v := filepath.VolumeName(abs); v != "" {
+		return v + string(filepath.Separator)
+	}
+	return string(filepath.Separator)
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package diskspace
+
+import (
+	"path/filepath"
+	"syscall"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// device returns the ID of the device holding path
+func device(path string) (uint64, bool) {
+	info, err := fsio.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// mountPoint returns the topmost ancestor of dir on device dev, which is
+// where that file system is mounted
+func mountPoint(dir string, dev uint64) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		if d, ok := device(parent); !ok || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
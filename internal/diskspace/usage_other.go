@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || dragonfly || windows)
+
+package diskspace
+
+import "errors"
+
+// usage is not available on this platform; projections show the space
+// freed without the file system's size
+func usage(mount string) (Usage, error) {
+	return Usage{}, errors.New("file system usage not supported on this platform")
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package diskspace
+
+import "syscall"
+
+// usage returns the size and available space of the file system mounted
+// at mount
+func usage(mount string) (Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(mount, &st); err != nil {
+		return Usage{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return Usage{Total: uint64(st.Blocks) * bsize, Free: uint64(st.Bavail) * bsize}, nil
+}
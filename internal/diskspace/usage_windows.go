@@ -0,0 +1,26 @@
+//go:build windows
+
+package diskspace
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// usage returns the size and the space available to the user of the
+// volume whose root is mount
+func usage(mount string) (Usage, error) {
+	path, err := syscall.UTF16PtrFromString(mount)
+	if err != nil {
+		return Usage{}, err
+	}
+	var free, total, totalFree uint64
+	ok, _, err := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&free)), uintptr(unsafe.Pointer(&total)), uintptr(unsafe.Pointer(&totalFree)))
+	if ok == 0 {
+		return Usage{}, err
+	}
+	return Usage{Total: total, Free: free}, nil
+}
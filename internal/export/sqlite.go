@@ -0,0 +1,185 @@
+// Package export saves scan results in formats meant for querying later
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// Export kinds accepted by --export
+const (
+	KindSQLite = "sqlite"
+)
+
+// Kinds lists the supported export kinds
+var Kinds = []string{KindSQLite}
+
+// SQLiteVersion is the current version of the exported database layout,
+// stored in its meta table
+const SQLiteVersion = 1
+
+// mtimeLayout stores times in UTC in a form SQLite date functions accept
+const mtimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// ParseTarget splits an --export value of the form KIND:PATH
+func ParseTarget(value string) (kind, path string, err error) {
+	kind, path, ok := strings.Cut(value, ":")
+	if !ok || path == "" {
+		return "", "", fmt.Errorf("invalid --export value %q: expected KIND:PATH, e.g. sqlite:results.db", value)
+	}
+	for _, k := range Kinds {
+		if kind == k {
+			return kind, path, nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown export kind %q (supported: %s)", kind, strings.Join(Kinds, ", "))
+}
+
+// sqliteTables lays out comparisons as the tables of the exported
+// database: meta, roots, files, matches, sets and set_files
+func sqliteTables(comparisons []models.PairComparison, labels output.Labels) []sqliteTable {
+	meta := sqliteTable{
+		Name: "meta",
+		SQL:  "CREATE TABLE meta(key TEXT NOT NULL, value TEXT NOT NULL)",
+		Rows: [][]any{
+			{"version", strconv.Itoa(SQLiteVersion)},
+			{"generated_at", time.Now().UTC().Format(mtimeLayout)},
+			{"run_id", runid.ID()},
+		},
+	}
+
+	roots := sqliteTable{
+		Name: "roots",
+		SQL:  "CREATE TABLE roots(id INTEGER PRIMARY KEY, path TEXT NOT NULL, label TEXT)",
+	}
+	rootIDs := make(map[string]int)
+	addRoot := func(dir string) {
+		if _, ok := rootIDs[dir]; ok {
+			return
+		}
+		rootIDs[dir] = len(roots.Rows) + 1
+		var label any
+		if l := labels.Dir(dir); l != dir {
+			label = l
+		}
+		roots.Rows = append(roots.Rows, []any{nil, dir, label})
+	}
+
+	// Files are listed once however many matches include them, with the
+	// hash from whichever match computed it
+	files := sqliteTable{
+		Name: "files",
+		SQL: "CREATE TABLE files(id INTEGER PRIMARY KEY, path TEXT NOT NULL, root TEXT NOT NULL, " +
+			"size INTEGER NOT NULL, mtime TEXT NOT NULL, hash TEXT, partial_hash TEXT)",
+	}
+	fileIDs := make(map[string]int)
+	addFile := func(file models.FileInfo) int {
+		if id, ok := fileIDs[file.Path]; ok {
+			row := files.Rows[id-1]
+			if row[5] == nil && file.Hash != "" {
+				row[5] = file.Hash
+			}
+			if row[6] == nil && file.PartialHash != "" {
+				row[6] = file.PartialHash
+			}
+			return id
+		}
+		id := len(files.Rows) + 1
+		fileIDs[file.Path] = id
+		files.Rows = append(files.Rows, []any{
+			nil, file.Path, file.Directory, file.Size, file.ModTime.UTC().Format(mtimeLayout),
+			nullIfEmpty(file.Hash), nullIfEmpty(file.PartialHash),
+		})
+		return id
+	}
+
+	matches := sqliteTable{
+		Name: "matches",
+		SQL: "CREATE TABLE matches(id INTEGER PRIMARY KEY, dir1 TEXT NOT NULL, dir2 TEXT NOT NULL, filename TEXT NOT NULL, " +
+			"file1_id INTEGER NOT NULL REFERENCES files(id), file2_id INTEGER NOT NULL REFERENCES files(id), " +
+			"hash_checked INTEGER NOT NULL, hash_match INTEGER NOT NULL, verified TEXT, mismatch TEXT, " +
+			"hardlinked INTEGER NOT NULL, duplicate INTEGER NOT NULL)",
+	}
+	for _, comparison := range comparisons {
+		addRoot(comparison.Dir1)
+		addRoot(comparison.Dir2)
+		for _, match := range comparison.Matches {
+			matches.Rows = append(matches.Rows, []any{
+				nil, comparison.Dir1, comparison.Dir2, match.Filename,
+				addFile(match.File1), addFile(match.File2),
+				match.HashChecked, match.HashMatch, nullIfEmpty(match.Verified), nullIfEmpty(match.Mismatch),
+				match.Hardlinked, match.IsDuplicate(),
+			})
+		}
+	}
+
+	sets := sqliteTable{
+		Name: "sets",
+		SQL: "CREATE TABLE sets(id INTEGER PRIMARY KEY, hash TEXT, verified INTEGER NOT NULL, " +
+			"copies INTEGER NOT NULL, reclaimable_bytes INTEGER NOT NULL)",
+	}
+	setFiles := sqliteTable{
+		Name: "set_files",
+		SQL:  "CREATE TABLE set_files(set_id INTEGER NOT NULL REFERENCES sets(id), file_id INTEGER NOT NULL REFERENCES files(id))",
+	}
+	for _, set := range finder.MergeMatches(comparisons) {
+		sets.Rows = append(sets.Rows, []any{
+			nil, nullIfEmpty(setHash(set)), set.HashComputed, len(set.Files), finder.SetSavings(set),
+		})
+		for _, file := range set.Files {
+			setFiles.Rows = append(setFiles.Rows, []any{len(sets.Rows), fileIDs[file.Path]})
+		}
+	}
+
+	return []sqliteTable{meta, roots, files, matches, sets, setFiles}
+}
+
+// SQLite saves comparisons to a new SQLite database at path, replacing any
+// file there once the database is complete. Duplicate sets are the merged
+// pairwise matches, numbered as in interactive mode.
+func SQLite(path string, comparisons []models.PairComparison, labels output.Labels) error {
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	w := bufio.NewWriter(file)
+	if err := writeSQLite(w, sqliteTables(comparisons, labels)); err != nil {
+		return fmt.Errorf("cannot write SQLite export: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("cannot write SQLite export: %w", err)
+	}
+	return file.Commit()
+}
+
+// setHash returns the hash shared by the files of set, or "" if they were
+// not all hashed
+func setHash(set models.DuplicateSet) string {
+	if set.Hash != "" {
+		return set.Hash
+	}
+	for _, file := range set.Files {
+		if file.Hash == "" || file.Hash != set.Files[0].Hash {
+			return ""
+		}
+	}
+	return set.Files[0].Hash
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// readTable walks the table B-tree rooted at page and decodes its records,
+// as a check on the writer independent of a SQLite library
+func readTable(t *testing.T, db []byte, page int) [][]any {
+	t.Helper()
+	data := db[(page-1)*pageSize : page*pageSize]
+	offset := 0
+	if page == 1 {
+		offset = 100
+	}
+	count := int(binary.BigEndian.Uint16(data[offset+3:]))
+
+	switch data[offset] {
+	case pageTableInterior:
+		var rows [][]any
+		for i := 0; i < count; i++ {
+			cell := int(binary.BigEndian.Uint16(data[offset+interiorHeaderSize+2*i:]))
+			rows = append(rows, readTable(t, db, int(binary.BigEndian.Uint32(data[cell:])))...)
+		}
+		return append(rows, readTable(t, db, int(binary.BigEndian.Uint32(data[offset+8:])))...)
+	case pageTableLeaf:
+		var rows [][]any
+		for i := 0; i < count; i++ {
+			cell := data[binary.BigEndian.Uint16(data[offset+leafHeaderSize+2*i:]):]
+			size, n := readVarint(cell)
+			_, m := readVarint(cell[n:])
+			cell = cell[n+m:]
+
+			local := localSize(int(size))
+			payload := append([]byte{}, cell[:local]...)
+			for next := 0; len(payload) < int(size); {
+				if next == 0 {
+					next = int(binary.BigEndian.Uint32(cell[local:]))
+				}
+				overflow := db[(next-1)*pageSize : next*pageSize]
+				payload = append(payload, overflow[4:min(pageSize, 4+int(size)-len(payload))]...)
+				next = int(binary.BigEndian.Uint32(overflow))
+			}
+			rows = append(rows, decodeRecord(t, payload))
+		}
+		return rows
+	default:
+		t.Fatalf("page %d: unexpected page type %#x", page, data[offset])
+		return nil
+	}
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+func decodeRecord(t *testing.T, payload []byte) []any {
+	headerSize, n := readVarint(payload)
+	header, body := payload[n:headerSize], payload[headerSize:]
+	var values []any
+	for len(header) > 0 {
+		serial, m := readVarint(header)
+		header = header[m:]
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial == 8 || serial == 9:
+			values = append(values, int64(serial-8))
+		case serial >= 1 && serial <= 6:
+			size := []int{0, 1, 2, 3, 4, 6, 8}[serial]
+			var v int64
+			for _, c := range body[:size] {
+				v = v<<8 | int64(c)
+			}
+			// Sign-extend
+			shift := 64 - 8*size
+			values = append(values, v<<shift>>shift)
+			body = body[size:]
+		case serial >= 13 && serial%2 == 1:
+			size := int(serial-13) / 2
+			values = append(values, string(body[:size]))
+			body = body[size:]
+		default:
+			t.Fatalf("unexpected serial type %d", serial)
+		}
+	}
+	return values
+}
+
+func TestWriteSQLite(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	var many [][]any
+	for i := 0; i < 5000; i++ {
+		many = append(many, []any{nil, fmt.Sprintf("/photos/%05d.jpg", i), int64(i) * 1000003})
+	}
+	tables := []sqliteTable{
+		{Name: "empty", SQL: "CREATE TABLE empty(a)"},
+		{Name: "values", SQL: "CREATE TABLE \"values\"(a, b, c, d)", Rows: [][]any{
+			{int64(-1), 1 << 40, true, nil},
+			{long, false, int64(-1) << 50, "写真"},
+		}},
+		{Name: "many", SQL: "CREATE TABLE many(id INTEGER PRIMARY KEY, path TEXT, n INTEGER)", Rows: many},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeSQLite(&buf, tables))
+	db := buf.Bytes()
+
+	require.Zero(t, len(db)%pageSize)
+	assert.Equal(t, "SQLite format 3\x00", string(db[:16]))
+	assert.Equal(t, uint32(len(db)/pageSize), binary.BigEndian.Uint32(db[28:]))
+
+	schema := readTable(t, db, 1)
+	require.Len(t, schema, 3)
+	roots := make(map[string]int)
+	for _, row := range schema {
+		assert.Equal(t, "table", row[0])
+		roots[row[1].(string)] = int(row[3].(int64))
+	}
+
+	assert.Empty(t, readTable(t, db, roots["empty"]))
+	assert.Equal(t, [][]any{
+		{int64(-1), int64(1 << 40), int64(1), nil},
+		{long, int64(0), int64(-1) << 50, "写真"},
+	}, readTable(t, db, roots["values"]))
+
+	rows := readTable(t, db, roots["many"])
+	require.Len(t, rows, len(many))
+	assert.Equal(t, []any{nil, "/photos/04999.jpg", int64(4999) * 1000003}, rows[4999])
+}
+
+func TestAppendVarint(t *testing.T) {
+	for _, v := range []uint64{0, 127, 128, 16383, 16384, 1 << 35, 1<<56 - 1, 1 << 56, 1<<64 - 1} {
+		b := appendVarint(nil, v)
+		got, n := readVarint(b)
+		assert.Equal(t, v, got)
+		assert.Equal(t, len(b), n)
+		assert.LessOrEqual(t, n, 9)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	kind, path, err := ParseTarget(`sqlite:C:\results\scan.db`)
+	require.NoError(t, err)
+	assert.Equal(t, KindSQLite, kind)
+	assert.Equal(t, `C:\results\scan.db`, path)
+
+	_, _, err = ParseTarget("sqlite:")
+	assert.Error(t, err)
+	_, _, err = ParseTarget("postgres:db")
+	assert.ErrorContains(t, err, "supported: sqlite")
+}
+
+func TestSQLite(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := func(path, dir, hash string) models.FileInfo {
+		return models.FileInfo{Path: path, Directory: dir, Size: 10, ModTime: mtime, Hash: hash}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{
+			{Filename: "x.jpg", File1: file("/a/x.jpg", "/a", ""), File2: file("/b/x.jpg", "/b", ""), HashChecked: true, HashMatch: true},
+		}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "x.jpg", File1: file("/a/x.jpg", "/a", "abc"), File2: file("/c/x.jpg", "/c", "abc"), HashChecked: true, HashMatch: true},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	require.NoError(t, SQLite(path, comparisons, output.Labels{"/a": "main"}))
+	db, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	roots := make(map[string]int)
+	for _, row := range readTable(t, db, 1) {
+		roots[row[1].(string)] = int(row[3].(int64))
+	}
+
+	files := readTable(t, db, roots["files"])
+	require.Len(t, files, 3)
+	assert.Equal(t, []any{nil, "/a/x.jpg", "/a", int64(10), "2024-03-01T12:00:00.000000000Z", "abc", nil}, files[0])
+
+	assert.Equal(t, [][]any{{nil, "/a", "main"}, {nil, "/b", nil}, {nil, "/c", nil}}, readTable(t, db, roots["roots"]))
+	assert.Len(t, readTable(t, db, roots["matches"]), 2)
+	assert.Equal(t, [][]any{{nil, nil, int64(1), int64(3), int64(20)}}, readTable(t, db, roots["sets"]))
+	assert.Len(t, readTable(t, db, roots["set_files"]), 3)
+}
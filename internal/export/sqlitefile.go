@@ -0,0 +1,352 @@
+package export
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The SQLite file format is written directly, without a driver: each table
+// is built bottom-up as a table B-tree from rows in rowid order. Only what a
+// write-once export needs is supported: tables without indexes, integer,
+// text and NULL values, and overflow pages for long rows. See
+// https://www.sqlite.org/fileformat.html.
+
+const (
+	pageSize = 4096
+
+	// sqliteVersion is the library version recorded in the header
+	sqliteVersion = 3045000
+
+	pageTableLeaf     = 0x0D
+	pageTableInterior = 0x05
+
+	leafHeaderSize     = 8
+	interiorHeaderSize = 12
+
+	// Children per interior page, allowing for the largest rowid varint
+	maxChildren = (pageSize - interiorHeaderSize) / (2 + 4 + 9)
+
+	// Largest payload stored entirely on a leaf page, and the minimum kept
+	// there when a payload spills to overflow pages
+	maxLocal = pageSize - 35
+	minLocal = (pageSize-12)*32/255 - 23
+)
+
+// sqliteTable is a table to write, with its CREATE TABLE statement and
+// rows of values in rowid order, rowids counting from 1. Values are int64,
+// int, bool, string or nil.
+type sqliteTable struct {
+	Name string
+	SQL  string
+	Rows [][]any
+}
+
+// sqliteFile accumulates the pages of a database
+type sqliteFile struct {
+	pages [][]byte
+}
+
+// writeSQLite writes tables as a SQLite 3 database to w
+func writeSQLite(w io.Writer, tables []sqliteTable) error {
+	f := &sqliteFile{}
+	f.allocate() // Page 1 holds the header and the schema table
+
+	var schema [][]any
+	for _, table := range tables {
+		root, err := f.buildTable(table.Rows)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", table.Name, err)
+		}
+		schema = append(schema, []any{"table", table.Name, table.Name, root, table.SQL})
+	}
+
+	// The schema must fit on page 1, after the 100-byte file header
+	cells, err := f.leafCells(schema)
+	if err != nil {
+		return err
+	}
+	if !fits(cells, pageSize-100-leafHeaderSize) {
+		return fmt.Errorf("schema does not fit on the first page")
+	}
+	f.writeLeaf(f.pages[0], 100, cells)
+	f.writeHeader()
+
+	for _, page := range f.pages {
+		if _, err := w.Write(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocate adds an empty page and returns its 1-based number
+func (f *sqliteFile) allocate() int {
+	f.pages = append(f.pages, make([]byte, pageSize))
+	return len(f.pages)
+}
+
+// writeHeader fills the 100-byte database header on page 1
+func (f *sqliteFile) writeHeader() {
+	h := f.pages[0][:100]
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], pageSize)
+	h[18], h[19] = 1, 1                                       // Legacy journal mode
+	h[20] = 0                                                 // Reserved bytes per page
+	h[21], h[22], h[23] = 64, 32, 32                          // Payload fractions
+	binary.BigEndian.PutUint32(h[24:], 1)                     // File change counter
+	binary.BigEndian.PutUint32(h[28:], uint32(len(f.pages)))  // Database size in pages
+	binary.BigEndian.PutUint32(h[40:], 1)                     // Schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4)                     // Schema format
+	binary.BigEndian.PutUint32(h[56:], 1)                     // UTF-8 text
+	binary.BigEndian.PutUint32(h[92:], 1)                     // Version-valid-for, matching the change counter
+	binary.BigEndian.PutUint32(h[96:], uint32(sqliteVersion)) // Writer library version
+}
+
+// buildTable writes rows as a table B-tree and returns its root page
+func (f *sqliteFile) buildTable(rows [][]any) (int, error) {
+	cells, err := f.leafCells(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	// Fill leaf pages in rowid order
+	type child struct {
+		page   int
+		maxKey int64
+	}
+	var level []child
+	for start := 0; ; {
+		end := start
+		for end < len(cells) && fits(cells[start:end+1], pageSize-leafHeaderSize) {
+			end++
+		}
+		page := f.allocate()
+		f.writeLeaf(f.pages[page-1], 0, cells[start:end])
+		level = append(level, child{page: page, maxKey: int64(end)})
+		if start = end; start >= len(cells) {
+			break
+		}
+	}
+
+	// Add interior levels until a single page remains. Children are spread
+	// evenly so no interior page is left with a single child.
+	for len(level) > 1 {
+		groups := (len(level) + maxChildren - 1) / maxChildren
+		var next []child
+		for g := 0; g < groups; g++ {
+			start, end := g*len(level)/groups, (g+1)*len(level)/groups
+
+			// Each cell points at a child and holds its largest rowid; the
+			// last child goes in the right-most pointer
+			var cells [][]byte
+			for _, c := range level[start : end-1] {
+				cell := binary.BigEndian.AppendUint32(nil, uint32(c.page))
+				cells = append(cells, appendVarint(cell, uint64(c.maxKey)))
+			}
+			page := f.allocate()
+			f.writeInterior(f.pages[page-1], cells, level[end-1].page)
+			next = append(next, child{page: page, maxKey: level[end-1].maxKey})
+		}
+		level = next
+	}
+	return level[0].page, nil
+}
+
+// leafCells encodes rows as table leaf cells, with rowids counting from 1,
+// spilling long payloads to overflow pages
+func (f *sqliteFile) leafCells(rows [][]any) ([][]byte, error) {
+	cells := make([][]byte, 0, len(rows))
+	for i, row := range rows {
+		payload, err := encodeRecord(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		cell := appendVarint(nil, uint64(len(payload)))
+		cell = appendVarint(cell, uint64(i+1))
+
+		local := localSize(len(payload))
+		cell = append(cell, payload[:local]...)
+		if local < len(payload) {
+			cell = binary.BigEndian.AppendUint32(cell, uint32(f.writeOverflow(payload[local:])))
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+// localSize returns how much of a payload is stored on the leaf page
+func localSize(payload int) int {
+	if payload <= maxLocal {
+		return payload
+	}
+	k := minLocal + (payload-minLocal)%(pageSize-4)
+	if k <= maxLocal {
+		return k
+	}
+	return minLocal
+}
+
+// writeOverflow stores data in a chain of overflow pages and returns the
+// first page
+func (f *sqliteFile) writeOverflow(data []byte) int {
+	first := 0
+	var prev []byte
+	for len(data) > 0 {
+		page := f.allocate()
+		if prev != nil {
+			binary.BigEndian.PutUint32(prev, uint32(page))
+		} else {
+			first = page
+		}
+		buf := f.pages[page-1]
+		n := copy(buf[4:], data)
+		data = data[n:]
+		prev = buf
+	}
+	return first
+}
+
+// fits reports whether cells and their pointers fit in space bytes
+func fits(cells [][]byte, space int) bool {
+	total := 0
+	for _, cell := range cells {
+		total += len(cell) + 2
+	}
+	return total <= space
+}
+
+// writeLeaf lays out a table leaf page whose header starts at offset
+func (f *sqliteFile) writeLeaf(page []byte, offset int, cells [][]byte) {
+	writeCells(page, offset, pageTableLeaf, leafHeaderSize, cells)
+}
+
+// writeInterior lays out a table interior page
+func (f *sqliteFile) writeInterior(page []byte, cells [][]byte, rightMost int) {
+	writeCells(page, 0, pageTableInterior, interiorHeaderSize, cells)
+	binary.BigEndian.PutUint32(page[8:], uint32(rightMost))
+}
+
+// writeCells writes a B-tree page header at offset, the cell pointer
+// array after it, and the cells packed at the end of the page
+func writeCells(page []byte, offset int, kind byte, headerSize int, cells [][]byte) {
+	content := len(page)
+	pointers := offset + headerSize
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[pointers+2*i:], uint16(content))
+	}
+
+	page[offset] = kind
+	binary.BigEndian.PutUint16(page[offset+1:], 0) // No freeblocks
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content))
+	page[offset+7] = 0 // No fragmented bytes
+}
+
+// encodeRecord encodes values in the SQLite record format
+func encodeRecord(values []any) ([]byte, error) {
+	var types []uint64
+	var body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = append(types, 0)
+		case bool:
+			if v {
+				types = append(types, 9)
+			} else {
+				types = append(types, 8)
+			}
+		case int:
+			t, b := encodeInt(int64(v))
+			types, body = append(types, t), append(body, b...)
+		case int64:
+			t, b := encodeInt(v)
+			types, body = append(types, t), append(body, b...)
+		case string:
+			types = append(types, uint64(2*len(v)+13))
+			body = append(body, v...)
+		default:
+			return nil, fmt.Errorf("unsupported value type %T", v)
+		}
+	}
+
+	var header []byte
+	for _, t := range types {
+		header = appendVarint(header, t)
+	}
+	// The header size includes its own varint
+	size := len(header) + 1
+	for len(header)+varintLen(uint64(size)) != size {
+		size = len(header) + varintLen(uint64(size))
+	}
+	record := appendVarint(nil, uint64(size))
+	record = append(record, header...)
+	return append(record, body...), nil
+}
+
+// encodeInt returns the smallest serial type holding v and its big-endian
+// bytes
+func encodeInt(v int64) (uint64, []byte) {
+	switch {
+	case v == 0:
+		return 8, nil
+	case v == 1:
+		return 9, nil
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return 1, []byte{byte(v)}
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return 2, binary.BigEndian.AppendUint16(nil, uint16(v))
+	case v >= -1<<23 && v < 1<<23:
+		return 3, []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return 4, binary.BigEndian.AppendUint32(nil, uint32(v))
+	case v >= -1<<47 && v < 1<<47:
+		b := binary.BigEndian.AppendUint64(nil, uint64(v))
+		return 5, b[2:]
+	default:
+		return 6, binary.BigEndian.AppendUint64(nil, uint64(v))
+	}
+}
+
+// appendVarint appends v in SQLite's big-endian variable-length encoding
+func appendVarint(buf []byte, v uint64) []byte {
+	if v > 0x00ffffffffffffff {
+		// Nine bytes: eight of seven bits, then a full last byte
+		var b [9]byte
+		b[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			b[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(buf, b[:]...)
+	}
+
+	var b [8]byte
+	n := 0
+	for {
+		b[n] = byte(v & 0x7f)
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		c := b[i]
+		if i > 0 {
+			c |= 0x80
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// varintLen returns the encoded length of v
+func varintLen(v uint64) int {
+	return len(appendVarint(nil, v))
+}
@@ -0,0 +1,167 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+	"github.com/Sho2010/dup-finder/internal/media"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/progress"
+)
+
+// audioStream is where the audio of a file lies and, once computed, the
+// hash of the audio alone
+type audioStream struct {
+	sections []media.Section
+	length   int64 // Total length of the sections
+	hash     string
+}
+
+// audioStreams holds the audio streams of files by path, nil for files
+// whose container could not be parsed
+type audioStreams map[string]*audioStream
+
+// matchAudio pairs the MP3, FLAC and M4A files of the two directories whose
+// audio streams are identical, whatever their names and tags, so a song
+// whose title or cover art was edited still matches. Only files whose
+// audio is as long as that of a file on the other side are hashed, each
+// once per run. Files are paired within a group as by content. Other files,
+// files whose container cannot be parsed, and files of offline volumes are
+// never matched. Once ctx is done, files not yet hashed are left unmatched.
+func (f *Finder) matchAudio(ctx context.Context, dir1Files, dir2Files []models.FileInfo) (matches []models.FileMatch, found int) {
+	side1, side2 := f.audioFiles(dir1Files), f.audioFiles(dir2Files)
+
+	lengths := func(side []models.FileInfo) map[int64]bool {
+		m := make(map[int64]bool)
+		for _, file := range side {
+			m[f.audio[file.Path].length] = true
+		}
+		return m
+	}
+	lengths1, lengths2 := lengths(side1), lengths(side2)
+	var toHash []*models.FileInfo
+	queued := make(map[string]bool)
+	for _, candidates := range []struct {
+		side  []models.FileInfo
+		other map[int64]bool
+	}{{side1, lengths2}, {side2, lengths1}} {
+		for i := range candidates.side {
+			path := candidates.side[i].Path
+			stream := f.audio[path]
+			if candidates.other[stream.length] && stream.hash == "" && !queued[path] {
+				toHash = append(toHash, &candidates.side[i])
+				queued[path] = true
+			}
+		}
+	}
+	f.hashAudio(ctx, toHash)
+
+	// Group each side by audio
+	type key struct {
+		length int64
+		hash   string
+	}
+	group := func(side []models.FileInfo) map[key][]models.FileInfo {
+		groups := make(map[key][]models.FileInfo)
+		for _, file := range side {
+			if stream := f.audio[file.Path]; stream.hash != "" {
+				k := key{stream.length, stream.hash}
+				groups[k] = append(groups[k], file)
+			}
+		}
+		return groups
+	}
+	groups1, groups2 := group(side1), group(side2)
+
+	for k, files1 := range groups1 {
+		files2 := groups2[k]
+		if len(files2) == 0 {
+			continue
+		}
+		for _, pair := range pairInOrder(files1, files2) {
+			matches = append(matches, models.FileMatch{
+				Filename: pairName(pair[0], pair[1]),
+				File1:    pair[0],
+				File2:    pair[1],
+				Verified: models.CheckAudio,
+			})
+		}
+	}
+
+	sortMatches(matches)
+	found = len(matches)
+	return f.applyMatchLimits(matches), found
+}
+
+// audioFiles returns the audio files among files whose containers parse,
+// finding where their audio lies once per run
+func (f *Finder) audioFiles(files []models.FileInfo) []models.FileInfo {
+	if f.audio == nil {
+		f.audio = make(audioStreams)
+	}
+	var audio []models.FileInfo
+	for _, file := range files {
+		if file.Offline || !media.IsAudio(file.Path) {
+			continue
+		}
+		stream, ok := f.audio[file.Path]
+		if !ok {
+			sections, err := media.AudioSections(file.Path)
+			if err == nil {
+				stream = &audioStream{sections: sections}
+				for _, s := range sections {
+					stream.length += s.Length
+				}
+			}
+			// Files that cannot be parsed are remembered too, as nil
+			f.audio[file.Path] = stream
+		}
+		if stream != nil && stream.length > 0 {
+			audio = append(audio, file)
+		}
+	}
+	return audio
+}
+
+// hashAudio hashes the audio sections of files, which must each have a
+// different path, in parallel, stopping like ComputeHashesParallel once
+// ctx is done. The audio lengths are queued on the progress display.
+func (f *Finder) hashAudio(ctx context.Context, files []*models.FileInfo) {
+	var queued int64
+	for _, file := range files {
+		queued += f.audio[file.Path].length
+	}
+	progress.HashQueued(queued)
+	_ = computeParallel(ctx, files, f.hashWorkers(), func(file *models.FileInfo) error {
+		stream := f.audio[file.Path]
+		hash, err := hashSections(ctx, file.Path, stream.sections)
+		if err != nil {
+			return err
+		}
+		stream.hash = hash
+		return nil
+	})
+}
+
+// hashSections computes the xxHash of the given sections of a file, read
+// in order, giving up with ctx's error once ctx is done
+func hashSections(ctx context.Context, path string, sections []media.Section) (string, error) {
+	file, err := fsio.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := xxhash.New()
+	for _, s := range sections {
+		section := io.NewSectionReader(file, s.Offset, s.Length)
+		if _, err := io.Copy(hash, contextReader{ctx: ctx, r: section, counted: true}); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
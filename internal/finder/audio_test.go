@@ -0,0 +1,42 @@
+package finder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// id3Tagged returns MP3 frames behind an ID3v2 tag holding title
+func id3Tagged(title, frames string) string {
+	n := len(title)
+	return "ID3\x03\x00\x00" + string([]byte{0, 0, byte(n >> 7 & 0x7F), byte(n & 0x7F)}) + title + frames
+}
+
+func TestComparePair_MatchAudio(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "01 Song.mp3", id3Tagged("Song", "\xFF\xFBsong frames")),
+		writeTestFile(t, dir1, "02 Other.mp3", id3Tagged("Other", "\xFF\xFBother frames")),
+		writeTestFile(t, dir1, "notes.txt", "notes"),
+	}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "Song (Remastered).mp3", id3Tagged("Song (Remastered 2024)", "\xFF\xFBsong frames")),
+		writeTestFile(t, dir2, "notes.txt", "notes"),
+	}
+
+	f := NewFinder(models.ScanOptions{Match: MatchAudio, CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Only audio is compared, and the differing tags are ignored
+	require.Len(t, comparison.Matches, 1)
+	match := comparison.Matches[0]
+	assert.Equal(t, "01 Song.mp3 ↔ Song (Remastered).mp3", match.Filename)
+	assert.Equal(t, models.CheckAudio, match.Verified)
+	assert.Empty(t, match.Mismatch, "sizes of retagged songs differ by design")
+	assert.False(t, match.HashChecked)
+	assert.True(t, match.IsDuplicate())
+}
@@ -0,0 +1,140 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Sampling used by the collision audit: auditSamples blocks of
+// auditBlockSize bytes spread evenly from the start to the end of the file
+const (
+	auditSamples   = 16
+	auditBlockSize = 4 * 1024
+)
+
+// Collision is a hash-equal pair whose contents turned out to differ
+type Collision struct {
+	File1 string
+	File2 string
+	Hash  string
+	Check string // Check that exposed the difference (CheckSize or CheckSample)
+}
+
+// AuditCollisions re-checks every hash-equal pair by size and sampled
+// bytes, so a collision of the non-cryptographic hash cannot pass as a
+// duplicate. Colliding matches are marked as mismatches. It returns the
+// number of pairs audited and the collisions found. Once ctx is done the
+// remaining pairs are left unaudited and not counted, like pairs with a
+// file on an offline volume, which cannot be read.
+func (f *Finder) AuditCollisions(ctx context.Context, comparisons []models.PairComparison) (int, []Collision) {
+	var audited []*models.FileMatch
+	for i := range comparisons {
+		for j := range comparisons[i].Matches {
+			m := &comparisons[i].Matches[j]
+			if m.HashChecked && m.HashMatch && m.Mismatch == "" && !m.Offline() {
+				audited = append(audited, m)
+			}
+		}
+	}
+
+	jobs := make(chan *models.FileMatch, len(audited))
+	for _, m := range audited {
+		jobs <- m
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var collisions []Collision
+	count := 0
+	var wg sync.WaitGroup
+	for i := 0; i < f.hashWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				mu.Lock()
+				count++
+				mu.Unlock()
+				check := ""
+				if m.File1.Size != m.File2.Size {
+					check = models.CheckSize
+				} else {
+					equal, err := compareSafely(m, func(path1, path2 string) (bool, error) {
+						return SampledEqual(path1, path2, m.File1.Size)
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error sampling %s and %s: %v\n", m.File1.Path, m.File2.Path, err)
+						continue
+					}
+					if !equal {
+						check = models.CheckSample
+					}
+				}
+				if check == "" {
+					continue
+				}
+
+				m.Mismatch = check
+				mu.Lock()
+				collisions = append(collisions, Collision{
+					File1: m.File1.Path,
+					File2: m.File2.Path,
+					Hash:  m.File1.Hash,
+					Check: check,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return count, collisions
+}
+
+// SampledEqual compares blocks taken at evenly spaced offsets of two files
+// of the given size, always including the first and last block
+func SampledEqual(path1, path2 string, size int64) (bool, error) {
+	f1, err := fsio.Open(path1)
+	if err != nil {
+		return false, err
+	}
+	defer f1.Close()
+
+	f2, err := fsio.Open(path2)
+	if err != nil {
+		return false, err
+	}
+	defer f2.Close()
+
+	buf1 := make([]byte, auditBlockSize)
+	buf2 := make([]byte, auditBlockSize)
+	last := size - auditBlockSize
+	if last < 0 {
+		last = 0
+	}
+	for i := int64(0); i < auditSamples; i++ {
+		offset := last * i / (auditSamples - 1)
+		n1, err := f1.ReadAt(buf1, offset)
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		n2, err := f2.ReadAt(buf2, offset)
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package finder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestAuditCollisions(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	content := strings.Repeat("a", 64*1024)
+	altered := content[:30000] + "b" + content[30001:]
+
+	// Hashes are faked equal to simulate collisions
+	hashMatch := func(f1, f2 models.FileInfo) models.FileMatch {
+		f1.Hash, f2.Hash = "same", "same"
+		return models.FileMatch{File1: f1, File2: f2, HashChecked: true, HashMatch: true, Verified: models.CheckHash}
+	}
+	comparisons := []models.PairComparison{{
+		Dir1: dir1,
+		Dir2: dir2,
+		Matches: []models.FileMatch{
+			hashMatch(writeTestFile(t, dir1, "same.bin", content), writeTestFile(t, dir2, "same.bin", content)),
+			hashMatch(writeTestFile(t, dir1, "middle.bin", content), writeTestFile(t, dir2, "middle.bin", altered)),
+			hashMatch(writeTestFile(t, dir1, "size.bin", content), writeTestFile(t, dir2, "size.bin", content+"x")),
+			// Pairs that were not hash-equal are not audited
+			{File1: models.FileInfo{Path: "/missing/a"}, File2: models.FileInfo{Path: "/missing/b"}, HashChecked: true},
+		},
+	}}
+
+	f := NewFinder(models.ScanOptions{NumWorkers: 2})
+	audited, collisions := f.AuditCollisions(context.Background(), comparisons)
+
+	assert.Equal(t, 3, audited)
+	require.Len(t, collisions, 2)
+	matches := comparisons[0].Matches
+	assert.True(t, matches[0].IsDuplicate())
+	assert.Equal(t, models.CheckSample, matches[1].Mismatch)
+	assert.Equal(t, models.CheckSize, matches[2].Mismatch)
+	assert.False(t, matches[1].IsDuplicate())
+}
+
+func TestSampledEqual_SmallFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a", "tiny")
+	b := writeTestFile(t, dir, "b", "tiny")
+	c := writeTestFile(t, dir, "c", "tina")
+
+	equal, err := SampledEqual(a.Path, b.Path, a.Size)
+	require.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = SampledEqual(a.Path, c.Path, a.Size)
+	require.NoError(t, err)
+	assert.False(t, equal)
+}
@@ -0,0 +1,96 @@
+package finder
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sho2010/dup-finder/internal/devgen"
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+)
+
+// benchTree generates two roots with devgen and scans them. Run at release
+// scale with DUP_FINDER_BENCH_FILES=2000000.
+func benchTree(b *testing.B) (devgen.Stats, models.ScanOptions, map[string][]models.FileInfo) {
+	b.Helper()
+	opts := devgen.DefaultOptions()
+	opts.Files = devgen.ScaleFromEnv(opts.Files)
+	stats, err := devgen.Generate(b.TempDir(), opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	scanOpts := models.ScanOptions{Directories: stats.Roots, Recursive: true, MaxDepth: -1, CompareHash: true, NumWorkers: 8}
+	files, err := scanner.NewScanner(scanOpts).ScanAll(context.Background())
+	if err != nil {
+		b.Fatal(err)
+	}
+	return stats, scanOpts, files
+}
+
+func BenchmarkComparePair(b *testing.B) {
+	stats, scanOpts, files := benchTree(b)
+	dir1, dir2 := files[stats.Roots[0]], files[stats.Roots[1]]
+
+	b.Run("ByName", func(b *testing.B) {
+		byName := scanOpts
+		byName.CompareHash = false
+		for b.Loop() {
+			NewFinder(byName).ComparePair(context.Background(), dir1, dir2)
+		}
+	})
+
+	b.Run("ByHash", func(b *testing.B) {
+		for b.Loop() {
+			comparison := NewFinder(scanOpts).ComparePair(context.Background(), clone(dir1), clone(dir2))
+			if len(comparison.Matches) != stats.Duplicates+stats.Changed {
+				b.Fatalf("found %d matches, want %d", len(comparison.Matches), stats.Duplicates+stats.Changed)
+			}
+		}
+	})
+}
+
+func BenchmarkComputeHashesParallel(b *testing.B) {
+	stats, scanOpts, files := benchTree(b)
+	b.SetBytes(stats.Bytes / int64(len(stats.Roots)))
+
+	for b.Loop() {
+		b.StopTimer()
+		batch := clone(files[stats.Roots[0]])
+		ptrs := make([]*models.FileInfo, len(batch))
+		for i := range batch {
+			ptrs[i] = &batch[i]
+		}
+		b.StartTimer()
+		if err := ComputeHashesParallel(context.Background(), ptrs, scanOpts.NumWorkers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHashWithCache(b *testing.B) {
+	stats, _, files := benchTree(b)
+	idx := index.New(filepath.Join(b.TempDir(), "index.json"))
+	warm := clone(files[stats.Roots[0]])
+	for i := range warm {
+		if err := HashWithCache(&warm[i], idx); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// Every file is answered from the index once it is warm
+	for b.Loop() {
+		batch := clone(files[stats.Roots[0]])
+		for i := range batch {
+			if err := HashWithCache(&batch[i], idx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// clone copies files, so hashes set by one iteration do not carry over
+func clone(files []models.FileInfo) []models.FileInfo {
+	return append([]models.FileInfo(nil), files...)
+}
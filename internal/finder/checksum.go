@@ -0,0 +1,136 @@
+package finder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/checksum"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// checksumKey identifies a digest computed to compare with a checksum file
+type checksumKey struct {
+	algorithm string
+	path      string
+}
+
+// checksumDigests holds the digests computed in a run
+type checksumDigests map[checksumKey]string
+
+// compareChecksums hashes the matches with a file listed by a checksum
+// file, which records a digest rather than an xxHash: the other file's
+// digest of the same algorithm is taken from its own checksum file, or
+// computed if it can be read. Matches whose other file is offline with no
+// such digest stay unverified, like catalogs without hashes.
+func (f *Finder) compareChecksums(ctx context.Context, matches []*models.FileMatch) {
+	var pending []*models.FileMatch
+	for _, m := range matches {
+		if m.Hardlinked || m.Mismatch != "" || !hasChecksum(*m) {
+			continue
+		}
+		pending = append(pending, m)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	byAlgorithm := make(map[string][]*models.FileInfo)
+	for _, m := range pending {
+		algorithm := matchAlgorithm(*m)
+		byAlgorithm[algorithm] = append(byAlgorithm[algorithm], &m.File1, &m.File2)
+	}
+	for algorithm, files := range byAlgorithm {
+		f.computeChecksums(ctx, files, algorithm)
+	}
+
+	for _, m := range pending {
+		algorithm := matchAlgorithm(*m)
+		digest1, digest2 := f.checksum(m.File1, algorithm), f.checksum(m.File2, algorithm)
+		if digest1 == "" || digest2 == "" {
+			continue
+		}
+		m.HashChecked = true
+		m.HashMatch = digest1 == digest2
+		m.Verified, m.Mismatch = verdict(models.CheckHash, m.HashMatch, m.Verified)
+	}
+}
+
+// hasChecksum reports whether a file of m is listed by a checksum file, so
+// m is compared by digest rather than by xxHash
+func hasChecksum(m models.FileMatch) bool {
+	return m.File1.Checksum != "" || m.File2.Checksum != ""
+}
+
+// matchAlgorithm returns the algorithm of the checksum recorded for m's
+// first file that has one
+func matchAlgorithm(m models.FileMatch) string {
+	value := m.File1.Checksum
+	if value == "" {
+		value = m.File2.Checksum
+	}
+	algorithm, _ := checksum.Split(value)
+	return algorithm
+}
+
+// checksum returns the digest of file with algorithm, as its checksum file
+// records it or as computed earlier in the run, or "" if neither knows it
+func (f *Finder) checksum(file models.FileInfo, algorithm string) string {
+	if recorded, digest := checksum.Split(file.Checksum); recorded == algorithm && digest != "" {
+		return digest
+	}
+	return f.checksums[checksumKey{algorithm, file.Path}]
+}
+
+// computeChecksums computes in parallel the digests with algorithm of the
+// files that can be read and whose digest is not yet known, each once per
+// run
+func (f *Finder) computeChecksums(ctx context.Context, files []*models.FileInfo, algorithm string) {
+	if f.checksums == nil {
+		f.checksums = make(checksumDigests)
+	}
+	var toHash []*models.FileInfo
+	queued := make(map[string]bool)
+	for _, file := range files {
+		if file.Offline || queued[file.Path] || f.checksum(*file, algorithm) != "" {
+			continue
+		}
+		toHash = append(toHash, file)
+		queued[file.Path] = true
+	}
+
+	var mu sync.Mutex
+	_ = computeParallel(ctx, toHash, f.hashWorkers(), func(file *models.FileInfo) error {
+		digest, err := checksum.Sum(file.Path, algorithm)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		f.checksums[checksumKey{algorithm, file.Path}] = digest
+		mu.Unlock()
+		return nil
+	})
+}
+
+// FillChecksums records in Checksum the digest with algorithm of each file
+// of the duplicate matches of comparisons, as written by the checksum
+// output formats, computing those not yet known. It is called once the
+// matches are compared, as the matches of files given a checksum would be
+// compared by it. Files of offline volumes
+// whose catalog records no such digest are left as they are.
+func (f *Finder) FillChecksums(ctx context.Context, comparisons []models.PairComparison, algorithm string) {
+	var files []*models.FileInfo
+	for i := range comparisons {
+		for j := range comparisons[i].Matches {
+			m := &comparisons[i].Matches[j]
+			if m.IsDuplicate() {
+				files = append(files, &m.File1, &m.File2)
+			}
+		}
+	}
+	f.computeChecksums(ctx, files, algorithm)
+	for _, file := range files {
+		if digest := f.checksum(*file, algorithm); digest != "" {
+			file.Checksum = checksum.Value(algorithm, digest)
+		}
+	}
+}
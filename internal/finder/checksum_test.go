@@ -0,0 +1,79 @@
+package finder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/checksum"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// listed returns a file as a checksum file lists it: offline, without a
+// size, and with its digest
+func listed(path, algorithm, digest string) models.FileInfo {
+	return models.FileInfo{Path: path, Directory: "catalog:/sums", Offline: true, SizeUnknown: true, Checksum: checksum.Value(algorithm, digest)}
+}
+
+func TestComparePair_Checksums(t *testing.T) {
+	dir := t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir, "same.txt", "hello\n"),
+		writeTestFile(t, dir, "changed.txt", "hello again\n"),
+		writeTestFile(t, dir, "crc.txt", "hello\n"),
+	}
+	files2 := []models.FileInfo{
+		listed("/sums/same.txt", checksum.SHA256, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"),
+		listed("/sums/changed.txt", checksum.SHA256, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"),
+		listed("/sums/crc.txt", checksum.CRC32, "363a3020"),
+	}
+
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 3)
+
+	byName := make(map[string]models.FileMatch)
+	for _, m := range comparison.Matches {
+		byName[m.Filename] = m
+	}
+	for _, name := range []string{"same.txt", "crc.txt"} {
+		assert.True(t, byName[name].HashChecked, name)
+		assert.True(t, byName[name].IsDuplicate(), name)
+		assert.Equal(t, models.CheckHash, byName[name].Verified, name)
+	}
+	changed := byName["changed.txt"]
+	assert.True(t, changed.HashChecked)
+	assert.Equal(t, models.CheckHash, changed.Mismatch, "the size the list lacks is not what tells the files apart")
+	assert.Empty(t, changed.File1.Hash, "files compared by digest are not hashed again")
+}
+
+func TestComparePair_ChecksumsBothListed(t *testing.T) {
+	digest := "b1946ac92492d2347c6235b4d2611184"
+	files1 := []models.FileInfo{listed("/old/a.txt", checksum.MD5, digest), listed("/old/b.txt", checksum.MD5, digest)}
+	files2 := []models.FileInfo{listed("/new/a.txt", checksum.MD5, digest), listed("/new/b.txt", checksum.SHA256, digest+digest)}
+
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 2)
+	assert.True(t, comparison.Matches[0].HashChecked, "digests of one algorithm compare")
+	assert.False(t, comparison.Matches[1].HashChecked, "digests of two algorithms cannot")
+}
+
+func TestFillChecksums(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "hello\n")
+	b := writeTestFile(t, dir, "b.txt", "hello\n")
+	comparisons := []models.PairComparison{{Matches: []models.FileMatch{
+		{File1: a, File2: b},
+		{File1: a, File2: listed("/offline/c.txt", checksum.SHA256, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03")},
+	}}}
+
+	NewFinder(models.ScanOptions{}).FillChecksums(context.Background(), comparisons, checksum.MD5)
+	matches := comparisons[0].Matches
+	assert.Equal(t, "md5:b1946ac92492d2347c6235b4d2611184", matches[0].File1.Checksum)
+	assert.Equal(t, "md5:b1946ac92492d2347c6235b4d2611184", matches[0].File2.Checksum)
+	assert.Equal(t, "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", matches[1].File2.Checksum,
+		"offline files keep the digest their list records")
+}
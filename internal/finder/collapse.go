@@ -0,0 +1,65 @@
+package finder
+
+import (
+	"path/filepath"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// CollapseRepeatedMatches keeps a single match for each pair of physical
+// files found by more than one directory pair, as happens with overlapping
+// roots or roots reached through symlinks. The first match is kept and the
+// directory pairs of the others are listed in its AlsoIn, so counts and
+// savings see the files once. It returns the number of matches removed.
+func CollapseRepeatedMatches(comparisons []models.PairComparison) int {
+	type location struct{ comparison, match int }
+	first := make(map[[2]string]location)
+	removed := 0
+
+	for i := range comparisons {
+		c := &comparisons[i]
+		kept := c.Matches[:0]
+		for _, m := range c.Matches {
+			key := physicalKey(m)
+			loc, seen := first[key]
+			if !seen {
+				first[key] = location{i, len(kept)}
+				kept = append(kept, m)
+				continue
+			}
+
+			original := &comparisons[loc.comparison].Matches[loc.match]
+			original.AlsoIn = append(original.AlsoIn, models.MatchContext{
+				Dir1:  c.Dir1,
+				Dir2:  c.Dir2,
+				Path1: m.File1.Path,
+				Path2: m.File2.Path,
+			})
+			removed++
+		}
+		c.Matches = kept
+	}
+	return removed
+}
+
+// physicalKey identifies the two files of a match by their resolved
+// paths, independent of order and of the root they were found through
+func physicalKey(m models.FileMatch) [2]string {
+	a, b := resolvePath(m.File1.Path), resolvePath(m.File2.Path)
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// resolvePath returns the absolute path with symlinks resolved, or the
+// cleaned absolute path if it cannot be resolved
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,67 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestCollapseRepeatedMatches(t *testing.T) {
+	root := t.TempDir()
+	photos := filepath.Join(root, "photos")
+	backup := filepath.Join(root, "backup")
+	require.NoError(t, os.MkdirAll(photos, 0755))
+	require.NoError(t, os.MkdirAll(backup, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "a.jpg"), []byte("same"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(backup, "a.jpg"), []byte("same"), 0644))
+
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(photos, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	file := func(dir string) models.FileInfo {
+		return models.FileInfo{Path: filepath.Join(dir, "a.jpg"), Directory: dir, Size: 4}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: photos, Dir2: backup, Matches: []models.FileMatch{{Filename: "a.jpg", File1: file(photos), File2: file(backup)}}},
+		{Dir1: photos, Dir2: link, Matches: []models.FileMatch{{Filename: "a.jpg", File1: file(photos), File2: file(link)}}},
+		// The same two files again, reached through the symlinked root
+		{Dir1: backup, Dir2: link, Matches: []models.FileMatch{{Filename: "a.jpg", File1: file(backup), File2: file(link)}}},
+	}
+
+	removed := CollapseRepeatedMatches(comparisons)
+
+	assert.Equal(t, 1, removed)
+	require.Len(t, comparisons[0].Matches, 1)
+	assert.Len(t, comparisons[1].Matches, 1)
+	assert.Empty(t, comparisons[2].Matches)
+
+	require.Len(t, comparisons[0].Matches[0].AlsoIn, 1)
+	assert.Equal(t, models.MatchContext{
+		Dir1:  backup,
+		Dir2:  link,
+		Path1: filepath.Join(backup, "a.jpg"),
+		Path2: filepath.Join(link, "a.jpg"),
+	}, comparisons[0].Matches[0].AlsoIn[0])
+}
+
+func TestCollapseRepeatedMatches_Distinct(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{
+			{Filename: "x", File1: models.FileInfo{Path: "/a/x"}, File2: models.FileInfo{Path: "/b/x"}},
+		}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "x", File1: models.FileInfo{Path: "/a/x"}, File2: models.FileInfo{Path: "/c/x"}},
+		}},
+	}
+
+	assert.Equal(t, 0, CollapseRepeatedMatches(comparisons))
+	assert.Len(t, comparisons[0].Matches, 1)
+	assert.Len(t, comparisons[1].Matches, 1)
+}
@@ -1,37 +1,131 @@
 package finder
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"sync"
 
 	"github.com/cespare/xxhash/v2"
 
+	"github.com/Sho2010/dup-finder/internal/fsio"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/panics"
+	"github.com/Sho2010/dup-finder/internal/progress"
 )
 
 // CalculateFileHash computes the xxHash hash of a file
 func CalculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+	return hashFile(context.Background(), filePath, false)
+}
+
+// hashFile computes the xxHash hash of a file, giving up with ctx's error
+// once ctx is done. With counted set, the bytes read count towards the
+// progress display.
+func hashFile(ctx context.Context, filePath string, counted bool) (string, error) {
+	file, err := fsio.Open(filePath)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
 	hash := xxhash.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(hash, contextReader{ctx: ctx, r: file, counted: counted}); err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-// ComputeHashesParallel computes hashes for multiple files in parallel
-func ComputeHashesParallel(files []*models.FileInfo, numWorkers int) error {
+// contextReader fails every read once ctx is done, so reading a long file
+// can be abandoned part way
+type contextReader struct {
+	ctx     context.Context
+	r       io.Reader
+	counted bool // Report the bytes read to progress.Hashed
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if c.counted {
+		progress.Hashed(int64(n))
+	}
+	return n, err
+}
+
+// ComputeHashesParallel computes hashes for multiple files in parallel,
+// reading each whole. Once ctx is done no further file is hashed, files
+// being hashed are abandoned without a hash, and ctx's error is returned.
+// The files' sizes are queued on the progress display and the bytes read
+// counted as hashed. Files are hashed smallest first (see scheduleFiles).
+func ComputeHashesParallel(ctx context.Context, files []*models.FileInfo, numWorkers int) error {
+	return computeHashes(ctx, files, numWorkers, models.ScanOptions{})
+}
+
+// ComputeHashes computes hashes like ComputeHashesParallel with
+// opts.NumWorkers workers, hashing files of at least opts.LargeFileSize in
+// resumable chunks
+func ComputeHashes(ctx context.Context, files []*models.FileInfo, opts models.ScanOptions) error {
+	return computeHashes(ctx, files, opts.NumWorkers, opts)
+}
+
+// computeHashes hashes files with numWorkers workers, handling large files
+// as opts sets out
+func computeHashes(ctx context.Context, files []*models.FileInfo, numWorkers int, opts models.ScanOptions) error {
+	var queued int64
+	for _, file := range files {
+		queued += file.Size
+	}
+	progress.HashQueued(queued)
+	return computeParallel(ctx, scheduleFiles(files), numWorkers, func(file *models.FileInfo) error {
+		if isLarge(opts, file.Size) {
+			hash, err := hashLargeFile(ctx, *file, opts)
+			if err != nil {
+				return err
+			}
+			file.Hash = hash
+			return nil
+		}
+		hash, err := hashFile(ctx, file.Path, true)
+		if err != nil {
+			return err
+		}
+		file.Hash = hash
+		return nil
+	})
+}
+
+// ComputePartialHashesParallel computes partial hashes for multiple files
+// in parallel, stopping like ComputeHashesParallel once ctx is done
+func ComputePartialHashesParallel(ctx context.Context, files []*models.FileInfo, numWorkers int) error {
+	return computeParallel(ctx, files, numWorkers, func(file *models.FileInfo) error {
+		hash, err := CalculatePartialHash(file.Path, file.Size)
+		if err != nil {
+			return err
+		}
+		file.PartialHash = hash
+		return nil
+	})
+}
+
+// computeParallel runs fn for every file using numWorkers goroutines
+// (the CPU count if numWorkers is not positive), skipping the files left
+// once ctx is done. Errors are logged to stderr and the first one is
+// returned; an interrupted run returns ctx's error instead. A panic in fn
+// is an error for its file, and the worker goes on with the next one.
+func computeParallel(ctx context.Context, files []*models.FileInfo, numWorkers int, fn func(*models.FileInfo) error) error {
 	if len(files) == 0 {
 		return nil
 	}
+	if numWorkers < 1 {
+		numWorkers = runtime.NumCPU()
+	}
 
 	jobs := make(chan *models.FileInfo, len(files))
 	errors := make(chan error, len(files))
@@ -43,12 +137,13 @@ func ComputeHashesParallel(files []*models.FileInfo, numWorkers int) error {
 		go func() {
 			defer wg.Done()
 			for file := range jobs {
-				hash, err := CalculateFileHash(file.Path)
-				if err != nil {
-					errors <- fmt.Errorf("error hashing %s: %w", file.Path, err)
+				if ctx.Err() != nil {
 					continue
 				}
-				file.Hash = hash
+				err := panics.Do(file.Path, func() error { return fn(file) })
+				if err != nil && ctx.Err() == nil {
+					errors <- fmt.Errorf("error hashing %s: %w", file.Path, err)
+				}
 			}
 		}()
 	}
@@ -73,5 +168,154 @@ func ComputeHashesParallel(files []*models.FileInfo, numWorkers int) error {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return firstError
 }
+
+// partialBlockSize is how much of each end of a file the partial hash reads
+const partialBlockSize = 64 * 1024
+
+// CalculatePartialHash hashes the first and last 64KB of a file together with
+// its size. Files no larger than two blocks are hashed in full, so for them
+// the partial hash is conclusive.
+func CalculatePartialHash(filePath string, size int64) (string, error) {
+	file, err := fsio.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := xxhash.New()
+	fmt.Fprintf(hash, "%d:", size)
+
+	if size <= 2*partialBlockSize {
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	}
+
+	if _, err := io.CopyN(hash, file, partialBlockSize); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(size-partialBlockSize, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, err := io.CopyN(hash, file, partialBlockSize); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// compareSafely runs equal on the files of m from a worker, returning a
+// panic in it as an error for the pair instead of ending the run
+func compareSafely(m *models.FileMatch, equal func(path1, path2 string) (bool, error)) (result bool, err error) {
+	err = panics.Do(m.File1.Path+" and "+m.File2.Path, func() error {
+		result, err = equal(m.File1.Path, m.File2.Path)
+		return err
+	})
+	return result, err
+}
+
+// FilesEqual compares two files byte by byte
+func FilesEqual(path1, path2 string) (bool, error) {
+	f1, err := fsio.Open(path1)
+	if err != nil {
+		return false, err
+	}
+	defer f1.Close()
+
+	f2, err := fsio.Open(path2)
+	if err != nil {
+		return false, err
+	}
+	defer f2.Close()
+
+	const chunkSize = 256 * 1024
+	buf1 := make([]byte, chunkSize)
+	buf2 := make([]byte, chunkSize)
+	for {
+		n1, err1 := io.ReadFull(f1, buf1)
+		n2, err2 := io.ReadFull(f2, buf2)
+		if n1 != n2 || !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return false, nil
+		}
+
+		end1 := err1 == io.EOF || err1 == io.ErrUnexpectedEOF
+		end2 := err2 == io.EOF || err2 == io.ErrUnexpectedEOF
+		if err1 != nil && !end1 {
+			return false, err1
+		}
+		if err2 != nil && !end2 {
+			return false, err2
+		}
+		if end1 || end2 {
+			return end1 && end2, nil
+		}
+	}
+}
+
+// HashCache stores previously computed hashes between runs
+type HashCache interface {
+	Lookup(file models.FileInfo) (string, bool)
+	Store(file models.FileInfo)
+}
+
+// FillFromCache sets the hash of each file of files that cache recorded
+// while the file had its current size and mtime, so only new and changed
+// files are hashed. Files of offline volumes and files already hashed are
+// left as they are. It returns the number of hashes filled in.
+func FillFromCache(files []models.FileInfo, cache HashCache) int {
+	filled := 0
+	for i := range files {
+		if files[i].Hash != "" || files[i].Offline {
+			continue
+		}
+		if hash, ok := cache.Lookup(files[i]); ok {
+			files[i].Hash = hash
+			filled++
+		}
+	}
+	return filled
+}
+
+// StoreHashes records in cache the hashes of the files of comparisons'
+// matches, except files of offline volumes, for FillFromCache in later runs
+func StoreHashes(comparisons []models.PairComparison, cache HashCache) {
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			for _, file := range []models.FileInfo{match.File1, match.File2} {
+				if file.Hash != "" && !file.Offline {
+					cache.Store(file)
+				}
+			}
+		}
+	}
+}
+
+// HashWithCache fills file.Hash from cache when possible, otherwise computes
+// it and records the result. A nil cache always computes.
+func HashWithCache(file *models.FileInfo, cache HashCache) error {
+	if file.Hash != "" {
+		return nil
+	}
+	if cache != nil {
+		if hash, ok := cache.Lookup(*file); ok {
+			file.Hash = hash
+			return nil
+		}
+	}
+
+	hash, err := CalculateFileHash(file.Path)
+	if err != nil {
+		return err
+	}
+	file.Hash = hash
+
+	if cache != nil {
+		cache.Store(*file)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package finder
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/panics"
 )
 
 func TestCalculateFileHash(t *testing.T) {
@@ -114,7 +116,7 @@ func TestComputeHashesParallel(t *testing.T) {
 	}
 
 	// Compute hashes in parallel
-	err := ComputeHashesParallel(fileInfos, 2)
+	err := ComputeHashesParallel(context.Background(), fileInfos, 2)
 	require.NoError(t, err)
 
 	// Verify all files have hashes
@@ -131,8 +133,111 @@ func TestComputeHashesParallel(t *testing.T) {
 	}
 }
 
+func TestComputeHashesParallel_InvalidWorkerCount(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+
+	for _, workers := range []int{0, -1, -100} {
+		file := &models.FileInfo{Path: path, Directory: tmpDir, Size: 7}
+		require.NoError(t, ComputeHashesParallel(context.Background(), []*models.FileInfo{file}, workers))
+		assert.NotEmpty(t, file.Hash, "workers=%d", workers)
+	}
+}
+
 func TestComputeHashesParallel_EmptyList(t *testing.T) {
 	var fileInfos []*models.FileInfo
-	err := ComputeHashesParallel(fileInfos, 2)
+	err := ComputeHashesParallel(context.Background(), fileInfos, 2)
+	require.NoError(t, err)
+}
+
+func TestCalculatePartialHash(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Large files that differ only in the middle share a partial hash
+	size := 3 * partialBlockSize
+	content1 := make([]byte, size)
+	content2 := make([]byte, size)
+	content2[size/2] = 1
+
+	file1 := filepath.Join(tmpDir, "file1.bin")
+	file2 := filepath.Join(tmpDir, "file2.bin")
+	require.NoError(t, os.WriteFile(file1, content1, 0644))
+	require.NoError(t, os.WriteFile(file2, content2, 0644))
+
+	hash1, err := CalculatePartialHash(file1, int64(size))
+	require.NoError(t, err)
+	hash2, err := CalculatePartialHash(file2, int64(size))
+	require.NoError(t, err)
+	assert.Equal(t, hash1, hash2)
+
+	// A difference in the last block is detected
+	content2[size-1] = 1
+	require.NoError(t, os.WriteFile(file2, content2, 0644))
+	hash2, err = CalculatePartialHash(file2, int64(size))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash1, hash2)
+}
+
+func TestFilesEqual(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	write := func(name string, content []byte) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, content, 0644))
+		return path
+	}
+
+	big := make([]byte, 600*1024)
+	a := write("a.bin", big)
+	b := write("b.bin", big)
+	changed := append([]byte{}, big...)
+	changed[len(changed)-1] = 1
+	c := write("c.bin", changed)
+	d := write("d.bin", big[:len(big)-1])
+
+	equal, err := FilesEqual(a, b)
+	require.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = FilesEqual(a, c)
 	require.NoError(t, err)
+	assert.False(t, equal)
+
+	equal, err = FilesEqual(a, d)
+	require.NoError(t, err)
+	assert.False(t, equal, "a prefix is not equal")
+
+	_, err = FilesEqual(a, filepath.Join(tmpDir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestComputeHashesParallel_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, "a.txt", "content")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := ComputeHashesParallel(ctx, []*models.FileInfo{&file}, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, file.Hash)
+}
+
+func TestComputeParallel_Panic(t *testing.T) {
+	defer panics.Reset()
+	files := []*models.FileInfo{{Path: "/a"}, {Path: "/fuzz/crash"}, {Path: "/b"}}
+
+	err := computeParallel(context.Background(), files, 1, func(file *models.FileInfo) error {
+		if file.Path == "/fuzz/crash" {
+			panic("unexpected header")
+		}
+		file.Hash = "h"
+		return nil
+	})
+	var panicErr *panics.Error
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "/fuzz/crash", panicErr.Path)
+	assert.Equal(t, "h", files[0].Hash)
+	assert.Equal(t, "h", files[2].Hash, "the worker goes on after a panic")
+	assert.Len(t, panics.Recovered(), 1)
 }
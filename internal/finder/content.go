@@ -0,0 +1,213 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Ways to pair files across directories (ScanOptions.Match)
+const (
+	MatchName    = "name"    // Files with the same name, checked by hash only with CompareHash
+	MatchRelPath = "relpath" // Files at the same path relative to their root directories
+	MatchContent = "content" // Files with the same size and hash, whatever their names
+	MatchFuzzy   = "fuzzy"   // Files of the same size whose names differ only by copy markers, checked by hash
+	MatchAudio   = "audio"   // MP3, FLAC and M4A files with the same audio stream, whatever their tags
+)
+
+// MatchModes lists the supported ways to pair files
+var MatchModes = []string{MatchName, MatchRelPath, MatchContent, MatchFuzzy, MatchAudio}
+
+// ValidateMatch checks a --match value
+func ValidateMatch(mode string) error {
+	for _, m := range MatchModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --match mode %q (supported: %s)", mode, strings.Join(MatchModes, ", "))
+}
+
+// matchesContent reports whether files are paired by content instead of name
+func (f *Finder) matchesContent() bool {
+	return f.options.Match == MatchContent
+}
+
+// matchesWholeFiles reports whether matches pair files that are identical
+// as a whole, and so have the same size and hash. Similar images and audio
+// files with different tags do not.
+func (f *Finder) matchesWholeFiles() bool {
+	return !f.options.Perceptual && f.options.Match != MatchAudio
+}
+
+// hashesMatches reports whether matches are hashed once found: with
+// CompareHash, and always with MatchFuzzy, whose names alone say little.
+// Matches by content are hashed to be found, and matches that are not of
+// whole files are not expected to hash alike.
+func (f *Finder) hashesMatches() bool {
+	if !f.matchesWholeFiles() {
+		return false
+	}
+	return f.options.CompareHash && !f.matchesContent() || f.options.Match == MatchFuzzy
+}
+
+// findMatches finds the matches of a pair by name, by relative path with
+// MatchRelPath, by content with MatchContent, by copy variants of names
+// with MatchFuzzy, by audio stream with MatchAudio, or images that look
+// alike with Perceptual, sorted by filename and cut to the match limits.
+// With NormalizeNames, names and paths are compared composed. found is the
+// count before the limits were applied.
+func (f *Finder) findMatches(ctx context.Context, dir1Files, dir2Files []models.FileInfo) ([]models.FileMatch, int) {
+	if f.options.Perceptual {
+		return f.matchPerceptual(ctx, dir1Files, dir2Files)
+	}
+	name := baseName
+	switch f.options.Match {
+	case MatchContent:
+		return f.matchContent(ctx, dir1Files, dir2Files)
+	case MatchFuzzy:
+		return f.matchFuzzy(dir1Files, dir2Files)
+	case MatchAudio:
+		return f.matchAudio(ctx, dir1Files, dir2Files)
+	case MatchRelPath:
+		name = relativePath
+	}
+	if f.options.NormalizeNames {
+		return f.matchNames(dir1Files, dir2Files, composed(name))
+	}
+	return f.matchNames(dir1Files, dir2Files, name)
+}
+
+// matchContent finds the files of the two directories with identical
+// contents, whatever their names. Only files sharing a size and partial
+// hash with a file on the other side are hashed in full, each once per
+// run. Within a group of identical files, the files of each side are
+// paired in path order and any left over are paired with the first file
+// of the other side, so every file appears in a match without listing
+// every combination. Empty files have no content to compare and are never
+// matched. Once ctx is done, files not yet hashed are left unmatched.
+func (f *Finder) matchContent(ctx context.Context, dir1Files, dir2Files []models.FileInfo) (matches []models.FileMatch, found int) {
+	sizes2 := make(map[int64]bool)
+	for _, file := range dir2Files {
+		sizes2[file.Size] = true
+	}
+	var side1, side2 []models.FileInfo
+	sizes1 := make(map[int64]bool)
+	for _, file := range dir1Files {
+		if file.Size > 0 && sizes2[file.Size] {
+			side1 = append(side1, file)
+			sizes1[file.Size] = true
+		}
+	}
+	for _, file := range dir2Files {
+		if sizes1[file.Size] {
+			side2 = append(side2, file)
+		}
+	}
+
+	side1, side2 = f.narrowByPartialHash(ctx, side1, side2)
+	f.hashContent(ctx, side1, side2)
+
+	// Group each side by content
+	type key struct {
+		size int64
+		hash string
+	}
+	groups1 := make(map[key][]models.FileInfo)
+	for _, file := range side1 {
+		if file.Hash != "" {
+			groups1[key{file.Size, file.Hash}] = append(groups1[key{file.Size, file.Hash}], file)
+		}
+	}
+	groups2 := make(map[key][]models.FileInfo)
+	for _, file := range side2 {
+		if file.Hash != "" {
+			groups2[key{file.Size, file.Hash}] = append(groups2[key{file.Size, file.Hash}], file)
+		}
+	}
+
+	for k, files1 := range groups1 {
+		files2 := groups2[k]
+		if len(files2) == 0 {
+			continue
+		}
+		for _, pair := range pairInOrder(files1, files2) {
+			matches = append(matches, contentMatch(pair[0], pair[1]))
+		}
+	}
+
+	sortMatches(matches)
+	found = len(matches)
+	return f.applyMatchLimits(matches), found
+}
+
+// pairInOrder pairs the files of each side of a group in path order, and
+// any left over with the first file of the other side. files1 and files2
+// must not be empty.
+func pairInOrder(files1, files2 []models.FileInfo) [][2]models.FileInfo {
+	sort.Slice(files1, func(i, j int) bool { return files1[i].Path < files1[j].Path })
+	sort.Slice(files2, func(i, j int) bool { return files2[i].Path < files2[j].Path })
+	pairs := make([][2]models.FileInfo, max(len(files1), len(files2)))
+	for i := range pairs {
+		pairs[i] = [2]models.FileInfo{files1[0], files2[0]}
+		if i < len(files1) {
+			pairs[i][0] = files1[i]
+		}
+		if i < len(files2) {
+			pairs[i][1] = files2[i]
+		}
+	}
+	return pairs
+}
+
+// pairName names a match after both files when their names differ
+func pairName(file1, file2 models.FileInfo) string {
+	name := filepath.Base(file1.Path)
+	if other := filepath.Base(file2.Path); other != name {
+		name += " ↔ " + other
+	}
+	return name
+}
+
+// contentMatch returns the match of two files found identical by hash
+func contentMatch(file1, file2 models.FileInfo) models.FileMatch {
+	return models.FileMatch{
+		Filename:    pairName(file1, file2),
+		File1:       file1,
+		File2:       file2,
+		HashChecked: true,
+		HashMatch:   true,
+		Verified:    models.CheckHash,
+	}
+}
+
+// hashContent fills in the hashes of files, hashing only those not hashed
+// earlier in the run. Files on offline volumes keep the hash their catalog
+// records, if any.
+func (f *Finder) hashContent(ctx context.Context, sides ...[]models.FileInfo) {
+	if f.hashes == nil {
+		f.hashes = make(map[string]string)
+	}
+	var toHash []*models.FileInfo
+	for _, side := range sides {
+		for i := range side {
+			if hash, ok := f.hashes[side[i].Path]; ok {
+				side[i].Hash = hash
+			} else if side[i].Hash == "" && !side[i].Offline {
+				toHash = append(toHash, &side[i])
+			}
+		}
+	}
+	_ = computeHashes(ctx, toHash, f.hashWorkers(), f.options)
+	for _, side := range sides {
+		for _, file := range side {
+			if file.Hash != "" {
+				f.hashes[file.Path] = file.Hash
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package finder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestComparePair_MatchContent(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "IMG_1234.jpg", "beach"),
+		writeTestFile(t, dir1, "notes.txt", "draft 1"),
+		writeTestFile(t, dir1, "empty.txt", ""),
+	}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "beach.jpg", "beach"),
+		writeTestFile(t, dir2, "notes.txt", "draft 2"),
+		writeTestFile(t, dir2, "blank.txt", ""),
+	}
+
+	f := NewFinder(models.ScanOptions{Match: MatchContent})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	require.Len(t, comparison.Matches, 1, "same-named files that differ and empty files are not matched")
+	match := comparison.Matches[0]
+	assert.Equal(t, "IMG_1234.jpg ↔ beach.jpg", match.Filename)
+	assert.Equal(t, files1[0].Path, match.File1.Path)
+	assert.Equal(t, files2[0].Path, match.File2.Path)
+	assert.True(t, match.HashChecked)
+	assert.True(t, match.IsDuplicate())
+	assert.Equal(t, models.CheckHash, match.Verified)
+}
+
+func TestComparePair_MatchContentGroups(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "a.jpg", "same"),
+		writeTestFile(t, dir1, "b.jpg", "same"),
+		writeTestFile(t, dir1, "c.jpg", "same"),
+	}
+	files2 := []models.FileInfo{writeTestFile(t, dir2, "copy.jpg", "same")}
+
+	f := NewFinder(models.ScanOptions{Match: MatchContent})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Every file of the group is in a match, so they merge into one set
+	require.Len(t, comparison.Matches, 3)
+	sets := MergeMatches([]models.PairComparison{comparison})
+	require.Len(t, sets, 1)
+	assert.Len(t, sets[0].Files, 4)
+}
+
+func TestComparePair_MatchContentReusesHashes(t *testing.T) {
+	dir1, dir2, dir3 := t.TempDir(), t.TempDir(), t.TempDir()
+	a := writeTestFile(t, dir1, "a.jpg", "same")
+	b := writeTestFile(t, dir2, "b.jpg", "same")
+	c := writeTestFile(t, dir3, "c.jpg", "same")
+
+	f := NewFinder(models.ScanOptions{Match: MatchContent})
+	f.ComparePair(context.Background(), []models.FileInfo{a}, []models.FileInfo{b})
+	require.Contains(t, f.hashes, a.Path)
+
+	// A hash from an earlier pair is used without reading the file again
+	f.hashes[a.Path] = "stale"
+	comparison := f.ComparePair(context.Background(), []models.FileInfo{a}, []models.FileInfo{c})
+	assert.Empty(t, comparison.Matches)
+}
+
+func TestComparePair_Offline(t *testing.T) {
+	dir := t.TempDir()
+	local := []models.FileInfo{
+		writeTestFile(t, dir, "beach.jpg", "beach"),
+		writeTestFile(t, dir, "notes.txt", "draft"),
+	}
+	hash, err := CalculateFileHash(local[0].Path)
+	require.NoError(t, err)
+	// Files listed in a catalog, which do not exist here
+	offline := []models.FileInfo{
+		{Path: "/mnt/backup/IMG_1.jpg", Directory: "catalog:backup.tsv", Size: 5, Hash: hash, Offline: true},
+		{Path: "/mnt/backup/notes.txt", Directory: "catalog:backup.tsv", Size: 5, Offline: true},
+	}
+
+	// By content, the catalog's hash is compared with the local file's
+	f := NewFinder(models.ScanOptions{Match: MatchContent})
+	comparison := f.ComparePair(context.Background(), local, offline)
+	require.Len(t, comparison.Matches, 1)
+	assert.Equal(t, "beach.jpg ↔ IMG_1.jpg", comparison.Matches[0].Filename)
+
+	// By name, a file without a recorded hash is left unverified
+	f = NewFinder(models.ScanOptions{CompareHash: true})
+	comparison = f.ComparePair(context.Background(), local, offline)
+	require.Len(t, comparison.Matches, 1)
+	match := comparison.Matches[0]
+	assert.Equal(t, "notes.txt", match.Filename)
+	assert.False(t, match.HashChecked)
+	assert.False(t, match.Hardlinked)
+	assert.NotEmpty(t, match.File1.Hash, "the local file is still hashed")
+}
+
+func TestValidateMatch(t *testing.T) {
+	assert.NoError(t, ValidateMatch(MatchName))
+	assert.NoError(t, ValidateMatch(MatchRelPath))
+	assert.NoError(t, ValidateMatch(MatchContent))
+	assert.NoError(t, ValidateMatch(MatchFuzzy))
+	assert.NoError(t, ValidateMatch(MatchAudio))
+	assert.ErrorContains(t, ValidateMatch("size"), "unknown --match mode")
+}
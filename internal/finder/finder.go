@@ -1,107 +1,338 @@
 package finder
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/Sho2010/dup-finder/internal/fsio"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/nfc"
 )
 
 // Finder handles duplicate file detection
 type Finder struct {
-	options models.ScanOptions
+	options       models.ScanOptions
+	totalMatches  int               // Matches reported so far across all pairs
+	hashes        map[string]string // Hashes by path computed to match by content
+	partialHashes map[string]string // Partial hashes by path computed before full hashes
+	dhashes       map[string]uint64 // Perceptual hashes by path computed to pair similar images
+	audio         audioStreams      // Audio streams found to match by audio
+	checksums     checksumDigests   // Digests computed to compare with checksum files
+	normalizers   []Normalizer      // Reduce copy variants of names to match with MatchFuzzy
 }
 
 // NewFinder creates a new finder with the given options
 func NewFinder(opts models.ScanOptions) *Finder {
-	return &Finder{options: opts}
+	return &Finder{options: opts, normalizers: CopyVariants}
 }
 
-// ComparePair compares files from two directories and finds matches by name
-func (f *Finder) ComparePair(dir1Files, dir2Files []models.FileInfo) models.PairComparison {
-	// Group files by basename
-	group1 := groupByName(dir1Files)
-	group2 := groupByName(dir2Files)
+// ComparePair compares files from two directories and finds matches by
+// name, or as findMatches describes with other match modes. Matches that
+// are not hashed are still compared by size, except similar images and
+// audio files, which differ in size by design, and with VerifyBytes those
+// found equal by hash are compared byte by byte. Once ctx is done, matches
+// by name are still found but no longer hashed.
+func (f *Finder) ComparePair(ctx context.Context, dir1Files, dir2Files []models.FileInfo) models.PairComparison {
+	matches, found := f.findMatches(ctx, dir1Files, dir2Files)
+	markHardlinks(matches)
 
-	// Find common filenames
-	matches := findCommonFiles(group1, group2)
+	// If hash comparison is enabled, compute hashes; matches by content
+	// already have them
+	if f.hashesMatches() && len(matches) > 0 {
+		f.computeHashesForMatches(ctx, matches)
+	} else if f.matchesWholeFiles() {
+		markSizeMismatches(matches)
+	}
+	f.verifyHashMatches(ctx, matches)
+
+	dir1, dir2 := pairDirs(dir1Files, dir2Files)
+	return models.PairComparison{
+		Dir1:      dir1,
+		Dir2:      dir2,
+		Matches:   matches,
+		Truncated: len(matches) < found,
+	}
+}
+
+// streamBatchSize is how many matches StreamPair checks and emits at a time
+const streamBatchSize = 256
+
+// StreamPair compares two directories like ComparePair, but hands the
+// matches to emit in small batches as soon as they are checked instead of
+// collecting them, so results can be written while the comparison runs.
+// Batches arrive in filename order, except that matches to be hashed are
+// scheduled cheapest first, so confirmed duplicates among small files are
+// emitted before large files are read. The returned comparison has no
+// matches; it carries the directories and whether a match limit cut the
+// pair short. An error from emit stops the comparison and is returned.
+// Once ctx is done, matches are still emitted but no longer hashed.
+func (f *Finder) StreamPair(ctx context.Context, dir1Files, dir2Files []models.FileInfo, emit func([]models.FileMatch) error) (models.PairComparison, error) {
+	matches, found := f.findMatches(ctx, dir1Files, dir2Files)
+	dir1, dir2 := pairDirs(dir1Files, dir2Files)
+	comparison := models.PairComparison{Dir1: dir1, Dir2: dir2, Truncated: len(matches) < found}
 
-	// If hash comparison is enabled, compute hashes
-	if f.options.CompareHash && len(matches) > 0 {
-		f.computeHashesForMatches(matches)
+	hash := f.hashesMatches()
+	if hash {
+		markHardlinks(matches)
+		scheduleMatches(matches)
 	}
+	for start := 0; start < len(matches); start += streamBatchSize {
+		batch := matches[start:min(start+streamBatchSize, len(matches))]
+		if hash {
+			f.computeHashesForMatches(ctx, batch)
+		} else {
+			markHardlinks(batch)
+			if f.matchesWholeFiles() {
+				markSizeMismatches(batch)
+			}
+		}
+		f.verifyHashMatches(ctx, batch)
+		if err := emit(batch); err != nil {
+			return comparison, err
+		}
+	}
+	return comparison, nil
+}
+
+// matchNames finds the files present in both directories under the same
+// name, as given by name, sorted by filename and cut to the match limits.
+// Every file of a name is matched with every file of that name on the
+// other side. found is the count before the limits were applied.
+func (f *Finder) matchNames(dir1Files, dir2Files []models.FileInfo, name func(models.FileInfo) string) (matches []models.FileMatch, found int) {
+	// Group files by name
+	group1 := groupByName(dir1Files, name)
+	group2 := groupByName(dir2Files, name)
+
+	// Find common filenames
+	matches = findCommonFiles(group1, group2)
 
-	// Extract directory names from file lists
-	var dir1, dir2 string
+	// Sort matches by filename for consistent output
+	sortMatches(matches)
+
+	// Apply match limits before hashing so truncated matches are never read
+	found = len(matches)
+	return f.applyMatchLimits(matches), found
+}
+
+// pairDirs extracts the root directories of two file lists
+func pairDirs(dir1Files, dir2Files []models.FileInfo) (dir1, dir2 string) {
 	if len(dir1Files) > 0 {
 		dir1 = dir1Files[0].Directory
 	}
 	if len(dir2Files) > 0 {
 		dir2 = dir2Files[0].Directory
 	}
+	return dir1, dir2
+}
 
-	// Sort matches by filename for consistent output
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].Filename < matches[j].Filename
-	})
+// applyMatchLimits truncates matches to MaxMatchesPerPair and to what is
+// left of MaxTotalMatches
+func (f *Finder) applyMatchLimits(matches []models.FileMatch) []models.FileMatch {
+	if f.options.MaxMatchesPerPair > 0 && len(matches) > f.options.MaxMatchesPerPair {
+		matches = matches[:f.options.MaxMatchesPerPair]
+	}
 
-	return models.PairComparison{
-		Dir1:    dir1,
-		Dir2:    dir2,
-		Matches: matches,
+	if f.options.MaxTotalMatches > 0 {
+		remaining := f.options.MaxTotalMatches - f.totalMatches
+		if remaining < 0 {
+			remaining = 0
+		}
+		if len(matches) > remaining {
+			matches = matches[:remaining]
+		}
 	}
+
+	f.totalMatches += len(matches)
+	return matches
 }
 
-// groupByName creates a map of basename -> FileInfo
-func groupByName(files []models.FileInfo) map[string]models.FileInfo {
-	m := make(map[string]models.FileInfo)
+// LimitReached reports whether MaxTotalMatches has been reached,
+// in which case comparing further pairs is pointless
+func (f *Finder) LimitReached() bool {
+	return f.options.MaxTotalMatches > 0 && f.totalMatches >= f.options.MaxTotalMatches
+}
+
+// groupByName creates a map of name -> files, keeping every file of a
+// name: a tree can hold a/report.pdf and b/report.pdf
+func groupByName(files []models.FileInfo, name func(models.FileInfo) string) map[string][]models.FileInfo {
+	m := make(map[string][]models.FileInfo)
 	for _, f := range files {
-		basename := filepath.Base(f.Path)
-		m[basename] = f
+		m[name(f)] = append(m[name(f)], f)
 	}
 	return m
 }
 
-// findCommonFiles finds files that exist in both groups
-func findCommonFiles(group1, group2 map[string]models.FileInfo) []models.FileMatch {
+// baseName names a file by its basename
+func baseName(file models.FileInfo) string {
+	return filepath.Base(file.Path)
+}
+
+// composed names files as name does, in composed Unicode form
+func composed(name func(models.FileInfo) string) func(models.FileInfo) string {
+	return func(file models.FileInfo) string {
+		return nfc.Compose(name(file))
+	}
+}
+
+// relativePath names a file by its slash-separated path relative to its
+// root directory, or by its full path if it has none, as in a catalog
+func relativePath(file models.FileInfo) string {
+	rel, err := filepath.Rel(file.Directory, file.Path)
+	if err != nil {
+		return filepath.ToSlash(file.Path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findCommonFiles finds files that exist in both groups, matching each
+// file of a name with each file of that name in the other group. Matches
+// of a name held by several files of one side are named by relative
+// paths, so they can be told apart.
+func findCommonFiles(group1, group2 map[string][]models.FileInfo) []models.FileMatch {
 	var matches []models.FileMatch
-	for name, file1 := range group1 {
-		if file2, exists := group2[name]; exists {
-			matches = append(matches, models.FileMatch{
-				Filename:    name,
-				File1:       file1,
-				File2:       file2,
-				HashChecked: false,
-				HashMatch:   false,
-			})
+	for name, files1 := range group1 {
+		files2 := group2[name]
+		shared := len(files1) > 1 || len(files2) > 1
+		for _, file1 := range files1 {
+			for _, file2 := range files2 {
+				filename := name
+				if shared {
+					filename = relativeName(file1, file2)
+				}
+				matches = append(matches, models.FileMatch{
+					Filename:    filename,
+					File1:       file1,
+					File2:       file2,
+					HashChecked: false,
+					HashMatch:   false,
+				})
+			}
 		}
 	}
 	return matches
 }
 
-// computeHashesForMatches computes hashes for all matched files and updates HashMatch
-func (f *Finder) computeHashesForMatches(matches []models.FileMatch) {
+// relativeName names a match after the relative paths of both files, or
+// one when they are the same
+func relativeName(file1, file2 models.FileInfo) string {
+	name := relativePath(file1)
+	if other := relativePath(file2); other != name {
+		name += " ↔ " + other
+	}
+	return name
+}
+
+// markHardlinks flags matches whose two paths are links to the same file,
+// from the device and inode recorded by the scanner where known and by
+// looking the files up otherwise. A file on an offline volume is never
+// linked to another.
+func markHardlinks(matches []models.FileMatch) {
+	for i := range matches {
+		if matches[i].Offline() {
+			continue
+		}
+		if matches[i].File1.Inode != 0 && matches[i].File2.Inode != 0 {
+			matches[i].Hardlinked = matches[i].File1.SameStorage(matches[i].File2)
+			continue
+		}
+		info1, err := fsio.Stat(matches[i].File1.Path)
+		if err != nil {
+			continue
+		}
+		info2, err := fsio.Stat(matches[i].File2.Path)
+		if err != nil {
+			continue
+		}
+		matches[i].Hardlinked = os.SameFile(info1, info2)
+	}
+}
+
+// markSizeMismatches flags matches whose two files differ in size, which
+// cannot have the same content, so a name match is never reported as a
+// duplicate when its sizes alone tell the files apart. Files listed by a
+// checksum file have no size to compare.
+func markSizeMismatches(matches []models.FileMatch) {
+	for i := range matches {
+		if matches[i].File1.SizeUnknown || matches[i].File2.SizeUnknown {
+			continue
+		}
+		if matches[i].File1.Size != matches[i].File2.Size {
+			matches[i].Mismatch = models.CheckSize
+		}
+	}
+}
+
+// computeHashesForMatches computes hashes for all matched files and updates
+// HashMatch. Matches are compared by size, then by partial hash, and large
+// ones by sampled regions, and only those still alike are hashed in full.
+// Matches left without a hash once ctx is done stay unverified, as do
+// matches with a file on an offline volume whose catalog records no hash;
+// files whose hash is already known are not read again.
+func (f *Finder) computeHashesForMatches(ctx context.Context, matches []models.FileMatch) {
+	// Pairs of different sizes or partial hashes need no full hashes
+	markSizeMismatches(matches)
+	f.comparePartialHashes(ctx, matches)
+
+	// Large pairs whose samples already differ need no hashes either
+	var pending []*models.FileMatch
+	for i := range matches {
+		if !matches[i].Hardlinked && !matches[i].Offline() {
+			pending = append(pending, &matches[i])
+		}
+	}
+	f.sampleLarge(ctx, pending)
+
+	// Files listed by checksum files are compared by their digests instead
+	var listed []*models.FileMatch
+	for i := range matches {
+		if hasChecksum(matches[i]) {
+			listed = append(listed, &matches[i])
+		}
+	}
+	f.compareChecksums(ctx, listed)
+
 	// Collect all files that need hashing
 	var files []*models.FileInfo
 	for i := range matches {
-		files = append(files, &matches[i].File1)
-		files = append(files, &matches[i].File2)
+		if matches[i].Hardlinked || matches[i].Mismatch != "" || hasChecksum(matches[i]) {
+			// One file under two paths, already known to differ, or
+			// compared by checksum
+			continue
+		}
+		for _, file := range []*models.FileInfo{&matches[i].File1, &matches[i].File2} {
+			if file.Hash == "" && !file.Offline {
+				files = append(files, file)
+			}
+		}
 	}
 
 	// Compute hashes in parallel
-	numWorkers := runtime.NumCPU() * 2 // I/O bound, so use more workers
-	if f.options.NumWorkers > 0 {
-		numWorkers = f.options.NumWorkers * 2
-	}
-
-	_ = ComputeHashesParallel(files, numWorkers)
+	_ = computeHashes(ctx, files, f.hashWorkers(), f.options)
 
 	// Update HashMatch for each pair
 	for i := range matches {
+		if matches[i].Hardlinked || matches[i].Mismatch != "" || hasChecksum(matches[i]) {
+			continue
+		}
+		if ctx.Err() != nil && (matches[i].File1.Hash == "" || matches[i].File2.Hash == "") {
+			// Interrupted before both files were hashed
+			continue
+		}
+		if matches[i].Offline() && (matches[i].File1.Hash == "" || matches[i].File2.Hash == "") {
+			// The catalog has no hash to compare with
+			continue
+		}
 		matches[i].HashChecked = true
 		matches[i].HashMatch = matches[i].File1.Hash == matches[i].File2.Hash &&
 			matches[i].File1.Hash != ""
+		if matches[i].File1.Hash != "" && matches[i].File2.Hash != "" {
+			matches[i].Verified, matches[i].Mismatch = verdict(models.CheckHash, matches[i].HashMatch, matches[i].Verified)
+		}
 	}
 }
 
@@ -115,3 +346,176 @@ func GeneratePairs(dirs []string) [][2]string {
 	}
 	return pairs
 }
+
+// PairsWith keeps the pairs of which dir is one side, so each other
+// directory is compared with dir alone
+func PairsWith(pairs [][2]string, dir string) [][2]string {
+	var result [][2]string
+	for _, pair := range pairs {
+		if pair[0] == dir || pair[1] == dir {
+			result = append(result, pair)
+		}
+	}
+	return result
+}
+
+// ExcludePairs removes the given directory pairs from pairs.
+// A skip entry matches a pair regardless of order.
+func ExcludePairs(pairs [][2]string, skip [][2]string) [][2]string {
+	if len(skip) == 0 {
+		return pairs
+	}
+
+	var result [][2]string
+	for _, pair := range pairs {
+		excluded := false
+		for _, s := range skip {
+			if (samePath(pair[0], s[0]) && samePath(pair[1], s[1])) ||
+				(samePath(pair[0], s[1]) && samePath(pair[1], s[0])) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			result = append(result, pair)
+		}
+	}
+	return result
+}
+
+// samePath reports whether two directory arguments refer to the same path
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// FindContent returns the first candidate whose content is identical to target.
+// Only candidates of the same size are hashed, and the search stops at the
+// first match. It returns nil if no candidate matches.
+func FindContent(target models.FileInfo, candidates []models.FileInfo, cache HashCache) (*models.FileInfo, error) {
+	targetHashed := false
+
+	for i := range candidates {
+		candidate := candidates[i]
+		if candidate.Size != target.Size {
+			continue
+		}
+		if samePath(candidate.Path, target.Path) {
+			continue
+		}
+
+		// Hash the target lazily, only once a same-sized candidate exists
+		if !targetHashed {
+			if err := HashWithCache(&target, cache); err != nil {
+				return nil, fmt.Errorf("error hashing %s: %w", target.Path, err)
+			}
+			targetHashed = true
+		}
+
+		if err := HashWithCache(&candidate, cache); err != nil {
+			fmt.Fprintf(os.Stderr, "error hashing %s: %v\n", candidate.Path, err)
+			continue
+		}
+		if candidate.Hash == target.Hash {
+			return &candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// MergeMatches merges duplicate matches from all comparisons into transitive
+// duplicate sets, so a file present in several directories appears in one set.
+// Matches whose hashes were checked and differ are ignored. A set counts as
+// hash-verified only if every match that formed it was.
+func MergeMatches(comparisons []models.PairComparison) []models.DuplicateSet {
+	parent := make(map[string]string)
+	files := make(map[string]models.FileInfo)
+	unverified := make(map[string]bool)
+
+	var find func(string) string
+	find = func(p string) string {
+		if parent[p] != p {
+			parent[p] = find(parent[p])
+		}
+		return parent[p]
+	}
+	add := func(file models.FileInfo) {
+		if _, ok := parent[file.Path]; !ok {
+			parent[file.Path] = file.Path
+			files[file.Path] = file
+		}
+	}
+
+	var order []string
+	var noted []models.FileMatch
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			if !match.IsDuplicate() {
+				continue
+			}
+			for _, file := range []models.FileInfo{match.File1, match.File2} {
+				if _, ok := parent[file.Path]; !ok {
+					order = append(order, file.Path)
+				}
+				add(file)
+			}
+
+			root1, root2 := find(match.File1.Path), find(match.File2.Path)
+			if root1 != root2 {
+				parent[root2] = root1
+				unverified[root1] = unverified[root1] || unverified[root2]
+			}
+			if !match.HashChecked {
+				unverified[root1] = true
+			}
+			if match.Note != "" {
+				noted = append(noted, match)
+			}
+		}
+	}
+
+	// A set carries the notes of its matches, each once
+	notes := make(map[string][]string)
+	for _, match := range noted {
+		root := find(match.File1.Path)
+		if !slices.Contains(notes[root], match.Note) {
+			notes[root] = append(notes[root], match.Note)
+		}
+	}
+
+	// Collect members per root, keeping first-seen order of roots
+	members := make(map[string][]models.FileInfo)
+	var roots []string
+	for _, path := range order {
+		root := find(path)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], files[path])
+	}
+
+	var sets []models.DuplicateSet
+	for _, root := range roots {
+		group := members[root]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Path < group[j].Path
+		})
+
+		set := models.DuplicateSet{
+			ID:           len(sets) + 1,
+			Files:        group,
+			HashComputed: !unverified[find(root)],
+			Note:         strings.Join(notes[root], "; "),
+		}
+		if set.HashComputed {
+			set.Hash = group[0].Hash
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
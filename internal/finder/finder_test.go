@@ -0,0 +1,387 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// makeFiles builds n in-memory files named file00.txt, file01.txt, ... under dir
+func makeFiles(dir string, n int) []models.FileInfo {
+	var files []models.FileInfo
+	for i := 0; i < n; i++ {
+		files = append(files, models.FileInfo{
+			Path:      fmt.Sprintf("%s/file%02d.txt", dir, i),
+			Directory: dir,
+			Size:      10,
+		})
+	}
+	return files
+}
+
+func TestComparePair_MaxMatchesPerPair(t *testing.T) {
+	f := NewFinder(models.ScanOptions{MaxMatchesPerPair: 3})
+
+	comparison := f.ComparePair(context.Background(), makeFiles("/a", 5), makeFiles("/b", 5))
+
+	require.Len(t, comparison.Matches, 3)
+	assert.True(t, comparison.Truncated)
+	// Limits keep the first matches in sorted order
+	assert.Equal(t, "file00.txt", comparison.Matches[0].Filename)
+	assert.Equal(t, "file02.txt", comparison.Matches[2].Filename)
+}
+
+func TestComparePair_MaxTotalMatches(t *testing.T) {
+	f := NewFinder(models.ScanOptions{MaxTotalMatches: 7})
+
+	first := f.ComparePair(context.Background(), makeFiles("/a", 5), makeFiles("/b", 5))
+	assert.Len(t, first.Matches, 5)
+	assert.False(t, first.Truncated)
+	assert.False(t, f.LimitReached())
+
+	second := f.ComparePair(context.Background(), makeFiles("/a", 5), makeFiles("/c", 5))
+	assert.Len(t, second.Matches, 2)
+	assert.True(t, second.Truncated)
+	assert.True(t, f.LimitReached())
+}
+
+func TestComparePair_NoLimits(t *testing.T) {
+	f := NewFinder(models.ScanOptions{})
+
+	comparison := f.ComparePair(context.Background(), makeFiles("/a", 5), makeFiles("/b", 5))
+
+	assert.Len(t, comparison.Matches, 5)
+	assert.False(t, comparison.Truncated)
+	assert.False(t, f.LimitReached())
+}
+
+// fakeCache is an in-memory HashCache for tests
+type fakeCache map[string]string
+
+func (c fakeCache) Lookup(file models.FileInfo) (string, bool) {
+	hash, ok := c[file.Path]
+	return hash, ok
+}
+
+func (c fakeCache) Store(file models.FileInfo) {
+	c[file.Path] = file.Hash
+}
+
+func TestComparePair_MatchRelPath(t *testing.T) {
+	files1 := []models.FileInfo{
+		{Path: "/a/2023/x.txt", Directory: "/a", Size: 10},
+		{Path: "/a/2024/y.txt", Directory: "/a", Size: 10},
+	}
+	files2 := []models.FileInfo{
+		{Path: "/b/2023/x.txt", Directory: "/b", Size: 10},
+		{Path: "/b/2023/y.txt", Directory: "/b", Size: 10},
+	}
+
+	// By name both files match; by relative path only the one in the same place
+	f := NewFinder(models.ScanOptions{})
+	assert.Len(t, f.ComparePair(context.Background(), files1, files2).Matches, 2)
+
+	f = NewFinder(models.ScanOptions{Match: MatchRelPath})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 1)
+	assert.Equal(t, "2023/x.txt", comparison.Matches[0].Filename)
+	assert.Equal(t, "/b/2023/x.txt", comparison.Matches[0].File2.Path)
+}
+
+func TestComparePair_SharedNames(t *testing.T) {
+	files1 := []models.FileInfo{
+		{Path: "/a/2023/report.pdf", Directory: "/a", Size: 10},
+		{Path: "/a/2024/report.pdf", Directory: "/a", Size: 10},
+		{Path: "/a/notes.txt", Directory: "/a", Size: 10},
+	}
+	files2 := []models.FileInfo{
+		{Path: "/b/2024/report.pdf", Directory: "/b", Size: 10},
+		{Path: "/b/notes.txt", Directory: "/b", Size: 10},
+	}
+
+	// Neither report shadows the other, and their matches are named by path
+	f := NewFinder(models.ScanOptions{})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 3)
+	assert.Equal(t, "2023/report.pdf ↔ 2024/report.pdf", comparison.Matches[0].Filename)
+	assert.Equal(t, "/a/2023/report.pdf", comparison.Matches[0].File1.Path)
+	assert.Equal(t, "2024/report.pdf", comparison.Matches[1].Filename)
+	assert.Equal(t, "/a/2024/report.pdf", comparison.Matches[1].File1.Path)
+	assert.Equal(t, "notes.txt", comparison.Matches[2].Filename)
+}
+
+func TestComparePair_NormalizeNames(t *testing.T) {
+	// The same name, decomposed as macOS writes it and composed
+	files1 := []models.FileInfo{{Path: "/a/cafe\u0301.txt", Directory: "/a"}}
+	files2 := []models.FileInfo{{Path: "/b/caf\u00e9.txt", Directory: "/b"}}
+
+	f := NewFinder(models.ScanOptions{NormalizeNames: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 1)
+	assert.Equal(t, "caf\u00e9.txt", comparison.Matches[0].Filename)
+
+	f = NewFinder(models.ScanOptions{})
+	assert.Empty(t, f.ComparePair(context.Background(), files1, files2).Matches)
+}
+
+func TestComparePair_SizeMismatch(t *testing.T) {
+	files1 := []models.FileInfo{
+		{Path: "/a/notes.txt", Directory: "/a", Size: 2 << 10},
+		{Path: "/a/photo.jpg", Directory: "/a", Size: 100},
+	}
+	files2 := []models.FileInfo{
+		{Path: "/b/notes.txt", Directory: "/b", Size: 2 << 20},
+		{Path: "/b/photo.jpg", Directory: "/b", Size: 100},
+	}
+
+	f := NewFinder(models.ScanOptions{})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Without hashes, sizes still tell the two notes apart
+	require.Len(t, comparison.Matches, 2)
+	assert.Equal(t, models.CheckSize, comparison.Matches[0].Mismatch)
+	assert.False(t, comparison.Matches[0].IsDuplicate())
+	assert.Empty(t, comparison.Matches[1].Mismatch)
+	assert.True(t, comparison.Matches[1].IsDuplicate())
+
+	var streamed []models.FileMatch
+	_, err := f.StreamPair(context.Background(), files1, files2, func(batch []models.FileMatch) error {
+		streamed = append(streamed, batch...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, streamed, 2)
+	assert.Equal(t, models.CheckSize, streamed[0].Mismatch)
+}
+
+func TestFindContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := writeTestFile(t, tmpDir, "incoming.jpg", "photo data")
+	other := writeTestFile(t, tmpDir, "other.jpg", "other data")
+	copyFile := writeTestFile(t, tmpDir, "copy.jpg", "photo data")
+
+	cache := fakeCache{}
+	match, err := FindContent(target, []models.FileInfo{other, copyFile}, cache)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, copyFile.Path, match.Path)
+
+	// Hashes computed along the way are recorded in the cache
+	assert.Contains(t, cache, target.Path)
+	assert.Contains(t, cache, copyFile.Path)
+}
+
+func TestFindContent_NoMatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := writeTestFile(t, tmpDir, "incoming.jpg", "photo data")
+	other := writeTestFile(t, tmpDir, "other.jpg", "other data")
+	smaller := writeTestFile(t, tmpDir, "small.jpg", "tiny")
+
+	match, err := FindContent(target, []models.FileInfo{target, other, smaller}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, match)
+}
+
+func TestFindContent_UsesCache(t *testing.T) {
+	// Paths do not exist, so a match is only possible through the cache
+	target := models.FileInfo{Path: "/virtual/a.jpg", Size: 10}
+	candidate := models.FileInfo{Path: "/virtual/b.jpg", Size: 10}
+	cache := fakeCache{target.Path: "0123456789abcdef", candidate.Path: "0123456789abcdef"}
+
+	match, err := FindContent(target, []models.FileInfo{candidate}, cache)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, candidate.Path, match.Path)
+}
+
+func TestFillFromCache_StoreHashes(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{writeTestFile(t, dir1, "photo.jpg", "photo data")}
+	files2 := []models.FileInfo{writeTestFile(t, dir2, "photo.jpg", "photo data")}
+
+	cache := fakeCache{}
+	assert.Equal(t, 0, FillFromCache(files1, cache))
+	comparison := NewFinder(models.ScanOptions{CompareHash: true}).ComparePair(context.Background(), files1, files2)
+	StoreHashes([]models.PairComparison{comparison}, cache)
+	require.Len(t, cache, 2)
+
+	// A later run takes the recorded hashes instead of reading the files,
+	// so a hash recorded differently shows through
+	cache[files2[0].Path] = "0123456789abcdef"
+	assert.Equal(t, 1, FillFromCache(files1, cache))
+	assert.Equal(t, 1, FillFromCache(files2, cache))
+	comparison = NewFinder(models.ScanOptions{CompareHash: true}).ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 1)
+	assert.Equal(t, models.CheckHash, comparison.Matches[0].Mismatch)
+
+	offline := []models.FileInfo{{Path: files1[0].Path, Offline: true}}
+	assert.Equal(t, 0, FillFromCache(offline, cache), "files of offline volumes keep what their catalog records")
+}
+
+// writeTestFile creates a file with content and returns its FileInfo
+func writeTestFile(t *testing.T, dir, name, content string) models.FileInfo {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return models.FileInfo{Path: path, Directory: dir, Size: info.Size(), ModTime: info.ModTime()}
+}
+
+func TestMergeMatches(t *testing.T) {
+	a := models.FileInfo{Path: "/a/photo.jpg", Directory: "/a", Hash: "h1"}
+	b := models.FileInfo{Path: "/b/photo.jpg", Directory: "/b", Hash: "h1"}
+	c := models.FileInfo{Path: "/c/photo.jpg", Directory: "/c", Hash: "h1"}
+	x := models.FileInfo{Path: "/a/notes.txt", Directory: "/a"}
+	y := models.FileInfo{Path: "/b/notes.txt", Directory: "/b"}
+
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{
+			{Filename: "notes.txt", File1: x, File2: y},
+			{Filename: "photo.jpg", File1: a, File2: b, HashChecked: true, HashMatch: true},
+		}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "photo.jpg", File1: a, File2: c, HashChecked: true, HashMatch: true},
+		}},
+		{Dir1: "/b", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "photo.jpg", File1: b, File2: c, HashChecked: true, HashMatch: true},
+		}},
+	}
+
+	sets := MergeMatches(comparisons)
+	require.Len(t, sets, 2)
+
+	// Name-only match stays unverified
+	assert.Equal(t, 1, sets[0].ID)
+	assert.Len(t, sets[0].Files, 2)
+	assert.False(t, sets[0].HashComputed)
+
+	// Three pairwise matches collapse into one set of three files
+	assert.Equal(t, 2, sets[1].ID)
+	require.Len(t, sets[1].Files, 3)
+	assert.Equal(t, "/a/photo.jpg", sets[1].Files[0].Path)
+	assert.Equal(t, "/c/photo.jpg", sets[1].Files[2].Path)
+	assert.True(t, sets[1].HashComputed)
+	assert.Equal(t, "h1", sets[1].Hash)
+}
+
+func TestMergeMatches_SkipsHashMismatches(t *testing.T) {
+	comparisons := []models.PairComparison{{Matches: []models.FileMatch{{
+		File1:       models.FileInfo{Path: "/a/x"},
+		File2:       models.FileInfo{Path: "/b/x"},
+		HashChecked: true,
+		HashMatch:   false,
+	}}}}
+
+	assert.Empty(t, MergeMatches(comparisons))
+}
+
+func TestMergeMatches_Notes(t *testing.T) {
+	a := models.FileInfo{Path: "/a/x"}
+	b := models.FileInfo{Path: "/b/x"}
+	c := models.FileInfo{Path: "/c/x"}
+	comparisons := []models.PairComparison{{Matches: []models.FileMatch{
+		{File1: a, File2: b, Note: "check later"},
+		{File1: a, File2: c, Note: "keep both"},
+		{File1: b, File2: c, Note: "check later"},
+	}}}
+
+	sets := MergeMatches(comparisons)
+	require.Len(t, sets, 1)
+	assert.Equal(t, "check later; keep both", sets[0].Note)
+}
+
+func TestComparePair_Hardlinked(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	original := writeTestFile(t, dir1, "photo.jpg", "content")
+	require.NoError(t, os.Link(original.Path, filepath.Join(dir2, "photo.jpg")))
+	copied := writeTestFile(t, dir2, "notes.txt", "text")
+	other := writeTestFile(t, dir1, "notes.txt", "text")
+	linked := original
+	linked.Path, linked.Directory = filepath.Join(dir2, "photo.jpg"), dir2
+
+	f := NewFinder(models.ScanOptions{})
+	comparison := f.ComparePair(context.Background(), []models.FileInfo{original, other}, []models.FileInfo{linked, copied})
+
+	require.Len(t, comparison.Matches, 2)
+	assert.Equal(t, "notes.txt", comparison.Matches[0].Filename)
+	assert.False(t, comparison.Matches[0].Hardlinked)
+	assert.True(t, comparison.Matches[1].Hardlinked)
+}
+
+func TestComparePair_RecordedInodes(t *testing.T) {
+	// Recorded device and inode numbers decide without touching the files,
+	// and already hardlinked matches are neither hashed nor duplicates
+	file := func(dir string, ino uint64) models.FileInfo {
+		return models.FileInfo{Path: filepath.Join(dir, "photo.jpg"), Directory: dir, Size: 7, Device: 1, Inode: ino}
+	}
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	comparison := f.ComparePair(context.Background(), []models.FileInfo{file("/a", 10)}, []models.FileInfo{file("/b", 10)})
+
+	require.Len(t, comparison.Matches, 1)
+	match := comparison.Matches[0]
+	assert.True(t, match.Hardlinked)
+	assert.False(t, match.HashChecked)
+	assert.False(t, match.IsDuplicate())
+	assert.Empty(t, MergeMatches([]models.PairComparison{comparison}))
+
+	other := file("/b", 11)
+	other.Device = 2
+	comparison = f.ComparePair(context.Background(), []models.FileInfo{file("/a", 11)}, []models.FileInfo{other})
+	assert.False(t, comparison.Matches[0].Hardlinked)
+}
+
+func TestComparePair_Cancelled(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	file1 := writeTestFile(t, dir1, "photo.jpg", "content")
+	file2 := writeTestFile(t, dir2, "photo.jpg", "content")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Matches are still found, but left unverified rather than different
+	comparison := NewFinder(models.ScanOptions{CompareHash: true}).ComparePair(ctx, []models.FileInfo{file1}, []models.FileInfo{file2})
+	require.Len(t, comparison.Matches, 1)
+	assert.False(t, comparison.Matches[0].HashChecked)
+	assert.True(t, comparison.Matches[0].IsDuplicate())
+}
+
+func TestStreamPair(t *testing.T) {
+	f := NewFinder(models.ScanOptions{MaxMatchesPerPair: streamBatchSize + 10})
+
+	var batches [][]models.FileMatch
+	comparison, err := f.StreamPair(context.Background(), makeFiles("/a", streamBatchSize+20), makeFiles("/b", streamBatchSize+20), func(batch []models.FileMatch) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/a", comparison.Dir1)
+	assert.Equal(t, "/b", comparison.Dir2)
+	assert.Empty(t, comparison.Matches)
+	assert.True(t, comparison.Truncated)
+
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0], streamBatchSize)
+	assert.Len(t, batches[1], 10)
+	// Batches continue in filename order
+	assert.Less(t, batches[0][streamBatchSize-1].Filename, batches[1][0].Filename)
+}
+
+func TestStreamPair_EmitError(t *testing.T) {
+	f := NewFinder(models.ScanOptions{})
+
+	calls := 0
+	_, err := f.StreamPair(context.Background(), makeFiles("/a", streamBatchSize*2), makeFiles("/b", streamBatchSize*2), func([]models.FileMatch) error {
+		calls++
+		return fmt.Errorf("write failed")
+	})
+	assert.EqualError(t, err, "write failed")
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,197 @@
+package finder
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/nfc"
+)
+
+// Normalizer rewrites the lowercased name of a file, without its extension,
+// to the name it was copied from, or returns it unchanged if it does not
+// look like a copy
+type Normalizer func(stem string) string
+
+// CopyVariants are the normalizers MatchFuzzy uses unless SetNormalizers
+// replaces them. They strip the markers file managers, browsers and people
+// add to copies: "file (1)", "file - Copy", "file copy 2", "Copy of file",
+// "file - コピー", and "_final", "_draft", "_old", "_backup" or "_v2" suffixes.
+var CopyVariants = []Normalizer{
+	stripPattern(`^copy( \(\d+\))? of\s+`),
+	stripPattern(`\s*-\s*(copy|コピー)(\s*\(\d+\))?$`),
+	stripPattern(`\s+copy(\s+\d+)?$`),
+	stripPattern(`\s*\(\d+\)$`),
+	stripPattern(`[\s_-]+(final|draft|old|backup|bak|v\d+(\.\d+)*)$`),
+}
+
+// stripPattern returns a normalizer removing what expr matches
+func stripPattern(expr string) Normalizer {
+	re := regexp.MustCompile(expr)
+	return func(stem string) string {
+		return re.ReplaceAllString(stem, "")
+	}
+}
+
+// SetNormalizers replaces the normalizers MatchFuzzy reduces names with
+func (f *Finder) SetNormalizers(normalizers []Normalizer) {
+	f.normalizers = normalizers
+}
+
+// fuzzyName returns the name a file is compared under with MatchFuzzy: its
+// extension, and the rest of its name lowercased and reduced by the
+// normalizers until none of them changes it, so "Report_final_v2.docx"
+// becomes "report" and ".docx"
+func (f *Finder) fuzzyName(file models.FileInfo) (stem, ext string) {
+	name := baseName(file)
+	if f.options.NormalizeNames {
+		name = nfc.Compose(name)
+	}
+	ext = strings.ToLower(filepath.Ext(name))
+	stem = strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	for changed := true; changed; {
+		changed = false
+		for _, normalize := range f.normalizers {
+			if next := strings.TrimSpace(normalize(stem)); next != stem && next != "" {
+				stem, changed = next, true
+			}
+		}
+	}
+	return stem, ext
+}
+
+// matchFuzzy pairs the files of the two directories whose names reduce to
+// the same name, or to names at most FuzzyDistance edits apart, and that
+// have the same extension and size, since copies of different sizes cannot
+// be identical. Within a group of such files, the files of each side are
+// paired as by content. Empty files are never matched. Matches are sorted
+// like matches by content and cut to the match limits; found is the count
+// before the limits were applied.
+func (f *Finder) matchFuzzy(dir1Files, dir2Files []models.FileInfo) (matches []models.FileMatch, found int) {
+	type bucket struct {
+		size int64
+		ext  string
+	}
+	type entry struct {
+		side int
+		stem string
+		file models.FileInfo
+	}
+	buckets := make(map[bucket][]entry)
+	for side, files := range [][]models.FileInfo{dir1Files, dir2Files} {
+		for _, file := range files {
+			if file.Size == 0 {
+				continue
+			}
+			stem, ext := f.fuzzyName(file)
+			b := bucket{file.Size, ext}
+			buckets[b] = append(buckets[b], entry{side, stem, file})
+		}
+	}
+
+	for _, entries := range buckets {
+		// Group the stems of the bucket, joining those within the distance
+		var stems []string
+		group := make(map[string]string)
+		for _, e := range entries {
+			if _, ok := group[e.stem]; !ok {
+				group[e.stem] = e.stem
+				stems = append(stems, e.stem)
+			}
+		}
+		var root func(string) string
+		root = func(stem string) string {
+			if group[stem] != stem {
+				group[stem] = root(group[stem])
+			}
+			return group[stem]
+		}
+		if f.options.FuzzyDistance > 0 {
+			for i := range stems {
+				for j := i + 1; j < len(stems); j++ {
+					if withinDistance(stems[i], stems[j], f.options.FuzzyDistance) {
+						group[root(stems[j])] = root(stems[i])
+					}
+				}
+			}
+		}
+
+		sides := make(map[string][2][]models.FileInfo)
+		for _, e := range entries {
+			g := sides[root(e.stem)]
+			g[e.side] = append(g[e.side], e.file)
+			sides[root(e.stem)] = g
+		}
+		for _, g := range sides {
+			if len(g[0]) == 0 || len(g[1]) == 0 {
+				continue
+			}
+			for _, pair := range pairInOrder(g[0], g[1]) {
+				matches = append(matches, models.FileMatch{
+					Filename: pairName(pair[0], pair[1]),
+					File1:    pair[0],
+					File2:    pair[1],
+				})
+			}
+		}
+	}
+
+	sortMatches(matches)
+	found = len(matches)
+	return f.applyMatchLimits(matches), found
+}
+
+// withinDistance reports whether a and b are at most limit single-character
+// insertions, deletions or substitutions apart (Levenshtein distance)
+func withinDistance(a, b string, limit int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return false
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		best := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			best = min(best, curr[j])
+		}
+		if best > limit {
+			// Every path through this row is already too long
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)] <= limit
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// sortMatches sorts matches by filename, then by the paths of their files
+func sortMatches(matches []models.FileMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Filename != matches[j].Filename {
+			return matches[i].Filename < matches[j].Filename
+		}
+		if matches[i].File1.Path != matches[j].File1.Path {
+			return matches[i].File1.Path < matches[j].File1.Path
+		}
+		return matches[i].File2.Path < matches[j].File2.Path
+	})
+}
@@ -0,0 +1,107 @@
+package finder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFuzzyName(t *testing.T) {
+	tests := []struct {
+		name string
+		stem string
+		ext  string
+	}{
+		{"photo.jpg", "photo", ".jpg"},
+		{"photo (1).jpg", "photo", ".jpg"},
+		{"Photo - Copy.JPG", "photo", ".jpg"},
+		{"photo - Copy (2).jpg", "photo", ".jpg"},
+		{"photo copy 2.jpg", "photo", ".jpg"},
+		{"Copy of photo.jpg", "photo", ".jpg"},
+		{"photo - コピー.jpg", "photo", ".jpg"},
+		{"Report_final_v2.docx", "report", ".docx"},
+		{"report-v1.2.docx", "report", ".docx"},
+		{"final.docx", "final", ".docx"},
+		{"(1).txt", "(1)", ".txt"},
+	}
+	f := NewFinder(models.ScanOptions{Match: MatchFuzzy})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stem, ext := f.fuzzyName(models.FileInfo{Path: "/dir/" + tt.name})
+			assert.Equal(t, tt.stem, stem)
+			assert.Equal(t, tt.ext, ext)
+		})
+	}
+}
+
+func TestWithinDistance(t *testing.T) {
+	assert.True(t, withinDistance("report", "report", 0))
+	assert.True(t, withinDistance("report", "reprt", 1))
+	assert.True(t, withinDistance("report", "raport", 1))
+	assert.False(t, withinDistance("report", "rapirt", 1))
+	assert.True(t, withinDistance("report", "rapirt", 2))
+	assert.False(t, withinDistance("a", "abcd", 2), "lengths too far apart")
+	assert.True(t, withinDistance("写真", "写真2", 1), "distances count characters, not bytes")
+}
+
+func TestComparePair_MatchFuzzy(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "beach.jpg", "beach"),
+		writeTestFile(t, dir1, "notes.txt", "notes"),
+		writeTestFile(t, dir1, "thesis.docx", "thesis"),
+		writeTestFile(t, dir1, "summary.txt", "summary"),
+		writeTestFile(t, dir1, "empty.txt", ""),
+	}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "beach (1).jpg", "beach"),
+		writeTestFile(t, dir2, "notes - Copy.txt", "NOTES"),
+		writeTestFile(t, dir2, "thesis_final_v2.docx", "thesis"),
+		writeTestFile(t, dir2, "sumary.txt", "summary"),
+		writeTestFile(t, dir2, "summary.txt", "a longer summary"),
+		writeTestFile(t, dir2, "empty (1).txt", ""),
+	}
+
+	f := NewFinder(models.ScanOptions{Match: MatchFuzzy, FuzzyDistance: 1})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Files of different sizes and empty files are never paired
+	require.Len(t, comparison.Matches, 4)
+	byName := make(map[string]models.FileMatch)
+	for _, m := range comparison.Matches {
+		byName[m.Filename] = m
+	}
+	require.Contains(t, byName, "beach.jpg ↔ beach (1).jpg")
+	assert.True(t, byName["beach.jpg ↔ beach (1).jpg"].IsDuplicate(), "matches are hashed without CompareHash")
+	require.Contains(t, byName, "notes.txt ↔ notes - Copy.txt")
+	assert.True(t, byName["notes.txt ↔ notes - Copy.txt"].HashChecked)
+	assert.False(t, byName["notes.txt ↔ notes - Copy.txt"].HashMatch, "same size, different content")
+	assert.True(t, byName["thesis.docx ↔ thesis_final_v2.docx"].IsDuplicate())
+	assert.True(t, byName["summary.txt ↔ sumary.txt"].IsDuplicate(), "one edit apart")
+
+	// Without a distance, names must reduce to the same name
+	f = NewFinder(models.ScanOptions{Match: MatchFuzzy})
+	comparison = f.ComparePair(context.Background(), files1, files2)
+	assert.Len(t, comparison.Matches, 3)
+}
+
+func TestComparePair_MatchFuzzyNormalizers(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{writeTestFile(t, dir1, "scan.pdf", "scan")}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "scan.orig.pdf", "scan"),
+		writeTestFile(t, dir2, "scan (1).pdf", "scan"),
+	}
+
+	f := NewFinder(models.ScanOptions{Match: MatchFuzzy})
+	f.SetNormalizers([]Normalizer{func(stem string) string { return strings.TrimSuffix(stem, ".orig") }})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	require.Len(t, comparison.Matches, 1, "the default copy markers no longer apply")
+	assert.Equal(t, "scan.pdf ↔ scan.orig.pdf", comparison.Matches[0].Filename)
+}
@@ -0,0 +1,92 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// GroupIdentical sorts files into sets of identical contents. Files of the
+// same size are compared by partial hash, files still alike are hashed in
+// full in parallel with numWorkers goroutines, and files of the same hash
+// compared byte by byte, so a set never rests on the hash alone. Sets and
+// the files identical to no other are returned in the order files were
+// given.
+func GroupIdentical(ctx context.Context, files []models.FileInfo, numWorkers int) (sets []models.DuplicateSet, unique []models.FileInfo, err error) {
+	files = append([]models.FileInfo(nil), files...)
+
+	bySize := make(map[int64]int)
+	for _, file := range files {
+		bySize[file.Size]++
+	}
+	var toPartial []*models.FileInfo
+	for i := range files {
+		if bySize[files[i].Size] > 1 && files[i].Size > 2*partialBlockSize {
+			toPartial = append(toPartial, &files[i])
+		}
+	}
+	if err := ComputePartialHashesParallel(ctx, toPartial, numWorkers); err != nil {
+		return nil, nil, err
+	}
+
+	// Files of up to two blocks have no partial hash and group by size alone
+	type prefix struct {
+		size    int64
+		partial string
+	}
+	byPrefix := make(map[prefix][]int)
+	for i, file := range files {
+		key := prefix{file.Size, file.PartialHash}
+		byPrefix[key] = append(byPrefix[key], i)
+	}
+	var toHash []*models.FileInfo
+	for i := range files {
+		if len(byPrefix[prefix{files[i].Size, files[i].PartialHash}]) > 1 {
+			toHash = append(toHash, &files[i])
+		}
+	}
+	if err := ComputeHashesParallel(ctx, toHash, numWorkers); err != nil {
+		return nil, nil, err
+	}
+
+	// groups[i] lists the files found identical to files[i], the first of its group
+	groups := make(map[int][]int)
+	first := make([]int, len(files))
+	for i := range files {
+		first[i] = i
+		for _, j := range byPrefix[prefix{files[i].Size, files[i].PartialHash}] {
+			if j >= i {
+				break
+			}
+			if first[j] != j || files[j].Hash != files[i].Hash {
+				continue
+			}
+			equal, err := FilesEqual(files[j].Path, files[i].Path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error comparing %s and %s: %w", files[j].Path, files[i].Path, err)
+			}
+			if equal {
+				first[i] = j
+				groups[j] = append(groups[j], i)
+				break
+			}
+		}
+	}
+
+	for i := range files {
+		if first[i] != i {
+			continue
+		}
+		if len(groups[i]) == 0 {
+			unique = append(unique, files[i])
+			continue
+		}
+		set := models.DuplicateSet{ID: len(sets) + 1, Files: []models.FileInfo{files[i]}, Hash: files[i].Hash, HashComputed: true}
+		for _, j := range groups[i] {
+			set.Files = append(set.Files, files[j])
+		}
+		sets = append(sets, set)
+	}
+	return sets, unique, nil
+}
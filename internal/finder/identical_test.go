@@ -0,0 +1,63 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// paths lists the paths of files
+func paths(files []models.FileInfo) []string {
+	var list []string
+	for _, file := range files {
+		list = append(list, file.Path)
+	}
+	return list
+}
+
+func TestGroupIdentical(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "same content")
+	b := writeTestFile(t, dir, "b.txt", "other stuff!")
+	c := writeTestFile(t, dir, "c.txt", "same content")
+	d := writeTestFile(t, dir, "d.txt", "short")
+	e := writeTestFile(t, dir, "e.txt", "other stuff!")
+
+	sets, unique, err := GroupIdentical(context.Background(), []models.FileInfo{a, b, c, d, e}, 2)
+	require.NoError(t, err)
+
+	require.Len(t, sets, 2)
+	assert.Equal(t, 1, sets[0].ID)
+	assert.Equal(t, []string{a.Path, c.Path}, paths(sets[0].Files))
+	assert.Equal(t, []string{b.Path, e.Path}, paths(sets[1].Files))
+	assert.True(t, sets[0].HashComputed)
+	assert.NotEmpty(t, sets[0].Hash)
+	assert.Equal(t, []string{d.Path}, paths(unique))
+}
+
+func TestGroupIdentical_NoneIdentical(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "one")
+	b := writeTestFile(t, dir, "b.txt", "two")
+
+	sets, unique, err := GroupIdentical(context.Background(), []models.FileInfo{a, b}, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sets)
+	assert.Equal(t, []string{a.Path, b.Path}, paths(unique))
+}
+
+func TestGroupIdentical_Unreadable(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "one")
+	b := writeTestFile(t, dir, "b.txt", "two")
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.txt")))
+
+	_, _, err := GroupIdentical(context.Background(), []models.FileInfo{a, b}, 1)
+	assert.Error(t, err)
+}
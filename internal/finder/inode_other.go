@@ -0,0 +1,15 @@
+//go:build !unix
+
+package finder
+
+// inode is not available on this platform; snapshot copies are recognized
+// by relative path and content only
+func inode(path string) (uint64, bool) {
+	return 0, false
+}
+
+// storageID is not available on this platform; savings treat each resolved
+// path as separate storage
+func storageID(path string) ([2]uint64, bool) {
+	return [2]uint64{}, false
+}
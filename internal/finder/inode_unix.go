@@ -0,0 +1,36 @@
+//go:build unix
+
+package finder
+
+import (
+	"syscall"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// inode returns the inode number of the file at path
+func inode(path string) (uint64, bool) {
+	info, err := fsio.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Ino), true
+}
+
+// storageID identifies the storage behind path by device and inode, so
+// hardlinks of one file share an ID
+func storageID(path string) ([2]uint64, bool) {
+	info, err := fsio.Stat(path)
+	if err != nil {
+		return [2]uint64{}, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return [2]uint64{}, false
+	}
+	return [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}, true
+}
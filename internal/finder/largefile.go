@@ -0,0 +1,205 @@
+package finder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/progress"
+)
+
+// isLarge reports whether a file of size gets the large-file handling of
+// opts: files such as disk images and backups, whose full hashes dominate a
+// run
+func isLarge(opts models.ScanOptions, size int64) bool {
+	return opts.LargeFileSize > 0 && size >= opts.LargeFileSize
+}
+
+// Large files are read in chunks of largeChunkSize bytes, and their digest
+// state is saved every largeStateInterval bytes
+const (
+	largeChunkSize     = 8 * 1024 * 1024
+	largeStateInterval = 1024 * 1024 * 1024
+)
+
+// hashState is the saved progress of hashing a large file, valid while the
+// file keeps the same size and mtime
+type hashState struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Offset  int64     `json:"offset"` // Bytes hashed so far
+	Digest  []byte    `json:"digest"` // Marshaled xxHash state after Offset bytes
+}
+
+// statePath returns where the hash state of path is kept inside dir
+func statePath(dir, path string) string {
+	return filepath.Join(dir, fmt.Sprintf("%016x.json", xxhash.Sum64String(path)))
+}
+
+// hashLargeFile computes the xxHash hash of a large file in chunks. With a
+// state directory, progress is saved as it goes, so an interrupted run
+// resumes where it stopped instead of reading the file again from the
+// start; the state is removed once the hash is complete. Once ctx is done
+// the progress is saved and ctx's error returned.
+func hashLargeFile(ctx context.Context, file models.FileInfo, opts models.ScanOptions) (string, error) {
+	path, err := filepath.Abs(file.Path)
+	if err != nil {
+		path = file.Path
+	}
+
+	in, err := fsio.Open(file.Path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	digest := xxhash.New()
+	var offset int64
+	if opts.HashStateDir != "" {
+		if state, ok := loadHashState(opts.HashStateDir, path, file); ok && digest.UnmarshalBinary(state.Digest) == nil {
+			if _, err := in.Seek(state.Offset, io.SeekStart); err != nil {
+				return "", err
+			}
+			offset = state.Offset
+			progress.Hashed(offset)
+		}
+	}
+	if offset > 0 && opts.LargeProgress != nil {
+		fmt.Fprintf(opts.LargeProgress, "Resuming hash of %s at %s of %s\n", file.Path, output.FormatSize(offset), output.FormatSize(file.Size))
+	}
+
+	buf := make([]byte, largeChunkSize)
+	reported := offset * 10 / max(file.Size, 1)
+	lastSaved := offset
+	for {
+		if err := ctx.Err(); err != nil {
+			if opts.HashStateDir != "" && offset > lastSaved {
+				if saveErr := saveHashState(opts.HashStateDir, path, file, offset, digest); saveErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: cannot save hash progress of %s: %v\n", file.Path, saveErr)
+				}
+			}
+			return "", err
+		}
+		n, err := in.Read(buf)
+		if n > 0 {
+			digest.Write(buf[:n])
+			offset += int64(n)
+			progress.Hashed(int64(n))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if tenth := offset * 10 / max(file.Size, 1); tenth > reported && tenth < 10 && opts.LargeProgress != nil {
+			reported = tenth
+			fmt.Fprintf(opts.LargeProgress, "Hashing %s: %d%% (%s of %s)\n", file.Path, tenth*10, output.FormatSize(offset), output.FormatSize(file.Size))
+		}
+		if opts.HashStateDir != "" && offset-lastSaved >= largeStateInterval {
+			lastSaved = offset
+			if err := saveHashState(opts.HashStateDir, path, file, offset, digest); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: cannot save hash progress of %s: %v\n", file.Path, err)
+			}
+		}
+	}
+
+	if opts.HashStateDir != "" {
+		os.Remove(statePath(opts.HashStateDir, path))
+	}
+	return fmt.Sprintf("%x", digest.Sum(nil)), nil
+}
+
+// loadHashState returns the saved progress of hashing path, if any was
+// saved for the file as it is now
+func loadHashState(dir, path string, file models.FileInfo) (hashState, bool) {
+	data, err := os.ReadFile(statePath(dir, path))
+	if err != nil {
+		return hashState{}, false
+	}
+	var state hashState
+	if json.Unmarshal(data, &state) != nil {
+		return hashState{}, false
+	}
+	if state.Path != path || state.Size != file.Size || !state.ModTime.Equal(file.ModTime) || state.Offset <= 0 || state.Offset > file.Size {
+		return hashState{}, false
+	}
+	return state, true
+}
+
+// saveHashState records that the first offset bytes of path hash to digest
+func saveHashState(dir, path string, file models.FileInfo, offset int64, digest *xxhash.Digest) error {
+	marshaled, err := digest.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(hashState{Path: path, Size: file.Size, ModTime: file.ModTime, Offset: offset, Digest: marshaled})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	out, err := output.CreateAtomic(statePath(dir, path))
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	return out.Commit()
+}
+
+// sampleLarge compares same-sized pairs of large files by sampled regions
+// before they are hashed, and marks the pairs that differ as mismatches, so
+// their full hashes are never computed. It does nothing unless SampleLarge
+// is set.
+func (f *Finder) sampleLarge(ctx context.Context, matches []*models.FileMatch) {
+	if !f.options.SampleLarge {
+		return
+	}
+	jobs := make(chan *models.FileMatch, len(matches))
+	for _, m := range matches {
+		if m.Mismatch == "" && !m.Offline() && m.File1.Size == m.File2.Size && isLarge(f.options, m.File1.Size) {
+			jobs <- m
+		}
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < f.hashWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				equal, err := compareSafely(m, func(path1, path2 string) (bool, error) {
+					return SampledEqual(path1, path2, m.File1.Size)
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error sampling %s and %s: %v\n", m.File1.Path, m.File2.Path, err)
+					continue
+				}
+				if !equal {
+					m.Mismatch = models.CheckSample
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
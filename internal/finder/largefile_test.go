@@ -0,0 +1,108 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestHashLargeFile(t *testing.T) {
+	var progress bytes.Buffer
+	opts := models.ScanOptions{NumWorkers: 1, LargeFileSize: 1, LargeProgress: &progress}
+	content := strings.Repeat("0123456789abcdef", largeChunkSize/16*3)
+	file := writeTestFile(t, t.TempDir(), "disk.img", content)
+
+	want, err := CalculateFileHash(file.Path)
+	require.NoError(t, err)
+	files := []*models.FileInfo{&file}
+	require.NoError(t, ComputeHashes(context.Background(), files, opts))
+	assert.Equal(t, want, file.Hash)
+	assert.Contains(t, progress.String(), "Hashing "+file.Path+": 30%")
+	assert.NotContains(t, progress.String(), "100%")
+}
+
+func TestHashLargeFile_Resume(t *testing.T) {
+	var progress bytes.Buffer
+	stateDir := t.TempDir()
+	opts := models.ScanOptions{LargeFileSize: 1, HashStateDir: stateDir, LargeProgress: &progress}
+	content := strings.Repeat("x", 1000) + strings.Repeat("y", 1000)
+	file := writeTestFile(t, t.TempDir(), "disk.img", content)
+	want, err := CalculateFileHash(file.Path)
+	require.NoError(t, err)
+
+	// Progress saved by an interrupted run after the first half
+	digest := xxhash.New()
+	digest.WriteString(content[:1000])
+	require.NoError(t, saveHashState(stateDir, file.Path, file, 1000, digest))
+
+	hash, err := hashLargeFile(context.Background(), file, opts)
+	require.NoError(t, err)
+	assert.Equal(t, want, hash)
+	assert.Contains(t, progress.String(), "Resuming hash of "+file.Path)
+	assert.NoFileExists(t, statePath(stateDir, file.Path))
+}
+
+func TestHashLargeFile_StaleState(t *testing.T) {
+	stateDir := t.TempDir()
+	opts := models.ScanOptions{LargeFileSize: 1, HashStateDir: stateDir}
+	file := writeTestFile(t, t.TempDir(), "disk.img", "current contents")
+	want, err := CalculateFileHash(file.Path)
+	require.NoError(t, err)
+
+	// Saved for an earlier version of the file: ignored
+	digest := xxhash.New()
+	digest.WriteString("old")
+	earlier := file
+	earlier.ModTime = file.ModTime.Add(-time.Hour)
+	require.NoError(t, saveHashState(stateDir, file.Path, earlier, 3, digest))
+
+	hash, err := hashLargeFile(context.Background(), file, opts)
+	require.NoError(t, err)
+	assert.Equal(t, want, hash)
+}
+
+func TestComparePair_SampleLarge(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	size := 64 * 1024
+	same := strings.Repeat("a", size)
+	differs := []byte(same)
+	differs[size-1] = 'b'
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "backup.img", same),
+		writeTestFile(t, dir1, "disk.img", same),
+		writeTestFile(t, dir1, "small.txt", "s"),
+	}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "backup.img", same),
+		writeTestFile(t, dir2, "disk.img", string(differs)),
+		writeTestFile(t, dir2, "small.txt", "s"),
+	}
+
+	for _, sample := range []bool{true, false} {
+		opts := models.ScanOptions{CompareHash: true, LargeFileSize: int64(size), SampleLarge: sample}
+		comparison := NewFinder(opts).ComparePair(context.Background(), files1, files2)
+		require.Len(t, comparison.Matches, 3)
+		backup, disk, small := comparison.Matches[0], comparison.Matches[1], comparison.Matches[2]
+
+		assert.True(t, backup.IsDuplicate(), fmt.Sprint(sample))
+		assert.True(t, small.IsDuplicate(), fmt.Sprint(sample))
+		assert.False(t, disk.IsDuplicate(), fmt.Sprint(sample))
+		if sample {
+			// Ruled out by its samples without being hashed
+			assert.Equal(t, models.CheckSample, disk.Mismatch)
+			assert.False(t, disk.HashChecked)
+			assert.Empty(t, disk.File1.Hash)
+		} else {
+			assert.Equal(t, models.CheckHash, disk.Mismatch)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Passes selectable with --passes, in order of increasing cost
+const (
+	PassQuick    = "quick"    // Compare sizes
+	PassStandard = "standard" // Compare partial hashes (first and last 64KB)
+	PassDeep     = "deep"     // Compare full hashes, then verify bytes
+)
+
+// PassNames lists the supported passes in order of increasing cost
+var PassNames = []string{PassQuick, PassStandard, PassDeep}
+
+// ParsePasses validates a list of pass names
+func ParsePasses(names []string) ([]string, error) {
+	var passes []string
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+		valid := false
+		for _, known := range PassNames {
+			if name == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown pass %q (supported: %s)", name, strings.Join(PassNames, ", "))
+		}
+		passes = append(passes, name)
+	}
+	return passes, nil
+}
+
+// RunPass applies one verification pass to every match that is still a
+// duplicate candidate, recording the outcome in Verified or Mismatch.
+// It returns the number of candidates left afterwards. Once ctx is done the
+// pass stops, leaving the remaining candidates unchecked by it, and ctx's
+// error is returned.
+func (f *Finder) RunPass(ctx context.Context, pass string, comparisons []models.PairComparison) (int, error) {
+	var candidates []*models.FileMatch
+	for i := range comparisons {
+		for j := range comparisons[i].Matches {
+			if comparisons[i].Matches[j].IsDuplicate() {
+				candidates = append(candidates, &comparisons[i].Matches[j])
+			}
+		}
+	}
+
+	switch pass {
+	case PassQuick:
+		for _, m := range candidates {
+			if m.File1.SizeUnknown || m.File2.SizeUnknown {
+				continue
+			}
+			m.Verified, m.Mismatch = verdict(models.CheckSize, m.File1.Size == m.File2.Size, m.Verified)
+		}
+	case PassStandard:
+		f.runStandardPass(ctx, candidates)
+	case PassDeep:
+		f.runDeepPass(ctx, candidates)
+	default:
+		return 0, fmt.Errorf("unknown pass %q", pass)
+	}
+
+	remaining := 0
+	for _, m := range candidates {
+		if m.IsDuplicate() {
+			remaining++
+		}
+	}
+	return remaining, ctx.Err()
+}
+
+// runStandardPass compares partial hashes of same-sized candidates. Files
+// on offline volumes cannot be read, so their candidates are only compared
+// by size.
+func (f *Finder) runStandardPass(ctx context.Context, candidates []*models.FileMatch) {
+	var files []*models.FileInfo
+	for _, m := range candidates {
+		if m.File1.Size != m.File2.Size && !m.File1.SizeUnknown && !m.File2.SizeUnknown {
+			m.Verified, m.Mismatch = verdict(models.CheckSize, false, m.Verified)
+			continue
+		}
+		if m.Offline() {
+			continue
+		}
+		// Partial hashes computed before full hashes are not read again
+		for _, file := range []*models.FileInfo{&m.File1, &m.File2} {
+			if file.PartialHash == "" {
+				files = append(files, file)
+			}
+		}
+	}
+	_ = ComputePartialHashesParallel(ctx, files, f.hashWorkers())
+
+	for _, m := range candidates {
+		if m.Mismatch != "" || m.File1.PartialHash == "" || m.File2.PartialHash == "" {
+			continue
+		}
+		m.Verified, m.Mismatch = verdict(models.CheckPartial, m.File1.PartialHash == m.File2.PartialHash, m.Verified)
+	}
+}
+
+// runDeepPass compares full hashes and then verifies matching pairs byte by
+// byte. Candidates with a file on an offline volume are compared by the
+// hash its catalog records, if any, and never byte by byte; those with a
+// file listed by a checksum file are compared by its digest.
+func (f *Finder) runDeepPass(ctx context.Context, candidates []*models.FileMatch) {
+	for _, m := range candidates {
+		if m.File1.Size != m.File2.Size && !m.File1.SizeUnknown && !m.File2.SizeUnknown {
+			m.Verified, m.Mismatch = verdict(models.CheckSize, false, m.Verified)
+		}
+	}
+	f.sampleLarge(ctx, candidates)
+	f.compareChecksums(ctx, candidates)
+
+	var files []*models.FileInfo
+	for _, m := range candidates {
+		if m.Mismatch != "" || hasChecksum(*m) {
+			continue
+		}
+		if m.File1.Hash == "" && !m.File1.Offline {
+			files = append(files, &m.File1)
+		}
+		if m.File2.Hash == "" && !m.File2.Offline {
+			files = append(files, &m.File2)
+		}
+	}
+	_ = computeHashes(ctx, files, f.hashWorkers(), f.options)
+
+	var toVerify []*models.FileMatch
+	for _, m := range candidates {
+		if m.Mismatch != "" || hasChecksum(*m) || m.File1.Hash == "" || m.File2.Hash == "" {
+			continue
+		}
+		m.HashChecked = true
+		m.HashMatch = m.File1.Hash == m.File2.Hash
+		m.Verified, m.Mismatch = verdict(models.CheckHash, m.HashMatch, m.Verified)
+		if m.HashMatch && !m.Offline() {
+			toVerify = append(toVerify, m)
+		}
+	}
+
+	// Byte verification of hash-equal pairs
+	f.verifyBytes(ctx, toVerify)
+}
+
+// hashWorkers returns the worker count for I/O-bound hashing
+func (f *Finder) hashWorkers() int {
+	if f.options.NumWorkers > 0 {
+		return f.options.NumWorkers * 2
+	}
+	return runtime.NumCPU() * 2 // I/O bound, so use more workers
+}
+
+// verdict returns the new Verified and Mismatch values after a check
+func verdict(check string, passed bool, verified string) (string, string) {
+	if passed {
+		return check, ""
+	}
+	return verified, check
+}
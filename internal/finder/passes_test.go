@@ -0,0 +1,115 @@
+package finder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// passComparisons builds one comparison with identical, different-size and
+// same-size-different-content pairs
+func passComparisons(t *testing.T) []models.PairComparison {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	return []models.PairComparison{{
+		Dir1: dir1,
+		Dir2: dir2,
+		Matches: []models.FileMatch{
+			{Filename: "same.txt", File1: writeTestFile(t, dir1, "same.txt", "identical"), File2: writeTestFile(t, dir2, "same.txt", "identical")},
+			{Filename: "size.txt", File1: writeTestFile(t, dir1, "size.txt", "short"), File2: writeTestFile(t, dir2, "size.txt", "much longer")},
+			{Filename: "edit.txt", File1: writeTestFile(t, dir1, "edit.txt", "version 1"), File2: writeTestFile(t, dir2, "edit.txt", "version 2")},
+		},
+	}}
+}
+
+func TestRunPass_Escalating(t *testing.T) {
+	comparisons := passComparisons(t)
+	f := NewFinder(models.ScanOptions{NumWorkers: 2})
+	matches := comparisons[0].Matches
+
+	remaining, err := f.RunPass(context.Background(), PassQuick, comparisons)
+	require.NoError(t, err)
+	assert.Equal(t, 2, remaining)
+	assert.Equal(t, models.CheckSize, matches[0].Verified)
+	assert.Equal(t, models.CheckSize, matches[1].Mismatch)
+	assert.Equal(t, models.CheckSize, matches[2].Verified)
+
+	remaining, err = f.RunPass(context.Background(), PassStandard, comparisons)
+	require.NoError(t, err)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, models.CheckPartial, matches[0].Verified)
+	assert.Equal(t, models.CheckPartial, matches[2].Mismatch)
+	// Previously passed checks are kept on mismatch
+	assert.Equal(t, models.CheckSize, matches[2].Verified)
+
+	remaining, err = f.RunPass(context.Background(), PassDeep, comparisons)
+	require.NoError(t, err)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, models.CheckBytes, matches[0].Verified)
+	assert.True(t, matches[0].HashChecked)
+	assert.True(t, matches[0].HashMatch)
+	// Pairs ruled out earlier are not hashed
+	assert.False(t, matches[2].HashChecked)
+}
+
+func TestRunPass_DeepAlone(t *testing.T) {
+	comparisons := passComparisons(t)
+	f := NewFinder(models.ScanOptions{NumWorkers: 2})
+
+	remaining, err := f.RunPass(context.Background(), PassDeep, comparisons)
+	require.NoError(t, err)
+	assert.Equal(t, 1, remaining)
+
+	matches := comparisons[0].Matches
+	assert.Equal(t, models.CheckSize, matches[1].Mismatch)
+	assert.Equal(t, models.CheckHash, matches[2].Mismatch)
+	assert.False(t, matches[2].HashMatch)
+}
+
+func TestParsePasses(t *testing.T) {
+	passes, err := ParsePasses([]string{"quick", " Deep"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{PassQuick, PassDeep}, passes)
+
+	_, err = ParsePasses([]string{"thorough"})
+	assert.Error(t, err)
+}
+
+func TestComparePair_VerifyBytes(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	// A hash collision: equal recorded hashes over different contents
+	collide := func(file models.FileInfo) models.FileInfo {
+		file.Hash = "colliding"
+		return file
+	}
+	files1 := []models.FileInfo{
+		collide(writeTestFile(t, dir1, "collision.txt", "one")),
+		writeTestFile(t, dir1, "same.txt", "same"),
+	}
+	files2 := []models.FileInfo{
+		collide(writeTestFile(t, dir2, "collision.txt", "two")),
+		writeTestFile(t, dir2, "same.txt", "same"),
+	}
+
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 2)
+	assert.True(t, comparison.Matches[0].IsDuplicate(), "equal hashes are enough by default")
+
+	f = NewFinder(models.ScanOptions{CompareHash: true, Verify: VerifyBytes})
+	comparison = f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 2)
+	assert.Equal(t, models.CheckBytes, comparison.Matches[0].Mismatch)
+	assert.False(t, comparison.Matches[0].IsDuplicate())
+	assert.Equal(t, models.CheckBytes, comparison.Matches[1].Verified)
+	assert.True(t, comparison.Matches[1].IsDuplicate())
+}
+
+func TestValidateVerify(t *testing.T) {
+	assert.NoError(t, ValidateVerify(VerifyHash))
+	assert.NoError(t, ValidateVerify(VerifyBytes))
+	assert.ErrorContains(t, ValidateVerify("sha256"), "unknown --verify mode")
+}
@@ -0,0 +1,121 @@
+package finder
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/media"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// DefaultPerceptualLimit is how many bits the perceptual hashes of two
+// images may differ by for them to be paired: enough for re-encoding and
+// resizing, which flip a few bits in flat areas, while unrelated images
+// differ by about half of the 64
+const DefaultPerceptualLimit = 10
+
+// matchPerceptual pairs the images of the two directories that look alike,
+// whatever their names, sizes and formats: those whose perceptual hashes
+// (see media.DHash) differ by at most PerceptualLimit bits. Each image is
+// paired with the closest image of the other side, the first in path order
+// among equally close ones, so every similar image appears in a match
+// without listing every combination. Files that are not images DHash can
+// decode, and files of offline volumes, are never matched. Once ctx is
+// done, images not yet hashed are left unmatched.
+func (f *Finder) matchPerceptual(ctx context.Context, dir1Files, dir2Files []models.FileInfo) (matches []models.FileMatch, found int) {
+	side1 := f.perceptualHashes(ctx, dir1Files)
+	side2 := f.perceptualHashes(ctx, dir2Files)
+
+	type pair struct{ i, j int }
+	paired := make(map[pair]bool)
+	add := func(i, j int) {
+		if paired[pair{i, j}] {
+			return
+		}
+		paired[pair{i, j}] = true
+		matches = append(matches, models.FileMatch{
+			Filename: pairName(side1[i].file, side2[j].file),
+			File1:    side1[i].file,
+			File2:    side2[j].file,
+			Verified: models.CheckPerceptual,
+			Distance: media.Distance(side1[i].hash, side2[j].hash),
+		})
+	}
+
+	matched2 := make(map[int]bool)
+	for i := range side1 {
+		if j, ok := f.closestImage(side1[i], side2); ok {
+			add(i, j)
+			matched2[j] = true
+		}
+	}
+	for j := range side2 {
+		if matched2[j] {
+			continue
+		}
+		if i, ok := f.closestImage(side2[j], side1); ok {
+			add(i, j)
+		}
+	}
+
+	sortMatches(matches)
+	found = len(matches)
+	return f.applyMatchLimits(matches), found
+}
+
+// hashedImage is an image with its perceptual hash
+type hashedImage struct {
+	file models.FileInfo
+	hash uint64
+}
+
+// closestImage returns the index of the image of others closest to image,
+// if any is within PerceptualLimit
+func (f *Finder) closestImage(image hashedImage, others []hashedImage) (int, bool) {
+	best, bestDistance := -1, f.options.PerceptualLimit+1
+	for k, other := range others {
+		if d := media.Distance(image.hash, other.hash); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best, best >= 0
+}
+
+// perceptualHashes returns the decodable images among files with their
+// perceptual hashes, in path order, hashing in parallel only the images
+// not hashed earlier in the run. Images that cannot be decoded are left
+// out.
+func (f *Finder) perceptualHashes(ctx context.Context, files []models.FileInfo) []hashedImage {
+	var mu sync.Mutex
+	if f.dhashes == nil {
+		f.dhashes = make(map[string]uint64)
+	}
+	var toHash []*models.FileInfo
+	for i := range files {
+		file := &files[i]
+		if _, ok := f.dhashes[file.Path]; ok || file.Size == 0 || file.Offline || !media.IsDecodableImage(file.Path) {
+			continue
+		}
+		toHash = append(toHash, file)
+	}
+	_ = computeParallel(ctx, toHash, f.options.NumWorkers, func(file *models.FileInfo) error {
+		hash, err := media.DHash(file.Path)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		f.dhashes[file.Path] = hash
+		mu.Unlock()
+		return nil
+	})
+
+	var images []hashedImage
+	for _, file := range files {
+		if hash, ok := f.dhashes[file.Path]; ok {
+			images = append(images, hashedImage{file, hash})
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].file.Path < images[j].file.Path })
+	return images
+}
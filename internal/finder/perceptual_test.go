@@ -0,0 +1,87 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// writeImage writes a width×height image whose brightness rises to the
+// right, or to the left with reversed set, as PNG or, by extension, JPEG
+func writeImage(t *testing.T, dir, name string, width, height int, reversed bool) models.FileInfo {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			level := x * 255 / width
+			if reversed {
+				level = 255 - level
+			}
+			img.SetGray(x, y, color.Gray{uint8(level ^ y*64/height)})
+		}
+	}
+	var buf bytes.Buffer
+	if filepath.Ext(name) == ".png" {
+		require.NoError(t, png.Encode(&buf, img))
+	} else {
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return models.FileInfo{Path: path, Directory: dir, Size: int64(buf.Len())}
+}
+
+func TestComparePair_Perceptual(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeImage(t, dir1, "beach.png", 400, 300, false),
+		writeImage(t, dir1, "sunset.png", 400, 300, true),
+		writeTestFile(t, dir1, "notes.txt", "notes"),
+	}
+	files2 := []models.FileInfo{
+		writeImage(t, dir2, "IMG_0001_small.jpg", 100, 75, false),
+		writeTestFile(t, dir2, "notes.txt", "notes"),
+	}
+
+	f := NewFinder(models.ScanOptions{Perceptual: true, PerceptualLimit: DefaultPerceptualLimit, CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Only images are compared, and only those that look alike are paired
+	require.Len(t, comparison.Matches, 1)
+	match := comparison.Matches[0]
+	assert.Equal(t, "beach.png ↔ IMG_0001_small.jpg", match.Filename)
+	assert.Equal(t, models.CheckPerceptual, match.Verified)
+	assert.LessOrEqual(t, match.Distance, DefaultPerceptualLimit)
+	assert.Empty(t, match.Mismatch, "sizes and hashes of similar images differ by design")
+	assert.False(t, match.HashChecked)
+	assert.True(t, match.IsDuplicate())
+}
+
+func TestComparePair_PerceptualClosest(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeImage(t, dir1, "a.png", 400, 300, false),
+		writeImage(t, dir1, "b.png", 200, 150, false),
+	}
+	files2 := []models.FileInfo{writeImage(t, dir2, "copy.jpg", 100, 75, false)}
+
+	f := NewFinder(models.ScanOptions{Perceptual: true, PerceptualLimit: DefaultPerceptualLimit})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	// Every similar image appears, so the three merge into one set
+	require.Len(t, comparison.Matches, 2)
+	sets := MergeMatches([]models.PairComparison{comparison})
+	require.Len(t, sets, 1)
+	assert.Len(t, sets[0].Files, 3)
+}
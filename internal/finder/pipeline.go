@@ -0,0 +1,103 @@
+package finder
+
+import (
+	"context"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Files are hashed in stages, each reading more of them than the last:
+// first they are grouped by size, then by a partial hash of their first
+// and last 64KB, and only files still alike are hashed in full. Files of
+// up to two blocks are read whole by the partial hash, so they skip it.
+
+// needsPartialHash reports whether a file is worth a partial hash before
+// its full hash: it is larger than the two blocks the partial hash reads,
+// can be read, and neither of its hashes is known yet
+func (f *Finder) needsPartialHash(file models.FileInfo) bool {
+	if file.Size <= 2*partialBlockSize || file.Offline || file.Hash != "" || file.PartialHash != "" {
+		return false
+	}
+	_, hashed := f.hashes[file.Path]
+	return !hashed
+}
+
+// computePartialHashes fills in the partial hashes of the files that need
+// one, each computed once per run
+func (f *Finder) computePartialHashes(ctx context.Context, files []*models.FileInfo) {
+	if f.partialHashes == nil {
+		f.partialHashes = make(map[string]string)
+	}
+	var toHash []*models.FileInfo
+	for _, file := range files {
+		if hash, ok := f.partialHashes[file.Path]; ok {
+			file.PartialHash = hash
+		} else if f.needsPartialHash(*file) {
+			toHash = append(toHash, file)
+		}
+	}
+	_ = ComputePartialHashesParallel(ctx, toHash, f.hashWorkers())
+	for _, file := range toHash {
+		if file.PartialHash != "" {
+			f.partialHashes[file.Path] = file.PartialHash
+		}
+	}
+}
+
+// narrowByPartialHash drops from each side the files whose partial hash
+// matches that of no file of the same size on the other side, so they are
+// never hashed in full. Files without a partial hash, such as small files
+// or files of an offline volume, are kept and match any file of their size.
+func (f *Finder) narrowByPartialHash(ctx context.Context, side1, side2 []models.FileInfo) ([]models.FileInfo, []models.FileInfo) {
+	var files []*models.FileInfo
+	for _, side := range [][]models.FileInfo{side1, side2} {
+		for i := range side {
+			files = append(files, &side[i])
+		}
+	}
+	f.computePartialHashes(ctx, files)
+	return keepPartialMatches(side1, side2), keepPartialMatches(side2, side1)
+}
+
+// keepPartialMatches returns the files that may be identical to one of
+// others by size and partial hash
+func keepPartialMatches(files, others []models.FileInfo) []models.FileInfo {
+	known := make(map[int64]map[string]bool)
+	for _, other := range others {
+		if known[other.Size] == nil {
+			known[other.Size] = make(map[string]bool)
+		}
+		known[other.Size][other.PartialHash] = true
+	}
+	var kept []models.FileInfo
+	for _, file := range files {
+		hashes := known[file.Size]
+		if file.PartialHash == "" || hashes[""] || hashes[file.PartialHash] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// comparePartialHashes marks the same-sized matches whose partial hashes
+// differ as mismatches, so neither file is hashed in full. Matches whose
+// files need no partial hash are left to the full hash.
+func (f *Finder) comparePartialHashes(ctx context.Context, matches []models.FileMatch) {
+	var files []*models.FileInfo
+	for i := range matches {
+		m := &matches[i]
+		if m.Hardlinked || m.Mismatch != "" || !f.needsPartialHash(m.File1) || !f.needsPartialHash(m.File2) {
+			continue
+		}
+		files = append(files, &m.File1, &m.File2)
+	}
+	f.computePartialHashes(ctx, files)
+
+	for i := range matches {
+		m := &matches[i]
+		if m.Hardlinked || m.Mismatch != "" || m.File1.PartialHash == "" || m.File2.PartialHash == "" {
+			continue
+		}
+		m.Verified, m.Mismatch = verdict(models.CheckPartial, m.File1.PartialHash == m.File2.PartialHash, m.Verified)
+	}
+}
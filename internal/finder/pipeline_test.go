@@ -0,0 +1,86 @@
+package finder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// largeContent returns content of three partial hash blocks, with head at
+// its start and middle in its middle
+func largeContent(head, middle string) string {
+	body := []byte(strings.Repeat("x", 3*partialBlockSize))
+	copy(body, head)
+	copy(body[len(body)/2:], middle)
+	return string(body)
+}
+
+func TestComparePair_PartialHashStage(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "head.bin", largeContent("a", "")),
+		writeTestFile(t, dir1, "middle.bin", largeContent("", "a")),
+		writeTestFile(t, dir1, "same.bin", largeContent("", "")),
+	}
+	files2 := []models.FileInfo{
+		writeTestFile(t, dir2, "head.bin", largeContent("b", "")),
+		writeTestFile(t, dir2, "middle.bin", largeContent("", "b")),
+		writeTestFile(t, dir2, "same.bin", largeContent("", "")),
+	}
+
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+	require.Len(t, comparison.Matches, 3)
+
+	// Different heads are told apart without a full hash
+	head := comparison.Matches[0]
+	assert.Equal(t, models.CheckPartial, head.Mismatch)
+	assert.Empty(t, head.File1.Hash)
+	assert.Empty(t, head.File2.Hash)
+
+	// The middle is only read by the full hash
+	middle := comparison.Matches[1]
+	assert.Equal(t, models.CheckHash, middle.Mismatch)
+	assert.Equal(t, models.CheckPartial, middle.Verified)
+
+	same := comparison.Matches[2]
+	assert.True(t, same.IsDuplicate())
+	assert.Equal(t, models.CheckHash, same.Verified)
+}
+
+func TestComparePair_MatchContentPartialHashStage(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	files1 := []models.FileInfo{
+		writeTestFile(t, dir1, "a.bin", largeContent("a", "")),
+		writeTestFile(t, dir1, "b.bin", largeContent("b", "")),
+	}
+	files2 := []models.FileInfo{writeTestFile(t, dir2, "copy.bin", largeContent("a", ""))}
+
+	f := NewFinder(models.ScanOptions{Match: MatchContent})
+	comparison := f.ComparePair(context.Background(), files1, files2)
+
+	require.Len(t, comparison.Matches, 1)
+	assert.Equal(t, "a.bin ↔ copy.bin", comparison.Matches[0].Filename)
+	assert.NotContains(t, f.hashes, files1[1].Path, "a file whose head matches nothing is never hashed in full")
+}
+
+func TestGroupIdentical_PartialHashStage(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.bin", largeContent("", ""))
+	b := writeTestFile(t, dir, "b.bin", largeContent("", ""))
+	c := writeTestFile(t, dir, "c.bin", largeContent("c", ""))
+
+	sets, unique, err := GroupIdentical(context.Background(), []models.FileInfo{a, b, c}, 2)
+	require.NoError(t, err)
+
+	require.Len(t, sets, 1)
+	assert.Equal(t, []string{a.Path, b.Path}, paths(sets[0].Files))
+	require.Len(t, unique, 1)
+	assert.Empty(t, unique[0].Hash, "a file whose head matches nothing is never hashed in full")
+	assert.NotEmpty(t, unique[0].PartialHash)
+}
@@ -0,0 +1,85 @@
+package finder
+
+import (
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// storageKey identifies the physical storage of a file: its device and
+// inode where the platform reports them, otherwise its resolved path
+type storageKey struct {
+	id   [2]uint64
+	path string
+}
+
+// keyOf returns the storage key of file, from its recorded device and
+// inode if the scanner saw them
+func keyOf(file models.FileInfo) storageKey {
+	if file.Inode != 0 {
+		return storageKey{id: [2]uint64{file.Device, file.Inode}}
+	}
+	if id, ok := storageID(file.Path); ok {
+		return storageKey{id: id}
+	}
+	return storageKey{path: resolvePath(file.Path)}
+}
+
+// SetSavings returns the bytes freed by keeping one copy of set: all
+// copies but the largest, with hardlinks of one file counted once since
+// they share storage
+func SetSavings(set models.DuplicateSet) int64 {
+	return TotalSavings([]models.DuplicateSet{set})
+}
+
+// TotalSavings returns the bytes freed by keeping one copy of each set.
+// A file listed in several sets, under another path or as a hardlink, is
+// counted once, and a set sharing a file with an earlier set has its kept
+// copy there already, so all its other copies count.
+func TotalSavings(sets []models.DuplicateSet) int64 {
+	seen := make(map[storageKey]bool)
+	var total int64
+	for _, set := range sets {
+		var sum, largest int64
+		kept := false
+		counted := make(map[storageKey]bool)
+		for _, file := range set.Files {
+			key := keyOf(file)
+			if seen[key] {
+				kept = true
+				continue
+			}
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+			sum += file.Size
+			largest = max(largest, file.Size)
+		}
+		for key := range counted {
+			seen[key] = true
+		}
+		if !kept {
+			sum -= largest
+		}
+		total += sum
+	}
+	return total
+}
+
+// RemovalSavings returns the bytes freed by removing the removed files
+// while the kept files stay. Removing a hardlink of a kept file frees
+// nothing, and a file removed under several paths is counted once.
+func RemovalSavings(kept, removed []models.FileInfo) int64 {
+	seen := make(map[storageKey]bool)
+	for _, file := range kept {
+		seen[keyOf(file)] = true
+	}
+	var total int64
+	for _, file := range removed {
+		key := keyOf(file)
+		if !seen[key] {
+			seen[key] = true
+			total += file.Size
+		}
+	}
+	return total
+}
@@ -0,0 +1,65 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestTotalSavings(t *testing.T) {
+	file := func(path string, size int64) models.FileInfo {
+		return models.FileInfo{Path: path, Size: size}
+	}
+	sets := []models.DuplicateSet{
+		// Three copies: two are reclaimable
+		{ID: 1, Files: []models.FileInfo{file("/a/x", 100), file("/b/x", 100), file("/c/x", 100)}},
+		// Name-only match of different sizes: the largest is kept
+		{ID: 2, Files: []models.FileInfo{file("/a/y", 10), file("/b/y", 30)}},
+		// Shares /c/x with set 1, whose copy is already kept
+		{ID: 3, Files: []models.FileInfo{file("/c/x", 100), file("/d/x", 100)}},
+	}
+
+	assert.Equal(t, int64(200), SetSavings(sets[0]))
+	assert.Equal(t, int64(10), SetSavings(sets[1]))
+	assert.Equal(t, int64(200+10+100), TotalSavings(sets))
+}
+
+func TestTotalSavings_RecordedInodes(t *testing.T) {
+	file := func(path string, ino uint64) models.FileInfo {
+		return models.FileInfo{Path: path, Size: 100, Device: 1, Inode: ino}
+	}
+	// /a/x and /b/x are hardlinks of one file; /c/x is a copy
+	set := models.DuplicateSet{Files: []models.FileInfo{file("/a/x", 5), file("/b/x", 5), file("/c/x", 6)}}
+
+	assert.Equal(t, int64(100), SetSavings(set))
+	assert.Equal(t, int64(0), RemovalSavings(set.Files[:1], set.Files[1:2]))
+}
+
+func TestTotalSavings_Hardlinks(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a.jpg")
+	require.NoError(t, os.WriteFile(original, []byte("same"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.jpg"), []byte("same"), 0644))
+	link := filepath.Join(dir, "c.jpg")
+	if err := os.Link(original, link); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+	if _, ok := storageID(original); !ok {
+		t.Skip("storage IDs not available on this platform")
+	}
+
+	file := func(name string) models.FileInfo {
+		return models.FileInfo{Path: filepath.Join(dir, name), Size: 4}
+	}
+	set := models.DuplicateSet{Files: []models.FileInfo{file("a.jpg"), file("b.jpg"), file("c.jpg")}}
+
+	// a.jpg and c.jpg share storage, so only one copy is reclaimable
+	assert.Equal(t, int64(4), SetSavings(set))
+	assert.Equal(t, int64(0), RemovalSavings([]models.FileInfo{file("a.jpg")}, []models.FileInfo{file("c.jpg")}))
+	assert.Equal(t, int64(4), RemovalSavings([]models.FileInfo{file("a.jpg")}, []models.FileInfo{file("b.jpg"), file("c.jpg")}))
+}
@@ -0,0 +1,39 @@
+package finder
+
+import (
+	"sort"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// scheduleFiles returns files in the order to hash them: smallest first, so
+// the many small files are confirmed in the time one giant file would take
+// and giant files begin last. Files of the same size keep their order.
+func scheduleFiles(files []*models.FileInfo) []*models.FileInfo {
+	scheduled := append([]*models.FileInfo(nil), files...)
+	sort.SliceStable(scheduled, func(i, j int) bool { return scheduled[i].Size < scheduled[j].Size })
+	return scheduled
+}
+
+// hashCost returns the bytes still to read to check m by hash: none for
+// matches that need no hashing, and one file's size for a match whose
+// other file is already hashed
+func hashCost(m models.FileMatch) int64 {
+	if m.Hardlinked || m.Mismatch != "" {
+		return 0
+	}
+	var cost int64
+	for _, file := range []models.FileInfo{m.File1, m.File2} {
+		if file.Hash == "" {
+			cost += file.Size
+		}
+	}
+	return cost
+}
+
+// scheduleMatches orders matches cheapest to check by hash first, keeping
+// filename order among equal costs, so small and nearly finished matches
+// are confirmed and can be shown while large files are still being read
+func scheduleMatches(matches []models.FileMatch) {
+	sort.SliceStable(matches, func(i, j int) bool { return hashCost(matches[i]) < hashCost(matches[j]) })
+}
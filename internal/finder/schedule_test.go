@@ -0,0 +1,67 @@
+package finder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScheduleFiles(t *testing.T) {
+	files := []*models.FileInfo{
+		{Path: "/movie.mkv", Size: 4 << 30},
+		{Path: "/a.jpg", Size: 100},
+		{Path: "/b.jpg", Size: 100},
+		{Path: "/c.txt", Size: 10},
+	}
+	var paths []string
+	for _, file := range scheduleFiles(files) {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"/c.txt", "/a.jpg", "/b.jpg", "/movie.mkv"}, paths)
+	assert.Equal(t, "/movie.mkv", files[0].Path, "the caller's slice is left as it was")
+}
+
+func TestScheduleMatches(t *testing.T) {
+	file := func(size int64, hash string) models.FileInfo { return models.FileInfo{Size: size, Hash: hash} }
+	matches := []models.FileMatch{
+		{Filename: "big", File1: file(1000, ""), File2: file(1000, "")},
+		{Filename: "half done", File1: file(600, "h"), File2: file(600, "")},
+		{Filename: "small", File1: file(400, ""), File2: file(400, "")},
+		{Filename: "different", File1: file(5000, ""), File2: file(5000, ""), Mismatch: models.CheckSample},
+	}
+	scheduleMatches(matches)
+
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Filename)
+	}
+	assert.Equal(t, []string{"different", "half done", "small", "big"}, names)
+}
+
+func TestStreamPair_SmallFilesFirst(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	big := string(make([]byte, 64*1024))
+	var files1, files2 []models.FileInfo
+	for _, name := range []string{"a.bin", "b.bin"} {
+		files1 = append(files1, writeTestFile(t, dir1, name, big))
+		files2 = append(files2, writeTestFile(t, dir2, name, big))
+	}
+	files1 = append(files1, writeTestFile(t, dir1, "z.txt", "small"))
+	files2 = append(files2, writeTestFile(t, dir2, "z.txt", "small"))
+
+	f := NewFinder(models.ScanOptions{CompareHash: true})
+	var emitted []string
+	_, err := f.StreamPair(context.Background(), files1, files2, func(batch []models.FileMatch) error {
+		for _, m := range batch {
+			emitted = append(emitted, m.Filename)
+			assert.True(t, m.HashChecked)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"z.txt", "a.bin", "b.bin"}, emitted)
+}
@@ -0,0 +1,97 @@
+package finder
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// DropSnapshotCopies removes matches that are one file seen through a
+// snapshot root (a ZFS or btrfs snapshot, or a Time Machine backup) rather
+// than an extra copy. A match with a file under one of the snapshot roots is
+// dropped when both files share an inode number and size, or sit at the same
+// path relative to their roots with the same content. Hashes are computed
+// where needed, until ctx is done. It returns the number of matches dropped.
+func (f *Finder) DropSnapshotCopies(ctx context.Context, comparisons []models.PairComparison, snapshots []string) int {
+	if len(snapshots) == 0 {
+		return 0
+	}
+	isSnapshot := func(dir string) bool {
+		for _, s := range snapshots {
+			if samePath(dir, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Find the matches that might be the same file, hashing those that
+	// can only be told apart by content
+	var toHash []*models.FileInfo
+	for i := range comparisons {
+		c := &comparisons[i]
+		if !isSnapshot(c.Dir1) && !isSnapshot(c.Dir2) {
+			continue
+		}
+		for j := range c.Matches {
+			m := &c.Matches[j]
+			if sameInode(m.File1, m.File2) || !sameRelativePath(m.File1, m.File2) || m.File1.Size != m.File2.Size {
+				continue
+			}
+			for _, file := range []*models.FileInfo{&m.File1, &m.File2} {
+				if file.Hash == "" {
+					toHash = append(toHash, file)
+				}
+			}
+		}
+	}
+	_ = computeHashes(ctx, toHash, f.hashWorkers(), f.options)
+
+	dropped := 0
+	for i := range comparisons {
+		c := &comparisons[i]
+		if !isSnapshot(c.Dir1) && !isSnapshot(c.Dir2) {
+			continue
+		}
+		kept := c.Matches[:0]
+		for _, m := range c.Matches {
+			if isSnapshotCopy(m) {
+				dropped++
+				continue
+			}
+			kept = append(kept, m)
+		}
+		c.Matches = kept
+	}
+	return dropped
+}
+
+// isSnapshotCopy reports whether a match is one file seen through two roots
+func isSnapshotCopy(m models.FileMatch) bool {
+	if sameInode(m.File1, m.File2) {
+		return true
+	}
+	return sameRelativePath(m.File1, m.File2) && m.File1.Size == m.File2.Size &&
+		m.File1.Hash != "" && m.File1.Hash == m.File2.Hash
+}
+
+// sameRelativePath reports whether two files sit at the same path below
+// their root directories
+func sameRelativePath(a, b models.FileInfo) bool {
+	relA, errA := filepath.Rel(a.Directory, a.Path)
+	relB, errB := filepath.Rel(b.Directory, b.Path)
+	return errA == nil && errB == nil && relA == relB
+}
+
+// sameInode reports whether two files of the same size share an inode
+// number. Devices are not compared, since a snapshot is mounted as a
+// separate filesystem that keeps the inode numbers of the live tree.
+func sameInode(a, b models.FileInfo) bool {
+	if a.Size != b.Size {
+		return false
+	}
+	inoA, okA := inode(a.Path)
+	inoB, okB := inode(b.Path)
+	return okA && okB && inoA == inoB
+}
@@ -0,0 +1,79 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestDropSnapshotCopies(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "live")
+	snap := filepath.Join(root, "snap")
+	other := filepath.Join(root, "other")
+
+	write := func(dir, rel, content string) models.FileInfo {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return models.FileInfo{Path: path, Directory: dir, Size: int64(len(content))}
+	}
+
+	unchanged := models.FileMatch{Filename: "a.jpg", File1: write(live, "2024/a.jpg", "same"), File2: write(snap, "2024/a.jpg", "same")}
+	edited := models.FileMatch{Filename: "b.jpg", File1: write(live, "2024/b.jpg", "new!"), File2: write(snap, "2024/b.jpg", "old!")}
+	moved := models.FileMatch{Filename: "c.jpg", File1: write(live, "2024/c.jpg", "copy"), File2: write(snap, "2023/c.jpg", "copy")}
+	liveCopy := models.FileMatch{Filename: "a.jpg", File1: unchanged.File1, File2: write(other, "2024/a.jpg", "same")}
+
+	comparisons := []models.PairComparison{
+		{Dir1: live, Dir2: snap, Matches: []models.FileMatch{unchanged, edited, moved}},
+		{Dir1: live, Dir2: other, Matches: []models.FileMatch{liveCopy}},
+	}
+
+	f := NewFinder(models.ScanOptions{NumWorkers: 2})
+	dropped := f.DropSnapshotCopies(context.Background(), comparisons, []string{snap})
+
+	assert.Equal(t, 1, dropped)
+	require.Len(t, comparisons[0].Matches, 2)
+	assert.Equal(t, "b.jpg", comparisons[0].Matches[0].Filename)
+	assert.Equal(t, "c.jpg", comparisons[0].Matches[1].Filename)
+	// Copies between live roots are untouched
+	assert.Len(t, comparisons[1].Matches, 1)
+}
+
+func TestDropSnapshotCopies_SameInode(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "live")
+	snap := filepath.Join(root, "snap")
+	require.NoError(t, os.MkdirAll(live, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(snap, "moved"), 0755))
+
+	livePath := filepath.Join(live, "a.jpg")
+	snapPath := filepath.Join(snap, "moved", "a.jpg")
+	require.NoError(t, os.WriteFile(livePath, []byte("content"), 0644))
+	if err := os.Link(livePath, snapPath); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	if _, ok := inode(livePath); !ok {
+		t.Skip("inode numbers not available on this platform")
+	}
+
+	comparisons := []models.PairComparison{{
+		Dir1: live,
+		Dir2: snap,
+		Matches: []models.FileMatch{{
+			Filename: "a.jpg",
+			File1:    models.FileInfo{Path: livePath, Directory: live, Size: 7},
+			File2:    models.FileInfo{Path: snapPath, Directory: snap, Size: 7},
+		}},
+	}}
+
+	f := NewFinder(models.ScanOptions{NumWorkers: 1})
+	assert.Equal(t, 1, f.DropSnapshotCopies(context.Background(), comparisons, []string{snap}))
+	assert.Empty(t, comparisons[0].Matches)
+}
@@ -0,0 +1,79 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// What hash-equal files must also pass to count as identical (ScanOptions.Verify)
+const (
+	VerifyHash  = "hash"  // Equal hashes are enough
+	VerifyBytes = "bytes" // Files with equal hashes are also compared byte by byte
+)
+
+// VerifyModes lists the supported verification modes
+var VerifyModes = []string{VerifyHash, VerifyBytes}
+
+// ValidateVerify checks a --verify value
+func ValidateVerify(mode string) error {
+	for _, m := range VerifyModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --verify mode %q (supported: %s)", mode, strings.Join(VerifyModes, ", "))
+}
+
+// verifyHashMatches compares the hash-equal matches byte by byte with
+// VerifyBytes, so a hash collision is never reported as a duplicate.
+// Matches with a file on an offline volume cannot be read and keep their
+// hash verdict.
+func (f *Finder) verifyHashMatches(ctx context.Context, matches []models.FileMatch) {
+	if f.options.Verify != VerifyBytes {
+		return
+	}
+	var toVerify []*models.FileMatch
+	for i := range matches {
+		m := &matches[i]
+		if m.IsDuplicate() && m.HashChecked && m.HashMatch && !m.Offline() {
+			toVerify = append(toVerify, m)
+		}
+	}
+	f.verifyBytes(ctx, toVerify)
+}
+
+// verifyBytes compares the two files of each match byte by byte, recording
+// the outcome in Verified or Mismatch. Once ctx is done, the matches left
+// keep their earlier verdict.
+func (f *Finder) verifyBytes(ctx context.Context, matches []*models.FileMatch) {
+	jobs := make(chan *models.FileMatch, len(matches))
+	for _, m := range matches {
+		jobs <- m
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < f.hashWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				equal, err := compareSafely(m, FilesEqual)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error comparing %s and %s: %v\n", m.File1.Path, m.File2.Path, err)
+					continue
+				}
+				m.Verified, m.Mismatch = verdict(models.CheckBytes, equal, m.Verified)
+			}
+		}()
+	}
+	wg.Wait()
+}
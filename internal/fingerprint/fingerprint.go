@@ -0,0 +1,273 @@
+// Package fingerprint computes a Merkle-style digest of a directory tree
+// from its names and file contents, so two trees, or one tree at two
+// times, can be compared from saved fingerprints instead of reading both
+// again
+package fingerprint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// Version is the current version of the fingerprint document
+const Version = 1
+
+// Fingerprint is the digest of a tree, with the digests of every
+// directory in it so a comparison can tell where two trees differ
+type Fingerprint struct {
+	Version     int            `json:"version"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	RunID       string         `json:"run_id"`
+	Root        string         `json:"root"`   // The directory, as given
+	Digest      string         `json:"digest"` // Digest of the whole tree, the root's in Dirs
+	Files       int            `json:"files"`  // Regular files in the tree
+	Bytes       int64          `json:"bytes"`  // Total size of the files
+	Dirs        map[string]Dir `json:"dirs"`   // By slash-separated path relative to the root, "." for the root
+}
+
+// Dir holds the digests of one directory
+type Dir struct {
+	Digest  string `json:"digest"`  // Names and contents of everything under the directory
+	Entries string `json:"entries"` // Names and contents of its files and symlinks, and the names of its subdirectories
+}
+
+// Compute walks root and fingerprints it. File contents are hashed with
+// numWorkers goroutines, taking the hashes of unchanged files from cache
+// when it is not nil and storing the new ones. Symlinks are recorded by
+// their target without being followed, and FIFOs, sockets and devices
+// are left out. Any path that cannot be read fails the fingerprint, as it
+// would no longer describe the whole tree.
+func Compute(ctx context.Context, root string, cache finder.HashCache, numWorkers int) (*Fingerprint, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	// A root given as a symlink is followed, as the scanner does
+	walkRoot := root
+	if info, err := os.Lstat(root); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		walkRoot = root + string(filepath.Separator)
+	}
+
+	lines := make(map[string][]string)    // Entry lines of each directory
+	children := make(map[string][]string) // Subdirectories of each directory
+	var dirs []string
+	var files []*models.FileInfo
+	var fileRels []string // Path of each file relative to the root
+
+	err = filepath.WalkDir(walkRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		parent, name := path.Dir(rel), path.Base(rel)
+
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, rel)
+			if rel != "." {
+				lines[parent] = append(lines[parent], entryLine("d", name, ""))
+				children[parent] = append(children[parent], rel)
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			lines[parent] = append(lines[parent], entryLine("l", name, filepath.ToSlash(target)))
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, &models.FileInfo{Path: p, Directory: root, Size: info.Size(), ModTime: info.ModTime()})
+			fileRels = append(fileRels, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fp := &Fingerprint{Version: Version, GeneratedAt: time.Now().UTC(), RunID: runid.ID(), Root: root, Dirs: make(map[string]Dir)}
+	if err := hashFiles(ctx, files, cache, numWorkers); err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		parent, name := path.Dir(fileRels[i]), path.Base(fileRels[i])
+		lines[parent] = append(lines[parent], entryLine("f", name, file.Hash))
+		fp.Files++
+		fp.Bytes += file.Size
+	}
+
+	// Deepest first, so every subdirectory's digest is known before its parent's
+	sort.Slice(dirs, func(i, j int) bool { return depth(dirs[i]) > depth(dirs[j]) })
+	for _, dir := range dirs {
+		entries := lines[dir]
+		sort.Strings(entries)
+		dirDigest := Dir{Entries: digest(entries)}
+
+		var subdirs []string
+		for _, sub := range children[dir] {
+			subdirs = append(subdirs, entryLine("d", path.Base(sub), fp.Dirs[sub].Digest))
+		}
+		sort.Strings(subdirs)
+		dirDigest.Digest = digest(append([]string{dirDigest.Entries}, subdirs...))
+		fp.Dirs[dir] = dirDigest
+	}
+	fp.Digest = fp.Dirs["."].Digest
+	return fp, nil
+}
+
+// hashFiles fills in the hash of every file, from cache where the file is
+// unchanged
+func hashFiles(ctx context.Context, files []*models.FileInfo, cache finder.HashCache, numWorkers int) error {
+	var toHash []*models.FileInfo
+	for _, file := range files {
+		if cache != nil {
+			if hash, ok := cache.Lookup(*file); ok {
+				file.Hash = hash
+				continue
+			}
+		}
+		toHash = append(toHash, file)
+	}
+	if err := finder.ComputeHashesParallel(ctx, toHash, numWorkers); err != nil {
+		return err
+	}
+	if cache != nil {
+		for _, file := range toHash {
+			cache.Store(*file)
+		}
+	}
+	return nil
+}
+
+// entryLine describes one entry of a directory: its kind (d, f or l), its
+// name and its file hash or link target
+func entryLine(kind, name, value string) string {
+	return kind + "\x00" + name + "\x00" + value
+}
+
+// digest hashes lines, in the order given
+func digest(lines []string) string {
+	hash := xxhash.New()
+	for _, line := range lines {
+		hash.WriteString(line)
+		hash.WriteString("\n")
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// depth returns how many directories deep rel lies below the root
+func depth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// Save writes fp to path, replacing the file only once it is complete
+func Save(fp *Fingerprint, path string) error {
+	data, err := json.MarshalIndent(fp, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot write fingerprint: %w", err)
+	}
+	return file.Commit()
+}
+
+// Load reads a fingerprint saved with Save
+func Load(path string) (*Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fingerprint: %w", err)
+	}
+
+	var fp Fingerprint
+	if err := json.Unmarshal(data, &fp); err != nil {
+		return nil, fmt.Errorf("cannot parse fingerprint %s: %w", path, err)
+	}
+	if fp.Version > Version {
+		return nil, fmt.Errorf("fingerprint %s uses version %d, newer than supported version %d", path, fp.Version, Version)
+	}
+	if fp.Digest == "" {
+		return nil, fmt.Errorf("%s is not a fingerprint", path)
+	}
+	return &fp, nil
+}
+
+// Kinds of Difference
+const (
+	Changed = "changed" // Files, links or subdirectory names differ
+	OnlyIn1 = "only in first"
+	OnlyIn2 = "only in second"
+)
+
+// Difference is a directory where two fingerprints differ
+type Difference struct {
+	Dir  string // Slash-separated path relative to the roots
+	Kind string
+}
+
+// Compare returns where the trees of a and b differ, sorted by directory:
+// directories whose own entries changed, and the topmost directories
+// present in only one tree. Identical trees have no differences.
+func Compare(a, b *Fingerprint) []Difference {
+	if a.Digest == b.Digest {
+		return nil
+	}
+	var diffs []Difference
+	for dir, da := range a.Dirs {
+		db, ok := b.Dirs[dir]
+		switch {
+		case !ok:
+			if _, parentOK := b.Dirs[path.Dir(dir)]; parentOK {
+				diffs = append(diffs, Difference{Dir: dir, Kind: OnlyIn1})
+			}
+		case da.Entries != db.Entries:
+			diffs = append(diffs, Difference{Dir: dir, Kind: Changed})
+		}
+	}
+	for dir := range b.Dirs {
+		if _, ok := a.Dirs[dir]; ok {
+			continue
+		}
+		if _, parentOK := a.Dirs[path.Dir(dir)]; parentOK {
+			diffs = append(diffs, Difference{Dir: dir, Kind: OnlyIn2})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Dir < diffs[j].Dir })
+	return diffs
+}
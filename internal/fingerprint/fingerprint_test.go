@@ -0,0 +1,146 @@
+package fingerprint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// writeTree creates files under dir from slash-separated paths to contents
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+}
+
+var sampleTree = map[string]string{
+	"a.jpg":             "a",
+	"album/b.jpg":       "b",
+	"album/2024/c.jpg":  "c",
+	"documents/cv.pdf":  "cv",
+	"documents/old/x.d": "x",
+}
+
+func compute(t *testing.T, dir string) *Fingerprint {
+	t.Helper()
+	fp, err := Compute(context.Background(), dir, nil, 2)
+	require.NoError(t, err)
+	return fp
+}
+
+func TestCompute(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeTree(t, dir1, sampleTree)
+	writeTree(t, dir2, sampleTree)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir1, "empty"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir2, "empty"), 0755))
+
+	fp1, fp2 := compute(t, dir1), compute(t, dir2)
+	assert.Equal(t, Version, fp1.Version)
+	assert.Equal(t, 5, fp1.Files)
+	assert.Equal(t, int64(6), fp1.Bytes)
+	assert.Len(t, fp1.Dirs, 6)
+	assert.Equal(t, fp1.Dirs["."].Digest, fp1.Digest)
+	assert.Equal(t, fp1.Digest, fp2.Digest)
+	assert.Empty(t, Compare(fp1, fp2))
+}
+
+func TestCompute_DetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, sampleTree)
+	base := compute(t, dir)
+
+	for name, change := range map[string]func(dir string){
+		"contents": func(dir string) { writeTree(t, dir, map[string]string{"album/2024/c.jpg": "C"}) },
+		"rename": func(dir string) {
+			require.NoError(t, os.Rename(filepath.Join(dir, "a.jpg"), filepath.Join(dir, "A.jpg")))
+		},
+		"move": func(dir string) {
+			require.NoError(t, os.Rename(filepath.Join(dir, "album/b.jpg"), filepath.Join(dir, "album/2024/b.jpg")))
+		},
+		"empty dir": func(dir string) { require.NoError(t, os.MkdirAll(filepath.Join(dir, "new"), 0755)) },
+	} {
+		other := t.TempDir()
+		writeTree(t, other, sampleTree)
+		change(other)
+		assert.NotEqual(t, base.Digest, compute(t, other).Digest, name)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeTree(t, dir1, sampleTree)
+	writeTree(t, dir2, sampleTree)
+	writeTree(t, dir2, map[string]string{"album/2024/c.jpg": "changed", "new/deep/y.jpg": "y"})
+	require.NoError(t, os.RemoveAll(filepath.Join(dir2, "documents", "old")))
+
+	diffs := Compare(compute(t, dir1), compute(t, dir2))
+	assert.Equal(t, []Difference{
+		{Dir: ".", Kind: Changed},
+		{Dir: "album/2024", Kind: Changed},
+		{Dir: "documents", Kind: Changed},
+		{Dir: "documents/old", Kind: OnlyIn1},
+		{Dir: "new", Kind: OnlyIn2},
+	}, diffs)
+}
+
+func TestCompute_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, sampleTree)
+	if err := os.Symlink("a.jpg", filepath.Join(dir, "link.jpg")); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+	fp := compute(t, dir)
+	assert.Equal(t, 5, fp.Files, "links are not counted as files")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "link.jpg")))
+	require.NoError(t, os.Symlink("album/b.jpg", filepath.Join(dir, "link.jpg")))
+	assert.NotEqual(t, fp.Digest, compute(t, dir).Digest, "a link's target is part of the digest")
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, sampleTree)
+	fp := compute(t, dir)
+
+	path := filepath.Join(t.TempDir(), "tree.fp.json")
+	require.NoError(t, Save(fp, path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, fp.Digest, loaded.Digest)
+	assert.Equal(t, fp.Dirs, loaded.Dirs)
+	assert.Empty(t, Compare(fp, loaded))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1}`), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "not a fingerprint")
+}
+
+// fakeCache answers every lookup with a fixed hash
+type fakeCache struct{ stored *int }
+
+func (c fakeCache) Lookup(file models.FileInfo) (string, bool) { return "cached", true }
+func (c fakeCache) Store(file models.FileInfo)                 { *c.stored++ }
+
+func TestCompute_UsesCache(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeTree(t, dir1, map[string]string{"a.jpg": "a"})
+	writeTree(t, dir2, map[string]string{"a.jpg": "different"})
+
+	stored := 0
+	fp1, err := Compute(context.Background(), dir1, fakeCache{&stored}, 1)
+	require.NoError(t, err)
+	fp2, err := Compute(context.Background(), dir2, fakeCache{&stored}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, fp1.Digest, fp2.Digest, "cached hashes are used instead of reading the files")
+	assert.Zero(t, stored)
+}
@@ -0,0 +1,176 @@
+// Package foreign reads the duplicate sets found by other duplicate
+// finders, so they can be reviewed in dup-finder's interactive session:
+// the output of fdupes -1, the results.txt of rdfind, and the JSON saved
+// by czkawka.
+package foreign
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tools whose results can be read
+const (
+	ToolFdupes  = "fdupes"  // Output of fdupes -1: one set per line
+	ToolRdfind  = "rdfind"  // results.txt written by rdfind
+	ToolCzkawka = "czkawka" // JSON saved by czkawka
+)
+
+// Tools lists the tools whose results can be read
+var Tools = []string{ToolFdupes, ToolRdfind, ToolCzkawka}
+
+// Detect returns the tool that wrote data, judged by its first bytes: JSON
+// is czkawka's, a line starting with DUPTYPE_ or rdfind's header comment
+// is rdfind's, and anything else is read as fdupes -1 output
+func Detect(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")):
+		return ToolCzkawka
+	case bytes.HasPrefix(trimmed, []byte("# Automatically generated")), bytes.HasPrefix(trimmed, []byte("DUPTYPE_")),
+		bytes.Contains(trimmed, []byte("\nDUPTYPE_")):
+		return ToolRdfind
+	}
+	return ToolFdupes
+}
+
+// Read returns the duplicate sets in data, written by tool, each as the
+// paths of its files in the order the tool listed them. Sets of fewer
+// than two files are left out.
+func Read(data []byte, tool string) ([][]string, error) {
+	var sets [][]string
+	var err error
+	switch tool {
+	case ToolFdupes:
+		sets, err = readFdupes(data)
+	case ToolRdfind:
+		sets, err = readRdfind(data)
+	case ToolCzkawka:
+		sets, err = readCzkawka(data)
+	default:
+		return nil, fmt.Errorf("unknown tool %q (supported: %s)", tool, strings.Join(Tools, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s results: %w", tool, err)
+	}
+	return slices.DeleteFunc(sets, func(set []string) bool { return len(set) < 2 }), nil
+}
+
+// readFdupes reads the output of fdupes -1, which lists each set on a line,
+// its paths separated by spaces and the spaces and backslashes within
+// paths escaped with a backslash
+func readFdupes(data []byte) ([][]string, error) {
+	var sets [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" {
+			continue
+		}
+		var set []string
+		var path strings.Builder
+		escaped := false
+		for _, r := range line {
+			switch {
+			case escaped:
+				path.WriteRune(r)
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == ' ':
+				if path.Len() > 0 {
+					set = append(set, path.String())
+					path.Reset()
+				}
+			default:
+				path.WriteRune(r)
+			}
+		}
+		if path.Len() > 0 {
+			set = append(set, path.String())
+		}
+		sets = append(sets, set)
+	}
+	return sets, scanner.Err()
+}
+
+// readRdfind reads rdfind's results.txt: a line per file of duptype, id,
+// depth, size, device, inode, priority and path, where the first file of a
+// set has a positive id and its duplicates the same id negated
+func readRdfind(data []byte) ([][]string, error) {
+	var sets [][]string
+	index := make(map[int]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, " ", 8)
+		if len(fields) < 8 || !strings.HasPrefix(fields[0], "DUPTYPE_") {
+			return nil, fmt.Errorf("line %d: expected duptype, id, depth, size, device, inode, priority and name", line)
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id %q", line, fields[1])
+		}
+		id = max(id, -id)
+		i, ok := index[id]
+		if !ok {
+			i = len(sets)
+			index[id] = i
+			sets = append(sets, nil)
+		}
+		sets[i] = append(sets[i], fields[7])
+	}
+	return sets, scanner.Err()
+}
+
+// readCzkawka reads the JSON czkawka saves, whose layout varies with the
+// search mode and version: every array of objects with a path, found at any
+// depth, is a set. Sets under an object are taken in key order.
+func readCzkawka(data []byte) ([][]string, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var sets [][]string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k])
+			}
+		case []any:
+			var set []string
+			for _, item := range v {
+				if entry, ok := item.(map[string]any); ok {
+					if path, ok := entry["path"].(string); ok {
+						set = append(set, path)
+						continue
+					}
+				}
+				walk(item)
+			}
+			if len(set) > 0 {
+				sets = append(sets, set)
+			}
+		}
+	}
+	walk(doc)
+	return sets, nil
+}
@@ -0,0 +1,77 @@
+package foreign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead_Fdupes(t *testing.T) {
+	data := "/a/photo.jpg /b/photo.jpg /c/my\\ photo\\\\1.jpg \n\n/a/lonely.txt\n/a/notes.txt /b/notes.txt\r\n"
+	assert.Equal(t, ToolFdupes, Detect([]byte(data)))
+
+	sets, err := Read([]byte(data), ToolFdupes)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"/a/photo.jpg", "/b/photo.jpg", "/c/my photo\\1.jpg"},
+		{"/a/notes.txt", "/b/notes.txt"},
+	}, sets)
+}
+
+func TestRead_Rdfind(t *testing.T) {
+	data := `# Automatically generated
+# duptype id depth size device inode priority name
+DUPTYPE_FIRST_OCCURRENCE 3 0 1024 2049 11 1 /a/photo.jpg
+DUPTYPE_FIRST_OCCURRENCE 5 1 12 2049 12 1 /a/docs/notes.txt
+DUPTYPE_OUTSIDE_TREE -3 0 1024 2049 21 2 /b/my photo.jpg
+DUPTYPE_WITHIN_SAME_TREE -5 1 12 2049 13 1 /a/old/notes.txt
+# end of file
+`
+	assert.Equal(t, ToolRdfind, Detect([]byte(data)))
+
+	sets, err := Read([]byte(data), ToolRdfind)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"/a/photo.jpg", "/b/my photo.jpg"},
+		{"/a/docs/notes.txt", "/a/old/notes.txt"},
+	}, sets)
+
+	_, err = Read([]byte("DUPTYPE_FIRST_OCCURRENCE 3 0\n"), ToolRdfind)
+	assert.Error(t, err)
+}
+
+func TestRead_Czkawka(t *testing.T) {
+	// Duplicates by hash are grouped under their size
+	data := `{
+  "2048": [[
+    {"path": "/b/photo.jpg", "modified_date": 1700000000, "size": 2048, "hash": "abc"},
+    {"path": "/a/photo.jpg", "modified_date": 1700000000, "size": 2048, "hash": "abc"}
+  ]],
+  "12": [[
+    {"path": "/a/notes.txt", "size": 12},
+    {"path": "/b/notes.txt", "size": 12}
+  ]]
+}`
+	assert.Equal(t, ToolCzkawka, Detect([]byte(data)))
+
+	sets, err := Read([]byte(data), ToolCzkawka)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"/a/notes.txt", "/b/notes.txt"},
+		{"/b/photo.jpg", "/a/photo.jpg"},
+	}, sets)
+
+	// Other modes save a plain list of sets
+	sets, err = Read([]byte(`[[{"path": "/a/x"}, {"path": "/b/x"}], [{"path": "/a/y"}]]`), ToolCzkawka)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"/a/x", "/b/x"}}, sets)
+
+	_, err = Read([]byte(`{"12": [`), ToolCzkawka)
+	assert.Error(t, err)
+}
+
+func TestRead_UnknownTool(t *testing.T) {
+	_, err := Read(nil, "dupeguru")
+	assert.Error(t, err)
+}
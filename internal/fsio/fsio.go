@@ -0,0 +1,136 @@
+// Package fsio wraps file system calls that can block indefinitely, such as
+// stat and open on an unresponsive network mount, with a timeout. A call
+// that times out is abandoned, not cancelled: its goroutine finishes
+// whenever the kernel returns, and a file it opens late is closed.
+package fsio
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.Mutex
+	timeout  time.Duration
+	timedOut = make(map[string]bool)
+)
+
+// TimeoutError reports a call that did not return within the timeout
+type TimeoutError struct {
+	Op      string
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s %s: timed out after %s", e.Op, e.Path, e.Timeout)
+}
+
+// SetTimeout sets how long Stat, Lstat, Open, ReadDir and Info wait before
+// giving up. Zero, the default, waits as long as the call takes.
+func SetTimeout(d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	timeout = d
+}
+
+// TimedOut returns the paths of calls that timed out, sorted
+func TimedOut() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	paths := make([]string, 0, len(timedOut))
+	for path := range timedOut {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Reset forgets the paths recorded by earlier timeouts
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	timedOut = make(map[string]bool)
+}
+
+// Stat is os.Stat with the timeout
+func Stat(path string) (os.FileInfo, error) {
+	return call("stat", path, func() (os.FileInfo, error) { return os.Stat(path) }, nil)
+}
+
+// Lstat is os.Lstat with the timeout
+func Lstat(path string) (os.FileInfo, error) {
+	return call("lstat", path, func() (os.FileInfo, error) { return os.Lstat(path) }, nil)
+}
+
+// Open is os.Open with the timeout
+func Open(path string) (*os.File, error) {
+	return call("open", path, func() (*os.File, error) { return os.Open(path) }, func(f *os.File) { f.Close() })
+}
+
+// ReadDir is os.ReadDir with the timeout covering both opening and reading
+// the directory. The entries are sorted by name.
+func ReadDir(path string) ([]fs.DirEntry, error) {
+	return call("readdir", path, func() ([]fs.DirEntry, error) { return os.ReadDir(path) }, nil)
+}
+
+// Info is d.Info with the timeout, for an entry read from the directory
+// holding path. Where the directory listing already carries the details,
+// as on Windows, no call is made.
+func Info(path string, d fs.DirEntry) (os.FileInfo, error) {
+	return call("lstat", path, d.Info, nil)
+}
+
+// call runs fn, giving up once the timeout passes. discard releases a
+// result that arrives after the caller has gone.
+func call[T any](op, path string, fn func() (T, error), discard func(T)) (T, error) {
+	mu.Lock()
+	d := timeout
+	mu.Unlock()
+	if d <= 0 {
+		return fn()
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	// Unbuffered, so a late result is handed to discard instead of being
+	// left in the channel
+	results := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		value, err := fn()
+		select {
+		case results <- result{value, err}:
+		case <-abandoned:
+			if err == nil && discard != nil {
+				discard(value)
+			}
+		}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case r := <-results:
+		return r.value, r.err
+	case <-timer.C:
+		close(abandoned)
+		// The result may have arrived just as the timer fired
+		select {
+		case r := <-results:
+			return r.value, r.err
+		default:
+		}
+		mu.Lock()
+		timedOut[path] = true
+		mu.Unlock()
+		var zero T
+		return zero, &TimeoutError{Op: op, Path: path, Timeout: d}
+	}
+}
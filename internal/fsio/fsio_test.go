@@ -0,0 +1,66 @@
+package fsio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCall_Timeout(t *testing.T) {
+	SetTimeout(20 * time.Millisecond)
+	defer SetTimeout(0)
+	defer Reset()
+
+	release := make(chan struct{})
+	discarded := make(chan string, 1)
+	_, err := call("open", "/mnt/nas/stuck", func() (string, error) {
+		<-release
+		return "late", nil
+	}, func(v string) { discarded <- v })
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, "open /mnt/nas/stuck: timed out after 20ms", err.Error())
+	assert.Equal(t, []string{"/mnt/nas/stuck"}, TimedOut())
+
+	// A result arriving after the caller gave up is released
+	close(release)
+	select {
+	case v := <-discarded:
+		assert.Equal(t, "late", v)
+	case <-time.After(time.Second):
+		t.Fatal("late result was not discarded")
+	}
+}
+
+func TestStatAndReadDir(t *testing.T) {
+	SetTimeout(time.Second)
+	defer SetTimeout(0)
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+
+	info, err := Stat(filepath.Join(dir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Size())
+
+	_, err = Lstat(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a.txt", entries[0].Name())
+	assert.Equal(t, "b.txt", entries[1].Name())
+
+	info, err = Info(filepath.Join(dir, "b.txt"), entries[1])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Size())
+	assert.Empty(t, TimedOut())
+}
@@ -0,0 +1,221 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/media"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Options controls an import run
+type Options struct {
+	Into   string // Destination directory
+	ByDate bool   // Organize copies into YYYY/MM folders by capture date (EXIF) or mtime
+	DryRun bool   // Report what would be copied without writing anything
+}
+
+// Result describes what happened to a single source file
+type Result struct {
+	Source      string // Source file path
+	Destination string // Path the file was (or would be) copied to
+	Existing    string // Identical file already present (for skipped files)
+	Size        int64  // File size in bytes
+	Error       error  // Copy error (for failed files)
+}
+
+// Summary collects the results of an import run
+type Summary struct {
+	Copied      []Result
+	Skipped     []Result
+	Failed      []Result
+	BytesCopied int64
+}
+
+// Importer copies files into a destination, skipping content already present there
+type Importer struct {
+	options Options
+	cache   finder.HashCache
+	bySize  map[int64][]*models.FileInfo // Known destination content grouped by size
+	planned map[string]bool              // Destination paths claimed during this run
+	pending map[string]string            // Destination of each source not copied in a dry run
+}
+
+// NewImporter creates an importer that treats existing as the destination's content
+func NewImporter(opts Options, existing []models.FileInfo, cache finder.HashCache) *Importer {
+	im := &Importer{
+		options: opts,
+		cache:   cache,
+		bySize:  make(map[int64][]*models.FileInfo),
+		planned: make(map[string]bool),
+		pending: make(map[string]string),
+	}
+	for i := range existing {
+		file := existing[i]
+		im.bySize[file.Size] = append(im.bySize[file.Size], &file)
+	}
+	return im
+}
+
+// Import copies each file whose content is not yet present in the destination.
+// Copied files join the known content, so duplicates within the source are
+// only imported once.
+func (im *Importer) Import(files []models.FileInfo) Summary {
+	var summary Summary
+
+	for _, file := range files {
+		existing, err := im.findExisting(&file)
+		if err != nil {
+			summary.Failed = append(summary.Failed, Result{Source: file.Path, Size: file.Size, Error: err})
+			continue
+		}
+		if existing != "" {
+			summary.Skipped = append(summary.Skipped, Result{Source: file.Path, Existing: existing, Size: file.Size})
+			continue
+		}
+
+		dest, err := im.destinationFor(file)
+		if err != nil {
+			summary.Failed = append(summary.Failed, Result{Source: file.Path, Size: file.Size, Error: err})
+			continue
+		}
+
+		if !im.options.DryRun {
+			if err := copyFile(file.Path, dest, file.ModTime); err != nil {
+				summary.Failed = append(summary.Failed, Result{Source: file.Path, Destination: dest, Size: file.Size, Error: err})
+				continue
+			}
+		}
+
+		// A dry run writes no copy, so the source stands in for the
+		// destination when later files are compared
+		im.planned[dest] = true
+		copied := file
+		if im.options.DryRun {
+			im.pending[file.Path] = dest
+		} else {
+			copied.Path = dest
+		}
+		im.bySize[file.Size] = append(im.bySize[file.Size], &copied)
+
+		summary.Copied = append(summary.Copied, Result{Source: file.Path, Destination: dest, Size: file.Size})
+		summary.BytesCopied += file.Size
+	}
+
+	return summary
+}
+
+// findExisting returns the path of known content identical to file, or ""
+func (im *Importer) findExisting(file *models.FileInfo) (string, error) {
+	candidates := im.bySize[file.Size]
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	if err := finder.HashWithCache(file, im.cache); err != nil {
+		return "", fmt.Errorf("error hashing: %w", err)
+	}
+	for _, candidate := range candidates {
+		if err := finder.HashWithCache(candidate, im.cache); err != nil {
+			fmt.Fprintf(os.Stderr, "error hashing %s: %v\n", candidate.Path, err)
+			continue
+		}
+		if candidate.Hash == file.Hash {
+			if dest, ok := im.pending[candidate.Path]; ok {
+				return dest, nil
+			}
+			return candidate.Path, nil
+		}
+	}
+	return "", nil
+}
+
+// destinationFor picks a free destination path, keeping the source layout
+// or grouping by date when ByDate is set
+func (im *Importer) destinationFor(file models.FileInfo) (string, error) {
+	var rel string
+	if im.options.ByDate {
+		date := fileDate(file)
+		rel = filepath.Join(date.Format("2006"), date.Format("01"), filepath.Base(file.Path))
+	} else {
+		var err error
+		rel, err = filepath.Rel(file.Directory, file.Path)
+		if err != nil {
+			return "", fmt.Errorf("error computing relative path: %w", err)
+		}
+	}
+
+	return im.uniquePath(filepath.Join(im.options.Into, rel)), nil
+}
+
+// uniquePath appends " (n)" before the extension until the path is unused
+func (im *Importer) uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	candidate := path
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) && !im.planned[candidate] {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+}
+
+// fileDate returns the EXIF capture date for JPEG images, otherwise the mtime
+func fileDate(file models.FileInfo) time.Time {
+	switch strings.ToLower(filepath.Ext(file.Path)) {
+	case ".jpg", ".jpeg":
+		if captured, err := media.CaptureTime(file.Path); err == nil {
+			return captured
+		}
+	}
+	return file.ModTime
+}
+
+// copyFile copies src to dst through a temporary file and preserves the mtime
+func copyFile(src, dst string, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot open source: %w", err)
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".dup-finder-import-*")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot set permissions: %w", err)
+	}
+	if err := os.Chtimes(tmpPath, modTime, modTime); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot set modification time: %w", err)
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot move into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// writeFile creates a file under root and returns its FileInfo
+func writeFile(t *testing.T, root, rel, content string) models.FileInfo {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return models.FileInfo{Path: path, Directory: root, Size: info.Size(), ModTime: info.ModTime()}
+}
+
+func TestImport_SkipsExistingContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dest := filepath.Join(tmpDir, "dest")
+
+	existing := []models.FileInfo{writeFile(t, dest, "archived.jpg", "old photo")}
+	files := []models.FileInfo{
+		writeFile(t, src, "renamed.jpg", "old photo"),
+		writeFile(t, src, "trip/new.jpg", "new photo"),
+	}
+
+	summary := NewImporter(Options{Into: dest}, existing, nil).Import(files)
+
+	require.Len(t, summary.Skipped, 1)
+	assert.Equal(t, existing[0].Path, summary.Skipped[0].Existing)
+
+	require.Len(t, summary.Copied, 1)
+	copied := filepath.Join(dest, "trip", "new.jpg")
+	assert.Equal(t, copied, summary.Copied[0].Destination)
+	assert.Equal(t, int64(len("new photo")), summary.BytesCopied)
+
+	content, err := os.ReadFile(copied)
+	require.NoError(t, err)
+	assert.Equal(t, "new photo", string(content))
+
+	// The modification time is preserved
+	info, err := os.Stat(copied)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(files[1].ModTime))
+}
+
+func TestImport_DuplicatesWithinSourceCopiedOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dest := filepath.Join(tmpDir, "dest")
+
+	files := []models.FileInfo{
+		writeFile(t, src, "a.txt", "same"),
+		writeFile(t, src, "b.txt", "same"),
+	}
+
+	summary := NewImporter(Options{Into: dest}, nil, nil).Import(files)
+
+	assert.Len(t, summary.Copied, 1)
+	require.Len(t, summary.Skipped, 1)
+	assert.Equal(t, filepath.Join(dest, "a.txt"), summary.Skipped[0].Existing)
+}
+
+func TestImport_DryRunMatchesRealRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dest := filepath.Join(tmpDir, "dest")
+
+	files := []models.FileInfo{
+		writeFile(t, src, "x/a.txt", "same"),
+		writeFile(t, src, "y/b.txt", "same"),
+	}
+
+	dry := NewImporter(Options{Into: dest, DryRun: true}, nil, nil).Import(files)
+	require.Empty(t, dry.Failed)
+	assert.Len(t, dry.Copied, 1)
+	require.Len(t, dry.Skipped, 1)
+	assert.Equal(t, filepath.Join(dest, "x", "a.txt"), dry.Skipped[0].Existing)
+
+	real := NewImporter(Options{Into: dest}, nil, nil).Import(files)
+	require.Empty(t, real.Failed)
+	assert.Len(t, real.Copied, len(dry.Copied))
+	assert.Len(t, real.Skipped, len(dry.Skipped))
+	assert.Equal(t, dry.BytesCopied, real.BytesCopied)
+}
+
+func TestImport_NameCollisionGetsSuffix(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dest := filepath.Join(tmpDir, "dest")
+
+	existing := []models.FileInfo{writeFile(t, dest, "notes.txt", "old notes")}
+	files := []models.FileInfo{writeFile(t, src, "notes.txt", "new notes")}
+
+	summary := NewImporter(Options{Into: dest}, existing, nil).Import(files)
+
+	require.Len(t, summary.Copied, 1)
+	assert.Equal(t, filepath.Join(dest, "notes (1).txt"), summary.Copied[0].Destination)
+}
+
+func TestImport_ByDateAndDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dest := filepath.Join(tmpDir, "dest")
+
+	file := writeFile(t, src, "clip.mp4", "video")
+	mtime := time.Date(2019, 3, 10, 12, 0, 0, 0, time.Local)
+	require.NoError(t, os.Chtimes(file.Path, mtime, mtime))
+	file.ModTime = mtime
+
+	summary := NewImporter(Options{Into: dest, ByDate: true, DryRun: true}, nil, nil).Import([]models.FileInfo{file})
+
+	require.Len(t, summary.Copied, 1)
+	assert.Equal(t, filepath.Join(dest, "2019", "03", "clip.mp4"), summary.Copied[0].Destination)
+
+	// Dry run writes nothing
+	_, err := os.Stat(dest)
+	assert.True(t, os.IsNotExist(err))
+}
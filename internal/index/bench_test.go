@@ -0,0 +1,78 @@
+package index
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/devgen"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// benchFiles returns files with hashes to fill an index with, as many as
+// DUP_FINDER_BENCH_FILES asks for
+func benchFiles() []models.FileInfo {
+	n := devgen.ScaleFromEnv(100000)
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := make([]models.FileInfo, n)
+	for i := range files {
+		files[i] = models.FileInfo{
+			Path:    fmt.Sprintf("/data/d%d/d%d/file-%07d.jpg", i%16, i%256, i),
+			Size:    int64(i),
+			ModTime: modTime,
+			Hash:    fmt.Sprintf("%016x", i),
+		}
+	}
+	return files
+}
+
+func BenchmarkIndex(b *testing.B) {
+	files := benchFiles()
+	path := filepath.Join(b.TempDir(), "index.json")
+
+	b.Run("Store", func(b *testing.B) {
+		for b.Loop() {
+			idx := New(path)
+			for _, file := range files {
+				idx.Store(file)
+			}
+		}
+	})
+
+	idx := New(path)
+	for _, file := range files {
+		idx.Store(file)
+	}
+
+	b.Run("Lookup", func(b *testing.B) {
+		for b.Loop() {
+			for _, file := range files {
+				if _, ok := idx.Lookup(file); !ok {
+					b.Fatalf("%s missing", file.Path)
+				}
+			}
+		}
+	})
+
+	b.Run("Save", func(b *testing.B) {
+		for b.Loop() {
+			idx.dirty = true
+			if err := idx.Save(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Load", func(b *testing.B) {
+		for b.Loop() {
+			loaded, err := Load(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if loaded.Len() != len(files) {
+				b.Fatalf("loaded %d entries, want %d", loaded.Len(), len(files))
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package index
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/checksum"
+)
+
+// Supported exchange formats
+const (
+	FormatTSV = "tsv" // path, size, mtime (RFC 3339), xxh64 hash
+	FormatSum = "sum" // "<xxh64>  <path>" lines, as written by xxhsum
+
+	// FormatSHA256Sum is sha256sum output, export only: the digests are
+	// computed on export, as the index holds xxh64 hashes
+	FormatSHA256Sum = checksum.FormatSHA256Sum
+)
+
+// Formats lists the supported exchange formats
+var Formats = []string{FormatTSV, FormatSum, FormatSHA256Sum}
+
+// unsupported explains why formats other tools use cannot be exchanged
+// with an index of xxh64 hashes
+var unsupported = map[string]string{
+	"parquet":  "writing Parquet is not built in",
+	"hashdeep": "hashdeep lists MD5 and SHA-256 hashes, not xxh64",
+	"rdfind":   "rdfind results list duplicates without hashes",
+}
+
+// formatError reports a format that cannot be exchanged
+func formatError(format string) error {
+	if reason, ok := unsupported[format]; ok {
+		return fmt.Errorf("unsupported format %q: %s (supported: %s)", format, reason, strings.Join(Formats, ", "))
+	}
+	return fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
+
+// Export writes all entries to w in the given format. Paths with tabs or
+// line breaks cannot be told apart from the fields of a TSV line, so they
+// are left out of TSV exports and reported in the error once the rest is
+// written. Sum lists escape such paths as GNU tools do. sha256sum exports
+// read every file again, leaving out those missing or changed since they
+// were indexed, as their xxh64 hash no longer describes them.
+func Export(w io.Writer, idx *Index, format string) error {
+	bw := bufio.NewWriter(w)
+
+	left := 0
+	var reason string
+	switch format {
+	case FormatTSV:
+		fmt.Fprintln(bw, "path\tsize\tmtime\txxh64")
+		idx.Each(func(path string, e Entry) {
+			if strings.ContainsAny(path, "\t\n\r") {
+				left++
+				return
+			}
+			fmt.Fprintf(bw, "%s\t%d\t%s\t%s\n", path, e.Size, e.ModTime.Format(time.RFC3339Nano), e.Hash)
+		})
+		reason = "with tabs or line breaks left out of the TSV export"
+	case FormatSum:
+		idx.Each(func(path string, e Entry) {
+			checksum.WriteSumLine(bw, e.Hash, path)
+		})
+	case FormatSHA256Sum:
+		idx.Each(func(path string, e Entry) {
+			info, err := os.Stat(path)
+			if err != nil || info.Size() != e.Size || !info.ModTime().Equal(e.ModTime) {
+				left++
+				return
+			}
+			digest, err := checksum.Sum(path, checksum.SHA256)
+			if err != nil {
+				left++
+				return
+			}
+			checksum.WriteSumLine(bw, digest, path)
+		})
+		reason = "missing, unreadable or changed since indexed left out of the sha256sum export"
+	default:
+		return formatError(format)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if left > 0 {
+		return fmt.Errorf("%d path(s) %s", left, reason)
+	}
+	return nil
+}
+
+// Import reads entries in the given format from r into idx and returns how
+// many were added. Sum lists carry no size or mtime, so those are taken from
+// the file on disk and entries for missing files are skipped.
+func Import(r io.Reader, idx *Index, format string) (int, error) {
+	switch format {
+	case FormatSHA256Sum:
+		return 0, fmt.Errorf("cannot import %s: the index holds xxh64 hashes, not SHA-256", format)
+	case FormatTSV:
+		return importTSV(r, idx)
+	case FormatSum:
+		return importSum(r, idx)
+	default:
+		return 0, formatError(format)
+	}
+}
+
+func importTSV(r io.Reader, idx *Index) (int, error) {
+	scanner := bufio.NewScanner(r)
+	added := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || (line == 1 && strings.HasPrefix(text, "path\t")) {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 4 {
+			return added, fmt.Errorf("line %d: expected 4 tab-separated fields, got %d", line, len(fields))
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return added, fmt.Errorf("line %d: invalid size: %w", line, err)
+		}
+		mtime, err := time.Parse(time.RFC3339Nano, fields[2])
+		if err != nil {
+			return added, fmt.Errorf("line %d: invalid mtime: %w", line, err)
+		}
+		if !isXXH64(fields[3]) {
+			return added, fmt.Errorf("line %d: %q is not an xxh64 hash", line, fields[3])
+		}
+
+		idx.Put(fields[0], Entry{Size: size, ModTime: mtime, Hash: fields[3]})
+		added++
+	}
+	return added, scanner.Err()
+}
+
+func importSum(r io.Reader, idx *Index) (int, error) {
+	scanner := bufio.NewScanner(r)
+	added := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+
+		// "<hash>  <path>" or "<hash> *<path>" (binary mode marker), after
+		// a backslash when the path is escaped
+		escaped := strings.HasPrefix(text, `\`)
+		hash, path, ok := strings.Cut(strings.TrimPrefix(text, `\`), " ")
+		if !ok || !isXXH64(hash) {
+			return added, fmt.Errorf("line %d: expected \"<xxh64>  <path>\"", line)
+		}
+		path = strings.TrimPrefix(strings.TrimPrefix(path, " "), "*")
+		if escaped {
+			path = checksum.Unescape(path)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		idx.Put(path, Entry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash})
+		added++
+	}
+	return added, scanner.Err()
+}
+
+// isXXH64 reports whether s looks like a 64-bit xxHash in hex
+func isXXH64(s string) bool {
+	if len(s) != 16 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 16, 64)
+	return err == nil
+}
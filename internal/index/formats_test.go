@@ -0,0 +1,135 @@
+package index
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestExportImport_TSV(t *testing.T) {
+	tmpDir := t.TempDir()
+	mtime := time.Date(2024, 1, 15, 14, 30, 0, 123, time.UTC)
+	file := models.FileInfo{Path: filepath.Join(tmpDir, "photo.jpg"), Size: 100, ModTime: mtime, Hash: "abcdef0123456789"}
+
+	src := New(filepath.Join(tmpDir, "src.json"))
+	src.Store(file)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, src, FormatTSV))
+	assert.True(t, strings.HasPrefix(buf.String(), "path\tsize\tmtime\txxh64\n"))
+
+	dst := New(filepath.Join(tmpDir, "dst.json"))
+	added, err := Import(&buf, dst, FormatTSV)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	hash, ok := dst.Lookup(file)
+	require.True(t, ok)
+	assert.Equal(t, "abcdef0123456789", hash)
+}
+
+func TestImport_Sum(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("photo"), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	input := "abcdef0123456789  " + path + "\n" +
+		"0123456789abcdef *" + filepath.Join(tmpDir, "missing.jpg") + "\n"
+
+	idx := New(filepath.Join(tmpDir, "index.json"))
+	added, err := Import(strings.NewReader(input), idx, FormatSum)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added, "entries for missing files are skipped")
+
+	hash, ok := idx.Lookup(models.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	require.True(t, ok)
+	assert.Equal(t, "abcdef0123456789", hash)
+}
+
+func TestImport_RejectsForeignHashes(t *testing.T) {
+	idx := New(filepath.Join(t.TempDir(), "index.json"))
+
+	// A sha256 digest cannot seed an xxHash cache
+	sha256Line := strings.Repeat("ab", 32) + "  /data/photo.jpg\n"
+	_, err := Import(strings.NewReader(sha256Line), idx, FormatSum)
+	assert.Error(t, err)
+
+	for _, format := range []string{"hashdeep", "rdfind"} {
+		_, err = Import(strings.NewReader(""), idx, format)
+		assert.ErrorContains(t, err, "unsupported format")
+	}
+	assert.ErrorContains(t, Export(&bytes.Buffer{}, idx, "parquet"), "unsupported format")
+	_, err = Import(strings.NewReader(sha256Line), idx, FormatSHA256Sum)
+	assert.ErrorContains(t, err, "xxh64")
+}
+
+func TestExport_TSVLeavesOutTabbedPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	idx := New(filepath.Join(tmpDir, "index.json"))
+	idx.Store(models.FileInfo{Path: filepath.Join(tmpDir, "ok.jpg"), Size: 1, ModTime: time.Now(), Hash: "abcdef0123456789"})
+	idx.Store(models.FileInfo{Path: filepath.Join(tmpDir, "a\tb.jpg"), Size: 1, ModTime: time.Now(), Hash: "0123456789abcdef"})
+	idx.Store(models.FileInfo{Path: filepath.Join(tmpDir, "a\nb.jpg"), Size: 1, ModTime: time.Now(), Hash: "0123456789abcdef"})
+
+	var buf bytes.Buffer
+	err := Export(&buf, idx, FormatTSV)
+	assert.ErrorContains(t, err, "2 path(s)")
+
+	// The rest is still exported and reads back
+	dst := New(filepath.Join(tmpDir, "dst.json"))
+	added, err := Import(&buf, dst, FormatTSV)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+}
+
+func TestExportImport_SumEscapesLineBreaks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file names cannot hold line breaks on Windows")
+	}
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "a\nb.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("photo"), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	src := New(filepath.Join(tmpDir, "src.json"))
+	src.Store(models.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime(), Hash: "abcdef0123456789"})
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, src, FormatSum))
+	assert.Equal(t, `\abcdef0123456789  `+strings.ReplaceAll(path, "\n", `\n`)+"\n", buf.String())
+
+	dst := New(filepath.Join(tmpDir, "dst.json"))
+	added, err := Import(&buf, dst, FormatSum)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	hash, ok := dst.Lookup(models.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+	require.True(t, ok)
+	assert.Equal(t, "abcdef0123456789", hash)
+}
+
+func TestExport_SHA256Sum(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("photo"), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	idx := New(filepath.Join(tmpDir, "index.json"))
+	idx.Store(models.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime(), Hash: "abcdef0123456789"})
+	idx.Store(models.FileInfo{Path: filepath.Join(tmpDir, "gone.jpg"), Size: 1, ModTime: time.Now(), Hash: "0123456789abcdef"})
+
+	var buf bytes.Buffer
+	err = Export(&buf, idx, FormatSHA256Sum)
+	assert.ErrorContains(t, err, "1 path(s) missing")
+	// sha256sum of "photo"
+	assert.Equal(t, "55c64d0fcd6f9d5f7c828093857e3fdfda68478bb4e9bd24d481ef391c7804e8  "+path+"\n", buf.String())
+}
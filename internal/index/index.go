@@ -0,0 +1,180 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Entry is a cached hash, valid while the file keeps the same size and mtime
+type Entry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"hash"`
+}
+
+// Index is a persistent cache of file hashes keyed by absolute path
+type Index struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Entry
+	dirty   bool
+}
+
+// DefaultPath returns the index location inside the user cache directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "index.json"), nil
+}
+
+// New creates an empty index that will be saved to path
+func New(path string) *Index {
+	return &Index{path: path, entries: make(map[string]Entry)}
+}
+
+// Load reads the index at path. A missing file yields an empty index.
+func Load(path string) (*Index, error) {
+	idx := New(path)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading index: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &idx.entries); err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %w", path, err)
+	}
+	if idx.entries == nil {
+		idx.entries = make(map[string]Entry)
+	}
+	return idx, nil
+}
+
+// Lookup returns the cached hash for file if its size and mtime are unchanged
+func (idx *Index) Lookup(file models.FileInfo) (string, bool) {
+	key, err := filepath.Abs(file.Path)
+	if err != nil {
+		return "", false
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.entries[key]
+	if !ok || entry.Size != file.Size || !entry.ModTime.Equal(file.ModTime) {
+		return "", false
+	}
+	return entry.Hash, true
+}
+
+// Get returns the entry recorded for path, whatever the file looks like now
+func (idx *Index) Get(path string) (Entry, bool) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.entries[key]
+	return entry, ok
+}
+
+// Store records the hash of file. Files without a hash are ignored.
+func (idx *Index) Store(file models.FileInfo) {
+	if file.Hash == "" {
+		return
+	}
+	key, err := filepath.Abs(file.Path)
+	if err != nil {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.entries[key] = Entry{Size: file.Size, ModTime: file.ModTime, Hash: file.Hash}
+	idx.dirty = true
+}
+
+// Put records an entry for path directly, e.g. when importing another index
+func (idx *Index) Put(path string, entry Entry) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.entries[key] = entry
+	idx.dirty = true
+}
+
+// Each calls fn for every entry in path order
+func (idx *Index) Each(fn func(path string, entry Entry)) {
+	idx.mu.Lock()
+	paths := make([]string, 0, len(idx.entries))
+	for path := range idx.entries {
+		paths = append(paths, path)
+	}
+	entries := idx.entries
+	idx.mu.Unlock()
+
+	sort.Strings(paths)
+	for _, path := range paths {
+		fn(path, entries[path])
+	}
+}
+
+// Len returns the number of cached entries
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return len(idx.entries)
+}
+
+// Save writes the index to disk if it changed since it was loaded
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(idx.entries)
+	if err != nil {
+		return fmt.Errorf("error encoding index: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("error creating index directory: %w", err)
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a partial index
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing index: %w", err)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index: %w", err)
+	}
+
+	idx.dirty = false
+	return nil
+}
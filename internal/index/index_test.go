@@ -0,0 +1,67 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestIndex_LookupAndStore(t *testing.T) {
+	idx := New(filepath.Join(t.TempDir(), "index.json"))
+	mtime := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	file := models.FileInfo{Path: "/data/photo.jpg", Size: 100, ModTime: mtime, Hash: "abcdef0123456789"}
+
+	_, ok := idx.Lookup(file)
+	assert.False(t, ok)
+
+	idx.Store(file)
+	hash, ok := idx.Lookup(file)
+	require.True(t, ok)
+	assert.Equal(t, "abcdef0123456789", hash)
+
+	// A changed size or mtime invalidates the entry
+	changed := file
+	changed.Size = 200
+	_, ok = idx.Lookup(changed)
+	assert.False(t, ok)
+
+	changed = file
+	changed.ModTime = mtime.Add(time.Second)
+	_, ok = idx.Lookup(changed)
+	assert.False(t, ok)
+}
+
+func TestIndex_StoreIgnoresEmptyHash(t *testing.T) {
+	idx := New(filepath.Join(t.TempDir(), "index.json"))
+	idx.Store(models.FileInfo{Path: "/data/photo.jpg", Size: 100})
+	assert.Equal(t, 0, idx.Len())
+}
+
+func TestIndex_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "index.json")
+	mtime := time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)
+	file := models.FileInfo{Path: "/data/photo.jpg", Size: 100, ModTime: mtime, Hash: "abcdef0123456789"}
+
+	idx := New(path)
+	idx.Store(file)
+	require.NoError(t, idx.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded.Len())
+
+	hash, ok := loaded.Lookup(file)
+	require.True(t, ok)
+	assert.Equal(t, "abcdef0123456789", hash)
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	idx, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, idx.Len())
+}
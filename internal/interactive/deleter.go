@@ -3,12 +3,111 @@ package interactive
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/trash"
 )
 
 // SafeDelete performs pre-flight checks and deletes the file
 func SafeDelete(path string) models.DeletionResult {
+	return safeRemove(path, os.Remove, "deletion failed")
+}
+
+// SafeTrash performs the same checks as SafeDelete and moves the file to
+// the trash, where it can be restored from
+func SafeTrash(path string) models.DeletionResult {
+	return safeRemove(path, trash.Move, "moving to the trash failed")
+}
+
+// Kinds of symlink that replace duplicates
+const (
+	LinkRelative = "relative" // Relative to the directory of the link, so a tree can be moved as a whole
+	LinkAbsolute = "absolute" // The absolute path of the kept file
+)
+
+// LinkModes lists the kinds of symlink
+var LinkModes = []string{LinkRelative, LinkAbsolute}
+
+// ValidateLink checks a kind of symlink; "" is valid and means files are
+// removed rather than replaced
+func ValidateLink(mode string) error {
+	if mode == "" || slices.Contains(LinkModes, mode) {
+		return nil
+	}
+	return fmt.Errorf("unknown --symlink kind %q (supported: %s)", mode, strings.Join(LinkModes, ", "))
+}
+
+// SafeLink performs the same checks as SafeDelete and replaces the file
+// with a symlink to target, the copy kept in its place. Unlike a hardlink,
+// the link can point to another file system. The link is created beside
+// the file and renamed over it, so the path never goes missing.
+func SafeLink(path, target, mode string) models.DeletionResult {
+	return safeRemove(path, func(path string) error {
+		info, err := os.Stat(target)
+		if err != nil {
+			return fmt.Errorf("cannot access kept file: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("kept file %s is not a regular file", target)
+		}
+		link, err := linkTarget(path, target, mode)
+		if err != nil {
+			return err
+		}
+		tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".dup-finder-link")
+		os.Remove(tmp)
+		if err := os.Symlink(link, tmp); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	}, "replacing with a symlink failed")
+}
+
+// linkTarget returns what a link at path to target holds: target's absolute
+// path, or its path relative to the directory of the link
+func linkTarget(path, target, mode string) (string, error) {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	if mode == LinkAbsolute {
+		return absTarget, nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(filepath.Dir(absPath), absTarget)
+}
+
+// SafeLinks drops, with a message, the actions that replace a file kept
+// by another action of the session, so that every symlink points to a file
+// that stays, never to a link or to nothing
+func SafeLinks(actions []models.UserAction) []models.UserAction {
+	kept := make(map[string]bool)
+	for _, action := range actions {
+		kept[action.KeepFile] = true
+	}
+	var safe []models.UserAction
+	for _, action := range actions {
+		if kept[action.DeleteFile] {
+			fmt.Fprintf(os.Stderr, "✗ %s is not replaced with a symlink: other copies link to it\n", action.DeleteFile)
+			continue
+		}
+		safe = append(safe, action)
+	}
+	return safe
+}
+
+// safeRemove checks that path is a regular file and removes it with remove
+func safeRemove(path string, remove func(string) error, failure string) models.DeletionResult {
 	result := models.DeletionResult{Path: path}
 
 	// Get file info
@@ -27,8 +126,8 @@ func SafeDelete(path string) models.DeletionResult {
 	size := info.Size()
 
 	// Attempt deletion
-	if err := os.Remove(path); err != nil {
-		result.Error = fmt.Errorf("deletion failed: %w", err)
+	if err := remove(path); err != nil {
+		result.Error = fmt.Errorf("%s: %w", failure, err)
 		return result
 	}
 
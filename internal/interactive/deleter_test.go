@@ -3,7 +3,10 @@ package interactive
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+
+	"github.com/Sho2010/dup-finder/internal/models"
 )
 
 func TestSafeDelete(t *testing.T) {
@@ -110,3 +113,150 @@ func TestSafeDeleteResult(t *testing.T) {
 		t.Errorf("Expected size freed %d, got %d", len(content), result.SizeFreed)
 	}
 }
+
+func TestRemoveFilesHardlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	keep := filepath.Join(tmpDir, "keep.txt")
+	link := filepath.Join(tmpDir, "hl.txt")
+	if err := os.WriteFile(keep, []byte("12345"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Link(keep, link); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	actions := []models.UserAction{{Action: "delete", KeepFile: keep, DeleteFile: link}}
+	results, err := RemoveFiles(actions, models.ScanOptions{})
+	if err != nil {
+		t.Fatalf("RemoveFiles failed: %v", err)
+	}
+	if !results[0].Success {
+		t.Fatalf("Expected success, got failure: %v", results[0].Error)
+	}
+	// The kept file still holds the data
+	if results[0].SizeFreed != 0 {
+		t.Errorf("Expected no space freed by removing a hardlink, got %d", results[0].SizeFreed)
+	}
+}
+
+func TestSafeTrash(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash is only set up through XDG_DATA_HOME on Linux")
+	}
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+
+	testFile := filepath.Join(tmpDir, "test_file.txt")
+	content := []byte("test content")
+	if err := os.WriteFile(testFile, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	result := SafeTrash(testFile)
+	if !result.Success {
+		t.Fatalf("Expected success, got failure: %v", result.Error)
+	}
+	if result.SizeFreed != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), result.SizeFreed)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Errorf("File still exists after moving it to the trash")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "data", "Trash", "files", "test_file.txt")); err != nil {
+		t.Errorf("Expected the file in the trash: %v", err)
+	}
+
+	// Directories are refused as by SafeDelete
+	if result := SafeTrash(tmpDir); result.Success {
+		t.Errorf("Expected failure for a directory")
+	}
+}
+
+func TestSafeLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs a privilege on Windows")
+	}
+
+	for _, mode := range LinkModes {
+		t.Run(mode, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			keep := filepath.Join(tmpDir, "a", "photo.jpg")
+			dup := filepath.Join(tmpDir, "b", "photo.jpg")
+			for _, path := range []string{keep, dup} {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("Failed to create directory: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("photo"), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			result := SafeLink(dup, keep, mode)
+			if !result.Success {
+				t.Fatalf("Expected success, got failure: %v", result.Error)
+			}
+			if result.SizeFreed != 5 {
+				t.Errorf("Expected size freed 5, got %d", result.SizeFreed)
+			}
+
+			target, err := os.Readlink(dup)
+			if err != nil {
+				t.Fatalf("Expected a symlink: %v", err)
+			}
+			want := filepath.Join("..", "a", "photo.jpg")
+			if mode == LinkAbsolute {
+				want = keep
+			}
+			if target != want {
+				t.Errorf("Expected the link to point to %s, got %s", want, target)
+			}
+			if data, err := os.ReadFile(dup); err != nil || string(data) != "photo" {
+				t.Errorf("Expected the link to read the kept file, got %q, %v", data, err)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(dup)); len(entries) != 1 {
+				t.Errorf("Expected no temporary link left, got %d entries", len(entries))
+			}
+		})
+	}
+
+	t.Run("kept file missing", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dup := filepath.Join(tmpDir, "photo.jpg")
+		if err := os.WriteFile(dup, []byte("photo"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		result := SafeLink(dup, filepath.Join(tmpDir, "gone.jpg"), LinkRelative)
+		if result.Success {
+			t.Errorf("Expected failure when the kept file is missing")
+		}
+		if info, err := os.Lstat(dup); err != nil || !info.Mode().IsRegular() {
+			t.Errorf("Expected the file left in place")
+		}
+	})
+}
+
+func TestSafeLinks(t *testing.T) {
+	actions := []models.UserAction{
+		{Action: "delete", KeepFile: "/a/1", DeleteFile: "/b/1"},
+		{Action: "delete", KeepFile: "/b/2", DeleteFile: "/a/2"},
+		// Replacing /b/2 would leave the link at /a/2 dangling
+		{Action: "delete", KeepFile: "/c/2", DeleteFile: "/b/2"},
+	}
+
+	safe := SafeLinks(actions)
+	if len(safe) != 2 || safe[0] != actions[0] || safe[1] != actions[1] {
+		t.Errorf("Expected the first two actions, got %+v", safe)
+	}
+}
+
+func TestValidateLink(t *testing.T) {
+	for _, mode := range []string{"", LinkRelative, LinkAbsolute} {
+		if err := ValidateLink(mode); err != nil {
+			t.Errorf("ValidateLink(%q) = %v", mode, err)
+		}
+	}
+	if err := ValidateLink("hard"); err == nil {
+		t.Errorf("Expected an error for an unknown kind")
+	}
+}
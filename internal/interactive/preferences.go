@@ -0,0 +1,58 @@
+package interactive
+
+import (
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// minHabitSets is how many sets a session needs before its choices count
+// as a habit
+const minHabitSets = 3
+
+// habits counts the choices made during a session
+type habits struct {
+	shown        int            // Sets displayed
+	hashRequests int            // Times [h] was chosen
+	choices      int            // Keep decisions, a batch choice counting once
+	kept         map[string]int // Keep decisions per root directory
+}
+
+// keep records that a copy under root was kept
+func (h *habits) keep(root string) {
+	if root == "" {
+		return
+	}
+	if h.kept == nil {
+		h.kept = make(map[string]int)
+	}
+	h.kept[root]++
+	h.choices++
+}
+
+// preferences turns the session's choices into preferences: a root kept in
+// most decisions becomes the preferred directory, and asking for hashes on
+// most sets turns on automatic hashing. autoHash is whether automatic
+// hashing was already on, which stays on since [h] is then never needed.
+func (h habits) preferences(autoHash bool) models.Preferences {
+	prefs := models.Preferences{AutoHash: autoHash}
+	if h.shown >= minHabitSets && h.hashRequests*2 > h.shown {
+		prefs.AutoHash = true
+	}
+	if h.choices >= minHabitSets {
+		for root, n := range h.kept {
+			if n*2 > h.choices {
+				prefs.PreferredDir = root
+			}
+		}
+	}
+	return prefs
+}
+
+// keptRoot returns the root directory of the kept file in set
+func keptRoot(set models.DuplicateSet, keepFile string) string {
+	for _, file := range set.Files {
+		if file.Path == keepFile {
+			return file.Directory
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package interactive
+
+import (
+	"testing"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestHabitsPreferences(t *testing.T) {
+	var h habits
+	h.shown = 4
+	h.hashRequests = 3
+	h.keep("/photos")
+	h.keep("/photos")
+	h.keep("/backup")
+
+	prefs := h.preferences(false)
+	if !prefs.AutoHash {
+		t.Error("Expected hashing most sets to turn on AutoHash")
+	}
+	if prefs.PreferredDir != "/photos" {
+		t.Errorf("Expected /photos as preferred directory, got %q", prefs.PreferredDir)
+	}
+}
+
+func TestHabitsPreferences_NoHabit(t *testing.T) {
+	// Too few choices to count as a habit
+	var h habits
+	h.shown = 2
+	h.hashRequests = 2
+	h.keep("/photos")
+	h.keep("/photos")
+
+	if prefs := h.preferences(false); prefs != (models.Preferences{}) {
+		t.Errorf("Expected no preferences from two sets, got %+v", prefs)
+	}
+
+	// No directory kept in most decisions
+	h = habits{shown: 4}
+	h.keep("/photos")
+	h.keep("/photos")
+	h.keep("/backup")
+	h.keep("/backup")
+	if prefs := h.preferences(false); prefs.PreferredDir != "" || prefs.AutoHash {
+		t.Errorf("Expected no preferences from split choices, got %+v", prefs)
+	}
+
+	// Automatic hashing stays on once enabled
+	if prefs := (habits{}).preferences(true); !prefs.AutoHash {
+		t.Error("Expected AutoHash to stay on")
+	}
+}
+
+func TestKeptRoot(t *testing.T) {
+	set := models.DuplicateSet{Files: []models.FileInfo{
+		{Path: "/a/x.jpg", Directory: "/a"},
+		{Path: "/b/x.jpg", Directory: "/b"},
+	}}
+	if got := keptRoot(set, "/b/x.jpg"); got != "/b" {
+		t.Errorf("Expected /b, got %q", got)
+	}
+	if got := keptRoot(set, "/c/x.jpg"); got != "" {
+		t.Errorf("Expected no root for an unknown file, got %q", got)
+	}
+}
@@ -0,0 +1,765 @@
+package interactive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/media"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// Panes of the full-screen interface
+const (
+	paneSets  = iota // List of duplicate sets
+	paneFiles        // Files of the set under the cursor
+)
+
+// What has been decided for a set in the full-screen interface
+const (
+	setUndecided = iota
+	setMarked    // Files are marked for deletion
+	setSkipped   // Left as it is, by choice or by a skip rule
+	setDifferent // Hashing showed the files differ
+)
+
+// screenSet is a duplicate set with the choices made on it
+type screenSet struct {
+	set      models.DuplicateSet
+	state    int
+	marked   []bool   // Files chosen for deletion
+	reasons  []string // Why each marked file is deleted
+	selected bool     // Chosen for a batch choice
+	shown    bool     // Has been under the cursor
+	detail   string   // What a batch choice retained, or the rule that skipped the set
+}
+
+// lineInput is text being typed on the status line, such as a note
+type lineInput struct {
+	label  string
+	text   []rune
+	commit func(text string)
+}
+
+// screen is the state of the full-screen interface of an interactive
+// session. It changes with keys and is drawn as lines, so it works the
+// same whatever terminal it is shown on.
+type screen struct {
+	sets    []screenSet
+	cursor  int // Set under the cursor
+	top     int // First set in view
+	file    int // File under the cursor in the files pane
+	focus   int
+	rows    int // Sets in view at the last draw
+	input   *lineInput
+	help    bool
+	status  string
+	done    bool   // Finished: go on to confirmation
+	quit    bool   // Quit, leaving the session to resume
+	expired bool   // The session budget was used
+	pending func() // Slow work, run once the status line says what it is
+
+	opts       models.ScanOptions
+	labels     output.Labels
+	timeFormat output.TimeFormat
+	rules      policy.Policy
+	allowBatch bool
+	skipRules  []SkipRule
+	skipped    int
+	notes      []models.SetNote
+	habits     habits
+	types      map[string]string // Content type of each file, probed once
+}
+
+// newScreen returns the interface for sets, with the decisions of an
+// interrupted session restored and the sets matching skipRules skipped
+func newScreen(sets []models.DuplicateSet, opts models.ScanOptions, skipRules []SkipRule) *screen {
+	s := &screen{
+		opts:       opts,
+		labels:     output.Labels(opts.Labels),
+		timeFormat: output.TimeFormat(opts.TimeFormat),
+		rules:      policy.Policy{Priorities: opts.Priorities, Protected: opts.Protected, MinAge: opts.MinAge, RequireHash: opts.RequireHash},
+		allowBatch: len(opts.Directories) == 2,
+		skipRules:  skipRules,
+		types:      make(map[string]string),
+		rows:       1,
+	}
+	for i, set := range sets {
+		set.ID = i + 1
+		s.sets = append(s.sets, screenSet{set: set, marked: make([]bool, len(set.Files)), reasons: make([]string, len(set.Files))})
+	}
+
+	if resume := opts.Resume; resume != nil {
+		s.skipped = resume.Skipped
+		s.notes = append(s.notes, resume.Notes...)
+		reasons := make(map[string]string)
+		for _, action := range resume.Actions {
+			reasons[action.DeleteFile] = action.Reason
+		}
+		for i := 0; i < resume.Decided && i < len(s.sets); i++ {
+			ss := &s.sets[i]
+			ss.state = setSkipped
+			for j, file := range ss.set.Files {
+				if reason, ok := reasons[file.Path]; ok {
+					ss.marked[j], ss.reasons[j] = true, reason
+					ss.state = setMarked
+				}
+			}
+		}
+		s.cursor = min(resume.Decided, len(s.sets)-1)
+		if resume.BatchKeepDir != "" {
+			s.batch(resume.BatchKeepDir, s.undecided())
+		}
+	}
+
+	now := time.Now()
+	for i := range s.sets {
+		ss := &s.sets[i]
+		if ss.state != setUndecided {
+			continue
+		}
+		if rule, ok := skippedBy(skipRules, ss.set, now); ok {
+			ss.state = setSkipped
+			ss.detail = "skipped by rule: " + rule.String()
+			s.skipped++
+		}
+	}
+	return s
+}
+
+// current returns the set under the cursor
+func (s *screen) current() *screenSet {
+	return &s.sets[s.cursor]
+}
+
+// undecided returns the indexes of the sets not decided on yet, from the
+// cursor on
+func (s *screen) undecided() []int {
+	var indexes []int
+	for i := s.cursor; i < len(s.sets); i++ {
+		if s.sets[i].state == setUndecided {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// targets returns the sets a choice applies to: the selected ones, or the
+// one under the cursor
+func (s *screen) targets() []int {
+	var indexes []int
+	for i := range s.sets {
+		if s.sets[i].selected {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		indexes = []int{s.cursor}
+	}
+	return indexes
+}
+
+// handle applies a key, as named by keyNames
+func (s *screen) handle(key string) {
+	s.status = ""
+	if s.input != nil {
+		s.edit(key)
+		return
+	}
+	if s.help {
+		s.help = false
+		return
+	}
+
+	ss := s.current()
+	switch key {
+	case "up", "k":
+		if s.focus == paneFiles {
+			s.file = max(s.file-1, 0)
+		} else {
+			s.move(s.cursor - 1)
+		}
+	case "down", "j":
+		if s.focus == paneFiles {
+			s.file = min(s.file+1, len(ss.set.Files)-1)
+		} else {
+			s.move(s.cursor + 1)
+		}
+	case "pgup":
+		s.move(s.cursor - s.rows)
+	case "pgdn":
+		s.move(s.cursor + s.rows)
+	case "home", "g":
+		s.move(0)
+	case "end", "G":
+		s.move(len(s.sets) - 1)
+	case "tab", "left", "right", "enter":
+		if key == "left" {
+			s.focus = paneSets
+		} else if key == "right" || key == "enter" || s.focus == paneSets {
+			s.focus = paneFiles
+		} else {
+			s.focus = paneSets
+		}
+	case "space":
+		if s.focus == paneFiles {
+			s.toggle(s.file)
+		} else {
+			ss.selected = !ss.selected
+			s.move(s.cursor + 1)
+		}
+	case "*":
+		all := true
+		for i := range s.sets {
+			all = all && s.sets[i].selected
+		}
+		for i := range s.sets {
+			s.sets[i].selected = !all
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		n, _ := strconv.Atoi(key)
+		if n > len(ss.set.Files) {
+			s.status = fmt.Sprintf("Set #%d has %d files", ss.set.ID, len(ss.set.Files))
+			return
+		}
+		s.keepOnly(n - 1)
+	case "a", "b":
+		if !s.allowBatch {
+			s.status = "Keeping a whole directory needs exactly two directories"
+			return
+		}
+		keep := ss.set.Files[0].Directory
+		if key == "b" {
+			keep = ss.set.Files[1].Directory
+		}
+		targets := s.targets()
+		if !ss.selected {
+			// Like [a] and [b] at the prompt: this set and every later undecided one
+			targets = s.undecided()
+			if ss.state != setUndecided {
+				targets = append([]int{s.cursor}, targets...)
+			}
+		}
+		s.batch(keep, targets)
+		s.habits.keep(keep)
+		s.status = fmt.Sprintf("Keeping the copies under %s in %d set(s)", s.labels.Dir(keep), len(targets))
+	case "s":
+		for _, i := range s.targets() {
+			s.clear(i)
+			s.sets[i].state = setSkipped
+		}
+		s.move(s.cursor + 1)
+	case "u":
+		for _, i := range s.targets() {
+			s.clear(i)
+		}
+	case "h":
+		s.habits.hashRequests++
+		s.hashCurrent()
+	case "n":
+		s.input = &lineInput{label: "Note (empty to remove): ", text: []rune(ss.set.Note), commit: s.annotate}
+	case "x":
+		s.input = &lineInput{label: "Skip rule (" + SkipRuleHelp + "): ", commit: s.addSkipRule}
+	case "f":
+		s.done = true
+	case "q", "ctrl-c":
+		s.quit = true
+	case "?":
+		s.help = true
+	}
+}
+
+// move puts the cursor on set i, within bounds
+func (s *screen) move(i int) {
+	i = max(0, min(i, len(s.sets)-1))
+	if i != s.cursor {
+		s.cursor = i
+		s.file = 0
+	}
+}
+
+// edit applies a key to the text being typed
+func (s *screen) edit(key string) {
+	in := s.input
+	switch key {
+	case "enter":
+		s.input = nil
+		in.commit(strings.TrimSpace(string(in.text)))
+	case "esc", "ctrl-c":
+		s.input = nil
+	case "backspace":
+		if len(in.text) > 0 {
+			in.text = in.text[:len(in.text)-1]
+		}
+	case "space":
+		in.text = append(in.text, ' ')
+	default:
+		if r := []rune(key); len(r) == 1 && unicode.IsPrint(r[0]) {
+			in.text = append(in.text, r[0])
+		}
+	}
+}
+
+// toggle marks file i of the current set for deletion, or unmarks it. One
+// file of each set is always kept, and files on offline volumes cannot be
+// deleted.
+func (s *screen) toggle(i int) {
+	ss := s.current()
+	if ss.marked[i] {
+		ss.marked[i], ss.reasons[i] = false, ""
+		if !anyMarked(ss.marked) {
+			ss.state = setUndecided
+		}
+		return
+	}
+	if ss.set.Files[i].Offline {
+		s.status = "The file is on an offline volume and cannot be deleted"
+		return
+	}
+	if ss.state == setDifferent {
+		s.status = "Hashing showed the files of this set differ"
+		return
+	}
+	left := 0
+	for j := range ss.marked {
+		if !ss.marked[j] && j != i {
+			left++
+		}
+	}
+	if left == 0 {
+		s.status = "Keep at least one file of each set"
+		return
+	}
+	ss.marked[i], ss.reasons[i] = true, ReasonUserChoice
+	ss.state = setMarked
+	ss.detail = ""
+}
+
+// keepOnly marks every file of the current set but file i
+func (s *screen) keepOnly(i int) {
+	ss := s.current()
+	if ss.state == setDifferent {
+		s.status = "Hashing showed the files of this set differ"
+		return
+	}
+	s.clear(s.cursor)
+	for j, file := range ss.set.Files {
+		if j != i && !file.Offline {
+			ss.marked[j], ss.reasons[j] = true, ReasonUserChoice
+		}
+	}
+	if anyMarked(ss.marked) {
+		ss.state = setMarked
+		s.habits.keep(keptRoot(ss.set, ss.set.Files[i].Path))
+	}
+	s.move(s.cursor + 1)
+}
+
+// batch marks, in each set of indexes, the files the policy removes when
+// the copies under keepDir are kept. Protected and recent files stay.
+func (s *screen) batch(keepDir string, indexes []int) {
+	rules := s.rules
+	rules.PreferDirs = []string{keepDir}
+	for _, i := range indexes {
+		ss := &s.sets[i]
+		if ss.state == setDifferent {
+			continue
+		}
+		s.clear(i)
+		d := rules.Apply(ss.set)
+		for j, file := range ss.set.Files {
+			for _, removed := range d.Remove {
+				if removed.Path == file.Path && !file.Offline {
+					reason, _ := d.Reason(file.Path)
+					ss.marked[j], ss.reasons[j] = true, "batch "+reason.String()
+				}
+			}
+		}
+		var retained []string
+		for _, file := range d.Retained {
+			reason, _ := d.Reason(file.Path)
+			retained = append(retained, fmt.Sprintf("%s (%s)", s.labels.Path(file.Path), reason))
+		}
+		if len(retained) > 0 {
+			ss.detail = "Retained " + strings.Join(retained, ", ")
+		}
+		ss.state = setSkipped
+		if anyMarked(ss.marked) {
+			ss.state = setMarked
+		}
+	}
+}
+
+// clear undoes the decision on set i
+func (s *screen) clear(i int) {
+	ss := &s.sets[i]
+	for j := range ss.marked {
+		ss.marked[j], ss.reasons[j] = false, ""
+	}
+	if ss.state != setDifferent {
+		ss.state = setUndecided
+	}
+	ss.detail = ""
+}
+
+// hashCurrent verifies the set under the cursor by hash, once the status
+// line says so
+func (s *screen) hashCurrent() {
+	i, ss := s.cursor, s.current()
+	if ss.set.HashComputed {
+		s.status = "Already verified by hash"
+		return
+	}
+	if hasOfflineFile(ss.set) {
+		s.status = "A file is on an offline volume and cannot be hashed"
+		return
+	}
+	s.status = "Computing hashes..."
+	s.pending = func() {
+		err := computeHashForSet(&ss.set, s.opts)
+		switch {
+		case err != nil && err.Error() == "hash mismatch":
+			s.clear(i)
+			ss.state = setDifferent
+			s.status = "✗ Files are different (hash mismatch)"
+		case err != nil:
+			s.status = "✗ " + err.Error()
+		default:
+			s.status = "✓ Files are identical (hash verified)"
+		}
+	}
+}
+
+// annotate sets the note of the current set
+func (s *screen) annotate(text string) {
+	ss := s.current()
+	ss.set.Note = text
+	s.notes = append(s.notes, models.SetNote{Paths: [2]string{ss.set.Files[0].Path, ss.set.Files[1].Path}, Text: text})
+	if text == "" {
+		s.status = "Note removed"
+	} else {
+		s.status = "Note saved"
+	}
+}
+
+// addSkipRule skips the undecided sets matching a new rule
+func (s *screen) addSkipRule(text string) {
+	rule, err := ParseSkipRule(text)
+	if err != nil {
+		s.status = err.Error()
+		return
+	}
+	s.skipRules = append(s.skipRules, rule)
+	now := time.Now()
+	n := 0
+	for i := range s.sets {
+		ss := &s.sets[i]
+		if ss.state == setUndecided && rule.Matches(ss.set, now) {
+			ss.state = setSkipped
+			ss.detail = "skipped by rule: " + rule.String()
+			n++
+		}
+	}
+	s.skipped += n
+	s.status = fmt.Sprintf("Skip rule added: %s (%d set(s) skipped)", rule, n)
+}
+
+// actions returns the deletions marked in the sets before end, each
+// keeping the first unmarked file of its set
+func (s *screen) actions(end int) []models.UserAction {
+	var actions []models.UserAction
+	for _, ss := range s.sets[:end] {
+		if ss.state != setMarked {
+			continue
+		}
+		actions = append(actions, markedActions(ss)...)
+	}
+	return actions
+}
+
+func markedActions(ss screenSet) []models.UserAction {
+	keep := ""
+	for j, file := range ss.set.Files {
+		if !ss.marked[j] {
+			keep = file.Path
+			break
+		}
+	}
+	var actions []models.UserAction
+	for j, file := range ss.set.Files {
+		if ss.marked[j] {
+			actions = append(actions, models.UserAction{Action: "delete", KeepFile: keep, DeleteFile: file.Path, Reason: ss.reasons[j]})
+		}
+	}
+	return actions
+}
+
+// decided returns how many sets from the first are decided on, which is
+// what an interrupted session resumes after
+func (s *screen) decided() int {
+	for i, ss := range s.sets {
+		if ss.state == setUndecided {
+			return i
+		}
+	}
+	return len(s.sets)
+}
+
+// progress returns the decisions to save for resume
+func (s *screen) progress() models.SessionProgress {
+	decided := s.decided()
+	return models.SessionProgress{
+		Decided:   decided,
+		Skipped:   s.skipped,
+		Actions:   s.actions(decided),
+		SkipRules: ruleTexts(s.skipRules),
+		Notes:     s.notes,
+	}
+}
+
+// tally returns the files marked for deletion and the bytes removing them
+// frees
+func (s *screen) tally() (files int, freed int64) {
+	var kept, removed []models.FileInfo
+	for _, ss := range s.sets {
+		if ss.state != setMarked {
+			continue
+		}
+		for j, file := range ss.set.Files {
+			if ss.marked[j] {
+				removed = append(removed, file)
+			} else {
+				kept = append(kept, file)
+			}
+		}
+	}
+	return len(removed), finder.RemovalSavings(kept, removed)
+}
+
+// fileType returns the content type of the file at path, probed once
+func (s *screen) fileType(path string) string {
+	t, ok := s.types[path]
+	if !ok {
+		if info, err := media.Probe(path); err == nil {
+			t = info.String()
+		}
+		s.types[path] = t
+	}
+	return t
+}
+
+// screenKeys is the key help on the last line
+const screenKeys = "↑↓ move  tab files  space mark  1-9 keep only  a/b keep dir  s skip  u undo  h hash  n note  x rule  f finish  q quit  ? help"
+
+// screenHelp explains every key
+var screenHelp = []string{
+	"Keys",
+	"",
+	"  ↑ ↓ j k         Move between sets, or between files in the files pane",
+	"  PgUp PgDn g G   Move a page, or to the first or last set",
+	"  tab ← → enter   Switch between the sets and the files of the set",
+	"  space           Files pane: mark the file for deletion, or unmark it",
+	"                  Sets pane: select the set for a, b, s or u",
+	"  *               Select every set, or none",
+	"  1-9             Keep only that file of the set, marking the others",
+	"  a b             Keep the copies in the first or second directory, in the",
+	"                  selected sets, or in this and every later undecided set",
+	"  s               Skip the set (or the selected sets), leaving it as it is",
+	"  u               Undo the decision on the set (or the selected sets)",
+	"  h               Verify the set by hash",
+	"  n               Write a note on the set",
+	"  x               Skip every undecided set matching a rule",
+	"  f               Finish: confirm the marked deletions",
+	"  q               Quit, leaving the session to resume",
+	"",
+	"Press any key to go back",
+}
+
+// draw renders the interface in width columns and height rows
+func (s *screen) draw(width, height int) []string {
+	lines := make([]string, 0, height)
+	if s.help {
+		for _, line := range screenHelp {
+			lines = append(lines, output.Fit(line, width))
+		}
+		for len(lines) < height {
+			lines = append(lines, strings.Repeat(" ", width))
+		}
+		return lines[:height]
+	}
+
+	files, freed := s.tally()
+	title := fmt.Sprintf(" dup-finder: %d duplicate set(s), %d decided", len(s.sets), s.countDecided())
+	tally := fmt.Sprintf("%d file(s) marked, %s to free ", files, output.FormatSize(freed))
+	if s.opts.Trash {
+		tally = fmt.Sprintf("%d file(s) marked, %s to move to the trash ", files, output.FormatSize(freed))
+	} else if s.opts.Quarantine != "" {
+		tally = fmt.Sprintf("%d file(s) marked, %s to quarantine ", files, output.FormatSize(freed))
+	}
+	// The tally is ASCII, so it takes a column per byte
+	tallyWidth := min(len(tally), width)
+	lines = append(lines, inverse(output.Fit(title, width-tallyWidth)+output.Fit(tally, tallyWidth)))
+
+	body := max(height-3, 1)
+	s.rows = body
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+body {
+		s.top = s.cursor - body + 1
+	}
+	left := min(max(width*2/5, 24), width/2)
+	right := max(width-left-1, 0)
+	details := s.details(right)
+	for row := 0; row < body; row++ {
+		line := strings.Repeat(" ", left)
+		if i := s.top + row; i < len(s.sets) {
+			line = output.Fit(s.listEntry(i), left)
+			if i == s.cursor {
+				line = highlight(line, s.focus == paneSets)
+			}
+		}
+		detail := strings.Repeat(" ", right)
+		if row < len(details) {
+			detail = details[row]
+		}
+		lines = append(lines, line+"│"+detail)
+	}
+
+	status := s.status
+	if s.input != nil {
+		status = s.input.label + string(s.input.text) + "█"
+	}
+	lines = append(lines, output.Fit(status, width))
+	lines = append(lines, output.Fit(screenKeys, width))
+	return lines
+}
+
+// countDecided returns how many sets are decided on
+func (s *screen) countDecided() int {
+	n := 0
+	for _, ss := range s.sets {
+		if ss.state != setUndecided {
+			n++
+		}
+	}
+	return n
+}
+
+// listEntry returns the line of set i in the sets pane
+func (s *screen) listEntry(i int) string {
+	ss := s.sets[i]
+	state := "  "
+	switch ss.state {
+	case setMarked:
+		state = "✗ "
+	case setSkipped:
+		state = "– "
+	case setDifferent:
+		state = "≠ "
+	}
+	selected := " "
+	if ss.selected {
+		selected = "*"
+	}
+	size := int64(0)
+	if len(ss.set.Files) > 0 {
+		size = ss.set.Files[0].Size
+	}
+	name := ""
+	if len(ss.set.Files) > 0 {
+		name = baseName(ss.set.Files[0].Path)
+	}
+	return fmt.Sprintf("%s%s#%-4d %9s  %s", selected, state, ss.set.ID, output.FormatSize(size), name)
+}
+
+// details returns the lines of the files pane for the set under the cursor
+func (s *screen) details(width int) []string {
+	ss := s.current()
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, output.Fit(fmt.Sprintf(format, args...), width))
+	}
+
+	add(" Set #%d: %d files of %s", ss.set.ID, len(ss.set.Files), output.FormatSize(ss.set.Files[0].Size))
+	switch {
+	case ss.state == setDifferent:
+		add(" ✗ Files are different (hash mismatch)")
+	case ss.set.HashComputed && len(ss.set.Hash) >= 16:
+		add(" Hash: %s... (verified)", ss.set.Hash[:16])
+	case ss.set.HashComputed:
+		add(" Hash: verified by checksum")
+	default:
+		add(" Hash: not verified (h to verify)")
+	}
+	if ss.set.Note != "" {
+		add(" Note: %s", ss.set.Note)
+	}
+	if ss.detail != "" {
+		add(" %s", ss.detail)
+	}
+	add("")
+
+	suggest := suggestedDeletion(ss.set, s.rules)
+	for j, file := range ss.set.Files {
+		mark := "[ ]"
+		if ss.marked[j] {
+			mark = "[✗]"
+		}
+		line := output.Fit(fmt.Sprintf(" %s %d %s", mark, j+1, s.labels.Path(file.Path)), width)
+		if s.focus == paneFiles && j == s.file {
+			line = highlight(line, true)
+		}
+		lines = append(lines, line)
+		extra := ""
+		switch {
+		case file.Offline:
+			extra = "  offline"
+		case ss.marked[j]:
+			extra = "  delete: " + ss.reasons[j]
+		case file.Path == suggest:
+			extra = "  suggested by directory priority"
+		}
+		add("       %s%s", s.timeFormat.Format(file.ModTime), extra)
+		if t := s.fileType(file.Path); t != "" {
+			add("       %s", t)
+		}
+	}
+	return lines
+}
+
+// baseName returns the last element of a path with either separator
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+func anyMarked(marked []bool) bool {
+	for _, m := range marked {
+		if m {
+			return true
+		}
+	}
+	return false
+}
+
+// inverse shows line in reverse video
+func inverse(line string) string {
+	return "\x1b[7m" + line + "\x1b[0m"
+}
+
+// highlight marks the line under the cursor: in reverse video in the pane
+// with the focus, underlined in the other
+func highlight(line string, focused bool) string {
+	if focused {
+		return inverse(line)
+	}
+	return "\x1b[4m" + line + "\x1b[0m"
+}
@@ -0,0 +1,218 @@
+package interactive
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// screenSets returns n duplicate sets of a file in /a and its copy in /b
+func screenSets(n int) []models.DuplicateSet {
+	var sets []models.DuplicateSet
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		sets = append(sets, models.DuplicateSet{
+			Files: []models.FileInfo{
+				{Path: "/a/" + name, Directory: "/a", Size: int64(i * 100), ModTime: time.Now().Add(-48 * time.Hour)},
+				{Path: "/b/" + name, Directory: "/b", Size: int64(i * 100), ModTime: time.Now().Add(-48 * time.Hour)},
+			},
+			HashComputed: true,
+		})
+	}
+	return sets
+}
+
+func press(s *screen, keys ...string) {
+	for _, key := range keys {
+		s.handle(key)
+	}
+}
+
+func TestScreen_MarkFiles(t *testing.T) {
+	s := newScreen(screenSets(2), models.ScanOptions{Directories: []string{"/a", "/b"}}, nil)
+
+	press(s, "right", "space")
+	if !s.sets[0].marked[0] || s.sets[0].state != setMarked {
+		t.Fatalf("Expected /a/file1.txt to be marked, got %+v", s.sets[0])
+	}
+	// The last file of a set is always kept
+	press(s, "down", "space")
+	if s.sets[0].marked[1] {
+		t.Errorf("Expected the last file of the set to stay kept")
+	}
+
+	files, freed := s.tally()
+	if files != 1 || freed != 100 {
+		t.Errorf("Expected 1 file and 100 bytes marked, got %d and %d", files, freed)
+	}
+
+	// Keeping only the second file of the next set
+	press(s, "left", "down", "2")
+	actions := s.actions(len(s.sets))
+	if len(actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(actions))
+	}
+	if actions[1].DeleteFile != "/a/file2.txt" || actions[1].KeepFile != "/b/file2.txt" || actions[1].Reason != ReasonUserChoice {
+		t.Errorf("Unexpected action %+v", actions[1])
+	}
+
+	// Undo clears the decision
+	press(s, "up", "u")
+	if s.sets[0].state != setUndecided || s.sets[0].marked[0] {
+		t.Errorf("Expected set #1 to be undecided after undo, got %+v", s.sets[0])
+	}
+	if s.decided() != 0 {
+		t.Errorf("Expected no decided prefix, got %d", s.decided())
+	}
+}
+
+func TestScreen_SelectAndBatch(t *testing.T) {
+	s := newScreen(screenSets(4), models.ScanOptions{Directories: []string{"/a", "/b"}}, nil)
+
+	// Selecting sets #1 and #3 and keeping the copies under /a in them
+	press(s, "space", "down", "space", "home", "a")
+	for i, ss := range s.sets {
+		wantMarked := i == 0 || i == 2
+		if got := ss.state == setMarked; got != wantMarked {
+			t.Errorf("Set #%d: expected marked %v, got state %d", i+1, wantMarked, ss.state)
+		}
+	}
+
+	// With no set selected, [b] applies to this set and every later undecided one
+	press(s, "*", "*", "b")
+	if !s.sets[0].marked[0] || s.sets[0].marked[1] {
+		t.Errorf("Expected the copy under /a to be marked in set #1, got %v", s.sets[0].marked)
+	}
+	if !strings.HasPrefix(s.sets[0].reasons[0], "batch ") {
+		t.Errorf("Expected a batch reason, got %q", s.sets[0].reasons[0])
+	}
+	if s.decided() != len(s.sets) {
+		t.Errorf("Expected every set decided, got %d", s.decided())
+	}
+}
+
+func TestScreen_SkipRuleAndNote(t *testing.T) {
+	s := newScreen(screenSets(3), models.ScanOptions{}, nil)
+
+	press(s, "x")
+	for _, key := range "name file3*" {
+		press(s, string(key))
+	}
+	press(s, "enter")
+	if s.sets[2].state != setSkipped || s.skipped != 1 {
+		t.Fatalf("Expected set #3 skipped by the rule, got state %d and %d skipped", s.sets[2].state, s.skipped)
+	}
+
+	press(s, "n", "o", "k", "enter")
+	if s.sets[0].set.Note != "ok" || len(s.notes) != 1 {
+		t.Errorf("Expected the note on set #1, got %q and %d note(s)", s.sets[0].set.Note, len(s.notes))
+	}
+
+	// [a] needs exactly two directories
+	press(s, "a")
+	if s.sets[0].state != setUndecided || s.status == "" {
+		t.Errorf("Expected [a] to be refused, got state %d and status %q", s.sets[0].state, s.status)
+	}
+
+	press(s, "s")
+	progress := s.progress()
+	if progress.Decided != 1 || len(progress.SkipRules) != 1 {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+}
+
+func TestScreen_Resume(t *testing.T) {
+	sets := screenSets(3)
+	opts := models.ScanOptions{
+		Directories: []string{"/a", "/b"},
+		Resume: &models.SessionProgress{
+			Decided: 1,
+			Actions: []models.UserAction{{Action: "delete", KeepFile: "/a/file1.txt", DeleteFile: "/b/file1.txt", Reason: ReasonUserChoice}},
+		},
+	}
+	s := newScreen(sets, opts, nil)
+	if s.cursor != 1 || !s.sets[0].marked[1] {
+		t.Fatalf("Expected the decisions restored and the cursor on set #2, got cursor %d and %v", s.cursor, s.sets[0].marked)
+	}
+	if actions := s.actions(len(s.sets)); len(actions) != 1 || actions[0] != opts.Resume.Actions[0] {
+		t.Errorf("Expected the resumed action, got %+v", actions)
+	}
+}
+
+func TestScreen_Draw(t *testing.T) {
+	s := newScreen(screenSets(30), models.ScanOptions{Directories: []string{"/a", "/b"}}, nil)
+	press(s, "end", "right", "space")
+
+	lines := s.draw(100, 20)
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 lines, got %d", len(lines))
+	}
+	all := strings.Join(lines, "\n")
+	for _, want := range []string{"30 duplicate set(s)", "1 file(s) marked", "#30", "[✗] 1 /a/file30.txt", "user choice"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected the screen to show %q:\n%s", want, all)
+		}
+	}
+	// The list scrolls to keep the cursor in view
+	if strings.Contains(all, "#1 ") {
+		t.Errorf("Expected set #1 scrolled out of view:\n%s", all)
+	}
+
+	press(s, "?")
+	if lines := s.draw(100, 20); !strings.HasPrefix(lines[0], "Keys") {
+		t.Errorf("Expected the help, got %q", lines[0])
+	}
+}
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		msg  tea.KeyMsg
+		want []string
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("jk")}, []string{"j", "k"}},
+		{tea.KeyMsg{Type: tea.KeyUp}, []string{"up"}},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, []string{"pgdn"}},
+		{tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, []string{"space"}},
+		{tea.KeyMsg{Type: tea.KeyCtrlC}, []string{"ctrl-c"}},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a é"), Paste: true}, []string{"a", "space", "é"}},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q"), Alt: true}, nil},
+		{tea.KeyMsg{Type: tea.KeyF1}, nil},
+	}
+	for _, tt := range tests {
+		if got := keyNames(tt.msg); !slices.Equal(got, tt.want) {
+			t.Errorf("keyNames(%v) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestScreenModel(t *testing.T) {
+	var saved []models.SessionProgress
+	opts := models.ScanOptions{Directories: []string{"/a", "/b"}, Checkpoint: func(p models.SessionProgress) { saved = append(saved, p) }}
+	m := &screenModel{s: newScreen(screenSets(3), opts, nil), start: time.Now()}
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	if view := m.View(); !strings.Contains(view, "3 duplicate set(s)") {
+		t.Fatalf("Expected the screen to be drawn, got:\n%s", view)
+	}
+
+	// Progress is saved once the keys pause
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m.Update(tickMsg{})
+	m.Update(tickMsg{})
+	if len(saved) != 1 || saved[0].Decided != 1 {
+		t.Errorf("Expected one checkpoint with 1 set decided, got %+v", saved)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Errorf("Expected q to end the program")
+	}
+	if !m.s.quit {
+		t.Errorf("Expected q to quit the session")
+	}
+}
@@ -1,62 +1,191 @@
 package interactive
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/Sho2010/dup-finder/internal/finder"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/quarantine"
+	"github.com/Sho2010/dup-finder/internal/trash"
 )
 
 // RunInteractiveSession manages the entire interactive workflow
 func RunInteractiveSession(comparisons []models.PairComparison, opts models.ScanOptions) (*models.SessionSummary, error) {
-	// 1. Convert PairComparison to DuplicateSet (only for hash-matching pairs)
+	// 1. Convert PairComparison to DuplicateSet (only for hash-matching pairs).
+	// With three or more directories, matches are merged into transitive
+	// sets, so a file present in every directory is decided on once.
 	sets := convertToDuplicateSets(comparisons, opts.NumWorkers)
+	if len(opts.Directories) > 2 {
+		sets = finder.MergeMatches(comparisons)
+	}
 
 	if len(sets) == 0 {
 		fmt.Fprintln(os.Stderr, "No duplicate files found (based on size)")
 		return &models.SessionSummary{}, nil
 	}
 
+	labels := output.Labels(opts.Labels)
+	timeFormat := output.TimeFormat(opts.TimeFormat)
+	rules := policy.Policy{Priorities: opts.Priorities, Protected: opts.Protected, MinAge: opts.MinAge, RequireHash: opts.RequireHash}
+
 	// Check if batch-by-directory option should be available
 	// (only when comparing exactly 2 directories)
 	allowBatchByDir := len(opts.Directories) == 2
 
+	skipRules, err := ParseSkipRules(opts.SkipRules)
+	if err != nil {
+		return nil, err
+	}
+	skipped := 0
+
+	// The full-screen interface takes the session when the terminal can show it
+	if opts.FullScreen {
+		if summary, ok, err := runScreen(sets, opts, skipRules); ok {
+			return summary, err
+		}
+	}
+
 	// 2. Collect user decisions for all duplicate sets
 	var actions []models.UserAction
-	batchDirAction := "" // Track if user chose batch deletion by directory
+	var deferred []models.DuplicateSet
+	batchKeepDir := "" // Directory kept for all remaining sets once batch mode is chosen
+	start := time.Now()
+	var habits habits
+	var notes []models.SetNote
+
+	// An interrupted session continues from its first undecided set, with
+	// the deletions it had chosen still awaiting confirmation. Its skip
+	// rules come in opts.SkipRules.
+	resumeAt := 0
+	if opts.Resume != nil {
+		resumeAt = opts.Resume.Decided
+		skipped = opts.Resume.Skipped
+		actions = slices.Clone(opts.Resume.Actions)
+		batchKeepDir = opts.Resume.BatchKeepDir
+		notes = slices.Clone(opts.Resume.Notes)
+	}
+	checkpoint := func(decided int) {
+		if opts.Checkpoint == nil {
+			return
+		}
+		opts.Checkpoint(models.SessionProgress{
+			Decided:      decided,
+			Skipped:      skipped,
+			Actions:      actions,
+			BatchKeepDir: batchKeepDir,
+			SkipRules:    ruleTexts(skipRules),
+			Notes:        notes,
+		})
+	}
+	decided := len(sets) // Sets left undecided by the budget are not
 
 	for i, set := range sets {
+		if i < resumeAt {
+			continue
+		}
 		set.ID = i + 1
 
+		// Sets matching a skip rule are passed over, even in batch mode
+		if _, ok := skippedBy(skipRules, set, time.Now()); ok {
+			skipped++
+			continue
+		}
+
 		// If batch directory deletion was chosen, apply it automatically
-		if batchDirAction != "" {
-			var deleteDir string
-			if batchDirAction == "keep_dir1" {
-				deleteDir = set.Files[1].Directory
-			} else {
-				deleteDir = set.Files[0].Directory
+		if batchKeepDir != "" {
+			ok, err := verifyBeforeDeletion(&set, opts)
+			if err != nil {
+				return nil, err
 			}
+			if ok {
+				sets[i] = set
+				actions = append(actions, batchActions(set, rules, batchKeepDir)...)
+			}
+			continue
+		}
+
+		// Once the budget is used, confirm what was decided and keep the rest for later
+		if opts.SessionBudget > 0 && time.Since(start) > opts.SessionBudget {
+			deferred = sets[i:]
+			decided = i
+			fmt.Fprintf(os.Stderr, "\nSession budget of %s used: %d set(s) deferred to the next session\n", opts.SessionBudget, len(deferred))
+			break
+		}
 
-			// Find which file to delete based on directory
-			for _, file := range set.Files {
-				if file.Directory == deleteDir {
-					actions = append(actions, models.UserAction{
-						Action:     "delete",
-						DeleteFile: file.Path,
-					})
+		// Verify up front when the user habitually asks for hashes
+		if opts.AutoHash && !set.HashComputed {
+			if err := computeHashForSet(&set, opts); err != nil {
+				if err.Error() == "hash mismatch" {
+					fmt.Fprintln(os.Stderr, "✗ Files are different (hash mismatch). Skipping.")
+					fmt.Fprintln(os.Stderr)
+					continue
 				}
+				return nil, err
 			}
-			continue
+			sets[i] = set
 		}
 
 		// Display the duplicate set
-		if err := DisplayDuplicateSet(set); err != nil {
+		checkpoint(i)
+		if err := DisplayDuplicateSet(set, labels, timeFormat); err != nil {
 			return nil, err
 		}
-
-		// Get user choice
-		action, err := PromptUserAction(set, allowBatchByDir)
+		habits.shown++
+
+		// Get user choice. Notes are attached as they are written, and the
+		// same set is offered again. A skip rule applies to the remaining
+		// sets at once, and skips this one if it matches.
+		suggestDelete := suggestedDeletion(set, rules)
+		prompt := func() (models.UserAction, error) {
+			for {
+				action, err := PromptUserAction(set, allowBatchByDir, labels, suggestDelete)
+				if err == nil && action.Action == "skip_rule" {
+					rule, err := ParseSkipRule(action.Rule)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err)
+						fmt.Fprintln(os.Stderr)
+						continue
+					}
+					skipRules = append(skipRules, rule)
+					now := time.Now()
+					remaining := 0
+					for _, later := range sets[i+1:] {
+						if rule.Matches(later, now) {
+							remaining++
+						}
+					}
+					fmt.Fprintf(os.Stderr, "Skip rule added: %s (%d later set(s) will be skipped)\n", rule, remaining)
+					if rule.Matches(set, now) {
+						skipped++
+						return models.UserAction{Action: "skip"}, nil
+					}
+					fmt.Fprintln(os.Stderr, "This set does not match the rule; choose an action for it.")
+					fmt.Fprintln(os.Stderr)
+					continue
+				}
+				if err != nil || action.Action != "annotate" {
+					return action, err
+				}
+				set.Note = action.Note
+				sets[i] = set
+				notes = append(notes, models.SetNote{Paths: [2]string{set.Files[0].Path, set.Files[1].Path}, Text: set.Note})
+				if set.Note == "" {
+					fmt.Fprintln(os.Stderr, "Note removed")
+				} else {
+					fmt.Fprintln(os.Stderr, "Note saved")
+				}
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+		action, err := prompt()
 		if err != nil {
 			if err.Error() == "user finished" {
 				// User wants to proceed with selected files
@@ -67,8 +196,14 @@ func RunInteractiveSession(comparisons []models.PairComparison, opts models.Scan
 
 		// Handle hash computation request
 		if action.Action == "compute_hash" {
+			habits.hashRequests++
+			if hasOfflineFile(set) {
+				fmt.Fprintln(os.Stderr, "✗ A file is on an offline volume and cannot be hashed. Skipping.")
+				fmt.Fprintln(os.Stderr)
+				continue
+			}
 			fmt.Fprintln(os.Stderr, "Computing hashes...")
-			err := computeHashForSet(&set, opts.NumWorkers)
+			err := computeHashForSet(&set, opts)
 			if err != nil {
 				if err.Error() == "hash mismatch" {
 					fmt.Fprintln(os.Stderr, "✗ Files are different (hash mismatch). Skipping.")
@@ -84,11 +219,11 @@ func RunInteractiveSession(comparisons []models.PairComparison, opts models.Scan
 			sets[i] = set
 
 			// Re-display with hash info and prompt again
-			if err := DisplayDuplicateSet(set); err != nil {
+			if err := DisplayDuplicateSet(set, labels, timeFormat); err != nil {
 				return nil, err
 			}
 
-			action, err = PromptUserAction(set, allowBatchByDir)
+			action, err = prompt()
 			if err != nil {
 				if err.Error() == "user finished" {
 					// User wants to proceed with selected files
@@ -98,26 +233,33 @@ func RunInteractiveSession(comparisons []models.PairComparison, opts models.Scan
 			}
 		}
 
-		// Handle batch directory deletion
-		if action.Action == "batch_delete_by_dir" {
-			// Set batch mode for remaining sets
-			if action.KeepDirectory == set.Files[0].Directory {
-				batchDirAction = "keep_dir1"
-			} else {
-				batchDirAction = "keep_dir2"
-			}
+		// Files on offline volumes are only listed in a catalog
+		if action.Action == "delete" && offlineFile(set, action.DeleteFile) {
+			fmt.Fprintf(os.Stderr, "✗ %s is on an offline volume and cannot be deleted. Skipping.\n", labels.Path(action.DeleteFile))
+			fmt.Fprintln(os.Stderr)
+			continue
+		}
 
-			// Apply to current set
-			for _, file := range set.Files {
-				if file.Directory == action.DeleteDirectory {
-					actions = append(actions, models.UserAction{
-						Action:     "delete",
-						DeleteFile: file.Path,
-					})
-				}
+		// Deletions need verified contents under --require-hash
+		if action.Action == "delete" || action.Action == "keep" || action.Action == "batch_delete_by_dir" {
+			ok, err := verifyBeforeDeletion(&set, opts)
+			if err != nil {
+				return nil, err
 			}
+			sets[i] = set
+			if !ok {
+				continue
+			}
+		}
+
+		// Handle batch directory deletion
+		if action.Action == "batch_delete_by_dir" {
+			// Set batch mode for remaining sets and apply it to this one
+			batchKeepDir = action.KeepDirectory
+			habits.keep(batchKeepDir)
+			actions = append(actions, batchActions(set, rules, batchKeepDir)...)
 
-			fmt.Fprintf(os.Stderr, "\nBatch mode enabled: All remaining duplicates from %s will be deleted.\n", action.DeleteDirectory)
+			fmt.Fprintf(os.Stderr, "\nBatch mode enabled: All remaining duplicates from %s will be deleted.\n", labels.Dir(action.DeleteDirectory))
 			fmt.Fprintln(os.Stderr)
 			continue
 		}
@@ -125,29 +267,61 @@ func RunInteractiveSession(comparisons []models.PairComparison, opts models.Scan
 		// Collect individual actions (don't delete yet)
 		if action.Action == "delete" {
 			actions = append(actions, action)
+			habits.keep(keptRoot(set, action.KeepFile))
+		}
+		if action.Action == "keep" {
+			actions = append(actions, keepActions(set, action.KeepFile, labels)...)
+			habits.keep(keptRoot(set, action.KeepFile))
 		}
 	}
+	learned := habits.preferences(opts.AutoHash)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "\nSkipped %d set(s) by skip rules\n", skipped)
+	}
+	session := models.SessionSummary{TotalSets: len(sets), Deferred: deferred, Learned: learned, Notes: notes, SkipRules: ruleTexts(skipRules)}
+	return concludeSession(session, actions, opts, func() { checkpoint(decided) })
+}
 
+// concludeSession confirms the deletions of actions and carries them out,
+// completing session with their results. checkpoint saves the decisions
+// before confirmation.
+func concludeSession(session models.SessionSummary, actions []models.UserAction, opts models.ScanOptions, checkpoint func()) (*models.SessionSummary, error) {
 	// 3. Show final confirmation with list of files to delete
+	if opts.Symlink != "" {
+		actions = SafeLinks(actions)
+	}
 	if len(actions) == 0 {
 		fmt.Fprintln(os.Stderr, "\nNo files selected for deletion.")
-		return &models.SessionSummary{TotalSets: len(sets)}, nil
+		return &session, nil
 	}
 
-	confirmed, err := ConfirmDeletion(actions)
+	checkpoint()
+	confirmed, err := ConfirmDeletion(actions, opts)
 	if err != nil || !confirmed {
 		fmt.Fprintln(os.Stderr, "\nDeletion cancelled.")
-		return &models.SessionSummary{TotalSets: len(sets)}, nil
+		return &session, nil
 	}
 
 	// 4. Execute deletions and collect results
-	summary := &models.SessionSummary{
-		TotalSets:     len(sets),
-		SetsProcessed: len(actions),
+	summary := &session
+	summary.SetsProcessed = len(actions)
+	summary.Quarantine = opts.Quarantine
+	summary.Trash = opts.Trash
+	summary.Symlink = opts.Symlink
+
+	// Record the hashes of a sample of files before they are deleted, so the
+	// copies kept in their place can be checked afterwards
+	var recorded map[int]string
+	if opts.VerifySample > 0 {
+		rng := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+		recorded = recordHashes(actions, sampleActions(actions, opts.VerifySample, rng))
 	}
 
-	for _, action := range actions {
-		result := SafeDelete(action.DeleteFile)
+	results, err := RemoveFiles(actions, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
 		summary.Results = append(summary.Results, result)
 
 		if result.Success {
@@ -158,21 +332,140 @@ func RunInteractiveSession(comparisons []models.PairComparison, opts models.Scan
 		}
 	}
 
+	if recorded != nil {
+		v := verifyKept(actions, summary.Results, recorded)
+		summary.Verification = &v
+	}
+
 	return summary, nil
 }
 
+// Destination returns where the files chosen for deletion under opts are
+// moved instead of being deleted, for messages: the quarantine directory
+// or the trash, or "" when they are deleted
+func Destination(opts models.ScanOptions) string {
+	if opts.Trash {
+		return trash.Name
+	}
+	return opts.Quarantine
+}
+
+// ruleTexts returns the skip rules as they are written
+func ruleTexts(skipRules []SkipRule) []string {
+	var texts []string
+	for _, rule := range skipRules {
+		texts = append(texts, rule.String())
+	}
+	return texts
+}
+
+// offlineFile reports whether path is a file of set on an offline volume
+func offlineFile(set models.DuplicateSet, path string) bool {
+	for _, file := range set.Files {
+		if file.Path == path && file.Offline {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOfflineFile reports whether any file of set is on an offline volume
+func hasOfflineFile(set models.DuplicateSet) bool {
+	for _, file := range set.Files {
+		if file.Offline {
+			return true
+		}
+	}
+	return false
+}
+
+// keepActions returns the deletions of every file of set but keepFile.
+// Files on offline volumes are only listed in a catalog, so they are
+// passed over.
+func keepActions(set models.DuplicateSet, keepFile string, labels output.Labels) []models.UserAction {
+	var actions []models.UserAction
+	for _, file := range set.Files {
+		if file.Path == keepFile {
+			continue
+		}
+		if file.Offline {
+			fmt.Fprintf(os.Stderr, "✗ %s is on an offline volume and cannot be deleted. Skipping it.\n", labels.Path(file.Path))
+			continue
+		}
+		actions = append(actions, models.UserAction{
+			Action:     "delete",
+			KeepFile:   keepFile,
+			DeleteFile: file.Path,
+			Reason:     ReasonUserChoice,
+		})
+	}
+	return actions
+}
+
+// RemoveFiles deletes the files of actions, or moves them to the quarantine
+// directory when one is set or to the trash, or replaces them with symlinks
+// to the kept files, returning one result per action. Removing a file
+// that is a hardlink of its kept file frees no space.
+func RemoveFiles(actions []models.UserAction, opts models.ScanOptions) ([]models.DeletionResult, error) {
+	if opts.Quarantine != "" {
+		paths := make([]string, len(actions))
+		for i, action := range actions {
+			paths[i] = action.DeleteFile
+		}
+		return quarantine.Move(opts.Quarantine, paths, opts.NumWorkers)
+	}
+
+	results := make([]models.DeletionResult, len(actions))
+	for i, action := range actions {
+		if opts.Trash {
+			results[i] = SafeTrash(action.DeleteFile)
+			continue
+		}
+		shared := sameStorage(action.DeleteFile, action.KeepFile)
+		if opts.Symlink != "" {
+			results[i] = SafeLink(action.DeleteFile, action.KeepFile, opts.Symlink)
+		} else {
+			results[i] = SafeDelete(action.DeleteFile)
+		}
+		if shared {
+			results[i].SizeFreed = 0
+		}
+	}
+	return results, nil
+}
+
+// sameStorage reports whether path1 and path2 are the same file, such as
+// hardlinks of each other
+func sameStorage(path1, path2 string) bool {
+	info1, err := os.Stat(path1)
+	if err != nil {
+		return false
+	}
+	info2, err := os.Stat(path2)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(info1, info2)
+}
+
 // convertToDuplicateSets converts PairComparison to DuplicateSet (keeps pairwise structure)
-// No hash calculation is performed - hashes are computed on-demand
+// No hash calculation is performed - hashes are computed on-demand. Matches
+// that are not duplicates, such as name matches of different sizes, are left out
 func convertToDuplicateSets(comparisons []models.PairComparison, numWorkers int) []models.DuplicateSet {
 	var sets []models.DuplicateSet
 
 	for _, comp := range comparisons {
 		// Create DuplicateSet for each match based on size (no hash required)
 		for _, match := range comp.Matches {
+			// Matches already known to differ are never offered for deletion
+			if !match.IsDuplicate() {
+				continue
+			}
 			sets = append(sets, models.DuplicateSet{
 				Hash:         "",    // Empty - not computed yet
 				HashComputed: false, // Hash will be computed on-demand
 				Files:        []models.FileInfo{match.File1, match.File2},
+				Note:         match.Note,
 			})
 		}
 	}
@@ -180,8 +473,74 @@ func convertToDuplicateSets(comparisons []models.PairComparison, numWorkers int)
 	return sets
 }
 
+// verifyBeforeDeletion hashes a set that was not hash-verified when
+// RequireHash is set, so no file is deleted on a name and size match alone,
+// and with Verify "bytes" compares its files byte by byte, so none is
+// deleted on a hash match alone. It reports whether files of the set may
+// be deleted.
+func verifyBeforeDeletion(set *models.DuplicateSet, opts models.ScanOptions) (bool, error) {
+	ok, err := hashBeforeDeletion(set, opts)
+	if !ok || err != nil || opts.Verify != finder.VerifyBytes {
+		return ok, err
+	}
+	fmt.Fprintln(os.Stderr, "Comparing files byte by byte before deleting (--verify bytes)...")
+	equal, err := bytesEqual(*set)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Files could not be compared: %v. Skipping.\n", err)
+		fmt.Fprintln(os.Stderr)
+		return false, nil
+	}
+	if !equal {
+		fmt.Fprintln(os.Stderr, "✗ Files are different (byte comparison). Skipping.")
+		fmt.Fprintln(os.Stderr)
+		return false, nil
+	}
+	return true, nil
+}
+
+// hashBeforeDeletion hashes a set that was not hash-verified when
+// RequireHash is set, and reports whether its files may be deleted
+func hashBeforeDeletion(set *models.DuplicateSet, opts models.ScanOptions) (bool, error) {
+	if !opts.RequireHash || set.HashComputed {
+		return true, nil
+	}
+	fmt.Fprintln(os.Stderr, "Computing hashes before deleting (--require-hash)...")
+	if err := computeHashForSet(set, opts); err != nil {
+		if err.Error() == "hash mismatch" {
+			fmt.Fprintln(os.Stderr, "✗ Files are different (hash mismatch). Skipping.")
+			fmt.Fprintln(os.Stderr)
+			return false, nil
+		}
+		return false, err
+	}
+	if set.Hash == "" {
+		fmt.Fprintln(os.Stderr, "✗ Files could not be hashed. Skipping.")
+		fmt.Fprintln(os.Stderr)
+		return false, nil
+	}
+	return true, nil
+}
+
+// bytesEqual compares every readable file of a set with the first one byte
+// by byte. Files on offline volumes cannot be read, and are never deleted.
+func bytesEqual(set models.DuplicateSet) (bool, error) {
+	var files []models.FileInfo
+	for _, file := range set.Files {
+		if !file.Offline {
+			files = append(files, file)
+		}
+	}
+	for i := 1; i < len(files); i++ {
+		equal, err := finder.FilesEqual(files[0].Path, files[i].Path)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // computeHashForSet calculates hashes for files in a specific duplicate set
-func computeHashForSet(set *models.DuplicateSet, numWorkers int) error {
+func computeHashForSet(set *models.DuplicateSet, opts models.ScanOptions) error {
 	// Collect files that need hashing
 	var filesToHash []*models.FileInfo
 	for i := range set.Files {
@@ -196,7 +555,7 @@ func computeHashForSet(set *models.DuplicateSet, numWorkers int) error {
 	}
 
 	// Compute hashes using existing parallel function
-	finder.ComputeHashesParallel(filesToHash, numWorkers)
+	finder.ComputeHashes(context.Background(), filesToHash, opts)
 
 	// Verify all hashes match
 	if len(set.Files) > 0 {
@@ -212,3 +571,73 @@ func computeHashForSet(set *models.DuplicateSet, numWorkers int) error {
 	set.HashComputed = true
 	return nil
 }
+
+// batchActions applies the batch-by-directory choice to a set through the
+// policy engine: the copy under keepDir is kept, and protection rules still
+// retain files that would otherwise be deleted
+func batchActions(set models.DuplicateSet, rules policy.Policy, keepDir string) []models.UserAction {
+	rules.PreferDirs = []string{keepDir}
+	d := rules.Apply(set)
+
+	for _, file := range d.Retained {
+		reason, _ := d.Reason(file.Path)
+		fmt.Fprintf(os.Stderr, "Retained %s (%s)\n", file.Path, reason)
+	}
+
+	var actions []models.UserAction
+	for _, file := range d.Remove {
+		reason, _ := d.Reason(file.Path)
+		actions = append(actions, models.UserAction{
+			Action:     "delete",
+			KeepFile:   d.Keep.Path,
+			DeleteFile: file.Path,
+			Reason:     "batch " + reason.String(),
+		})
+	}
+	return actions
+}
+
+// suggestedDeletion returns the path directory priorities would delete
+// from a pair, or "" when they do not single out a file to keep
+func suggestedDeletion(set models.DuplicateSet, rules policy.Policy) string {
+	keep, ok := rules.Suggest(set)
+	if !ok || len(set.Files) != 2 {
+		return ""
+	}
+	if set.Files[0].Path == keep.Path {
+		return set.Files[1].Path
+	}
+	return set.Files[0].Path
+}
+
+// QueueComparisons turns deferred sets back into pair comparisons, grouped
+// by directory pair in order of appearance, so they can be saved as results
+// and resumed later. A set of more than two files becomes a match of its
+// first file with each of the others, which resuming merges back.
+func QueueComparisons(sets []models.DuplicateSet) []models.PairComparison {
+	var comparisons []models.PairComparison
+	index := make(map[[2]string]int)
+	for _, set := range sets {
+		if len(set.Files) < 2 {
+			continue
+		}
+		for _, other := range set.Files[1:] {
+			key := [2]string{set.Files[0].Directory, other.Directory}
+			i, ok := index[key]
+			if !ok {
+				i = len(comparisons)
+				index[key] = i
+				comparisons = append(comparisons, models.PairComparison{Dir1: key[0], Dir2: key[1]})
+			}
+			comparisons[i].Matches = append(comparisons[i].Matches, models.FileMatch{
+				Filename:    filepath.Base(set.Files[0].Path),
+				File1:       set.Files[0],
+				File2:       other,
+				HashChecked: set.HashComputed,
+				HashMatch:   set.HashComputed,
+				Note:        set.Note,
+			})
+		}
+	}
+	return comparisons
+}
@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Sho2010/dup-finder/internal/finder"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/policy"
 )
 
 func TestConvertToDuplicateSets(t *testing.T) {
@@ -111,6 +113,55 @@ func TestConvertToDuplicateSets(t *testing.T) {
 			t.Errorf("Expected 2 duplicate sets, got %d", len(sets))
 		}
 	})
+
+	t.Run("skips matches that differ", func(t *testing.T) {
+		comparisons := []models.PairComparison{
+			{
+				Dir1: "/tmp/dir1",
+				Dir2: "/tmp/dir2",
+				Matches: []models.FileMatch{
+					{
+						Filename: "notes.txt",
+						File1:    models.FileInfo{Path: "/tmp/dir1/notes.txt", Directory: "/tmp/dir1", Size: 3},
+						File2:    models.FileInfo{Path: "/tmp/dir2/notes.txt", Directory: "/tmp/dir2", Size: 5000},
+						Mismatch: "size",
+					},
+					{
+						Filename:    "data.bin",
+						File1:       models.FileInfo{Path: "/tmp/dir1/data.bin", Directory: "/tmp/dir1", Size: 100},
+						File2:       models.FileInfo{Path: "/tmp/dir2/data.bin", Directory: "/tmp/dir2", Size: 100},
+						HashChecked: true,
+						HashMatch:   false,
+					},
+				},
+			},
+		}
+
+		if sets := convertToDuplicateSets(comparisons, 2); len(sets) != 0 {
+			t.Errorf("Expected no duplicate sets for differing files, got %d", len(sets))
+		}
+	})
+
+	t.Run("skips hardlinked matches", func(t *testing.T) {
+		comparisons := []models.PairComparison{
+			{
+				Dir1: "/tmp/dir1",
+				Dir2: "/tmp/dir2",
+				Matches: []models.FileMatch{
+					{
+						Filename:   "hl.txt",
+						File1:      models.FileInfo{Path: "/tmp/dir1/hl.txt", Directory: "/tmp/dir1", Size: 5},
+						File2:      models.FileInfo{Path: "/tmp/dir2/hl.txt", Directory: "/tmp/dir2", Size: 5},
+						Hardlinked: true,
+					},
+				},
+			},
+		}
+
+		if sets := convertToDuplicateSets(comparisons, 2); len(sets) != 0 {
+			t.Errorf("Expected no duplicate sets for hardlinked files, got %d", len(sets))
+		}
+	})
 }
 
 func TestComputeHashForSet(t *testing.T) {
@@ -138,7 +189,7 @@ func TestComputeHashForSet(t *testing.T) {
 			HashComputed: false,
 		}
 
-		err := computeHashForSet(&set, 2)
+		err := computeHashForSet(&set, models.ScanOptions{NumWorkers: 2})
 
 		// Should succeed
 		if err != nil {
@@ -192,7 +243,7 @@ func TestComputeHashForSet(t *testing.T) {
 			HashComputed: false,
 		}
 
-		err := computeHashForSet(&set, 2)
+		err := computeHashForSet(&set, models.ScanOptions{NumWorkers: 2})
 
 		// Should return hash mismatch error
 		if err == nil {
@@ -277,7 +328,7 @@ func TestConvertToDuplicateSetsWithRealFiles(t *testing.T) {
 	}
 
 	// Now test on-demand hash computation
-	err := computeHashForSet(&sets[0], 2)
+	err := computeHashForSet(&sets[0], models.ScanOptions{NumWorkers: 2})
 	if err != nil {
 		t.Fatalf("Failed to compute hash: %v", err)
 	}
@@ -297,3 +348,313 @@ func TestConvertToDuplicateSetsWithRealFiles(t *testing.T) {
 		t.Errorf("Files with identical content should have same hash")
 	}
 }
+
+func TestRunInteractiveSession_BudgetExceeded(t *testing.T) {
+	match := func(name string) models.FileMatch {
+		return models.FileMatch{
+			Filename: name,
+			File1:    models.FileInfo{Path: "/a/" + name, Directory: "/a", Size: 10},
+			File2:    models.FileInfo{Path: "/b/" + name, Directory: "/b", Size: 10},
+		}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{match("one.txt"), match("two.txt")}},
+	}
+
+	// A budget that is used up before the first set defers every set
+	// without prompting
+	summary, err := RunInteractiveSession(comparisons, models.ScanOptions{
+		Directories:   []string{"/a", "/b"},
+		NumWorkers:    1,
+		SessionBudget: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(summary.Deferred) != 2 {
+		t.Fatalf("Expected 2 deferred sets, got %d", len(summary.Deferred))
+	}
+	if summary.FilesDeleted != 0 {
+		t.Errorf("Expected no deletions, got %d", summary.FilesDeleted)
+	}
+
+	queue := QueueComparisons(summary.Deferred)
+	if len(queue) != 1 || len(queue[0].Matches) != 2 {
+		t.Fatalf("Expected one comparison with 2 matches, got %+v", queue)
+	}
+	if queue[0].Dir1 != "/a" || queue[0].Dir2 != "/b" || queue[0].Matches[1].Filename != "two.txt" {
+		t.Errorf("Unexpected queue contents: %+v", queue[0])
+	}
+}
+
+func TestRunInteractiveSession_Resume(t *testing.T) {
+	match := func(name string) models.FileMatch {
+		return models.FileMatch{
+			Filename: name,
+			File1:    models.FileInfo{Path: "/a/" + name, Directory: "/a", Size: 10},
+			File2:    models.FileInfo{Path: "/b/" + name, Directory: "/b", Size: 10},
+		}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{match("one.txt"), match("two.txt"), match("three.txt")}},
+	}
+
+	// Sets decided before the interruption are not offered again
+	summary, err := RunInteractiveSession(comparisons, models.ScanOptions{
+		Directories:   []string{"/a", "/b"},
+		NumWorkers:    1,
+		SessionBudget: time.Nanosecond,
+		Resume:        &models.SessionProgress{Decided: 1},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(summary.Deferred) != 2 || summary.Deferred[0].Files[0].Path != "/a/two.txt" {
+		t.Fatalf("Expected two.txt and three.txt deferred, got %+v", summary.Deferred)
+	}
+}
+
+func TestRunInteractiveSession_Checkpoint(t *testing.T) {
+	match := func(name string) models.FileMatch {
+		return models.FileMatch{
+			Filename: name,
+			File1:    models.FileInfo{Path: "/a/" + name, Directory: "/a", Size: 10},
+			File2:    models.FileInfo{Path: "/b/" + name, Directory: "/b", Size: 10},
+		}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{match("one.txt"), match("two.txt")}},
+	}
+
+	// The confirmation prompt reads no answer and cancels
+	stdin := os.Stdin
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdin = devNull
+	defer func() { os.Stdin = stdin }()
+
+	// A session resumed in batch mode decides the remaining sets, and saves
+	// its chosen deletions before asking for confirmation
+	var saved []models.SessionProgress
+	_, err = RunInteractiveSession(comparisons, models.ScanOptions{
+		Directories: []string{"/a", "/b"},
+		NumWorkers:  1,
+		Resume: &models.SessionProgress{
+			Decided:      1,
+			Actions:      []models.UserAction{{Action: "delete", KeepFile: "/a/one.txt", DeleteFile: "/b/one.txt"}},
+			BatchKeepDir: "/a",
+		},
+		Checkpoint: func(progress models.SessionProgress) { saved = append(saved, progress) },
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("Expected one checkpoint, got %d", len(saved))
+	}
+	progress := saved[0]
+	if progress.Decided != 2 || progress.BatchKeepDir != "/a" {
+		t.Errorf("Expected both sets decided in batch mode, got %+v", progress)
+	}
+	if len(progress.Actions) != 2 || progress.Actions[1].DeleteFile != "/b/two.txt" {
+		t.Errorf("Expected deletions of /b/one.txt and /b/two.txt, got %+v", progress.Actions)
+	}
+}
+
+func TestRunInteractiveSession_MergesThreeDirectories(t *testing.T) {
+	file := func(dir string) models.FileInfo {
+		return models.FileInfo{Path: dir + "/photo.jpg", Directory: dir, Size: 10}
+	}
+	a, b, c := file("/a"), file("/b"), file("/c")
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{{Filename: "photo.jpg", File1: a, File2: b}}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{{Filename: "photo.jpg", File1: a, File2: c}}},
+		{Dir1: "/b", Dir2: "/c", Matches: []models.FileMatch{{Filename: "photo.jpg", File1: b, File2: c}}},
+	}
+
+	// The three pairwise matches are one set of three files
+	summary, err := RunInteractiveSession(comparisons, models.ScanOptions{
+		Directories:   []string{"/a", "/b", "/c"},
+		NumWorkers:    1,
+		SessionBudget: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(summary.Deferred) != 1 || len(summary.Deferred[0].Files) != 3 {
+		t.Fatalf("Expected one deferred set of 3 files, got %+v", summary.Deferred)
+	}
+
+	// Queued, the set is a match of its first file with each of the others
+	queue := QueueComparisons(summary.Deferred)
+	if len(queue) != 2 || queue[0].Dir2 != "/b" || queue[1].Dir2 != "/c" {
+		t.Fatalf("Expected /a matched with /b and /c, got %+v", queue)
+	}
+}
+
+func TestKeepChoice(t *testing.T) {
+	set := models.DuplicateSet{
+		Files: []models.FileInfo{{Path: "/a/photo.jpg"}, {Path: "/b/photo.jpg"}, {Path: "/c/photo.jpg"}},
+	}
+	if keep, ok := keepChoice("k2", set); !ok || keep != "/b/photo.jpg" {
+		t.Errorf("Expected k2 to keep /b/photo.jpg, got %q, %v", keep, ok)
+	}
+	for _, input := range []string{"k0", "k4", "k", "kx", "2"} {
+		if _, ok := keepChoice(input, set); ok {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+
+	// Pairs are decided by deleting one file
+	pair := models.DuplicateSet{Files: set.Files[:2]}
+	if _, ok := keepChoice("k1", pair); ok {
+		t.Errorf("Expected keep choices to be rejected for a pair")
+	}
+}
+
+func TestKeepActions(t *testing.T) {
+	set := models.DuplicateSet{
+		Files: []models.FileInfo{
+			{Path: "/a/photo.jpg"},
+			{Path: "/b/photo.jpg"},
+			{Path: "/c/photo.jpg", Offline: true},
+			{Path: "/d/photo.jpg"},
+		},
+	}
+
+	// Every other file is deleted, except those on offline volumes
+	actions := keepActions(set, "/b/photo.jpg", nil)
+	if len(actions) != 2 || actions[0].DeleteFile != "/a/photo.jpg" || actions[1].DeleteFile != "/d/photo.jpg" {
+		t.Fatalf("Expected to delete /a/photo.jpg and /d/photo.jpg, got %+v", actions)
+	}
+	for _, action := range actions {
+		if action.Action != "delete" || action.KeepFile != "/b/photo.jpg" || action.Reason != ReasonUserChoice {
+			t.Errorf("Unexpected action %+v", action)
+		}
+	}
+}
+
+func TestBatchActions(t *testing.T) {
+	set := models.DuplicateSet{
+		Files: []models.FileInfo{
+			{Path: "/a/photo.jpg", Directory: "/a"},
+			{Path: "/b/photo.jpg", Directory: "/b"},
+		},
+	}
+
+	actions := batchActions(set, policy.Policy{}, "/a")
+	if len(actions) != 1 || actions[0].DeleteFile != "/b/photo.jpg" || actions[0].KeepFile != "/a/photo.jpg" {
+		t.Fatalf("Expected to delete /b/photo.jpg and keep /a/photo.jpg, got %+v", actions)
+	}
+	if actions[0].Reason != "batch prefer-dir: kept copy: under preferred /a" {
+		t.Errorf("Expected the batch rule as reason, got %q", actions[0].Reason)
+	}
+
+	// Protected directories are never deleted from, even in batch mode
+	actions = batchActions(set, policy.Policy{Protected: []string{"/b"}}, "/a")
+	if len(actions) != 0 {
+		t.Errorf("Expected no deletions from a protected directory, got %+v", actions)
+	}
+
+	// Recently modified files are not deleted by batch mode either
+	recent := set
+	recent.Files = []models.FileInfo{set.Files[0], {Path: "/b/photo.jpg", Directory: "/b", ModTime: time.Now()}}
+	actions = batchActions(recent, policy.Policy{MinAge: 24 * time.Hour}, "/a")
+	if len(actions) != 0 {
+		t.Errorf("Expected no deletion of a recently modified file, got %+v", actions)
+	}
+}
+
+func TestExplainMark(t *testing.T) {
+	action := models.UserAction{DeleteFile: "/b/photo.jpg", Reason: ReasonUserChoice}
+
+	if got := explainMark(action, false); got != "" {
+		t.Errorf("Expected no mark without explain, got %q", got)
+	}
+	if got := explainMark(action, true); got != "  [user choice]" {
+		t.Errorf("Expected user choice mark, got %q", got)
+	}
+	if got := explainMark(models.UserAction{}, true); got != "" {
+		t.Errorf("Expected no mark without a reason, got %q", got)
+	}
+}
+
+func TestVerifyBeforeDeletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) models.FileInfo {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return models.FileInfo{Path: path, Size: int64(len(content))}
+	}
+	same := models.DuplicateSet{Files: []models.FileInfo{write("a1", "same"), write("a2", "same")}}
+	different := models.DuplicateSet{Files: []models.FileInfo{write("b1", "one"), write("b2", "two")}}
+
+	// Without --require-hash, sets are acted on as matched
+	ok, err := verifyBeforeDeletion(&different, models.ScanOptions{NumWorkers: 1})
+	if err != nil || !ok || different.HashComputed {
+		t.Fatalf("Expected the set to pass unhashed, got ok=%v err=%v", ok, err)
+	}
+
+	opts := models.ScanOptions{NumWorkers: 1, RequireHash: true}
+	ok, err = verifyBeforeDeletion(&same, opts)
+	if err != nil || !ok || !same.HashComputed {
+		t.Errorf("Expected identical files to be verified, got ok=%v err=%v", ok, err)
+	}
+	ok, err = verifyBeforeDeletion(&different, opts)
+	if err != nil || ok {
+		t.Errorf("Expected different files to be refused, got ok=%v err=%v", ok, err)
+	}
+
+	// Files that cannot be read are not taken as verified
+	missing := models.DuplicateSet{Files: []models.FileInfo{
+		{Path: filepath.Join(tmpDir, "gone1")},
+		{Path: filepath.Join(tmpDir, "gone2")},
+	}}
+	ok, err = verifyBeforeDeletion(&missing, opts)
+	if err != nil || ok {
+		t.Errorf("Expected unreadable files to be refused, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestVerifyBeforeDeletion_Bytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) models.FileInfo {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		// A hash collision: equal recorded hashes over different contents
+		return models.FileInfo{Path: path, Size: int64(len(content)), Hash: "colliding"}
+	}
+	same := models.DuplicateSet{Files: []models.FileInfo{write("a1", "same"), write("a2", "same")}, HashComputed: true}
+	collision := models.DuplicateSet{Files: []models.FileInfo{write("b1", "one"), write("b2", "two")}, HashComputed: true}
+
+	opts := models.ScanOptions{NumWorkers: 1, RequireHash: true}
+	ok, err := verifyBeforeDeletion(&collision, opts)
+	if err != nil || !ok {
+		t.Fatalf("Expected the hash match to be enough without --verify bytes, got ok=%v err=%v", ok, err)
+	}
+
+	opts.Verify = finder.VerifyBytes
+	ok, err = verifyBeforeDeletion(&same, opts)
+	if err != nil || !ok {
+		t.Errorf("Expected identical files to be verified, got ok=%v err=%v", ok, err)
+	}
+	ok, err = verifyBeforeDeletion(&collision, opts)
+	if err != nil || ok {
+		t.Errorf("Expected a hash collision to be refused, got ok=%v err=%v", ok, err)
+	}
+
+	// Files that cannot be read are not taken as verified
+	gone := collision
+	gone.Files = []models.FileInfo{same.Files[0], {Path: filepath.Join(tmpDir, "gone")}}
+	ok, err = verifyBeforeDeletion(&gone, opts)
+	if err != nil || ok {
+		t.Errorf("Expected unreadable files to be refused, got ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,115 @@
+package interactive
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// Kinds of skip rule, written first in the rule text
+const (
+	SkipUnder = "under" // A file of the set is under a directory
+	SkipName  = "name"  // A file name matches a glob, case-insensitive
+	SkipNewer = "newer" // A file was modified within an age
+	SkipOlder = "older" // Every file was last modified longer ago than an age
+)
+
+// SkipRuleHelp describes the rule syntax at the prompt
+const SkipRuleHelp = "under DIR, name GLOB, newer AGE or older AGE (e.g. under /archive/2019, name *.psd, newer 30d)"
+
+// SkipRule skips the sets it matches for the rest of a session, and in
+// sessions resumed from it
+type SkipRule struct {
+	Kind  string
+	Value string
+	age   time.Duration
+}
+
+// ParseSkipRule parses a rule such as "under /archive/2019" or "newer 30d"
+func ParseSkipRule(text string) (SkipRule, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(text), " ")
+	rule := SkipRule{Kind: strings.ToLower(kind), Value: strings.TrimSpace(value)}
+	if rule.Value == "" {
+		return SkipRule{}, fmt.Errorf("invalid skip rule %q: expected %s", text, SkipRuleHelp)
+	}
+
+	switch rule.Kind {
+	case SkipUnder:
+		rule.Value = filepath.Clean(rule.Value)
+	case SkipName:
+		if _, err := filepath.Match(rule.Value, ""); err != nil {
+			return SkipRule{}, fmt.Errorf("invalid skip rule %q: %w", text, err)
+		}
+	case SkipNewer, SkipOlder:
+		age, err := policy.ParseAge(rule.Value)
+		if err != nil {
+			return SkipRule{}, fmt.Errorf("invalid skip rule %q: %w", text, err)
+		}
+		rule.age = age
+	default:
+		return SkipRule{}, fmt.Errorf("invalid skip rule %q: expected %s", text, SkipRuleHelp)
+	}
+	return rule, nil
+}
+
+// String renders the rule the way ParseSkipRule accepts it
+func (r SkipRule) String() string {
+	return r.Kind + " " + r.Value
+}
+
+// Matches reports whether the rule skips set at time now
+func (r SkipRule) Matches(set models.DuplicateSet, now time.Time) bool {
+	if r.Kind == SkipOlder {
+		for _, file := range set.Files {
+			if now.Sub(file.ModTime) <= r.age {
+				return false
+			}
+		}
+		return len(set.Files) > 0
+	}
+
+	for _, file := range set.Files {
+		switch r.Kind {
+		case SkipUnder:
+			if policy.IsUnder(file.Path, r.Value) {
+				return true
+			}
+		case SkipName:
+			if ok, _ := filepath.Match(strings.ToLower(r.Value), strings.ToLower(filepath.Base(file.Path))); ok {
+				return true
+			}
+		case SkipNewer:
+			if now.Sub(file.ModTime) < r.age {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseSkipRules parses rules saved with a session
+func ParseSkipRules(texts []string) ([]SkipRule, error) {
+	var rules []SkipRule
+	for _, text := range texts {
+		rule, err := ParseSkipRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// skippedBy returns the first rule that skips set, if any
+func skippedBy(rules []SkipRule, set models.DuplicateSet, now time.Time) (SkipRule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(set, now) {
+			return rule, true
+		}
+	}
+	return SkipRule{}, false
+}
@@ -0,0 +1,97 @@
+package interactive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestParseSkipRule(t *testing.T) {
+	valid := map[string]string{
+		"under /archive/2019/":  "under /archive/2019",
+		"  NAME *.psd ":         "name *.psd",
+		"newer 30d":             "newer 30d",
+		"older 36h":             "older 36h",
+		"under /My Photos/2019": "under /My Photos/2019",
+	}
+	for text, want := range valid {
+		rule, err := ParseSkipRule(text)
+		if err != nil {
+			t.Errorf("ParseSkipRule(%q): unexpected error: %v", text, err)
+			continue
+		}
+		if rule.String() != want {
+			t.Errorf("ParseSkipRule(%q) = %q, want %q", text, rule.String(), want)
+		}
+	}
+
+	for _, text := range []string{"", "under", "skip /a", "name [", "newer soon", "older -1d"} {
+		if _, err := ParseSkipRule(text); err == nil {
+			t.Errorf("ParseSkipRule(%q): expected an error", text)
+		}
+	}
+}
+
+func TestSkipRule_Matches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	set := models.DuplicateSet{Files: []models.FileInfo{
+		{Path: "/photos/2024/IMG_1.JPG", ModTime: now.Add(-10 * 24 * time.Hour)},
+		{Path: "/archive/2019/IMG_1.JPG", ModTime: now.Add(-400 * 24 * time.Hour)},
+	}}
+
+	tests := map[string]bool{
+		"under /archive/2019": true,
+		"under /archive/201":  false,
+		"under /archive":      true,
+		"name img_*.jpg":      true,
+		"name *.png":          false,
+		"newer 30d":           true,
+		"newer 5d":            false,
+		"older 5d":            true,
+		"older 30d":           false,
+	}
+	for text, want := range tests {
+		rule, err := ParseSkipRule(text)
+		if err != nil {
+			t.Fatalf("ParseSkipRule(%q): %v", text, err)
+		}
+		if got := rule.Matches(set, now); got != want {
+			t.Errorf("%q matches = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestRunInteractiveSession_SkipRules(t *testing.T) {
+	match := func(dir, name string) models.FileMatch {
+		return models.FileMatch{
+			Filename: name,
+			File1:    models.FileInfo{Path: "/a/" + name, Directory: "/a", Size: 10},
+			File2:    models.FileInfo{Path: dir + "/" + name, Directory: dir, Size: 10},
+		}
+	}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{match("/b", "one.txt")}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{match("/c", "two.txt")}},
+	}
+
+	// Every set is skipped by a rule, so nothing is prompted for
+	summary, err := RunInteractiveSession(comparisons, models.ScanOptions{
+		Directories: []string{"/a", "/b", "/c"},
+		NumWorkers:  1,
+		SkipRules:   []string{"under /b", "name two.*"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.FilesDeleted != 0 || len(summary.Deferred) != 0 {
+		t.Errorf("Expected every set to be skipped, got %+v", summary)
+	}
+	if len(summary.SkipRules) != 2 || summary.SkipRules[0] != "under /b" {
+		t.Errorf("Expected the rules to be kept for the next session, got %v", summary.SkipRules)
+	}
+
+	if _, err := RunInteractiveSession(comparisons, models.ScanOptions{SkipRules: []string{"bogus"}}); err == nil {
+		t.Error("Expected an error for an invalid saved rule")
+	}
+}
@@ -0,0 +1,223 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Smallest terminal the full-screen interface is drawn in; smaller ones
+// get the prompts
+const (
+	minScreenWidth  = 60
+	minScreenHeight = 10
+)
+
+// screenTick is how often the full-screen interface checks the session
+// budget and whether the keys paused long enough to save the progress
+const screenTick = 250 * time.Millisecond
+
+// runScreen runs the session over sets in the full-screen interface, on the
+// terminal of stdin and stderr. It reports false, leaving the session to
+// the prompts, when that terminal cannot show it.
+func runScreen(sets []models.DuplicateSet, opts models.ScanOptions, skipRules []SkipRule) (*models.SessionSummary, bool, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil, false, nil
+	}
+	if width, height, err := term.GetSize(os.Stderr.Fd()); err != nil || width < minScreenWidth || height < minScreenHeight {
+		return nil, false, nil
+	}
+
+	// bubbletea puts the terminal back as it was however the program ends,
+	// a panic included
+	s := newScreen(sets, opts, skipRules)
+	program := tea.NewProgram(&screenModel{s: s, start: time.Now()},
+		tea.WithOutput(os.Stderr), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		return nil, true, fmt.Errorf("full-screen interface: %w", err)
+	}
+	if s.quit {
+		return nil, true, fmt.Errorf("user quit")
+	}
+	summary, err := s.finish()
+	return summary, true, err
+}
+
+// screenModel runs a screen as a bubbletea program: keys go to the screen,
+// which is drawn at the size of the terminal, and the progress is saved
+// for resume whenever the keys pause
+type screenModel struct {
+	s             *screen
+	start         time.Time
+	width, height int
+	saved         bool // Progress saved since the last key
+}
+
+// workMsg runs the slow work of the screen, once the status line saying
+// what it is has been drawn
+type workMsg struct{}
+
+// tickMsg comes every screenTick
+type tickMsg struct{}
+
+func tick() tea.Cmd {
+	return tea.Tick(screenTick, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+func (m *screenModel) Init() tea.Cmd {
+	return tea.Batch(tick(), m.settle())
+}
+
+func (m *screenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	s := m.s
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		for _, key := range keyNames(msg) {
+			s.handle(key)
+		}
+		m.saved = false
+	case workMsg:
+		if work := s.pending; work != nil {
+			s.pending = nil
+			work()
+		}
+		m.saved = false
+	case tickMsg:
+		if s.opts.SessionBudget > 0 && time.Since(m.start) > s.opts.SessionBudget {
+			s.expired = true
+			return m, tea.Quit
+		}
+		if !m.saved && s.pending == nil && s.opts.Checkpoint != nil {
+			s.opts.Checkpoint(s.progress())
+			m.saved = true
+		}
+		return m, tick()
+	}
+	return m, m.settle()
+}
+
+// settle ends the program once the user finishes or quits, and otherwise
+// starts the slow work the screen is waiting for
+func (m *screenModel) settle() tea.Cmd {
+	s := m.s
+	if s.done || s.quit {
+		return tea.Quit
+	}
+	s.view()
+	if s.pending != nil {
+		return func() tea.Msg { return workMsg{} }
+	}
+	return nil
+}
+
+func (m *screenModel) View() string {
+	if m.width == 0 || m.s.done || m.s.quit || m.s.expired {
+		return ""
+	}
+	return strings.Join(m.s.draw(m.width, m.height), "\n")
+}
+
+// view notes the set under the cursor as shown, verifying it by hash
+// first under AutoHash
+func (s *screen) view() {
+	ss := s.current()
+	if ss.shown {
+		return
+	}
+	ss.shown = true
+	s.habits.shown++
+	if s.opts.AutoHash && ss.state == setUndecided && !ss.set.HashComputed && !hasOfflineFile(ss.set) {
+		s.hashCurrent()
+	}
+}
+
+// finish verifies the marked sets, as the prompts do before deleting, and
+// confirms and carries out their deletions. Sets left undecided when the
+// session budget is used are deferred to the next session.
+func (s *screen) finish() (*models.SessionSummary, error) {
+	var actions []models.UserAction
+	var deferred []models.DuplicateSet
+	for i := range s.sets {
+		ss := &s.sets[i]
+		switch ss.state {
+		case setUndecided:
+			if s.expired {
+				deferred = append(deferred, ss.set)
+			}
+		case setMarked:
+			ok, err := verifyBeforeDeletion(&ss.set, s.opts)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				actions = append(actions, markedActions(*ss)...)
+			}
+		}
+	}
+	if len(deferred) > 0 {
+		fmt.Fprintf(os.Stderr, "\nSession budget of %s used: %d set(s) deferred to the next session\n", s.opts.SessionBudget, len(deferred))
+	}
+	if s.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "\nSkipped %d set(s) by skip rules\n", s.skipped)
+	}
+
+	session := models.SessionSummary{
+		TotalSets: len(s.sets),
+		Deferred:  deferred,
+		Learned:   s.habits.preferences(s.opts.AutoHash),
+		Notes:     s.notes,
+		SkipRules: ruleTexts(s.skipRules),
+	}
+	checkpoint := func() {
+		if s.opts.Checkpoint != nil {
+			progress := s.progress()
+			progress.Decided = len(s.sets) - len(deferred)
+			progress.Actions = actions
+			s.opts.Checkpoint(progress)
+		}
+	}
+	return concludeSession(session, actions, s.opts, checkpoint)
+}
+
+// keyTypes names the keys screen.handle takes that bubbletea reports by
+// type rather than as the runes typed
+var keyTypes = map[tea.KeyType]string{
+	tea.KeyUp: "up", tea.KeyDown: "down", tea.KeyLeft: "left", tea.KeyRight: "right",
+	tea.KeyHome: "home", tea.KeyEnd: "end", tea.KeyPgUp: "pgup", tea.KeyPgDown: "pgdn",
+	tea.KeyTab: "tab", tea.KeyEnter: "enter", tea.KeyEsc: "esc", tea.KeySpace: "space",
+	tea.KeyBackspace: "backspace", tea.KeyCtrlC: "ctrl-c",
+}
+
+// keyNames names the keys of msg as screen.handle takes them: printable
+// characters as themselves, others as "up", "enter", "ctrl-c" and the
+// like. Keys it does not know, and keys pressed with Alt, are dropped.
+func keyNames(msg tea.KeyMsg) []string {
+	if msg.Alt {
+		return nil
+	}
+	if msg.Type != tea.KeyRunes {
+		if name, ok := keyTypes[msg.Type]; ok {
+			return []string{name}
+		}
+		return nil
+	}
+	// Pasted text comes as one message
+	keys := make([]string, 0, len(msg.Runes))
+	for _, r := range msg.Runes {
+		if r == ' ' {
+			keys = append(keys, "space")
+		} else {
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
@@ -2,39 +2,68 @@ package interactive
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Sho2010/dup-finder/internal/diskspace"
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/media"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/scanner"
+	"github.com/Sho2010/dup-finder/internal/trash"
 )
 
-// DisplayDuplicateSet shows file details for user decision
-func DisplayDuplicateSet(set models.DuplicateSet) error {
+// DisplayDuplicateSet shows file details for user decision, including the
+// content type detected from each file's leading bytes and, for images,
+// audio and video, properties such as resolution and duration
+func DisplayDuplicateSet(set models.DuplicateSet, labels output.Labels, timeFormat output.TimeFormat) error {
 	fmt.Printf("\n=== Duplicate Set #%d ===\n", set.ID)
 	fmt.Printf("Found %d files with same size\n", len(set.Files))
 
-	// Only show hash if computed
-	if set.HashComputed {
+	// Only show hash if computed; sets verified by a checksum file have none
+	if set.HashComputed && len(set.Hash) >= 16 {
 		fmt.Printf("Hash: %s... (verified)\n", set.Hash[:16])
+	} else if set.HashComputed {
+		fmt.Println("Hash: verified by checksum")
+	}
+	if set.Note != "" {
+		fmt.Printf("Note: %s\n", set.Note)
 	}
 	fmt.Println()
 
 	for i, file := range set.Files {
-		fmt.Printf("[%d] %s\n", i+1, file.Path)
+		fmt.Printf("[%d] %s\n", i+1, labels.Path(file.Path))
 		fmt.Printf("    Size: %s\n", formatSize(file.Size))
-		fmt.Printf("    Modified: %s\n", file.ModTime.Format("2006-01-02 15:04:05"))
+		fmt.Printf("    Modified: %s\n", timeFormat.Format(file.ModTime))
+		if info, err := media.Probe(file.Path); err == nil {
+			fmt.Printf("    Type: %s\n", info)
+		}
 		fmt.Println()
 	}
 
 	return nil
 }
 
-// PromptUserAction gets user's choice for a duplicate set
-func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool) (models.UserAction, error) {
+// PromptUserAction gets user's choice for a duplicate set. The delete
+// option for suggestDelete, if any, is marked as suggested. A set of more
+// than two files, merged across directories, offers to keep one file and
+// delete the others instead.
+func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool, labels output.Labels, suggestDelete string) (models.UserAction, error) {
 	for {
 		fmt.Println("Choose an action:")
 		fmt.Println("  [s] Skip (do nothing)")
-		fmt.Printf("  [1] Delete: %s\n", set.Files[1].Path)
-		fmt.Printf("  [2] Delete: %s\n", set.Files[0].Path)
+		if len(set.Files) > 2 {
+			for i, file := range set.Files {
+				fmt.Printf("  [k%d] Keep only: %s\n", i+1, labels.Path(file.Path))
+			}
+		} else {
+			fmt.Printf("  [1] Delete: %s%s\n", labels.Path(set.Files[1].Path), suggestedMark(set.Files[1].Path, suggestDelete))
+			fmt.Printf("  [2] Delete: %s%s\n", labels.Path(set.Files[0].Path), suggestedMark(set.Files[0].Path, suggestDelete))
+		}
 
 		// Show hash option only if hash hasn't been computed yet
 		if !set.HashComputed {
@@ -43,12 +72,18 @@ func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool) (models.Use
 
 		if allowBatchByDir {
 			// Show directory names for batch operations
-			dir1 := set.Files[0].Directory
-			dir2 := set.Files[1].Directory
+			dir1 := labels.Dir(set.Files[0].Directory)
+			dir2 := labels.Dir(set.Files[1].Directory)
 			fmt.Printf("  [a] Keep all from %s, delete all from %s\n", dir1, dir2)
 			fmt.Printf("  [b] Keep all from %s, delete all from %s\n", dir2, dir1)
 		}
 
+		fmt.Println("  [x] Skip this and later sets by a rule, e.g. \"under /archive/2019\"")
+		if set.Note == "" {
+			fmt.Println("  [n] Add a note, e.g. \"check later\"")
+		} else {
+			fmt.Println("  [n] Edit the note (empty to remove it)")
+		}
 		fmt.Println("  [q] Quit interactive mode")
 		fmt.Println("  [f] Finish selection and proceed to confirmation")
 		fmt.Print("\nYour choice: ")
@@ -59,6 +94,10 @@ func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool) (models.Use
 			return models.UserAction{}, fmt.Errorf("failed to read input: %w", err)
 		}
 
+		if keep, ok := keepChoice(input, set); ok {
+			return models.UserAction{Action: "keep", KeepFile: keep, Reason: ReasonUserChoice}, nil
+		}
+
 		switch input {
 		case "s", "S":
 			return models.UserAction{Action: "skip"}, nil
@@ -72,18 +111,42 @@ func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool) (models.Use
 			}
 			fmt.Println("Hash already computed. Please choose a different option.")
 			fmt.Println()
+		case "n", "N":
+			fmt.Print("Note: ")
+			note, err := readLine()
+			if err != nil {
+				return models.UserAction{}, fmt.Errorf("failed to read input: %w", err)
+			}
+			return models.UserAction{Action: "annotate", Note: note}, nil
+		case "x", "X":
+			fmt.Printf("Rule (%s): ", SkipRuleHelp)
+			rule, err := readLine()
+			if err != nil {
+				return models.UserAction{}, fmt.Errorf("failed to read input: %w", err)
+			}
+			return models.UserAction{Action: "skip_rule", Rule: rule}, nil
 		case "1":
-			return models.UserAction{
-				Action:     "delete",
-				KeepFile:   set.Files[0].Path,
-				DeleteFile: set.Files[1].Path,
-			}, nil
+			if len(set.Files) == 2 {
+				return models.UserAction{
+					Action:     "delete",
+					KeepFile:   set.Files[0].Path,
+					DeleteFile: set.Files[1].Path,
+					Reason:     ReasonUserChoice,
+				}, nil
+			}
+			fmt.Println("Invalid choice. Please try again.")
+			fmt.Println()
 		case "2":
-			return models.UserAction{
-				Action:     "delete",
-				KeepFile:   set.Files[1].Path,
-				DeleteFile: set.Files[0].Path,
-			}, nil
+			if len(set.Files) == 2 {
+				return models.UserAction{
+					Action:     "delete",
+					KeepFile:   set.Files[1].Path,
+					DeleteFile: set.Files[0].Path,
+					Reason:     ReasonUserChoice,
+				}, nil
+			}
+			fmt.Println("Invalid choice. Please try again.")
+			fmt.Println()
 		case "a", "A":
 			if allowBatchByDir {
 				return models.UserAction{
@@ -111,23 +174,94 @@ func PromptUserAction(set models.DuplicateSet, allowBatchByDir bool) (models.Use
 	}
 }
 
-// ConfirmDeletion shows list of files to delete and asks for final confirmation
-func ConfirmDeletion(actions []models.UserAction) (bool, error) {
+// keepChoice returns the file of a set of more than two files that input,
+// such as "k2", chooses to keep
+func keepChoice(input string, set models.DuplicateSet) (string, bool) {
+	if len(set.Files) <= 2 || len(input) < 2 || (input[0] != 'k' && input[0] != 'K') {
+		return "", false
+	}
+	n, err := strconv.Atoi(input[1:])
+	if err != nil || n < 1 || n > len(set.Files) {
+		return "", false
+	}
+	return set.Files[n-1].Path, true
+}
+
+// readLine reads a line from stdin, spaces included, with surrounding
+// space trimmed. It reads a byte at a time so no input meant for later
+// prompts is buffered away.
+func readLine() (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimSpace(string(line)), nil
+}
+
+// suggestedMark returns the suggestion marker if path is the suggested deletion
+func suggestedMark(path, suggestDelete string) string {
+	if suggestDelete != "" && path == suggestDelete {
+		return "  (suggested by directory priority)"
+	}
+	return ""
+}
+
+// ReasonUserChoice is the reason recorded for deletions picked at the prompt
+const ReasonUserChoice = "user choice"
+
+// ConfirmDeletion shows list of files to delete and asks for final confirmation.
+// With opts.Explain, each file is followed by the choice or rule that selected
+// it. Files moved to a quarantine directory or the trash (see Destination),
+// or replaced with symlinks, are listed as such instead.
+func ConfirmDeletion(actions []models.UserAction, opts models.ScanOptions) (bool, error) {
+	explain, destination := opts.Explain, Destination(opts)
 	fmt.Println("\n=== Final Confirmation ===")
-	fmt.Printf("The following %d file(s) will be deleted:\n\n", len(actions))
+	if opts.Symlink != "" {
+		fmt.Printf("The following %d file(s) will be replaced with %s symlinks to the copies kept:\n\n", len(actions), opts.Symlink)
+	} else if destination != "" {
+		fmt.Printf("The following %d file(s) will be moved to %s:\n\n", len(actions), destination)
+	} else {
+		fmt.Printf("The following %d file(s) will be deleted:\n\n", len(actions))
+	}
 
-	var totalSize int64
+	var kept, removed []models.FileInfo
 	for i, action := range actions {
 		info, err := os.Stat(action.DeleteFile)
 		if err != nil {
-			fmt.Printf("%d. %s (cannot read file info)\n", i+1, action.DeleteFile)
+			fmt.Printf("%d. %s (cannot read file info)%s\n", i+1, action.DeleteFile, explainMark(action, explain))
 			continue
 		}
-		totalSize += info.Size()
-		fmt.Printf("%d. %s (%s)\n", i+1, action.DeleteFile, formatSize(info.Size()))
+		kept = append(kept, models.FileInfo{Path: action.KeepFile})
+		removed = append(removed, models.FileInfo{Path: action.DeleteFile, Size: info.Size()})
+		link := ""
+		if opts.Symlink != "" {
+			link = " → " + action.KeepFile
+		}
+		fmt.Printf("%d. %s (%s)%s%s\n", i+1, action.DeleteFile, formatSize(info.Size()), link, explainMark(action, explain))
 	}
 
-	fmt.Printf("\nTotal space to be freed: %s\n", formatSize(totalSize))
+	// Removing a hardlink of a kept file frees nothing
+	totalSize := finder.RemovalSavings(kept, removed)
+	if destination != "" {
+		// Space is only freed once the quarantine or trash is emptied
+		fmt.Printf("\nTotal size to be moved: %s\n", formatSize(totalSize))
+	} else {
+		fmt.Printf("\nTotal space to be freed: %s\n", formatSize(totalSize))
+		fmt.Print(diskspace.Format(diskspace.Project(kept, removed)))
+	}
 	fmt.Println("\nOptions:")
 	fmt.Println("  [y] Execute deletions (proceed)")
 	fmt.Println("  [n] Cancel all deletions (abort)")
@@ -139,18 +273,66 @@ func ConfirmDeletion(actions []models.UserAction) (bool, error) {
 	return input == "y" || input == "Y", nil
 }
 
+// explainMark returns the reason suffix for a confirmation line, or "" when
+// explain is off or the action has no recorded reason
+func explainMark(action models.UserAction, explain bool) string {
+	if !explain || action.Reason == "" {
+		return ""
+	}
+	return fmt.Sprintf("  [%s]", action.Reason)
+}
+
 // DisplaySummary shows final results after session
 func DisplaySummary(summary models.SessionSummary) error {
 	fmt.Println("\n=== Interactive Session Summary ===")
 	fmt.Printf("Duplicate Sets Found: %d\n", summary.TotalSets)
-	fmt.Printf("Files Deleted: %d\n", summary.FilesDeleted)
+	moved := summary.Quarantine // Where the files went instead of being deleted
+	if summary.Trash {
+		moved = trash.Name
+		fmt.Printf("Files Moved to Trash: %d\n", summary.FilesDeleted)
+	} else if summary.Symlink != "" {
+		fmt.Printf("Files Replaced with Symlinks: %d\n", summary.FilesDeleted)
+	} else if summary.Quarantine != "" {
+		fmt.Printf("Files Quarantined: %d\n", summary.FilesDeleted)
+	} else {
+		fmt.Printf("Files Deleted: %d\n", summary.FilesDeleted)
+	}
 	if summary.FilesFailed > 0 {
 		fmt.Printf("Failed Deletions: %d\n", summary.FilesFailed)
 	}
-	fmt.Printf("Space Freed: %s\n", formatSize(summary.SpaceFreed))
+	if moved != "" {
+		fmt.Printf("Size Moved: %s\n", formatSize(summary.SpaceFreed))
+	} else {
+		fmt.Printf("Space Freed: %s\n", formatSize(summary.SpaceFreed))
+	}
+	if len(summary.Deferred) > 0 {
+		fmt.Printf("Deferred to Next Session: %d set(s)\n", len(summary.Deferred))
+	}
+	if v := summary.Verification; v != nil {
+		fmt.Printf("Verification: %d of %d sampled kept file(s) match (%s)\n", v.Matched, v.Sampled, confidence(*v))
+	}
 
 	// Show successful deletions
-	if summary.FilesDeleted > 0 {
+	if summary.FilesDeleted > 0 && moved != "" {
+		fmt.Printf("\nMoved to %s:\n", moved)
+		for _, result := range summary.Results {
+			if result.Success {
+				fmt.Printf("  ✓ %s (%s)\n", result.Path, formatSize(result.SizeFreed))
+			}
+		}
+		if summary.Trash {
+			fmt.Println("Undo by restoring them from the trash")
+		} else {
+			fmt.Printf("Undo with: dup-finder restore %s\n", summary.Quarantine)
+		}
+	} else if summary.FilesDeleted > 0 && summary.Symlink != "" {
+		fmt.Println("\nReplaced with Symlinks:")
+		for _, result := range summary.Results {
+			if result.Success {
+				fmt.Printf("  ✓ %s (%s freed)\n", result.Path, formatSize(result.SizeFreed))
+			}
+		}
+	} else if summary.FilesDeleted > 0 {
 		fmt.Println("\nSuccessfully Deleted:")
 		for _, result := range summary.Results {
 			if result.Success {
@@ -159,6 +341,13 @@ func DisplaySummary(summary models.SessionSummary) error {
 		}
 	}
 
+	if v := summary.Verification; v != nil && len(v.Failed) > 0 {
+		fmt.Println("\nFailed Verification (kept copy missing or changed):")
+		for _, path := range v.Failed {
+			fmt.Printf("  ✗ %s\n", path)
+		}
+	}
+
 	// Show errors at the end
 	if summary.FilesFailed > 0 {
 		fmt.Println("\nFailed Deletions:")
@@ -174,14 +363,35 @@ func DisplaySummary(summary models.SessionSummary) error {
 
 // formatSize converts bytes to human-readable format
 func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	return output.FormatSize(bytes)
+}
+
+// PromptSoftLimit asks whether to keep scanning once a soft limit is passed.
+// It writes to stderr so that results on stdout stay clean.
+func PromptSoftLimit(files int, elapsed time.Duration) scanner.SoftLimitAction {
+	fmt.Fprintf(os.Stderr, "\nSoft limit reached: %d files walked in %s\n", files, elapsed.Round(time.Second))
+	for {
+		fmt.Fprintln(os.Stderr, "Choose an action:")
+		fmt.Fprintln(os.Stderr, "  [c] Continue scanning")
+		fmt.Fprintln(os.Stderr, "  [p] Stop now and proceed with partial results")
+		fmt.Fprintln(os.Stderr, "  [q] Quit to narrow filters (--extensions, --min-size, --exclude-dir, ...)")
+		fmt.Fprint(os.Stderr, "\nYour choice: ")
+
+		var input string
+		if _, err := fmt.Scanln(&input); err != nil {
+			return scanner.SoftLimitAbort
+		}
+
+		switch input {
+		case "c", "C":
+			return scanner.SoftLimitContinue
+		case "p", "P":
+			return scanner.SoftLimitPartial
+		case "q", "Q":
+			return scanner.SoftLimitAbort
+		default:
+			fmt.Fprintln(os.Stderr, "Invalid choice. Please try again.")
+			fmt.Fprintln(os.Stderr)
+		}
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
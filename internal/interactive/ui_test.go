@@ -12,14 +12,14 @@ func TestFormatSize(t *testing.T) {
 	}{
 		{"zero bytes", 0, "0 B"},
 		{"bytes", 100, "100 B"},
-		{"kilobytes", 1024, "1.0 KB"},
-		{"kilobytes with decimal", 1536, "1.5 KB"},
-		{"megabytes", 1048576, "1.0 MB"},
-		{"megabytes with decimal", 1572864, "1.5 MB"},
-		{"gigabytes", 1073741824, "1.0 GB"},
-		{"gigabytes with decimal", 1610612736, "1.5 GB"},
-		{"terabytes", 1099511627776, "1.0 TB"},
-		{"large file", 5368709120, "5.0 GB"},
+		{"kilobytes", 1024, "1.0 KiB"},
+		{"kilobytes with decimal", 1536, "1.5 KiB"},
+		{"megabytes", 1048576, "1.0 MiB"},
+		{"megabytes with decimal", 1572864, "1.5 MiB"},
+		{"gigabytes", 1073741824, "1.0 GiB"},
+		{"gigabytes with decimal", 1610612736, "1.5 GiB"},
+		{"terabytes", 1099511627776, "1.0 TiB"},
+		{"large file", 5368709120, "5.0 GiB"},
 	}
 
 	for _, tt := range tests {
@@ -40,17 +40,17 @@ func TestFormatSizeEdgeCases(t *testing.T) {
 		}
 	})
 
-	t.Run("exactly 1 KB", func(t *testing.T) {
+	t.Run("exactly 1 KiB", func(t *testing.T) {
 		result := formatSize(1024)
-		if result != "1.0 KB" {
-			t.Errorf("formatSize(1024) = %s; want 1.0 KB", result)
+		if result != "1.0 KiB" {
+			t.Errorf("formatSize(1024) = %s; want 1.0 KiB", result)
 		}
 	})
 
-	t.Run("exactly 1 MB", func(t *testing.T) {
+	t.Run("exactly 1 MiB", func(t *testing.T) {
 		result := formatSize(1048576)
-		if result != "1.0 MB" {
-			t.Errorf("formatSize(1048576) = %s; want 1.0 MB", result)
+		if result != "1.0 MiB" {
+			t.Errorf("formatSize(1048576) = %s; want 1.0 MiB", result)
 		}
 	})
 }
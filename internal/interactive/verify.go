@@ -0,0 +1,108 @@
+package interactive
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// sampleActions picks about percent% of the delete actions (at least one)
+// for verification. The sample is weighted by file size, so the copies with
+// the most data at stake are the most likely to be checked.
+func sampleActions(actions []models.UserAction, percent float64, rng *rand.Rand) []int {
+	if percent <= 0 || len(actions) == 0 {
+		return nil
+	}
+	n := int(math.Ceil(float64(len(actions)) * math.Min(percent, 100) / 100))
+
+	// Weighted sampling without replacement: each action gets the key
+	// u^(1/weight) and the n largest keys win
+	type keyed struct {
+		index int
+		key   float64
+	}
+	keys := make([]keyed, len(actions))
+	for i, action := range actions {
+		weight := 1.0
+		if info, err := os.Stat(action.DeleteFile); err == nil {
+			weight += float64(info.Size())
+		}
+		keys[i] = keyed{index: i, key: math.Pow(rng.Float64(), 1/weight)}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	sample := make([]int, n)
+	for i := range sample {
+		sample[i] = keys[i].index
+	}
+	sort.Ints(sample)
+	return sample
+}
+
+// recordHashes hashes the files the sampled actions are about to delete,
+// keyed by action index. Files that cannot be read are left out.
+func recordHashes(actions []models.UserAction, sample []int) map[int]string {
+	recorded := make(map[int]string, len(sample))
+	for _, i := range sample {
+		hash, err := finder.CalculateFileHash(actions[i].DeleteFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot record hash of %s for verification: %v\n", actions[i].DeleteFile, err)
+			continue
+		}
+		recorded[i] = hash
+	}
+	return recorded
+}
+
+// verifyKept re-hashes the kept file of every recorded action whose deletion
+// succeeded and compares it with the deleted copy's recorded hash.
+// results must be in the same order as actions.
+func verifyKept(actions []models.UserAction, results []models.DeletionResult, recorded map[int]string) models.Verification {
+	var v models.Verification
+	for _, result := range results {
+		if result.Success {
+			v.Candidates++
+		}
+	}
+
+	indexes := make([]int, 0, len(recorded))
+	for i := range recorded {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	for _, i := range indexes {
+		if i >= len(results) || !results[i].Success {
+			continue
+		}
+		v.Sampled++
+		hash, err := finder.CalculateFileHash(actions[i].KeepFile)
+		if err != nil || hash != recorded[i] {
+			v.Failed = append(v.Failed, actions[i].KeepFile)
+			continue
+		}
+		v.Matched++
+	}
+	return v
+}
+
+// confidence describes what a verification sample says about all kept
+// files. With no failures it gives the 95% upper bound on the failure rate
+// (the rule of three); otherwise the failure rate seen in the sample.
+func confidence(v models.Verification) string {
+	if v.Sampled == 0 {
+		return "no kept files could be sampled"
+	}
+	if len(v.Failed) > 0 {
+		return fmt.Sprintf("%.1f%% of sampled kept files failed", 100*float64(len(v.Failed))/float64(v.Sampled))
+	}
+	if v.Sampled == v.Candidates {
+		return "every kept file verified"
+	}
+	return fmt.Sprintf("95%% confidence that fewer than %.1f%% of kept files are bad", math.Min(100, 300/float64(v.Sampled)))
+}
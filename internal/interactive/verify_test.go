@@ -0,0 +1,109 @@
+package interactive
+
+import (
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestSampleActions(t *testing.T) {
+	actions := make([]models.UserAction, 10)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	if sample := sampleActions(actions, 0, rng); sample != nil {
+		t.Errorf("Expected no sample at 0%%, got %v", sample)
+	}
+	if sample := sampleActions(actions, 1, rng); len(sample) != 1 {
+		t.Errorf("Expected at least one sampled action, got %v", sample)
+	}
+	if sample := sampleActions(actions, 25, rng); len(sample) != 3 {
+		t.Errorf("Expected 3 sampled actions at 25%%, got %v", sample)
+	}
+
+	sample := sampleActions(actions, 100, rng)
+	for i, index := range sample {
+		if index != i {
+			t.Fatalf("Expected every action once in order at 100%%, got %v", sample)
+		}
+	}
+}
+
+func TestSampleActions_WeightedBySize(t *testing.T) {
+	tmpDir := t.TempDir()
+	large := filepath.Join(tmpDir, "large.bin")
+	if err := os.WriteFile(large, make([]byte, 1<<20), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	actions := []models.UserAction{
+		{DeleteFile: filepath.Join(tmpDir, "missing1")},
+		{DeleteFile: large},
+		{DeleteFile: filepath.Join(tmpDir, "missing2")},
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 20; i++ {
+		if sample := sampleActions(actions, 1, rng); len(sample) != 1 || sample[0] != 1 {
+			t.Fatalf("Expected the large file to be sampled, got %v", sample)
+		}
+	}
+}
+
+func TestVerifyKept(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	actions := []models.UserAction{
+		{KeepFile: write("keep1", "same"), DeleteFile: write("delete1", "same")},
+		{KeepFile: write("keep2", "same"), DeleteFile: write("delete2", "same")},
+		{KeepFile: write("keep3", "same"), DeleteFile: write("delete3", "same")},
+	}
+	recorded := recordHashes(actions, []int{0, 1})
+	if len(recorded) != 2 {
+		t.Fatalf("Expected 2 recorded hashes, got %d", len(recorded))
+	}
+
+	var results []models.DeletionResult
+	for _, action := range actions {
+		results = append(results, SafeDelete(action.DeleteFile))
+	}
+
+	// The second kept copy changes after its duplicate is gone
+	write("keep2", "changed")
+
+	v := verifyKept(actions, results, recorded)
+	if v.Candidates != 3 || v.Sampled != 2 || v.Matched != 1 {
+		t.Errorf("Expected 3 candidates, 2 sampled and 1 matched, got %+v", v)
+	}
+	if len(v.Failed) != 1 || v.Failed[0] != actions[1].KeepFile {
+		t.Errorf("Expected %s to fail verification, got %v", actions[1].KeepFile, v.Failed)
+	}
+	if got := confidence(v); !strings.Contains(got, "50.0% of sampled kept files failed") {
+		t.Errorf("Unexpected confidence %q", got)
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		v    models.Verification
+		want string
+	}{
+		{models.Verification{}, "no kept files could be sampled"},
+		{models.Verification{Candidates: 5, Sampled: 5, Matched: 5}, "every kept file verified"},
+		{models.Verification{Candidates: 1000, Sampled: 100, Matched: 100}, "95% confidence that fewer than 3.0% of kept files are bad"},
+	}
+	for _, tt := range tests {
+		if got := confidence(tt.v); got != tt.want {
+			t.Errorf("confidence(%+v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
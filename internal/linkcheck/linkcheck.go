@@ -0,0 +1,150 @@
+// Package linkcheck verifies the symlinks and hardlinks in a tree, such as
+// those left by deduplicating with links: that every link still resolves,
+// and that no linked file changed since its hash was recorded
+package linkcheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/index"
+)
+
+// Problems found by Check
+const (
+	Broken   = "broken"   // A symlink whose target is missing or cannot be reached
+	Diverged = "diverged" // A linked file whose contents changed since they were indexed
+)
+
+// Issue is a link that failed a check
+type Issue struct {
+	Kind   string // Broken or Diverged
+	Path   string // The link
+	Target string // Where a symlink points ("" for hardlinks)
+	Detail string
+}
+
+// Report is the outcome of Check
+type Report struct {
+	Symlinks       int // Symlinks to files
+	Hardlinks      int // Files with more than one hardlink
+	HardlinkGroups int // Distinct files behind Hardlinks
+	Issues         []Issue
+}
+
+// linked is a file reached through a link: a symlink's target or one path
+// of a hardlinked file
+type linked struct {
+	path   string
+	target string
+	info   os.FileInfo
+}
+
+// Check walks dir, without following links to directories, and checks
+// every symlink to a file and every hardlinked file in it. A symlink whose
+// target cannot be reached is broken. With idx, a linked file is diverged
+// when its contents no longer hash to what idx recorded for it; a file
+// with unchanged size and mtime is taken as unchanged without reading it.
+// Hardlinks are only recognized where the platform reports link counts.
+func Check(dir string, idx *index.Index) (Report, error) {
+	var report Report
+	var files []linked
+	groups := make(map[[2]uint64]bool)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			// Unreadable entries hold no links we can check
+			return nil
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			info, err := os.Stat(path)
+			if err != nil {
+				report.Symlinks++
+				report.Issues = append(report.Issues, Issue{Kind: Broken, Path: path, Target: target, Detail: describe(err)})
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			report.Symlinks++
+			files = append(files, linked{path: path, target: target, info: info})
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			id, nlink := linkInfo(info)
+			if nlink < 2 {
+				return nil
+			}
+			report.Hardlinks++
+			groups[id] = true
+			files = append(files, linked{path: path, info: info})
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	report.HardlinkGroups = len(groups)
+
+	if idx != nil {
+		for _, file := range files {
+			if issue, ok := diverged(file, idx); !ok {
+				report.Issues = append(report.Issues, issue)
+			}
+		}
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Path < report.Issues[j].Path
+	})
+	return report, nil
+}
+
+// diverged checks file against the entry idx holds for its path, or for a
+// symlink's target, and reports false with the issue if it changed
+func diverged(file linked, idx *index.Index) (Issue, bool) {
+	entry, ok := idx.Get(file.path)
+	if !ok && file.target != "" {
+		if resolved, err := filepath.EvalSymlinks(file.path); err == nil {
+			entry, ok = idx.Get(resolved)
+		}
+	}
+	if !ok || (entry.Size == file.info.Size() && entry.ModTime.Equal(file.info.ModTime())) {
+		return Issue{}, true
+	}
+
+	issue := Issue{Kind: Diverged, Path: file.path, Target: file.target}
+	hash, err := finder.CalculateFileHash(file.path)
+	switch {
+	case err != nil:
+		issue.Detail = fmt.Sprintf("cannot hash: %v", err)
+	case hash != entry.Hash:
+		issue.Detail = fmt.Sprintf("modified since it was indexed on %s", entry.ModTime.Format("2006-01-02 15:04"))
+	default:
+		return Issue{}, true
+	}
+	return issue, false
+}
+
+// describe explains why a symlink target cannot be reached
+func describe(err error) string {
+	if errors.Is(err, fs.ErrNotExist) {
+		return "target missing"
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,107 @@
+package linkcheck
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/index"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// indexFile records the current hash of path in idx
+func indexFile(t *testing.T, idx *index.Index, path string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	hash, err := finder.CalculateFileHash(path)
+	require.NoError(t, err)
+	idx.Put(path, index.Entry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash})
+}
+
+func TestCheck_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "photo.jpg"), "photo")
+	if err := os.Symlink("photo.jpg", filepath.Join(dir, "copy.jpg")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink("gone.jpg", filepath.Join(dir, "broken.jpg")))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.Symlink("sub", filepath.Join(dir, "subdir")))
+
+	report, err := Check(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Symlinks)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, Issue{Kind: Broken, Path: filepath.Join(dir, "broken.jpg"), Target: "gone.jpg", Detail: "target missing"}, report.Issues[0])
+}
+
+func TestCheck_Diverged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("link counts are not available on Windows")
+	}
+	dir := t.TempDir()
+	idx := index.New(filepath.Join(t.TempDir(), "index.json"))
+
+	edited := filepath.Join(dir, "a.txt")
+	writeFile(t, edited, "original")
+	require.NoError(t, os.Link(edited, filepath.Join(dir, "b.txt")))
+	touched := filepath.Join(dir, "c.txt")
+	writeFile(t, touched, "same")
+	require.NoError(t, os.Link(touched, filepath.Join(dir, "d.txt")))
+	writeFile(t, filepath.Join(dir, "single.txt"), "alone")
+	indexFile(t, idx, edited)
+	indexFile(t, idx, touched)
+
+	// Editing one path changes both; touching only changes the mtime
+	writeFile(t, edited, "changed!")
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(touched, later, later))
+
+	report, err := Check(dir, idx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Hardlinks)
+	assert.Equal(t, 2, report.HardlinkGroups)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, Diverged, report.Issues[0].Kind)
+	assert.Equal(t, edited, report.Issues[0].Path)
+	assert.Contains(t, report.Issues[0].Detail, "modified since it was indexed")
+
+	// Without an index only reachability is checked
+	report, err = Check(dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestCheck_SymlinkTargetIndexed(t *testing.T) {
+	dir := t.TempDir()
+	idx := index.New(filepath.Join(t.TempDir(), "index.json"))
+	target := filepath.Join(dir, "photo.jpg")
+	writeFile(t, target, "photo")
+	if err := os.Symlink("photo.jpg", filepath.Join(dir, "copy.jpg")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	indexFile(t, idx, target)
+
+	writeFile(t, target, "edited")
+	report, err := Check(dir, idx)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, filepath.Join(dir, "copy.jpg"), report.Issues[0].Path)
+	assert.Equal(t, "photo.jpg", report.Issues[0].Target)
+}
+
+func TestCheck_MissingDir(t *testing.T) {
+	_, err := Check(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.Error(t, err)
+}
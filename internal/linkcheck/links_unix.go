@@ -0,0 +1,18 @@
+//go:build unix
+
+package linkcheck
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkInfo returns the device and inode of the file behind info and its
+// number of hardlinks
+func linkInfo(info os.FileInfo) (id [2]uint64, nlink uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return id, 1
+	}
+	return [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}, uint64(stat.Nlink)
+}
@@ -0,0 +1,188 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Section is a byte range of a file
+type Section struct {
+	Offset int64
+	Length int64
+}
+
+// audioExtensions are the formats AudioSections can parse
+var audioExtensions = []string{".mp3", ".flac", ".m4a"}
+
+// Sizes of the tag structures found around MP3 and FLAC audio
+const (
+	id3v2HeaderLen = 10
+	id3v1Len       = 128
+	apeFooterLen   = 32
+)
+
+// IsAudio reports whether AudioSections can parse the file at path, judged
+// by its extension
+func IsAudio(path string) bool {
+	return slices.Contains(audioExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// AudioSections returns where the audio stream of an MP3, FLAC or M4A file
+// lies, leaving out its tags: ID3v2 tags at the start and ID3v1 and APEv2
+// tags at the end of MP3 files, the metadata blocks of FLAC files (Vorbis
+// comments, pictures and the like), and every box but the media data
+// (mdat) of M4A files. Two copies of a song whose tags were edited have the
+// same audio sections.
+func AudioSections(path string) ([]Section, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return mp3Sections(file, size)
+	case ".flac":
+		return flacSections(file, size)
+	case ".m4a":
+		return m4aSections(file, size)
+	}
+	return nil, fmt.Errorf("unsupported audio format %q", filepath.Ext(path))
+}
+
+// mp3Sections returns the frames between the leading and trailing tags
+func mp3Sections(r io.ReadSeeker, size int64) ([]Section, error) {
+	start, err := skipID3v2(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	end, err := trimTrailingTags(r, start, size)
+	if err != nil {
+		return nil, err
+	}
+	return []Section{{Offset: start, Length: end - start}}, nil
+}
+
+// flacSections returns the frames after the metadata blocks
+func flacSections(r io.ReadSeeker, size int64) ([]Section, error) {
+	// ID3v2 tags are not part of FLAC, but some taggers prepend them anyway
+	pos, err := skipID3v2(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	var marker [4]byte
+	if err := readAt(r, pos, marker[:]); err != nil || string(marker[:]) != "fLaC" {
+		return nil, errors.New("not a FLAC file")
+	}
+	pos += 4
+	for {
+		var header [4]byte
+		if err := readAt(r, pos, header[:]); err != nil {
+			return nil, errors.New("truncated FLAC metadata")
+		}
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		pos += 4 + length
+		if pos > size {
+			return nil, errors.New("truncated FLAC metadata")
+		}
+		if header[0]&0x80 != 0 { // Last metadata block
+			break
+		}
+	}
+	end, err := trimTrailingTags(r, pos, size)
+	if err != nil {
+		return nil, err
+	}
+	return []Section{{Offset: pos, Length: end - pos}}, nil
+}
+
+// m4aSections returns the bodies of the top-level media data boxes
+func m4aSections(r io.ReadSeeker, size int64) ([]Section, error) {
+	var sections []Section
+	for _, b := range boxes(r, 0, size) {
+		if b.kind == "mdat" {
+			sections = append(sections, Section{Offset: b.body, Length: b.end - b.body})
+		}
+	}
+	if len(sections) == 0 {
+		return nil, errors.New("no media data box in M4A file")
+	}
+	return sections, nil
+}
+
+// skipID3v2 returns the offset after the ID3v2 tags starting at pos, or pos
+// if there are none
+func skipID3v2(r io.ReadSeeker, pos, size int64) (int64, error) {
+	for {
+		var header [id3v2HeaderLen]byte
+		if err := readAt(r, pos, header[:]); err != nil || string(header[:3]) != "ID3" {
+			return pos, nil
+		}
+		// The tag size is synchsafe: 7 bits per byte
+		length := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+		if header[5]&0x10 != 0 { // Footer present
+			length += id3v2HeaderLen
+		}
+		pos += id3v2HeaderLen + length
+		if pos > size {
+			return 0, errors.New("truncated ID3v2 tag")
+		}
+	}
+}
+
+// trimTrailingTags returns where the audio before the ID3v1 and APEv2 tags
+// at the end of a file ends, no earlier than start
+func trimTrailingTags(r io.ReadSeeker, start, end int64) (int64, error) {
+	for {
+		if end-start >= id3v1Len {
+			var tag [3]byte
+			if err := readAt(r, end-id3v1Len, tag[:]); err != nil {
+				return 0, err
+			}
+			if string(tag[:]) == "TAG" {
+				end -= id3v1Len
+				continue
+			}
+		}
+		if end-start >= apeFooterLen {
+			var footer [apeFooterLen]byte
+			if err := readAt(r, end-apeFooterLen, footer[:]); err != nil {
+				return 0, err
+			}
+			if bytes.HasPrefix(footer[:], []byte("APETAGEX")) {
+				// The size covers the items and the footer, but not the header
+				length := int64(binary.LittleEndian.Uint32(footer[12:16]))
+				if binary.LittleEndian.Uint32(footer[20:24])&(1<<31) != 0 {
+					length += apeFooterLen
+				}
+				if length < apeFooterLen || end-length < start {
+					return 0, errors.New("malformed APEv2 tag")
+				}
+				end -= length
+				continue
+			}
+		}
+		return end, nil
+	}
+}
+
+// readAt reads len(buf) bytes at offset
+func readAt(r io.ReadSeeker, offset int64, buf []byte) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(r, buf)
+	return err
+}
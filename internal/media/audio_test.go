@@ -0,0 +1,110 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// id3v2 builds an ID3v2.3 tag holding body
+func id3v2(body string) []byte {
+	n := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	return append(header, body...)
+}
+
+// id3v1 builds an ID3v1 tag with title
+func id3v1(title string) []byte {
+	tag := make([]byte, id3v1Len)
+	copy(tag, "TAG")
+	copy(tag[3:], title)
+	return tag
+}
+
+// apeTag builds an APEv2 tag with a header and footer around items
+func apeTag(items string) []byte {
+	block := func() []byte {
+		b := make([]byte, apeFooterLen)
+		copy(b, "APETAGEX")
+		binary.LittleEndian.PutUint32(b[8:12], 2000)
+		binary.LittleEndian.PutUint32(b[12:16], uint32(len(items)+apeFooterLen))
+		binary.LittleEndian.PutUint32(b[20:24], 1<<31)
+		return b
+	}
+	tag := append(block(), items...)
+	return append(tag, block()...)
+}
+
+// sectionBytes reads the sections of the file at path
+func sectionBytes(t *testing.T, path string) string {
+	sections, err := AudioSections(path)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var out []byte
+	for _, s := range sections {
+		out = append(out, data[s.Offset:s.Offset+s.Length]...)
+	}
+	return string(out)
+}
+
+func TestAudioSections_MP3(t *testing.T) {
+	const frames = "\xFF\xFBframes of audio"
+	tagged := bytes.Join([][]byte{id3v2("title=Song"), []byte(frames), apeTag("Artist=Someone"), id3v1("Song")}, nil)
+	retagged := bytes.Join([][]byte{id3v2("title=Song (Remastered), with cover art"), []byte(frames)}, nil)
+
+	assert.Equal(t, frames, sectionBytes(t, writeTemp(t, "tagged.mp3", tagged)))
+	assert.Equal(t, frames, sectionBytes(t, writeTemp(t, "retagged.mp3", retagged)))
+	assert.Equal(t, frames, sectionBytes(t, writeTemp(t, "bare.mp3", []byte(frames))))
+}
+
+func TestAudioSections_FLAC(t *testing.T) {
+	const frames = "\xFF\xF8frames of audio"
+	block := func(last bool, kind byte, body string) []byte {
+		header := []byte{kind, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+		if last {
+			header[0] |= 0x80
+		}
+		return append(header, body...)
+	}
+	flac := func(comment string) []byte {
+		return bytes.Join([][]byte{[]byte("fLaC"), block(false, 0, "streaminfo"), block(true, 4, comment), []byte(frames)}, nil)
+	}
+
+	assert.Equal(t, frames, sectionBytes(t, writeTemp(t, "a.flac", flac("TITLE=Song"))))
+	assert.Equal(t, frames, sectionBytes(t, writeTemp(t, "b.flac", append(id3v2("stray"), flac("TITLE=Another title")...))))
+
+	_, err := AudioSections(writeTemp(t, "c.flac", []byte("not flac")))
+	assert.ErrorContains(t, err, "not a FLAC file")
+	_, err = AudioSections(writeTemp(t, "d.flac", []byte("fLaC\x00\x00\x10\x00short")))
+	assert.ErrorContains(t, err, "truncated FLAC metadata")
+}
+
+func TestAudioSections_M4A(t *testing.T) {
+	box := func(kind, body string) []byte {
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+		copy(header[4:], kind)
+		return append(header, body...)
+	}
+	m4a := func(tags string) []byte {
+		return bytes.Join([][]byte{box("ftyp", "M4A "), box("moov", string(box("udta", tags))), box("mdat", "aac frames")}, nil)
+	}
+
+	assert.Equal(t, "aac frames", sectionBytes(t, writeTemp(t, "a.m4a", m4a("title"))))
+	assert.Equal(t, "aac frames", sectionBytes(t, writeTemp(t, "b.m4a", m4a("a much longer title"))))
+
+	_, err := AudioSections(writeTemp(t, "c.m4a", box("ftyp", "M4A ")))
+	assert.ErrorContains(t, err, "no media data box")
+}
+
+func TestIsAudio(t *testing.T) {
+	assert.True(t, IsAudio("/music/Song.MP3"))
+	assert.True(t, IsAudio("/music/song.flac"))
+	assert.True(t, IsAudio("/music/song.m4a"))
+	assert.False(t, IsAudio("/music/song.wav"))
+}
@@ -0,0 +1,108 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	// Decoders of the formats in imageExtensions
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// DHashBits is the length of a difference hash, and so the largest
+// distance between two of them
+const DHashBits = 64
+
+// Grid images are shrunk to: one more column than the hash has bits per
+// row, so each of the 8 rows yields 8 comparisons of neighbouring cells
+const (
+	dhashWidth  = 9
+	dhashHeight = 8
+)
+
+// imageExtensions are the image formats DHash decodes
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif"}
+
+// IsDecodableImage reports whether DHash can read the file at path, judged
+// by its extension
+func IsDecodableImage(path string) bool {
+	return slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// DHash returns the difference hash of the image at path: the image is
+// shrunk to 9×8 cells of average brightness, and each bit records whether
+// a cell is brighter than its right neighbour. The hash follows the
+// image's gradients rather than its bytes, so re-encoded, resized or
+// lightly edited copies of a photo hash alike.
+func DHash(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, err
+	}
+	cells := shrink(img)
+
+	var hash uint64
+	for y := 0; y < dhashHeight; y++ {
+		for x := 0; x < dhashWidth-1; x++ {
+			hash <<= 1
+			if cells[y][x] > cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// Distance returns how many bits of two difference hashes differ, from 0
+// for images that look the same to DHashBits
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// shrink returns the average brightness of each of the dhashWidth ×
+// dhashHeight cells img divides into
+func shrink(img image.Image) [dhashHeight][dhashWidth]float64 {
+	var sums [dhashHeight][dhashWidth]float64
+	var counts [dhashHeight][dhashWidth]int
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	for y := 0; y < height; y++ {
+		row := y * dhashHeight / height
+		for x := 0; x < width; x++ {
+			column := x * dhashWidth / width
+			sums[row][column] += luma(img, bounds.Min.X+x, bounds.Min.Y+y)
+			counts[row][column]++
+		}
+	}
+
+	var cells [dhashHeight][dhashWidth]float64
+	for y := range cells {
+		for x := range cells[y] {
+			if counts[y][x] > 0 {
+				cells[y][x] = sums[y][x] / float64(counts[y][x])
+			}
+		}
+	}
+	return cells
+}
+
+// luma returns the brightness of a pixel, read straight from the Y plane
+// of JPEG images, which are by far the most common
+func luma(img image.Image, x, y int) float64 {
+	if ycc, ok := img.(*image.YCbCr); ok {
+		return float64(ycc.Y[ycc.YOffset(x, y)])
+	}
+	return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+}
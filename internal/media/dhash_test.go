@@ -0,0 +1,77 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scene draws the same smooth pattern at any size, as a photo and its
+// resized copies would show it
+func scene(width, height int, phase float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			u, v := float64(x)/float64(width), float64(y)/float64(height)
+			level := 127 + 127*math.Sin(7*u+phase)*math.Cos(5*v+phase)
+			img.Set(x, y, color.RGBA{uint8(level), uint8(255 - level), uint8(level / 2), 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image, quality int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}))
+	return buf.Bytes()
+}
+
+func TestDHash(t *testing.T) {
+	original, err := DHash(writeTemp(t, "original.png", encodePNG(t, scene(640, 480, 0))))
+	require.NoError(t, err)
+
+	reencoded, err := DHash(writeTemp(t, "reencoded.jpg", encodeJPEG(t, scene(640, 480, 0), 60)))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, Distance(original, reencoded), 2, "re-encoded as a lossy JPEG")
+
+	resized, err := DHash(writeTemp(t, "resized.jpg", encodeJPEG(t, scene(160, 120, 0), 90)))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, Distance(original, resized), 2, "resized to a quarter")
+
+	other, err := DHash(writeTemp(t, "other.png", encodePNG(t, scene(640, 480, 2))))
+	require.NoError(t, err)
+	assert.Greater(t, Distance(original, other), 10, "a different picture")
+}
+
+func TestDHash_Errors(t *testing.T) {
+	_, err := DHash(writeTemp(t, "photo.jpg", []byte("not an image")))
+	assert.Error(t, err)
+	_, err = DHash("/nonexistent/photo.jpg")
+	assert.Error(t, err)
+}
+
+func TestIsDecodableImage(t *testing.T) {
+	assert.True(t, IsDecodableImage("/a/IMG_0001.JPG"))
+	assert.True(t, IsDecodableImage("/a/scan.png"))
+	assert.False(t, IsDecodableImage("/a/IMG_0001.HEIC"))
+	assert.False(t, IsDecodableImage("/a/notes.txt"))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance(0xF0F0, 0xF0F0))
+	assert.Equal(t, 3, Distance(0b1011, 0b0000))
+	assert.Equal(t, DHashBits, Distance(0, math.MaxUint64))
+}
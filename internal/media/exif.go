@@ -0,0 +1,147 @@
+package media
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// EXIF tags used to find the capture date
+const (
+	tagDateTime         = 0x0132
+	tagExifIFDPointer   = 0x8769
+	tagDateTimeOriginal = 0x9003
+)
+
+// exifTimeLayout is the fixed EXIF timestamp format
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+var errNoExif = errors.New("no EXIF data")
+
+// CaptureTime returns the EXIF capture date of a JPEG image.
+// It prefers DateTimeOriginal and falls back to DateTime.
+func CaptureTime(path string) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	data, err := readExifSegment(bufio.NewReader(file))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseExifTime(data)
+}
+
+// readExifSegment returns the TIFF payload of the JPEG APP1 Exif segment
+func readExifSegment(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return nil, errors.New("not a JPEG file")
+	}
+
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return nil, errNoExif
+		}
+		if marker[0] != 0xFF {
+			return nil, errors.New("malformed JPEG segment")
+		}
+		// Start of scan or end of image: no metadata follows
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil, errNoExif
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return nil, errors.New("malformed JPEG segment")
+		}
+		payload := make([]byte, length-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, errors.New("truncated JPEG segment")
+		}
+
+		if marker[1] == 0xE1 && len(payload) > 6 && string(payload[:6]) == "Exif\x00\x00" {
+			return payload[6:], nil
+		}
+	}
+}
+
+// parseExifTime extracts the capture date from a TIFF-structured EXIF block
+func parseExifTime(data []byte) (time.Time, error) {
+	if len(data) < 8 {
+		return time.Time{}, errNoExif
+	}
+
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, errors.New("invalid TIFF header")
+	}
+
+	ifd0 := readIFD(data, order, order.Uint32(data[4:8]))
+
+	// DateTimeOriginal lives in the Exif sub-IFD
+	if ptr, ok := ifd0[tagExifIFDPointer]; ok {
+		exifIFD := readIFD(data, order, order.Uint32(ptr[8:12]))
+		if t, ok := asciiTime(data, order, exifIFD[tagDateTimeOriginal]); ok {
+			return t, nil
+		}
+	}
+	if t, ok := asciiTime(data, order, ifd0[tagDateTime]); ok {
+		return t, nil
+	}
+	return time.Time{}, errNoExif
+}
+
+// readIFD returns the raw 12-byte entries of the IFD at offset, keyed by tag
+func readIFD(data []byte, order binary.ByteOrder, offset uint32) map[uint16][]byte {
+	entries := make(map[uint16][]byte)
+	if int(offset)+2 > len(data) {
+		return entries
+	}
+
+	count := int(order.Uint16(data[offset:]))
+	pos := int(offset) + 2
+	for i := 0; i < count && pos+12 <= len(data); i++ {
+		entries[order.Uint16(data[pos:])] = data[pos : pos+12]
+		pos += 12
+	}
+	return entries
+}
+
+// asciiTime decodes an ASCII IFD entry holding an EXIF timestamp
+func asciiTime(data []byte, order binary.ByteOrder, entry []byte) (time.Time, bool) {
+	if len(entry) != 12 {
+		return time.Time{}, false
+	}
+
+	count := order.Uint32(entry[4:8])
+	var value []byte
+	if count <= 4 {
+		value = entry[8 : 8+count]
+	} else {
+		offset := order.Uint32(entry[8:12])
+		if uint64(offset)+uint64(count) > uint64(len(data)) {
+			return time.Time{}, false
+		}
+		value = data[offset : offset+count]
+	}
+
+	s := strings.TrimRight(string(value), "\x00 ")
+	t, err := time.ParseInLocation(exifTimeLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,83 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildExifJPEG builds a minimal JPEG whose Exif IFD holds DateTimeOriginal
+func buildExifJPEG(t *testing.T, order binary.ByteOrder, dateTime string) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	w := func(v interface{}) { require.NoError(t, binary.Write(&tiff, order, v)) }
+	w(uint16(42))
+	w(uint32(8)) // IFD0 offset
+
+	// IFD0: one entry pointing at the Exif IFD
+	w(uint16(1))
+	w(uint16(tagExifIFDPointer))
+	w(uint16(4)) // LONG
+	w(uint32(1))
+	w(uint32(26))
+	w(uint32(0)) // next IFD
+
+	// Exif IFD: DateTimeOriginal stored out of line
+	value := dateTime + "\x00"
+	w(uint16(1))
+	w(uint16(tagDateTimeOriginal))
+	w(uint16(2)) // ASCII
+	w(uint32(len(value)))
+	w(uint32(44))
+	w(uint32(0))
+	tiff.WriteString(value)
+
+	var jpeg bytes.Buffer
+	jpeg.Write([]byte{0xFF, 0xD8})
+	jpeg.Write([]byte{0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00}) // unrelated APP0 segment
+	jpeg.Write([]byte{0xFF, 0xE1})
+	require.NoError(t, binary.Write(&jpeg, binary.BigEndian, uint16(2+6+tiff.Len())))
+	jpeg.WriteString("Exif\x00\x00")
+	jpeg.Write(tiff.Bytes())
+	jpeg.Write([]byte{0xFF, 0xD9})
+	return jpeg.Bytes()
+}
+
+func TestCaptureTime(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "photo.jpg")
+			require.NoError(t, os.WriteFile(path, buildExifJPEG(t, order, "2021:07:04 10:20:30"), 0644))
+
+			captured, err := CaptureTime(path)
+			require.NoError(t, err)
+			assert.Equal(t, time.Date(2021, 7, 4, 10, 20, 30, 0, time.Local), captured)
+		})
+	}
+}
+
+func TestCaptureTime_NoExif(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	plain := filepath.Join(tmpDir, "plain.jpg")
+	require.NoError(t, os.WriteFile(plain, []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0644))
+	_, err := CaptureTime(plain)
+	assert.Error(t, err)
+
+	text := filepath.Join(tmpDir, "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("not an image"), 0644))
+	_, err = CaptureTime(text)
+	assert.Error(t, err)
+}
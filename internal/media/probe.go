@@ -0,0 +1,314 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// sniffLen is how many leading bytes are read to detect the content type
+const sniffLen = 512
+
+// Info describes a file's content: its type from its leading bytes and,
+// for media, the properties that can be read from the headers
+type Info struct {
+	Type     string        // MIME type without parameters, e.g. "image/jpeg"
+	Width    int           // Pixels (0 if unknown)
+	Height   int           // Pixels (0 if unknown)
+	Duration time.Duration // Play time of audio and video (0 if unknown)
+	Bitrate  int64         // Bits per second of audio and video (0 if unknown)
+}
+
+// String renders the type followed by the known properties, e.g.
+// "video/mp4, 1920×1080, 1m32s, 8.2 Mbit/s"
+func (i Info) String() string {
+	parts := []string{i.Type}
+	if i.Width > 0 && i.Height > 0 {
+		parts = append(parts, fmt.Sprintf("%d×%d", i.Width, i.Height))
+	}
+	if i.Duration > 0 {
+		parts = append(parts, i.Duration.Round(time.Second).String())
+	}
+	if i.Bitrate > 0 {
+		parts = append(parts, formatBitrate(i.Bitrate))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBitrate renders bits per second in bit/s, kbit/s or Mbit/s
+func formatBitrate(bps int64) string {
+	switch {
+	case bps >= 1_000_000:
+		return fmt.Sprintf("%.1f Mbit/s", float64(bps)/1_000_000)
+	case bps >= 1000:
+		return fmt.Sprintf("%d kbit/s", (bps+500)/1000)
+	}
+	return fmt.Sprintf("%d bit/s", bps)
+}
+
+// Probe detects the content type of the file at path from its leading
+// bytes, and reads the resolution of PNG, GIF, BMP and JPEG images and the
+// resolution, duration and bitrate of MP4/QuickTime video and WAV audio.
+// Properties that cannot be read are left zero.
+func Probe(path string) (Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Info{}, err
+	}
+	head = head[:n]
+
+	info := Info{Type: detectType(head)}
+	switch info.Type {
+	case "image/png":
+		if len(head) >= 24 {
+			info.Width = int(binary.BigEndian.Uint32(head[16:20]))
+			info.Height = int(binary.BigEndian.Uint32(head[20:24]))
+		}
+	case "image/gif":
+		if len(head) >= 10 {
+			info.Width = int(binary.LittleEndian.Uint16(head[6:8]))
+			info.Height = int(binary.LittleEndian.Uint16(head[8:10]))
+		}
+	case "image/bmp":
+		if len(head) >= 26 {
+			info.Width = int(int32(binary.LittleEndian.Uint32(head[18:22])))
+			info.Height = abs(int(int32(binary.LittleEndian.Uint32(head[22:26]))))
+		}
+	case "image/jpeg":
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			info.Width, info.Height = jpegSize(file)
+		}
+	case "audio/wave":
+		probeWAV(file, &info)
+	case "video/mp4", "video/quicktime":
+		probeMP4(file, &info)
+	}
+	return info, nil
+}
+
+// detectType returns the MIME type of content starting with head. Formats
+// the standard sniffer does not know, QuickTime and HEIF, are checked first.
+func detectType(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "qt  ":
+			return "video/quicktime"
+		case "heic", "heix", "mif1":
+			return "image/heic"
+		}
+	}
+	mime := http.DetectContentType(head)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	return mime
+}
+
+// jpegSize reads the frame size from the first start-of-frame segment
+func jpegSize(r io.Reader) (width, height int) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return 0, 0
+	}
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return 0, 0
+		}
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return 0, 0
+		}
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return 0, 0
+		}
+		payload := make([]byte, length-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return 0, 0
+		}
+		// SOF0 to SOF15, except DHT (C4), JPG (C8) and DAC (CC)
+		if m := marker[1]; m >= 0xC0 && m <= 0xCF && m != 0xC4 && m != 0xC8 && m != 0xCC && len(payload) >= 5 {
+			return int(binary.BigEndian.Uint16(payload[3:5])), int(binary.BigEndian.Uint16(payload[1:3]))
+		}
+	}
+}
+
+// probeWAV reads the byte rate from the fmt chunk and the duration from
+// the size of the data chunk
+func probeWAV(r io.ReadSeeker, info *Info) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return
+	}
+	var byteRate uint32
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		size := binary.LittleEndian.Uint32(header[4:8])
+		switch string(header[:4]) {
+		case "fmt ":
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil || len(chunk) < 12 {
+				return
+			}
+			byteRate = binary.LittleEndian.Uint32(chunk[8:12])
+			info.Bitrate = int64(byteRate) * 8
+			if size%2 == 1 {
+				r.Seek(1, io.SeekCurrent)
+			}
+		case "data":
+			if byteRate > 0 {
+				info.Duration = time.Duration(float64(size) / float64(byteRate) * float64(time.Second))
+			}
+			return
+		default:
+			if _, err := r.Seek(int64(size+size%2), io.SeekCurrent); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// probeMP4 reads the duration from the movie header (mvhd) and the
+// resolution from the largest track header (tkhd), then derives the
+// average bitrate from the file size
+func probeMP4(r io.ReadSeeker, info *Info) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+	moov, ok := findBox(r, 0, end, "moov")
+	if !ok {
+		return
+	}
+
+	for _, child := range boxes(r, moov.body, moov.end) {
+		switch child.kind {
+		case "mvhd":
+			probeMVHD(r, child, info)
+		case "trak":
+			if tkhd, ok := findBox(r, child.body, child.end, "tkhd"); ok {
+				probeTKHD(r, tkhd, info)
+			}
+		}
+	}
+	if info.Duration > 0 {
+		info.Bitrate = int64(float64(end*8) / info.Duration.Seconds())
+	}
+}
+
+// box is an ISO base media file box: its type and where its body and the
+// box itself end
+type box struct {
+	kind      string
+	body, end int64
+}
+
+// boxes lists the boxes between start and end
+func boxes(r io.ReadSeeker, start, end int64) []box {
+	var list []box
+	for pos := start; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return list
+		}
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return list
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		body := pos + 8
+		switch size {
+		case 0: // Extends to the end
+			size = end - pos
+		case 1: // 64-bit size follows the type
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return list
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			body += 8
+		}
+		if size < body-pos || pos+size > end {
+			return list
+		}
+		list = append(list, box{kind: string(header[4:8]), body: body, end: pos + size})
+		pos += size
+	}
+	return list
+}
+
+// findBox returns the first box of kind between start and end
+func findBox(r io.ReadSeeker, start, end int64, kind string) (box, bool) {
+	for _, b := range boxes(r, start, end) {
+		if b.kind == kind {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+// readBody reads up to n bytes of a box body
+func readBody(r io.ReadSeeker, b box, n int) []byte {
+	if _, err := r.Seek(b.body, io.SeekStart); err != nil {
+		return nil
+	}
+	data := make([]byte, min(int64(n), b.end-b.body))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil
+	}
+	return data
+}
+
+// probeMVHD reads the movie duration, in version 0 or 1 layout
+func probeMVHD(r io.ReadSeeker, b box, info *Info) {
+	data := readBody(r, b, 32)
+	var timescale uint32
+	var duration uint64
+	switch {
+	case len(data) >= 20 && data[0] == 0:
+		timescale = binary.BigEndian.Uint32(data[12:16])
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	case len(data) >= 32 && data[0] == 1:
+		timescale = binary.BigEndian.Uint32(data[20:24])
+		duration = binary.BigEndian.Uint64(data[24:32])
+	}
+	if timescale > 0 {
+		info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+}
+
+// probeTKHD reads a track's display size, keeping the largest track seen;
+// audio tracks have none
+func probeTKHD(r io.ReadSeeker, b box, info *Info) {
+	data := readBody(r, b, 92)
+	offset := 76 // Width and height follow the matrix
+	if len(data) > 0 && data[0] == 1 {
+		offset = 88
+	}
+	if len(data) < offset+8 {
+		return
+	}
+	width := int(binary.BigEndian.Uint32(data[offset:offset+4]) >> 16)
+	height := int(binary.BigEndian.Uint32(data[offset+4:offset+8]) >> 16)
+	if width*height > info.Width*info.Height {
+		info.Width, info.Height = width, height
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
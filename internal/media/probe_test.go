@@ -0,0 +1,140 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemp(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestProbe_Images(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	encoders := map[string]func(*bytes.Buffer) error{
+		"image/png":  func(b *bytes.Buffer) error { return png.Encode(b, img) },
+		"image/gif":  func(b *bytes.Buffer) error { return gif.Encode(b, img, nil) },
+		"image/jpeg": func(b *bytes.Buffer) error { return jpeg.Encode(b, img, nil) },
+	}
+	for mime, encode := range encoders {
+		var buf bytes.Buffer
+		require.NoError(t, encode(&buf))
+
+		info, err := Probe(writeTemp(t, "image", buf.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, Info{Type: mime, Width: 64, Height: 48}, info, mime)
+		assert.Equal(t, mime+", 64×48", info.String())
+	}
+}
+
+func TestProbe_WAV(t *testing.T) {
+	// One second of 16-bit stereo at 44.1 kHz
+	var buf bytes.Buffer
+	w := func(v interface{}) { require.NoError(t, binary.Write(&buf, binary.LittleEndian, v)) }
+	dataSize := uint32(44100 * 4)
+	buf.WriteString("RIFF")
+	w(uint32(36 + dataSize))
+	buf.WriteString("WAVEfmt ")
+	w(uint32(16))
+	w(uint16(1))         // PCM
+	w(uint16(2))         // Channels
+	w(uint32(44100))     // Sample rate
+	w(uint32(44100 * 4)) // Byte rate
+	w(uint16(4))         // Block align
+	w(uint16(16))        // Bits per sample
+	buf.WriteString("data")
+	w(dataSize)
+	buf.Write(make([]byte, dataSize))
+
+	info, err := Probe(writeTemp(t, "a.wav", buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "audio/wave", info.Type)
+	assert.Equal(t, time.Second, info.Duration)
+	assert.Equal(t, int64(1411200), info.Bitrate)
+	assert.Equal(t, "audio/wave, 1s, 1.4 Mbit/s", info.String())
+}
+
+// mp4Box wraps body in a box of kind
+func mp4Box(kind string, body ...[]byte) []byte {
+	data := bytes.Join(body, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(data)))
+	copy(header[4:], kind)
+	return append(header, data...)
+}
+
+func TestProbe_MP4(t *testing.T) {
+	// mvhd version 0: 90 seconds at timescale 1000
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 90000)
+
+	// tkhd version 0 with a 1920x1080 display size (16.16 fixed point)
+	video := make([]byte, 84)
+	binary.BigEndian.PutUint32(video[76:], 1920<<16)
+	binary.BigEndian.PutUint32(video[80:], 1080<<16)
+	audio := make([]byte, 84)
+
+	ftyp := mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	moov := mp4Box("moov",
+		mp4Box("mvhd", mvhd),
+		mp4Box("trak", mp4Box("tkhd", audio)),
+		mp4Box("trak", mp4Box("tkhd", video)),
+	)
+	mdat := mp4Box("mdat", make([]byte, 4096))
+	file := bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+
+	info, err := Probe(writeTemp(t, "a.mp4", file))
+	require.NoError(t, err)
+	assert.Equal(t, "video/mp4", info.Type)
+	assert.Equal(t, 1920, info.Width)
+	assert.Equal(t, 1080, info.Height)
+	assert.Equal(t, 90*time.Second, info.Duration)
+	assert.Equal(t, int64(len(file)*8/90), info.Bitrate)
+	assert.Equal(t, "video/mp4, 1920×1080, 1m30s, 395 bit/s", info.String())
+
+	// QuickTime files are told apart by their brand
+	mov := append(mp4Box("ftyp", []byte("qt  \x00\x00\x02\x00qt  ")), moov...)
+	info, err = Probe(writeTemp(t, "a.mov", mov))
+	require.NoError(t, err)
+	assert.Equal(t, "video/quicktime", info.Type)
+	assert.Equal(t, 90*time.Second, info.Duration)
+}
+
+func TestProbe_Other(t *testing.T) {
+	info, err := Probe(writeTemp(t, "a.txt", []byte("hello, world\n")))
+	require.NoError(t, err)
+	assert.Equal(t, Info{Type: "text/plain"}, info)
+
+	info, err = Probe(writeTemp(t, "empty", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", info.Type)
+
+	// Truncated headers leave the properties unknown
+	info, err = Probe(writeTemp(t, "a.png", []byte("\x89PNG\r\n\x1a\n")))
+	require.NoError(t, err)
+	assert.Equal(t, Info{Type: "image/png"}, info)
+
+	_, err = Probe(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestFormatBitrate(t *testing.T) {
+	assert.Equal(t, "128 kbit/s", formatBitrate(128000))
+	assert.Equal(t, "8.2 Mbit/s", formatBitrate(8_200_000))
+	assert.Equal(t, "400 bit/s", formatBitrate(400))
+}
@@ -1,41 +1,183 @@
 package models
 
-import "time"
+import (
+	"io"
+	"regexp"
+	"time"
+)
 
 // FileInfo represents information about a scanned file
 type FileInfo struct {
-	Path      string    // Full path to the file
-	Directory string    // Root directory this file belongs to
-	Size      int64     // File size in bytes
-	ModTime   time.Time // Modification time
-	Hash      string    // xxHash hash (computed lazily)
+	Path        string    `json:"path"`                   // Full path to the file
+	Directory   string    `json:"directory"`              // Root directory this file belongs to
+	Size        int64     `json:"size"`                   // File size in bytes
+	ModTime     time.Time `json:"mtime"`                  // Modification time
+	Hash        string    `json:"hash,omitempty"`         // xxHash hash (computed lazily)
+	PartialHash string    `json:"partial_hash,omitempty"` // xxHash of the first and last blocks (computed lazily)
+	Device      uint64    `json:"device,omitempty"`       // Device holding the file, where the platform reports it
+	Inode       uint64    `json:"inode,omitempty"`        // Inode on Device, shared by hardlinks (0 if unknown)
+	Offline     bool      `json:"offline,omitempty"`      // Listed in a catalog of a volume that is not attached, so never opened
+	Checksum    string    `json:"checksum,omitempty"`     // Digest recorded by a checksum file, as algorithm:hex (see checksum.Value)
+	SizeUnknown bool      `json:"size_unknown,omitempty"` // Listed by a checksum file, which records no size, so Size is 0
+}
+
+// SameStorage reports whether f and other are known, from their recorded
+// device and inode, to be hardlinks of one file. It is false when either
+// inode is unknown.
+func (f FileInfo) SameStorage(other FileInfo) bool {
+	return f.Inode != 0 && f.Inode == other.Inode && f.Device == other.Device
 }
 
 // ScanOptions contains configuration for file scanning
 type ScanOptions struct {
-	Directories []string // Directories to scan
-	Recursive   bool     // Search directories recursively
-	MinSize     int64    // Minimum file size in bytes to consider
-	Extensions  []string // File extensions to filter (empty = all files)
-	MaxDepth    int      // Maximum directory depth (-1 = unlimited)
-	CompareHash bool     // Whether to compare file content using hash
-	NumWorkers  int      // Number of parallel workers
+	Directories       []string          // Directories to scan
+	Recursive         bool              // Search directories recursively
+	MinSize           int64             // Minimum file size in bytes to consider
+	Extensions        []string          // File extensions to filter (empty = all files)
+	Includes          []string          // File name globs to filter, such as IMG_*.jpg (empty = all files)
+	NameRegex         *regexp.Regexp    // Only files whose slash-separated path relative to their root matches (nil = all files)
+	ExcludeRegex      *regexp.Regexp    // Skip files whose slash-separated path relative to their root matches (nil = none)
+	MaxDepth          int               // Maximum directory depth (-1 = unlimited)
+	CompareHash       bool              // Whether to compare file content using hash
+	Verify            string            // What hash-equal files must also pass: "hash" ("" is the same) or "bytes", a byte-by-byte comparison
+	Match             string            // How files are paired across directories: "name" ("" is the same), "relpath", "content", "fuzzy" or "audio"
+	FuzzyDistance     int               // Edits by which reduced names may still differ with Match "fuzzy" (0 = none)
+	Perceptual        bool              // Pair images that look alike by perceptual hash instead of matching files
+	PerceptualLimit   int               // Bits by which the perceptual hashes of paired images may differ
+	NormalizeNames    bool              // Compare names in composed Unicode form (NFC), so decomposed names from macOS match
+	NumWorkers        int               // Number of parallel workers
+	LargeFileSize     int64             // Size from which files are large: hashed in resumable chunks with progress (0 = no file is large)
+	SampleLarge       bool              // Compare same-sized large files by sampled regions before hashing them
+	HashStateDir      string            // Directory keeping the progress of interrupted large-file hashes ("" = not kept)
+	LargeProgress     io.Writer         // Receives a line each time another tenth of a large file is hashed (nil = none)
+	WalkWorkers       int               // Directories of each root read at once while walking (0 or 1 = one at a time)
+	SkipPairs         [][2]string       // Directory pairs excluded from comparison
+	MaxMatchesPerPair int               // Stop listing matches for a pair after this many (0 = unlimited)
+	MaxTotalMatches   int               // Stop comparing once this many matches are found overall (0 = unlimited)
+	SkipCacheDirs     bool              // Skip package-manager and cache directories (node_modules, .cache, ...)
+	FollowSymlinks    bool              // Follow links to directories inside the scanned trees, scanning each directory once
+	SymlinkFiles      string            // How links to files are handled: "skip" ("" is the same) or "target"
+	SkipHidden        bool              // Skip hidden files and directories (dot names on Unix, the hidden attribute on Windows)
+	ExcludeDirs       []string          // Directory name patterns never descended into, at any depth
+	UseDupignore      bool              // Skip files and directories matched by .dupignore files while walking
+	Labels            map[string]string // Short display names keyed by absolute root directory
+	TimeFormat        string            // Timestamp format for interactive display
+	SoftMaxFiles      int               // Ask before walking more than this many files (0 = no limit)
+	SoftMaxDuration   time.Duration     // Ask once scanning takes longer than this (0 = no limit)
+	SessionBudget     time.Duration     // Move on to confirmation once an interactive session takes this long (0 = no limit)
+	Priorities        map[string]int    // Keep priority per directory for suggestions and policies (lower wins)
+	Protected         []string          // Directories whose files batch and policy decisions never remove
+	MinAge            time.Duration     // Files modified more recently than this are never deleted by batch or policy decisions
+	AutoHash          bool              // Verify each interactive set by hash before showing it
+	FullScreen        bool              // Run interactive sessions in the full-screen interface when the terminal allows it
+	VerifySample      float64           // Percent of kept files to re-hash after deletions (0 = off)
+	Explain           bool              // Show the rule or choice behind each planned deletion
+	SkipRules         []string          // Interactive sets matching one of these rules are skipped ("under DIR", "name GLOB", "newer AGE", "older AGE")
+	RequireHash       bool              // Never delete a file from a set whose contents were not hash-verified
+	Quarantine        string            // Move files chosen for deletion into this directory instead of removing them ("" = remove)
+	Trash             bool              // Move files chosen for deletion to the trash of the user instead of removing them
+	Symlink           string            // Replace files chosen for deletion with symlinks to the kept file, "relative" or "absolute" ("" = remove)
+	Checkpoint        CheckpointFunc    // Called with the decisions of an interactive session before each prompt (nil = none)
+	Resume            *SessionProgress  // Decisions of an interrupted interactive session to continue from (nil = start afresh)
+}
+
+// ScanError is a path the scan could not read, so any files at or under it
+// are missing from the results
+type ScanError struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`    // What failed: lstat, readdir, read .dupignore or panic
+	Err  string `json:"error"` // The error message
+}
+
+// Stages of a run recorded in Failure.Stage
+const (
+	StageScan   = "scan"   // Scanning a root directory
+	StagePass   = "pass"   // A verification pass over every pair
+	StageWorker = "worker" // A worker processing one file or pair, which panicked
+)
+
+// Failure is a part of a run that failed while the rest completed, so the
+// results lack what it would have found: the pairs of a root that could not
+// be scanned, or the checks of a pass that did not finish
+type Failure struct {
+	Stage  string `json:"stage"`
+	Target string `json:"target"` // Root directory, pass name, or path of the file or pair
+	Err    string `json:"error"`
 }
 
 // PairComparison represents the result of comparing two directories
 type PairComparison struct {
-	Dir1    string      // First directory path
-	Dir2    string      // Second directory path
-	Matches []FileMatch // Files that match by name
+	Dir1      string      `json:"dir1"`                // First directory path
+	Dir2      string      `json:"dir2"`                // Second directory path
+	Matches   []FileMatch `json:"matches"`             // Files that match by name
+	Truncated bool        `json:"truncated,omitempty"` // Whether matches were cut off by a match limit
 }
 
 // FileMatch represents a pair of files with the same name
 type FileMatch struct {
-	Filename    string   // Base filename
-	File1       FileInfo // File from first directory
-	File2       FileInfo // File from second directory
-	HashChecked bool     // Whether hash comparison was performed
-	HashMatch   bool     // Whether hashes match (only meaningful if HashChecked)
+	Filename    string         `json:"filename"`             // Base filename
+	File1       FileInfo       `json:"file1"`                // File from first directory
+	File2       FileInfo       `json:"file2"`                // File from second directory
+	HashChecked bool           `json:"hash_checked"`         // Whether hash comparison was performed
+	HashMatch   bool           `json:"hash_match"`           // Whether hashes match (only meaningful if HashChecked)
+	Verified    string         `json:"verified,omitempty"`   // Strongest check the pair passed (see Check* constants)
+	Mismatch    string         `json:"mismatch,omitempty"`   // Check that showed the files differ ("" if none)
+	Hardlinked  bool           `json:"hardlinked,omitempty"` // Both paths are links to the same file, so removing one frees nothing
+	Distance    int            `json:"distance,omitempty"`   // Bits by which the perceptual hashes of two similar images differ
+	AlsoIn      []MatchContext `json:"also_in,omitempty"`    // Other directory pairs that found the same two files
+	Note        string         `json:"note,omitempty"`       // Note attached to the two files during interactive review
+}
+
+// MatchContext is a directory pair through which a match was also found,
+// with the paths of the two files as seen from that pair
+type MatchContext struct {
+	Dir1  string `json:"dir1"`
+	Dir2  string `json:"dir2"`
+	Path1 string `json:"path1"`
+	Path2 string `json:"path2"`
+}
+
+// Checks recorded in FileMatch.Verified and FileMatch.Mismatch, weakest first
+const (
+	CheckSize    = "size"
+	CheckPartial = "partial hash"
+	CheckHash    = "hash"
+	CheckSample  = "sampled bytes"
+	CheckBytes   = "bytes"
+)
+
+// Checks recorded in FileMatch.Verified for files that are alike without
+// being known to be identical
+const (
+	CheckPerceptual = "perceptual hash" // Images that look alike
+	CheckAudio      = "audio hash"      // Audio files with the same audio stream, whatever their tags
+)
+
+// IsDuplicate reports whether the match still counts as a duplicate:
+// no check found a difference and, if hashed, the hashes are identical.
+// Hardlinked matches are already deduplicated and never count.
+func (m FileMatch) IsDuplicate() bool {
+	return !m.Hardlinked && m.Mismatch == "" && (!m.HashChecked || m.HashMatch)
+}
+
+// Offline reports whether either file of m is on an offline volume, so the
+// match can only be checked by what its catalog records
+func (m FileMatch) Offline() bool {
+	return m.File1.Offline || m.File2.Offline
+}
+
+// ResultsVersion is the current version of the saved results document
+const ResultsVersion = 1
+
+// Results is the saved form of a run, as written by --format json
+type Results struct {
+	Version     int               `json:"version"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	RunID       string            `json:"run_id,omitempty"` // Run that wrote the document (see runid)
+	Labels      map[string]string `json:"labels,omitempty"` // Root directory -> display label
+	ScanErrors  []ScanError       `json:"scan_errors,omitempty"`
+	Failures    []Failure         `json:"failures,omitempty"`
+	Comparisons []PairComparison  `json:"comparisons"`
 }
 
 // DuplicateSet represents files that are duplicates based on hash
@@ -44,15 +186,19 @@ type DuplicateSet struct {
 	Files        []FileInfo // All duplicate files
 	Hash         string     // Common hash value (empty until computed)
 	HashComputed bool       // Whether hash has been calculated
+	Note         string     // Note attached during interactive review ("" if none)
 }
 
 // UserAction represents the user's decision
 type UserAction struct {
-	Action          string // "skip", "delete", "batch_delete_by_dir", or "compute_hash"
-	KeepFile        string // Path of file to keep (for delete action)
-	DeleteFile      string // Path of file to delete (for delete action)
-	KeepDirectory   string // Directory to keep (for batch_delete_by_dir)
-	DeleteDirectory string // Directory to delete from (for batch_delete_by_dir)
+	Action          string `json:"action"`                     // "skip", "delete", "keep", "batch_delete_by_dir", "compute_hash", "annotate", or "skip_rule"
+	KeepFile        string `json:"keep_file,omitempty"`        // Path of file to keep (for delete and keep actions; keep deletes the rest of the set)
+	DeleteFile      string `json:"delete_file,omitempty"`      // Path of file to delete (for delete action)
+	KeepDirectory   string `json:"keep_directory,omitempty"`   // Directory to keep (for batch_delete_by_dir)
+	DeleteDirectory string `json:"delete_directory,omitempty"` // Directory to delete from (for batch_delete_by_dir)
+	Reason          string `json:"reason,omitempty"`           // What produced a delete action: the user's choice or the batch rule
+	Note            string `json:"note,omitempty"`             // Note to attach to the set (for annotate; "" removes it)
+	Rule            string `json:"rule,omitempty"`             // Skip rule for this and later sets, e.g. "under /archive/2019" (for skip_rule)
 }
 
 // DeletionResult tracks deletion outcome
@@ -71,4 +217,48 @@ type SessionSummary struct {
 	FilesFailed   int
 	SpaceFreed    int64
 	Results       []DeletionResult
+	Deferred      []DuplicateSet // Sets left undecided when the session budget ran out
+	Verification  *Verification  // Sampled check of kept files after deletion (nil when not requested)
+	Learned       Preferences    // Habits seen during the session, saved with --remember
+	Notes         []SetNote      // Notes added, changed or removed during the session, for later runs
+	Quarantine    string         // Directory the files were moved to instead of being removed ("" when removed)
+	Trash         bool           // The files were moved to the trash instead of being removed
+	Symlink       string         // Kind of symlink the files were replaced with instead of being removed ("" when removed)
+	SkipRules     []string       // Skip rules in effect at the end of the session, saved with deferred sets
+}
+
+// SetNote is a note attached to the two files of a duplicate set
+type SetNote struct {
+	Paths [2]string `json:"paths"` // Paths of the set's files
+	Text  string    `json:"text"`  // The note ("" when it was removed)
+}
+
+// SessionProgress is what an interactive session has decided so far,
+// saved so an interrupted session can continue from the next undecided set
+type SessionProgress struct {
+	Decided      int          `json:"decided"`                  // Sets decided, in order; the session continues from the next one
+	Skipped      int          `json:"skipped,omitempty"`        // Sets among them passed over by skip rules
+	Actions      []UserAction `json:"actions,omitempty"`        // Deletions chosen and awaiting confirmation
+	BatchKeepDir string       `json:"batch_keep_dir,omitempty"` // Directory kept for all remaining sets once batch mode was chosen
+	SkipRules    []string     `json:"skip_rules,omitempty"`     // Skip rules in effect
+	Notes        []SetNote    `json:"notes,omitempty"`          // Notes written so far
+}
+
+// CheckpointFunc receives the progress of an interactive session each
+// time it waits for the user
+type CheckpointFunc func(SessionProgress)
+
+// Preferences are interactive habits remembered between sessions
+type Preferences struct {
+	PreferredDir string `json:"preferred_dir,omitempty"` // Root directory whose copies the user usually keeps
+	AutoHash     bool   `json:"auto_hash,omitempty"`     // Verify each set by hash before showing it
+}
+
+// Verification is the outcome of re-hashing a sample of kept files after
+// their duplicates were deleted
+type Verification struct {
+	Candidates int      // Completed deletions the sample was drawn from
+	Sampled    int      // Kept files re-hashed
+	Matched    int      // Kept files whose hash matched the deleted copy's recorded hash
+	Failed     []string // Kept files that were missing, unreadable or changed
 }
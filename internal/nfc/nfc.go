@@ -0,0 +1,75 @@
+// Package nfc composes decomposed Unicode text, so that names written in
+// the decomposed form macOS file systems use (NFD) compare equal to the
+// composed form used almost everywhere else (NFC).
+package nfc
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Hangul syllables are composed arithmetically from their jamo
+const (
+	hangulBase   = 0xAC00
+	leadBase     = 0x1100
+	vowelBase    = 0x1161
+	trailBase    = 0x11A7 // One before the first trailing consonant
+	leadCount    = 19
+	vowelCount   = 21
+	trailCount   = 28
+	syllableSpan = vowelCount * trailCount
+)
+
+// Compose returns s with each character and the combining characters that
+// directly follow it replaced by their canonical composite, as NFC does.
+// Text already composed is returned unchanged. Unlike full NFC, marks are
+// not reordered, and composition stops at the first mark that does not
+// compose, which gives the same result for the canonically ordered text
+// file systems store.
+func Compose(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var builder strings.Builder
+	builder.Grow(len(s))
+	starter, pending := rune(-1), false
+	for _, r := range s {
+		if pending {
+			if composed, ok := compose(starter, r); ok {
+				starter = composed
+				continue
+			}
+			builder.WriteRune(starter)
+		}
+		starter, pending = r, true
+	}
+	if pending {
+		builder.WriteRune(starter)
+	}
+	return builder.String()
+}
+
+// compose returns the canonical composite of a and b, if there is one
+func compose(a, b rune) (rune, bool) {
+	// Leading consonant and vowel
+	if a >= leadBase && a < leadBase+leadCount && b >= vowelBase && b < vowelBase+vowelCount {
+		return hangulBase + ((a-leadBase)*vowelCount+(b-vowelBase))*trailCount, true
+	}
+	// Syllable without a trailing consonant, and a trailing consonant
+	if a >= hangulBase && a < hangulBase+leadCount*syllableSpan && (a-hangulBase)%trailCount == 0 &&
+		b > trailBase && b < trailBase+trailCount {
+		return a + (b - trailBase), true
+	}
+	composed, ok := compositions[[2]rune{a, b}]
+	return composed, ok
+}
+
+// isASCII reports whether s has only ASCII characters, which never compose
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
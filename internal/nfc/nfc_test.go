@@ -0,0 +1,31 @@
+package nfc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		name       string
+		decomposed string
+		composed   string
+	}{
+		{"ascii", "photo.jpg", "photo.jpg"},
+		{"acute", "café.txt", "café.txt"},
+		{"capital umlaut", "Übersicht.pdf", "Übersicht.pdf"},
+		{"kana voiced marks", "がぱ.jpg", "がぱ.jpg"},
+		{"hangul", "한국", "한국"},
+		{"two marks", "Việt", "Việt"},
+		{"mark without composite", "ą́", "ą́"},
+		{"leading mark", "́e", "́e"},
+		{"already composed", "café 한국", "café 한국"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.composed, Compose(tt.decomposed))
+			assert.Equal(t, tt.composed, Compose(tt.composed))
+		})
+	}
+}
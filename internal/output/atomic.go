@@ -0,0 +1,58 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is written under a temporary name beside its destination and
+// renamed into place on Commit, so readers never see a partial file
+type AtomicFile struct {
+	*os.File
+	path string
+	done bool
+}
+
+// CreateAtomic starts writing the file at path
+func CreateAtomic(path string) (*AtomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".dup-finder-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create output file: %w", err)
+	}
+	return &AtomicFile{File: tmp, path: path}, nil
+}
+
+// Commit closes the file and moves it to its destination
+func (a *AtomicFile) Commit() error {
+	if a.done {
+		return nil
+	}
+	a.done = true
+	tmpPath := a.Name()
+
+	if err := a.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot write output file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot set output file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, a.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot move output file into place: %w", err)
+	}
+	return nil
+}
+
+// Abort discards the file, leaving any existing destination untouched.
+// It does nothing after Commit.
+func (a *AtomicFile) Abort() {
+	if a.done {
+		return
+	}
+	a.done = true
+	a.Close()
+	os.Remove(a.Name())
+}
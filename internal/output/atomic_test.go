@@ -0,0 +1,56 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomicFile_Commit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	f, err := CreateAtomic(path)
+	require.NoError(t, err)
+	_, err = f.WriteString("new")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data), "destination must not change before Commit")
+
+	require.NoError(t, f.Commit())
+	f.Abort()
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file should be left behind")
+}
+
+func TestAtomicFile_Abort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.txt")
+
+	f, err := CreateAtomic(path)
+	require.NoError(t, err)
+	_, err = f.WriteString("partial")
+	require.NoError(t, err)
+	f.Abort()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCreateAtomic_MissingDir(t *testing.T) {
+	_, err := CreateAtomic(filepath.Join(t.TempDir(), "missing", "results.txt"))
+	assert.Error(t, err)
+}
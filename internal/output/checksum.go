@@ -0,0 +1,40 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/checksum"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// FormatChecksums lists each file of the duplicate matches once, by
+// absolute path, in the checksum file format (see checksum.Formats), so
+// md5sum -c, sha256sum -c or an SFV checker can verify the copies later.
+// Files without a Checksum of the format's algorithm, such as files of
+// offline volumes whose catalog records none, are left out.
+func FormatChecksums(format string, comparisons []models.PairComparison) (string, error) {
+	algorithm := checksum.Algorithm(format)
+	var entries []checksum.Entry
+	seen := make(map[string]bool)
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			if !match.IsDuplicate() {
+				continue
+			}
+			for _, file := range []models.FileInfo{match.File1, match.File2} {
+				recorded, digest := checksum.Split(file.Checksum)
+				if recorded != algorithm || seen[file.Path] {
+					continue
+				}
+				seen[file.Path] = true
+				entries = append(entries, checksum.Entry{Path: absClean(file.Path), Algorithm: algorithm, Digest: digest})
+			}
+		}
+	}
+
+	var builder strings.Builder
+	if err := checksum.Write(&builder, format, entries); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
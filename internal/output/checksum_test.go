@@ -0,0 +1,35 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatChecksums(t *testing.T) {
+	a := models.FileInfo{Path: "/a/hello.txt", Checksum: "sha256:5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}
+	b := models.FileInfo{Path: "/b/hello.txt", Checksum: a.Checksum}
+	c := models.FileInfo{Path: "/c/hello.txt", Checksum: "crc32:363a3020"}
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{
+			{Filename: "hello.txt", File1: a, File2: b, HashChecked: true, HashMatch: true},
+			{Filename: "other.txt", File1: models.FileInfo{Path: "/a/other.txt"}, File2: models.FileInfo{Path: "/b/other.txt"}, Mismatch: models.CheckSize},
+		}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "hello.txt", File1: a, File2: c, HashChecked: true, HashMatch: true},
+		}},
+	}
+
+	result, err := FormatComparisons(FormatNameSHA256Sum, comparisons, Options{})
+	require.NoError(t, err)
+	// Each file once; c has no SHA-256 to list
+	assert.Equal(t, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  "+absClean("/a/hello.txt")+"\n"+
+		"5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  "+absClean("/b/hello.txt")+"\n", result)
+
+	result, err = FormatComparisons(FormatNameSFV, comparisons, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "; Written by dup-finder\n"+absClean("/c/hello.txt")+" 363A3020\n", result)
+}
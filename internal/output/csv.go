@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// csvHeader names the columns written by FormatCSV
+var csvHeader = []string{
+	"filename", "path1", "path2", "size1", "size2", "mtime1", "mtime2", "hash_checked", "hash_match",
+}
+
+// FormatCSV writes one row per match with a header row, for loading
+// results into a spreadsheet. Times are RFC 3339; hash_match is empty when
+// the hashes were not compared.
+func FormatCSV(comparisons []models.PairComparison) (string, error) {
+	var builder strings.Builder
+	w := csv.NewWriter(&builder)
+
+	if err := w.Write(csvHeader); err != nil {
+		return "", err
+	}
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			hashMatch := ""
+			if match.HashChecked {
+				hashMatch = strconv.FormatBool(match.HashMatch)
+			}
+			record := []string{
+				match.Filename,
+				match.File1.Path,
+				match.File2.Path,
+				strconv.FormatInt(match.File1.Size, 10),
+				strconv.FormatInt(match.File2.Size, 10),
+				match.File1.ModTime.Format(time.RFC3339),
+				match.File2.ModTime.Format(time.RFC3339),
+				strconv.FormatBool(match.HashChecked),
+				hashMatch,
+			}
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
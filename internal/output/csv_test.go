@@ -0,0 +1,58 @@
+package output
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatCSV(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/a",
+			Dir2: "/b",
+			Matches: []models.FileMatch{
+				{
+					Filename:    "photo, 1.jpg",
+					File1:       models.FileInfo{Path: "/a/photo, 1.jpg", Size: 1024, ModTime: mtime},
+					File2:       models.FileInfo{Path: "/b/photo, 1.jpg", Size: 1024, ModTime: mtime},
+					HashChecked: true,
+					HashMatch:   true,
+				},
+				{
+					Filename: "notes.txt",
+					File1:    models.FileInfo{Path: "/a/notes.txt", Size: 10, ModTime: mtime},
+					File2:    models.FileInfo{Path: "/b/notes.txt", Size: 10, ModTime: mtime},
+				},
+			},
+		},
+		{Dir1: "/a", Dir2: "/c"},
+	}
+
+	result, err := FormatCSV(comparisons)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(result)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{
+		"photo, 1.jpg", "/a/photo, 1.jpg", "/b/photo, 1.jpg", "1024", "1024",
+		"2024-03-01T12:00:00Z", "2024-03-01T12:00:00Z", "true", "true",
+	}, records[1])
+	assert.Equal(t, "false", records[2][7])
+	assert.Equal(t, "", records[2][8])
+}
+
+func TestFormatComparisons_CSV(t *testing.T) {
+	result, err := FormatComparisons(FormatNameCSV, nil, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join(csvHeader, ",")+"\n", result)
+}
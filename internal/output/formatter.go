@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Sho2010/dup-finder/internal/checksum"
 	"github.com/Sho2010/dup-finder/internal/models"
 )
 
@@ -12,14 +13,50 @@ type Formatter interface {
 	FormatPairComparison(comparison models.PairComparison) string
 }
 
+// Options controls how results are rendered
+type Options struct {
+	ShowHash   bool       // Show hash comparison results
+	Labels     Labels     // Short display names for root directories
+	TimeFormat TimeFormat // Timestamp format for reports
+	Explain    bool       // Annotate decisions with the detail behind each rule
+	Width      int        // Terminal width that text lines are fitted to; 0 to never truncate
+	Verbose    bool       // List the full paths of both files under each match
+
+	// Duplicate sets merged across directory pairs (see finder.MergeMatches).
+	// When set, text output lists each set once in place of the duplicates
+	// of every pair.
+	Sets []models.DuplicateSet
+
+	// Paths the scan could not read and parts of the run that failed,
+	// listed after the results
+	ScanErrors []models.ScanError
+	Failures   []models.Failure
+}
+
 // SimpleFormatter provides a simple text-based output format
 type SimpleFormatter struct {
 	showHash bool
+	labels   Labels
+	width    int
+	verbose  bool
 }
 
+// Bounds of the filename column. Names are padded to at least the minimum;
+// without a known terminal width, longer names overflow the maximum rather
+// than being truncated.
+const (
+	minNameColumn = 20
+	maxNameColumn = 40
+)
+
 // NewSimpleFormatter creates a new simple formatter
 func NewSimpleFormatter(showHash bool) *SimpleFormatter {
-	return &SimpleFormatter{showHash: showHash}
+	return NewSimpleFormatterWithOptions(Options{ShowHash: showHash})
+}
+
+// NewSimpleFormatterWithOptions creates a simple formatter from rendering options
+func NewSimpleFormatterWithOptions(opts Options) *SimpleFormatter {
+	return &SimpleFormatter{showHash: opts.ShowHash, labels: opts.Labels, width: opts.Width, verbose: opts.Verbose}
 }
 
 // FormatPairComparison formats a pair comparison result
@@ -27,34 +64,95 @@ func (sf *SimpleFormatter) FormatPairComparison(comparison models.PairComparison
 	var builder strings.Builder
 
 	// Header
-	builder.WriteString(fmt.Sprintf("=== %s ↔ %s ===\n", comparison.Dir1, comparison.Dir2))
+	builder.WriteString(fmt.Sprintf("=== %s ↔ %s ===\n", sf.labels.Dir(comparison.Dir1), sf.labels.Dir(comparison.Dir2)))
 
 	if len(comparison.Matches) == 0 {
 		builder.WriteString("(No duplicates)\n")
 		return builder.String()
 	}
 
-	// List each matching file
+	// List each matching file, with names in a column that fits the terminal
+	column := sf.nameColumn(comparison.Matches)
 	for _, match := range comparison.Matches {
-		if sf.showHash && match.HashChecked {
-			// Show hash comparison result
-			hashStatus := "✓ Identical"
-			if !match.HashMatch {
-				hashStatus = "✗ Different"
-			}
-			builder.WriteString(fmt.Sprintf("%-20s ✓ [Hash: %s]\n", match.Filename+":", hashStatus))
-		} else {
-			// Just show the filename match
-			builder.WriteString(fmt.Sprintf("%-20s ✓\n", match.Filename+":"))
+		name := match.Filename
+		if sf.width > 0 {
+			name = truncateMiddle(name, column-1)
+		}
+		builder.WriteString(fmt.Sprintf("%s ✓%s\n", padRight(name+":", column), sf.status(match)))
+		if sf.verbose {
+			builder.WriteString(fmt.Sprintf("    %s\n    %s\n", sf.labels.Path(match.File1.Path), sf.labels.Path(match.File2.Path)))
+		}
+		for _, c := range match.AlsoIn {
+			builder.WriteString(fmt.Sprintf("%s   also found in %s ↔ %s\n", padRight("", column), sf.labels.Dir(c.Dir1), sf.labels.Dir(c.Dir2)))
+		}
+		if match.Note != "" {
+			builder.WriteString(fmt.Sprintf("%s   note: %s\n", padRight("", column), match.Note))
 		}
 	}
 
+	if comparison.Truncated {
+		builder.WriteString("(Further matches omitted: match limit reached)\n")
+	}
+
 	return builder.String()
 }
 
+// nameColumn returns the width of the filename column for matches: wide
+// enough for the longest name with its colon, within the column bounds, and
+// narrow enough for the longest status to fit the terminal width
+func (sf *SimpleFormatter) nameColumn(matches []models.FileMatch) int {
+	longest, statusWidth := 0, 0
+	for _, match := range matches {
+		longest = max(longest, displayWidth(match.Filename)+1)
+		statusWidth = max(statusWidth, displayWidth(" ✓"+sf.status(match)))
+	}
+
+	column := max(longest, minNameColumn)
+	if sf.width <= 0 {
+		return min(column, maxNameColumn)
+	}
+	// Leave room for the status, but never squeeze names below a few
+	// characters on very narrow terminals
+	return max(min(column, sf.width-statusWidth-1), 8)
+}
+
+// status describes the content checks applied to a match
+func (sf *SimpleFormatter) status(match models.FileMatch) string {
+	switch {
+	case match.Hardlinked:
+		return " [Already hardlinked]"
+	case match.Mismatch != "" && match.Mismatch != models.CheckHash:
+		return fmt.Sprintf(" [✗ Different: %s]", match.Mismatch)
+	case sf.showHash && match.HashChecked:
+		// Show hash comparison result
+		hashStatus := "✓ Identical"
+		if !match.HashMatch {
+			hashStatus = "✗ Different"
+		} else if match.Verified == models.CheckBytes {
+			hashStatus = "✓ Identical, bytes verified"
+		}
+		return fmt.Sprintf(" [Hash: %s]", hashStatus)
+	case match.Verified == models.CheckPerceptual:
+		return fmt.Sprintf(" [Similar image: %d of 64 bits differ]", match.Distance)
+	case match.Verified != "":
+		return fmt.Sprintf(" [Verified: %s]", match.Verified)
+	default:
+		// Just show the filename match
+		return ""
+	}
+}
+
 // FormatAllComparisons formats all pair comparisons
 func FormatAllComparisons(comparisons []models.PairComparison, showHash bool) string {
-	formatter := NewSimpleFormatter(showHash)
+	return formatAll(comparisons, Options{ShowHash: showHash})
+}
+
+// formatAll formats all pair comparisons as text with the given options
+func formatAll(comparisons []models.PairComparison, opts Options) string {
+	if len(opts.Sets) > 0 {
+		return formatSetsAndRest(comparisons, opts)
+	}
+	formatter := NewSimpleFormatterWithOptions(opts)
 	var builder strings.Builder
 
 	for i, comparison := range comparisons {
@@ -65,5 +163,113 @@ func FormatAllComparisons(comparisons []models.PairComparison, showHash bool) st
 		}
 	}
 
+	if pairs, size := hardlinkedTotals(comparisons); pairs > 0 {
+		builder.WriteString(fmt.Sprintf("\nAlready hardlinked: %s pair(s), %s shared\n", FormatCount(pairs), FormatSize(size)))
+	}
+	writeProblems(&builder, opts)
+
 	return builder.String()
 }
+
+// writeProblems appends the scan errors and failures in opts, if any,
+// each list after a blank line
+func writeProblems(builder *strings.Builder, opts Options) {
+	if len(opts.ScanErrors) > 0 {
+		builder.WriteString("\n" + FormatScanErrors(opts.ScanErrors))
+	}
+	if len(opts.Failures) > 0 {
+		builder.WriteString("\n" + FormatFailures(opts.Failures))
+	}
+}
+
+// FormatScanErrors lists the paths a scan could not read, whose files are
+// missing from the results, with the operation that failed and why
+func FormatScanErrors(scanErrors []models.ScanError) string {
+	if len(scanErrors) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Skipped %s path(s) that could not be read:\n", FormatCount(len(scanErrors))))
+	for _, scanErr := range scanErrors {
+		builder.WriteString(fmt.Sprintf("  %s  %s: %s\n", scanErr.Path, scanErr.Op, scanErr.Err))
+	}
+	return builder.String()
+}
+
+// FormatFailures lists the parts of a run that failed while the rest
+// completed, so the results are incomplete
+func FormatFailures(failures []models.Failure) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Incomplete: %s part(s) of the run failed:\n", FormatCount(len(failures))))
+	for _, failure := range failures {
+		builder.WriteString(fmt.Sprintf("  %s %s: %s\n", failure.Stage, failure.Target, failure.Err))
+	}
+	return builder.String()
+}
+
+// Output formats selectable with --format
+const (
+	FormatNameText        = "text"
+	FormatNameJSON        = "json"
+	FormatNameFileManager = "filemanager"
+	FormatNameCSV         = "csv"
+	FormatNameNDJSON      = "ndjson"
+	FormatNameMarkdown    = "markdown"
+	FormatNameMD5Sum      = checksum.FormatMD5Sum
+	FormatNameSHA256Sum   = checksum.FormatSHA256Sum
+	FormatNameSFV         = checksum.FormatSFV
+)
+
+// FormatNames lists the supported output formats
+var FormatNames = []string{FormatNameText, FormatNameJSON, FormatNameFileManager, FormatNameCSV, FormatNameNDJSON, FormatNameMarkdown,
+	FormatNameMD5Sum, FormatNameSHA256Sum, FormatNameSFV}
+
+// ValidateFormat checks that format names a supported output format
+func ValidateFormat(format string) error {
+	for _, name := range FormatNames {
+		if format == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(FormatNames, ", "))
+}
+
+// FormatComparisons renders comparisons in the named output format
+func FormatComparisons(format string, comparisons []models.PairComparison, opts Options) (string, error) {
+	switch format {
+	case FormatNameJSON:
+		return FormatJSON(comparisons, opts)
+	case FormatNameText:
+		return formatAll(comparisons, opts), nil
+	case FormatNameFileManager:
+		return FormatURIs(comparisons), nil
+	case FormatNameCSV:
+		return FormatCSV(comparisons)
+	case FormatNameNDJSON:
+		return FormatNDJSON(comparisons)
+	case FormatNameMarkdown:
+		return FormatMarkdown(comparisons, opts), nil
+	case FormatNameMD5Sum, FormatNameSHA256Sum, FormatNameSFV:
+		return FormatChecksums(format, comparisons)
+	default:
+		return "", ValidateFormat(format)
+	}
+}
+
+// hardlinkedTotals counts hardlinked matches and the bytes they share
+func hardlinkedTotals(comparisons []models.PairComparison) (int, int64) {
+	var pairs int
+	var size int64
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			if match.Hardlinked {
+				pairs++
+				size += match.File1.Size
+			}
+		}
+	}
+	return pairs, size
+}
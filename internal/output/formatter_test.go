@@ -1,10 +1,12 @@
 package output
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/Sho2010/dup-finder/internal/models"
 )
@@ -136,3 +138,207 @@ func TestFormatAllComparisons_WithoutHash(t *testing.T) {
 	assert.Contains(t, result, "✓")
 	assert.NotContains(t, result, "Hash:")
 }
+
+func TestSimpleFormatter_FormatPairComparison_Truncated(t *testing.T) {
+	formatter := NewSimpleFormatter(false)
+	comparison := models.PairComparison{
+		Dir1:      "/dir1",
+		Dir2:      "/dir2",
+		Matches:   []models.FileMatch{{Filename: "file1.txt"}},
+		Truncated: true,
+	}
+
+	result := formatter.FormatPairComparison(comparison)
+
+	assert.Contains(t, result, "file1.txt:")
+	assert.Contains(t, result, "match limit reached")
+}
+
+func TestFormatComparisons_JSON(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/dir1",
+			Dir2: "/dir2",
+			Matches: []models.FileMatch{
+				{
+					Filename:    "file1.txt",
+					File1:       models.FileInfo{Path: "/dir1/file1.txt", Size: 10},
+					File2:       models.FileInfo{Path: "/dir2/file1.txt", Size: 10},
+					HashChecked: true,
+					HashMatch:   true,
+				},
+			},
+		},
+		{Dir1: "/dir1", Dir2: "/dir3"},
+	}
+
+	result, err := FormatComparisons(FormatNameJSON, comparisons, Options{ShowHash: true})
+	require.NoError(t, err)
+
+	var decoded models.Results
+	require.NoError(t, json.Unmarshal([]byte(result), &decoded))
+	assert.Equal(t, models.ResultsVersion, decoded.Version)
+	require.Len(t, decoded.Comparisons, 2)
+	assert.Equal(t, "/dir2/file1.txt", decoded.Comparisons[0].Matches[0].File2.Path)
+	assert.True(t, decoded.Comparisons[0].Matches[0].HashMatch)
+
+	// Pairs without matches are written as an empty list, not null
+	assert.Contains(t, result, `"matches": []`)
+}
+
+func TestFormatComparisons_ScanErrorsAndFailures(t *testing.T) {
+	comparisons := []models.PairComparison{{Dir1: "/dir1", Dir2: "/dir2"}}
+	scanErrors := []models.ScanError{{Path: "/dir1/locked", Op: "readdir", Err: "permission denied"}}
+	failures := []models.Failure{{Stage: models.StageScan, Target: "/dir3", Err: "input/output error"}}
+	opts := Options{ScanErrors: scanErrors, Failures: failures}
+
+	text, err := FormatComparisons(FormatNameText, comparisons, opts)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Skipped 1 path(s) that could not be read:\n  /dir1/locked  readdir: permission denied\n")
+	assert.Contains(t, text, "Incomplete: 1 part(s) of the run failed:\n  scan /dir3: input/output error\n")
+	summary := FormatSummary(comparisons, opts)
+	assert.Contains(t, summary, "/dir1/locked  readdir: permission denied")
+	assert.Contains(t, summary, "scan /dir3: input/output error")
+
+	result, err := FormatComparisons(FormatNameJSON, comparisons, opts)
+	require.NoError(t, err)
+	var decoded models.Results
+	require.NoError(t, json.Unmarshal([]byte(result), &decoded))
+	assert.Equal(t, scanErrors, decoded.ScanErrors)
+	assert.Equal(t, failures, decoded.Failures)
+
+	// Without errors, neither output mentions them
+	text, err = FormatComparisons(FormatNameText, comparisons, Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, text, "Skipped")
+	assert.NotContains(t, text, "Incomplete")
+	result, err = FormatComparisons(FormatNameJSON, comparisons, Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "scan_errors")
+	assert.NotContains(t, result, "failures")
+}
+
+func TestFormatComparisons_UnknownFormat(t *testing.T) {
+	_, err := FormatComparisons("yaml", nil, Options{})
+	assert.Error(t, err)
+	assert.Error(t, ValidateFormat("yaml"))
+	assert.NoError(t, ValidateFormat(FormatNameText))
+}
+
+func TestSimpleFormatter_FormatPairComparison_Verification(t *testing.T) {
+	formatter := NewSimpleFormatter(true)
+	comparison := models.PairComparison{
+		Dir1: "/dir1",
+		Dir2: "/dir2",
+		Matches: []models.FileMatch{
+			{Filename: "size.txt", Mismatch: models.CheckSize},
+			{Filename: "partial.txt", Verified: models.CheckPartial},
+			{Filename: "bytes.txt", HashChecked: true, HashMatch: true, Verified: models.CheckBytes},
+			{Filename: "photo.jpg ↔ photo_small.jpg", Verified: models.CheckPerceptual, Distance: 3},
+		},
+	}
+
+	result := formatter.FormatPairComparison(comparison)
+
+	assert.Contains(t, result, "[✗ Different: size]")
+	assert.Contains(t, result, "[Verified: partial hash]")
+	assert.Contains(t, result, "[Hash: ✓ Identical, bytes verified]")
+	assert.Contains(t, result, "[Similar image: 3 of 64 bits differ]")
+}
+
+func TestSimpleFormatter_FormatPairComparison_Labels(t *testing.T) {
+	formatter := NewSimpleFormatterWithOptions(Options{
+		Labels: Labels{"/mnt/nas/photos": "nas"},
+	})
+	comparison := models.PairComparison{
+		Dir1:    "/mnt/nas/photos",
+		Dir2:    "/home/me/Pictures",
+		Matches: []models.FileMatch{},
+	}
+
+	result := formatter.FormatPairComparison(comparison)
+
+	assert.Contains(t, result, "nas ↔ /home/me/Pictures")
+	assert.NotContains(t, result, "/mnt/nas/photos")
+}
+
+func TestFormatAllComparisons_Hardlinked(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/dir1",
+			Dir2: "/dir2",
+			Matches: []models.FileMatch{
+				{
+					Filename:   "linked.jpg",
+					File1:      models.FileInfo{Path: "/dir1/linked.jpg", Size: 2048},
+					File2:      models.FileInfo{Path: "/dir2/linked.jpg", Size: 2048},
+					Hardlinked: true,
+				},
+				{Filename: "copy.jpg"},
+			},
+		},
+	}
+
+	result := FormatAllComparisons(comparisons, true)
+
+	assert.Contains(t, result, "linked.jpg:          ✓ [Already hardlinked]")
+	assert.Contains(t, result, "Already hardlinked: 1 pair(s), 2.0 KiB shared")
+	assert.Contains(t, FormatURIs(comparisons), "# already hardlinked\nfile:///dir1/linked.jpg\n")
+}
+
+func TestSimpleFormatter_FormatPairComparison_AlsoIn(t *testing.T) {
+	formatter := NewSimpleFormatterWithOptions(Options{Labels: Labels{"/link": "alias"}})
+	comparison := models.PairComparison{
+		Dir1: "/a",
+		Dir2: "/b",
+		Matches: []models.FileMatch{{
+			Filename: "photo.jpg",
+			AlsoIn:   []models.MatchContext{{Dir1: "/b", Dir2: "/link"}},
+		}},
+	}
+
+	result := formatter.FormatPairComparison(comparison)
+	assert.Contains(t, result, "photo.jpg:           ✓\n")
+	assert.Contains(t, result, "also found in /b ↔ alias\n")
+}
+
+func TestSimpleFormatter_FormatPairComparison_Note(t *testing.T) {
+	formatter := NewSimpleFormatter(false)
+	comparison := models.PairComparison{
+		Dir1:    "/a",
+		Dir2:    "/b",
+		Matches: []models.FileMatch{{Filename: "photo.jpg", Note: "check later"}},
+	}
+
+	result := formatter.FormatPairComparison(comparison)
+	assert.Contains(t, result, "photo.jpg:           ✓\n"+strings.Repeat(" ", 20)+"   note: check later\n")
+}
+
+func TestSimpleFormatter_ColumnLayout(t *testing.T) {
+	long := "IMG_20240101_123456_holiday_in_the_mountains_final_edit.jpg"
+	comparison := models.PairComparison{
+		Dir1: "/a",
+		Dir2: "/b",
+		Matches: []models.FileMatch{
+			{Filename: "short.jpg", File1: models.FileInfo{Path: "/a/short.jpg"}, File2: models.FileInfo{Path: "/b/short.jpg"}},
+			{Filename: long, File1: models.FileInfo{Path: "/a/" + long}, File2: models.FileInfo{Path: "/b/" + long}},
+		},
+	}
+
+	// Without a terminal width, names are never cut
+	result := NewSimpleFormatterWithOptions(Options{}).FormatPairComparison(comparison)
+	assert.Contains(t, result, "short.jpg:"+strings.Repeat(" ", 30)+" ✓\n")
+	assert.Contains(t, result, long+": ✓\n")
+
+	// With one, lines fit and long names lose their middle
+	result = NewSimpleFormatterWithOptions(Options{Width: 40}).FormatPairComparison(comparison)
+	for _, line := range strings.Split(strings.TrimSuffix(result, "\n"), "\n") {
+		assert.LessOrEqual(t, displayWidth(line), 40, line)
+	}
+	assert.Contains(t, result, "IMG_20240101_12345…ns_final_edit.jpg: ✓\n")
+	assert.Contains(t, result, "short.jpg:"+strings.Repeat(" ", 27)+" ✓\n")
+
+	// Verbose lists the full paths, untruncated
+	result = NewSimpleFormatterWithOptions(Options{Width: 40, Verbose: true}).FormatPairComparison(comparison)
+	assert.Contains(t, result, "    /a/"+long+"\n    /b/"+long+"\n")
+}
@@ -0,0 +1,37 @@
+package output
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// FormatJSON renders all comparisons as a versioned results document.
+// Labels in opts are saved so reports built from the document can use them,
+// and scan errors and failures so readers know what the results leave out.
+func FormatJSON(comparisons []models.PairComparison, opts Options) (string, error) {
+	results := models.Results{
+		Version:     models.ResultsVersion,
+		GeneratedAt: time.Now().UTC(),
+		RunID:       runid.ID(),
+		Labels:      opts.Labels,
+		ScanErrors:  opts.ScanErrors,
+		Failures:    opts.Failures,
+		Comparisons: make([]models.PairComparison, len(comparisons)),
+	}
+	for i, comparison := range comparisons {
+		// Emit [] rather than null for pairs without matches
+		if comparison.Matches == nil {
+			comparison.Matches = []models.FileMatch{}
+		}
+		results.Comparisons[i] = comparison
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
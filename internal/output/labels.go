@@ -0,0 +1,66 @@
+package output
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Labels maps absolute root directories to short display names
+type Labels map[string]string
+
+// Dir returns the label for a root directory, or dir unchanged
+func (l Labels) Dir(dir string) string {
+	if label, ok := l[absClean(dir)]; ok {
+		return label
+	}
+	return dir
+}
+
+// Path shortens a path under a labelled root to "label:relative/path".
+// The longest matching root wins; other paths are returned unchanged.
+func (l Labels) Path(path string) string {
+	if len(l) == 0 {
+		return path
+	}
+
+	abs := absClean(path)
+	bestRoot, bestLabel := "", ""
+	for root, label := range l {
+		if len(root) > len(bestRoot) && isWithin(abs, root) {
+			bestRoot, bestLabel = root, label
+		}
+	}
+	if bestRoot == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(bestRoot, abs)
+	if err != nil {
+		return path
+	}
+	if rel == "." {
+		return bestLabel + ":"
+	}
+	return bestLabel + ":" + filepath.ToSlash(rel)
+}
+
+// absClean returns the cleaned absolute form of path, or path cleaned on error
+func absClean(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// isWithin reports whether path equals root or lies inside it
+func isWithin(path, root string) bool {
+	if path == root {
+		return true
+	}
+	prefix := root
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, prefix)
+}
@@ -0,0 +1,33 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels_Dir(t *testing.T) {
+	labels := Labels{"/mnt/nas/photos": "nas"}
+
+	assert.Equal(t, "nas", labels.Dir("/mnt/nas/photos"))
+	assert.Equal(t, "nas", labels.Dir("/mnt/nas/photos/"))
+	assert.Equal(t, "/mnt/other", labels.Dir("/mnt/other"))
+}
+
+func TestLabels_Path(t *testing.T) {
+	labels := Labels{
+		"/mnt/nas":        "nas",
+		"/mnt/nas/photos": "photos",
+	}
+
+	// The longest matching root wins
+	assert.Equal(t, "photos:2023/a.jpg", labels.Path("/mnt/nas/photos/2023/a.jpg"))
+	assert.Equal(t, "nas:music/b.mp3", labels.Path("/mnt/nas/music/b.mp3"))
+	assert.Equal(t, "photos:", labels.Path("/mnt/nas/photos"))
+
+	// A shared prefix is not a parent directory
+	assert.Equal(t, "/mnt/nas-backup/a.jpg", labels.Path("/mnt/nas-backup/a.jpg"))
+
+	var none Labels
+	assert.Equal(t, "/mnt/nas/a.jpg", none.Path("/mnt/nas/a.jpg"))
+}
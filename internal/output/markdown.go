@@ -0,0 +1,65 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// markdownEscaper escapes characters that would end a table cell or be
+// read as Markdown formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;",
+)
+
+// FormatMarkdown renders comparisons as a Markdown report with a heading
+// per directory pair and a table of its matches, for pasting into issues or
+// wiki pages. Paths use opts.Labels and times use opts.TimeFormat.
+func FormatMarkdown(comparisons []models.PairComparison, opts Options) string {
+	formatter := NewSimpleFormatterWithOptions(opts)
+	var builder strings.Builder
+
+	builder.WriteString("# Duplicate files\n\n")
+	builder.WriteString(fmt.Sprintf("%s match(es) in %s directory pair(s)\n", FormatCount(CountMatches(comparisons)), FormatCount(len(comparisons))))
+
+	for _, comparison := range comparisons {
+		builder.WriteString(fmt.Sprintf("\n## %s ↔ %s\n\n",
+			markdownEscaper.Replace(opts.Labels.Dir(comparison.Dir1)),
+			markdownEscaper.Replace(opts.Labels.Dir(comparison.Dir2))))
+
+		if len(comparison.Matches) == 0 {
+			builder.WriteString("_No duplicates_\n")
+			continue
+		}
+
+		builder.WriteString("| File | Size | Path 1 | Modified 1 | Path 2 | Modified 2 | Status |\n")
+		builder.WriteString("|------|-----:|--------|------------|--------|------------|--------|\n")
+		for _, match := range comparison.Matches {
+			status := markdownEscaper.Replace(strings.TrimSpace(formatter.status(match)))
+			for _, c := range match.AlsoIn {
+				status += fmt.Sprintf("<br>also found in %s ↔ %s",
+					markdownEscaper.Replace(opts.Labels.Dir(c.Dir1)), markdownEscaper.Replace(opts.Labels.Dir(c.Dir2)))
+			}
+			builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+				markdownEscaper.Replace(match.Filename),
+				FormatSize(match.File1.Size),
+				markdownEscaper.Replace(opts.Labels.Path(match.File1.Path)),
+				opts.TimeFormat.Format(match.File1.ModTime),
+				markdownEscaper.Replace(opts.Labels.Path(match.File2.Path)),
+				opts.TimeFormat.Format(match.File2.ModTime),
+				status))
+		}
+
+		if comparison.Truncated {
+			builder.WriteString("\n_Further matches omitted: match limit reached_\n")
+		}
+	}
+
+	if pairs, size := hardlinkedTotals(comparisons); pairs > 0 {
+		builder.WriteString(fmt.Sprintf("\nAlready hardlinked: %s pair(s), %s shared\n", FormatCount(pairs), FormatSize(size)))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/photos",
+			Dir2: "/backup",
+			Matches: []models.FileMatch{
+				{
+					Filename:    "a|b_1.jpg",
+					File1:       models.FileInfo{Path: "/photos/a|b_1.jpg", Size: 2048, ModTime: mtime},
+					File2:       models.FileInfo{Path: "/backup/a|b_1.jpg", Size: 2048, ModTime: mtime},
+					HashChecked: true,
+					HashMatch:   true,
+					Verified:    models.CheckHash,
+				},
+			},
+		},
+		{Dir1: "/photos", Dir2: "/old"},
+	}
+
+	result := FormatMarkdown(comparisons, Options{
+		ShowHash:   true,
+		Labels:     Labels{"/photos": "photos"},
+		TimeFormat: TimeFormatISO,
+	})
+
+	assert.Contains(t, result, "# Duplicate files\n\n1 match(es) in 2 directory pair(s)\n")
+	assert.Contains(t, result, "## photos ↔ /backup\n")
+	assert.Contains(t, result, `| a\|b\_1.jpg | 2.0 KiB | photos:a\|b\_1.jpg | 2024-03-01T12:00:00Z | /backup/a\|b\_1.jpg | 2024-03-01T12:00:00Z | \[Hash: ✓ Identical\] |`)
+	assert.Contains(t, result, "## photos ↔ /old\n\n_No duplicates_\n")
+}
+
+func TestFormatComparisons_Markdown(t *testing.T) {
+	result, err := FormatComparisons(FormatNameMarkdown, nil, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "# Duplicate files\n\n0 match(es) in 0 directory pair(s)\n", result)
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// MatchRecord is one line of NDJSON output: a match together with the
+// directory pair it belongs to, so every line stands on its own
+type MatchRecord struct {
+	RunID string `json:"run_id"` // Run that found the match
+	Dir1  string `json:"dir1"`
+	Dir2  string `json:"dir2"`
+	models.FileMatch
+}
+
+// NDJSONWriter writes matches as newline-delimited JSON, one object per
+// match, as they arrive
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter creates an NDJSON writer on w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// Write writes one line per match of the dir1/dir2 pair
+func (nw *NDJSONWriter) Write(dir1, dir2 string, matches []models.FileMatch) error {
+	for _, match := range matches {
+		if err := nw.enc.Encode(MatchRecord{RunID: runid.ID(), Dir1: dir1, Dir2: dir2, FileMatch: match}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatNDJSON renders all comparisons as newline-delimited JSON
+func FormatNDJSON(comparisons []models.PairComparison) (string, error) {
+	var builder strings.Builder
+	w := NewNDJSONWriter(&builder)
+	for _, comparison := range comparisons {
+		if err := w.Write(comparison.Dir1, comparison.Dir2, comparison.Matches); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
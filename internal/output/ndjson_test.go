@@ -0,0 +1,49 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatNDJSON(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/a",
+			Dir2: "/b",
+			Matches: []models.FileMatch{
+				{Filename: "x.jpg", File1: models.FileInfo{Path: "/a/x.jpg"}, File2: models.FileInfo{Path: "/b/x.jpg"}, HashChecked: true, HashMatch: true},
+				{Filename: "y.jpg"},
+			},
+		},
+		{Dir1: "/a", Dir2: "/c"},
+		{Dir1: "/b", Dir2: "/c", Matches: []models.FileMatch{{Filename: "z.jpg"}}},
+	}
+
+	result, err := FormatComparisons(FormatNameNDJSON, comparisons, Options{})
+	require.NoError(t, err)
+
+	var records []MatchRecord
+	scanner := bufio.NewScanner(strings.NewReader(result))
+	for scanner.Scan() {
+		var record MatchRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record), scanner.Text())
+		records = append(records, record)
+	}
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "/a", records[0].Dir1)
+	assert.Equal(t, "/b", records[0].Dir2)
+	assert.Equal(t, "x.jpg", records[0].Filename)
+	assert.Equal(t, "/b/x.jpg", records[0].File2.Path)
+	assert.True(t, records[0].HashMatch)
+	assert.Equal(t, "z.jpg", records[2].Filename)
+	assert.Equal(t, "/c", records[2].Dir2)
+	assert.Contains(t, result, `"filename":"x.jpg"`)
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat is how counts and sizes are written for people to read
+type NumberFormat struct {
+	Grouping string // Thousands separator ("" for none)
+	Decimal  string // Decimal mark
+	SI       bool   // Sizes in powers of 1000 (kB, MB) instead of 1024 (KiB, MiB)
+}
+
+// numberFormat is used by FormatCount and FormatSize. The default, the C
+// locale's, groups no digits, so output is the same wherever it runs
+// until the command line sets the user's locale.
+var numberFormat = NumberFormat{Decimal: "."}
+
+// SetNumberFormat sets how FormatCount and FormatSize write numbers
+func SetNumberFormat(f NumberFormat) {
+	numberFormat = f
+}
+
+// nbsp keeps a grouped number from being split across lines
+const nbsp = "\u00a0"
+
+// Digit grouping and decimal marks by language, and the regions that
+// differ from their language
+var (
+	localeLanguages = map[string]NumberFormat{
+		"de": {Grouping: ".", Decimal: ","}, "nl": {Grouping: ".", Decimal: ","},
+		"it": {Grouping: ".", Decimal: ","}, "es": {Grouping: ".", Decimal: ","},
+		"pt": {Grouping: ".", Decimal: ","}, "da": {Grouping: ".", Decimal: ","},
+		"id": {Grouping: ".", Decimal: ","}, "tr": {Grouping: ".", Decimal: ","},
+		"el": {Grouping: ".", Decimal: ","}, "ro": {Grouping: ".", Decimal: ","},
+		"hr": {Grouping: ".", Decimal: ","}, "sl": {Grouping: ".", Decimal: ","},
+		"sr": {Grouping: ".", Decimal: ","}, "vi": {Grouping: ".", Decimal: ","},
+		"fr": {Grouping: nbsp, Decimal: ","}, "ru": {Grouping: nbsp, Decimal: ","},
+		"uk": {Grouping: nbsp, Decimal: ","}, "pl": {Grouping: nbsp, Decimal: ","},
+		"cs": {Grouping: nbsp, Decimal: ","}, "sk": {Grouping: nbsp, Decimal: ","},
+		"fi": {Grouping: nbsp, Decimal: ","}, "sv": {Grouping: nbsp, Decimal: ","},
+		"nb": {Grouping: nbsp, Decimal: ","}, "no": {Grouping: nbsp, Decimal: ","},
+		"hu": {Grouping: nbsp, Decimal: ","}, "bg": {Grouping: nbsp, Decimal: ","},
+		"lt": {Grouping: nbsp, Decimal: ","}, "lv": {Grouping: nbsp, Decimal: ","},
+		"et": {Grouping: nbsp, Decimal: ","},
+	}
+	localeRegions = map[string]NumberFormat{
+		"de_CH": {Grouping: "'", Decimal: "."}, "it_CH": {Grouping: "'", Decimal: "."},
+		"es_MX": {Grouping: ",", Decimal: "."}, "es_US": {Grouping: ",", Decimal: "."},
+	}
+)
+
+// LocaleNumberFormat returns the number format of a POSIX locale name such
+// as de_DE.UTF-8. The C and POSIX locales, and an empty name, group no
+// digits; other languages not known to differ use "," and ".", as English
+// does.
+func LocaleNumberFormat(locale string) NumberFormat {
+	name, _, _ := strings.Cut(locale, ".")
+	name, _, _ = strings.Cut(name, "@")
+	if name == "" || name == "C" || name == "POSIX" {
+		return NumberFormat{Decimal: "."}
+	}
+	name = strings.ReplaceAll(name, "-", "_")
+	if f, ok := localeRegions[name]; ok {
+		return f
+	}
+	language, _, _ := strings.Cut(name, "_")
+	if f, ok := localeLanguages[strings.ToLower(language)]; ok {
+		return f
+	}
+	return NumberFormat{Grouping: ",", Decimal: "."}
+}
+
+// EnvLocale returns the locale that governs number formatting, from
+// LC_ALL, LC_NUMERIC or LANG, in that order
+func EnvLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// FormatCount writes n with the locale's thousands separator
+func FormatCount[N ~int | ~int64](n N) string {
+	return groupDigits(strconv.FormatInt(int64(n), 10))
+}
+
+// formatDecimal writes a number formatted with '.' using the locale's
+// digit grouping and decimal mark
+func formatDecimal(s string) string {
+	whole, fraction, ok := strings.Cut(s, ".")
+	whole = groupDigits(whole)
+	if !ok {
+		return whole
+	}
+	return whole + numberFormat.Decimal + fraction
+}
+
+// groupDigits inserts the thousands separator into a string of digits
+// with an optional sign
+func groupDigits(s string) string {
+	if numberFormat.Grouping == "" {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= 3 {
+		return sign + s
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(s) % 3
+	if head == 0 {
+		head = 3
+	}
+	b.WriteString(s[:head])
+	for i := head; i < len(s); i += 3 {
+		b.WriteString(numberFormat.Grouping)
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+// FormatPercent writes a percentage with one decimal, using the locale's
+// decimal mark
+func FormatPercent(p float64) string {
+	return formatDecimal(strconv.FormatFloat(p, 'f', 1, 64))
+}
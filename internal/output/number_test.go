@@ -0,0 +1,65 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withNumberFormat(t *testing.T, f NumberFormat) {
+	t.Helper()
+	previous := numberFormat
+	SetNumberFormat(f)
+	t.Cleanup(func() { SetNumberFormat(previous) })
+}
+
+func TestLocaleNumberFormat(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   NumberFormat
+	}{
+		{"", NumberFormat{Decimal: "."}},
+		{"C.UTF-8", NumberFormat{Decimal: "."}},
+		{"POSIX", NumberFormat{Decimal: "."}},
+		{"en_US.UTF-8", NumberFormat{Grouping: ",", Decimal: "."}},
+		{"ja_JP.UTF-8", NumberFormat{Grouping: ",", Decimal: "."}},
+		{"de_DE.UTF-8", NumberFormat{Grouping: ".", Decimal: ","}},
+		{"de_DE@euro", NumberFormat{Grouping: ".", Decimal: ","}},
+		{"de_CH.UTF-8", NumberFormat{Grouping: "'", Decimal: "."}},
+		{"fr_FR.UTF-8", NumberFormat{Grouping: " ", Decimal: ","}},
+		{"es_MX", NumberFormat{Grouping: ",", Decimal: "."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			assert.Equal(t, tt.want, LocaleNumberFormat(tt.locale))
+		})
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "1234567", FormatCount(1234567))
+
+	withNumberFormat(t, NumberFormat{Grouping: ",", Decimal: "."})
+	assert.Equal(t, "0", FormatCount(0))
+	assert.Equal(t, "999", FormatCount(999))
+	assert.Equal(t, "1,000", FormatCount(1000))
+	assert.Equal(t, "184,302", FormatCount(184302))
+	assert.Equal(t, "1,234,567", FormatCount(int64(1234567)))
+	assert.Equal(t, "-12,345", FormatCount(-12345))
+}
+
+func TestFormatSize_Locale(t *testing.T) {
+	withNumberFormat(t, NumberFormat{Grouping: ".", Decimal: ","})
+	assert.Equal(t, "1.023 B", FormatSize(1023))
+	assert.Equal(t, "1,5 KiB", FormatSize(1536))
+	assert.Equal(t, "412,7 GiB", FormatSize(443136000000))
+}
+
+func TestFormatSize_SI(t *testing.T) {
+	withNumberFormat(t, NumberFormat{Decimal: ".", SI: true})
+	assert.Equal(t, "999 B", FormatSize(999))
+	assert.Equal(t, "1.0 kB", FormatSize(1000))
+	assert.Equal(t, "1.5 kB", FormatSize(1536))
+	assert.Equal(t, "1.6 MB", FormatSize(1572864))
+	assert.Equal(t, "5.4 GB", FormatSize(5368709120))
+}
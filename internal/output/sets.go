@@ -0,0 +1,60 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// FormatSets formats duplicate sets merged across directory pairs: each set
+// once, with the paths of all its files, so a file present in three or more
+// directories is not listed again for every pair that found it
+func (sf *SimpleFormatter) FormatSets(sets []models.DuplicateSet) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("=== %s duplicate set(s) across directories ===\n", FormatCount(len(sets))))
+	for _, set := range sets {
+		status := ""
+		if set.HashComputed {
+			status = " [Verified: hash]"
+		}
+		builder.WriteString(fmt.Sprintf("#%d %s: %d files of %s ✓%s\n", set.ID, filepath.Base(set.Files[0].Path), len(set.Files), FormatSize(set.Files[0].Size), status))
+		for _, file := range set.Files {
+			builder.WriteString(fmt.Sprintf("    %s\n", sf.labels.Path(file.Path)))
+		}
+		if set.Note != "" {
+			builder.WriteString(fmt.Sprintf("    note: %s\n", set.Note))
+		}
+	}
+	return builder.String()
+}
+
+// formatSetsAndRest formats the duplicate sets in opts, then, under their
+// directory pairs, the matches that are not duplicates and so belong to no
+// set, such as files that turned out to differ
+func formatSetsAndRest(comparisons []models.PairComparison, opts Options) string {
+	formatter := NewSimpleFormatterWithOptions(opts)
+	var builder strings.Builder
+	builder.WriteString(formatter.FormatSets(opts.Sets))
+
+	for _, comparison := range comparisons {
+		rest := comparison
+		rest.Matches = nil
+		for _, match := range comparison.Matches {
+			if !match.IsDuplicate() {
+				rest.Matches = append(rest.Matches, match)
+			}
+		}
+		if len(rest.Matches) > 0 || rest.Truncated {
+			builder.WriteString("\n" + formatter.FormatPairComparison(rest))
+		}
+	}
+
+	if pairs, size := hardlinkedTotals(comparisons); pairs > 0 {
+		builder.WriteString(fmt.Sprintf("\nAlready hardlinked: %s pair(s), %s shared\n", FormatCount(pairs), FormatSize(size)))
+	}
+	writeProblems(&builder, opts)
+
+	return builder.String()
+}
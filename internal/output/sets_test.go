@@ -0,0 +1,36 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatComparisons_Sets(t *testing.T) {
+	file := func(dir string) models.FileInfo {
+		return models.FileInfo{Path: dir + "/photo.jpg", Directory: dir, Size: 2048}
+	}
+	a, b, c := file("/a"), file("/b"), file("/c")
+	comparisons := []models.PairComparison{
+		{Dir1: "/a", Dir2: "/b", Matches: []models.FileMatch{{Filename: "photo.jpg", File1: a, File2: b}}},
+		{Dir1: "/a", Dir2: "/c", Matches: []models.FileMatch{
+			{Filename: "photo.jpg", File1: a, File2: c},
+			{Filename: "notes.txt", Mismatch: models.CheckSize},
+		}},
+		{Dir1: "/b", Dir2: "/c", Matches: []models.FileMatch{{Filename: "photo.jpg", File1: b, File2: c}}},
+	}
+	sets := []models.DuplicateSet{{ID: 1, Files: []models.FileInfo{a, b, c}, HashComputed: true, Note: "keep /a"}}
+
+	result, err := FormatComparisons(FormatNameText, comparisons, Options{Sets: sets})
+	assert.NoError(t, err)
+
+	// The file present in all three directories is listed once
+	assert.Equal(t, "=== 1 duplicate set(s) across directories ===\n"+
+		"#1 photo.jpg: 3 files of 2.0 KiB ✓ [Verified: hash]\n"+
+		"    /a/photo.jpg\n    /b/photo.jpg\n    /c/photo.jpg\n"+
+		"    note: keep /a\n"+
+		"\n=== /a ↔ /c ===\n"+
+		"notes.txt:           ✓ [✗ Different: size]\n", result)
+}
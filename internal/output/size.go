@@ -0,0 +1,24 @@
+package output
+
+import "strconv"
+
+// FormatSize converts bytes to human-readable format: in binary units
+// (KiB, MiB; powers of 1024, as du -h counts) or, with NumberFormat.SI, in
+// SI units (kB, MB; powers of 1000, as du --si counts), written with the
+// locale's decimal mark
+func FormatSize(bytes int64) string {
+	unit, prefixes, suffix := int64(1024), "KMGTPE", "iB"
+	if numberFormat.SI {
+		unit, prefixes, suffix = 1000, "kMGTPE", "B"
+	}
+	if bytes < unit {
+		return FormatCount(bytes) + " B"
+	}
+	div, exp := unit, 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	value := strconv.FormatFloat(float64(bytes)/float64(div), 'f', 1, 64)
+	return formatDecimal(value) + " " + prefixes[exp:exp+1] + suffix
+}
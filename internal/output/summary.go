@@ -0,0 +1,121 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Actions taken when text output has more matches than the large-output
+// threshold
+const (
+	LargeOutputPrint   = "print"   // List every match anyway
+	LargeOutputSuggest = "suggest" // Show a per-pair summary and suggest a structured format
+	LargeOutputFile    = "file"    // Save JSON results to a file and show a per-pair summary
+)
+
+// LargeOutputActions lists the supported large-output actions
+var LargeOutputActions = []string{LargeOutputPrint, LargeOutputSuggest, LargeOutputFile}
+
+// ValidateLargeOutput checks that action names a supported large-output action
+func ValidateLargeOutput(action string) error {
+	for _, name := range LargeOutputActions {
+		if action == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported large-output action %q (supported: %s)", action, strings.Join(LargeOutputActions, ", "))
+}
+
+// CountMatches returns the number of matches across all comparisons
+func CountMatches(comparisons []models.PairComparison) int {
+	var total int
+	for _, comparison := range comparisons {
+		total += len(comparison.Matches)
+	}
+	return total
+}
+
+// CountDuplicates returns the number of matches that are still duplicates
+// after every check that ran
+func CountDuplicates(comparisons []models.PairComparison) int {
+	var total int
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			if match.IsDuplicate() {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+// FormatNoDuplicates renders the one-line result of a run that found no
+// duplicates, in place of an empty block per directory pair
+func FormatNoDuplicates(files, dirs, pairs int, elapsed time.Duration) string {
+	return fmt.Sprintf("No duplicates found: %s file(s) in %d directories, %s pair(s) compared in %s\n",
+		FormatCount(files), dirs, FormatCount(pairs), elapsed.Round(10*time.Millisecond))
+}
+
+// FormatSummary renders one line per directory pair with its match count,
+// how many were confirmed identical by content and the bytes reclaimable
+// by removing the pair's second copies, followed by the totals, for
+// results too large to list match by match
+func FormatSummary(comparisons []models.PairComparison, opts Options) string {
+	var builder strings.Builder
+	var identical int
+	for _, comparison := range comparisons {
+		truncated := ""
+		if comparison.Truncated {
+			truncated = " (match limit reached)"
+		}
+		pairIdentical, reclaimable := pairTotals(comparison)
+		identical += pairIdentical
+		builder.WriteString(fmt.Sprintf("=== %s ↔ %s ===  %s match(es), %s identical, %s reclaimable%s\n",
+			opts.Labels.Dir(comparison.Dir1), opts.Labels.Dir(comparison.Dir2), FormatCount(len(comparison.Matches)),
+			FormatCount(pairIdentical), FormatSize(reclaimable), truncated))
+	}
+	builder.WriteString(fmt.Sprintf("\nTotal: %s match(es) in %s pair(s), %s identical\n",
+		FormatCount(CountMatches(comparisons)), FormatCount(len(comparisons)), FormatCount(identical)))
+
+	if pairs, size := hardlinkedTotals(comparisons); pairs > 0 {
+		builder.WriteString(fmt.Sprintf("Already hardlinked: %s pair(s), %s shared\n", FormatCount(pairs), FormatSize(size)))
+	}
+	writeProblems(&builder, opts)
+	return builder.String()
+}
+
+// pairTotals returns the number of matches in comparison confirmed
+// identical by content, and the bytes freed by removing the second copy of
+// every duplicate, counting each file once. Hardlinked matches are not
+// duplicates and free nothing.
+func pairTotals(comparison models.PairComparison) (int, int64) {
+	identical := 0
+	var reclaimable int64
+	removed := make(map[string]bool)
+	for _, match := range comparison.Matches {
+		if !match.IsDuplicate() {
+			continue
+		}
+		if contentVerified(match) {
+			identical++
+		}
+		if !removed[match.File2.Path] {
+			removed[match.File2.Path] = true
+			reclaimable += match.File2.Size
+		}
+	}
+	return identical, reclaimable
+}
+
+// contentVerified reports whether a match's contents were compared, by
+// hash or bytes, rather than only its name and size
+func contentVerified(match models.FileMatch) bool {
+	switch match.Verified {
+	case models.CheckHash, models.CheckSample, models.CheckBytes:
+		return true
+	}
+	return match.HashChecked && match.HashMatch
+}
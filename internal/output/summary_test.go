@@ -0,0 +1,62 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFormatSummary(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/a",
+			Dir2: "/b",
+			Matches: []models.FileMatch{
+				{Filename: "x.jpg", File2: models.FileInfo{Path: "/b/x.jpg", Size: 1024}, HashChecked: true, HashMatch: true},
+				{Filename: "y.jpg", File2: models.FileInfo{Path: "/b/y.jpg", Size: 1024}, HashChecked: true},
+			},
+		},
+		{
+			Dir1:      "/a",
+			Dir2:      "/c",
+			Matches:   []models.FileMatch{{Filename: "z.jpg", File2: models.FileInfo{Path: "/c/z.jpg", Size: 2048}}},
+			Truncated: true,
+		},
+	}
+
+	assert.Equal(t, 3, CountMatches(comparisons))
+
+	result := FormatSummary(comparisons, Options{Labels: Labels{"/a": "main"}})
+	assert.Contains(t, result, "=== main ↔ /b ===  2 match(es), 1 identical, 1.0 KiB reclaimable\n")
+	assert.Contains(t, result, "=== main ↔ /c ===  1 match(es), 0 identical, 2.0 KiB reclaimable (match limit reached)\n")
+	assert.Contains(t, result, "Total: 3 match(es) in 2 pair(s), 1 identical")
+	assert.NotContains(t, result, "x.jpg")
+}
+
+func TestValidateLargeOutput(t *testing.T) {
+	for _, action := range LargeOutputActions {
+		assert.NoError(t, ValidateLargeOutput(action))
+	}
+	assert.Error(t, ValidateLargeOutput("pager"))
+}
+
+func TestCountDuplicates(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{Matches: []models.FileMatch{
+			{Filename: "same.jpg", HashChecked: true, HashMatch: true},
+			{Filename: "differs.jpg", HashChecked: true, HashMatch: false},
+			{Filename: "named.jpg"},
+		}},
+		{},
+	}
+	assert.Equal(t, 2, CountDuplicates(comparisons))
+	assert.Equal(t, 0, CountDuplicates(nil))
+}
+
+func TestFormatNoDuplicates(t *testing.T) {
+	assert.Equal(t, "No duplicates found: 1200 file(s) in 15 directories, 105 pair(s) compared in 1.23s\n",
+		FormatNoDuplicates(1200, 15, 105, 1234*time.Millisecond))
+}
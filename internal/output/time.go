@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time format presets accepted by --time-format. Any other value is used as
+// a Go time layout.
+const (
+	TimeFormatDefault  = "default"
+	TimeFormatISO      = "iso"
+	TimeFormatRelative = "relative"
+)
+
+// TimeFormatNames lists the named time format presets
+var TimeFormatNames = []string{TimeFormatDefault, TimeFormatISO, TimeFormatRelative}
+
+const defaultTimeLayout = "2006-01-02 15:04:05"
+
+// TimeFormat controls how timestamps are displayed
+type TimeFormat string
+
+// Format renders t according to the format; the zero value uses the default layout
+func (tf TimeFormat) Format(t time.Time) string {
+	switch tf {
+	case "", TimeFormatDefault:
+		return t.Format(defaultTimeLayout)
+	case TimeFormatISO:
+		return t.Format(time.RFC3339)
+	case TimeFormatRelative:
+		return FormatRelative(t, time.Now())
+	default:
+		return t.Format(string(tf))
+	}
+}
+
+// FormatRelative describes the age of t as seen from now, e.g. "3 months ago"
+func FormatRelative(t, now time.Time) string {
+	d := now.Sub(t)
+	if d > -time.Minute && d < time.Minute {
+		return "just now"
+	}
+	if d < 0 {
+		return "in " + FormatDuration(-d)
+	}
+	return FormatDuration(d) + " ago"
+}
+
+// FormatDuration describes a duration in its largest whole unit, e.g.
+// "2 years" or "1 minute"; anything under a minute is "0 minutes"
+func FormatDuration(d time.Duration) string {
+	const (
+		day   = 24 * time.Hour
+		month = 30 * day
+		year  = 365 * day
+	)
+
+	if d < 0 {
+		d = -d
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < day:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < month:
+		n, unit = int64(d/day), "day"
+	case d < year:
+		n, unit = int64(d/month), "month"
+	default:
+		n, unit = int64(d/year), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
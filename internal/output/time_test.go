@@ -0,0 +1,46 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{10 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{5 * time.Hour, "5 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{95 * 24 * time.Hour, "3 months ago"},
+		{800 * 24 * time.Hour, "2 years ago"},
+		{-2 * 24 * time.Hour, "in 2 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatRelative(now.Add(-tt.age), now))
+		})
+	}
+}
+
+func TestTimeFormat_Format(t *testing.T) {
+	ts := time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "2024-06-01 08:30:00", TimeFormat("").Format(ts))
+	assert.Equal(t, "2024-06-01 08:30:00", TimeFormat(TimeFormatDefault).Format(ts))
+	assert.Equal(t, "2024-06-01T08:30:00Z", TimeFormat(TimeFormatISO).Format(ts))
+	assert.Equal(t, "01/06/2024", TimeFormat("02/01/2006").Format(ts))
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "0 minutes", FormatDuration(30*time.Second))
+	assert.Equal(t, "1 hour", FormatDuration(90*time.Minute))
+	assert.Equal(t, "2 years", FormatDuration(-800*24*time.Hour))
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// FormatURIs lists each duplicate pair as two file:// URIs, one per line
+// with a blank line between pairs; hardlinked pairs get a comment line.
+// Terminals and file managers open them directly, which makes the files
+// easy to inspect side by side.
+func FormatURIs(comparisons []models.PairComparison) string {
+	var builder strings.Builder
+	for _, comparison := range comparisons {
+		for _, match := range comparison.Matches {
+			if match.Hardlinked && match.Mismatch == "" {
+				builder.WriteString("# already hardlinked\n")
+			} else if !match.IsDuplicate() {
+				continue
+			}
+			builder.WriteString(FileURI(match.File1.Path) + "\n")
+			builder.WriteString(FileURI(match.File2.Path) + "\n")
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// FileURI converts a local path to a percent-encoded file:// URI
+func FileURI(path string) string {
+	p := filepath.ToSlash(absClean(path))
+	if !strings.HasPrefix(p, "/") {
+		// Windows drive paths become file:///C:/...
+		p = "/" + p
+	}
+	u := url.URL{Scheme: "file", Path: p}
+	return u.String()
+}
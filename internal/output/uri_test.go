@@ -0,0 +1,42 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestFileURI(t *testing.T) {
+	assert.Equal(t, "file:///photos/a.jpg", FileURI("/photos/a.jpg"))
+	assert.Equal(t, "file:///photos/my%20trip/%231.jpg", FileURI("/photos/my trip/#1.jpg"))
+}
+
+func TestFormatURIs(t *testing.T) {
+	comparisons := []models.PairComparison{
+		{
+			Dir1: "/a",
+			Dir2: "/b",
+			Matches: []models.FileMatch{
+				{
+					Filename: "same.jpg",
+					File1:    models.FileInfo{Path: "/a/same.jpg"},
+					File2:    models.FileInfo{Path: "/b/same.jpg"},
+				},
+				{
+					Filename:    "different.jpg",
+					File1:       models.FileInfo{Path: "/a/different.jpg"},
+					File2:       models.FileInfo{Path: "/b/different.jpg"},
+					HashChecked: true,
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "file:///a/same.jpg\nfile:///b/same.jpg\n\n", FormatURIs(comparisons))
+
+	result, err := FormatComparisons(FormatNameFileManager, comparisons, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, FormatURIs(comparisons), result)
+}
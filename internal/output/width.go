@@ -0,0 +1,111 @@
+package output
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ellipsis marks the part of a name cut out by truncateMiddle
+const ellipsis = "…"
+
+// displayWidth returns the number of terminal columns s occupies: East
+// Asian wide and fullwidth characters take two columns, combining marks
+// none, and everything else one
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the terminal columns taken by r
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case isWide(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// isWide reports whether r is an East Asian wide or fullwidth character
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) || // Hangul Jamo
+		(r >= 0x2E80 && r <= 0x303E) || // CJK radicals, punctuation
+		(r >= 0x3041 && r <= 0x33FF) || // Hiragana, Katakana, CJK symbols
+		(r >= 0x3400 && r <= 0x4DBF) || // CJK extension A
+		(r >= 0x4E00 && r <= 0x9FFF) || // CJK unified ideographs
+		(r >= 0xA000 && r <= 0xA4CF) || // Yi
+		(r >= 0xAC00 && r <= 0xD7A3) || // Hangul syllables
+		(r >= 0xF900 && r <= 0xFAFF) || // CJK compatibility ideographs
+		(r >= 0xFE30 && r <= 0xFE4F) || // CJK compatibility forms
+		(r >= 0xFF00 && r <= 0xFF60) || // Fullwidth forms
+		(r >= 0xFFE0 && r <= 0xFFE6) ||
+		(r >= 0x1F300 && r <= 0x1F64F) || // Emoji
+		(r >= 0x1F900 && r <= 0x1F9FF) ||
+		(r >= 0x20000 && r <= 0x3FFFD) // CJK extensions B and later
+}
+
+// truncateMiddle shortens s to at most width columns by replacing its
+// middle with "…", keeping the start and the end, where a file's
+// extension and distinguishing suffix usually are. s is returned
+// unchanged when it fits.
+func truncateMiddle(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	if width == 1 {
+		return ellipsis
+	}
+
+	runes := []rune(s)
+	budget := width - 1
+	tailBudget := budget / 2
+	headBudget := budget - tailBudget
+
+	var head strings.Builder
+	used := 0
+	for _, r := range runes {
+		w := runeWidth(r)
+		if used+w > headBudget {
+			break
+		}
+		head.WriteRune(r)
+		used += w
+	}
+
+	// Collect the tail from the end, then give it any columns the head
+	// could not use
+	tailBudget = budget - used
+	start := len(runes)
+	used = 0
+	for start > 0 {
+		w := runeWidth(runes[start-1])
+		if used+w > tailBudget {
+			break
+		}
+		start--
+		used += w
+	}
+	return head.String() + ellipsis + string(runes[start:])
+}
+
+// padRight pads s with spaces to width columns
+func padRight(s string, width int) string {
+	if pad := width - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// Fit shortens s with truncateMiddle and pads it, so it takes exactly width
+// terminal columns
+func Fit(s string, width int) string {
+	return padRight(truncateMiddle(s, width), width)
+}
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 9, displayWidth("photo.jpg"))
+	assert.Equal(t, 10, displayWidth("写真01.jpg"))
+	assert.Equal(t, 5, displayWidth("café!"))
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	assert.Equal(t, "photo.jpg", truncateMiddle("photo.jpg", 9))
+	assert.Equal(t, "IMG_20…al.jpg", truncateMiddle("IMG_20240101_123456_final.jpg", 13))
+	assert.Equal(t, "写真…ム.jpg", truncateMiddle("写真アルバム.jpg", 11))
+	assert.Equal(t, "…", truncateMiddle("photo.jpg", 1))
+	for width := 2; width < 30; width++ {
+		assert.LessOrEqual(t, displayWidth(truncateMiddle("写真アルバム_IMG_20240101.jpg", width)), width)
+	}
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, "photo.jpg   ", Fit("photo.jpg", 12))
+	assert.Equal(t, "IMG_20…al.jpg", Fit("IMG_20240101_123456_final.jpg", 13))
+	for width := 2; width < 30; width++ {
+		assert.Equal(t, width, displayWidth(Fit("写真アルバム_IMG_20240101.jpg", width)))
+	}
+}
@@ -0,0 +1,64 @@
+// Package panics turns a panic in a worker into an error for the file it
+// was processing, so one pathological file cannot end an hours-long run.
+// Every panic recovered is recorded for the report at the end of the run.
+package panics
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	mu        sync.Mutex
+	recovered []*Error
+)
+
+// Error is a panic recovered while processing a path
+type Error struct {
+	Path  string
+	Value any    // Value passed to panic
+	Stack []byte // Stack of the goroutine that panicked
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("panic while processing %s: %v", e.Path, e.Value)
+}
+
+// Recover, deferred by a worker, stops a panic while processing path and
+// sets *err to the *Error describing it. The panic and its stack are
+// printed to stderr and recorded for Recovered.
+func Recover(path string, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	panicErr := &Error{Path: path, Value: value, Stack: debug.Stack()}
+	fmt.Fprintf(os.Stderr, "Error: %v\n%s\n", panicErr, panicErr.Stack)
+
+	mu.Lock()
+	recovered = append(recovered, panicErr)
+	mu.Unlock()
+	*err = panicErr
+}
+
+// Do calls fn, returning a panic in it as an *Error for path
+func Do(path string, fn func() error) (err error) {
+	defer Recover(path, &err)
+	return fn()
+}
+
+// Recovered returns the panics recovered so far, in the order they happened
+func Recovered() []*Error {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]*Error(nil), recovered...)
+}
+
+// Reset forgets the panics recovered so far
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	recovered = nil
+}
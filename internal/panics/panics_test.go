@@ -0,0 +1,48 @@
+package panics
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	defer Reset()
+
+	err := Do("/dev/weird", func() error {
+		var m map[string]int
+		m["x"]++ // Assignment to a nil map
+		return nil
+	})
+	var panicErr *Error
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "/dev/weird", panicErr.Path)
+	assert.Contains(t, err.Error(), "panic while processing /dev/weird: assignment to entry in nil map")
+	assert.NotEmpty(t, panicErr.Stack)
+	assert.Equal(t, []*Error{panicErr}, Recovered())
+
+	// Without a panic, fn's own result is returned and nothing is recorded
+	Reset()
+	plain := errors.New("permission denied")
+	assert.Equal(t, plain, Do("/a", func() error { return plain }))
+	assert.NoError(t, Do("/b", func() error { return nil }))
+	assert.Empty(t, Recovered())
+}
+
+func TestDo_Concurrent(t *testing.T) {
+	defer Reset()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = Do("/file", func() error { panic("boom") })
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, Recovered(), 8)
+}
@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseAge parses a --min-age value. Whole days ("90d") are accepted in
+// addition to Go durations ("36h", "90m").
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q: expected whole days such as 90d or a duration such as 36h", value)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q: expected whole days such as 90d or a duration such as 36h", value)
+		}
+		age = d
+	}
+
+	if age < 0 {
+		return 0, fmt.Errorf("invalid age %q: must not be negative", value)
+	}
+	return age, nil
+}
+
+// FormatAge renders an age the way ParseAge accepts it, in days when it
+// is a whole number of days
+func FormatAge(age time.Duration) string {
+	const day = 24 * time.Hour
+	if age >= day && age%day == 0 {
+		return fmt.Sprintf("%dd", age/day)
+	}
+	return age.String()
+}
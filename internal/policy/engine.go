@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// Actions recorded in Reason.Action
+const (
+	ActionKeep   = "keep"
+	ActionRemove = "remove"
+	ActionRetain = "retain"
+)
+
+// Rules recorded in Reason.Rule
+const (
+	RuleProtected  = "protected"
+	RuleOffline    = "offline"
+	RuleMinAge     = "min-age"
+	RulePreferDir  = "prefer-dir"
+	RulePriority   = "priority"
+	RulePathOrder  = "path order"
+	RuleOnlyCopy   = "only copy"
+	RuleUnverified = "unverified"
+	RuleFirstSeen  = "first seen" // Present before watch found a copy added
+)
+
+// Reason explains why a file was kept, removed or retained
+type Reason struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`           // ActionKeep, ActionRemove or ActionRetain
+	Rule   string `json:"rule"`             // Rule that decided the action
+	Detail string `json:"detail,omitempty"` // Rule-specific detail, e.g. "newer mtime by 2 years"
+}
+
+// String renders the reason as "rule" or "rule: detail"
+func (r Reason) String() string {
+	if r.Detail == "" {
+		return r.Rule
+	}
+	return r.Rule + ": " + r.Detail
+}
+
+// now is replaced in tests
+var now = time.Now
+
+// retain reports whether a retention rule keeps file from being removed
+func (p Policy) retain(file models.FileInfo) (rule, detail string, ok bool) {
+	if file.Offline {
+		return RuleOffline, "listed in a catalog of a volume that is not attached", true
+	}
+	for _, dir := range p.Protected {
+		if IsUnder(file.Path, dir) {
+			return RuleProtected, "under " + dir, true
+		}
+	}
+	if p.MinAge > 0 {
+		if age := now().Sub(file.ModTime); age < p.MinAge {
+			return RuleMinAge, fmt.Sprintf("modified %s ago, within %s", output.FormatDuration(age), FormatAge(p.MinAge)), true
+		}
+	}
+	return "", "", false
+}
+
+// rank orders two files by the ranking rules and names the rule that
+// decided; files that tie on every other rule are ordered by path. Files
+// on offline volumes, which are never removed, come first so that the
+// copy kept is one of them.
+func (p Policy) rank(a, b models.FileInfo) (less bool, rule string) {
+	if a.Offline != b.Offline {
+		return a.Offline, RuleOffline
+	}
+	if ra, rb := p.preferRank(a), p.preferRank(b); ra != rb {
+		return ra < rb, RulePreferDir
+	}
+	if ra, rb := p.priorityRank(a), p.priorityRank(b); ra != rb {
+		return ra < rb, RulePriority
+	}
+	if less, decided := p.compare(a, b); decided {
+		return less, "keep-" + p.Keep
+	}
+	return a.Path < b.Path, RulePathOrder
+}
+
+// explain describes how keep won over other under rule, from keep's side
+func (p Policy) explain(keep, other models.FileInfo, rule string) string {
+	switch rule {
+	case RuleOffline:
+		return "copy on an offline volume"
+	case RulePreferDir:
+		if i := p.preferRank(keep); i < len(p.PreferDirs) {
+			return "under preferred " + p.PreferDirs[i]
+		}
+	case RulePriority:
+		kr, or := p.priorityRank(keep), p.priorityRank(other)
+		if or == math.MaxInt {
+			return fmt.Sprintf("priority %d over unprioritized", kr)
+		}
+		return fmt.Sprintf("priority %d over %d", kr, or)
+	case "keep-" + KeepNewest:
+		return "newer mtime by " + output.FormatDuration(keep.ModTime.Sub(other.ModTime))
+	case "keep-" + KeepOldest:
+		return "older mtime by " + output.FormatDuration(other.ModTime.Sub(keep.ModTime))
+	case "keep-" + KeepShortestPath:
+		return fmt.Sprintf("path shorter by %d characters", len(other.Path)-len(keep.Path))
+	case RulePathOrder:
+		return "all other rules tied; first by path"
+	}
+	return ""
+}
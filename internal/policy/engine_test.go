@@ -0,0 +1,112 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func threeCopies() models.DuplicateSet {
+	return models.DuplicateSet{
+		ID: 1,
+		Files: []models.FileInfo{
+			{Path: "/downloads/photo.jpg", Size: 100, ModTime: newer},
+			{Path: "/master/photo.jpg", Size: 100, ModTime: older},
+			{Path: "/archive/photo.jpg", Size: 100, ModTime: older},
+		},
+	}
+}
+
+func TestApply_Reasons(t *testing.T) {
+	d := Policy{Keep: KeepNewest, Priorities: map[string]int{"/master": 1}}.Apply(threeCopies())
+
+	assert.Equal(t, "/master/photo.jpg", d.Keep.Path)
+	reason, ok := d.Reason("/master/photo.jpg")
+	require.True(t, ok)
+	assert.Equal(t, Reason{
+		Path:   "/master/photo.jpg",
+		Action: ActionKeep,
+		Rule:   RulePriority,
+		Detail: "priority 1 over unprioritized",
+	}, reason)
+
+	reason, _ = d.Reason("/archive/photo.jpg")
+	assert.Equal(t, ActionRemove, reason.Action)
+	assert.Equal(t, RulePriority, reason.Rule)
+
+	// Without priorities the keep strategy decides, then path order
+	d = Policy{Keep: KeepOldest}.Apply(threeCopies())
+	assert.Equal(t, "/archive/photo.jpg", d.Keep.Path)
+	reason, _ = d.Reason("/master/photo.jpg")
+	assert.Equal(t, RulePathOrder, reason.Rule)
+	reason, _ = d.Reason("/downloads/photo.jpg")
+	assert.Equal(t, "keep-oldest: kept copy: older mtime by 4 years", reason.String())
+}
+
+func TestApply_Protected(t *testing.T) {
+	p := Policy{Keep: KeepNewest, Protected: []string{"/master", "/archive"}}
+	d := p.Apply(threeCopies())
+
+	// Protection does not change which copy is kept, only what is removed
+	assert.Equal(t, "/downloads/photo.jpg", d.Keep.Path)
+	assert.Empty(t, d.Remove)
+	assert.Len(t, d.Retained, 2)
+	assert.Equal(t, int64(0), d.Savings())
+
+	reason, _ := d.Reason("/archive/photo.jpg")
+	assert.Equal(t, "protected: under /archive", reason.String())
+}
+
+func TestApply_Offline(t *testing.T) {
+	set := threeCopies()
+	set.Files[2].Offline = true
+	set.Files = append(set.Files, models.FileInfo{Path: "/backup/photo.jpg", Size: 100, ModTime: older, Offline: true})
+	d := Policy{Keep: KeepNewest}.Apply(set)
+
+	// A copy on an offline volume is kept before any rule on the others,
+	// and no offline file is removed
+	assert.Equal(t, "/archive/photo.jpg", d.Keep.Path)
+	reason, _ := d.Reason("/downloads/photo.jpg")
+	assert.Equal(t, "offline: kept copy: copy on an offline volume", reason.String())
+	require.Len(t, d.Retained, 1)
+	assert.Equal(t, "/backup/photo.jpg", d.Retained[0].Path)
+	assert.Len(t, d.Remove, 2)
+}
+
+func TestApply_RequireHash(t *testing.T) {
+	p := Policy{Keep: KeepNewest, RequireHash: true}
+
+	// Sets matched by name and size alone lose nothing
+	d := p.Apply(threeCopies())
+	assert.Equal(t, "/downloads/photo.jpg", d.Keep.Path)
+	assert.Empty(t, d.Remove)
+	assert.Len(t, d.Retained, 2)
+	reason, _ := d.Reason("/master/photo.jpg")
+	assert.Equal(t, "unverified: contents not hash-verified", reason.String())
+
+	verified := threeCopies()
+	verified.HashComputed = true
+	d = p.Apply(verified)
+	assert.Len(t, d.Remove, 2)
+	assert.Empty(t, d.Retained)
+}
+
+func TestApply_MinAge(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return newer.Add(24 * time.Hour) }
+
+	p := Policy{Keep: KeepOldest, MinAge: 90 * 24 * time.Hour}
+	d := p.Apply(threeCopies())
+
+	assert.Equal(t, "/archive/photo.jpg", d.Keep.Path)
+	require.Len(t, d.Retained, 1)
+	assert.Equal(t, "/downloads/photo.jpg", d.Retained[0].Path)
+	reason, _ := d.Reason("/downloads/photo.jpg")
+	assert.Equal(t, "min-age: modified 1 day ago, within 90d", reason.String())
+	require.Len(t, d.Remove, 1)
+	assert.Equal(t, "/master/photo.jpg", d.Remove[0].Path)
+}
@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Keep strategies deciding which copy of a duplicate set survives
+const (
+	KeepNewest       = "newest"
+	KeepOldest       = "oldest"
+	KeepShortestPath = "shortest-path"
+)
+
+// KeepStrategies lists the supported keep strategies
+var KeepStrategies = []string{KeepNewest, KeepOldest, KeepShortestPath}
+
+// Policy decides which file of a duplicate set to keep. Its rules apply in
+// a fixed order of precedence:
+//
+//  1. Require hash: with RequireHash, no file of a set whose contents were
+//     not hash-verified is removed
+//  2. Offline: files listed in a catalog of an offline volume are never
+//     removed, as they cannot be reached, and are kept first
+//  3. Protected: files under Protected directories are never removed
+//  4. Min age: files modified within MinAge are never removed
+//  5. Prefer dir: files under PreferDirs are kept first, earlier ones first
+//  6. Priority: lower directory Priorities are kept first
+//  7. Keep strategy: newest, oldest or shortest path
+//  8. Path order, so every decision is deterministic
+//
+// Rules 1 to 4 retain files in addition to the kept one; rules 5 to 8 rank
+// the files to choose which one is kept.
+type Policy struct {
+	Keep        string         // Keep strategy (see KeepStrategies); empty to skip it
+	PreferDirs  []string       // Files under these directories are kept before any others
+	Priorities  map[string]int // Keep priority per directory; lower numbers win, unlisted directories come last
+	Protected   []string       // Files under these directories are never removed
+	MinAge      time.Duration  // Files modified more recently than this are never removed
+	RequireHash bool           // Files of sets that were not hash-verified are never removed
+}
+
+// Decision is the outcome of applying a policy to one duplicate set
+type Decision struct {
+	SetID    int               `json:"set_id"`
+	Keep     models.FileInfo   `json:"keep"`
+	Remove   []models.FileInfo `json:"remove"`
+	Retained []models.FileInfo `json:"retained,omitempty"` // Files a retention rule saved from removal
+	Verified bool              `json:"verified"`           // Whether the set's contents were hash-verified
+	Reasons  []Reason          `json:"reasons"`            // Why each file was kept, removed or retained
+}
+
+// Savings returns the bytes freed by removing the decision's files
+func (d Decision) Savings() int64 {
+	var total int64
+	for _, file := range d.Remove {
+		total += file.Size
+	}
+	return total
+}
+
+// Validate checks that the policy is well-formed
+func (p Policy) Validate() error {
+	for _, s := range KeepStrategies {
+		if p.Keep == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown keep strategy %q (supported: %s)", p.Keep, strings.Join(KeepStrategies, ", "))
+}
+
+// Apply picks the file to keep in set, retains files protected by a
+// retention rule and marks the rest for removal, recording the rule behind
+// every choice.
+func (p Policy) Apply(set models.DuplicateSet) Decision {
+	files := make([]models.FileInfo, len(set.Files))
+	copy(files, set.Files)
+
+	sort.SliceStable(files, func(i, j int) bool {
+		less, _ := p.rank(files[i], files[j])
+		return less
+	})
+
+	d := Decision{
+		SetID:    set.ID,
+		Keep:     files[0],
+		Verified: set.HashComputed,
+	}
+	if len(files) > 1 {
+		_, rule := p.rank(files[0], files[1])
+		d.Reasons = append(d.Reasons, Reason{
+			Path:   files[0].Path,
+			Action: ActionKeep,
+			Rule:   rule,
+			Detail: p.explain(files[0], files[1], rule),
+		})
+	} else {
+		d.Reasons = append(d.Reasons, Reason{Path: files[0].Path, Action: ActionKeep, Rule: RuleOnlyCopy})
+	}
+
+	for _, file := range files[1:] {
+		if p.RequireHash && !set.HashComputed {
+			d.Retained = append(d.Retained, file)
+			d.Reasons = append(d.Reasons, Reason{Path: file.Path, Action: ActionRetain, Rule: RuleUnverified, Detail: "contents not hash-verified"})
+			continue
+		}
+		if rule, detail, ok := p.retain(file); ok {
+			d.Retained = append(d.Retained, file)
+			d.Reasons = append(d.Reasons, Reason{Path: file.Path, Action: ActionRetain, Rule: rule, Detail: detail})
+			continue
+		}
+		_, rule := p.rank(d.Keep, file)
+		d.Remove = append(d.Remove, file)
+		d.Reasons = append(d.Reasons, Reason{
+			Path:   file.Path,
+			Action: ActionRemove,
+			Rule:   rule,
+			Detail: "kept copy: " + p.explain(d.Keep, file, rule),
+		})
+	}
+	return d
+}
+
+// Reason returns the reason recorded for path, if any
+func (d Decision) Reason(path string) (Reason, bool) {
+	for _, r := range d.Reasons {
+		if r.Path == path {
+			return r, true
+		}
+	}
+	return Reason{}, false
+}
+
+// preferRank returns the index of the first preferred directory containing
+// file, or len(PreferDirs) if none does
+func (p Policy) preferRank(file models.FileInfo) int {
+	for i, dir := range p.PreferDirs {
+		if IsUnder(file.Path, dir) {
+			return i
+		}
+	}
+	return len(p.PreferDirs)
+}
+
+// compare orders two files by the keep strategy; decided is false on a tie
+func (p Policy) compare(a, b models.FileInfo) (less bool, decided bool) {
+	switch p.Keep {
+	case KeepNewest:
+		if !a.ModTime.Equal(b.ModTime) {
+			return a.ModTime.After(b.ModTime), true
+		}
+	case KeepOldest:
+		if !a.ModTime.Equal(b.ModTime) {
+			return a.ModTime.Before(b.ModTime), true
+		}
+	case KeepShortestPath:
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path), true
+		}
+	}
+	return false, false
+}
+
+// IsUnder reports whether path is dir or lies inside it
+func IsUnder(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+var (
+	older = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testSet() models.DuplicateSet {
+	return models.DuplicateSet{
+		ID: 1,
+		Files: []models.FileInfo{
+			{Path: "/downloads/deep/photo.jpg", Size: 100, ModTime: newer},
+			{Path: "/master/photo.jpg", Size: 100, ModTime: older},
+		},
+		HashComputed: true,
+	}
+}
+
+func TestApply_KeepStrategies(t *testing.T) {
+	tests := []struct {
+		keep     string
+		expected string
+	}{
+		{KeepNewest, "/downloads/deep/photo.jpg"},
+		{KeepOldest, "/master/photo.jpg"},
+		{KeepShortestPath, "/master/photo.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keep, func(t *testing.T) {
+			d := Policy{Keep: tt.keep}.Apply(testSet())
+			assert.Equal(t, tt.expected, d.Keep.Path)
+			assert.Len(t, d.Remove, 1)
+			assert.Equal(t, int64(100), d.Savings())
+			assert.True(t, d.Verified)
+		})
+	}
+}
+
+func TestApply_PreferDirWinsOverStrategy(t *testing.T) {
+	d := Policy{Keep: KeepNewest, PreferDirs: []string{"/master"}}.Apply(testSet())
+	assert.Equal(t, "/master/photo.jpg", d.Keep.Path)
+
+	// A directory that merely shares a prefix is not preferred
+	d = Policy{Keep: KeepNewest, PreferDirs: []string{"/mast"}}.Apply(testSet())
+	assert.Equal(t, "/downloads/deep/photo.jpg", d.Keep.Path)
+}
+
+func TestApply_TieBreaksOnPath(t *testing.T) {
+	set := models.DuplicateSet{Files: []models.FileInfo{
+		{Path: "/b/x", ModTime: older},
+		{Path: "/a/x", ModTime: older},
+	}}
+
+	d := Policy{Keep: KeepNewest}.Apply(set)
+	assert.Equal(t, "/a/x", d.Keep.Path)
+}
+
+func TestApply_Priorities(t *testing.T) {
+	p := Policy{Keep: KeepNewest, Priorities: map[string]int{"/master": 1, "/downloads": 9}}
+	d := p.Apply(testSet())
+	assert.Equal(t, "/master/photo.jpg", d.Keep.Path)
+
+	// The most specific prioritized directory applies
+	p.Priorities["/downloads/deep"] = 0
+	d = p.Apply(testSet())
+	assert.Equal(t, "/downloads/deep/photo.jpg", d.Keep.Path)
+
+	// Preferred directories still come first
+	p.PreferDirs = []string{"/master"}
+	d = p.Apply(testSet())
+	assert.Equal(t, "/master/photo.jpg", d.Keep.Path)
+}
+
+func TestSuggest(t *testing.T) {
+	keep, ok := Policy{Priorities: map[string]int{"/master": 1}}.Suggest(testSet())
+	assert.True(t, ok)
+	assert.Equal(t, "/master/photo.jpg", keep.Path)
+
+	// Equal priorities or no priorities give no suggestion
+	_, ok = Policy{Priorities: map[string]int{"/master": 1, "/downloads": 1}}.Suggest(testSet())
+	assert.False(t, ok)
+	_, ok = Policy{}.Suggest(testSet())
+	assert.False(t, ok)
+}
+
+func TestParsePriorities(t *testing.T) {
+	priorities, err := ParsePriorities([]string{"/master=1", "/a=b=9"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"/master": 1, "/a=b": 9}, priorities)
+
+	for _, bad := range []string{"/master", "=1", "/master=high"} {
+		_, err := ParsePriorities([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Policy{Keep: KeepNewest}.Validate())
+	assert.Error(t, Policy{Keep: "biggest"}.Validate())
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"90d", 90 * 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"0d", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseAge(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, value := range []string{"90", "d", "1.5d", "ninety days", "-3d", "-1h"} {
+		_, err := ParseAge(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "90d", FormatAge(90*24*time.Hour))
+	assert.Equal(t, "36h0m0s", FormatAge(36*time.Hour))
+	assert.Equal(t, "30m0s", FormatAge(30*time.Minute))
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// ParsePriorities parses --priority values of the form "DIR=N"
+func ParsePriorities(values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	priorities := make(map[string]int, len(values))
+	for _, v := range values {
+		// Split at the last "=" so directory names may contain one
+		i := strings.LastIndex(v, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid --priority value %q: expected DIR=N", v)
+		}
+		dir := strings.TrimSpace(v[:i])
+		n, err := strconv.Atoi(strings.TrimSpace(v[i+1:]))
+		if dir == "" || err != nil {
+			return nil, fmt.Errorf("invalid --priority value %q: expected DIR=N", v)
+		}
+		priorities[dir] = n
+	}
+	return priorities, nil
+}
+
+// priorityRank returns the priority of the most specific prioritized
+// directory containing file, or math.MaxInt if there is none
+func (p Policy) priorityRank(file models.FileInfo) int {
+	rank, depth := math.MaxInt, -1
+	for dir, priority := range p.Priorities {
+		if len(dir) > depth && IsUnder(file.Path, dir) {
+			rank, depth = priority, len(dir)
+		}
+	}
+	return rank
+}
+
+// Suggest returns the file that directory priorities alone would keep.
+// ok is false unless exactly one file has the best priority.
+func (p Policy) Suggest(set models.DuplicateSet) (keep models.FileInfo, ok bool) {
+	best, count := math.MaxInt, 0
+	for _, file := range set.Files {
+		switch rank := p.priorityRank(file); {
+		case rank < best:
+			best, count, keep = rank, 1, file
+		case rank == best:
+			count++
+		}
+	}
+	return keep, count == 1 && best != math.MaxInt
+}
@@ -0,0 +1,208 @@
+// Package progress counts the file(s) found by the scanner and the bytes read
+// for hashing, and renders them as a live status line on a terminal, so
+// long scans do not sit silent
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// Counters shared by every scan and hash in the process. They only grow; a
+// Display counts from their values when it starts.
+var (
+	filesFound  atomic.Int64
+	bytesQueued atomic.Int64
+	bytesHashed atomic.Int64
+)
+
+// FileFound counts a file kept by the scanner
+func FileFound() {
+	filesFound.Add(1)
+}
+
+// HashQueued counts bytes of files about to be hashed, the total the ETA
+// is computed against
+func HashQueued(bytes int64) {
+	bytesQueued.Add(bytes)
+}
+
+// Hashed counts bytes of queued files that were hashed
+func Hashed(bytes int64) {
+	bytesHashed.Add(bytes)
+}
+
+// counts is a reading of the counters
+type counts struct {
+	files, queued, hashed int64
+}
+
+func readCounts() counts {
+	return counts{files: filesFound.Load(), queued: bytesQueued.Load(), hashed: bytesHashed.Load()}
+}
+
+// rateSmoothing is the weight of the latest interval in the throughput
+// shown, so one slow directory does not swing the ETA
+const rateSmoothing = 0.3
+
+// Display redraws a status line on a terminal at a fixed interval. Other
+// output meant for the same terminal should be written through the Display,
+// which clears the line first. A nil Display does nothing, so callers need
+// not check whether progress is shown.
+type Display struct {
+	w     io.Writer
+	width int
+
+	mu     sync.Mutex
+	base   counts    // Counters when the display started
+	last   counts    // Counters at the previous tick
+	lastAt time.Time // Time of the previous tick
+	rate   float64   // Smoothed bytes hashed per second
+	drawn  bool      // The status line is on screen
+	paused bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Start draws the status line on w every interval until Stop. Lines are
+// cut to width columns when width is positive.
+func Start(w io.Writer, width int, interval time.Duration) *Display {
+	now := readCounts()
+	d := &Display{w: w, width: width, base: now, last: now, lastAt: time.Now(), stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case t := <-ticker.C:
+				d.tick(t)
+			}
+		}
+	}()
+	return d
+}
+
+// Stop stops drawing and clears the status line. Calling it again does
+// nothing.
+func (d *Display) Stop() {
+	if d == nil {
+		return
+	}
+	d.stopOnce.Do(func() { close(d.stop) })
+	<-d.done
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+}
+
+// Pause clears the status line and stops drawing it until Resume, e.g.
+// while a question waits for an answer on the terminal
+func (d *Display) Pause() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	d.paused = true
+}
+
+// Resume draws the status line again after Pause
+func (d *Display) Resume() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = false
+}
+
+// Write clears the status line and writes p below where it was; the line is
+// drawn again at the next tick
+func (d *Display) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	return d.w.Write(p)
+}
+
+// clear erases the status line if it is drawn. Called with mu held.
+func (d *Display) clear() {
+	if d.drawn {
+		fmt.Fprint(d.w, "\r\033[K")
+		d.drawn = false
+	}
+}
+
+// tick updates the throughput and redraws the status line
+func (d *Display) tick(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := readCounts()
+	if elapsed := now.Sub(d.lastAt).Seconds(); elapsed > 0 {
+		latest := float64(current.hashed-d.last.hashed) / elapsed
+		if d.rate == 0 {
+			d.rate = latest
+		} else {
+			d.rate = rateSmoothing*latest + (1-rateSmoothing)*d.rate
+		}
+	}
+	d.last, d.lastAt = current, now
+
+	if d.paused {
+		return
+	}
+	fmt.Fprintf(d.w, "\r\033[K%s", cut(d.line(current), d.width))
+	d.drawn = true
+}
+
+// line renders the counters since the display started, e.g.
+// "1,234,567 file(s) found · hashed 1.2 GiB of 4.5 GiB (27%) at 310 MiB/s, ETA 11s"
+func (d *Display) line(current counts) string {
+	files := current.files - d.base.files
+	queued := current.queued - d.base.queued
+	hashed := min(current.hashed-d.base.hashed, queued)
+
+	line := fmt.Sprintf("%s file(s) found", output.FormatCount(files))
+	if queued == 0 {
+		return line
+	}
+	line += fmt.Sprintf(" · hashed %s of %s (%d%%)", output.FormatSize(hashed), output.FormatSize(queued), hashed*100/queued)
+	if d.rate >= 1 {
+		line += fmt.Sprintf(" at %s/s", output.FormatSize(int64(d.rate)))
+		if remaining := queued - hashed; remaining > 0 {
+			eta := time.Duration(float64(remaining) / d.rate * float64(time.Second))
+			line += ", ETA " + eta.Round(time.Second).String()
+		}
+	}
+	return line
+}
+
+// cut shortens line to fit width columns, so it never wraps and leaves a
+// line behind that \r cannot return to
+func cut(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) < width {
+		return line
+	}
+	var b strings.Builder
+	for i, r := range []rune(line) {
+		if i >= width-2 {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + "…"
+}
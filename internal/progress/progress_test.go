@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplay_Line(t *testing.T) {
+	d := &Display{base: counts{files: 10, queued: 100, hashed: 100}}
+
+	assert.Equal(t, "1234 file(s) found", d.line(counts{files: 1244, queued: 100, hashed: 100}))
+
+	// Hashed bytes beyond those queued are not counted
+	current := counts{files: 1244, queued: 100 + 4<<30, hashed: 100 + 1<<30}
+	assert.Equal(t, "1234 file(s) found · hashed 1.0 GiB of 4.0 GiB (25%)", d.line(current))
+
+	d.rate = 256 << 20
+	assert.Equal(t, "1234 file(s) found · hashed 1.0 GiB of 4.0 GiB (25%) at 256.0 MiB/s, ETA 12s", d.line(current))
+}
+
+func TestDisplay_Tick(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Now()
+	d := &Display{w: &buf, base: readCounts(), last: readCounts(), lastAt: start}
+
+	FileFound()
+	HashQueued(2000)
+	Hashed(1000)
+	d.tick(start.Add(time.Second))
+	assert.Equal(t, 1000.0, d.rate)
+	assert.Equal(t, "\r\033[K1 file(s) found · hashed 1000 B of 2.0 KiB (50%) at 1000 B/s, ETA 1s", buf.String())
+
+	// Later rates are smoothed
+	Hashed(500)
+	d.tick(start.Add(2 * time.Second))
+	assert.InDelta(t, 850.0, d.rate, 0.001)
+
+	// Other output clears the line first, and a paused display draws nothing
+	buf.Reset()
+	d.Write([]byte("Warning\n"))
+	assert.Equal(t, "\r\033[KWarning\n", buf.String())
+	buf.Reset()
+	d.Pause()
+	d.tick(start.Add(3 * time.Second))
+	assert.Empty(t, buf.String())
+}
+
+func TestDisplay_StartStop(t *testing.T) {
+	var buf safeBuffer
+	d := Start(&buf, 0, time.Millisecond)
+	FileFound()
+	assert.Eventually(t, func() bool { return strings.Contains(buf.String(), "1 file(s) found") }, time.Second, time.Millisecond)
+	d.Stop()
+	assert.True(t, strings.HasSuffix(buf.String(), "\r\033[K"))
+	d.Stop()
+
+	var none *Display
+	none.Pause()
+	none.Resume()
+	none.Stop()
+}
+
+func TestCut(t *testing.T) {
+	assert.Equal(t, "short", cut("short", 0))
+	assert.Equal(t, "short", cut("short", 10))
+	assert.Equal(t, "1234 fil…", cut("1234 file(s) found", 10))
+	assert.Equal(t, "写真写…", cut("写真写真写真", 5))
+}
+
+// safeBuffer is a bytes.Buffer that can be read while a Display writes to it
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
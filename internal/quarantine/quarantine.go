@@ -0,0 +1,346 @@
+// Package quarantine moves files chosen for deletion into a quarantine
+// directory instead of removing them, and records each move in a manifest
+// so they can be put back
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// Version is the current version of the manifest
+const Version = 1
+
+// ManifestName is the name of the manifest inside the quarantine directory
+const ManifestName = "manifest.json"
+
+// Entry maps a file's original path to where it was moved. Quarantined is
+// relative to the quarantine directory, so the directory can be moved as
+// a whole.
+type Entry struct {
+	Original    string    `json:"original"`
+	Quarantined string    `json:"quarantined"`
+	Size        int64     `json:"size"`
+	Moved       time.Time `json:"moved"`
+	RunID       string    `json:"run_id,omitempty"` // Run that moved the file
+}
+
+// Manifest lists the files currently held in a quarantine directory
+type Manifest struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Failure is a file that could not be put back
+type Failure struct {
+	Entry Entry
+	Err   error
+}
+
+// LoadManifest reads the manifest of dir. A missing manifest is empty.
+func LoadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid quarantine manifest %s: %w", path, err)
+	}
+	if m.Version > Version {
+		return nil, fmt.Errorf("quarantine manifest %s has version %d; this build supports up to %d", path, m.Version, Version)
+	}
+	return &m, nil
+}
+
+// save writes the manifest into dir, replacing the earlier one only once
+// the new one is complete
+func (m *Manifest) save(dir string) error {
+	m.Version = Version
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := output.CreateAtomic(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot save quarantine manifest: %w", err)
+	}
+	return file.Commit()
+}
+
+// Move moves paths into dir using numWorkers goroutines (the CPU count if
+// numWorkers is not positive) and returns one result per path, in order.
+// Each file goes to its absolute path mirrored under dir, so files that
+// share a name never meet. The manifest lists every planned move before any
+// file is touched, and is rewritten without the failed ones afterwards, so
+// an interrupted run can still be restored.
+func Move(dir string, paths []string, numWorkers int) ([]models.DeletionResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create quarantine directory: %w", err)
+	}
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Destinations are picked one at a time, so two moves never share one
+	results := make([]models.DeletionResult, len(paths))
+	planned := make([]Entry, len(paths))
+	taken := make(map[string]bool)
+	for _, entry := range manifest.Entries {
+		taken[entry.Quarantined] = true
+	}
+	for i, path := range paths {
+		results[i].Path = path
+		entry, err := plan(dir, path, taken)
+		if err != nil {
+			results[i].Error = err
+			continue
+		}
+		taken[entry.Quarantined] = true
+		planned[i] = entry
+	}
+
+	before := len(manifest.Entries)
+	for i, entry := range planned {
+		if results[i].Error == nil {
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+	if err := manifest.save(dir); err != nil {
+		return nil, err
+	}
+
+	parallel(len(paths), numWorkers, func(i int) {
+		if results[i].Error != nil {
+			return
+		}
+		entry := planned[i]
+		if err := move(entry.Original, filepath.Join(dir, entry.Quarantined)); err != nil {
+			results[i].Error = fmt.Errorf("quarantine failed: %w", err)
+			return
+		}
+		results[i].Success = true
+		results[i].SizeFreed = entry.Size
+	})
+
+	manifest.Entries = manifest.Entries[:before]
+	for i, entry := range planned {
+		if results[i].Success {
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+	return results, manifest.save(dir)
+}
+
+// plan checks path and picks its destination inside dir, relative to dir.
+// The mirrored path is used when free; otherwise a short content hash is
+// added to the name, then a counter.
+func plan(dir, path string, taken map[string]bool) (Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("cannot access file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return Entry{}, fmt.Errorf("not a regular file")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("cannot resolve path: %w", err)
+	}
+
+	rel := mirror(abs)
+	free := func(rel string) bool {
+		if taken[rel] {
+			return false
+		}
+		_, err := os.Lstat(filepath.Join(dir, rel))
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if !free(rel) {
+		hash, err := finder.CalculateFileHash(abs)
+		if err != nil {
+			return Entry{}, fmt.Errorf("cannot hash file: %w", err)
+		}
+		ext := filepath.Ext(rel)
+		base := strings.TrimSuffix(rel, ext) + "~" + hash[:8]
+		rel = base + ext
+		for n := 2; !free(rel); n++ {
+			rel = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+	}
+	return Entry{Original: abs, Quarantined: rel, Size: info.Size(), Moved: time.Now(), RunID: runid.ID()}, nil
+}
+
+// mirror turns an absolute path into a relative one that keeps all of it,
+// with a Windows volume such as C: becoming a leading C directory
+func mirror(abs string) string {
+	volume := filepath.VolumeName(abs)
+	prefix := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '\\', '/':
+			return -1
+		}
+		return r
+	}, volume)
+	return filepath.Join(prefix, strings.TrimLeft(abs[len(volume):], `\/`))
+}
+
+// Restore moves every file listed in the manifest of dir back to its
+// original path and drops it from the manifest. A file whose original path
+// has been taken again is left in quarantine and reported as a failure.
+// An entry whose file was never moved, by a run interrupted part way, is
+// dropped as restored.
+func Restore(dir string, numWorkers int) (restored []Entry, failed []Failure, err error) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(manifest.Entries) == 0 {
+		return nil, nil, nil
+	}
+
+	errs := make([]error, len(manifest.Entries))
+	parallel(len(manifest.Entries), numWorkers, func(i int) {
+		entry := manifest.Entries[i]
+		_, missing := os.Lstat(filepath.Join(dir, entry.Quarantined))
+		if _, err := os.Lstat(entry.Original); err == nil {
+			if errors.Is(missing, os.ErrNotExist) {
+				// Listed by a run interrupted before the move
+				return
+			}
+			errs[i] = fmt.Errorf("%s already exists", entry.Original)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.Original), 0755); err != nil {
+			errs[i] = err
+			return
+		}
+		errs[i] = move(filepath.Join(dir, entry.Quarantined), entry.Original)
+	})
+
+	var remaining []Entry
+	for i, entry := range manifest.Entries {
+		if errs[i] != nil {
+			failed = append(failed, Failure{Entry: entry, Err: errs[i]})
+			remaining = append(remaining, entry)
+			continue
+		}
+		restored = append(restored, entry)
+		removeEmptyParents(dir, filepath.Dir(filepath.Join(dir, entry.Quarantined)))
+	}
+	manifest.Entries = remaining
+	return restored, failed, manifest.save(dir)
+}
+
+// removeEmptyParents removes directory and its parents up to, but not
+// including, root while they are empty
+func removeEmptyParents(root, directory string) {
+	root = filepath.Clean(root)
+	for directory != root && strings.HasPrefix(directory, root) {
+		if os.Remove(directory) != nil {
+			return
+		}
+		directory = filepath.Dir(directory)
+	}
+}
+
+// move renames src to dst, creating dst's directory. Across file systems,
+// where a rename cannot work, the file is copied and src removed.
+func move(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	renameErr := os.Rename(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	var linkErr *os.LinkError
+	if !errors.As(renameErr, &linkErr) {
+		return renameErr
+	}
+	if err := copyFile(src, dst); err != nil {
+		return fmt.Errorf("%w (copying instead: %v)", renameErr, err)
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to a new file dst with the same mode and
+// modification time. dst must not exist.
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// parallel calls fn for 0..n-1 using numWorkers goroutines (the CPU count
+// if numWorkers is not positive)
+func parallel(n, numWorkers int, fn func(int)) {
+	if numWorkers < 1 {
+		numWorkers = runtime.NumCPU()
+	}
+	jobs := make(chan int, n)
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(numWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
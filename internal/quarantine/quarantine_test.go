@@ -0,0 +1,170 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestMove_SameNameDoesNotCollide(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	paths := []string{
+		filepath.Join(src, "a", "photo.jpg"),
+		filepath.Join(src, "b", "photo.jpg"),
+		filepath.Join(src, "c", "photo.jpg"),
+	}
+	for i, path := range paths {
+		writeFile(t, path, string(rune('x'+i)))
+	}
+
+	results, err := Move(dir, paths, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for i, result := range results {
+		assert.True(t, result.Success, result.Error)
+		assert.Equal(t, paths[i], result.Path)
+		assert.Equal(t, int64(1), result.SizeFreed)
+		assert.NoFileExists(t, paths[i])
+	}
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 3)
+	for i, entry := range manifest.Entries {
+		assert.Equal(t, paths[i], entry.Original)
+		assert.Equal(t, mirror(paths[i]), entry.Quarantined)
+		data, err := os.ReadFile(filepath.Join(dir, entry.Quarantined))
+		require.NoError(t, err)
+		assert.Equal(t, string(rune('x'+i)), string(data))
+	}
+}
+
+func TestMove_HashSuffixWhenPathQuarantinedBefore(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	path := filepath.Join(src, "report.pdf")
+
+	writeFile(t, path, "first")
+	_, err := Move(dir, []string{path}, 1)
+	require.NoError(t, err)
+
+	writeFile(t, path, "second")
+	results, err := Move(dir, []string{path}, 1)
+	require.NoError(t, err)
+	require.True(t, results[0].Success, results[0].Error)
+
+	writeFile(t, path, "second")
+	results, err = Move(dir, []string{path}, 1)
+	require.NoError(t, err)
+	require.True(t, results[0].Success, results[0].Error)
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 3)
+	first, second, third := manifest.Entries[0].Quarantined, manifest.Entries[1].Quarantined, manifest.Entries[2].Quarantined
+	assert.Equal(t, mirror(path), first)
+	assert.Regexp(t, `report~[0-9a-f]{8}\.pdf$`, second)
+	assert.Regexp(t, `report~[0-9a-f]{8}-2\.pdf$`, third)
+}
+
+func TestMove_Failures(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	good := filepath.Join(src, "good.txt")
+	writeFile(t, good, "data")
+
+	results, err := Move(dir, []string{filepath.Join(src, "missing.txt"), src, good}, 2)
+	require.NoError(t, err)
+	assert.ErrorContains(t, results[0].Error, "cannot access file")
+	assert.ErrorContains(t, results[1].Error, "not a regular file")
+	assert.True(t, results[2].Success)
+
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 1)
+	assert.Equal(t, good, manifest.Entries[0].Original)
+}
+
+func TestRestore(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	kept := filepath.Join(src, "a", "notes.txt")
+	taken := filepath.Join(src, "b", "notes.txt")
+	writeFile(t, kept, "kept")
+	writeFile(t, taken, "taken")
+
+	_, err := Move(dir, []string{kept, taken}, 2)
+	require.NoError(t, err)
+
+	// Something new now lives at one of the original paths
+	writeFile(t, taken, "new")
+
+	restored, failed, err := Restore(dir, 2)
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	assert.Equal(t, kept, restored[0].Original)
+	require.Len(t, failed, 1)
+	assert.Equal(t, taken, failed[0].Entry.Original)
+	assert.ErrorContains(t, failed[0].Err, "already exists")
+
+	data, err := os.ReadFile(kept)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(data))
+	assert.NoDirExists(t, filepath.Dir(filepath.Join(dir, mirror(kept))))
+
+	// The failed file stays listed, to be restored once its path is free
+	manifest, err := LoadManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Entries, 1)
+	assert.Equal(t, taken, manifest.Entries[0].Original)
+}
+
+func TestRestore_InterruptedMove(t *testing.T) {
+	src := t.TempDir()
+	dir := t.TempDir()
+	path := filepath.Join(src, "file.txt")
+	writeFile(t, path, "data")
+
+	// A manifest written by a run that stopped before moving the file
+	manifest := &Manifest{Entries: []Entry{{Original: path, Quarantined: mirror(path), Size: 4}}}
+	require.NoError(t, manifest.save(dir))
+
+	restored, failed, err := Restore(dir, 1)
+	require.NoError(t, err)
+	assert.Len(t, restored, 1)
+	assert.Empty(t, failed)
+	assert.FileExists(t, path)
+}
+
+func TestLoadManifest_NewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ManifestName), `{"version": 99, "entries": []}`)
+
+	_, err := LoadManifest(dir)
+	assert.ErrorContains(t, err, "version 99")
+}
+
+func TestCopyFile(t *testing.T) {
+	src := t.TempDir()
+	from := filepath.Join(src, "from.txt")
+	to := filepath.Join(src, "to.txt")
+	writeFile(t, from, "content")
+
+	require.NoError(t, copyFile(from, to))
+	data, err := os.ReadFile(to)
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(data))
+
+	// The destination is never overwritten
+	assert.Error(t, copyFile(from, to))
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Anonymize returns a copy of results with every directory and file name
+// replaced by a stable pseudonym. The same name always maps to the same
+// pseudonym, so the tree structure, sizes, times, hashes and extensions
+// survive while private names do not. Review notes, free text that often
+// names people and places, become pseudonyms too.
+func Anonymize(results *models.Results) *models.Results {
+	a := &anonymizer{
+		dirs:  make(map[string]string),
+		files: make(map[string]string),
+		notes: make(map[string]string),
+	}
+
+	anon := &models.Results{
+		Version:     results.Version,
+		GeneratedAt: results.GeneratedAt,
+		RunID:       results.RunID,
+		Comparisons: make([]models.PairComparison, len(results.Comparisons)),
+	}
+	for i, comparison := range results.Comparisons {
+		c := comparison
+		c.Dir1 = a.dir(comparison.Dir1)
+		c.Dir2 = a.dir(comparison.Dir2)
+		c.Matches = make([]models.FileMatch, len(comparison.Matches))
+		for j, match := range comparison.Matches {
+			match.Filename = a.name(a.files, "file", match.Filename)
+			match.File1 = a.file(match.File1)
+			match.File2 = a.file(match.File2)
+			match.AlsoIn = a.contexts(match.AlsoIn)
+			match.Note = a.note(match.Note)
+			c.Matches[j] = match
+		}
+		anon.Comparisons[i] = c
+	}
+
+	if len(results.Labels) > 0 {
+		// Sorted so pseudonyms do not depend on map order
+		roots := make([]string, 0, len(results.Labels))
+		for root := range results.Labels {
+			roots = append(roots, root)
+		}
+		sort.Strings(roots)
+
+		anon.Labels = make(map[string]string, len(roots))
+		for i, root := range roots {
+			anon.Labels[a.dir(root)] = fmt.Sprintf("label%d", i+1)
+		}
+	}
+	return anon
+}
+
+// anonymizer hands out pseudonyms in order of first appearance
+type anonymizer struct {
+	dirs  map[string]string
+	files map[string]string
+	notes map[string]string
+}
+
+// note replaces a review note with note-N, keeping which sets share a note
+func (a *anonymizer) note(note string) string {
+	if note == "" {
+		return ""
+	}
+	if pseudonym, ok := a.notes[note]; ok {
+		return pseudonym
+	}
+	pseudonym := fmt.Sprintf("note-%d", len(a.notes)+1)
+	a.notes[note] = pseudonym
+	return pseudonym
+}
+
+func (a *anonymizer) file(info models.FileInfo) models.FileInfo {
+	info.Directory = a.dir(info.Directory)
+	dir, base := splitLast(info.Path)
+	info.Path = a.dir(dir) + a.name(a.files, "file", base)
+	return info
+}
+
+// contexts anonymizes the directory pairs a match was also found through
+func (a *anonymizer) contexts(contexts []models.MatchContext) []models.MatchContext {
+	if contexts == nil {
+		return nil
+	}
+	anon := make([]models.MatchContext, len(contexts))
+	for i, c := range contexts {
+		anon[i] = models.MatchContext{
+			Dir1:  a.dir(c.Dir1),
+			Dir2:  a.dir(c.Dir2),
+			Path1: a.file(models.FileInfo{Path: c.Path1}).Path,
+			Path2: a.file(models.FileInfo{Path: c.Path2}).Path,
+		}
+	}
+	return anon
+}
+
+// dir anonymizes every component of a directory path, keeping separators,
+// the root and Windows drive letters
+func (a *anonymizer) dir(path string) string {
+	var builder strings.Builder
+	start := 0
+	for i := 0; i <= len(path); i++ {
+		if i < len(path) && !isSeparator(path[i]) {
+			continue
+		}
+		component := path[start:i]
+		if component == "" || component == "." || component == ".." || isDrive(component) {
+			builder.WriteString(component)
+		} else {
+			builder.WriteString(a.name(a.dirs, "dir", component))
+		}
+		if i < len(path) {
+			builder.WriteByte(path[i])
+		}
+		start = i + 1
+	}
+	return builder.String()
+}
+
+// name returns the pseudonym for name, keeping a file extension
+func (a *anonymizer) name(names map[string]string, prefix, name string) string {
+	if pseudonym, ok := names[name]; ok {
+		return pseudonym
+	}
+	ext := ""
+	if prefix == "file" {
+		ext = filepath.Ext(name)
+	}
+	pseudonym := fmt.Sprintf("%s%d%s", prefix, len(names)+1, ext)
+	names[name] = pseudonym
+	return pseudonym
+}
+
+// splitLast splits path after its last separator; dir keeps the separator
+func splitLast(path string) (dir, base string) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if isSeparator(path[i]) {
+			return path[:i+1], path[i+1:]
+		}
+	}
+	return "", path
+}
+
+// isSeparator accepts both separators so results saved on another OS work
+func isSeparator(c byte) bool {
+	return c == '/' || c == '\\'
+}
+
+// isDrive reports whether component is a Windows drive such as "C:"
+func isDrive(component string) bool {
+	return len(component) == 2 && component[1] == ':' &&
+		(component[0] >= 'a' && component[0] <= 'z' || component[0] >= 'A' && component[0] <= 'Z')
+}
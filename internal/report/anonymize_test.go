@@ -0,0 +1,73 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestAnonymize(t *testing.T) {
+	m := match("holiday.jpg", true, true)
+	m.File1.Path = "/a/private/holiday.jpg"
+	m.File1.Size, m.File2.Size = 2048, 2048
+	m.File1.Hash, m.File2.Hash = "abc", "abc"
+
+	original := results(m, match("tax-return.pdf", false, false))
+	original.Labels = map[string]string{"/a": "home"}
+
+	anon := Anonymize(original)
+
+	require.Len(t, anon.Comparisons, 1)
+	c := anon.Comparisons[0]
+	assert.Equal(t, "/dir1", c.Dir1)
+	assert.Equal(t, "/dir2", c.Dir2)
+
+	first := c.Matches[0]
+	assert.Equal(t, "file1.jpg", first.Filename)
+	assert.Equal(t, "/dir1/dir3/file1.jpg", first.File1.Path)
+	assert.Equal(t, "/dir1", first.File1.Directory)
+	assert.Equal(t, "/dir2/file1.jpg", first.File2.Path)
+	assert.Equal(t, int64(2048), first.File1.Size)
+	assert.Equal(t, "abc", first.File1.Hash)
+	assert.True(t, first.HashMatch)
+
+	assert.Equal(t, "/dir1/file2.pdf", c.Matches[1].File1.Path)
+	assert.Equal(t, map[string]string{"/dir1": "label1"}, anon.Labels)
+
+	// The original is left untouched
+	assert.Equal(t, "/a/private/holiday.jpg", original.Comparisons[0].Matches[0].File1.Path)
+}
+
+func TestAnonymize_WindowsPaths(t *testing.T) {
+	a := &anonymizer{dirs: make(map[string]string), files: make(map[string]string)}
+
+	assert.Equal(t, `C:\dir1\dir2`, a.dir(`C:\Users\alice`))
+	assert.Equal(t, `D:\dir2`, a.dir(`D:\alice`))
+}
+
+func TestAnonymize_AlsoIn(t *testing.T) {
+	m := match("holiday.jpg", true, true)
+	m.AlsoIn = []models.MatchContext{{Dir1: "/b", Dir2: "/link", Path1: "/b/holiday.jpg", Path2: "/link/holiday.jpg"}}
+
+	anon := Anonymize(results(m))
+
+	assert.Equal(t, []models.MatchContext{{Dir1: "/dir2", Dir2: "/dir3", Path1: "/dir2/file1.jpg", Path2: "/dir3/file1.jpg"}},
+		anon.Comparisons[0].Matches[0].AlsoIn)
+}
+
+func TestAnonymize_Notes(t *testing.T) {
+	first := match("holiday.jpg", true, true)
+	first.Note = "Alice's copy, keep the one in /home/alice"
+	second := match("tax-return.pdf", true, true)
+	second.Note = first.Note
+
+	anon := Anonymize(results(first, second, match("cv.pdf", true, true)))
+
+	matches := anon.Comparisons[0].Matches
+	assert.Equal(t, "note-1", matches[0].Note)
+	assert.Equal(t, "note-1", matches[1].Note)
+	assert.Empty(t, matches[2].Note)
+}
@@ -0,0 +1,201 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// LoadPlan reads a plan saved by scan --save, report simulate --format json
+// or report suggest --out, possibly edited since
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("cannot parse plan %s: %w", path, err)
+	}
+	if plan.Version > PlanVersion {
+		return nil, fmt.Errorf("plan %s uses version %d, newer than supported version %d", path, plan.Version, PlanVersion)
+	}
+	if plan.Version == 0 || plan.Decisions == nil {
+		return nil, fmt.Errorf("%s is not a plan", path)
+	}
+	return &plan, nil
+}
+
+// AppendPlan adds decisions to the plan at path, numbering their sets
+// after those already there, or saves them as a new plan if there is none.
+// Decisions a person dropped from the plan since stay dropped.
+func AppendPlan(path string, decisions []policy.Decision) error {
+	var existing []policy.Decision
+	if _, err := os.Stat(path); err == nil {
+		plan, err := LoadPlan(path)
+		if err != nil {
+			return err
+		}
+		existing = plan.Decisions
+	}
+	next := 0
+	for _, d := range existing {
+		next = max(next, d.SetID)
+	}
+	for _, d := range decisions {
+		next++
+		d.SetID = next
+		existing = append(existing, d)
+	}
+
+	data, err := FormatPlan(existing)
+	if err != nil {
+		return err
+	}
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.WriteString(data); err != nil {
+		return fmt.Errorf("cannot save plan: %w", err)
+	}
+	return file.Commit()
+}
+
+// Skip is a planned removal that is no longer safe to carry out
+type Skip struct {
+	SetID  int
+	Path   string
+	Reason string
+}
+
+// PlanActions checks every removal of plan against the files as they are
+// now and returns the deletions that are still safe, with the reason each
+// other one is passed over. A removal is safe when the file and the copy
+// kept in its place are unchanged in size and modification time since the
+// plan, the file is not kept by any decision, and, with requireHash, both
+// have the same hash: the one recorded in the plan for unchanged files
+// that have one, otherwise computed now. With verify finder.VerifyBytes,
+// both are also compared byte by byte, so a hash collision never removes
+// a file.
+func PlanActions(plan *Plan, requireHash bool, verify string) ([]models.UserAction, []Skip) {
+	// A file kept by one set is never removed by another, however the plan
+	// was edited
+	kept := make(map[string]bool)
+	for _, d := range plan.Decisions {
+		kept[d.Keep.Path] = true
+		for _, file := range d.Retained {
+			kept[file.Path] = true
+		}
+	}
+
+	var actions []models.UserAction
+	var skips []Skip
+	for _, d := range plan.Decisions {
+		if len(d.Remove) == 0 {
+			continue
+		}
+		skipAll := func(reason string) {
+			for _, file := range d.Remove {
+				skips = append(skips, Skip{SetID: d.SetID, Path: file.Path, Reason: reason})
+			}
+		}
+		if !d.Keep.Offline {
+			if reason := changed(d.Keep); reason != "" {
+				skipAll("kept copy " + d.Keep.Path + " " + reason)
+				continue
+			}
+		}
+
+		keepHash := d.Keep.Hash
+		for _, file := range d.Remove {
+			skip := func(reason string) {
+				skips = append(skips, Skip{SetID: d.SetID, Path: file.Path, Reason: reason})
+			}
+			if kept[file.Path] {
+				skip("the plan also keeps it")
+				continue
+			}
+			if file.Offline {
+				skip("on an offline volume")
+				continue
+			}
+			if reason := changed(file); reason != "" {
+				skip(reason)
+				continue
+			}
+			if keepHash != "" && file.Hash != "" && keepHash != file.Hash {
+				skip("the plan records a different hash from the kept copy")
+				continue
+			}
+			if requireHash && (keepHash == "" || file.Hash == "") {
+				if d.Keep.Offline {
+					skip("the kept copy is on an offline volume and cannot be hashed")
+					continue
+				}
+				if keepHash == "" {
+					hash, err := finder.CalculateFileHash(d.Keep.Path)
+					if err != nil {
+						skip(fmt.Sprintf("cannot hash the kept copy: %v", err))
+						continue
+					}
+					keepHash = hash
+				}
+				hash, err := finder.CalculateFileHash(file.Path)
+				if err != nil {
+					skip(fmt.Sprintf("cannot hash: %v", err))
+					continue
+				}
+				if hash != keepHash {
+					skip("contents differ from the kept copy")
+					continue
+				}
+			}
+			if verify == finder.VerifyBytes {
+				if d.Keep.Offline {
+					skip("the kept copy is on an offline volume and cannot be compared")
+					continue
+				}
+				equal, err := finder.FilesEqual(d.Keep.Path, file.Path)
+				if err != nil {
+					skip(fmt.Sprintf("cannot compare with the kept copy: %v", err))
+					continue
+				}
+				if !equal {
+					skip("contents differ from the kept copy (byte comparison)")
+					continue
+				}
+			}
+
+			action := models.UserAction{Action: "delete", KeepFile: d.Keep.Path, DeleteFile: file.Path}
+			if reason, ok := d.Reason(file.Path); ok {
+				action.Reason = reason.Rule
+			}
+			actions = append(actions, action)
+		}
+	}
+	return actions, skips
+}
+
+// changed returns why file no longer looks as the plan recorded it, or ""
+// when it is the same regular file of the same size and modification time
+func changed(file models.FileInfo) string {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return "no longer exists or cannot be read"
+	}
+	if !info.Mode().IsRegular() {
+		return "is no longer a regular file"
+	}
+	if info.Size() != file.Size || !info.ModTime().Equal(file.ModTime) {
+		return "changed since the plan"
+	}
+	return ""
+}
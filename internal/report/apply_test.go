@@ -0,0 +1,145 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// planFile writes content to name under dir and returns it as the plan
+// records it
+func planFile(t *testing.T, dir, name, content string) models.FileInfo {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return models.FileInfo{Path: path, Directory: dir, Size: info.Size(), ModTime: info.ModTime()}
+}
+
+func TestLoadPlan(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.json")
+	data, err := FormatPlan([]policy.Decision{{SetID: 1, Keep: models.FileInfo{Path: "/a/x"}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	plan, err := LoadPlan(path)
+	require.NoError(t, err)
+	require.Len(t, plan.Decisions, 1)
+	assert.Equal(t, "/a/x", plan.Decisions[0].Keep.Path)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "comparisons": []}`), 0644))
+	_, err = LoadPlan(path)
+	assert.ErrorContains(t, err, "not a plan")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "decisions": []}`), 0644))
+	_, err = LoadPlan(path)
+	assert.ErrorContains(t, err, "newer than supported version")
+}
+
+func TestAppendPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	decision := func(keep, remove string) policy.Decision {
+		return policy.Decision{Keep: models.FileInfo{Path: keep}, Remove: []models.FileInfo{{Path: remove}}, Verified: true}
+	}
+
+	require.NoError(t, AppendPlan(path, []policy.Decision{decision("/a/x", "/in/x"), decision("/a/y", "/in/y")}))
+	// A reviewer dropped the first set; the next duplicate follows the last
+	plan, err := LoadPlan(path)
+	require.NoError(t, err)
+	data, err := FormatPlan(plan.Decisions[1:])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	require.NoError(t, AppendPlan(path, []policy.Decision{decision("/a/z", "/in/z")}))
+
+	plan, err = LoadPlan(path)
+	require.NoError(t, err)
+	require.Len(t, plan.Decisions, 2)
+	assert.Equal(t, 2, plan.Decisions[0].SetID)
+	assert.Equal(t, "/in/y", plan.Decisions[0].Remove[0].Path)
+	assert.Equal(t, 3, plan.Decisions[1].SetID)
+	assert.Equal(t, "/in/z", plan.Decisions[1].Remove[0].Path)
+}
+
+func TestPlanActions(t *testing.T) {
+	dir := t.TempDir()
+	keep := planFile(t, dir, "keep.txt", "same")
+	same := planFile(t, dir, "same.txt", "same")
+	other := planFile(t, dir, "other.txt", "diff")
+	edited := planFile(t, dir, "edited.txt", "same")
+	gone := planFile(t, dir, "gone.txt", "same")
+	third := planFile(t, dir, "third.txt", "same")
+	require.NoError(t, os.WriteFile(edited.Path, []byte("edited"), 0644))
+	require.NoError(t, os.Remove(gone.Path))
+
+	plan := &Plan{Version: PlanVersion, Decisions: []policy.Decision{
+		{
+			SetID:   1,
+			Keep:    keep,
+			Remove:  []models.FileInfo{same, other, edited, gone},
+			Reasons: []policy.Reason{{Path: same.Path, Action: policy.ActionRemove, Rule: "newest"}},
+		},
+		// A file one set keeps is not removed by another
+		{SetID: 2, Keep: third, Remove: []models.FileInfo{keep}},
+	}}
+
+	actions, skips := PlanActions(plan, true, finder.VerifyHash)
+	require.Len(t, actions, 1)
+	assert.Equal(t, models.UserAction{Action: "delete", KeepFile: keep.Path, DeleteFile: same.Path, Reason: "newest"}, actions[0])
+
+	reasons := make(map[string]string)
+	for _, skip := range skips {
+		reasons[skip.Path] = skip.Reason
+	}
+	assert.Equal(t, map[string]string{
+		other.Path:  "contents differ from the kept copy",
+		edited.Path: "changed since the plan",
+		gone.Path:   "no longer exists or cannot be read",
+		keep.Path:   "the plan also keeps it",
+	}, reasons)
+
+	// Without --require-hash, files are not read
+	actions, _ = PlanActions(plan, false, finder.VerifyHash)
+	assert.Len(t, actions, 2)
+}
+
+func TestPlanActions_KeptCopyChanged(t *testing.T) {
+	dir := t.TempDir()
+	keep := planFile(t, dir, "keep.txt", "same")
+	same := planFile(t, dir, "same.txt", "same")
+	require.NoError(t, os.Remove(keep.Path))
+
+	plan := &Plan{Version: PlanVersion, Decisions: []policy.Decision{{SetID: 1, Keep: keep, Remove: []models.FileInfo{same}}}}
+	actions, skips := PlanActions(plan, true, finder.VerifyHash)
+	assert.Empty(t, actions)
+	require.Len(t, skips, 1)
+	assert.Contains(t, skips[0].Reason, "kept copy")
+}
+
+func TestPlanActions_VerifyBytes(t *testing.T) {
+	dir := t.TempDir()
+	keep := planFile(t, dir, "keep.txt", "same")
+	same := planFile(t, dir, "same.txt", "same")
+	other := planFile(t, dir, "other.txt", "diff")
+	// The recorded hashes collide though the contents differ
+	keep.Hash, same.Hash, other.Hash = "abc", "abc", "abc"
+
+	plan := &Plan{Version: PlanVersion, Decisions: []policy.Decision{{SetID: 1, Keep: keep, Remove: []models.FileInfo{same, other}}}}
+	actions, _ := PlanActions(plan, true, finder.VerifyHash)
+	assert.Len(t, actions, 2)
+
+	actions, skips := PlanActions(plan, true, finder.VerifyBytes)
+	require.Len(t, actions, 1)
+	assert.Equal(t, same.Path, actions[0].DeleteFile)
+	require.Len(t, skips, 1)
+	assert.Equal(t, other.Path, skips[0].Path)
+	assert.Contains(t, skips[0].Reason, "byte comparison")
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// DiffResult lists duplicates that appeared or disappeared between two runs
+type DiffResult struct {
+	Introduced []models.FileMatch // Duplicates only present in the new results
+	Resolved   []models.FileMatch // Duplicates only present in the old results
+	Unchanged  int                // Duplicates present in both
+}
+
+// Diff compares two saved result sets
+func Diff(oldResults, newResults *models.Results) DiffResult {
+	oldDups := duplicates(oldResults)
+	newDups := duplicates(newResults)
+
+	var result DiffResult
+	for key, match := range newDups {
+		if _, ok := oldDups[key]; ok {
+			result.Unchanged++
+		} else {
+			result.Introduced = append(result.Introduced, match)
+		}
+	}
+	for key, match := range oldDups {
+		if _, ok := newDups[key]; !ok {
+			result.Resolved = append(result.Resolved, match)
+		}
+	}
+
+	sortMatches(result.Introduced)
+	sortMatches(result.Resolved)
+	return result
+}
+
+// FormatDiff renders a diff result as text, shortening labelled roots
+func FormatDiff(diff DiffResult, labels output.Labels) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("=== Newly introduced duplicates (%s) ===\n", output.FormatCount(len(diff.Introduced))))
+	for _, match := range diff.Introduced {
+		builder.WriteString(fmt.Sprintf("+ %s ↔ %s\n", labels.Path(match.File1.Path), labels.Path(match.File2.Path)))
+	}
+
+	builder.WriteString(fmt.Sprintf("\n=== Resolved duplicates (%s) ===\n", output.FormatCount(len(diff.Resolved))))
+	for _, match := range diff.Resolved {
+		builder.WriteString(fmt.Sprintf("- %s ↔ %s\n", labels.Path(match.File1.Path), labels.Path(match.File2.Path)))
+	}
+
+	builder.WriteString(fmt.Sprintf("\nUnchanged: %s\n", output.FormatCount(diff.Unchanged)))
+	return builder.String()
+}
+
+// sortMatches orders matches by their path pair for stable output
+func sortMatches(matches []models.FileMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		ki, kj := pairKey(matches[i]), pairKey(matches[j])
+		if ki[0] != kj[0] {
+			return ki[0] < kj[0]
+		}
+		return ki[1] < kj[1]
+	})
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// match builds a FileMatch between /a/name and /b/name
+func match(name string, hashChecked, hashMatch bool) models.FileMatch {
+	return models.FileMatch{
+		Filename:    name,
+		File1:       models.FileInfo{Path: "/a/" + name, Directory: "/a"},
+		File2:       models.FileInfo{Path: "/b/" + name, Directory: "/b"},
+		HashChecked: hashChecked,
+		HashMatch:   hashMatch,
+	}
+}
+
+func results(matches ...models.FileMatch) *models.Results {
+	return &models.Results{
+		Version:     models.ResultsVersion,
+		Comparisons: []models.PairComparison{{Dir1: "/a", Dir2: "/b", Matches: matches}},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldResults := results(match("kept.jpg", true, true), match("cleaned.jpg", true, true))
+	newResults := results(match("kept.jpg", true, true), match("new.jpg", false, false))
+
+	diff := Diff(oldResults, newResults)
+
+	require.Len(t, diff.Introduced, 1)
+	assert.Equal(t, "new.jpg", diff.Introduced[0].Filename)
+	require.Len(t, diff.Resolved, 1)
+	assert.Equal(t, "cleaned.jpg", diff.Resolved[0].Filename)
+	assert.Equal(t, 1, diff.Unchanged)
+
+	text := FormatDiff(diff, nil)
+	assert.Contains(t, text, "+ /a/new.jpg ↔ /b/new.jpg")
+	assert.Contains(t, text, "- /a/cleaned.jpg ↔ /b/cleaned.jpg")
+}
+
+func TestDiff_IgnoresHashMismatches(t *testing.T) {
+	// A same-named pair with different content is not a duplicate in either run
+	diff := Diff(results(), results(match("notes.txt", true, false)))
+
+	assert.Empty(t, diff.Introduced)
+	assert.Empty(t, diff.Resolved)
+}
+
+func TestDiff_PairOrderDoesNotMatter(t *testing.T) {
+	swapped := match("photo.jpg", true, true)
+	swapped.File1, swapped.File2 = swapped.File2, swapped.File1
+
+	diff := Diff(results(match("photo.jpg", true, true)), results(swapped))
+
+	assert.Empty(t, diff.Introduced)
+	assert.Empty(t, diff.Resolved)
+	assert.Equal(t, 1, diff.Unchanged)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	data, err := output.FormatJSON(results(match("photo.jpg", true, true)).Comparisons, output.Options{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Comparisons, 1)
+	assert.Equal(t, "photo.jpg", loaded.Comparisons[0].Matches[0].Filename)
+
+	// Newer document versions are rejected
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0644))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// Capture date grouping periods for --group-by
+const (
+	GroupByDay   = "day"
+	GroupByMonth = "month"
+)
+
+// GroupPeriods lists the accepted grouping periods
+var GroupPeriods = []string{GroupByDay, GroupByMonth}
+
+// unknownCapture heads the group of sets without a readable capture date
+const unknownCapture = "unknown capture date"
+
+// Group is a run of duplicate sets captured in the same period
+type Group struct {
+	Period    string
+	Decisions []policy.Decision
+}
+
+// CaptureFunc returns the capture time of an image
+type CaptureFunc func(path string) (time.Time, error)
+
+// ValidateGroupPeriod checks a --group-by value
+func ValidateGroupPeriod(period string) error {
+	for _, p := range GroupPeriods {
+		if p == period {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown group period %q (valid: %s, %s)", period, GroupByDay, GroupByMonth)
+}
+
+// GroupByCapture buckets decisions by the capture date of their files,
+// oldest period first. The kept file is tried first, then the removed
+// ones; sets without any capture date are grouped last.
+func GroupByCapture(decisions []policy.Decision, period string, capture CaptureFunc) []Group {
+	layout := "2006-01"
+	if period == GroupByDay {
+		layout = "2006-01-02"
+	}
+
+	index := make(map[string]int)
+	var groups []Group
+	for _, d := range decisions {
+		key := unknownCapture
+		if t, ok := decisionCaptureTime(d, capture); ok {
+			key = t.Format(layout)
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Period: key})
+		}
+		groups[i].Decisions = append(groups[i].Decisions, d)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Period == unknownCapture) != (groups[j].Period == unknownCapture) {
+			return groups[j].Period == unknownCapture
+		}
+		return groups[i].Period < groups[j].Period
+	})
+	return groups
+}
+
+// decisionCaptureTime returns the first capture time found among the
+// decision's files
+func decisionCaptureTime(d policy.Decision, capture CaptureFunc) (time.Time, bool) {
+	for _, file := range append([]models.FileInfo{d.Keep}, d.Remove...) {
+		if t, err := capture(file.Path); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
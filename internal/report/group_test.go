@@ -0,0 +1,60 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+func TestGroupByCapture(t *testing.T) {
+	captured := map[string]time.Time{
+		"/a/june.jpg":  time.Date(2023, 6, 10, 9, 0, 0, 0, time.UTC),
+		"/b/june2.jpg": time.Date(2023, 6, 28, 9, 0, 0, 0, time.UTC),
+		"/b/march.jpg": time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+	capture := func(path string) (time.Time, error) {
+		if t, ok := captured[path]; ok {
+			return t, nil
+		}
+		return time.Time{}, errors.New("no EXIF data")
+	}
+	decision := func(id int, keep, remove string) policy.Decision {
+		return policy.Decision{
+			SetID:  id,
+			Keep:   models.FileInfo{Path: keep},
+			Remove: []models.FileInfo{{Path: remove}},
+		}
+	}
+	decisions := []policy.Decision{
+		decision(1, "/a/june.jpg", "/b/june.jpg"),
+		decision(2, "/a/notes.txt", "/b/notes.txt"),
+		// Only the removed copy carries EXIF data
+		decision(3, "/a/march.jpg", "/b/march.jpg"),
+		decision(4, "/a/june2.jpg", "/b/june2.jpg"),
+	}
+
+	groups := GroupByCapture(decisions, GroupByMonth, capture)
+	require.Len(t, groups, 3)
+	assert.Equal(t, "2021-03", groups[0].Period)
+	assert.Equal(t, "2023-06", groups[1].Period)
+	assert.Len(t, groups[1].Decisions, 2)
+	assert.Equal(t, unknownCapture, groups[2].Period)
+
+	byDay := GroupByCapture(decisions, GroupByDay, capture)
+	assert.Len(t, byDay, 4)
+	assert.Equal(t, "2023-06-10", byDay[1].Period)
+
+	text := FormatGroupedSimulation(groups, output.Options{})
+	assert.Contains(t, text, "##### 2021-03: 1 set(s) #####")
+	assert.Contains(t, text, "Duplicate sets: 4")
+
+	assert.NoError(t, ValidateGroupPeriod(GroupByMonth))
+	assert.Error(t, ValidateGroupPeriod("year"))
+}
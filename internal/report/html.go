@@ -0,0 +1,186 @@
+package report
+
+import (
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	GeneratedAt string
+	RunID       string
+	Sets        []htmlSet
+	Dirs        []htmlDir
+	Files       int
+	Wasted      int64
+	Unverified  int
+	Hardlinked  int
+}
+
+// htmlSet is one duplicate set in the report
+type htmlSet struct {
+	ID       int
+	Name     string
+	Size     int64
+	Copies   int
+	Wasted   int64
+	Verified bool
+	Paths    []string
+	Note     string
+}
+
+// htmlDir summarizes the duplicates found under one root directory
+type htmlDir struct {
+	Dir   string
+	Files int
+	Bytes int64
+}
+
+// FormatHTML renders comparisons as a self-contained HTML page: the total
+// wasted space, a summary per root directory and every duplicate set, in
+// tables that sort by clicking a column heading. Pairs that are already
+// hardlinked are counted but not listed, since removing them frees nothing.
+func FormatHTML(comparisons []models.PairComparison, labels output.Labels) (string, error) {
+	var listed []models.PairComparison
+	r := htmlReport{GeneratedAt: time.Now().Format("2006-01-02 15:04"), RunID: runid.ID()}
+	for _, comparison := range comparisons {
+		kept := comparison
+		kept.Matches = nil
+		for _, match := range comparison.Matches {
+			if match.Hardlinked && match.Mismatch == "" {
+				r.Hardlinked++
+				continue
+			}
+			kept.Matches = append(kept.Matches, match)
+		}
+		listed = append(listed, kept)
+	}
+
+	dirs := make(map[string]*htmlDir)
+	sets := finder.MergeMatches(listed)
+	for _, set := range sets {
+		size := set.Files[0].Size
+		s := htmlSet{
+			ID:       set.ID,
+			Name:     filepath.Base(set.Files[0].Path),
+			Size:     size,
+			Copies:   len(set.Files),
+			Wasted:   finder.SetSavings(set),
+			Verified: set.HashComputed,
+			Note:     set.Note,
+		}
+		for _, file := range set.Files {
+			s.Paths = append(s.Paths, labels.Path(file.Path))
+
+			dir := labels.Dir(file.Directory)
+			if dirs[dir] == nil {
+				dirs[dir] = &htmlDir{Dir: dir}
+			}
+			dirs[dir].Files++
+			dirs[dir].Bytes += file.Size
+		}
+
+		r.Sets = append(r.Sets, s)
+		r.Files += len(set.Files)
+		if !s.Verified {
+			r.Unverified++
+		}
+	}
+
+	r.Wasted = finder.TotalSavings(sets)
+
+	// Biggest savings first
+	sort.SliceStable(r.Sets, func(i, j int) bool { return r.Sets[i].Wasted > r.Sets[j].Wasted })
+	for _, d := range dirs {
+		r.Dirs = append(r.Dirs, *d)
+	}
+	sort.Slice(r.Dirs, func(i, j int) bool { return r.Dirs[i].Dir < r.Dirs[j].Dir })
+
+	var builder strings.Builder
+	if err := htmlTemplate.Execute(&builder, r); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"size": output.FormatSize,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Duplicate files report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.summary { font-size: 1.2em; margin: 1em 0 2em; }
+.summary strong { color: #b03000; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; cursor: pointer; user-select: none; }
+th:hover { background: #e8e8e8; }
+td.num { text-align: right; white-space: nowrap; }
+td.paths { font-family: monospace; font-size: 0.9em; }
+.note { color: #666; }
+</style>
+</head>
+<body>
+<h1>Duplicate files report</h1>
+<p class="note">Created {{.GeneratedAt}} by run {{.RunID}}. Nothing has been deleted.</p>
+
+<p class="summary">
+{{len .Sets}} file(s) exist more than once, in {{.Files}} copies.
+Removing the extra copies would free <strong>{{size .Wasted}}</strong>.
+</p>
+{{if .Unverified}}<p class="note">{{.Unverified}} set(s) matched by name and size only; their contents were not compared.</p>{{end}}
+{{if .Hardlinked}}<p class="note">{{.Hardlinked}} pair(s) are already hardlinked and are not listed: they take no extra space.</p>{{end}}
+
+<h2>By folder</h2>
+<table class="sortable">
+<thead><tr><th>Folder</th><th data-type="number">Duplicate files</th><th data-type="number">Size of duplicates</th></tr></thead>
+<tbody>
+{{range .Dirs}}<tr><td>{{.Dir}}</td><td class="num" data-value="{{.Files}}">{{.Files}}</td><td class="num" data-value="{{.Bytes}}">{{size .Bytes}}</td></tr>
+{{end}}</tbody>
+</table>
+
+<h2>Duplicate sets</h2>
+<table class="sortable">
+<thead><tr><th data-type="number">#</th><th>Name</th><th data-type="number">Size</th><th data-type="number">Copies</th><th data-type="number">Wasted</th><th>Contents compared</th><th>Locations</th><th>Note</th></tr></thead>
+<tbody>
+{{range .Sets}}<tr><td class="num" data-value="{{.ID}}">{{.ID}}</td><td>{{.Name}}</td><td class="num" data-value="{{.Size}}">{{size .Size}}</td><td class="num" data-value="{{.Copies}}">{{.Copies}}</td><td class="num" data-value="{{.Wasted}}">{{size .Wasted}}</td><td>{{if .Verified}}yes{{else}}no{{end}}</td><td class="paths">{{range $i, $p := .Paths}}{{if $i}}<br>{{end}}{{$p}}{{end}}</td><td>{{.Note}}</td></tr>
+{{end}}</tbody>
+</table>
+
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, column) {
+    var ascending = true;
+    th.addEventListener("click", function () {
+      var numeric = th.dataset.type === "number";
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column], y = b.cells[column];
+        var c = numeric
+          ? Number(x.dataset.value) - Number(y.dataset.value)
+          : x.textContent.localeCompare(y.textContent);
+        return ascending ? c : -c;
+      });
+      ascending = !ascending;
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,38 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+func TestFormatHTML(t *testing.T) {
+	photo := match("photo.jpg", true, true)
+	photo.File1.Size, photo.File2.Size = 2048, 2048
+	notes := match("<notes>.txt", false, false)
+	notes.File1.Size, notes.File2.Size = 10, 10
+	linked := match("linked.mov", true, true)
+	linked.File1.Size, linked.File2.Size = 4096, 4096
+	linked.Hardlinked = true
+
+	page, err := FormatHTML(results(notes, photo, linked).Comparisons, output.Labels{"/b": "backup"})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "Removing the extra copies would free <strong>2.0 KiB</strong>")
+	assert.Contains(t, page, "1 set(s) matched by name and size only")
+	assert.Contains(t, page, "1 pair(s) are already hardlinked")
+	assert.NotContains(t, page, "linked.mov")
+
+	// Names are escaped and labels applied
+	assert.Contains(t, page, "&lt;notes&gt;.txt")
+	assert.Contains(t, page, "backup:photo.jpg")
+	assert.Contains(t, page, `<td>backup</td><td class="num" data-value="2">2</td>`)
+
+	// The set with the most wasted space comes first
+	assert.Less(t, strings.Index(page, "<td>photo.jpg</td>"), strings.Index(page, "<td>&lt;notes&gt;.txt</td>"))
+}
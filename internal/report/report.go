@@ -0,0 +1,49 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Load reads a results document saved with --format json
+func Load(path string) (*models.Results, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read results: %w", err)
+	}
+
+	var results models.Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("cannot parse results %s: %w", path, err)
+	}
+	if results.Version > models.ResultsVersion {
+		return nil, fmt.Errorf("results %s use version %d, newer than supported version %d",
+			path, results.Version, models.ResultsVersion)
+	}
+	return &results, nil
+}
+
+// duplicates returns every duplicate match in results keyed by its file pair
+func duplicates(results *models.Results) map[[2]string]models.FileMatch {
+	m := make(map[[2]string]models.FileMatch)
+	for _, comparison := range results.Comparisons {
+		for _, match := range comparison.Matches {
+			if match.IsDuplicate() {
+				m[pairKey(match)] = match
+			}
+		}
+	}
+	return m
+}
+
+// pairKey identifies a match by its two paths, independent of order
+func pairKey(match models.FileMatch) [2]string {
+	a, b := match.File1.Path, match.File2.Path
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,157 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// Simulate applies a policy to every duplicate set in saved results
+func Simulate(results *models.Results, p policy.Policy) []policy.Decision {
+	var decisions []policy.Decision
+	for _, set := range finder.MergeMatches(results.Comparisons) {
+		decisions = append(decisions, p.Apply(set))
+	}
+	return decisions
+}
+
+// FormatSimulation renders the kept and removed files of each decision
+// with their modification times, followed by the totals. Labels,
+// TimeFormat and Explain are taken from opts.
+func FormatSimulation(decisions []policy.Decision, opts output.Options) string {
+	var builder strings.Builder
+	for _, d := range decisions {
+		writeDecision(&builder, d, opts)
+	}
+	writeTotals(&builder, decisions)
+	return builder.String()
+}
+
+// FormatGroupedSimulation renders decisions under a heading per capture
+// period, followed by the totals for all groups
+func FormatGroupedSimulation(groups []Group, opts output.Options) string {
+	var builder strings.Builder
+	var all []policy.Decision
+	for _, group := range groups {
+		builder.WriteString(fmt.Sprintf("##### %s: %s set(s) #####\n\n", group.Period, output.FormatCount(len(group.Decisions))))
+		for _, d := range group.Decisions {
+			writeDecision(&builder, d, opts)
+		}
+		all = append(all, group.Decisions...)
+	}
+	writeTotals(&builder, all)
+	return builder.String()
+}
+
+// writeDecision writes one duplicate set with its kept, removed and
+// retained files, each followed by the rule that decided it and, with
+// Explain, the detail behind the rule
+func writeDecision(builder *strings.Builder, d policy.Decision, opts output.Options) {
+	note := ""
+	if !d.Verified {
+		note = " (not hash-verified)"
+	}
+	builder.WriteString(fmt.Sprintf("=== Set #%d: %d files, %s each%s ===\n",
+		d.SetID, len(d.Remove)+len(d.Retained)+1, output.FormatSize(d.Keep.Size), note))
+
+	line := func(action string, file models.FileInfo) {
+		rule := ""
+		if reason, ok := d.Reason(file.Path); ok {
+			if opts.Explain {
+				rule = fmt.Sprintf("  [%s]", reason)
+			} else {
+				rule = fmt.Sprintf("  [%s]", reason.Rule)
+			}
+		}
+		builder.WriteString(fmt.Sprintf("  %-6s  %s  (%s)%s\n",
+			action, opts.Labels.Path(file.Path), opts.TimeFormat.Format(file.ModTime), rule))
+	}
+	line(policy.ActionKeep, d.Keep)
+	for _, file := range d.Remove {
+		line(policy.ActionRemove, file)
+	}
+	for _, file := range d.Retained {
+		line(policy.ActionRetain, file)
+	}
+	builder.WriteString("\n")
+}
+
+// writeTotals writes the number of sets and files to remove and the
+// savings, counting each physical file once
+func writeTotals(builder *strings.Builder, decisions []policy.Decision) {
+	var removed, retained int
+	var unverified int
+	for _, d := range decisions {
+		removed += len(d.Remove)
+		retained += len(d.Retained)
+		if !d.Verified {
+			unverified++
+		}
+	}
+	kept, removes := PlannedRemovals(decisions)
+
+	builder.WriteString(fmt.Sprintf("Duplicate sets: %s\n", output.FormatCount(len(decisions))))
+	builder.WriteString(fmt.Sprintf("Files to remove: %s\n", output.FormatCount(removed)))
+	if retained > 0 {
+		builder.WriteString(fmt.Sprintf("Files retained by protection rules: %s\n", output.FormatCount(retained)))
+	}
+	builder.WriteString(fmt.Sprintf("Total savings: %s\n", output.FormatSize(finder.RemovalSavings(kept, removes))))
+	if unverified > 0 {
+		builder.WriteString(fmt.Sprintf("Warning: %s set(s) were matched by name only; rerun with --compare-hash before acting\n", output.FormatCount(unverified)))
+	}
+}
+
+// PlannedRemovals returns the files decisions keep, including those
+// retained by protection rules, and the files they remove
+func PlannedRemovals(decisions []policy.Decision) (kept, removed []models.FileInfo) {
+	for _, d := range decisions {
+		kept = append(append(kept, d.Keep), d.Retained...)
+		removed = append(removed, d.Remove...)
+	}
+	return kept, removed
+}
+
+// PlanVersion is the current version of the plan document
+const PlanVersion = 1
+
+// Plan is the saved form of simulated decisions, with the reason behind
+// every kept, removed and retained file
+type Plan struct {
+	Version     int               `json:"version"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	RunID       string            `json:"run_id"` // Run that wrote the plan
+	Decisions   []policy.Decision `json:"decisions"`
+}
+
+// FormatPlan renders decisions as a versioned JSON plan document
+func FormatPlan(decisions []policy.Decision) (string, error) {
+	plan := Plan{
+		Version:     PlanVersion,
+		GeneratedAt: time.Now().UTC(),
+		RunID:       runid.ID(),
+		Decisions:   decisions,
+	}
+	if plan.Decisions == nil {
+		plan.Decisions = []policy.Decision{}
+	}
+	// Emit [] rather than null for sets with nothing to remove, as the
+	// plan schema expects
+	for i := range plan.Decisions {
+		if plan.Decisions[i].Remove == nil {
+			plan.Decisions[i].Remove = []models.FileInfo{}
+		}
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+func TestSimulate(t *testing.T) {
+	verified := match("photo.jpg", true, true)
+	verified.File1.Size, verified.File2.Size = 1024, 1024
+
+	decisions := Simulate(results(verified, match("notes.txt", false, false)), policy.Policy{
+		Keep:       policy.KeepNewest,
+		PreferDirs: []string{"/b"},
+	})
+
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "/b/photo.jpg", decisions[0].Keep.Path)
+	assert.Equal(t, "/a/photo.jpg", decisions[0].Remove[0].Path)
+
+	text := FormatSimulation(decisions, output.Options{TimeFormat: "2006"})
+	assert.Contains(t, text, "keep    /b/photo.jpg  (0001)")
+	assert.Contains(t, text, "remove  /a/photo.jpg")
+	assert.Contains(t, text, "Files to remove: 2")
+	assert.Contains(t, text, "Total savings: 1.0 KiB")
+	assert.Contains(t, text, "1 set(s) were matched by name only")
+}
+
+func TestFormatSimulation_Retained(t *testing.T) {
+	decisions := Simulate(results(match("photo.jpg", true, true)), policy.Policy{
+		Keep:      policy.KeepShortestPath,
+		Protected: []string{"/b"},
+	})
+
+	text := FormatSimulation(decisions, output.Options{})
+	assert.Contains(t, text, "keep    /a/photo.jpg")
+	assert.Contains(t, text, "[path order]")
+	assert.Contains(t, text, "retain  /b/photo.jpg")
+	assert.Contains(t, text, "[protected]")
+	assert.Contains(t, text, "Files retained by protection rules: 1")
+}
+
+func TestFormatSimulation_Explain(t *testing.T) {
+	decisions := Simulate(results(match("photo.jpg", true, true)), policy.Policy{
+		Keep:      policy.KeepShortestPath,
+		Protected: []string{"/b"},
+	})
+
+	text := FormatSimulation(decisions, output.Options{Explain: true})
+	assert.Contains(t, text, "[path order: all other rules tied; first by path]")
+	assert.Contains(t, text, "[protected: under /b]")
+}
+
+func TestFormatPlan(t *testing.T) {
+	decisions := Simulate(results(match("photo.jpg", true, true)), policy.Policy{
+		Keep:       policy.KeepNewest,
+		PreferDirs: []string{"/b"},
+	})
+
+	text, err := FormatPlan(decisions)
+	require.NoError(t, err)
+
+	var plan Plan
+	require.NoError(t, json.Unmarshal([]byte(text), &plan))
+	assert.Equal(t, PlanVersion, plan.Version)
+	require.Len(t, plan.Decisions, 1)
+	require.Len(t, plan.Decisions[0].Reasons, 2)
+	assert.Equal(t, policy.RulePreferDir, plan.Decisions[0].Reasons[0].Rule)
+	assert.Equal(t, "under preferred /b", plan.Decisions[0].Reasons[0].Detail)
+	assert.Equal(t, "kept copy: under preferred /b", plan.Decisions[0].Reasons[1].Detail)
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+// Weights of the parts of a suggestion's score
+const (
+	unverifiedConfidence = 0.5                  // Confidence in a set matched by name and size only
+	valueWeight          = 0.6                  // Share of the score from the space reclaimed
+	ageWeight            = 0.4                  // Share of the score from how long the removed copies went unchanged
+	settledAge           = 365 * 24 * time.Hour // Age from which a copy counts as fully settled
+)
+
+// Suggestion is a decision ranked by how safe and worthwhile acting on it is
+type Suggestion struct {
+	Decision policy.Decision
+	Score    int           // 0 to 100, higher first
+	Savings  int64         // Bytes the decision frees
+	Age      time.Duration // Time since the most recently modified removed copy changed
+}
+
+// Suggest ranks the decisions that remove files, best first. Each score
+// combines confidence, whether the set was hash-verified; value, the space
+// freed, relative to the largest set on a logarithmic scale; and age, how
+// long the most recently modified copy to remove has gone unchanged, up to
+// a year. Ties keep the order of the sets.
+func Suggest(decisions []policy.Decision, now time.Time) []Suggestion {
+	var suggestions []Suggestion
+	var largest int64
+	for _, d := range decisions {
+		if len(d.Remove) == 0 {
+			continue
+		}
+		youngest := now.Sub(d.Remove[0].ModTime)
+		for _, file := range d.Remove[1:] {
+			youngest = min(youngest, now.Sub(file.ModTime))
+		}
+		s := Suggestion{Decision: d, Savings: d.Savings(), Age: max(youngest, 0)}
+		largest = max(largest, s.Savings)
+		suggestions = append(suggestions, s)
+	}
+
+	for i := range suggestions {
+		s := &suggestions[i]
+		confidence := 1.0
+		if !s.Decision.Verified {
+			confidence = unverifiedConfidence
+		}
+		value := 0.0
+		if largest > 0 {
+			value = math.Log1p(float64(s.Savings)) / math.Log1p(float64(largest))
+		}
+		age := min(float64(s.Age)/float64(settledAge), 1)
+		s.Score = int(math.Round(100 * confidence * (valueWeight*value + ageWeight*age)))
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	return suggestions
+}
+
+// Decisions returns the decisions of suggestions, in ranked order
+func Decisions(suggestions []Suggestion) []policy.Decision {
+	decisions := make([]policy.Decision, len(suggestions))
+	for i, s := range suggestions {
+		decisions[i] = s.Decision
+	}
+	return decisions
+}
+
+// FormatSuggestions renders each suggestion's rank and score, and what
+// it is made of, above its decision, followed by the totals. Labels,
+// TimeFormat and Explain are taken from opts.
+func FormatSuggestions(suggestions []Suggestion, opts output.Options) string {
+	var builder strings.Builder
+	for i, s := range suggestions {
+		confidence := "hash-verified"
+		if !s.Decision.Verified {
+			confidence = "matched by name only"
+		}
+		builder.WriteString(fmt.Sprintf("##### Suggestion %d: score %d (%s, frees %s, unchanged for %s) #####\n",
+			i+1, s.Score, confidence, output.FormatSize(s.Savings), output.FormatDuration(s.Age)))
+		writeDecision(&builder, s.Decision, opts)
+	}
+	writeTotals(&builder, Decisions(suggestions))
+	return builder.String()
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+)
+
+func suggestDecision(id int, verified bool, size int64, modTime time.Time) policy.Decision {
+	return policy.Decision{
+		SetID:    id,
+		Keep:     models.FileInfo{Path: "/a/keep.jpg", Size: size},
+		Remove:   []models.FileInfo{{Path: "/b/copy.jpg", Size: size, ModTime: modTime}},
+		Verified: verified,
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old, recent := now.AddDate(-2, 0, 0), now.Add(-time.Hour)
+	decisions := []policy.Decision{
+		suggestDecision(1, true, 1<<20, recent),
+		suggestDecision(2, true, 1<<30, old),
+		suggestDecision(3, false, 1<<30, old),
+		suggestDecision(4, true, 1<<30, recent),
+		{SetID: 5, Keep: models.FileInfo{Path: "/a/kept.jpg"}, Verified: true},
+	}
+
+	suggestions := Suggest(decisions, now)
+
+	// Sets with nothing to remove are not suggested
+	require.Len(t, suggestions, 4)
+	var ids []int
+	for _, s := range suggestions {
+		ids = append(ids, s.Decision.SetID)
+	}
+	assert.Equal(t, []int{2, 4, 3, 1}, ids)
+	assert.Equal(t, 100, suggestions[0].Score, "verified, largest and settled")
+	assert.Equal(t, 50, suggestions[2].Score, "unverified sets score half")
+	assert.Equal(t, int64(1<<30), suggestions[0].Savings)
+	assert.Equal(t, time.Hour, suggestions[1].Age)
+}
+
+func TestFormatSuggestions(t *testing.T) {
+	now := time.Now()
+	suggestions := Suggest([]policy.Decision{suggestDecision(7, false, 1024, now.AddDate(-3, 0, 0))}, now)
+
+	text := FormatSuggestions(suggestions, output.Options{})
+	assert.Contains(t, text, "##### Suggestion 1: score 50 (matched by name only, frees 1.0 KiB, unchanged for 3 years) #####")
+	assert.Contains(t, text, "=== Set #7: 2 files, 1.0 KiB each (not hash-verified) ===")
+	assert.Contains(t, text, "Total savings: 1.0 KiB")
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+// SummaryVersion is the current version of the summary document
+const SummaryVersion = 1
+
+// Summary gives the totals of saved results, for scripts that only need
+// to know whether and how much there is to clean up. RunID is the run that
+// wrote the summary and ResultsRunID the one that wrote the results, if
+// they record it.
+type Summary struct {
+	Version          int       `json:"version"`
+	GeneratedAt      time.Time `json:"generated_at"`
+	RunID            string    `json:"run_id"`
+	ResultsRunID     string    `json:"results_run_id,omitempty"`
+	Pairs            int       `json:"pairs"`             // Directory pairs compared
+	Matches          int       `json:"matches"`           // Matches listed, including files that turned out different
+	Duplicates       int       `json:"duplicates"`        // Matches still counted as duplicates
+	Sets             int       `json:"sets"`              // Duplicate sets after merging pairwise matches
+	Files            int       `json:"files"`             // Files in duplicate sets
+	ReclaimableBytes int64     `json:"reclaimable_bytes"` // Bytes freed by keeping one copy of each set
+	Unverified       int       `json:"unverified"`        // Sets matched without comparing contents
+	Truncated        bool      `json:"truncated"`         // Whether a match limit cut the results short
+}
+
+// Summarize computes the totals of results
+func Summarize(results *models.Results) Summary {
+	s := Summary{
+		Version:      SummaryVersion,
+		GeneratedAt:  time.Now().UTC(),
+		RunID:        runid.ID(),
+		ResultsRunID: results.RunID,
+		Pairs:        len(results.Comparisons),
+		Matches:      output.CountMatches(results.Comparisons),
+		Duplicates:   output.CountDuplicates(results.Comparisons),
+	}
+	for _, comparison := range results.Comparisons {
+		s.Truncated = s.Truncated || comparison.Truncated
+	}
+
+	sets := finder.MergeMatches(results.Comparisons)
+	s.Sets = len(sets)
+	s.ReclaimableBytes = finder.TotalSavings(sets)
+	for _, set := range sets {
+		s.Files += len(set.Files)
+		if !set.HashComputed {
+			s.Unverified++
+		}
+	}
+	return s
+}
+
+// FormatSummaryText renders a summary as text
+func FormatSummaryText(s Summary) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Directory pairs: %s\n", output.FormatCount(s.Pairs)))
+	builder.WriteString(fmt.Sprintf("Duplicates: %s of %s match(es)\n", output.FormatCount(s.Duplicates), output.FormatCount(s.Matches)))
+	builder.WriteString(fmt.Sprintf("Duplicate sets: %s (%s files)\n", output.FormatCount(s.Sets), output.FormatCount(s.Files)))
+	builder.WriteString(fmt.Sprintf("Reclaimable: %s\n", output.FormatSize(s.ReclaimableBytes)))
+	if s.Unverified > 0 {
+		builder.WriteString(fmt.Sprintf("Warning: %s set(s) were matched by name only\n", output.FormatCount(s.Unverified)))
+	}
+	if s.Truncated {
+		builder.WriteString("Warning: a match limit cut the results short\n")
+	}
+	return builder.String()
+}
+
+// FormatSummaryJSON renders a summary as a versioned JSON document
+func FormatSummaryJSON(s Summary) (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,38 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sho2010/dup-finder/internal/runid"
+)
+
+func TestSummarize(t *testing.T) {
+	photo := match("photo.jpg", true, true)
+	photo.File1.Size, photo.File2.Size = 1024, 1024
+
+	s := Summarize(results(photo, match("notes.txt", false, false), match("changed.txt", true, false)))
+
+	assert.Equal(t, SummaryVersion, s.Version)
+	assert.Equal(t, 1, s.Pairs)
+	assert.Equal(t, 3, s.Matches)
+	assert.Equal(t, 2, s.Duplicates)
+	assert.Equal(t, 2, s.Sets)
+	assert.Equal(t, 4, s.Files)
+	assert.Equal(t, int64(1024), s.ReclaimableBytes)
+	assert.Equal(t, 1, s.Unverified)
+	assert.Equal(t, runid.ID(), s.RunID)
+	assert.Empty(t, s.ResultsRunID)
+
+	text := FormatSummaryText(s)
+	assert.Contains(t, text, "Duplicates: 2 of 3 match(es)\n")
+	assert.Contains(t, text, "Reclaimable: 1.0 KiB\n")
+	assert.Contains(t, text, "1 set(s) were matched by name only")
+}
+
+func TestSummarize_ResultsRunID(t *testing.T) {
+	r := results(match("photo.jpg", true, true))
+	r.RunID = "nightly-2024-06-01"
+	assert.Equal(t, "nightly-2024-06-01", Summarize(r).ResultsRunID)
+}
@@ -0,0 +1,71 @@
+// Package runid identifies the current run of dup-finder, so the documents
+// written by overlapping or scheduled runs on the same machine can be told
+// apart and matched up
+package runid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	mu sync.Mutex
+	id string
+)
+
+// ID returns the identifier of this run, generated on first use from the
+// UTC time and random bits, e.g. 20240315T020000Z-9f86d081. IDs sort by
+// the time their run started.
+func ID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if id == "" {
+		id = generate(time.Now())
+	}
+	return id
+}
+
+// New returns a new identifier for a run started now, such as one this
+// process starts in turn, without changing ID
+func New() string {
+	return generate(time.Now())
+}
+
+// Set makes value the identifier of this run, e.g. one given by the
+// scheduler that started it
+func Set(value string) error {
+	if err := Validate(value); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	id = value
+	return nil
+}
+
+// Validate checks that value can identify a run: 1 to 64 letters, digits
+// and the characters . _ : -, so it can be used in file names and logs
+// without quoting
+func Validate(value string) error {
+	if value == "" || len(value) > 64 {
+		return fmt.Errorf("invalid run ID %q: expected 1 to 64 characters", value)
+	}
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == ':', r == '-':
+		default:
+			return fmt.Errorf("invalid run ID %q: only letters, digits and . _ : - are allowed", value)
+		}
+	}
+	return nil
+}
+
+// generate returns a new identifier for a run started at t
+func generate(t time.Time) string {
+	random := make([]byte, 4)
+	rand.Read(random)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(random)
+}
@@ -0,0 +1,37 @@
+package runid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestID(t *testing.T) {
+	first := ID()
+	assert.Regexp(t, `^\d{8}T\d{6}Z-[0-9a-f]{8}$`, first)
+	assert.Equal(t, first, ID())
+	assert.NoError(t, Validate(first))
+
+	require.NoError(t, Set("nightly-2024-03-15"))
+	assert.Equal(t, "nightly-2024-03-15", ID())
+}
+
+func TestGenerate(t *testing.T) {
+	start := time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	a, b := generate(start), generate(start)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, "20240315T020000Z-", a[:17])
+	assert.Less(t, a, generate(start.Add(time.Second)))
+	assert.NotEqual(t, ID(), New())
+}
+
+func TestValidate(t *testing.T) {
+	for _, valid := range []string{"a", "cron:backup.nightly_2", "20240315T020000Z-9f86d081"} {
+		assert.NoError(t, Validate(valid), valid)
+	}
+	for _, invalid := range []string{"", "has space", "slash/", "ü", string(make([]byte, 65))} {
+		assert.Error(t, Validate(invalid), invalid)
+	}
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sho2010/dup-finder/internal/devgen"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Run at release scale with DUP_FINDER_BENCH_FILES=2000000
+func BenchmarkScan(b *testing.B) {
+	opts := devgen.DefaultOptions()
+	opts.Files = devgen.ScaleFromEnv(opts.Files)
+	opts.MaxSize = 64
+	stats, err := devgen.Generate(b.TempDir(), opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	scanOpts := models.ScanOptions{Directories: stats.Roots, Recursive: true, MaxDepth: -1, NumWorkers: 8}
+
+	b.Run("ScanAll", func(b *testing.B) {
+		for b.Loop() {
+			files, err := NewScanner(scanOpts).ScanAll(context.Background())
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(files[stats.Roots[0]]) != opts.Files {
+				b.Fatalf("scanned %d files, want %d", len(files[stats.Roots[0]]), opts.Files)
+			}
+		}
+		b.ReportMetric(float64(stats.Files*b.N)/b.Elapsed().Seconds(), "files/s")
+	})
+
+	b.Run("Filtered", func(b *testing.B) {
+		filtered := scanOpts
+		filtered.Extensions = []string{".jpg"}
+		filtered.ExcludeDirs = ExcludeDirs(nil, false, false)
+		for b.Loop() {
+			if _, err := NewScanner(filtered).ScanAll(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkScan_Unicode(b *testing.B) {
+	opts := devgen.DefaultOptions()
+	opts.Roots = 1
+	opts.Files = devgen.ScaleFromEnv(opts.Files)
+	opts.MaxSize = 0
+	opts.Depth = 12
+	opts.Fanout = 2
+	opts.Unicode = true
+	stats, err := devgen.Generate(b.TempDir(), opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	scanOpts := models.ScanOptions{Directories: stats.Roots, Recursive: true, MaxDepth: -1, NumWorkers: 8}
+
+	for b.Loop() {
+		if _, err := NewScanner(scanOpts).Scan(context.Background(), stats.Roots[0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// DupignoreName is the per-directory ignore file honored while walking
+const DupignoreName = ".dupignore"
+
+// ignoreRule is one pattern line of a .dupignore file, in gitignore syntax
+type ignoreRule struct {
+	segments []string // Pattern split at "/", where "**" spans any number of segments
+	negate   bool     // "!pattern": re-include what an earlier rule ignored
+	dirOnly  bool     // "pattern/": match directories only
+	anchored bool     // Pattern contains a "/": match from the .dupignore directory, not at any depth
+}
+
+// parseIgnoreRule parses a line of a .dupignore file, returning false for
+// blank lines and comments
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// match reports whether the rule matches a path given as its segments
+// relative to the directory of the .dupignore file
+func (r ignoreRule) match(segments []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], segments[len(segments)-1])
+		return ok
+	}
+	return matchSegments(r.segments, segments)
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments. A trailing "**"
+// matches everything inside a directory but not the directory itself.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return len(segments) > 0
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// dupignores holds the .dupignore rules read so far during one walk, keyed
+// by the directory each file was found in. Walkers running concurrently
+// share it.
+type dupignores struct {
+	root  string
+	mu    sync.RWMutex
+	rules map[string][]ignoreRule
+}
+
+func newDupignores(root string) *dupignores {
+	return &dupignores{root: filepath.Clean(root), rules: make(map[string][]ignoreRule)}
+}
+
+// load reads the .dupignore file in dir, if there is one
+func (d *dupignores) load(dir string) error {
+	file, err := fsio.Open(filepath.Join(dir, DupignoreName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		if rule, ok := parseIgnoreRule(lines.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("cannot read %s: %w", filepath.Join(dir, DupignoreName), err)
+	}
+	if len(rules) > 0 {
+		d.mu.Lock()
+		d.rules[filepath.Clean(dir)] = rules
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// ignored reports whether path is ignored by the .dupignore files of its
+// ancestors up to the root. As in git, rules are applied from the
+// outermost file inward and the last matching rule wins.
+func (d *dupignores) ignored(path string, isDir bool) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.rules) == 0 {
+		return false
+	}
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == d.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules := d.rules[dirs[i]]
+		if len(rules) == 0 {
+			continue
+		}
+		rel, err := filepath.Rel(dirs[i], path)
+		if err != nil {
+			continue
+		}
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for _, rule := range rules {
+			if rule.match(segments, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestIgnoreRule_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.tmp", "a.tmp", false, true},
+		{"*.tmp", "deep/down/a.tmp", false, true},
+		{"*.tmp", "a.jpg", false, false},
+		{"RAW/", "RAW", true, true},
+		{"RAW/", "RAW", false, false},
+		{"/top.jpg", "top.jpg", false, true},
+		{"/top.jpg", "sub/top.jpg", false, false},
+		{"exports/*.jpg", "exports/a.jpg", false, true},
+		{"exports/*.jpg", "sub/exports/a.jpg", false, false},
+		{"**/cache", "a/b/cache", true, true},
+		{"**/cache", "cache", true, true},
+		{"a/**/b", "a/b", true, true},
+		{"a/**/b", "a/x/y/b", true, true},
+		{"logs/**", "logs", true, false},
+		{"logs/**", "logs/2024/x.log", false, true},
+		{`\#notes.txt`, "#notes.txt", false, true},
+		{`trailing\ `, "trailing ", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			rule, ok := parseIgnoreRule(tt.pattern)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, rule.match(strings.Split(tt.path, "/"), tt.isDir))
+		})
+	}
+}
+
+func TestParseIgnoreRule_Skipped(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "/", "\r"} {
+		_, ok := parseIgnoreRule(line)
+		assert.False(t, ok, "%q", line)
+	}
+	rule, ok := parseIgnoreRule("!keep.jpg  ")
+	require.True(t, ok)
+	assert.True(t, rule.negate)
+	assert.Equal(t, []string{"keep.jpg"}, rule.segments)
+}
+
+func TestScan_Dupignore(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(".dupignore", "*.tmp\nthumbs/\n")
+	write("a.jpg", "a")
+	write("a.tmp", "a")
+	write("thumbs/a.jpg", "a")
+	write("2024/b.jpg", "b")
+	write("2024/b.tmp", "b")
+	write("2024/.dupignore", "!b.tmp\nc.jpg\n")
+	write("2024/c.jpg", "c")
+	write("2025/c.jpg", "c")
+
+	scan := func(use bool) []string {
+		s := NewScanner(models.ScanOptions{
+			Recursive:    true,
+			MaxDepth:     -1,
+			NumWorkers:   runtime.NumCPU(),
+			UseDupignore: use,
+		})
+		files, err := s.Scan(context.Background(), dir)
+		require.NoError(t, err)
+		var rels []string
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file.Path)
+			require.NoError(t, err)
+			rels = append(rels, filepath.ToSlash(rel))
+		}
+		sort.Strings(rels)
+		return rels
+	}
+
+	// Deeper files override shallower ones, and apply only below their directory
+	assert.Equal(t, []string{"2024/b.jpg", "2024/b.tmp", "2025/c.jpg", "a.jpg"}, scan(true))
+	assert.Len(t, scan(false), 9)
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// cacheDirRule describes a directory holding package-manager or cache content
+type cacheDirRule struct {
+	name    string   // Directory name (exact match)
+	markers []string // Sibling files, one of which must exist; empty means always
+}
+
+// cacheDirRules is the curated set of directories skipped by SkipCacheDirs.
+// Duplicates inside them are expected, and deleting them breaks applications.
+var cacheDirRules = []cacheDirRule{
+	// Package managers and virtual environments
+	{name: "node_modules"},
+	{name: "bower_components"},
+	{name: ".pnpm-store"},
+	{name: ".yarn"},
+	{name: ".npm"},
+	{name: ".venv"},
+	{name: "venv", markers: []string{"pyvenv.cfg"}},
+	{name: "__pycache__"},
+	{name: ".tox"},
+	{name: ".gradle"},
+	{name: ".m2"},
+	{name: ".cargo"},
+	// Build output, only next to the matching project file
+	{name: "target", markers: []string{"Cargo.toml", "pom.xml"}},
+	// Caches (~/.cache, browser profile caches)
+	{name: ".cache"},
+	{name: "cache2"},
+	{name: "Code Cache"},
+	{name: "GPUCache"},
+	{name: "CacheStorage"},
+}
+
+// isCacheDir reports whether the directory at path matches a cache rule
+func isCacheDir(path string) bool {
+	name := filepath.Base(path)
+	for _, rule := range cacheDirRules {
+		if rule.name != name {
+			continue
+		}
+		if len(rule.markers) == 0 {
+			return true
+		}
+		for _, marker := range rule.markers {
+			// Markers live in the directory itself (pyvenv.cfg) or next to it (Cargo.toml)
+			if fileExists(filepath.Join(path, marker)) || fileExists(filepath.Join(filepath.Dir(path), marker)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fileExists reports whether a regular file exists at path
+func fileExists(path string) bool {
+	info, err := fsio.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// DefaultExcludeDirs are the directory names skipped unless the defaults
+// are turned off: version-control metadata, dependencies, and the trash
+// and system folders of Windows, macOS and Linux desktops. Windows spells
+// the recycle bin differently on system and data drives.
+var DefaultExcludeDirs = []string{
+	".git",
+	"node_modules",
+	"$RECYCLE.BIN",
+	"$Recycle.Bin",
+	"System Volume Information",
+	".Trash",
+	".Trash-*",
+}
+
+// ExcludeDirs returns the directory name patterns to skip: the defaults,
+// unless noDefaults is set, followed by patterns. With includeCache the
+// defaults that are also cache directories (node_modules) are left to the
+// cache rules, so --include-cache-dirs alone scans them.
+func ExcludeDirs(patterns []string, noDefaults, includeCache bool) []string {
+	if noDefaults {
+		return patterns
+	}
+	var dirs []string
+	for _, name := range DefaultExcludeDirs {
+		if includeCache && isCacheDirName(name) {
+			continue
+		}
+		dirs = append(dirs, name)
+	}
+	return append(dirs, patterns...)
+}
+
+// isCacheDirName reports whether a cache rule skips every directory named
+// name, whatever sits next to it
+func isCacheDirName(name string) bool {
+	for _, rule := range cacheDirRules {
+		if rule.name == name && len(rule.markers) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDirName reports whether a directory name matches any of the
+// patterns. Patterns apply to the name alone, never to the full path.
+func matchesDirName(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDirPatterns checks that every --exclude-dir pattern is a plain
+// name or a well-formed glob without path separators
+func ValidateDirPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || strings.ContainsAny(pattern, `/\`) {
+			return fmt.Errorf("invalid --exclude-dir pattern %q: expected a directory name, not a path", pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --exclude-dir pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
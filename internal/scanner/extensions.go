@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NormalizeExtensions cleans --extensions values so "zip", ".zip" and
+// " .ZIP " all mean the same filter: spaces are trimmed, a leading "*" or
+// a missing dot is fixed, case is folded and duplicates are removed.
+// Values that are probably typos are fixed where the intent is clear and
+// reported as warnings; values that cannot name an extension are an error.
+func NormalizeExtensions(values []string) (exts []string, warnings []string, err error) {
+	seen := make(map[string]bool)
+	for _, value := range values {
+		ext := strings.ToLower(strings.TrimSpace(value))
+		if ext == "" {
+			continue
+		}
+		// Accept shell-style "*.jpg"
+		ext = strings.TrimLeft(ext, "*")
+		if strings.ContainsAny(ext, `/\ *?[`) {
+			return nil, nil, fmt.Errorf("invalid extension %q: expected a name such as .jpg or jpg", value)
+		}
+		if trimmed := strings.TrimRight(ext, "."); trimmed != ext {
+			// "jpg." never matches anything, since a trailing dot is its own extension
+			ext = trimmed
+			warnings = append(warnings, fmt.Sprintf("extension %q ends with a dot; using %q", value, "."+strings.TrimLeft(ext, ".")))
+		}
+		ext = "." + strings.TrimLeft(ext, ".")
+		if ext == "." {
+			return nil, nil, fmt.Errorf("invalid extension %q: expected a name such as .jpg or jpg", value)
+		}
+		if i := strings.LastIndex(ext, "."); i > 0 {
+			// Only the last extension is compared, so ".tar.gz" would never match
+			warnings = append(warnings, fmt.Sprintf("extension %q has more than one dot; only the last part is compared, using %q", value, ext[i:]))
+			ext = ext[i:]
+		}
+
+		if !seen[ext] {
+			seen[ext] = true
+			exts = append(exts, ext)
+		}
+	}
+	return exts, warnings, nil
+}
+
+// HasExtension reports whether path has one of exts. Matching ignores case
+// and accepts extensions with or without the leading dot, so every filter
+// treats extensions the same way.
+func HasExtension(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return false
+	}
+	for _, allowed := range exts {
+		if !strings.HasPrefix(allowed, ".") {
+			allowed = "." + allowed
+		}
+		if strings.EqualFold(ext, allowed) {
+			return true
+		}
+	}
+	return false
+}
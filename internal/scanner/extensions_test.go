@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeExtensions(t *testing.T) {
+	exts, warnings, err := NormalizeExtensions([]string{"zip", ".zip", " .ZIP ", "*.jpg", "", "Mp4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".zip", ".jpg", ".mp4"}, exts)
+	assert.Empty(t, warnings)
+}
+
+func TestNormalizeExtensions_Typos(t *testing.T) {
+	exts, warnings, err := NormalizeExtensions([]string{"jpg.", "tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".jpg", ".gz"}, exts)
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], `using ".jpg"`)
+	assert.Contains(t, warnings[1], `using ".gz"`)
+}
+
+func TestNormalizeExtensions_Invalid(t *testing.T) {
+	for _, value := range []string{".", "*", "photos/jpg", "j pg", "j*g", "[jp]g"} {
+		_, _, err := NormalizeExtensions([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestHasExtension(t *testing.T) {
+	assert.True(t, HasExtension("/a/photo.JPG", []string{".jpg"}))
+	assert.True(t, HasExtension("/a/photo.jpg", []string{"JPG"}))
+	assert.False(t, HasExtension("/a/photo.jpeg", []string{".jpg"}))
+	assert.False(t, HasExtension("/a/README", []string{".jpg"}))
+	assert.False(t, HasExtension("/a/photo.jpg.", []string{".jpg"}))
+}
@@ -0,0 +1,28 @@
+//go:build !unix
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dirID identifies the directory at path by its path with every link
+// resolved, as device and inode numbers are not available here
+func dirID(path string, info os.FileInfo) (fileID, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return fileID{}, false
+	}
+	return fileID{path: abs}, true
+}
+
+// storageOf reports no device or inode, as they are not available here;
+// the finder looks hardlinks up by path instead
+func storageOf(info os.FileInfo) (dev, ino uint64) {
+	return 0, 0
+}
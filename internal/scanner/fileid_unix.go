@@ -0,0 +1,28 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// dirID identifies the directory behind info by device and inode, so a
+// directory reached through several links has one ID
+func dirID(path string, info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
+
+// storageOf returns the device and inode of the file behind info, which
+// hardlinks of one file share
+func storageOf(info os.FileInfo) (dev, ino uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return uint64(stat.Dev), uint64(stat.Ino)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package scanner
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// isHidden reports whether a file or directory is hidden: on Unix, its
+// name starts with a dot (.cache, .DS_Store)
+func isHidden(d fs.DirEntry) bool {
+	return strings.HasPrefix(d.Name(), ".")
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScan_SkipHidden(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".photos")
+	for _, name := range []string{
+		"a.jpg",
+		".DS_Store",
+		".thumbnails/a.jpg",
+		"album/b.jpg",
+		"album/.b.jpg.swp",
+	} {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+
+	scan := func(skip bool) []string {
+		opts := models.ScanOptions{Recursive: true, MaxDepth: -1, NumWorkers: 1, SkipHidden: skip}
+		files, err := NewScanner(opts).Scan(context.Background(), root)
+		require.NoError(t, err)
+		var rel []string
+		for _, file := range files {
+			r, err := filepath.Rel(root, file.Path)
+			require.NoError(t, err)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		sort.Strings(rel)
+		return rel
+	}
+
+	// A hidden root is still scanned; hidden entries under it are not
+	assert.Equal(t, []string{"a.jpg", "album/b.jpg"}, scan(true))
+	assert.Equal(t, []string{".DS_Store", ".thumbnails/a.jpg", "a.jpg", "album/.b.jpg.swp", "album/b.jpg"}, scan(false))
+}
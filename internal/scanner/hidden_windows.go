@@ -0,0 +1,20 @@
+//go:build windows
+
+package scanner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// isHidden reports whether a file or directory is hidden: on Windows, it
+// has the hidden attribute, whatever its name. The directory listing
+// carries the attributes, so no call is made.
+func isHidden(d fs.DirEntry) bool {
+	info, err := d.Info()
+	if err != nil {
+		return false
+	}
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	return ok && attrs.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// matchesInclude reports whether a file name matches any of the --include
+// patterns. Patterns apply to the name alone and ignore case, like
+// extensions, so IMG_*.jpg also matches IMG_0001.JPG.
+func matchesInclude(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateIncludePatterns checks that every --include pattern is a
+// well-formed glob for a file name, without path separators
+func ValidateIncludePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || strings.ContainsAny(pattern, `/\`) {
+			return fmt.Errorf("invalid --include pattern %q: expected a file name glob such as IMG_*.jpg, not a path", pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --include pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// relativeSlashPath returns path relative to root with forward slashes, the
+// form --name-regex and --exclude-regex are matched against on every
+// platform
+func relativeSlashPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// CompilePathRegex compiles the value of a path regular expression flag,
+// returning nil for an empty value
+func CompilePathRegex(flag, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", flag, expr, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestMatchesInclude(t *testing.T) {
+	patterns := []string{"IMG_*.jpg", "report-2023-*.pdf"}
+	assert.True(t, matchesInclude("IMG_0001.jpg", patterns))
+	assert.True(t, matchesInclude("img_0001.JPG", patterns))
+	assert.True(t, matchesInclude("report-2023-q4.pdf", patterns))
+	assert.False(t, matchesInclude("DSC_0001.jpg", patterns))
+	assert.False(t, matchesInclude("report-2024-q1.pdf", patterns))
+}
+
+func TestValidateIncludePatterns(t *testing.T) {
+	assert.NoError(t, ValidateIncludePatterns([]string{"IMG_*.jpg", "[ab]?.txt"}))
+	assert.Error(t, ValidateIncludePatterns([]string{"photos/IMG_*.jpg"}))
+	assert.Error(t, ValidateIncludePatterns([]string{""}))
+	assert.Error(t, ValidateIncludePatterns([]string{"IMG_[.jpg"}))
+}
+
+func TestScan_Includes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"IMG_0001.jpg", "IMG_0002.png", "DSC_0001.jpg", "sub/IMG_0003.JPG"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+
+	s := NewScanner(models.ScanOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		NumWorkers: runtime.NumCPU(),
+		Extensions: []string{".jpg"},
+		Includes:   []string{"IMG_*"},
+	})
+	files, err := s.Scan(context.Background(), dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file.Path))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"IMG_0001.jpg", "IMG_0003.JPG"}, names)
+}
+
+func TestCompilePathRegex(t *testing.T) {
+	re, err := CompilePathRegex("--name-regex", "")
+	require.NoError(t, err)
+	assert.Nil(t, re)
+
+	_, err = CompilePathRegex("--name-regex", "([0-9]")
+	assert.ErrorContains(t, err, "invalid --name-regex")
+}
+
+func TestScan_PathRegex(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20240301_a.jpg", "2024_b.jpg", "exports/20240302_c.jpg", "raw/20240303_d.jpg"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+
+	nameRe, err := CompilePathRegex("--name-regex", `(^|/)[0-9]{8}_`)
+	require.NoError(t, err)
+	excludeRe, err := CompilePathRegex("--exclude-regex", `^raw/`)
+	require.NoError(t, err)
+	s := NewScanner(models.ScanOptions{
+		Recursive:    true,
+		MaxDepth:     -1,
+		NumWorkers:   runtime.NumCPU(),
+		NameRegex:    nameRe,
+		ExcludeRegex: excludeRe,
+	})
+	files, err := s.Scan(context.Background(), dir)
+	require.NoError(t, err)
+
+	var rels []string
+	for _, file := range files {
+		rels = append(rels, relativeSlashPath(dir, file.Path))
+	}
+	sort.Strings(rels)
+	assert.Equal(t, []string{"20240301_a.jpg", "exports/20240302_c.jpg"}, rels)
+}
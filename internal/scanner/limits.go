@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SoftLimitAction is the caller's answer when a scan passes a soft limit
+type SoftLimitAction int
+
+const (
+	// SoftLimitContinue keeps scanning until the limit is passed again
+	SoftLimitContinue SoftLimitAction = iota
+	// SoftLimitPartial stops walking and returns the files found so far
+	SoftLimitPartial
+	// SoftLimitAbort stops the scan with ErrScanAborted
+	SoftLimitAbort
+)
+
+// SoftLimitFunc decides what to do once a scan has walked files entries
+// for elapsed time and passed a soft limit
+type SoftLimitFunc func(files int, elapsed time.Duration) SoftLimitAction
+
+// ErrScanAborted is returned when a soft limit handler aborts the scan
+var ErrScanAborted = errors.New("scan aborted at soft limit")
+
+// softLimits tracks progress across all directories of one ScanAll. The
+// handler is called with the lock held, so every walker pauses while the
+// user decides.
+type softLimits struct {
+	mu        sync.Mutex
+	handler   SoftLimitFunc
+	maxFiles  int
+	maxTime   time.Duration
+	start     time.Time
+	files     int
+	nextFiles int
+	nextTime  time.Duration
+	partial   bool
+	aborted   bool
+}
+
+func newSoftLimits(maxFiles int, maxTime time.Duration, handler SoftLimitFunc) *softLimits {
+	return &softLimits{
+		handler:   handler,
+		maxFiles:  maxFiles,
+		maxTime:   maxTime,
+		start:     time.Now(),
+		nextFiles: maxFiles,
+		nextTime:  maxTime,
+	}
+}
+
+// check records one walked entry and returns filepath.SkipAll or
+// ErrScanAborted once the scan should stop
+func (l *softLimits) check(isFile bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.aborted {
+		return ErrScanAborted
+	}
+	if l.partial {
+		return filepath.SkipAll
+	}
+	if isFile {
+		l.files++
+	}
+
+	elapsed := time.Since(l.start)
+	overFiles := l.maxFiles > 0 && l.files > l.nextFiles
+	overTime := l.maxTime > 0 && elapsed > l.nextTime
+	if !overFiles && !overTime {
+		return nil
+	}
+
+	action := SoftLimitContinue
+	if l.handler != nil {
+		action = l.handler(l.files, elapsed)
+	}
+
+	switch action {
+	case SoftLimitPartial:
+		l.partial = true
+		return filepath.SkipAll
+	case SoftLimitAbort:
+		l.aborted = true
+		return ErrScanAborted
+	}
+
+	// Ask again after another full limit's worth of work
+	if overFiles {
+		l.nextFiles = l.files + l.maxFiles
+	}
+	if overTime {
+		l.nextTime = time.Since(l.start) + l.maxTime
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// NormalizeRoot cleans a directory argument without making it absolute:
+// redundant and trailing separators and "." components are removed. On
+// Windows, forward slashes become backslashes, drive letters are
+// upper-cased and UNC shares (\\server\share\dir) keep their prefix.
+func NormalizeRoot(dir string) string {
+	if runtime.GOOS == "windows" {
+		return cleanWindowsPath(dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// cleanWindowsPath normalizes a Windows path using string rules only, so
+// it behaves the same on every OS
+func cleanWindowsPath(p string) string {
+	p = strings.ReplaceAll(p, "/", `\`)
+
+	// Split off the volume: UNC prefix, drive letter, or a leading separator
+	prefix := ""
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		prefix, p = `\\`, strings.TrimLeft(p, `\`)
+	case len(p) >= 2 && p[1] == ':' && isLetter(p[0]):
+		prefix, p = strings.ToUpper(p[:1])+":", p[2:]
+		if strings.HasPrefix(p, `\`) {
+			prefix, p = prefix+`\`, strings.TrimLeft(p, `\`)
+		}
+	case strings.HasPrefix(p, `\`):
+		prefix, p = `\`, strings.TrimLeft(p, `\`)
+	}
+
+	rest := strings.ReplaceAll(p, `\`, "/")
+	if strings.HasSuffix(prefix, `\`) {
+		// Rooted: ".." cannot climb above the volume
+		rest = strings.TrimPrefix(path.Clean("/"+rest), "/")
+	} else if rest != "" {
+		rest = path.Clean(rest)
+		if rest == "." && prefix != "" {
+			// "C:." is the current directory of drive C
+			rest = ""
+		}
+	}
+	return prefix + strings.ReplaceAll(rest, "/", `\`)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// rootKey returns the canonical form of a root used to compare roots:
+// absolute, and case-folded on Windows where paths are case-insensitive
+func rootKey(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	if runtime.GOOS == "windows" {
+		abs = strings.ToLower(abs)
+	}
+	return abs
+}
+
+// DedupRoots removes roots that name the same directory as an earlier one,
+// returning the remaining roots and the removed duplicates
+func DedupRoots(dirs []string) (unique, dropped []string) {
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		key := rootKey(dir)
+		if seen[key] {
+			dropped = append(dropped, dir)
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, dir)
+	}
+	return unique, dropped
+}
+
+// NestedRoots returns every pair of roots where the second lies inside the
+// first. Files under the inner root are scanned twice in that case.
+func NestedRoots(dirs []string) [][2]string {
+	var nested [][2]string
+	for _, outer := range dirs {
+		outerKey := rootKey(outer)
+		for _, inner := range dirs {
+			innerKey := rootKey(inner)
+			if innerKey == outerKey {
+				continue
+			}
+			rel, err := filepath.Rel(outerKey, innerKey)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				nested = append(nested, [2]string{outer, inner})
+			}
+		}
+	}
+	return nested
+}
@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanWindowsPath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`C:\Users\me\Photos`, `C:\Users\me\Photos`},
+		{`c:/Users//me/Photos/`, `C:\Users\me\Photos`},
+		{`C:\Users\me\..\you\.\Photos`, `C:\Users\you\Photos`},
+		{`C:\`, `C:\`},
+		{`C:/..`, `C:\`},
+		{`c:`, `C:`},
+		{`D:photos\`, `D:photos`},
+		{`\\server\share\dir\`, `\\server\share\dir`},
+		{`//server/share/dir`, `\\server\share\dir`},
+		{`\temp\`, `\temp`},
+		{`.\photos/2023\`, `photos\2023`},
+		{`..\photos`, `..\photos`},
+		{`.`, `.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cleanWindowsPath(tt.input))
+		})
+	}
+}
+
+func TestDedupRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a")
+	b := filepath.Join(tmpDir, "b")
+
+	unique, dropped := DedupRoots([]string{a, b, a + string(filepath.Separator) + ".", b})
+
+	assert.Equal(t, []string{a, b}, unique)
+	assert.Len(t, dropped, 2)
+}
+
+func TestNestedRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	photos := filepath.Join(tmpDir, "photos")
+	trip := filepath.Join(photos, "trip")
+	photosOld := filepath.Join(tmpDir, "photos-old")
+
+	nested := NestedRoots([]string{photos, trip, photosOld})
+
+	assert.Equal(t, [][2]string{{photos, trip}}, nested)
+}
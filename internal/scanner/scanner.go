@@ -1,17 +1,33 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/Sho2010/dup-finder/internal/fsio"
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/panics"
+	"github.com/Sho2010/dup-finder/internal/progress"
 )
 
 // Scanner handles directory scanning with filtering
 type Scanner struct {
-	options models.ScanOptions
+	options     models.ScanOptions
+	onSoftLimit SoftLimitFunc
+	limits      *softLimits
+	interrupted atomic.Bool // A scan's context was done: walks stop, keeping the files found
+	mu          sync.Mutex
+	symlinks    []Symlink
+	stats       []DirStats
+	errors      []models.ScanError
 }
 
 // NewScanner creates a new scanner with the given options
@@ -19,42 +35,165 @@ func NewScanner(opts models.ScanOptions) *Scanner {
 	return &Scanner{options: opts}
 }
 
-// Scan scans a single directory and returns all matching files
-func (s *Scanner) Scan(directory string) ([]models.FileInfo, error) {
+// OnSoftLimit sets the function consulted when a scan passes
+// SoftMaxFiles or SoftMaxDuration. Without one the scan always continues.
+func (s *Scanner) OnSoftLimit(fn SoftLimitFunc) {
+	s.onSoftLimit = fn
+}
+
+// Partial reports whether the last scan was stopped early, at a soft limit
+// or by its context
+func (s *Scanner) Partial() bool {
+	interrupted := s.interrupted.Load()
+	if interrupted || s.limits == nil {
+		return interrupted
+	}
+	s.limits.mu.Lock()
+	defer s.limits.mu.Unlock()
+	return s.limits.partial
+}
+
+// Scan scans a single directory and returns all matching files, recording
+// what it met for Stats. Once ctx is done the walk stops and the files
+// found so far are returned, with Partial reporting true.
+func (s *Scanner) Scan(ctx context.Context, directory string) ([]models.FileInfo, error) {
 	baseDir, err := filepath.Abs(directory)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path: %w", err)
 	}
 
+	if s.limits == nil {
+		s.limits = s.newSoftLimits()
+	}
+
+	stop := context.AfterFunc(ctx, func() { s.interrupted.Store(true) })
+	defer stop()
+
 	var files []models.FileInfo
+	counter := &statsCounter{stats: newDirStats(directory)}
+	defer counter.record(s)
 	pool := NewWorkerPool(s.options.NumWorkers)
 	pool.Start()
 
 	// Collect results in a separate goroutine
 	done := make(chan bool)
+	var poolErrors []models.ScanError
 	go func() {
 		for result := range pool.Results() {
 			if result.Error != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", result.Error)
+				op := OpLstat
+				var panicErr *panics.Error
+				if errors.As(result.Error, &panicErr) {
+					// Already printed with its stack
+					op = OpPanic
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", result.Error)
+				}
+				poolErrors = append(poolErrors, models.ScanError{Path: result.FileInfo.Path, Op: op, Err: result.Error.Error()})
 				continue
 			}
 			files = append(files, result.FileInfo)
+			progress.FileFound()
 		}
 		done <- true
 	}()
 
-	// Walk directory and submit jobs
-	err = filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+	// A root given as a symlink is followed: walking it with a trailing
+	// separator makes the walk list the target directory under the link's path
+	root := directory
+	if info, err := fsio.Lstat(directory); err == nil && isSymlink(info) {
+		link := inspectSymlink(directory)
+		if link.Kind == SymlinkDir {
+			link.Kind = SymlinkRoot
+			root = directory + string(filepath.Separator)
+		}
+		s.recordSymlink(link)
+	}
+
+	ignores := newDupignores(root)
+	visited := newDirSet() // Directories scanned so far, with FollowSymlinks
+
+	// Walk directory and submit jobs. With WalkWorkers, visit runs in
+	// several goroutines at once.
+	var w *walker
+	visit := func(path string, d fs.DirEntry, err error) error {
+		if s.interrupted.Load() {
+			return filepath.SkipAll
+		}
 		if err != nil {
+			// A root that cannot be read fails its scan instead of
+			// passing for an empty directory
+			if path == root {
+				return err
+			}
 			fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", path, err)
+			// Without an entry the path itself could not be read, otherwise
+			// the listing of a directory that was
+			op := OpReadDir
+			if d == nil {
+				op = OpLstat
+			}
+			counter.failed(path, op, err)
 			return nil
 		}
 
+		// Links inside the tree are followed only when the options ask for
+		// it; a root that links to anything but a directory was recorded above
+		if d.Type()&fs.ModeSymlink != 0 {
+			if path == root {
+				return nil
+			}
+			link := inspectSymlink(path)
+			target, followed := s.followSymlink(&link, visited)
+			s.recordSymlink(link)
+			if !followed {
+				if link.Kind == SymlinkDir || link.Kind == SymlinkLoop {
+					counter.skippedDir(FilteredSymlink)
+				} else {
+					counter.file(FilteredSymlink, 0)
+				}
+				return nil
+			}
+			if target.IsDir() {
+				// The directory is listed under the link's path
+				return w.walkPath(path, fs.FileInfoToDirEntry(target))
+			}
+			d = fs.FileInfoToDirEntry(target)
+		}
+
+		if err := s.limits.check(!d.IsDir()); err != nil {
+			return err
+		}
+
 		// Skip directories
-		if info.IsDir() {
-			if !s.options.Recursive && path != directory {
+		if d.IsDir() {
+			skip := func(reason string) error {
+				counter.skippedDir(reason)
 				return filepath.SkipDir
 			}
+			if !s.options.Recursive && path != root {
+				return skip(FilteredNotRecursive)
+			}
+
+			// Prune hidden directories
+			if s.options.SkipHidden && path != root && isHidden(d) {
+				return skip(FilteredHidden)
+			}
+
+			// Prune package-manager and cache directories
+			if s.options.SkipCacheDirs && path != root && isCacheDir(path) {
+				return skip(FilteredCacheDir)
+			}
+
+			// Prune directories excluded by name
+			if path != root && matchesDirName(d.Name(), s.options.ExcludeDirs) {
+				return skip(FilteredExcludeDir)
+			}
+
+			// Prune directories ignored by a .dupignore file above them
+			if s.options.UseDupignore && path != root && ignores.ignored(path, true) {
+				return skip(FilteredDupignore)
+			}
 
 			// Check max depth
 			if s.options.MaxDepth >= 0 {
@@ -68,17 +207,68 @@ func (s *Scanner) Scan(directory string) ([]models.FileInfo, error) {
 				}
 				depth := strings.Count(relPath, string(filepath.Separator))
 				if depth > s.options.MaxDepth {
+					return skip(FilteredMaxDepth)
+				}
+			}
+
+			// With links followed, each directory is scanned once, under
+			// the first path that reaches it, so a link cannot loop
+			if s.options.FollowSymlinks {
+				info, err := fsio.Info(path, d)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", path, err)
+					counter.failed(path, OpLstat, err)
 					return filepath.SkipDir
 				}
+				if id, ok := dirID(path, info); ok && !visited.add(id) {
+					return skip(FilteredScanned)
+				}
+			}
+
+			if s.options.UseDupignore {
+				if err := ignores.load(path); err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading %s in %s: %v\n", DupignoreName, path, err)
+					counter.failed(filepath.Join(path, DupignoreName), OpDupignore, err)
+				}
 			}
+			return nil
+		}
+
+		if s.options.SkipHidden && isHidden(d) {
+			counter.file(FilteredHidden, 0)
+			return nil
+		}
+
+		// Ignore files are settings, not data, and files they ignore are skipped
+		if s.options.UseDupignore && (d.Name() == DupignoreName || ignores.ignored(path, false)) {
+			counter.file(FilteredDupignore, 0)
+			return nil
+		}
 
+		// FIFOs, sockets and devices are not files to compare, and opening
+		// a FIFO blocks until something writes to it
+		if !d.Type().IsRegular() {
+			counter.file(FilteredSpecial, 0)
 			return nil
 		}
 
-		// Apply filters
-		if !s.shouldIncludeFile(path, info) {
+		// Apply the filters on names first, so the files they leave out
+		// are never stat'ed
+		if reason := s.filterName(root, path); reason != "" {
+			counter.file(reason, 0)
 			return nil
 		}
+		info, err := fsio.Info(path, d)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", path, err)
+			counter.failed(path, OpLstat, err)
+			return nil
+		}
+		if info.Size() < s.options.MinSize {
+			counter.file(FilteredMinSize, 0)
+			return nil
+		}
+		counter.file("", info.Size())
 
 		// Submit job to worker pool
 		pool.Submit(ScanJob{
@@ -88,10 +278,15 @@ func (s *Scanner) Scan(directory string) ([]models.FileInfo, error) {
 		})
 
 		return nil
-	})
+	}
+	w = newWalker(s.options.WalkWorkers, visit)
+	err = w.walk(root)
 
 	pool.Close()
 	<-done
+	counter.stats.Kept -= len(poolErrors)
+	counter.stats.Errors += len(poolErrors)
+	counter.errors = append(counter.errors, poolErrors...)
 
 	if err != nil {
 		return nil, fmt.Errorf("error walking directory: %w", err)
@@ -100,35 +295,85 @@ func (s *Scanner) Scan(directory string) ([]models.FileInfo, error) {
 	return files, nil
 }
 
-// shouldIncludeFile checks if a file should be included based on filters
-func (s *Scanner) shouldIncludeFile(path string, info os.FileInfo) bool {
-	// Check minimum size
-	if info.Size() < s.options.MinSize {
-		return false
+// filterName returns the filter on names and paths that leaves out a file
+// under root, or "" if the file is included
+func (s *Scanner) filterName(root, path string) string {
+	// Check file extension
+	if len(s.options.Extensions) > 0 && !HasExtension(path, s.options.Extensions) {
+		return FilteredExtension
 	}
 
-	// Check file extension
-	if len(s.options.Extensions) > 0 {
-		ext := strings.ToLower(filepath.Ext(path))
-		matched := false
-		for _, allowedExt := range s.options.Extensions {
-			if strings.ToLower(allowedExt) == ext {
-				matched = true
-				break
-			}
+	// Check file name patterns
+	if len(s.options.Includes) > 0 && !matchesInclude(filepath.Base(path), s.options.Includes) {
+		return FilteredInclude
+	}
+
+	// Check path regular expressions
+	if s.options.NameRegex != nil || s.options.ExcludeRegex != nil {
+		rel := relativeSlashPath(root, path)
+		if s.options.NameRegex != nil && !s.options.NameRegex.MatchString(rel) {
+			return FilteredNameRegex
+		}
+		if s.options.ExcludeRegex != nil && s.options.ExcludeRegex.MatchString(rel) {
+			return FilteredExcludeRegex
 		}
-		if !matched {
-			return false
+	}
+
+	return ""
+}
+
+// FilterFiles applies the filters on names and sizes to files listed
+// under root without scanning them, such as those of a catalog
+func (s *Scanner) FilterFiles(root string, files []models.FileInfo) []models.FileInfo {
+	var kept []models.FileInfo
+	for _, file := range files {
+		if s.filterName(root, file.Path) == "" && (file.Size >= s.options.MinSize || file.SizeUnknown) {
+			kept = append(kept, file)
 		}
 	}
+	return kept
+}
 
-	return true
+// RootsError is returned by ScanAll when some roots could not be scanned,
+// along with the files of the others
+type RootsError struct {
+	Failed map[string]error // Error of each root that failed
 }
 
-// ScanAll scans all directories in parallel
-func (s *Scanner) ScanAll() (map[string][]models.FileInfo, error) {
+func (e *RootsError) Error() string {
+	roots := e.Roots()
+	messages := make([]string, len(roots))
+	for i, root := range roots {
+		messages[i] = e.Failed[root].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Roots returns the roots that failed, sorted
+func (e *RootsError) Roots() []string {
+	roots := make([]string, 0, len(e.Failed))
+	for root := range e.Failed {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// ScanAll scans all directories in parallel. Once ctx is done every walk
+// stops and the files found so far are returned, with Partial reporting true.
+// A root that fails does not stop the others: their files are returned with
+// a *RootsError naming the roots that failed. ErrScanAborted ends every
+// scan.
+func (s *Scanner) ScanAll(ctx context.Context) (map[string][]models.FileInfo, error) {
 	results := make(map[string][]models.FileInfo)
-	errors := make(chan error, len(s.options.Directories))
+	s.limits = s.newSoftLimits()
+	s.symlinks = nil
+	s.stats = nil
+	s.errors = nil
+	failures := make(chan struct {
+		dir string
+		err error
+	}, len(s.options.Directories))
 	filesChan := make(chan struct {
 		dir   string
 		files []models.FileInfo
@@ -137,9 +382,12 @@ func (s *Scanner) ScanAll() (map[string][]models.FileInfo, error) {
 	// Scan each directory in parallel
 	for _, dir := range s.options.Directories {
 		go func(directory string) {
-			files, err := s.Scan(directory)
+			files, err := s.Scan(ctx, directory)
 			if err != nil {
-				errors <- fmt.Errorf("error scanning %s: %w", directory, err)
+				failures <- struct {
+					dir string
+					err error
+				}{directory, fmt.Errorf("error scanning %s: %w", directory, err)}
 				return
 			}
 			filesChan <- struct {
@@ -149,15 +397,27 @@ func (s *Scanner) ScanAll() (map[string][]models.FileInfo, error) {
 		}(dir)
 	}
 
-	// Collect results
+	// Collect results, waiting for every root
+	failed := make(map[string]error)
 	for i := 0; i < len(s.options.Directories); i++ {
 		select {
 		case result := <-filesChan:
 			results[result.dir] = result.files
-		case err := <-errors:
-			return nil, err
+		case failure := <-failures:
+			if errors.Is(failure.err, ErrScanAborted) {
+				return nil, failure.err
+			}
+			failed[failure.dir] = failure.err
 		}
 	}
 
+	if len(failed) > 0 {
+		return results, &RootsError{Failed: failed}
+	}
 	return results, nil
 }
+
+// newSoftLimits starts tracking soft limits from now
+func (s *Scanner) newSoftLimits() *softLimits {
+	return newSoftLimits(s.options.SoftMaxFiles, s.options.SoftMaxDuration, s.onSoftLimit)
+}
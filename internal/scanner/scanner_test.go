@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScanner_ContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+	s := NewScanner(models.ScanOptions{
+		Directories: []string{dir},
+		Recursive:   true,
+		MaxDepth:    -1,
+		NumWorkers:  1,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Stopped like a partial scan, keeping what was found
+	_, err := s.ScanAll(ctx)
+	assert.NoError(t, err)
+	assert.True(t, s.Partial())
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Reasons a file is left out of a scan, or a directory not descended into
+const (
+	FilteredHidden       = "hidden"        // Hidden file or directory, with SkipHidden
+	FilteredCacheDir     = "cache dir"     // Package-manager or cache directory, with SkipCacheDirs
+	FilteredExcludeDir   = "exclude-dir"   // Directory name matching ExcludeDirs
+	FilteredDupignore    = "dupignore"     // Matched by a .dupignore file, or the file itself
+	FilteredMaxDepth     = "max depth"     // Directory deeper than MaxDepth
+	FilteredNotRecursive = "not recursive" // Subdirectory, without Recursive
+	FilteredScanned      = "scanned"       // Directory already scanned through another link
+	FilteredSymlink      = "symlink"       // Link not followed (see Symlinks)
+	FilteredSpecial      = "special file"  // FIFO, socket or device
+	FilteredMinSize      = "min size"      // Smaller than MinSize
+	FilteredExtension    = "extension"     // Extension not in Extensions
+	FilteredInclude      = "include"       // Name matching none of Includes
+	FilteredNameRegex    = "name regex"    // Path not matching NameRegex
+	FilteredExcludeRegex = "exclude regex" // Path matching ExcludeRegex
+)
+
+// Operations that fail in a models.ScanError
+const (
+	OpLstat     = "lstat"                 // Reading a path's type, size and time
+	OpReadDir   = "readdir"               // Listing a directory
+	OpDupignore = "read " + DupignoreName // Reading a directory's ignore file
+	OpPanic     = "panic"                 // Processing a file panicked
+)
+
+// DirStats counts what the scan of one root directory met, to explain why
+// a file expected among the duplicates is missing. Files inside skipped
+// directories are never seen, so they are not counted.
+type DirStats struct {
+	Root        string         // The root directory, as given
+	Seen        int            // Files met, kept or not
+	Kept        int            // Files kept for comparison
+	Bytes       int64          // Total size of the kept files
+	Filtered    map[string]int // Files left out, by reason
+	SkippedDirs map[string]int // Directories not descended into, by reason
+	Errors      int            // Paths that could not be read
+}
+
+func newDirStats(root string) *DirStats {
+	return &DirStats{Root: root, Filtered: make(map[string]int), SkippedDirs: make(map[string]int)}
+}
+
+// statsCounter adds to the stats of one scan from walkers running
+// concurrently
+type statsCounter struct {
+	mu     sync.Mutex
+	stats  *DirStats
+	errors []models.ScanError
+}
+
+// file counts a file met, left out for reason, or kept with size bytes if
+// reason is ""
+func (c *statsCounter) file(reason string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Seen++
+	if reason != "" {
+		c.stats.Filtered[reason]++
+		return
+	}
+	c.stats.Kept++
+	c.stats.Bytes += size
+}
+
+// skippedDir counts a directory not descended into for reason
+func (c *statsCounter) skippedDir(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.SkippedDirs[reason]++
+}
+
+// failed counts a path that could not be read and records why
+func (c *statsCounter) failed(path, op string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Errors++
+	c.errors = append(c.errors, models.ScanError{Path: path, Op: op, Err: err.Error()})
+}
+
+// record adds the stats and errors of a finished scan to s
+func (c *statsCounter) record(s *Scanner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = append(s.stats, *c.stats)
+	s.errors = append(s.errors, c.errors...)
+}
+
+// Stats returns the stats of each directory scanned since the last
+// ScanAll, sorted by root
+func (s *Scanner) Stats() []DirStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]DirStats, len(s.stats))
+	copy(stats, s.stats)
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Root < stats[j].Root })
+	return stats
+}
+
+// Errors returns the paths that could not be read since the last ScanAll,
+// sorted by path. Files at or under them are missing from the results.
+func (s *Scanner) Errors() []models.ScanError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errors := make([]models.ScanError, len(s.errors))
+	copy(errors, s.errors)
+	sort.SliceStable(errors, func(i, j int) bool { return errors[i].Path < errors[j].Path })
+	return errors
+}
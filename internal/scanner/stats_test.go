@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScan_Stats(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"keep.jpg":             "12345",
+		"sub/keep.jpg":         "123",
+		"small.jpg":            "1",
+		"notes.txt":            "12345",
+		"draft-photo.jpg":      "12345",
+		".hidden.jpg":          "12345",
+		".cache/x.jpg":         "12345",
+		"node_modules/a/b.jpg": "12345",
+		"RAW/c.jpg":            "12345",
+		"a/b/c/deep.jpg":       "12345",
+	} {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+
+	s := NewScanner(models.ScanOptions{
+		Directories:   []string{dir},
+		Recursive:     true,
+		MaxDepth:      1,
+		MinSize:       2,
+		Extensions:    []string{".jpg"},
+		ExcludeRegex:  regexp.MustCompile(`draft`),
+		SkipHidden:    true,
+		SkipCacheDirs: true,
+		ExcludeDirs:   ExcludeDirs([]string{"RAW"}, false, false),
+		NumWorkers:    2,
+	})
+	files, err := s.ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, files[dir], 2)
+
+	stats := s.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, DirStats{
+		Root:  dir,
+		Seen:  6,
+		Kept:  2,
+		Bytes: 8,
+		Filtered: map[string]int{
+			FilteredMinSize:      1,
+			FilteredExtension:    1,
+			FilteredExcludeRegex: 1,
+			FilteredHidden:       1,
+		},
+		SkippedDirs: map[string]int{
+			FilteredHidden:     1,
+			FilteredCacheDir:   1,
+			FilteredExcludeDir: 1,
+			FilteredMaxDepth:   1,
+		},
+	}, stats[0])
+}
+
+func TestScan_StatsErrors(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() == 0 {
+		t.Skip("directories cannot be made unreadable")
+	}
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked")
+	require.NoError(t, os.MkdirAll(locked, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(locked, "a.jpg"), []byte("a"), 0644))
+	require.NoError(t, os.Chmod(locked, 0))
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	s := NewScanner(models.ScanOptions{Directories: []string{dir}, Recursive: true, MaxDepth: -1, NumWorkers: 1})
+	_, err := s.ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Stats()[0].Errors)
+	require.Len(t, s.Errors(), 1)
+	assert.Equal(t, locked, s.Errors()[0].Path)
+	assert.Equal(t, OpReadDir, s.Errors()[0].Op)
+	assert.NotEmpty(t, s.Errors()[0].Err)
+}
+
+func TestScan_ErrorsDupignore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "album", DupignoreName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "album", "a.jpg"), []byte("a"), 0644))
+
+	s := NewScanner(models.ScanOptions{Directories: []string{dir}, Recursive: true, MaxDepth: -1, NumWorkers: 1, UseDupignore: true})
+	files, err := s.ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, files[dir], 1, "the directory is still scanned")
+	require.Len(t, s.Errors(), 1)
+	assert.Equal(t, filepath.Join(dir, "album", DupignoreName), s.Errors()[0].Path)
+	assert.Equal(t, OpDupignore, s.Errors()[0].Op)
+
+	// Errors are reset by the next scan
+	require.NoError(t, os.Remove(filepath.Join(dir, "album", DupignoreName)))
+	_, err = s.ScanAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, s.Errors())
+}
+
+func TestScanAll_RootFailure(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0644))
+	missing := filepath.Join(t.TempDir(), "unmounted")
+
+	s := NewScanner(models.ScanOptions{Directories: []string{dir, missing}, Recursive: true, MaxDepth: -1, NumWorkers: 1})
+	files, err := s.ScanAll(context.Background())
+	var rootsErr *RootsError
+	require.ErrorAs(t, err, &rootsErr)
+	assert.Equal(t, []string{missing}, rootsErr.Roots())
+	assert.ErrorContains(t, err, missing)
+	assert.Len(t, files[dir], 1, "the other roots are still scanned")
+	assert.NotContains(t, files, missing)
+	assert.Empty(t, s.Errors(), "a failed root is not a scan error")
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// Kinds of symlinks met while scanning
+const (
+	SymlinkRoot   = "root"      // A root directory given as a symlink; it is followed
+	SymlinkFile   = "file"      // Link to a file; skipped unless SymlinkFiles is SymlinkFilesTarget
+	SymlinkDir    = "directory" // Link to a directory; skipped unless FollowSymlinks is set
+	SymlinkLoop   = "loop"      // Link to a directory already scanned; skipped even with FollowSymlinks
+	SymlinkBroken = "broken"    // Link whose target does not exist; skipped
+)
+
+// Ways to handle links to files (ScanOptions.SymlinkFiles)
+const (
+	SymlinkFilesSkip   = "skip"   // Skip them; the target is scanned where it lives
+	SymlinkFilesTarget = "target" // Scan them as files with the target's size and content
+)
+
+// SymlinkFilesModes lists the supported ways to handle links to files
+var SymlinkFilesModes = []string{SymlinkFilesSkip, SymlinkFilesTarget}
+
+// ValidateSymlinkFiles checks a --symlink-files value
+func ValidateSymlinkFiles(mode string) error {
+	for _, m := range SymlinkFilesModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --symlink-files mode %q (supported: %s)", mode, strings.Join(SymlinkFilesModes, ", "))
+}
+
+// fileID identifies a directory for loop detection: by device and inode
+// where the platform has them, otherwise by resolved path
+type fileID struct {
+	dev, ino uint64
+	path     string
+}
+
+// dirSet holds the IDs of the directories scanned so far in one walk
+type dirSet struct {
+	mu  sync.Mutex
+	ids map[fileID]bool
+}
+
+func newDirSet() *dirSet {
+	return &dirSet{ids: make(map[fileID]bool)}
+}
+
+// has reports whether the directory was scanned
+func (d *dirSet) has(id fileID) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ids[id]
+}
+
+// add records the directory, reporting false if it was already there
+func (d *dirSet) add(id fileID) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ids[id] {
+		return false
+	}
+	d.ids[id] = true
+	return true
+}
+
+// Symlink is a symbolic link met while scanning
+type Symlink struct {
+	Path   string // Path of the link
+	Target string // Link target as stored in the link
+	Kind   string // See the Symlink* constants
+
+	followed bool // Whether a link inside a tree was followed
+}
+
+// Followed reports whether the scan went through the link
+func (l Symlink) Followed() bool {
+	return l.Kind == SymlinkRoot || l.followed
+}
+
+// isSymlink reports whether info describes a symbolic link
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// inspectSymlink describes the link at path, classifying it by what it
+// points to
+func inspectSymlink(path string) Symlink {
+	link := Symlink{Path: path, Kind: SymlinkBroken}
+	link.Target, _ = os.Readlink(path)
+	if target, err := fsio.Stat(path); err == nil {
+		link.Kind = SymlinkFile
+		if target.IsDir() {
+			link.Kind = SymlinkDir
+		}
+	}
+	return link
+}
+
+// followSymlink decides whether the scan goes through a link inside a tree,
+// returning the target's info when it does. A link to a directory that was
+// already scanned, such as one of its own parents, is marked as a loop.
+func (s *Scanner) followSymlink(link *Symlink, visited *dirSet) (os.FileInfo, bool) {
+	switch {
+	case link.Kind == SymlinkDir && s.options.FollowSymlinks:
+	case link.Kind == SymlinkFile && s.options.SymlinkFiles == SymlinkFilesTarget:
+	default:
+		return nil, false
+	}
+
+	target, err := fsio.Stat(link.Path)
+	if err != nil {
+		return nil, false
+	}
+	if target.IsDir() {
+		if id, ok := dirID(link.Path, target); ok && visited.has(id) {
+			link.Kind = SymlinkLoop
+			return nil, false
+		}
+	}
+	link.followed = true
+	return target, true
+}
+
+// recordSymlink adds a link to those met during the scan
+func (s *Scanner) recordSymlink(link Symlink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symlinks = append(s.symlinks, link)
+}
+
+// Symlinks returns the symbolic links met by the last scan, sorted by path
+func (s *Scanner) Symlinks() []Symlink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	links := make([]Symlink, len(s.symlinks))
+	copy(links, s.symlinks)
+	sort.Slice(links, func(i, j int) bool { return links[i].Path < links[j].Path })
+	return links
+}
+
+// CountSymlinks returns the number of links of each kind
+func CountSymlinks(links []Symlink) map[string]int {
+	counts := make(map[string]int)
+	for _, link := range links {
+		counts[link.Kind]++
+	}
+	return counts
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScan_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	photos := filepath.Join(dir, "photos")
+	backup := filepath.Join(dir, "backup")
+	require.NoError(t, os.MkdirAll(filepath.Join(photos, "2024"), 0755))
+	require.NoError(t, os.MkdirAll(backup, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "2024", "a.jpg"), []byte("a"), 0644))
+
+	link := filepath.Join(dir, "photos-link")
+	if err := os.Symlink(photos, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink(filepath.Join(photos, "2024", "a.jpg"), filepath.Join(backup, "a.jpg")))
+	require.NoError(t, os.Symlink(photos, filepath.Join(backup, "photos")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "missing"), filepath.Join(backup, "gone.jpg")))
+
+	s := NewScanner(models.ScanOptions{
+		Directories: []string{link, backup},
+		Recursive:   true,
+		MaxDepth:    -1,
+		NumWorkers:  runtime.NumCPU(),
+	})
+	files, err := s.ScanAll(context.Background())
+	require.NoError(t, err)
+
+	// The root link is followed, with paths under the link
+	require.Len(t, files[link], 1)
+	assert.Equal(t, filepath.Join(link, "2024", "a.jpg"), files[link][0].Path)
+	assert.Equal(t, link, files[link][0].Directory)
+
+	// Links inside a tree are skipped
+	assert.Empty(t, files[backup])
+
+	links := s.Symlinks()
+	require.Len(t, links, 4)
+	assert.Equal(t, map[string]int{SymlinkRoot: 1, SymlinkFile: 1, SymlinkDir: 1, SymlinkBroken: 1}, CountSymlinks(links))
+	for _, l := range links {
+		assert.Equal(t, l.Path == link, l.Followed(), l.Path)
+	}
+}
+
+func TestScan_FollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	photos := filepath.Join(root, "photos")
+	outside := filepath.Join(t.TempDir(), "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(photos, "2024"), 0755))
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(photos, "2024", "a.jpg"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "b.jpg"), []byte("b"), 0644))
+
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	// A link to its own parent would loop forever, and a second link to a
+	// directory would scan it twice
+	require.NoError(t, os.Symlink(photos, filepath.Join(photos, "2024", "up")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "zz-again")))
+	require.NoError(t, os.Symlink(filepath.Join(photos, "2024", "a.jpg"), filepath.Join(root, "a-link.jpg")))
+
+	scan := func(opts models.ScanOptions) ([]string, *Scanner) {
+		opts.Recursive, opts.MaxDepth, opts.NumWorkers = true, -1, 1
+		s := NewScanner(opts)
+		files, err := s.Scan(context.Background(), root)
+		require.NoError(t, err)
+		var rel []string
+		for _, file := range files {
+			r, err := filepath.Rel(root, file.Path)
+			require.NoError(t, err)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		sort.Strings(rel)
+		return rel, s
+	}
+
+	files, _ := scan(models.ScanOptions{})
+	assert.Equal(t, []string{"photos/2024/a.jpg"}, files)
+
+	files, s := scan(models.ScanOptions{FollowSymlinks: true})
+	assert.Equal(t, []string{"linked/b.jpg", "photos/2024/a.jpg"}, files)
+	counts := map[string]int{}
+	for _, link := range s.Symlinks() {
+		if link.Followed() {
+			counts["followed "+link.Kind]++
+		} else {
+			counts[link.Kind]++
+		}
+	}
+	assert.Equal(t, map[string]int{"followed directory": 1, SymlinkLoop: 2, SymlinkFile: 1}, counts)
+
+	// Links to files can be scanned as their targets
+	files, _ = scan(models.ScanOptions{SymlinkFiles: SymlinkFilesTarget})
+	assert.Equal(t, []string{"a-link.jpg", "photos/2024/a.jpg"}, files)
+}
+
+func TestValidateSymlinkFiles(t *testing.T) {
+	assert.NoError(t, ValidateSymlinkFiles(SymlinkFilesSkip))
+	assert.NoError(t, ValidateSymlinkFiles(SymlinkFilesTarget))
+	assert.Error(t, ValidateSymlinkFiles("follow"))
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+
+	"github.com/Sho2010/dup-finder/internal/fsio"
+)
+
+// walker is filepath.WalkDir with its Lstat and directory reads going
+// through fsio, so a path that stops responding is reported to fn with a
+// timeout error instead of hanging the scan. With more than one worker,
+// subdirectories are walked in new goroutines while workers are free, so
+// several directories are read at once and fn is called concurrently; a
+// directory is still visited before its contents.
+type walker struct {
+	fn    fs.WalkDirFunc
+	slots chan struct{} // Held by each extra goroutine; nil to walk in one
+	wg    sync.WaitGroup
+
+	mu  sync.Mutex
+	err error // First error that ended a subdirectory walked in its own goroutine
+}
+
+// newWalker returns a walker calling fn with up to workers goroutines
+func newWalker(workers int, fn fs.WalkDirFunc) *walker {
+	w := &walker{fn: fn}
+	if workers > 1 {
+		w.slots = make(chan struct{}, workers-1)
+	}
+	return w
+}
+
+// walk walks the tree at root, returning once every goroutine is done
+func (w *walker) walk(root string) error {
+	info, err := fsio.Lstat(root)
+	if err != nil {
+		err = w.fn(root, nil, err)
+	} else {
+		err = w.walkPath(root, fs.FileInfoToDirEntry(info))
+	}
+	w.wg.Wait()
+	if err == nil {
+		err = w.failed()
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkPath walks path, following the same rules as filepath.WalkDir
+func (w *walker) walkPath(path string, d fs.DirEntry) error {
+	if err := w.fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			// The directory is skipped, not its parent
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := fsio.ReadDir(path)
+	if err != nil {
+		// A failed read is reported a second time; the walk then moves on
+		// without the directory's contents
+		if err := w.fn(path, d, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		if w.failed() != nil {
+			return nil
+		}
+		name := filepath.Join(path, entry.Name())
+		if entry.IsDir() && w.spawn(name, entry) {
+			continue
+		}
+		if err := w.walkPath(name, entry); err != nil {
+			if err == filepath.SkipDir {
+				// Returned for a file, it skips the rest of the directory
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// spawn walks the directory at path in a new goroutine if a slot is free
+func (w *walker) spawn(path string, d fs.DirEntry) bool {
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		return false
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { <-w.slots }()
+		if err := w.walkPath(path, d); err != nil {
+			w.mu.Lock()
+			if w.err == nil {
+				w.err = err
+			}
+			w.mu.Unlock()
+		}
+	}()
+	return true
+}
+
+// failed returns the error that ended a walk in another goroutine, if any
+func (w *walker) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
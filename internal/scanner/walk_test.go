@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestWalk_MatchesFilepathWalkDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"b/x.jpg", "a/y.jpg", "a/skip/z.jpg", "c.jpg"} {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte("x"), 0644))
+	}
+
+	visit := func(seen *[]string) fs.WalkDirFunc {
+		return func(path string, d fs.DirEntry, err error) error {
+			require.NoError(t, err)
+			*seen = append(*seen, path)
+			if d.IsDir() && d.Name() == "skip" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+	}
+	var want, got []string
+	require.NoError(t, filepath.WalkDir(dir, visit(&want)))
+	require.NoError(t, newWalker(1, visit(&got)).walk(dir))
+	assert.Equal(t, want, got)
+}
+
+func TestWalk_Parallel(t *testing.T) {
+	dir := t.TempDir()
+	var want []string
+	for i := 0; i < 20; i++ {
+		for _, path := range []string{"a.jpg", "sub/b.jpg", "skip/c.jpg"} {
+			full := filepath.Join(dir, fmt.Sprintf("d%02d", i), filepath.FromSlash(path))
+			require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+			require.NoError(t, os.WriteFile(full, []byte("x"), 0644))
+		}
+	}
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if d.IsDir() && d.Name() == "skip" {
+			return filepath.SkipDir
+		}
+		want = append(want, path)
+		return nil
+	}))
+
+	var mu sync.Mutex
+	var got []string
+	parentSeen := true
+	require.NoError(t, newWalker(8, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		if d.IsDir() && d.Name() == "skip" {
+			return filepath.SkipDir
+		}
+		if path != dir && !slices.Contains(got, filepath.Dir(path)) {
+			parentSeen = false
+		}
+		got = append(got, path)
+		return nil
+	}).walk(dir))
+	assert.True(t, parentSeen, "a directory is visited before its contents")
+	sort.Strings(want)
+	sort.Strings(got)
+	assert.Equal(t, want, got)
+}
+
+func TestWalk_ParallelError(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, fmt.Sprintf("d%02d", i), "sub"), 0755))
+	}
+	stop := errors.New("stop")
+	err := newWalker(4, func(path string, d fs.DirEntry, err error) error {
+		if d.Name() == "sub" {
+			return stop
+		}
+		return nil
+	}).walk(dir)
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestScan_WalkWorkers(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		for _, path := range []string{"a.jpg", "b.txt", "sub/c.jpg", "sub/deep/d.jpg", "node_modules/e.jpg", ".dupignore", "ignored/f.jpg"} {
+			full := filepath.Join(dir, fmt.Sprintf("d%d", i), filepath.FromSlash(path))
+			require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+			content := "x"
+			if path == ".dupignore" {
+				content = "ignored/\n"
+			}
+			require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+		}
+	}
+
+	scan := func(workers int) ([]string, DirStats) {
+		s := NewScanner(models.ScanOptions{
+			Directories:   []string{dir},
+			Recursive:     true,
+			MaxDepth:      1,
+			Extensions:    []string{".jpg"},
+			SkipCacheDirs: true,
+			UseDupignore:  true,
+			NumWorkers:    2,
+			WalkWorkers:   workers,
+		})
+		files, err := s.ScanAll(context.Background())
+		require.NoError(t, err)
+		var paths []string
+		for _, file := range files[dir] {
+			paths = append(paths, file.Path)
+		}
+		sort.Strings(paths)
+		return paths, s.Stats()[0]
+	}
+
+	want, wantStats := scan(1)
+	assert.Len(t, want, 20)
+	got, gotStats := scan(8)
+	assert.Equal(t, want, got)
+	assert.Equal(t, wantStats, gotStats)
+}
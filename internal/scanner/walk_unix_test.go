@@ -0,0 +1,46 @@
+//go:build unix
+
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestScan_SkipsFIFOs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0644))
+	require.NoError(t, syscall.Mkfifo(filepath.Join(dir, "pipe"), 0644))
+
+	files, err := NewScanner(models.ScanOptions{Recursive: true, MaxDepth: -1, NumWorkers: 1}).Scan(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "a.jpg", filepath.Base(files[0].Path))
+}
+
+func TestScan_RecordsInodes(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a.jpg")
+	require.NoError(t, os.WriteFile(original, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.jpg"), []byte("a"), 0644))
+	require.NoError(t, os.Link(original, filepath.Join(dir, "c.jpg")))
+
+	files, err := NewScanner(models.ScanOptions{Recursive: true, MaxDepth: -1, NumWorkers: 2}).Scan(context.Background(), dir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	byName := make(map[string]models.FileInfo)
+	for _, file := range files {
+		assert.NotZero(t, file.Inode, file.Path)
+		byName[filepath.Base(file.Path)] = file
+	}
+	assert.True(t, byName["a.jpg"].SameStorage(byName["c.jpg"]))
+	assert.False(t, byName["a.jpg"].SameStorage(byName["b.jpg"]))
+}
@@ -2,9 +2,11 @@ package scanner
 
 import (
 	"os"
+	"runtime"
 	"sync"
 
 	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/panics"
 )
 
 // ScanJob represents a file to be scanned
@@ -28,8 +30,23 @@ type WorkerPool struct {
 	wg         sync.WaitGroup
 }
 
-// NewWorkerPool creates a new worker pool
+// MaxWorkersPerCPU is the worker count per CPU above which more workers
+// mostly add contention
+const MaxWorkersPerCPU = 16
+
+// EffectiveWorkers returns numWorkers, or the CPU count when it is zero or
+// negative
+func EffectiveWorkers(numWorkers int) int {
+	if numWorkers < 1 {
+		return runtime.NumCPU()
+	}
+	return numWorkers
+}
+
+// NewWorkerPool creates a new worker pool. A zero or negative worker count
+// falls back to the CPU count instead of deadlocking or panicking.
 func NewWorkerPool(numWorkers int) *WorkerPool {
+	numWorkers = EffectiveWorkers(numWorkers)
 	return &WorkerPool{
 		numWorkers: numWorkers,
 		jobs:       make(chan ScanJob, numWorkers*2),
@@ -45,19 +62,21 @@ func (wp *WorkerPool) Start() {
 	}
 }
 
-// worker processes jobs from the jobs channel
+// worker processes jobs from the jobs channel. A job that panics is
+// reported as an error for its file, and the worker moves on to the next.
 func (wp *WorkerPool) worker() {
 	defer wp.wg.Done()
 	for job := range wp.jobs {
-		fileInfo := models.FileInfo{
-			Path:      job.Path,
-			Directory: job.Directory,
-			Size:      job.Info.Size(),
-			ModTime:   job.Info.ModTime(),
-		}
+		fileInfo := models.FileInfo{Path: job.Path, Directory: job.Directory}
+		err := panics.Do(job.Path, func() error {
+			fileInfo.Size = job.Info.Size()
+			fileInfo.ModTime = job.Info.ModTime()
+			fileInfo.Device, fileInfo.Inode = storageOf(job.Info)
+			return nil
+		})
 		wp.results <- ScanResult{
 			FileInfo: fileInfo,
-			Error:    nil,
+			Error:    err,
 		}
 	}
 }
@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/panics"
+)
+
+func TestWorkerPool_Panic(t *testing.T) {
+	defer panics.Reset()
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0644))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	pool := NewWorkerPool(1)
+	pool.Start()
+	// A job without file info panics in the worker
+	pool.Submit(ScanJob{Path: "/dev/odd", Directory: "/dev"})
+	pool.Submit(ScanJob{Path: path, Directory: filepath.Dir(path), Info: info})
+	go pool.Close()
+
+	var results []ScanResult
+	for result := range pool.Results() {
+		results = append(results, result)
+	}
+	require.Len(t, results, 2)
+	var panicErr *panics.Error
+	require.ErrorAs(t, results[0].Error, &panicErr)
+	assert.Equal(t, "/dev/odd", results[0].FileInfo.Path)
+	require.NoError(t, results[1].Error, "the worker goes on after a panic")
+	assert.Equal(t, int64(1), results[1].FileInfo.Size)
+}
@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// A Friday
+	from := time.Date(2024, 3, 15, 2, 30, 20, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 2, 31, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, 3, 16, 2, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 2, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 3, 15, 2, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * MON-wed", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 4 1,15 * *", time.Date(2024, 3, 15, 4, 0, 0, 0, time.UTC)},
+		{"0 1 1,15 * *", time.Date(2024, 4, 1, 1, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are given
+		{"0 0 20 * 6", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		// A field starting with "*" is not restricted: odd days that are Mondays
+		{"0 0 */2 * 1", time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, s.Next(from), tt.expr)
+	}
+}
+
+func TestNext_Location(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	s, err := Parse("0 3 * * *")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2024, 3, 15, 2, 0, 0, 0, tokyo))
+	assert.Equal(t, time.Date(2024, 3, 15, 3, 0, 0, 0, tokyo), next)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for expr, msg := range map[string]string{
+		"":              "expected 5 fields",
+		"0 3 * *":       "expected 5 fields",
+		"60 * * * *":    "invalid minute",
+		"* 24 * * *":    "invalid hour",
+		"* * 0 * *":     "invalid day of month",
+		"* * * foo *":   "invalid month",
+		"* * * * 8":     "invalid day of week",
+		"*/0 * * * *":   "invalid step",
+		"10-5 * * * *":  "invalid range",
+		"@fortnightly":  "expected 5 fields",
+		"1,,2 * * * *":  "invalid minute",
+		"* * * * * *":   "expected 5 fields",
+		"-1 * * * *":    "invalid minute",
+		"1-x * * * *":   "invalid minute",
+		"*/x * * * *":   "invalid step",
+		"* * * jan-x *": "invalid month",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorContains(t, err, msg, expr)
+	}
+}
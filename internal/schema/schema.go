@@ -0,0 +1,35 @@
+// Package schema holds the JSON Schema documents describing the JSON files
+// dup-finder writes, so other tools can validate them
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+// Documents described by a schema
+const (
+	Results     = "results"
+	Plan        = "plan"
+	Summary     = "summary"
+	Fingerprint = "fingerprint"
+	Manifest    = "manifest"
+	Session     = "session"
+)
+
+// Names lists the documents that have a schema
+var Names = []string{Results, Plan, Summary, Fingerprint, Manifest, Session}
+
+//go:embed *.schema.json
+var files embed.FS
+
+// Get returns the JSON Schema for the named document
+func Get(name string) ([]byte, error) {
+	for _, n := range Names {
+		if n == name {
+			return files.ReadFile(name + ".schema.json")
+		}
+	}
+	return nil, fmt.Errorf("unknown schema %q (available: %s)", name, strings.Join(Names, ", "))
+}
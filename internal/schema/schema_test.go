@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/catalog"
+	"github.com/Sho2010/dup-finder/internal/fingerprint"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+	"github.com/Sho2010/dup-finder/internal/policy"
+	"github.com/Sho2010/dup-finder/internal/report"
+	"github.com/Sho2010/dup-finder/internal/session"
+)
+
+// document is the part of a JSON Schema the tests check documents against
+type document struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Defs       map[string]document        `json:"$defs"`
+}
+
+func load(t *testing.T, name string) document {
+	data, err := Get(name)
+	require.NoError(t, err)
+	var doc document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	return doc
+}
+
+// assertConforms checks that object has every required property of s and
+// no property s does not declare
+func assertConforms(t *testing.T, s document, object map[string]any) {
+	for _, key := range s.Required {
+		assert.Contains(t, object, key)
+	}
+	for key := range object {
+		assert.Contains(t, s.Properties, key)
+	}
+}
+
+func TestGet_Unknown(t *testing.T) {
+	_, err := Get("config")
+	assert.ErrorContains(t, err, "available: results, plan, summary, fingerprint, manifest, session")
+}
+
+func TestVersions(t *testing.T) {
+	versions := map[string]int{
+		Results:     models.ResultsVersion,
+		Plan:        report.PlanVersion,
+		Summary:     report.SummaryVersion,
+		Fingerprint: fingerprint.Version,
+		Manifest:    catalog.ManifestVersion,
+		Session:     session.Version,
+	}
+	for _, name := range Names {
+		var doc struct {
+			Properties struct {
+				Version struct {
+					Const int `json:"const"`
+				} `json:"version"`
+			} `json:"properties"`
+		}
+		data, err := Get(name)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, versions[name], doc.Properties.Version.Const, name)
+	}
+}
+
+func sampleResults() []models.PairComparison {
+	file := func(path string) models.FileInfo {
+		return models.FileInfo{Path: path, Directory: "/a", Size: 10, ModTime: time.Now(), Hash: "abc"}
+	}
+	return []models.PairComparison{{
+		Dir1: "/a",
+		Dir2: "/b",
+		Matches: []models.FileMatch{{
+			Filename:    "x.jpg",
+			File1:       file("/a/x.jpg"),
+			File2:       file("/b/x.jpg"),
+			HashChecked: true,
+			HashMatch:   true,
+			Verified:    models.CheckHash,
+			Hardlinked:  true,
+			AlsoIn:      []models.MatchContext{{Dir1: "/a", Dir2: "/c", Path1: "/a/x.jpg", Path2: "/c/x.jpg"}},
+		}},
+		Truncated: true,
+	}}
+}
+
+func TestResultsSchema(t *testing.T) {
+	s := load(t, Results)
+	text, err := output.FormatJSON(sampleResults(), output.Options{
+		Labels:     output.Labels{"/a": "a"},
+		ScanErrors: []models.ScanError{{Path: "/a/locked", Op: "readdir", Err: "permission denied"}},
+		Failures:   []models.Failure{{Stage: models.StageScan, Target: "/c", Err: "permission denied"}},
+	})
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(text), &doc))
+	assertConforms(t, s, doc)
+
+	comparison := doc["comparisons"].([]any)[0].(map[string]any)
+	assertConforms(t, s.Defs["comparison"], comparison)
+	match := comparison["matches"].([]any)[0].(map[string]any)
+	assertConforms(t, s.Defs["match"], match)
+	assertConforms(t, s.Defs["file"], match["file1"].(map[string]any))
+}
+
+func TestPlanSchema(t *testing.T) {
+	s := load(t, Plan)
+	results := &models.Results{Comparisons: sampleResults()}
+	// Hardlinked matches are already deduplicated and get no decision
+	results.Comparisons[0].Matches[0].Hardlinked = false
+	text, err := report.FormatPlan(report.Simulate(results, policy.Policy{Keep: policy.KeepNewest}))
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(text), &doc))
+	assertConforms(t, s, doc)
+	decision := doc["decisions"].([]any)[0].(map[string]any)
+	assertConforms(t, s.Defs["decision"], decision)
+	assertConforms(t, s.Defs["file"], decision["keep"].(map[string]any))
+}
+
+func TestSummarySchema(t *testing.T) {
+	s := load(t, Summary)
+	text, err := report.FormatSummaryJSON(report.Summarize(&models.Results{Comparisons: sampleResults()}))
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(text), &doc))
+	assertConforms(t, s, doc)
+}
+
+func TestFingerprintSchema(t *testing.T) {
+	s := load(t, Fingerprint)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0644))
+	fp, err := fingerprint.Compute(context.Background(), dir, nil, 1)
+	require.NoError(t, err)
+	data, err := json.Marshal(fp)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assertConforms(t, s, doc)
+	assertConforms(t, s.Defs["dir"], doc["dirs"].(map[string]any)["."].(map[string]any))
+}
+
+func TestManifestSchema(t *testing.T) {
+	s := load(t, Manifest)
+	files := []models.FileInfo{{Path: "/mnt/nas/a.jpg", Size: 1, Hash: "0123456789abcdef"}}
+	var buf bytes.Buffer
+	require.NoError(t, catalog.WriteManifest(&buf, "/mnt/nas", files))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assertConforms(t, s, doc)
+	assertConforms(t, s.Defs["file"], doc["files"].([]any)[0].(map[string]any))
+}
+
+func TestSessionSchema(t *testing.T) {
+	s := load(t, Session)
+	state := &session.State{
+		Version:     session.Version,
+		GeneratedAt: time.Now().UTC(),
+		RunID:       "20240315T020000Z-9f86d081",
+		Stage:       session.StageReview,
+		Args:        []string{"-i", "/a", "/b"},
+		Directories: []string{"/a", "/b"},
+		Comparisons: sampleResults(),
+		Progress: &models.SessionProgress{
+			Decided: 1,
+			Actions: []models.UserAction{{Action: "delete", KeepFile: "/a/x.jpg", DeleteFile: "/b/x.jpg", Reason: "user choice"}},
+			Notes:   []models.SetNote{{Paths: [2]string{"/a/x.jpg", "/b/x.jpg"}, Text: "scan"}},
+		},
+	}
+	state.Store(models.FileInfo{Path: "/a/x.jpg", Size: 10, ModTime: time.Now(), Hash: "abc"})
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assertConforms(t, s, doc)
+	assertConforms(t, s.Defs["hash"], doc["hashes"].(map[string]any)["/a/x.jpg"].(map[string]any))
+	progress := doc["progress"].(map[string]any)
+	assertConforms(t, s.Defs["progress"], progress)
+	assertConforms(t, s.Defs["action"], progress["actions"].([]any)[0].(map[string]any))
+}
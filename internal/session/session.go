@@ -0,0 +1,152 @@
+// Package session saves the state of an interrupted run, so resume can
+// continue it where it left off instead of starting over
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/models"
+	"github.com/Sho2010/dup-finder/internal/output"
+)
+
+// Version is the current version of the session state document
+const Version = 1
+
+// Stages a run can be interrupted in
+const (
+	StageScan   = "scan"   // Scanning and hashing: resume runs the command again, reusing the hashes
+	StageReview = "review" // Deciding sets interactively: resume reopens the sets not yet decided
+)
+
+// State is what an interrupted run had done
+type State struct {
+	Version     int                     `json:"version"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	RunID       string                  `json:"run_id"`
+	Stage       string                  `json:"stage"`
+	Args        []string                `json:"args"`                  // Command line of the run, without the program name
+	WorkDir     string                  `json:"work_dir"`              // Directory the run started in, which relative paths are under
+	Hashes      map[string]index.Entry  `json:"hashes,omitempty"`      // Hashes computed so far, by absolute path (scan)
+	Directories []string                `json:"directories,omitempty"` // Directories compared (review)
+	Labels      map[string]string       `json:"labels,omitempty"`      // Short display names keyed by root directory (review)
+	Comparisons []models.PairComparison `json:"comparisons,omitempty"` // Results under review (review)
+	Progress    *models.SessionProgress `json:"progress,omitempty"`    // Decisions made so far (review)
+}
+
+// Dir returns where sessions are saved, inside the user cache directory
+func Dir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "dup-finder", "sessions"), nil
+}
+
+// Path returns the state file of the session named name: name itself when
+// it is a path to a file, otherwise the file of that run ID in Dir
+func Path(name string) (string, error) {
+	if filepath.Base(name) != name || strings.HasSuffix(name, ".json") {
+		return name, nil
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// Lookup returns the hash recorded for file if its size and mtime are
+// unchanged, so a State can stand in for a hash index
+func (s *State) Lookup(file models.FileInfo) (string, bool) {
+	key, err := filepath.Abs(file.Path)
+	if err != nil {
+		return "", false
+	}
+	entry, ok := s.Hashes[key]
+	if !ok || entry.Size != file.Size || !entry.ModTime.Equal(file.ModTime) {
+		return "", false
+	}
+	return entry.Hash, true
+}
+
+// Store records the hash of file. Files without a hash are ignored.
+func (s *State) Store(file models.FileInfo) {
+	if file.Hash == "" {
+		return
+	}
+	key, err := filepath.Abs(file.Path)
+	if err != nil {
+		return
+	}
+	if s.Hashes == nil {
+		s.Hashes = make(map[string]index.Entry)
+	}
+	s.Hashes[key] = index.Entry{Size: file.Size, ModTime: file.ModTime, Hash: file.Hash}
+}
+
+// Save writes s to path, replacing the file only once it is complete
+func Save(s *State, path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create session directory: %w", err)
+	}
+	file, err := output.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot write session: %w", err)
+	}
+	return file.Commit()
+}
+
+// Load reads a session saved with Save
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no session to resume (%s not found)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read session: %w", err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("cannot parse session %s: %w", path, err)
+	}
+	if s.Version > Version {
+		return nil, fmt.Errorf("session %s uses version %d, newer than supported version %d", path, s.Version, Version)
+	}
+	switch s.Stage {
+	case StageScan:
+	case StageReview:
+		if s.Progress == nil {
+			return nil, fmt.Errorf("session %s has no review progress", path)
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a session", path)
+	}
+	return &s, nil
+}
+
+// Remove deletes the session at path once it is finished
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove session: %w", err)
+	}
+	return nil
+}
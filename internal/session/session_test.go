@@ -0,0 +1,83 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions", "run.json")
+	state := &State{
+		Version:  Version,
+		RunID:    "run",
+		Stage:    StageReview,
+		Args:     []string{"-i", "/a", "/b"},
+		Progress: &models.SessionProgress{Decided: 2, Actions: []models.UserAction{{Action: "delete", KeepFile: "/a/x", DeleteFile: "/b/x"}}},
+	}
+	require.NoError(t, Save(state, path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, state.Args, loaded.Args)
+	assert.Equal(t, state.Progress, loaded.Progress)
+
+	require.NoError(t, Remove(path))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "no session to resume")
+	assert.NoError(t, Remove(path))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for content, msg := range map[string]string{
+		`{"version": 1}`:                      "not a session",
+		`{"version": 1, "stage": "review"}`:   "no review progress",
+		`{"version": 99, "stage": "scan"}`:    "newer than supported",
+		`{"version": 1, "stage": "scan"`:      "cannot parse session",
+		`{"version": 1, "stage": "unknown"} `: "not a session",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := Load(path)
+		assert.ErrorContains(t, err, msg, content)
+	}
+}
+
+func TestLookupStore(t *testing.T) {
+	mtime := time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	hashed := models.FileInfo{Path: "/a/x.jpg", Size: 10, ModTime: mtime, Hash: "abc"}
+	var state State
+	state.Store(hashed)
+	state.Store(models.FileInfo{Path: "/a/y.jpg", Size: 10, ModTime: mtime})
+
+	// Only unchanged files get their hash back
+	files := []models.FileInfo{
+		{Path: "/a/x.jpg", Size: 10, ModTime: mtime},
+		{Path: "/a/y.jpg", Size: 10, ModTime: mtime},
+		{Path: "/a/x.jpg", Size: 11, ModTime: mtime},
+	}
+	assert.Equal(t, 1, finder.FillFromCache(files, &state))
+	assert.Equal(t, "abc", files[0].Hash)
+	assert.Len(t, state.Hashes, 1)
+}
+
+func TestPath(t *testing.T) {
+	dir, err := Dir()
+	require.NoError(t, err)
+
+	path, err := Path("20240315T020000Z-9f86d081")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20240315T020000Z-9f86d081.json"), path)
+
+	file := filepath.Join(t.TempDir(), "state")
+	path, err = Path(file)
+	require.NoError(t, err)
+	assert.Equal(t, file, path)
+}
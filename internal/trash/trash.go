@@ -0,0 +1,19 @@
+// Package trash moves files to the trash of the user instead of removing
+// them, so a deletion can be undone from the desktop: the XDG trash on
+// Linux and the BSDs, the Trash on macOS and the Recycle Bin on Windows
+package trash
+
+import "path/filepath"
+
+// Name is how the trash is referred to in messages
+const Name = "the trash"
+
+// Move moves the file at path to the trash. A file that cannot be moved
+// there is left in place; it is never removed instead.
+func Move(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return move(abs)
+}
@@ -0,0 +1,54 @@
+//go:build darwin
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// move moves the file into ~/.Trash, or into .Trashes/$uid at the top of
+// its volume when it is on another one, as the Finder does. The Finder
+// keeps where files came from to itself, so Put Back is not offered for
+// them; drag them out of the Trash instead.
+func move(abs string) error {
+	dev, err := device(abs)
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(home, ".Trash")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if d, err := device(dir); err != nil || d != dev {
+		dir = filepath.Join(mountPoint(abs, dev), ".Trashes", strconv.Itoa(os.Getuid()))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("no trash on the volume of %s: %w", abs, err)
+		}
+	}
+
+	// Name copies as the Finder does: "photo 2.jpg"
+	base := filepath.Base(abs)
+	ext := filepath.Ext(base)
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s %d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		target := filepath.Join(dir, name)
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return os.Rename(abs, target)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package trash
+
+import "errors"
+
+func move(abs string) error {
+	return errors.New("moving files to the trash is not supported on this platform")
+}
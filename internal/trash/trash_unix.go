@@ -0,0 +1,38 @@
+//go:build unix
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// device returns the ID of the device holding path
+func device(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, &os.PathError{Op: "stat", Path: path, Err: syscall.ENOTSUP}
+	}
+	return uint64(stat.Dev), nil
+}
+
+// mountPoint returns the topmost ancestor of path on device dev, which is
+// where that file system is mounted
+func mountPoint(path string, dev uint64) string {
+	dir := path
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		if d, err := device(parent); err != nil || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
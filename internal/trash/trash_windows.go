@@ -0,0 +1,56 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procSHFileOperation = syscall.NewLazyDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// shFileOpStruct is SHFILEOPSTRUCTW
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// SHFileOperation operation and flags
+const (
+	foDelete           = 0x3
+	fofSilent          = 0x4
+	fofNoConfirmation  = 0x10
+	fofAllowUndo       = 0x40
+	fofNoErrorUI       = 0x400
+	fofWantNukeWarning = 0x4000
+)
+
+// move deletes the file through the shell with undo allowed, which moves
+// it to the Recycle Bin. A file the Recycle Bin cannot take, such as one
+// on a network share, would be removed for good, so the shell asks first.
+func move(abs string) error {
+	// A list of paths, ended by an empty one
+	from, err := syscall.UTF16FromString(abs)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofNoErrorUI | fofSilent | fofWantNukeWarning,
+	}
+	if r, _, _ := procSHFileOperation.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return fmt.Errorf("SHFileOperation failed with code %#x", r)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return errors.New("moving to the Recycle Bin was cancelled")
+	}
+	return nil
+}
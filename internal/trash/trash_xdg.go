@@ -0,0 +1,129 @@
+//go:build unix && !darwin
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// move follows the FreeDesktop.org trash specification, which desktops on
+// Linux and the BSDs restore files from: a file on the volume of the home
+// trash goes there, and others to the trash at the top of their own
+// volume, so that nothing is copied across volumes
+func move(abs string) error {
+	dev, err := device(abs)
+	if err != nil {
+		return err
+	}
+	dir, err := trashDir(abs, dev)
+	if err != nil {
+		return err
+	}
+	return moveInto(dir, abs, time.Now())
+}
+
+// homeTrash returns the trash of the user, $XDG_DATA_HOME/Trash
+func homeTrash() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "Trash"), nil
+}
+
+// trashDir returns the trash for the file at abs, on device dev: the home
+// trash if it is on that device, or else $topdir/.Trash/$uid when an
+// administrator set up $topdir/.Trash, or $topdir/.Trash-$uid
+func trashDir(abs string, dev uint64) (string, error) {
+	if home, err := homeTrash(); err == nil && os.MkdirAll(home, 0700) == nil {
+		if d, err := device(home); err == nil && d == dev {
+			return home, nil
+		}
+	}
+
+	top := mountPoint(abs, dev)
+	uid := strconv.Itoa(os.Getuid())
+	// The shared trash must be a sticky directory, not a link to one
+	if info, err := os.Lstat(filepath.Join(top, ".Trash")); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(top, ".Trash", uid)
+		if err := os.MkdirAll(dir, 0700); err == nil {
+			return dir, nil
+		}
+	}
+	dir := filepath.Join(top, ".Trash-"+uid)
+	if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("no trash on the volume of %s: %w", abs, err)
+	}
+	if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("no trash on the volume of %s: %s is not a directory", abs, dir)
+	}
+	return dir, nil
+}
+
+// moveInto moves the file at abs into the trash dir, with an info file
+// recording where it came from and when it was deleted. The info file is
+// created first, exclusively, to claim the name in the trash.
+func moveInto(dir, abs string, now time.Time) error {
+	files, info := filepath.Join(dir, "files"), filepath.Join(dir, "info")
+	for _, d := range []string{files, info} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			return err
+		}
+	}
+
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", escapePath(abs), now.Format("2006-01-02T15:04:05"))
+	base := filepath.Base(abs)
+	ext := filepath.Ext(base)
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		infoPath := filepath.Join(info, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// A file left in the trash without its info file still holds the name
+		if _, statErr := os.Lstat(filepath.Join(files, name)); err == nil && statErr == nil {
+			os.Remove(infoPath)
+			continue
+		}
+		if err == nil {
+			err = os.Rename(abs, filepath.Join(files, name))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+		return nil
+	}
+}
+
+// escapePath escapes path for the Path key of an info file, which holds
+// it as in a URL
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
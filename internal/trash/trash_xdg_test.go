@@ -0,0 +1,51 @@
+//go:build unix && !darwin
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMove_HomeTrash(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	trash := filepath.Join(root, "data", "Trash")
+
+	var paths []string
+	for _, dir := range []string{"a", "b"} {
+		path := filepath.Join(root, dir, "my photo.jpg")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(dir), 0644))
+		paths = append(paths, path)
+	}
+
+	for _, path := range paths {
+		require.NoError(t, Move(path))
+		assert.NoFileExists(t, path)
+	}
+
+	// The second file of the same name gets a name of its own
+	for i, name := range []string{"my photo.jpg", "my photo.2.jpg"} {
+		data, err := os.ReadFile(filepath.Join(trash, "files", name))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}[i], string(data))
+
+		info, err := os.ReadFile(filepath.Join(trash, "info", name+".trashinfo"))
+		require.NoError(t, err)
+		lines := strings.Split(string(info), "\n")
+		assert.Equal(t, "[Trash Info]", lines[0])
+		assert.Equal(t, "Path="+strings.ReplaceAll(paths[i], " ", "%20"), lines[1])
+		assert.Regexp(t, `^DeletionDate=\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d$`, lines[2])
+	}
+}
+
+func TestMove_Missing(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	assert.Error(t, Move(filepath.Join(t.TempDir(), "missing")))
+}
@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Events follows the directories under some roots for file system events,
+// so the caller scans again only once something changed. fsnotify watches
+// single directories: every directory below the roots is added at the
+// start, and directories created or moved in later as they appear.
+type Events struct {
+	watcher *fsnotify.Watcher
+	skip    func(path string) bool
+	changes chan struct{}
+	errs    chan error
+}
+
+// NewEvents starts following roots, leaving out directories whose path
+// skip accepts. It fails where events are unavailable, such as past the
+// system's limit on watches, and the caller then polls instead.
+func NewEvents(roots []string, skip func(path string) bool) (*Events, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	e := &Events{
+		watcher: watcher,
+		skip:    skip,
+		changes: make(chan struct{}, 1),
+		errs:    make(chan error, 1),
+	}
+	for _, root := range roots {
+		if err := e.addTree(root); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	go e.run()
+	return e, nil
+}
+
+// Changes receives a value once anything under the roots changed. Changes
+// made before it is read are reported once.
+func (e *Events) Changes() <-chan struct{} {
+	return e.changes
+}
+
+// Errors receives the errors of following, such as events lost to an
+// overflow; a change is reported with each, as something may be missed
+func (e *Events) Errors() <-chan error {
+	return e.errs
+}
+
+// Close stops following the roots
+func (e *Events) Close() error {
+	return e.watcher.Close()
+}
+
+func (e *Events) run() {
+	for {
+		select {
+		case event, ok := <-e.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) && !e.skip(event.Name) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					if err := e.addTree(event.Name); err != nil {
+						e.report(err)
+					}
+				}
+			}
+			e.changed()
+		case err, ok := <-e.watcher.Errors:
+			if !ok {
+				return
+			}
+			e.report(err)
+		}
+	}
+}
+
+// addTree follows dir and the directories below it. Directories that
+// cannot be read are left to the scans to report.
+func (e *Events) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && e.skip(path) {
+			return filepath.SkipDir
+		}
+		if err := e.watcher.Add(path); err != nil {
+			return fmt.Errorf("cannot follow %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// changed reports a change unless one is already waiting to be read
+func (e *Events) changed() {
+	select {
+	case e.changes <- struct{}{}:
+	default:
+	}
+}
+
+// report passes err on, dropping it if one is already waiting
+func (e *Events) report(err error) {
+	select {
+	case e.errs <- err:
+	default:
+	}
+	e.changed()
+}
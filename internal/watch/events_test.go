@@ -0,0 +1,59 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// waitChange fails the test unless events reports a change in time
+func waitChange(t *testing.T, events *Events) {
+	t.Helper()
+	select {
+	case <-events.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+// drain discards changes reported so far
+func drain(events *Events) {
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-events.Changes():
+	default:
+	}
+}
+
+func TestEvents(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".hidden"), 0755))
+	skip := func(path string) bool { return strings.HasPrefix(filepath.Base(path), ".") }
+	events, err := NewEvents([]string{dir}, skip)
+	require.NoError(t, err)
+	defer events.Close()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("photo"), 0644))
+	waitChange(t, events)
+
+	// Directories created later are followed too
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	waitChange(t, events)
+	drain(events)
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "b.jpg"), []byte("photo"), 0644))
+	waitChange(t, events)
+
+	// Skipped directories are not
+	drain(events)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden", "c.jpg"), []byte("photo"), 0644))
+	select {
+	case <-events.Changes():
+		t.Fatal("change reported in a skipped directory")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
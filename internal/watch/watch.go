@@ -0,0 +1,175 @@
+// Package watch follows the files under some directories from one scan to
+// the next, and reports each file added or changed in between that
+// duplicates a file already there. Events tells the caller when a scan is
+// due.
+package watch
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Sho2010/dup-finder/internal/finder"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// Duplicate is a new file with the content of one already present
+type Duplicate struct {
+	File     models.FileInfo
+	Original models.FileInfo
+}
+
+// Tree is what the watched directories held at the last scan. Hashes are
+// computed only for files that share a size with a new one, and kept for
+// later scans.
+type Tree struct {
+	cache   finder.HashCache
+	files   map[string]models.FileInfo    // Settled files by path
+	bySize  map[int64]map[string]struct{} // Paths of settled files by size
+	pending map[string]models.FileInfo    // New or changed files by path, still being written
+}
+
+// New returns an empty tree that reads and records hashes in cache, which
+// may be nil
+func New(cache finder.HashCache) *Tree {
+	return &Tree{
+		cache:   cache,
+		files:   make(map[string]models.FileInfo),
+		bySize:  make(map[int64]map[string]struct{}),
+		pending: make(map[string]models.FileInfo),
+	}
+}
+
+// Len returns the number of files the tree holds
+func (t *Tree) Len() int {
+	return len(t.files)
+}
+
+// Pending returns the number of new or changed files waiting for another
+// scan to show they stopped changing
+func (t *Tree) Pending() int {
+	return len(t.pending)
+}
+
+// Seed records files as present from the start: duplicates among them are
+// not reported
+func (t *Tree) Seed(files []models.FileInfo) {
+	for _, file := range files {
+		t.add(file)
+	}
+}
+
+// Update takes the files of a new scan and returns the new or changed ones
+// that duplicate another file, oldest original first. A file is considered
+// once it keeps the same size and mtime over two scans, so files still
+// being downloaded or copied are not hashed half-written. Files that could
+// not be hashed are returned as errors and considered again if they change.
+func (t *Tree) Update(files []models.FileInfo) ([]Duplicate, []error) {
+	seen := make(map[string]bool, len(files))
+	var settled []models.FileInfo
+	for _, file := range files {
+		seen[file.Path] = true
+		if known, ok := t.files[file.Path]; ok && same(known, file) {
+			continue
+		}
+		if waiting, ok := t.pending[file.Path]; ok && same(waiting, file) {
+			delete(t.pending, file.Path)
+			settled = append(settled, file)
+			continue
+		}
+		t.pending[file.Path] = file
+	}
+	for path := range t.files {
+		if !seen[path] {
+			t.remove(path)
+		}
+	}
+	for path := range t.pending {
+		if !seen[path] {
+			delete(t.pending, path)
+		}
+	}
+
+	sort.Slice(settled, func(i, j int) bool {
+		return settled[i].Path < settled[j].Path
+	})
+	var duplicates []Duplicate
+	var errs []error
+	for _, file := range settled {
+		t.remove(file.Path)
+		original, err := t.match(&file)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if original != nil {
+			duplicates = append(duplicates, Duplicate{File: file, Original: *original})
+		}
+		t.add(file)
+	}
+	return duplicates, errs
+}
+
+// Forget drops path from the tree, for a file removed or moved away
+func (t *Tree) Forget(path string) {
+	t.remove(path)
+	delete(t.pending, path)
+}
+
+// match returns the oldest settled file with the content of file, hashing
+// file and the candidates of its size as needed, or nil if there is none
+func (t *Tree) match(file *models.FileInfo) (*models.FileInfo, error) {
+	var candidates []models.FileInfo
+	for path := range t.bySize[file.Size] {
+		candidates = append(candidates, t.files[path])
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].ModTime.Equal(candidates[j].ModTime) {
+			return candidates[i].ModTime.Before(candidates[j].ModTime)
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	if err := finder.HashWithCache(file, t.cache); err != nil {
+		return nil, fmt.Errorf("error hashing %s: %w", file.Path, err)
+	}
+	for _, candidate := range candidates {
+		if err := finder.HashWithCache(&candidate, t.cache); err != nil {
+			// Gone since the last scan, most likely: the next one drops it
+			continue
+		}
+		t.files[candidate.Path] = candidate
+		if candidate.Hash == file.Hash {
+			return &candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (t *Tree) add(file models.FileInfo) {
+	t.files[file.Path] = file
+	paths := t.bySize[file.Size]
+	if paths == nil {
+		paths = make(map[string]struct{})
+		t.bySize[file.Size] = paths
+	}
+	paths[file.Path] = struct{}{}
+}
+
+func (t *Tree) remove(path string) {
+	file, ok := t.files[path]
+	if !ok {
+		return
+	}
+	delete(t.files, path)
+	delete(t.bySize[file.Size], path)
+	if len(t.bySize[file.Size]) == 0 {
+		delete(t.bySize, file.Size)
+	}
+}
+
+// same reports whether a and b have the same size and mtime
+func same(a, b models.FileInfo) bool {
+	return a.Size == b.Size && a.ModTime.Equal(b.ModTime)
+}
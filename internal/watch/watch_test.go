@@ -0,0 +1,120 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sho2010/dup-finder/internal/index"
+	"github.com/Sho2010/dup-finder/internal/models"
+)
+
+// scan lists the files of dir as the scanner would
+func scan(t *testing.T, dir string) []models.FileInfo {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var files []models.FileInfo
+	for _, entry := range entries {
+		info, err := entry.Info()
+		require.NoError(t, err)
+		files = append(files, models.FileInfo{
+			Path:      filepath.Join(dir, entry.Name()),
+			Directory: dir,
+			Size:      info.Size(),
+			ModTime:   info.ModTime(),
+		})
+	}
+	return files
+}
+
+func writeFile(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "a.jpg"), "photo", old)
+	writeFile(t, filepath.Join(dir, "b.jpg"), "photo", old.Add(time.Hour))
+
+	tree := New(nil)
+	tree.Seed(scan(t, dir))
+	assert.Equal(t, 2, tree.Len())
+
+	// Files present from the start are not reported
+	duplicates, errs := tree.Update(scan(t, dir))
+	assert.Empty(t, duplicates)
+	assert.Empty(t, errs)
+
+	// New files are considered once they stop changing
+	writeFile(t, filepath.Join(dir, "c.jpg"), "phot", old.Add(2*time.Hour))
+	writeFile(t, filepath.Join(dir, "other.jpg"), "other", old.Add(2*time.Hour))
+	duplicates, _ = tree.Update(scan(t, dir))
+	assert.Empty(t, duplicates)
+
+	writeFile(t, filepath.Join(dir, "c.jpg"), "photo", old.Add(3*time.Hour))
+	duplicates, _ = tree.Update(scan(t, dir))
+	assert.Empty(t, duplicates)
+
+	duplicates, errs = tree.Update(scan(t, dir))
+	assert.Empty(t, errs)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, filepath.Join(dir, "c.jpg"), duplicates[0].File.Path)
+	assert.Equal(t, filepath.Join(dir, "a.jpg"), duplicates[0].Original.Path)
+	assert.Equal(t, 4, tree.Len())
+
+	// Reported once only
+	duplicates, _ = tree.Update(scan(t, dir))
+	assert.Empty(t, duplicates)
+
+	// Removed files no longer match
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.jpg")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.jpg")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "c.jpg")))
+	writeFile(t, filepath.Join(dir, "d.jpg"), "photo", old)
+	tree.Update(scan(t, dir))
+	duplicates, _ = tree.Update(scan(t, dir))
+	assert.Empty(t, duplicates)
+	assert.Equal(t, 2, tree.Len())
+}
+
+func TestUpdate_NewCopiesOfEachOther(t *testing.T) {
+	dir := t.TempDir()
+	tree := New(nil)
+	tree.Seed(scan(t, dir))
+
+	mtime := time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "x.zip"), "archive", mtime)
+	writeFile(t, filepath.Join(dir, "x (1).zip"), "archive", mtime)
+	tree.Update(scan(t, dir))
+	duplicates, _ := tree.Update(scan(t, dir))
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, filepath.Join(dir, "x.zip"), duplicates[0].File.Path)
+	assert.Equal(t, filepath.Join(dir, "x (1).zip"), duplicates[0].Original.Path)
+}
+
+func TestUpdate_Cache(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "a.txt"), "same", mtime)
+
+	idx := index.New(filepath.Join(t.TempDir(), "index.json"))
+	tree := New(idx)
+	tree.Seed(scan(t, dir))
+	writeFile(t, filepath.Join(dir, "b.txt"), "same", mtime)
+	tree.Update(scan(t, dir))
+	duplicates, _ := tree.Update(scan(t, dir))
+	require.Len(t, duplicates, 1)
+
+	// Both hashes are kept for later runs
+	assert.Equal(t, 2, idx.Len())
+	tree.Forget(filepath.Join(dir, "b.txt"))
+	assert.Equal(t, 1, tree.Len())
+}